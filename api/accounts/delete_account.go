@@ -6,6 +6,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
 	"github.com/keratin/authn-server/services"
 )
 
@@ -17,16 +18,22 @@ func deleteAccount(app *api.App) http.HandlerFunc {
 			return
 		}
 
-		err = services.AccountArchiver(app.AccountStore, app.RefreshTokenStore, id)
+		err = services.AccountArchiver(app.Accounts(r), app.RefreshTokens(r), id)
 		if err != nil {
-			if _, ok := err.(services.FieldErrors); ok {
-				api.WriteNotFound(w, "account")
+			if fe, ok := err.(services.FieldErrors); ok {
+				if fe[0].Message == services.ErrNotFound {
+					api.WriteNotFound(w, "account")
+				} else {
+					api.WriteErrors(w, fe)
+				}
 				return
 			}
 
 			panic(err)
 		}
 
+		app.Events.Emit(events.AccountArchived, id)
+
 		w.WriteHeader(http.StatusOK)
 	}
 }
@@ -0,0 +1,35 @@
+package accounts
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+)
+
+func deleteAccountLegalHold(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+
+		err = services.AccountLegalHoldSetter(app.Accounts(r), id, false)
+		if err != nil {
+			if _, ok := err.(services.FieldErrors); ok {
+				api.WriteNotFound(w, "account")
+				return
+			}
+
+			panic(err)
+		}
+
+		app.Events.Emit(events.AccountReleased, id)
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,42 @@
+package accounts
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+)
+
+func deleteAccountNote(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+		noteID, err := strconv.Atoi(mux.Vars(r)["note_id"])
+		if err != nil {
+			api.WriteNotFound(w, "note")
+			return
+		}
+
+		account, err := services.AccountGetter(app.Accounts(r), id)
+		if err != nil {
+			if _, ok := err.(services.FieldErrors); ok {
+				api.WriteNotFound(w, "account")
+				return
+			}
+
+			panic(err)
+		}
+
+		err = app.AnnotationStore.DeleteNote(account.ID, noteID)
+		if err != nil {
+			panic(err)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,42 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteAccountNote(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.Delete("/accounts/999999/notes/1")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("noted account", func(t *testing.T) {
+		account, err := app.AccountStore.Create("noted@test.com", []byte("bar"))
+		require.NoError(t, err)
+		note, err := app.AnnotationStore.AddNote(account.ID, "called support")
+		require.NoError(t, err)
+
+		res, err := client.Delete(fmt.Sprintf("/accounts/%v/notes/%v", account.ID, note.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		notes, err := app.AnnotationStore.GetNotes(account.ID)
+		require.NoError(t, err)
+		assert.Empty(t, notes)
+	})
+}
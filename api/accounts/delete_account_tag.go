@@ -0,0 +1,37 @@
+package accounts
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+)
+
+func deleteAccountTag(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+
+		account, err := services.AccountGetter(app.Accounts(r), id)
+		if err != nil {
+			if _, ok := err.(services.FieldErrors); ok {
+				api.WriteNotFound(w, "account")
+				return
+			}
+
+			panic(err)
+		}
+
+		err = app.AnnotationStore.Untag(account.ID, mux.Vars(r)["tag"])
+		if err != nil {
+			panic(err)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
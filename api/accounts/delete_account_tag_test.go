@@ -0,0 +1,41 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteAccountTag(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.Delete("/accounts/999999/tags/vip")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("tagged account", func(t *testing.T) {
+		account, err := app.AccountStore.Create("tagged@test.com", []byte("bar"))
+		require.NoError(t, err)
+		app.AnnotationStore.Tag(account.ID, "vip")
+
+		res, err := client.Delete(fmt.Sprintf("/accounts/%v/tags/vip", account.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		tags, err := app.AnnotationStore.GetTags(account.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+	})
+}
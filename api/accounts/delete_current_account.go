@@ -0,0 +1,37 @@
+package accounts
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+)
+
+func deleteCurrentAccount(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID := api.GetSessionAccountID(r)
+		if accountID == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		archiveAt, err := services.AccountDeletionScheduler(app.Accounts(r), app.RefreshTokens(r), app.Config, accountID)
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		app.Events.Emit(events.AccountDeletionRequested, accountID)
+
+		api.SetSession(app.Config, w, nil, "")
+
+		api.WriteData(w, http.StatusOK, map[string]interface{}{
+			"archive_at": archiveAt,
+		})
+	}
+}
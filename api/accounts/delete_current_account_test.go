@@ -0,0 +1,53 @@
+package accounts_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteCurrentAccount(t *testing.T) {
+	app := test.App()
+	app.Config.EnableAccountDeletion = true
+	app.Config.AccountDeletionGrace = time.Hour
+	server := test.Server(app, accounts.PublicRoutes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("with a session", func(t *testing.T) {
+		account, err := app.AccountStore.Create("leaving@test.com", []byte("bar"))
+		require.NoError(t, err)
+		session := test.CreateSession(app.RefreshTokenStore, app.Config, account.ID)
+
+		res, err := client.WithCookie(session).Delete("/account")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		found, err := app.AccountStore.Find(account.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found.ArchiveAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *found.ArchiveAt, time.Second)
+		assert.False(t, found.Archived())
+
+		tokens, err := app.RefreshTokenStore.FindAll(account.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+
+		cookie := test.ReadCookie(res.Cookies(), app.Config.SessionCookieName)
+		require.NotNil(t, cookie)
+		assert.Empty(t, cookie.Value)
+	})
+
+	t.Run("without a session", func(t *testing.T) {
+		res, err := client.Delete("/account")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+}
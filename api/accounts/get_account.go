@@ -3,9 +3,11 @@ package accounts
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/hashes"
 	"github.com/keratin/authn-server/services"
 )
 
@@ -17,7 +19,7 @@ func getAccount(app *api.App) http.HandlerFunc {
 			return
 		}
 
-		account, err := services.AccountGetter(app.AccountStore, id)
+		account, err := services.AccountGetter(app.Accounts(r), id)
 		if err != nil {
 			if _, ok := err.(services.FieldErrors); ok {
 				api.WriteNotFound(w, "account")
@@ -27,11 +29,22 @@ func getAccount(app *api.App) http.HandlerFunc {
 			panic(err)
 		}
 
+		var throttledUntil *time.Time
+		if account.Throttled() {
+			throttledUntil = account.ThrottledUntil
+		}
+
+		w.Header().Set("ETag", services.AccountETag(account))
 		api.WriteData(w, http.StatusOK, map[string]interface{}{
-			"id":       account.ID,
-			"username": account.Username,
-			"locked":   account.Locked,
-			"deleted":  account.DeletedAt != nil,
+			"id":              account.ID,
+			"username":        account.Username,
+			"external_id":     account.ExternalID,
+			"locked":          account.Locked,
+			"throttled_until": throttledUntil,
+			"deleted":         account.DeletedAt != nil,
+			"expires_at":      account.ExpiresAt,
+			"legal_hold":      account.LegalHold,
+			"password_scheme": hashes.Scheme(account.Password),
 		})
 	}
 }
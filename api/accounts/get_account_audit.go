@@ -0,0 +1,57 @@
+package accounts
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+)
+
+// accountAuditLimit is how many events are listed per page.
+const accountAuditLimit = 50
+
+// getAccountAudit lists an account's events from the audit log, newest first. The next cursor
+// continues with older events, and is empty on the last page.
+func getAccountAudit(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+
+		account, err := services.AccountGetter(app.Accounts(r), id)
+		if err != nil {
+			if _, ok := err.(services.FieldErrors); ok {
+				api.WriteNotFound(w, "account")
+				return
+			}
+
+			panic(err)
+		}
+
+		// one extra entry tells whether another page exists
+		entries, err := app.AuditLog.Find(account.ID, r.FormValue("after"), accountAuditLimit+1)
+		if err != nil {
+			panic(err)
+		}
+
+		next := ""
+		if len(entries) > accountAuditLimit {
+			entries = entries[:accountAuditLimit]
+			next = entries[accountAuditLimit-1].Cursor
+		}
+		list := make([]events.Event, len(entries))
+		for i, entry := range entries {
+			list[i] = entry.Event
+		}
+
+		api.WriteData(w, http.StatusOK, map[string]interface{}{
+			"events": list,
+			"next":   next,
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountAudit(t *testing.T) {
+	app := test.App()
+	app.Config.AuditLog = true
+	app.AuditLog = mock.NewAuditLog(app.Config.AuditSigningKey)
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.Get("/accounts/999999/audit")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("account with events", func(t *testing.T) {
+		account, err := app.AccountStore.Create("audit@test.com", []byte("bar"))
+		require.NoError(t, err)
+		require.NoError(t, app.AuditLog.Append(events.Event{Type: events.AccountCreated, AccountID: account.ID, Time: time.Now()}))
+		for i := 0; i < 50; i++ {
+			require.NoError(t, app.AuditLog.Append(events.Event{Type: events.SessionCreated, AccountID: account.ID, Time: time.Now()}))
+		}
+		require.NoError(t, app.AuditLog.Append(events.Event{Type: events.SessionCreated, AccountID: account.ID + 1, Time: time.Now()}))
+
+		var page struct {
+			Events []events.Event `json:"events"`
+			Next   string         `json:"next"`
+		}
+		res, err := client.Get(fmt.Sprintf("/accounts/%v/audit", account.ID))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.NoError(t, test.ExtractResult(res, &page))
+		assert.Len(t, page.Events, 50)
+		assert.Equal(t, events.SessionCreated, page.Events[0].Type)
+		require.NotEmpty(t, page.Next)
+
+		res, err = client.Get(fmt.Sprintf("/accounts/%v/audit?after=%s", account.ID, page.Next))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.NoError(t, test.ExtractResult(res, &page))
+		require.Len(t, page.Events, 1)
+		assert.Equal(t, events.AccountCreated, page.Events[0].Type)
+		assert.Empty(t, page.Next)
+	})
+}
@@ -0,0 +1,50 @@
+package accounts
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+)
+
+func getAccountConsents(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+
+		account, err := services.AccountGetter(app.Accounts(r), id)
+		if err != nil {
+			if _, ok := err.(services.FieldErrors); ok {
+				api.WriteNotFound(w, "account")
+				return
+			}
+
+			panic(err)
+		}
+
+		consents, err := app.ConsentStore.FindAll(account.ID)
+		if err != nil {
+			panic(err)
+		}
+
+		acceptedTermsVersion := ""
+		marketingOptIn := false
+		if len(consents) > 0 {
+			latest := consents[len(consents)-1]
+			acceptedTermsVersion = latest.TermsVersion
+			marketingOptIn = latest.MarketingOptIn
+		}
+
+		api.WriteData(w, http.StatusOK, map[string]interface{}{
+			"terms_version":          app.Config.TermsVersion,
+			"accepted_terms_version": acceptedTermsVersion,
+			"marketing_opt_in":       marketingOptIn,
+			"history":                consents,
+		})
+	}
+}
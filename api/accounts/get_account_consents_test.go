@@ -0,0 +1,43 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountConsents(t *testing.T) {
+	app := test.App()
+	app.Config.TermsVersion = "2"
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.Get("/accounts/999999/consents")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("account with consents", func(t *testing.T) {
+		account, err := app.AccountStore.Create("consenting@test.com", []byte("bar"))
+		require.NoError(t, err)
+		_, err = app.ConsentStore.Create(account.ID, "1", true, "127.0.0.1")
+		require.NoError(t, err)
+
+		res, err := client.Get(fmt.Sprintf("/accounts/%v/consents", account.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		body := test.ReadBody(res)
+		assert.Contains(t, string(body), `"terms_version":"2"`)
+		assert.Contains(t, string(body), `"accepted_terms_version":"1"`)
+		assert.Contains(t, string(body), `"marketing_opt_in":true`)
+	})
+}
@@ -0,0 +1,39 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountMetadata(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.Get("/accounts/999999/metadata")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("account with metadata", func(t *testing.T) {
+		account, err := app.AccountStore.Create("metadata@test.com", []byte("bar"))
+		require.NoError(t, err)
+		err = app.AnnotationStore.SetMetadata(account.ID, map[string]string{"locale": "en-US"})
+		require.NoError(t, err)
+
+		res, err := client.Get(fmt.Sprintf("/accounts/%v/metadata", account.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, map[string]interface{}{"locale": "en-US"})
+	})
+}
@@ -0,0 +1,46 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountNotes(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.Get("/accounts/999999/notes")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("noted account", func(t *testing.T) {
+		account, err := app.AccountStore.Create("noted@test.com", []byte("bar"))
+		require.NoError(t, err)
+		note, err := app.AnnotationStore.AddNote(account.ID, "called support")
+		require.NoError(t, err)
+
+		res, err := client.Get(fmt.Sprintf("/accounts/%v/notes", account.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		notes := []map[string]interface{}{}
+		err = test.ExtractResult(res, &notes)
+		require.NoError(t, err)
+		require.Len(t, notes, 1)
+		assert.Equal(t, float64(note.ID), notes[0]["id"])
+		assert.Equal(t, "called support", notes[0]["body"])
+		assert.NotEmpty(t, notes[0]["created_at"])
+	})
+}
@@ -0,0 +1,50 @@
+package accounts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+)
+
+func getAccountSessions(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+
+		account, err := services.AccountGetter(app.Accounts(r), id)
+		if err != nil {
+			if _, ok := err.(services.FieldErrors); ok {
+				api.WriteNotFound(w, "account")
+				return
+			}
+
+			panic(err)
+		}
+
+		tokens, err := app.RefreshTokens(r).FindAll(account.ID)
+		if err != nil {
+			panic(err)
+		}
+
+		// refresh tokens are bearer credentials, so sessions are only identified by a digest
+		sessions := make([]string, len(tokens))
+		for i, t := range tokens {
+			sum := sha256.Sum256([]byte(t))
+			sessions[i] = hex.EncodeToString(sum[:])
+		}
+
+		api.WriteData(w, http.StatusOK, map[string]interface{}{
+			"count":    len(tokens),
+			"limit":    app.Config.MaxSessionsPerAccount,
+			"sessions": sessions,
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountSessions(t *testing.T) {
+	app := test.App()
+	app.Config.MaxSessionsPerAccount = 5
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.Get("/accounts/999999/sessions")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("account with sessions", func(t *testing.T) {
+		account, err := app.AccountStore.Create("sessions@test.com", []byte("bar"))
+		require.NoError(t, err)
+		test.CreateSession(app.RefreshTokenStore, app.Config, account.ID)
+		test.CreateSession(app.RefreshTokenStore, app.Config, account.ID)
+
+		res, err := client.Get(fmt.Sprintf("/accounts/%v/sessions", account.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		var result struct {
+			Count    int      `json:"count"`
+			Limit    int      `json:"limit"`
+			Sessions []string `json:"sessions"`
+		}
+		require.NoError(t, test.ExtractResult(res, &result))
+		assert.Equal(t, 2, result.Count)
+		assert.Equal(t, 5, result.Limit)
+		require.Len(t, result.Sessions, 2)
+		assert.Len(t, result.Sessions[0], 64)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/keratin/authn-server/api/accounts"
 	"github.com/keratin/authn-server/api/test"
@@ -41,6 +42,59 @@ func TestGetAccount(t *testing.T) {
 		assert.Equal(t, http.StatusOK, res.StatusCode)
 		assertGetAccountResponse(t, res, account)
 	})
+
+	t.Run("imported account", func(t *testing.T) {
+		account, err := app.AccountStore.Create("imported@test.com", []byte("$sha1$salt$0123456789abcdef0123456789abcdef01234567"))
+		require.NoError(t, err)
+
+		res, err := client.Get(fmt.Sprintf("/accounts/%v", account.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseData := struct {
+			PasswordScheme string `json:"password_scheme"`
+		}{}
+		require.NoError(t, test.ExtractResult(res, &responseData))
+		assert.Equal(t, "sha1", responseData.PasswordScheme)
+	})
+
+	t.Run("account with external id", func(t *testing.T) {
+		account, err := app.AccountStore.Create("external@test.com", []byte("bar"))
+		require.NoError(t, err)
+		require.NoError(t, app.AccountStore.SetExternalID(account.ID, "cus_456"))
+
+		res, err := client.Get(fmt.Sprintf("/accounts/%v", account.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseData := struct {
+			ExternalID *string `json:"external_id"`
+		}{}
+		require.NoError(t, test.ExtractResult(res, &responseData))
+		require.NotNil(t, responseData.ExternalID)
+		assert.Equal(t, "cus_456", *responseData.ExternalID)
+	})
+
+	t.Run("throttled account", func(t *testing.T) {
+		account, err := app.AccountStore.Create("throttled@test.com", []byte("bar"))
+		require.NoError(t, err)
+		until := time.Now().Add(time.Hour).Truncate(time.Second)
+		app.AccountStore.SetThrottledUntil(account.ID, &until)
+
+		res, err := client.Get(fmt.Sprintf("/accounts/%v", account.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseData := struct {
+			Locked         bool       `json:"locked"`
+			ThrottledUntil *time.Time `json:"throttled_until"`
+		}{}
+		require.NoError(t, test.ExtractResult(res, &responseData))
+		assert.False(t, responseData.Locked)
+		if assert.NotNil(t, responseData.ThrottledUntil) {
+			assert.True(t, until.Equal(*responseData.ThrottledUntil))
+		}
+	})
 }
 
 func assertGetAccountResponse(t *testing.T, res *http.Response, acc *models.Account) {
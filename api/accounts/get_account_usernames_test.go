@@ -0,0 +1,46 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountUsernames(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.Get("/accounts/999999/usernames")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("renamed account", func(t *testing.T) {
+		account, err := app.AccountStore.Create("before@test.com", []byte("bar"))
+		require.NoError(t, err)
+		err = app.AccountStore.UpdateUsername(account.ID, "after@test.com")
+		require.NoError(t, err)
+
+		res, err := client.Get(fmt.Sprintf("/accounts/%v/usernames", account.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		changes := []map[string]interface{}{}
+		err = test.ExtractResult(res, &changes)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "before@test.com", changes[0]["from"])
+		assert.Equal(t, "after@test.com", changes[0]["to"])
+		assert.NotEmpty(t, changes[0]["created_at"])
+	})
+}
@@ -0,0 +1,135 @@
+package accounts
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
+)
+
+// accounts with a tag are listed in pages, in order of ID
+const (
+	defaultAccountsLimit = 100
+	maxAccountsLimit     = 1000
+)
+
+func getAccounts(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := r.FormValue("username")
+		externalID := r.FormValue("external_id")
+		tag := r.FormValue("tag")
+		if username == "" && externalID == "" && tag == "" {
+			api.WriteErrors(w, services.FieldErrors{{"username", services.ErrMissing}})
+			return
+		}
+		after := 0
+		if r.FormValue("after") != "" {
+			val, err := strconv.Atoi(r.FormValue("after"))
+			if err != nil || val < 0 {
+				api.WriteErrors(w, services.FieldErrors{{"after", services.ErrFormatInvalid}})
+				return
+			}
+			after = val
+		}
+		limit := defaultAccountsLimit
+		if r.FormValue("limit") != "" {
+			val, err := strconv.Atoi(r.FormValue("limit"))
+			if err != nil || val < 1 || val > maxAccountsLimit {
+				api.WriteErrors(w, services.FieldErrors{{"limit", services.ErrFormatInvalid}})
+				return
+			}
+			limit = val
+		}
+
+		var accounts []*models.Account
+		if username != "" {
+			account, err := app.Accounts(r).FindByUsername(username)
+			if err != nil {
+				panic(err)
+			}
+			if account != nil {
+				accounts = append(accounts, account)
+			}
+		}
+
+		if externalID != "" {
+			if username != "" {
+				accounts = filterByExternalID(accounts, externalID)
+			} else {
+				account, err := app.Accounts(r).FindByExternalID(externalID)
+				if err != nil {
+					panic(err)
+				}
+				if account != nil {
+					accounts = append(accounts, account)
+				}
+			}
+		}
+
+		if tag != "" {
+			ids, err := app.AnnotationStore.FindByTag(tag)
+			if err != nil {
+				panic(err)
+			}
+
+			if username != "" || externalID != "" {
+				accounts = filterByID(accounts, ids)
+			} else {
+				accounts, err = app.Accounts(r).FindMany(page(ids, after, limit))
+				if err != nil {
+					panic(err)
+				}
+			}
+		}
+
+		results := []map[string]interface{}{}
+		for _, account := range accounts {
+			results = append(results, map[string]interface{}{
+				"id":          account.ID,
+				"username":    account.Username,
+				"external_id": account.ExternalID,
+				"locked":      account.Locked,
+				"throttled":   account.Throttled(),
+				"deleted":     account.DeletedAt != nil,
+			})
+		}
+
+		api.WriteData(w, http.StatusOK, results)
+	}
+}
+
+// page returns up to limit of the ascending ids that follow after.
+func page(ids []int, after int, limit int) []int {
+	start := sort.SearchInts(ids, after+1)
+	ids = ids[start:]
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids
+}
+
+func filterByID(accounts []*models.Account, ids []int) []*models.Account {
+	filtered := []*models.Account{}
+	for _, account := range accounts {
+		for _, id := range ids {
+			if account.ID == id {
+				filtered = append(filtered, account)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func filterByExternalID(accounts []*models.Account, externalID string) []*models.Account {
+	filtered := []*models.Account{}
+	for _, account := range accounts {
+		if account.ExternalID != nil && *account.ExternalID == externalID {
+			filtered = append(filtered, account)
+		}
+	}
+	return filtered
+}
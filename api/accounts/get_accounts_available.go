@@ -7,9 +7,16 @@ import (
 	"github.com/keratin/authn-server/services"
 )
 
+// getAccountsAvailable reports whether a username may be used for signup. When preventing
+// enumeration, every username is reported as available, and signup reports any conflict.
 func getAccountsAvailable(app *api.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		account, err := app.AccountStore.FindByUsername(r.FormValue("username"))
+		if app.Config.PreventEnumeration {
+			api.WriteData(w, http.StatusOK, true)
+			return
+		}
+
+		account, err := app.Accounts(r).FindByUsername(r.FormValue("username"))
 		if err != nil {
 			panic(err)
 		}
@@ -35,3 +35,22 @@ func TestGetAccountsAvailable(t *testing.T) {
 		assert.Equal(t, http.StatusOK, res.StatusCode)
 	})
 }
+
+func TestGetAccountsAvailablePreventingEnumeration(t *testing.T) {
+	app := test.App()
+	app.Config.PreventEnumeration = true
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	account, err := app.AccountStore.Create("existing@test.com", []byte("bar"))
+	require.NoError(t, err)
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	for _, username := range []string{account.Username, "unknown@test.com"} {
+		res, err := client.Get("/accounts/available?username=" + username)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, []byte(`{"result":true}`), test.ReadBody(res))
+	}
+}
@@ -0,0 +1,148 @@
+package accounts_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccounts(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("missing username", func(t *testing.T) {
+		res, err := client.Get("/accounts")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"username", services.ErrMissing}})
+	})
+
+	t.Run("unknown username", func(t *testing.T) {
+		res, err := client.Get("/accounts?username=unknown@test.com")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, []interface{}{})
+	})
+
+	t.Run("known username", func(t *testing.T) {
+		account, err := app.AccountStore.Create("known@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res, err := client.Get("/accounts?username=known@test.com")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, []interface{}{
+			map[string]interface{}{
+				"id":          account.ID,
+				"username":    "known@test.com",
+				"external_id": nil,
+				"locked":      false,
+				"throttled":   false,
+				"deleted":     false,
+			},
+		})
+	})
+	t.Run("by tag", func(t *testing.T) {
+		tagged, err := app.AccountStore.Create("tagged@test.com", []byte("bar"))
+		require.NoError(t, err)
+		app.AnnotationStore.Tag(tagged.ID, "vip")
+
+		res, err := client.Get("/accounts?tag=vip")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, []interface{}{
+			map[string]interface{}{
+				"id":          tagged.ID,
+				"username":    "tagged@test.com",
+				"external_id": nil,
+				"locked":      false,
+				"throttled":   false,
+				"deleted":     false,
+			},
+		})
+
+		res, err = client.Get("/accounts?tag=vip&username=known@test.com")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, []interface{}{})
+	})
+
+	t.Run("by tag in pages", func(t *testing.T) {
+		ids := []int{}
+		for _, username := range []string{"one@test.com", "two@test.com", "three@test.com"} {
+			account, err := app.AccountStore.Create(username, []byte("bar"))
+			require.NoError(t, err)
+			app.AnnotationStore.Tag(account.ID, "paged")
+			ids = append(ids, account.ID)
+		}
+		listed := func(path string) []int {
+			res, err := client.Get(path)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, res.StatusCode)
+			var found struct {
+				Result []struct {
+					ID int `json:"id"`
+				} `json:"result"`
+			}
+			require.NoError(t, json.Unmarshal(test.ReadBody(res), &found))
+			result := []int{}
+			for _, account := range found.Result {
+				result = append(result, account.ID)
+			}
+			return result
+		}
+
+		assert.Equal(t, ids[:2], listed("/accounts?tag=paged&limit=2"))
+		assert.Equal(t, ids[2:], listed(fmt.Sprintf("/accounts?tag=paged&limit=2&after=%d", ids[1])))
+		assert.Equal(t, []int{}, listed(fmt.Sprintf("/accounts?tag=paged&limit=2&after=%d", ids[2])))
+
+		res, err := client.Get("/accounts?tag=paged&limit=0")
+		require.NoError(t, err)
+		test.AssertErrors(t, res, services.FieldErrors{{"limit", services.ErrFormatInvalid}})
+
+		res, err = client.Get("/accounts?tag=paged&after=first")
+		require.NoError(t, err)
+		test.AssertErrors(t, res, services.FieldErrors{{"after", services.ErrFormatInvalid}})
+	})
+	t.Run("by external id", func(t *testing.T) {
+		linked, err := app.AccountStore.Create("linked@test.com", []byte("bar"))
+		require.NoError(t, err)
+		err = app.AccountStore.SetExternalID(linked.ID, "cus_123")
+		require.NoError(t, err)
+
+		res, err := client.Get("/accounts?external_id=cus_123")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, []interface{}{
+			map[string]interface{}{
+				"id":          linked.ID,
+				"username":    "linked@test.com",
+				"external_id": "cus_123",
+				"locked":      false,
+				"throttled":   false,
+				"deleted":     false,
+			},
+		})
+
+		res, err = client.Get("/accounts?external_id=cus_123&username=known@test.com")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, []interface{}{})
+
+		res, err = client.Get("/accounts?external_id=unknown")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, []interface{}{})
+	})
+}
@@ -0,0 +1,49 @@
+package accounts
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/keratin/authn-server/services"
+)
+
+// loginHistoryLimit is how many recent logins are listed.
+const loginHistoryLimit = 20
+
+type login struct {
+	Time      time.Time       `json:"time"`
+	IP        string          `json:"ip,omitempty"`
+	Location  *geoip.Location `json:"location,omitempty"`
+	UserAgent string          `json:"user_agent,omitempty"`
+}
+
+// getCurrentAccountLogins lists the recent logins of the current session's account, so that
+// applications may offer a "recent activity" page.
+func getCurrentAccountLogins(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID := api.GetSessionAccountID(r)
+		if accountID == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		events, err := services.LoginHistoryFinder(app.AuditLog, accountID, loginHistoryLimit)
+		if err != nil {
+			panic(err)
+		}
+
+		logins := make([]login, len(events))
+		for i, e := range events {
+			logins[i] = login{
+				Time:      e.Time,
+				IP:        e.IP,
+				Location:  e.Location,
+				UserAgent: e.UserAgent,
+			}
+		}
+
+		api.WriteData(w, http.StatusOK, logins)
+	}
+}
@@ -0,0 +1,68 @@
+package accounts_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCurrentAccountLogins(t *testing.T) {
+	app := test.App()
+	app.Config.AuditLog = true
+	app.AuditLog = mock.NewAuditLog(app.Config.AuditSigningKey)
+	server := test.Server(app, accounts.PublicRoutes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("with a session", func(t *testing.T) {
+		account, err := app.AccountStore.Create("active@test.com", []byte("bar"))
+		require.NoError(t, err)
+		loggedInAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		require.NoError(t, app.AuditLog.Append(events.Event{
+			Type:      events.SessionCreated,
+			AccountID: account.ID,
+			Time:      loggedInAt,
+			Location:  &geoip.Location{Country: "NZ", City: "Auckland"},
+			IP:        "203.0.113.1",
+			UserAgent: "Mozilla/5.0",
+		}))
+		require.NoError(t, app.AuditLog.Append(events.Event{
+			Type:      events.PasswordChanged,
+			AccountID: account.ID,
+			Time:      loggedInAt,
+		}))
+		session := test.CreateSession(app.RefreshTokenStore, app.Config, account.ID)
+
+		res, err := client.WithCookie(session).Get("/account/logins")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var result struct {
+			Result []map[string]interface{} `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(test.ReadBody(res), &result))
+		assert.Equal(t, []map[string]interface{}{{
+			"time":       "2017-01-01T00:00:00Z",
+			"ip":         "203.0.113.1",
+			"location":   map[string]interface{}{"country": "NZ", "city": "Auckland"},
+			"user_agent": "Mozilla/5.0",
+		}}, result.Result)
+	})
+
+	t.Run("without a session", func(t *testing.T) {
+		res, err := client.Get("/account/logins")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+}
@@ -0,0 +1,27 @@
+package accounts
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+)
+
+// getCurrentAccountProfile returns the current session's profile fields, and those that have yet
+// to be collected.
+func getCurrentAccountProfile(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID := api.GetSessionAccountID(r)
+		if accountID == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		profile, err := services.ProfileGetter(app.AnnotationStore, app.Config, accountID)
+		if err != nil {
+			panic(err)
+		}
+
+		api.WriteData(w, http.StatusOK, profile)
+	}
+}
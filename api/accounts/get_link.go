@@ -0,0 +1,50 @@
+package accounts
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/actions"
+)
+
+// getLink performs the action of a signed link. The link is the only credential, so that it may be
+// handed to the user. The token is a query param, which request logs redact.
+func getLink(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, err := services.ActionLinkPerformer(
+			app.Accounts(r),
+			app.RefreshTokens(r),
+			app.Quotas,
+			app.Config,
+			r.URL.Query().Get("token"),
+		)
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		id, err := strconv.Atoi(claims.Subject)
+		if err != nil {
+			panic(err)
+		}
+		switch claims.Action {
+		case actions.Unlock:
+			app.Events.EmitRequest(events.AccountUnlocked, id, nil, r)
+		case actions.Archive:
+			app.Events.EmitRequest(events.AccountArchived, id, nil, r)
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		api.WriteData(w, http.StatusOK, map[string]interface{}{
+			"account_id": id,
+			"action":     claims.Action,
+		})
+	}
+}
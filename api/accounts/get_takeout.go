@@ -0,0 +1,60 @@
+package accounts
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/takeouts"
+)
+
+// getTakeout downloads an archive of the account's data. The signed link is the only credential, so
+// that it may be handed to the user. The token is a query param, which request logs redact.
+func getTakeout(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, err := takeouts.Parse(r.URL.Query().Get("token"), app.Config)
+		if err != nil {
+			api.WriteNotFound(w, "takeout")
+			return
+		}
+		id, err := strconv.Atoi(claims.Subject)
+		if err != nil {
+			api.WriteNotFound(w, "takeout")
+			return
+		}
+
+		takeout, err := services.TakeoutAssembler(
+			app.Accounts(r),
+			app.AnnotationStore,
+			app.RefreshTokens(r),
+			app.ConsentStore,
+			app.AuditLog,
+			id,
+		)
+		if err != nil {
+			if _, ok := err.(services.FieldErrors); ok {
+				api.WriteNotFound(w, "takeout")
+				return
+			}
+
+			panic(err)
+		}
+
+		body, err := json.MarshalIndent(takeout, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+
+		app.Events.EmitRequest(events.AccountExported, id, nil, r)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="account-%d.json"`, id))
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}
+}
@@ -0,0 +1,94 @@
+package accounts_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/tokens/takeouts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTakeout(t *testing.T) {
+	app := test.App()
+	app.Config.TakeoutSigningKey = []byte("key-a-reno")
+	app.Config.TakeoutTTL = time.Hour
+	app.AuditLog = mock.NewAuditLog(app.Config.AuditSigningKey)
+	server := test.Server(app, accounts.PublicRoutes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL)
+	link := func(accountID int, key []byte) string {
+		claims, err := takeouts.New(app.Config, accountID)
+		require.NoError(t, err)
+		token, err := claims.Sign(key)
+		require.NoError(t, err)
+		return "/takeout?token=" + token
+	}
+
+	t.Run("valid link", func(t *testing.T) {
+		account, err := app.AccountStore.Create("takeout@test.com", []byte("bar"))
+		require.NoError(t, err)
+		_, err = app.ConsentStore.Create(account.ID, "1", true, "127.0.0.1")
+		require.NoError(t, err)
+		_, err = app.RefreshTokenStore.Create(account.ID)
+		require.NoError(t, err)
+		for _, eventType := range []string{events.AccountCreated, events.SessionCreated} {
+			err = app.AuditLog.Append(events.Event{Type: eventType, AccountID: account.ID, Time: time.Now()})
+			require.NoError(t, err)
+		}
+
+		res, err := client.Get(link(account.ID, app.Config.TakeoutSigningKey))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, fmt.Sprintf(`attachment; filename="account-%d.json"`, account.ID), res.Header.Get("Content-Disposition"))
+		assert.Equal(t, "no-store", res.Header.Get("Cache-Control"))
+
+		takeout := struct {
+			Account struct {
+				Username string `json:"username"`
+				Password string `json:"password"`
+			} `json:"account"`
+			Sessions []map[string]string      `json:"sessions"`
+			Consents []map[string]interface{} `json:"consents"`
+			Events   []events.Event           `json:"events"`
+		}{}
+		require.NoError(t, json.Unmarshal(test.ReadBody(res), &takeout))
+		assert.Equal(t, "takeout@test.com", takeout.Account.Username)
+		assert.Empty(t, takeout.Account.Password)
+		assert.Len(t, takeout.Sessions, 1)
+		assert.Len(t, takeout.Consents, 1)
+		require.Len(t, takeout.Events, 2)
+		assert.Equal(t, events.AccountCreated, takeout.Events[0].Type)
+		assert.Equal(t, events.SessionCreated, takeout.Events[1].Type)
+	})
+
+	t.Run("link signed with another key", func(t *testing.T) {
+		account, err := app.AccountStore.Create("forged@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res, err := client.Get(link(account.ID, []byte("old-a-reno")))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("link for an unknown account", func(t *testing.T) {
+		res, err := client.Get(link(999999, app.Config.TakeoutSigningKey))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("without a token", func(t *testing.T) {
+		res, err := client.Get("/takeout")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -0,0 +1,75 @@
+package accounts
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+	"github.com/pkg/errors"
+)
+
+// ifMatch rejects a change to an account with 412 Precondition Failed when its If-Match header
+// names a version that is no longer current, so that concurrent admin tools do not silently
+// overwrite each other. Requests without If-Match are unaffected. A successful change responds with
+// the account's new ETag, so that the next change may be made without fetching the account again.
+func ifMatch(app *api.App, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+
+		header := r.Header.Get("If-Match")
+		if header != "" {
+			err = services.AccountVersionClaimer(app.Accounts(r), id, header)
+			if err != nil {
+				if fe, ok := err.(services.FieldErrors); ok {
+					api.WriteJSON(w, http.StatusPreconditionFailed, api.ServiceErrors{Errors: fe})
+					return
+				}
+
+				panic(err)
+			}
+		}
+
+		next(&taggedResponse{ResponseWriter: w, tag: func() string {
+			account, err := app.Accounts(r).Find(id)
+			if err != nil {
+				app.Reporter.ReportRequestError(errors.Wrap(err, "Find"), r)
+				return ""
+			}
+			if account == nil {
+				return ""
+			}
+			return services.AccountETag(account)
+		}}, r)
+	}
+}
+
+// taggedResponse sets an ETag on a successful response. The tag is found when the status is
+// written, after the handler has made its change.
+type taggedResponse struct {
+	http.ResponseWriter
+	tag         func() string
+	wroteHeader bool
+}
+
+func (t *taggedResponse) WriteHeader(status int) {
+	if !t.wroteHeader && status >= 200 && status < 300 {
+		if etag := t.tag(); etag != "" {
+			t.Header().Set("ETag", etag)
+		}
+	}
+	t.wroteHeader = true
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *taggedResponse) Write(b []byte) (int, error) {
+	if !t.wroteHeader {
+		t.WriteHeader(http.StatusOK)
+	}
+	return t.ResponseWriter.Write(b)
+}
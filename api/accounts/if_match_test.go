@@ -0,0 +1,79 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIfMatch(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	account, err := app.AccountStore.Create("versioned@test.com", []byte("bar"))
+	require.NoError(t, err)
+	path := fmt.Sprintf("/accounts/%v", account.ID)
+
+	res, err := client.Get(path)
+	require.NoError(t, err)
+	etag := res.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	t.Run("current version", func(t *testing.T) {
+		res, err := client.WithHeader("If-Match", etag).Patch(path, url.Values{"username": []string{"first@test.com"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		res, err := client.WithHeader("If-Match", etag).Patch(path, url.Values{"username": []string{"second@test.com"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusPreconditionFailed, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"account", services.ErrStale}})
+
+		found, err := app.AccountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first@test.com", found.Username)
+	})
+
+	t.Run("without If-Match", func(t *testing.T) {
+		res, err := client.Patch(path+"/lock", url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NotEmpty(t, res.Header.Get("ETag"))
+	})
+
+	t.Run("chained versions", func(t *testing.T) {
+		res, err := client.Get(path)
+		require.NoError(t, err)
+		current := res.Header.Get("ETag")
+
+		res, err = client.WithHeader("If-Match", current).Patch(path+"/unlock", url.Values{})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		next := res.Header.Get("ETag")
+		assert.NotEmpty(t, next)
+		assert.NotEqual(t, current, next)
+
+		res, err = client.WithHeader("If-Match", next).Patch(path+"/lock", url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.WithHeader("If-Match", etag).Patch("/accounts/999999/lock", url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
 	"github.com/keratin/authn-server/services"
 )
 
@@ -17,7 +18,7 @@ func patchAccount(app *api.App) http.HandlerFunc {
 			return
 		}
 
-		err = services.AccountUpdater(app.AccountStore, app.Config, id, r.FormValue("username"))
+		err = services.AccountUpdater(app.Accounts(r), app.Config, id, r.FormValue("username"))
 		if err != nil {
 			if fe, ok := err.(services.FieldErrors); ok {
 				if fe[0].Message == services.ErrNotFound {
@@ -31,6 +32,8 @@ func patchAccount(app *api.App) http.HandlerFunc {
 			panic(err)
 		}
 
+		app.Events.Emit(events.AccountUpdated, id)
+
 		w.WriteHeader(http.StatusOK)
 	}
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
 	"github.com/keratin/authn-server/services"
 )
 
@@ -17,7 +18,7 @@ func patchAccountExpirePassword(app *api.App) http.HandlerFunc {
 			return
 		}
 
-		err = services.PasswordExpirer(app.AccountStore, app.RefreshTokenStore, id)
+		err = services.PasswordExpirer(app.Accounts(r), app.RefreshTokens(r), id)
 		if err != nil {
 			if _, ok := err.(services.FieldErrors); ok {
 				api.WriteNotFound(w, "account")
@@ -27,6 +28,8 @@ func patchAccountExpirePassword(app *api.App) http.HandlerFunc {
 			panic(err)
 		}
 
+		app.Events.Emit(events.PasswordExpired, id)
+
 		w.WriteHeader(http.StatusOK)
 	}
 }
@@ -0,0 +1,41 @@
+package accounts
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+)
+
+func patchAccountExpiry(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+
+		expiresAt, err := services.ExpiryValidator(r.FormValue("expires_at"))
+		if err != nil {
+			api.WriteErrors(w, err.(services.FieldErrors))
+			return
+		}
+
+		err = services.AccountExpirySetter(app.Accounts(r), id, expiresAt)
+		if err != nil {
+			if _, ok := err.(services.FieldErrors); ok {
+				api.WriteNotFound(w, "account")
+				return
+			}
+
+			panic(err)
+		}
+
+		app.Events.Emit(events.AccountUpdated, id)
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,67 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchAccountExpiry(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.Patch("/accounts/999999/expiry", url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("invalid expiry", func(t *testing.T) {
+		account, err := app.AccountStore.Create("invalid@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res, err := client.Patch(fmt.Sprintf("/accounts/%v/expiry", account.ID), url.Values{
+			"expires_at": []string{"tomorrow"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"expires_at", services.ErrFormatInvalid}})
+	})
+
+	t.Run("setting and clearing expiry", func(t *testing.T) {
+		account, err := app.AccountStore.Create("temporary@test.com", []byte("bar"))
+		require.NoError(t, err)
+		at := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+		res, err := client.Patch(fmt.Sprintf("/accounts/%v/expiry", account.ID), url.Values{
+			"expires_at": []string{at.Format(time.RFC3339)},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		found, err := app.AccountStore.Find(account.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found.ExpiresAt)
+		assert.True(t, at.Equal(*found.ExpiresAt))
+
+		res, err = client.Patch(fmt.Sprintf("/accounts/%v/expiry", account.ID), url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		found, err = app.AccountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.ExpiresAt)
+	})
+}
@@ -0,0 +1,63 @@
+package accounts
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+)
+
+// patchAccountResetPassword is the usual response to a compromised account: it requires a new
+// password, revokes every session, and optionally sends a password reset to the user.
+func patchAccountResetPassword(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+
+		sendReset, err := regexp.MatchString("^(?i:t|true|yes)$", r.FormValue("send_reset"))
+		if err != nil {
+			panic(err)
+		}
+		if sendReset && app.Config.AppPasswordResetURL == nil {
+			api.WriteErrors(w, services.FieldErrors{{"send_reset", services.ErrUnconfigured}})
+			return
+		}
+
+		err = services.PasswordExpirer(app.Accounts(r), app.RefreshTokens(r), id)
+		if err != nil {
+			if _, ok := err.(services.FieldErrors); ok {
+				api.WriteNotFound(w, "account")
+				return
+			}
+
+			panic(err)
+		}
+
+		app.Events.Emit(events.PasswordExpired, id)
+
+		if sendReset {
+			account, err := app.Accounts(r).Find(id)
+			if err != nil {
+				panic(err)
+			}
+
+			app.Events.Emit(events.PasswordResetRequested, id)
+
+			go func() {
+				err := services.PasswordResetSender(app.Config, account)
+				if err != nil {
+					app.Reporter.ReportRequestError(err, r)
+				}
+			}()
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
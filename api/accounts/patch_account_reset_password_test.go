@@ -0,0 +1,63 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchAccountResetPassword(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.Patch("/accounts/999999/reset_password", url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("active account", func(t *testing.T) {
+		account, err := app.AccountStore.Create("compromised@test.com", []byte("bar"))
+		require.NoError(t, err)
+		_, err = app.RefreshTokenStore.Create(account.ID)
+		require.NoError(t, err)
+
+		res, err := client.Patch(fmt.Sprintf("/accounts/%v/reset_password", account.ID), url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		account, err = app.AccountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.True(t, account.RequireNewPassword)
+
+		tokens, err := app.RefreshTokenStore.FindAll(account.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+
+	t.Run("sending a reset without a reset url", func(t *testing.T) {
+		app.Config.AppPasswordResetURL = nil
+		account, err := app.AccountStore.Create("unconfigured@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res, err := client.Patch(fmt.Sprintf("/accounts/%v/reset_password", account.ID), url.Values{"send_reset": []string{"true"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"send_reset", services.ErrUnconfigured}})
+
+		account, err = app.AccountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.False(t, account.RequireNewPassword)
+	})
+}
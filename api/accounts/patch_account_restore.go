@@ -0,0 +1,39 @@
+package accounts
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+)
+
+func patchAccountRestore(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+
+		err = services.AccountRestorer(app.Accounts(r), app.Config, id)
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				if fe[0].Message == services.ErrNotFound {
+					api.WriteNotFound(w, "account")
+				} else {
+					api.WriteErrors(w, fe)
+				}
+				return
+			}
+
+			panic(err)
+		}
+
+		app.Events.Emit(events.AccountRestored, id)
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,60 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchAccountRestore(t *testing.T) {
+	app := test.App()
+	app.Config.AccountRestoreWindow = time.Hour
+	app.AccountStore = data.NewRestorableAccountStore(app.AccountStore, []byte("DLz2TNDRdWWA5w8YNeCJ7uzcS4WDzQmB"))
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.Patch("/accounts/999999/restore", url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("archived account", func(t *testing.T) {
+		account, err := app.AccountStore.Create("archived@test.com", []byte("bar"))
+		require.NoError(t, err)
+		require.NoError(t, app.AccountStore.Archive(account.ID))
+
+		res, err := client.Patch(fmt.Sprintf("/accounts/%v/restore", account.ID), url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		account, err = app.AccountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "archived@test.com", account.Username)
+		assert.False(t, account.Archived())
+	})
+
+	t.Run("reclaimed username", func(t *testing.T) {
+		account, err := app.AccountStore.Create("reclaimed@test.com", []byte("bar"))
+		require.NoError(t, err)
+		require.NoError(t, app.AccountStore.Archive(account.ID))
+		_, err = app.AccountStore.Create("reclaimed@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res, err := client.Patch(fmt.Sprintf("/accounts/%v/restore", account.ID), url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		assert.Equal(t, []byte(`{"errors":[{"field":"username","message":"TAKEN"}]}`), test.ReadBody(res))
+	})
+}
@@ -0,0 +1,39 @@
+package accounts
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+)
+
+// patchCurrentAccountProfile collects profile fields after signup.
+func patchCurrentAccountProfile(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID := api.GetSessionAccountID(r)
+		if accountID == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		err := services.ProfileUpdater(app.AnnotationStore, app.Config, accountID, profileValues(r))
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		app.Events.Emit(events.AccountUpdated, accountID)
+
+		profile, err := services.ProfileGetter(app.AnnotationStore, app.Config, accountID)
+		if err != nil {
+			panic(err)
+		}
+
+		api.WriteData(w, http.StatusOK, profile)
+	}
+}
@@ -0,0 +1,67 @@
+package accounts_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrentAccountProfile(t *testing.T) {
+	app := test.App()
+	fields, err := config.ParseProfileFields(`{"name": {"required": true}, "locale": {"enum": ["en", "fr"]}}`)
+	require.NoError(t, err)
+	app.Config.ProfileFields = fields
+	server := test.Server(app, accounts.PublicRoutes(app))
+	defer server.Close()
+
+	account, err := app.AccountStore.Create("profiled@test.com", []byte("bar"))
+	require.NoError(t, err)
+	require.NoError(t, app.AnnotationStore.SetMetadata(account.ID, map[string]string{"name": "Jane", "plan": "pro"}))
+	session := test.CreateSession(app.RefreshTokenStore, app.Config, account.ID)
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("without a session", func(t *testing.T) {
+		res, err := client.Get("/account/profile")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("reading", func(t *testing.T) {
+		res, err := client.WithCookie(session).Get("/account/profile")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, services.Profile{
+			Fields:  map[string]string{"name": "Jane"},
+			Missing: []string{"locale"},
+		})
+	})
+
+	t.Run("collecting an invalid value", func(t *testing.T) {
+		res, err := client.WithCookie(session).Patch("/account/profile", url.Values{
+			"profile[locale]": []string{"de"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"locale", services.ErrFormatInvalid}})
+	})
+
+	t.Run("collecting a value", func(t *testing.T) {
+		res, err := client.WithCookie(session).Patch("/account/profile", url.Values{
+			"profile[locale]": []string{"fr"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, services.Profile{
+			Fields:  map[string]string{"name": "Jane", "locale": "fr"},
+			Missing: []string{},
+		})
+	})
+}
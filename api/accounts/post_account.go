@@ -1,18 +1,111 @@
 package accounts
 
 import (
+	"net"
 	"net/http"
+	"net/url"
+	"regexp"
+	"time"
 
 	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
 	"github.com/keratin/authn-server/lib/route"
 	"github.com/keratin/authn-server/services"
 )
 
+// profileParam matches form fields like profile[name].
+var profileParam = regexp.MustCompile(`\Aprofile\[(.+)\]\z`)
+
+// profileValues collects the PROFILE_FIELDS values from a form.
+func profileValues(r *http.Request) map[string]string {
+	values := map[string]string{}
+	if err := r.ParseForm(); err != nil {
+		return values
+	}
+	for name, vals := range r.PostForm {
+		if m := profileParam.FindStringSubmatch(name); m != nil {
+			values[m[1]] = vals[0]
+		}
+	}
+	return values
+}
+
 func postAccount(app *api.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		jkt, err := api.DPoPThumbprint(app.Config, app.DPoPProofs, r)
+		if err != nil {
+			api.WriteErrors(w, services.FieldErrors{{"dpop", services.ErrInvalidOrExpired}})
+			return
+		}
+
+		location, err := services.LocationFinder(app.Config.GeoIPLocator, r.RemoteAddr)
+		if err != nil {
+			app.Reporter.ReportRequestError(err, r)
+		}
+		app.Events.EmitRequest(events.SignupStarted, 0, location, r)
+
+		err = app.Hooks.ValidateSignup(r, r.FormValue("username"))
+		if err != nil {
+			api.WriteErrors(w, services.FieldErrors{{"username", err.Error()}})
+			return
+		}
+
+		honeypot := ""
+		if app.Config.SignupHoneypotField != "" {
+			honeypot = r.FormValue(app.Config.SignupHoneypotField)
+		}
+		bot := services.SignupBotDetector(app.Config, honeypot, r.FormValue("form_started_at"), time.Now())
+		if bot && app.Config.SignupBotAction != "flag" {
+			api.WriteErrors(w, services.FieldErrors{{"username", services.ErrVetoed}})
+			return
+		}
+
+		if app.Quotas != nil {
+			rl, err := services.SignupQuotaChecker(app.Quotas, app.Config, r.RemoteAddr, r.FormValue("username"))
+			api.SetRateLimit(w, rl)
+			if err != nil {
+				if fe, ok := err.(services.FieldErrors); ok {
+					api.WriteErrors(w, fe)
+					return
+				}
+
+				panic(err)
+			}
+		}
+
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		err = services.SignupVetoer(app.Config, app.Reporter, r.FormValue("username"), url.Values{
+			"ip":         []string{ip},
+			"user_agent": []string{r.UserAgent()},
+			"domain":     []string{route.MatchedDomain(r).String()},
+		})
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		err = services.TermsValidator(app.Config, r.FormValue("terms_version"))
+		if err != nil {
+			api.WriteErrors(w, err.(services.FieldErrors))
+			return
+		}
+
+		profile, err := services.ProfileValidator(app.Config, profileValues(r), true)
+		if err != nil {
+			api.WriteErrors(w, err.(services.FieldErrors))
+			return
+		}
+
 		// Create the account
 		account, err := services.AccountCreator(
-			app.AccountStore,
+			app.Accounts(r),
 			app.Config,
 			r.FormValue("username"),
 			r.FormValue("password"),
@@ -26,18 +119,68 @@ func postAccount(app *api.App) http.HandlerFunc {
 			panic(err)
 		}
 
-		err = api.RevokeSession(app.RefreshTokenStore, app.Config, r)
+		marketingOptIn, err := regexp.MatchString("^(?i:t|true|yes)$", r.FormValue("marketing_opt_in"))
+		if err != nil {
+			panic(err)
+		}
+		err = services.ConsentRecorder(app.ConsentStore, app.Config, account.ID, r.FormValue("terms_version"), marketingOptIn, r.RemoteAddr)
+		if err != nil {
+			panic(err)
+		}
+
+		if len(profile) > 0 {
+			err = app.AnnotationStore.SetMetadata(account.ID, profile)
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		if bot {
+			err = app.AnnotationStore.Tag(account.ID, services.SuspectedBotTag)
+			if err != nil {
+				app.Reporter.ReportRequestError(err, r)
+			}
+		}
+
+		app.Events.EmitRequest(events.AccountCreated, account.ID, location, r)
+		app.Hooks.AfterAccountCreated(r, account)
+
+		if app.Config.AppAccountProvisioningURL != nil {
+			go func() {
+				err := services.ExternalIDProvisioner(app.Accounts(r), app.Config, account)
+				if err != nil {
+					app.Reporter.ReportRequestError(err, r)
+				}
+			}()
+		}
+
+		if app.Signups != nil {
+			err = app.Signups.Track()
+			if err != nil {
+				app.Reporter.ReportRequestError(err, r)
+			}
+		}
+
+		if app.Quotas != nil {
+			err = services.SignupQuotaTracker(app.Quotas, app.Config, r.RemoteAddr, account.Username)
+			if err != nil {
+				app.Reporter.ReportRequestError(err, r)
+			}
+		}
+
+		err = api.RevokeSession(app.RefreshTokens(r), app.Config, r)
 		if err != nil {
 			app.Reporter.ReportRequestError(err, r)
 		}
 
-		sessionToken, identityToken, err := api.NewSession(app.RefreshTokenStore, app.KeyStore, app.Actives, app.Config, account.ID, route.MatchedDomain(r))
+		sessionToken, identityToken, err := api.NewSession(app.RefreshTokens(r), app.KeyStore, app.AccessTokenStore, app.Accounts(r), app.Actives, app.Config, account.ID, route.MatchedDomain(r), api.SessionFingerprint(app.Config, r), jkt, []string{"pwd"})
 		if err != nil {
 			panic(err)
 		}
 
 		// Return the signed session in a cookie
-		api.SetSession(app.Config, w, sessionToken)
+		api.SetSession(app.Config, w, route.MatchedDomain(r), sessionToken)
+		api.SetAccessToken(app.Config, w, route.MatchedDomain(r), identityToken)
 
 		// Return the signed identity token in the body
 		api.WriteData(w, http.StatusCreated, map[string]string{
@@ -0,0 +1,48 @@
+package accounts
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+)
+
+// postAccountLink issues a signed link that performs one action on the account when it is
+// visited, so that support may attach it to a ticket rather than act on the user's behalf.
+func postAccountLink(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+
+		claims, err := services.ActionLinkCreator(app.Accounts(r), app.Config, id, r.FormValue("action"))
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				if fe[0].Message == services.ErrNotFound {
+					api.WriteNotFound(w, "account")
+				} else {
+					api.WriteErrors(w, fe)
+				}
+				return
+			}
+
+			panic(err)
+		}
+		token, err := claims.Sign(app.Config.ActionLinkSigningKey)
+		if err != nil {
+			panic(err)
+		}
+
+		api.WriteData(w, http.StatusCreated, map[string]interface{}{
+			"url":        app.Config.AuthNURL.String() + "/links?token=" + url.QueryEscape(token),
+			"action":     claims.Action,
+			"expires_at": claims.Expiry.Time().UTC().Format(time.RFC3339),
+		})
+	}
+}
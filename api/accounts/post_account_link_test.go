@@ -0,0 +1,86 @@
+package accounts_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAccountLink(t *testing.T) {
+	app := test.App()
+	app.Config.ActionLinkSigningKey = []byte("key-a-reno")
+	app.Config.ActionLinkTTL = time.Hour
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.PostForm("/accounts/999999/links", url.Values{"action": []string{"unlock"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		account, err := app.AccountStore.Create("delete@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res, err := client.PostForm(fmt.Sprintf("/accounts/%v/links", account.ID), url.Values{"action": []string{"delete"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"action", services.ErrFormatInvalid}})
+	})
+
+	t.Run("unlock link", func(t *testing.T) {
+		account, err := app.AccountStore.Create("locked@test.com", []byte("bar"))
+		require.NoError(t, err)
+		require.NoError(t, app.AccountStore.Lock(account.ID))
+
+		res, err := client.PostForm(fmt.Sprintf("/accounts/%v/links", account.ID), url.Values{"action": []string{"unlock"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		body := struct {
+			Result struct {
+				URL       string `json:"url"`
+				Action    string `json:"action"`
+				ExpiresAt string `json:"expires_at"`
+			} `json:"result"`
+		}{}
+		require.NoError(t, json.Unmarshal(test.ReadBody(res), &body))
+		assert.True(t, strings.HasPrefix(body.Result.URL, app.Config.AuthNURL.String()+"/links?token="))
+		assert.Equal(t, "unlock", body.Result.Action)
+		expiresAt, err := time.Parse(time.RFC3339, body.Result.ExpiresAt)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+		link, err := url.Parse(body.Result.URL)
+		require.NoError(t, err)
+		visitor := route.NewClient(server.URL)
+		res, err = visitor.Get(link.RequestURI())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "no-store", res.Header.Get("Cache-Control"))
+		test.AssertData(t, res, map[string]interface{}{"account_id": account.ID, "action": "unlock"})
+
+		account, err = app.AccountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.False(t, account.Locked)
+
+		res, err = visitor.Get(link.RequestURI())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"token", services.ErrInvalidOrExpired}})
+	})
+}
@@ -0,0 +1,36 @@
+package accounts
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+)
+
+func postAccountNote(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+
+		note, err := services.AccountNoteCreator(app.Accounts(r), app.AnnotationStore, id, r.FormValue("body"))
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				if fe[0].Message == services.ErrNotFound {
+					api.WriteNotFound(w, "account")
+				} else {
+					api.WriteErrors(w, fe)
+				}
+				return
+			}
+
+			panic(err)
+		}
+
+		api.WriteData(w, http.StatusCreated, note)
+	}
+}
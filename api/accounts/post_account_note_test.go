@@ -0,0 +1,53 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAccountNote(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.PostForm("/accounts/999999/notes", url.Values{"body": []string{"hello"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("valid note", func(t *testing.T) {
+		account, err := app.AccountStore.Create("noted@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res, err := client.PostForm(fmt.Sprintf("/accounts/%v/notes", account.ID), url.Values{"body": []string{"called support"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		notes, err := app.AnnotationStore.GetNotes(account.ID)
+		require.NoError(t, err)
+		require.Len(t, notes, 1)
+		assert.Equal(t, "called support", notes[0].Body)
+	})
+
+	t.Run("missing body", func(t *testing.T) {
+		account, err := app.AccountStore.Create("blank@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res, err := client.PostForm(fmt.Sprintf("/accounts/%v/notes", account.ID), url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"body", services.ErrMissing}})
+	})
+}
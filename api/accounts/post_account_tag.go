@@ -0,0 +1,41 @@
+package accounts
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+)
+
+func postAccountTag(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+
+		err = services.AccountTagger(app.Accounts(r), app.AnnotationStore, id, r.FormValue("tag"))
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				if fe[0].Message == services.ErrNotFound {
+					api.WriteNotFound(w, "account")
+				} else {
+					api.WriteErrors(w, fe)
+				}
+				return
+			}
+
+			panic(err)
+		}
+
+		tags, err := app.AnnotationStore.GetTags(id)
+		if err != nil {
+			panic(err)
+		}
+
+		api.WriteData(w, http.StatusOK, tags)
+	}
+}
@@ -0,0 +1,49 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAccountTag(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.PostForm("/accounts/999999/tags", url.Values{"tag": []string{"vip"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("valid tag", func(t *testing.T) {
+		account, err := app.AccountStore.Create("tagged@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res, err := client.PostForm(fmt.Sprintf("/accounts/%v/tags", account.ID), url.Values{"tag": []string{"vip"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, []interface{}{"vip"})
+	})
+
+	t.Run("invalid tag", func(t *testing.T) {
+		account, err := app.AccountStore.Create("invalid@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res, err := client.PostForm(fmt.Sprintf("/accounts/%v/tags", account.ID), url.Values{"tag": []string{"not valid"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"tag", services.ErrFormatInvalid}})
+	})
+}
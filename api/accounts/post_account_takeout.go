@@ -0,0 +1,49 @@
+package accounts
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/takeouts"
+)
+
+// postAccountTakeout issues a signed link that downloads an archive of the account's data until it
+// expires. The archive is assembled when the link is followed.
+func postAccountTakeout(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "account")
+			return
+		}
+
+		account, err := services.AccountGetter(app.Accounts(r), id)
+		if err != nil {
+			if _, ok := err.(services.FieldErrors); ok {
+				api.WriteNotFound(w, "account")
+				return
+			}
+
+			panic(err)
+		}
+
+		claims, err := takeouts.New(app.Config, account.ID)
+		if err != nil {
+			panic(err)
+		}
+		token, err := claims.Sign(app.Config.TakeoutSigningKey)
+		if err != nil {
+			panic(err)
+		}
+
+		api.WriteData(w, http.StatusCreated, map[string]interface{}{
+			"url":        app.Config.AuthNURL.String() + "/takeout?token=" + url.QueryEscape(token),
+			"expires_at": claims.Expiry.Time().UTC().Format(time.RFC3339),
+		})
+	}
+}
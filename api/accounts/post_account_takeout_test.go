@@ -0,0 +1,61 @@
+package accounts_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAccountTakeout(t *testing.T) {
+	app := test.App()
+	app.Config.TakeoutSigningKey = []byte("key-a-reno")
+	app.Config.TakeoutTTL = time.Hour
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown account", func(t *testing.T) {
+		res, err := client.PostForm("/accounts/999999/takeout", nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("known account", func(t *testing.T) {
+		account, err := app.AccountStore.Create("takeout@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res, err := client.PostForm(fmt.Sprintf("/accounts/%v/takeout", account.ID), nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		body := struct {
+			Result struct {
+				URL       string `json:"url"`
+				ExpiresAt string `json:"expires_at"`
+			} `json:"result"`
+		}{}
+		require.NoError(t, json.Unmarshal(test.ReadBody(res), &body))
+		assert.True(t, strings.HasPrefix(body.Result.URL, app.Config.AuthNURL.String()+"/takeout?token="))
+		expiresAt, err := time.Parse(time.RFC3339, body.Result.ExpiresAt)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+		link, err := url.Parse(body.Result.URL)
+		require.NoError(t, err)
+		res, err = route.NewClient(server.URL).Get(link.RequestURI())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Contains(t, string(test.ReadBody(res)), `"username": "takeout@test.com"`)
+	})
+}
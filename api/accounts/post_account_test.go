@@ -1,13 +1,19 @@
 package accounts_test
 
 import (
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
+	"github.com/keratin/authn-server/api"
 	"github.com/keratin/authn-server/api/accounts"
 	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/config"
 	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
 	"github.com/keratin/authn-server/services"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -28,6 +34,10 @@ func TestPostAccountSuccess(t *testing.T) {
 	assert.Equal(t, http.StatusCreated, res.StatusCode)
 	test.AssertSession(t, app.Config, res.Cookies())
 	test.AssertIDTokenResponse(t, res, app.KeyStore, app.Config)
+
+	signups, err := app.Signups.SignupsByDay()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{time.Now().UTC().Format("2006-01-02"): 1}, signups)
 }
 
 func TestPostAccountSuccessWithSession(t *testing.T) {
@@ -81,3 +91,247 @@ func TestPostAccountFailure(t *testing.T) {
 		test.AssertErrors(t, res, tc.errors)
 	}
 }
+
+func TestPostAccountHooks(t *testing.T) {
+	app := test.App()
+	var created []*models.Account
+	app.Hooks = &api.Hooks{
+		SignupValidators: []api.SignupValidator{
+			func(r *http.Request, username string) error {
+				if username == "blocked" {
+					return errors.New("BLOCKED")
+				}
+				return nil
+			},
+		},
+		AccountCreated: []api.AccountHook{
+			func(r *http.Request, account *models.Account) {
+				created = append(created, account)
+			},
+		},
+	}
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("rejected by validator", func(t *testing.T) {
+		res, err := client.PostForm("/accounts", url.Values{
+			"username": []string{"blocked"},
+			"password": []string{"0a0b0c0"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"username", "BLOCKED"}})
+		assert.Empty(t, created)
+	})
+
+	t.Run("allowed by validator", func(t *testing.T) {
+		res, err := client.PostForm("/accounts", url.Values{
+			"username": []string{"allowed"},
+			"password": []string{"0a0b0c0"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		require.Len(t, created, 1)
+		assert.Equal(t, "allowed", created[0].Username)
+	})
+}
+
+func TestPostAccountTerms(t *testing.T) {
+	app := test.App()
+	app.Config.TermsVersion = "2"
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("without terms", func(t *testing.T) {
+		res, err := client.PostForm("/accounts", url.Values{
+			"username": []string{"terms"},
+			"password": []string{"0a0b0c0"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"terms_version", services.ErrMissing}})
+	})
+
+	t.Run("with old terms", func(t *testing.T) {
+		res, err := client.PostForm("/accounts", url.Values{
+			"username":      []string{"terms"},
+			"password":      []string{"0a0b0c0"},
+			"terms_version": []string{"1"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"terms_version", services.ErrExpired}})
+	})
+
+	t.Run("with current terms", func(t *testing.T) {
+		res, err := client.PostForm("/accounts", url.Values{
+			"username":         []string{"terms"},
+			"password":         []string{"0a0b0c0"},
+			"terms_version":    []string{"2"},
+			"marketing_opt_in": []string{"true"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		account, err := app.AccountStore.FindByUsername("terms")
+		require.NoError(t, err)
+		consent, err := app.ConsentStore.Latest(account.ID)
+		require.NoError(t, err)
+		require.NotNil(t, consent)
+		assert.Equal(t, "2", consent.TermsVersion)
+		assert.True(t, consent.MarketingOptIn)
+	})
+}
+
+func TestPostAccountProfile(t *testing.T) {
+	app := test.App()
+	fields, err := config.ParseProfileFields(`{"name": {"required": true}, "birthdate": {"type": "date"}}`)
+	require.NoError(t, err)
+	app.Config.ProfileFields = fields
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("without required fields", func(t *testing.T) {
+		res, err := client.PostForm("/accounts", url.Values{
+			"username":           []string{"profiled"},
+			"password":           []string{"0a0b0c0"},
+			"profile[birthdate]": []string{"yesterday"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{
+			{"birthdate", services.ErrFormatInvalid},
+			{"name", services.ErrMissing},
+		})
+	})
+
+	t.Run("with required fields", func(t *testing.T) {
+		res, err := client.PostForm("/accounts", url.Values{
+			"username":      []string{"profiled"},
+			"password":      []string{"0a0b0c0"},
+			"profile[name]": []string{"Jane Doe"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		account, err := app.AccountStore.FindByUsername("profiled")
+		require.NoError(t, err)
+		metadata, err := app.AnnotationStore.GetMetadata(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"name": "Jane Doe"}, metadata)
+	})
+}
+
+func TestPostAccountQuotas(t *testing.T) {
+	app := test.App()
+	app.Config.SignupQuotaPerIP = 1
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	res, err := client.PostForm("/accounts", url.Values{
+		"username": []string{"first"},
+		"password": []string{"0a0b0c0"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "1", res.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "1", res.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "1;w=3600", res.Header.Get("RateLimit-Policy"))
+	assert.Empty(t, res.Header.Get("Retry-After"))
+
+	res, err = client.PostForm("/accounts", url.Values{
+		"username": []string{"second"},
+		"password": []string{"0a0b0c0"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	test.AssertErrors(t, res, services.FieldErrors{{"ip", services.ErrThrottled}})
+	assert.Equal(t, "0", res.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "3600", res.Header.Get("Retry-After"))
+}
+
+func TestPostAccountBots(t *testing.T) {
+	app := test.App()
+	app.Config.SignupHoneypotField = "website"
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("rejecting a bot", func(t *testing.T) {
+		res, err := client.PostForm("/accounts", url.Values{
+			"username": []string{"bot"},
+			"password": []string{"0a0b0c0"},
+			"website":  []string{"http://spam.example.com"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"username", services.ErrVetoed}})
+
+		account, err := app.AccountStore.FindByUsername("bot")
+		require.NoError(t, err)
+		assert.Nil(t, account)
+	})
+
+	t.Run("flagging a bot", func(t *testing.T) {
+		app.Config.SignupBotAction = "flag"
+		defer func() { app.Config.SignupBotAction = "" }()
+
+		res, err := client.PostForm("/accounts", url.Values{
+			"username": []string{"flagged"},
+			"password": []string{"0a0b0c0"},
+			"website":  []string{"http://spam.example.com"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		account, err := app.AccountStore.FindByUsername("flagged")
+		require.NoError(t, err)
+		tags, err := app.AnnotationStore.GetTags(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{services.SuspectedBotTag}, tags)
+	})
+
+	t.Run("allowing a human", func(t *testing.T) {
+		res, err := client.PostForm("/accounts", url.Values{
+			"username": []string{"human"},
+			"password": []string{"0a0b0c0"},
+			"website":  []string{""},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+}
+
+func TestPostAccountVeto(t *testing.T) {
+	var metadata url.Values
+	veto := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		metadata = r.PostForm
+	}))
+	defer veto.Close()
+
+	app := test.App()
+	app.Config.AppSignupVetoURL, _ = url.Parse(veto.URL)
+	app.Config.SignupVetoTimeout = time.Second
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+	res, err := client.PostForm("/accounts", url.Values{
+		"username": []string{"vetted"},
+		"password": []string{"0a0b0c0"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "vetted", metadata.Get("username"))
+	assert.Equal(t, "127.0.0.1", metadata.Get("ip"))
+}
@@ -0,0 +1,77 @@
+package accounts
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+)
+
+var truthy = regexp.MustCompile("^(?i:t|true|yes)$")
+
+// metadataParam matches form fields like metadata[plan].
+var metadataParam = regexp.MustCompile(`\Ametadata\[(.+)\]\z`)
+
+// postAccounts provisions an account for a backoffice system, with its initial state in place.
+func postAccounts(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		expiresAt, err := services.ExpiryValidator(r.FormValue("expires_at"))
+		if err != nil {
+			api.WriteErrors(w, err.(services.FieldErrors))
+			return
+		}
+
+		metadata := map[string]string{}
+		for name, values := range r.PostForm {
+			if m := metadataParam.FindStringSubmatch(name); m != nil {
+				metadata[m[1]] = values[0]
+			}
+		}
+
+		roles := []string{}
+		for _, value := range r.PostForm["roles"] {
+			for _, role := range strings.Split(value, ",") {
+				if role = strings.TrimSpace(role); role != "" {
+					roles = append(roles, role)
+				}
+			}
+		}
+
+		account, err := services.AccountProvisioner(app.Accounts(r), app.AnnotationStore, app.Config, services.Provision{
+			Username:      r.FormValue("username"),
+			Password:      r.FormValue("password"),
+			Locked:        truthy.MatchString(r.FormValue("locked")),
+			EmailVerified: truthy.MatchString(r.FormValue("email_verified")),
+			ExpiresAt:     expiresAt,
+			Metadata:      metadata,
+			Roles:         roles,
+		})
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		app.Events.Emit(events.AccountCreated, account.ID)
+		app.Hooks.AfterAccountCreated(r, account)
+
+		if app.Config.AppAccountProvisioningURL != nil {
+			go func() {
+				err := services.ExternalIDProvisioner(app.Accounts(r), app.Config, account)
+				if err != nil {
+					app.Reporter.ReportRequestError(err, r)
+				}
+			}()
+		}
+
+		api.WriteData(w, http.StatusCreated, map[string]int{
+			"id": account.ID,
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package accounts
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+)
+
+// maxBulkIDs bounds the work of a single bulk request.
+const maxBulkIDs = 1000
+
+type bulkAction struct {
+	event string
+	run   func(app *api.App, id int) error
+}
+
+var bulkActions = map[string]bulkAction{
+	"lock": {events.AccountLocked, func(app *api.App, id int) error {
+		return services.AccountLocker(app.AccountStore, app.RefreshTokenStore, id)
+	}},
+	"unlock": {events.AccountUnlocked, func(app *api.App, id int) error {
+		return services.AccountUnlocker(app.AccountStore, id)
+	}},
+	"expire_password": {events.PasswordExpired, func(app *api.App, id int) error {
+		return services.PasswordExpirer(app.AccountStore, app.RefreshTokenStore, id)
+	}},
+	"archive": {events.AccountArchived, func(app *api.App, id int) error {
+		return services.AccountArchiver(app.AccountStore, app.RefreshTokenStore, id)
+	}},
+}
+
+// postAccountsBulk applies one action to many accounts. Each account succeeds or fails on its
+// own, and the result reports the outcome for every ID in the order given.
+func postAccountsBulk(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		action, ok := bulkActions[r.FormValue("action")]
+		if !ok {
+			if r.FormValue("action") == "" {
+				api.WriteErrors(w, services.FieldErrors{{"action", services.ErrMissing}})
+			} else {
+				api.WriteErrors(w, services.FieldErrors{{"action", services.ErrFormatInvalid}})
+			}
+			return
+		}
+
+		ids, err := parseIDs(r.FormValue("ids"))
+		if err != nil {
+			api.WriteErrors(w, services.FieldErrors{{"ids", services.ErrFormatInvalid}})
+			return
+		}
+		if len(ids) == 0 {
+			api.WriteErrors(w, services.FieldErrors{{"ids", services.ErrMissing}})
+			return
+		}
+		if len(ids) > maxBulkIDs {
+			api.WriteErrors(w, services.FieldErrors{{"ids", services.ErrFormatInvalid}})
+			return
+		}
+
+		results := make([]map[string]interface{}, 0, len(ids))
+		for _, id := range ids {
+			result := map[string]interface{}{"id": id}
+
+			err := action.run(app, id)
+			if err == nil {
+				app.Events.Emit(action.event, id)
+				result["status"] = "ok"
+			} else if fe, ok := err.(services.FieldErrors); ok {
+				result["status"] = "error"
+				result["errors"] = fe
+			} else {
+				app.Reporter.ReportRequestError(err, r)
+				result["status"] = "error"
+				result["errors"] = services.FieldErrors{{"account", services.ErrFailed}}
+			}
+
+			results = append(results, result)
+		}
+
+		api.WriteData(w, http.StatusOK, results)
+	}
+}
+
+// parseIDs reads a comma-delimited list of account IDs.
+func parseIDs(val string) ([]int, error) {
+	ids := []int{}
+	for _, str := range strings.Split(val, ",") {
+		str = strings.TrimSpace(str)
+		if str == "" {
+			continue
+		}
+		id, err := strconv.Atoi(str)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,87 @@
+package accounts_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAccountsBulk(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("invalid params", func(t *testing.T) {
+		testCases := []struct {
+			params url.Values
+			errors services.FieldErrors
+		}{
+			{url.Values{"ids": []string{"1"}}, services.FieldErrors{{"action", services.ErrMissing}}},
+			{url.Values{"action": []string{"explode"}, "ids": []string{"1"}}, services.FieldErrors{{"action", services.ErrFormatInvalid}}},
+			{url.Values{"action": []string{"lock"}}, services.FieldErrors{{"ids", services.ErrMissing}}},
+			{url.Values{"action": []string{"lock"}, "ids": []string{"1,two"}}, services.FieldErrors{{"ids", services.ErrFormatInvalid}}},
+			{url.Values{"action": []string{"lock"}, "ids": []string{strings.Repeat("1,", 1001)}}, services.FieldErrors{{"ids", services.ErrFormatInvalid}}},
+		}
+
+		for _, tc := range testCases {
+			res, err := client.PostForm("/accounts/bulk", tc.params)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+			test.AssertErrors(t, res, tc.errors)
+		}
+	})
+
+	t.Run("locking accounts", func(t *testing.T) {
+		first, err := app.AccountStore.Create("first@test.com", []byte("bar"))
+		require.NoError(t, err)
+		second, err := app.AccountStore.Create("second@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res, err := client.PostForm("/accounts/bulk", url.Values{
+			"action": []string{"lock"},
+			"ids":    []string{fmt.Sprintf("%d, 999999, %d", first.ID, second.ID)},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, []interface{}{
+			map[string]interface{}{"id": first.ID, "status": "ok"},
+			map[string]interface{}{"id": 999999, "status": "error", "errors": []interface{}{
+				map[string]interface{}{"field": "account", "message": "NOT_FOUND"},
+			}},
+			map[string]interface{}{"id": second.ID, "status": "ok"},
+		})
+
+		for _, id := range []int{first.ID, second.ID} {
+			account, err := app.AccountStore.Find(id)
+			require.NoError(t, err)
+			assert.True(t, account.Locked)
+		}
+	})
+
+	t.Run("expiring passwords", func(t *testing.T) {
+		account, err := app.AccountStore.Create("expired@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res, err := client.PostForm("/accounts/bulk", url.Values{
+			"action": []string{"expire_password"},
+			"ids":    []string{fmt.Sprint(account.ID)},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		account, err = app.AccountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.True(t, account.RequireNewPassword)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 
 	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
 	"github.com/keratin/authn-server/services"
 )
 
@@ -15,8 +16,14 @@ func postAccountsImport(app *api.App) http.HandlerFunc {
 			panic(err)
 		}
 
+		expiresAt, err := services.ExpiryValidator(r.FormValue("expires_at"))
+		if err != nil {
+			api.WriteErrors(w, err.(services.FieldErrors))
+			return
+		}
+
 		account, err := services.AccountImporter(
-			app.AccountStore,
+			app.Accounts(r),
 			app.Config,
 			r.FormValue("username"),
 			r.FormValue("password"),
@@ -31,6 +38,25 @@ func postAccountsImport(app *api.App) http.HandlerFunc {
 			panic(err)
 		}
 
+		if expiresAt != nil {
+			err = services.AccountExpirySetter(app.Accounts(r), account.ID, expiresAt)
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		app.Events.Emit(events.AccountImported, account.ID)
+		app.Hooks.AfterAccountCreated(r, account)
+
+		if app.Config.AppAccountProvisioningURL != nil {
+			go func() {
+				err := services.ExternalIDProvisioner(app.Accounts(r), app.Config, account)
+				if err != nil {
+					app.Reporter.ReportRequestError(err, r)
+				}
+			}()
+		}
+
 		api.WriteData(w, http.StatusCreated, map[string]int{
 			"id": account.ID,
 		})
@@ -61,6 +61,32 @@ func TestPostAccountsImport(t *testing.T) {
 		assert.False(t, account.Locked)
 	})
 
+	t.Run("importing a temporary user", func(t *testing.T) {
+		res, err := client.PostForm("/accounts/import", url.Values{
+			"username":   []string{"temporary@app.com"},
+			"password":   []string{"secret"},
+			"expires_at": []string{"2030-01-01T00:00:00Z"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		account, err := app.AccountStore.FindByUsername("temporary@app.com")
+		require.NoError(t, err)
+		require.NotNil(t, account.ExpiresAt)
+		assert.Equal(t, 2030, account.ExpiresAt.Year())
+	})
+
+	t.Run("importing with an invalid expiry", func(t *testing.T) {
+		res, err := client.PostForm("/accounts/import", url.Values{
+			"username":   []string{"badexpiry@app.com"},
+			"password":   []string{"secret"},
+			"expires_at": []string{"soon"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"expires_at", "FORMAT_INVALID"}})
+	})
+
 	t.Run("importing an invalid user", func(t *testing.T) {
 		res, err := client.PostForm("/accounts/import", url.Values{
 			"username": []string{"invalid@app.com"},
@@ -0,0 +1,102 @@
+package accounts_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAccounts(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("provisioning with initial state", func(t *testing.T) {
+		res, err := client.PostForm("/accounts", url.Values{
+			"username":       []string{"provisioned@app.com"},
+			"password":       []string{"secret"},
+			"locked":         []string{"true"},
+			"email_verified": []string{"true"},
+			"expires_at":     []string{"2030-01-01T00:00:00Z"},
+			"metadata[plan]": []string{"enterprise"},
+			"roles":          []string{"admin,billing"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Empty(t, res.Cookies())
+
+		account, err := app.AccountStore.FindByUsername("provisioned@app.com")
+		require.NoError(t, err)
+		test.AssertData(t, res, map[string]int{"id": account.ID})
+		assert.True(t, account.Locked)
+		require.NotNil(t, account.ExpiresAt)
+		assert.Equal(t, 2030, account.ExpiresAt.Year())
+
+		metadata, err := app.AnnotationStore.GetMetadata(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"plan": "enterprise", "email_verified": "true"}, metadata)
+
+		tags, err := app.AnnotationStore.GetTags(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin", "billing"}, tags)
+	})
+
+	t.Run("provisioning with invalid params", func(t *testing.T) {
+		res, err := client.PostForm("/accounts", url.Values{
+			"username": []string{"invalid@app.com"},
+			"password": []string{"secret"},
+			"roles":    []string{"not a role"},
+		})
+		require.NoError(t, err)
+		test.AssertErrors(t, res, services.FieldErrors{{"roles", services.ErrFormatInvalid}})
+	})
+
+	t.Run("with bad credentials", func(t *testing.T) {
+		res, err := route.NewClient(server.URL).Authenticated("wrong", "wrong").PostForm("/accounts", url.Values{
+			"username": []string{"unauthorized@app.com"},
+			"password": []string{"secret"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("signing up without credentials", func(t *testing.T) {
+		res, err := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0]).PostForm("/accounts", url.Values{
+			"username": []string{"signup@app.com"},
+			"password": []string{"0a0b0c0"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		test.AssertSession(t, app.Config, res.Cookies())
+	})
+}
+
+func TestPostAccountsWithoutSignup(t *testing.T) {
+	app := test.App()
+	app.Config.EnableSignup = false
+	server := test.Server(app, accounts.Routes(app))
+	defer server.Close()
+
+	res, err := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword).PostForm("/accounts", url.Values{
+		"username": []string{"provisioned@app.com"},
+		"password": []string{"secret"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+	res, err = route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0]).PostForm("/accounts", url.Values{
+		"username": []string{"signup@app.com"},
+		"password": []string{"0a0b0c0"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
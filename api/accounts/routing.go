@@ -1,63 +1,236 @@
 package accounts
 
 import (
+	"net/http"
+
 	"github.com/keratin/authn-server/api"
 	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
 )
 
 func PublicRoutes(app *api.App) []*route.HandledRoute {
-	originSecurity := route.OriginSecurity(app.Config.ApplicationDomains)
-
 	routes := []*route.HandledRoute{}
 
 	if app.Config.EnableSignup {
 		routes = append(routes,
 			route.Post("/accounts").
-				SecuredWith(originSecurity).
+				SecuredWith(route.OriginSecurity(app.Config.ApplicationDomains)).
 				Handle(postAccount(app)),
+		)
+	}
+
+	return append(routes, publicRoutes(app)...)
+}
+
+// publicRoutes are the public routes other than signup, which shares its path with a private
+// route.
+func publicRoutes(app *api.App) []*route.HandledRoute {
+	originSecurity := route.OriginSecurity(app.Config.ApplicationDomains)
+
+	routes := []*route.HandledRoute{}
+
+	if app.Config.EnableSignup {
+		routes = append(routes,
 			route.Get("/accounts/available").
 				SecuredWith(originSecurity).
 				Handle(getAccountsAvailable(app)),
 		)
 	}
 
+	if app.Config.EnableAccountDeletion {
+		routes = append(routes,
+			route.Delete("/account").
+				SecuredWith(originSecurity).
+				Handle(deleteCurrentAccount(app)),
+		)
+	}
+
+	if app.Config.AuditLog {
+		routes = append(routes,
+			route.Get("/account/logins").
+				SecuredWith(originSecurity).
+				Handle(getCurrentAccountLogins(app)),
+		)
+	}
+
+	if len(app.Config.ProfileFields) > 0 {
+		routes = append(routes,
+			route.Get("/account/profile").
+				SecuredWith(originSecurity).
+				Handle(getCurrentAccountProfile(app)),
+
+			route.Patch("/account/profile").
+				SecuredWith(originSecurity).
+				Handle(patchCurrentAccountProfile(app)),
+		)
+	}
+
+	routes = append(routes,
+		route.Get("/takeout").
+			SecuredWith(route.Unsecured()).
+			Handle(getTakeout(app)),
+
+		route.Get("/links").
+			SecuredWith(route.Unsecured()).
+			Handle(getLink(app)),
+	)
+
 	return routes
 }
 
 func Routes(app *api.App) []*route.HandledRoute {
-	authentication := route.BasicAuthSecurity(app.Config.AuthUsername, app.Config.AuthPassword, "Private AuthN Realm")
+	readOnly := api.PrivateSecurity(app, models.ScopeReadOnly)
+	accountAdmin := api.PrivateSecurity(app, models.ScopeAccountAdmin)
+
+	// POST /accounts is signup for browsers and provisioning for backoffice systems. Requests with
+	// Basic Auth credentials are provisioning.
+	signupSecurity := accountAdmin
+	if app.Config.EnableSignup {
+		signupSecurity = route.OriginSecurity(app.Config.ApplicationDomains)
+	}
+	routes := []*route.HandledRoute{
+		route.Post("/accounts").
+			SecuredWith(func(h http.Handler) http.Handler {
+				return byCredentials(accountAdmin(h), signupSecurity(h))
+			}).
+			Handle(byCredentials(postAccounts(app), postAccount(app))),
+	}
 
-	routes := PublicRoutes(app)
+	routes = append(routes, publicRoutes(app)...)
 
 	routes = append(routes,
 		route.Post("/accounts/import").
-			SecuredWith(authentication).
+			SecuredWith(accountAdmin).
 			Handle(postAccountsImport(app)),
 
+		route.Post("/accounts/bulk").
+			SecuredWith(accountAdmin).
+			Handle(postAccountsBulk(app)),
+
+		route.Get("/accounts").
+			SecuredWith(readOnly).
+			Handle(getAccounts(app)),
+
 		route.Get("/accounts/{id:[0-9]+}").
-			SecuredWith(authentication).
+			SecuredWith(readOnly).
 			Handle(getAccount(app)),
 
 		route.Patch("/accounts/{id:[0-9]+}").
-			SecuredWith(authentication).
-			Handle(patchAccount(app)),
+			SecuredWith(accountAdmin).
+			Handle(ifMatch(app, patchAccount(app))),
 
 		route.Patch("/accounts/{id:[0-9]+}/lock").
-			SecuredWith(authentication).
-			Handle(patchAccountLock(app)),
+			SecuredWith(accountAdmin).
+			Handle(ifMatch(app, patchAccountLock(app))),
+
+		route.Patch("/accounts/{id:[0-9]+}/expiry").
+			SecuredWith(accountAdmin).
+			Handle(ifMatch(app, patchAccountExpiry(app))),
 
 		route.Patch("/accounts/{id:[0-9]+}/unlock").
-			SecuredWith(authentication).
-			Handle(patchAccountUnlock(app)),
+			SecuredWith(accountAdmin).
+			Handle(ifMatch(app, patchAccountUnlock(app))),
+
+		route.Patch("/accounts/{id:[0-9]+}/unthrottle").
+			SecuredWith(accountAdmin).
+			Handle(ifMatch(app, patchAccountUnthrottle(app))),
 
 		route.Patch("/accounts/{id:[0-9]+}/expire_password").
-			SecuredWith(authentication).
-			Handle(patchAccountExpirePassword(app)),
+			SecuredWith(accountAdmin).
+			Handle(ifMatch(app, patchAccountExpirePassword(app))),
+
+		route.Patch("/accounts/{id:[0-9]+}/reset_password").
+			SecuredWith(accountAdmin).
+			Handle(ifMatch(app, patchAccountResetPassword(app))),
+
+		route.Patch("/accounts/{id:[0-9]+}/legal_hold").
+			SecuredWith(accountAdmin).
+			Handle(ifMatch(app, patchAccountLegalHold(app))),
+
+		route.Delete("/accounts/{id:[0-9]+}/legal_hold").
+			SecuredWith(accountAdmin).
+			Handle(ifMatch(app, deleteAccountLegalHold(app))),
 
 		route.Delete("/accounts/{id:[0-9]+}").
-			SecuredWith(authentication).
-			Handle(deleteAccount(app)),
+			SecuredWith(accountAdmin).
+			Handle(ifMatch(app, deleteAccount(app))),
+
+		route.Get("/accounts/{id:[0-9]+}/tags").
+			SecuredWith(readOnly).
+			Handle(getAccountTags(app)),
+
+		route.Post("/accounts/{id:[0-9]+}/tags").
+			SecuredWith(accountAdmin).
+			Handle(postAccountTag(app)),
+
+		route.Delete("/accounts/{id:[0-9]+}/tags/{tag}").
+			SecuredWith(accountAdmin).
+			Handle(deleteAccountTag(app)),
+
+		route.Get("/accounts/{id:[0-9]+}/metadata").
+			SecuredWith(readOnly).
+			Handle(getAccountMetadata(app)),
+
+		route.Get("/accounts/{id:[0-9]+}/consents").
+			SecuredWith(readOnly).
+			Handle(getAccountConsents(app)),
+
+		route.Get("/accounts/{id:[0-9]+}/sessions").
+			SecuredWith(readOnly).
+			Handle(getAccountSessions(app)),
+
+		route.Post("/accounts/{id:[0-9]+}/takeout").
+			SecuredWith(accountAdmin).
+			Handle(postAccountTakeout(app)),
+
+		route.Post("/accounts/{id:[0-9]+}/links").
+			SecuredWith(accountAdmin).
+			Handle(postAccountLink(app)),
+
+		route.Get("/accounts/{id:[0-9]+}/usernames").
+			SecuredWith(readOnly).
+			Handle(getAccountUsernames(app)),
+
+		route.Get("/accounts/{id:[0-9]+}/notes").
+			SecuredWith(readOnly).
+			Handle(getAccountNotes(app)),
+
+		route.Post("/accounts/{id:[0-9]+}/notes").
+			SecuredWith(accountAdmin).
+			Handle(postAccountNote(app)),
+
+		route.Delete("/accounts/{id:[0-9]+}/notes/{note_id:[0-9]+}").
+			SecuredWith(accountAdmin).
+			Handle(deleteAccountNote(app)),
 	)
 
+	if app.Config.AccountRestoreWindow > 0 {
+		routes = append(routes,
+			route.Patch("/accounts/{id:[0-9]+}/restore").
+				SecuredWith(accountAdmin).
+				Handle(ifMatch(app, patchAccountRestore(app))),
+		)
+	}
+
+	if app.Config.AuditLog {
+		routes = append(routes,
+			route.Get("/accounts/{id:[0-9]+}/audit").
+				SecuredWith(readOnly).
+				Handle(getAccountAudit(app)),
+		)
+	}
+
 	return routes
 }
+
+// byCredentials serves requests that carry Basic Auth credentials with the private handler, and
+// all others with the public handler.
+func byCredentials(private http.Handler, public http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); ok {
+			private.ServeHTTP(w, r)
+		} else {
+			public.ServeHTTP(w, r)
+		}
+	})
+}
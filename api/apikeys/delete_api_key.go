@@ -0,0 +1,30 @@
+package apikeys
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+)
+
+func deleteAPIKey(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "api_key")
+			return
+		}
+
+		revoked, err := app.APIKeyStore.Revoke(id)
+		if err != nil {
+			panic(err)
+		}
+		if !revoked {
+			api.WriteNotFound(w, "api_key")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
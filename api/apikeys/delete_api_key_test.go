@@ -0,0 +1,45 @@
+package apikeys_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/apikeys"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteAPIKey(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, apikeys.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("unknown key", func(t *testing.T) {
+		res, err := client.Delete("/api_keys/999")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("active key", func(t *testing.T) {
+		key, secret, err := services.APIKeyCreator(app.APIKeyStore, "billing", []string{"admin"})
+		require.NoError(t, err)
+
+		res, err := client.Delete(fmt.Sprintf("/api_keys/%v", key.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		res, err = route.NewClient(server.URL).Authenticated(key.Username(), secret).Get("/api_keys")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		res, err = client.Delete(fmt.Sprintf("/api_keys/%v", key.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
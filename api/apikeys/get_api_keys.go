@@ -0,0 +1,23 @@
+package apikeys
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+)
+
+func getAPIKeys(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := app.APIKeyStore.FindAll()
+		if err != nil {
+			panic(err)
+		}
+
+		result := []map[string]interface{}{}
+		for _, key := range keys {
+			result = append(result, apiKeyData(key))
+		}
+
+		api.WriteData(w, http.StatusOK, result)
+	}
+}
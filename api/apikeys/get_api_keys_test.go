@@ -0,0 +1,34 @@
+package apikeys_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/apikeys"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAPIKeys(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, apikeys.Routes(app))
+	defer server.Close()
+
+	key, _, err := services.APIKeyCreator(app.APIKeyStore, "billing", []string{"stats"})
+	require.NoError(t, err)
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+	res, err := client.Get("/api_keys")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	body := string(test.ReadBody(res))
+	assert.Contains(t, body, `"name":"billing"`)
+	assert.Contains(t, body, `"username":"key_1"`)
+	assert.Contains(t, body, `"scopes":["stats"]`)
+	assert.NotContains(t, body, key.SecretHash)
+	assert.NotContains(t, body, "password")
+}
@@ -0,0 +1,31 @@
+package apikeys
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+)
+
+func postAPIKey(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scopes := strings.FieldsFunc(r.FormValue("scopes"), func(c rune) bool {
+			return c == ' ' || c == ','
+		})
+
+		key, secret, err := services.APIKeyCreator(app.APIKeyStore, r.FormValue("name"), scopes)
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		data := apiKeyData(key)
+		data["password"] = secret
+		api.WriteData(w, http.StatusCreated, data)
+	}
+}
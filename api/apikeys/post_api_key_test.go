@@ -0,0 +1,80 @@
+package apikeys_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/apikeys"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAPIKey(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, append(apikeys.Routes(app), accounts.Routes(app)...))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("valid key", func(t *testing.T) {
+		res, err := client.PostForm("/api_keys", url.Values{
+			"name":   []string{"billing"},
+			"scopes": []string{"read-only, stats"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+
+		var body struct {
+			Result struct {
+				ID       int      `json:"id"`
+				Name     string   `json:"name"`
+				Username string   `json:"username"`
+				Password string   `json:"password"`
+				Scopes   []string `json:"scopes"`
+			} `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(test.ReadBody(res), &body))
+		assert.Equal(t, "billing", body.Result.Name)
+		assert.Equal(t, []string{"read-only", "stats"}, body.Result.Scopes)
+		assert.NotEmpty(t, body.Result.Password)
+
+		key, err := app.APIKeyStore.Find(body.Result.ID)
+		require.NoError(t, err)
+		assert.NotEqual(t, body.Result.Password, key.SecretHash)
+
+		account, err := app.AccountStore.Create("existing@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		keyClient := route.NewClient(server.URL).Authenticated(body.Result.Username, body.Result.Password)
+		res, err = keyClient.Get(fmt.Sprintf("/accounts/%v", account.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		res, err = keyClient.PostForm("/accounts/import", url.Values{
+			"username": []string{"imported@test.com"},
+			"password": []string{"secret"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+		res, err = keyClient.Get("/api_keys")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		res, err := client.PostForm("/api_keys", url.Values{
+			"scopes": []string{"root"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"name", "MISSING"}, {"scopes", "FORMAT_INVALID"}})
+	})
+}
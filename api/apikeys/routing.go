@@ -0,0 +1,37 @@
+package apikeys
+
+import (
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
+)
+
+func Routes(app *api.App) []*route.HandledRoute {
+	admin := api.PrivateSecurity(app, models.ScopeAdmin)
+
+	return []*route.HandledRoute{
+		route.Get("/api_keys").
+			SecuredWith(admin).
+			Handle(getAPIKeys(app)),
+
+		route.Post("/api_keys").
+			SecuredWith(admin).
+			Handle(postAPIKey(app)),
+
+		route.Delete("/api_keys/{id:[0-9]+}").
+			SecuredWith(admin).
+			Handle(deleteAPIKey(app)),
+	}
+}
+
+// apiKeyData never includes the secret hash.
+func apiKeyData(key *models.APIKey) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         key.ID,
+		"name":       key.Name,
+		"username":   key.Username(),
+		"scopes":     key.ScopeList(),
+		"created_at": key.CreatedAt,
+		"revoked_at": key.RevokedAt,
+	}
+}
@@ -1,66 +1,184 @@
 package api
 
 import (
+	"context"
 	"os"
+	"time"
 
 	"github.com/go-redis/redis"
+	"github.com/jmoiron/sqlx"
 	"github.com/keratin/authn-server/config"
 	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/jobs"
 	"github.com/keratin/authn-server/lib/oauth"
 	"github.com/keratin/authn-server/ops"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/dpop"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	"github.com/keratin/authn-server/data/dynamodb"
+	"github.com/keratin/authn-server/data/mongodb"
 	dataRedis "github.com/keratin/authn-server/data/redis"
 )
 
 type pinger func() bool
 
 type App struct {
-	DbCheck           pinger
-	RedisCheck        pinger
-	Config            *config.Config
-	AccountStore      data.AccountStore
-	RefreshTokenStore data.RefreshTokenStore
-	KeyStore          data.KeyStore
-	Actives           data.Actives
-	Reporter          ops.ErrorReporter
-	OauthProviders    map[string]oauth.Provider
+	DbCheck            pinger
+	RedisCheck         pinger
+	Config             *config.Config
+	AccountStore       data.AccountStore
+	RefreshTokenStore  data.RefreshTokenStore
+	AnnotationStore    data.AnnotationStore
+	ConsentStore       data.ConsentStore
+	APIKeyStore        data.APIKeyStore
+	OutboxStore        data.OutboxStore
+	WebhookSecretStore data.WebhookSecretStore
+	KeyStore           data.KeyStore
+	AccessTokenStore   data.AccessTokenStore
+	Actives            data.Actives
+	Signups            data.Signups
+	Quotas             data.Quotas
+	AuditLog           data.AuditLog
+	ModeStore          data.ModeStore
+	DPoPProofs         dpop.Proofs
+	Reporter           ops.ErrorReporter
+	OauthProviders     map[string]oauth.Provider
+	Events             *events.Emitter
+	Broker             *events.Broker
+	Hooks              *Hooks
 }
 
-func NewApp() (*App, error) {
-	cfg := config.ReadEnv()
-
+func NewApp(cfg *config.Config) (*App, error) {
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	logrus.SetLevel(logrus.InfoLevel)
+	logrus.SetLevel(cfg.LogLevel)
 	logrus.SetOutput(os.Stdout)
 
-	db, err := data.NewDB(cfg.DatabaseURL)
-	if err != nil {
-		return nil, errors.Wrap(err, "data.NewDB")
+	var db *sqlx.DB
+	var ddb *dynamodb.DB
+	var dbCheck pinger
+	var accountStore data.AccountStore
+	var annotationStore data.AnnotationStore
+	var consentStore data.ConsentStore
+	var apiKeyStore data.APIKeyStore
+	var err error
+	if cfg.DatabaseURL.Scheme == "mongodb" {
+		// MongoDB only holds accounts and what is known about them. Tokens and keys need Redis.
+		if cfg.RedisURL == nil {
+			return nil, errors.New("MongoDB requires REDIS_URL")
+		}
+		if cfg.EnableOutbox {
+			return nil, errors.New("ENABLE_OUTBOX requires a SQL database")
+		}
+		mdb, err := mongodb.NewDB(cfg.DatabaseURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "mongodb.NewDB")
+		}
+		dbCheck = func() bool { return mdb.Client().Ping(context.Background(), nil) == nil }
+		accountStore = &mongodb.AccountStore{Database: mdb}
+		annotationStore = &mongodb.AnnotationStore{Database: mdb}
+		consentStore = &mongodb.ConsentStore{Database: mdb}
+		apiKeyStore = &mongodb.APIKeyStore{Database: mdb}
+	} else if cfg.DatabaseURL.Scheme == "dynamodb" {
+		// DynamoDB also holds tokens and keys, unless Redis is configured.
+		if cfg.EnableOutbox {
+			return nil, errors.New("ENABLE_OUTBOX requires a SQL database")
+		}
+		ddb, err = dynamodb.NewDB(cfg.DatabaseURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "dynamodb.NewDB")
+		}
+		dbCheck = func() bool { return ddb.Ping() == nil }
+		accountStore = &dynamodb.AccountStore{DB: ddb}
+		annotationStore = &dynamodb.AnnotationStore{DB: ddb}
+		consentStore = &dynamodb.ConsentStore{DB: ddb}
+		apiKeyStore = &dynamodb.APIKeyStore{DB: ddb}
+	} else {
+		db, err = data.NewDB(cfg.DatabaseURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "data.NewDB")
+		}
+		dbCheck = func() bool { return db.Ping() == nil }
+		accountStore, err = data.NewAccountStore(db)
+		if err != nil {
+			return nil, errors.Wrap(err, "NewAccountStore")
+		}
+		annotationStore, err = data.NewAnnotationStore(db)
+		if err != nil {
+			return nil, errors.Wrap(err, "NewAnnotationStore")
+		}
+		consentStore, err = data.NewConsentStore(db)
+		if err != nil {
+			return nil, errors.Wrap(err, "NewConsentStore")
+		}
+		apiKeyStore, err = data.NewAPIKeyStore(db)
+		if err != nil {
+			return nil, errors.Wrap(err, "NewAPIKeyStore")
+		}
 	}
 
 	var redis *redis.Client
+	var locker jobs.Locker
 	if cfg.RedisURL != nil {
-		redis, err = dataRedis.New(cfg.RedisURL)
+		redis, err = dataRedis.New(cfg.RedisURL, cfg.RedisBreaker, cfg.RedisKeyPrefix)
 		if err != nil {
 			return nil, errors.Wrap(err, "redis.New")
 		}
+		locker = &dataRedis.Locker{Client: redis}
+	} else if ddb != nil {
+		locker = &dynamodb.Locker{DB: ddb}
 	}
 
-	accountStore, err := data.NewAccountStore(db)
-	if err != nil {
-		return nil, errors.Wrap(err, "NewAccountStore")
+	var stream *dataRedis.Stream
+	if cfg.RedisStreams {
+		stream = &dataRedis.Stream{Client: redis, Name: cfg.InstanceName}
 	}
 
-	tokenStore, err := data.NewRefreshTokenStore(db, redis, cfg.ErrorReporter, cfg.RefreshTokenTTL)
-	if err != nil {
-		return nil, errors.Wrap(err, "NewRefreshTokenStore")
+	scheduler := jobs.NewScheduler(locker, cfg.ErrorReporter)
+
+	accountStore = data.NewBreakerAccountStore(accountStore, cfg.DatabaseBreaker)
+	if cfg.AccountCacheTTL > 0 {
+		cachedStore := data.NewCachedAccountStore(accountStore, cfg.AccountCacheTTL)
+		if changes := data.NewAccountChanges(db, redis, cfg.RedisKeyPrefix, stream); changes != nil {
+			cachedStore.Listen(changes, cfg.ErrorReporter)
+		}
+		accountStore = cachedStore
 	}
+	if cfg.AccountRestoreWindow > 0 {
+		accountStore = data.NewRestorableAccountStore(accountStore, cfg.DBEncryptionKey)
+	}
+
+	if cfg.FieldKeyring != nil {
+		annotationStore = data.NewEncryptedAnnotationStore(annotationStore, cfg.FieldKeyring)
+	}
+
+	var tokenStore data.RefreshTokenStore
+	var blobStore data.BlobStore
+	if redis == nil && ddb != nil {
+		tokenStore = &dynamodb.RefreshTokenStore{DB: ddb, TTL: cfg.RefreshTokenTTL}
+		blobStore = data.NewDynamoDBBlobStore(cfg.AccessTokenTTL, ddb)
+	} else {
+		tokenStore, err = data.NewRefreshTokenStore(db, redis, scheduler, cfg.RefreshTokenTTL)
+		if err != nil {
+			return nil, errors.Wrap(err, "NewRefreshTokenStore")
+		}
+
+		replicas := []data.TokenReplica{}
+		for _, replicaURL := range cfg.SessionReplicaURLs {
+			client, err := dataRedis.New(replicaURL, nil, cfg.RedisKeyPrefix)
+			if err != nil {
+				return nil, errors.Wrap(err, "redis.New")
+			}
+			replicas = append(replicas, &dataRedis.RefreshTokenStore{Client: client, TTL: cfg.RefreshTokenTTL})
+		}
+		tokenStore = data.NewReplicatedRefreshTokenStore(tokenStore, cfg.RefreshTokenTTL, cfg.ErrorReporter, replicas...)
 
-	blobStore, err := data.NewBlobStore(cfg.AccessTokenTTL, redis, db, cfg.ErrorReporter)
-	if err != nil {
-		return nil, errors.Wrap(err, "NewBlobStore")
+		blobStore, err = data.NewBlobStore(cfg.AccessTokenTTL, redis, db, scheduler)
+		if err != nil {
+			return nil, errors.Wrap(err, "NewBlobStore")
+		}
 	}
 
 	keyStore := data.NewRotatingKeyStore()
@@ -69,7 +187,10 @@ func NewApp() (*App, error) {
 			data.NewEncryptedBlobStore(blobStore, cfg.DBEncryptionKey),
 			cfg.AccessTokenTTL,
 		)
-		err := m.Maintain(keyStore, cfg.ErrorReporter)
+		m.Alerts = cfg.Alerts
+		m.Stream = stream
+		m.Reporter = cfg.ErrorReporter
+		err := m.Maintain(keyStore, scheduler)
 		if err != nil {
 			return nil, errors.Wrap(err, "Maintain")
 		}
@@ -78,6 +199,9 @@ func NewApp() (*App, error) {
 	}
 
 	var actives data.Actives
+	var signups data.Signups
+	var quotas data.Quotas
+	var accessTokenStore data.AccessTokenStore
 	if redis != nil {
 		actives = dataRedis.NewActives(
 			redis,
@@ -86,6 +210,23 @@ func NewApp() (*App, error) {
 			cfg.WeeklyActivesRetention,
 			5*12,
 		)
+		signups = dataRedis.NewSignups(
+			redis,
+			cfg.StatisticsTimeZone,
+			cfg.DailyActivesRetention,
+			cfg.WeeklyActivesRetention,
+			5*12,
+		)
+		quotas = dataRedis.NewQuotas(redis)
+		accessTokenStore = &dataRedis.AccessTokenStore{Client: redis}
+	}
+	dpopProofs := data.NewDPoPProofs(redis)
+
+	publishers := cfg.EventPublishers
+	var auditLog data.AuditLog
+	if cfg.AuditLog {
+		auditLog = dataRedis.NewAuditLog(redis, cfg.AuditSigningKey)
+		publishers = append(publishers, &data.AuditPublisher{Log: auditLog})
 	}
 
 	oauthProviders := map[string]oauth.Provider{}
@@ -98,16 +239,172 @@ func NewApp() (*App, error) {
 	if cfg.FacebookOauthCredentials != nil {
 		oauthProviders["facebook"] = *oauth.NewFacebookProvider(cfg.FacebookOauthCredentials)
 	}
+	if cfg.MicrosoftOauthCredentials != nil {
+		oauthProviders["microsoft"] = *oauth.NewMicrosoftProvider(cfg.MicrosoftOauthCredentials, cfg.MicrosoftOauthTenant)
+	}
+	if cfg.DiscordOauthCredentials != nil {
+		oauthProviders["discord"] = *oauth.NewDiscordProvider(cfg.DiscordOauthCredentials)
+	}
+	if cfg.TwitterOauthCredentials != nil {
+		oauthProviders["twitter"] = *oauth.NewTwitterProvider(cfg.TwitterOauthCredentials)
+	}
+	if cfg.LinkedInOauthCredentials != nil {
+		oauthProviders["linkedin"] = *oauth.NewLinkedInProvider(cfg.LinkedInOauthCredentials)
+	}
+	for name, settings := range cfg.OauthProviderSettings {
+		provider, err := oauth.NewGenericProvider(settings)
+		if err != nil {
+			return nil, errors.Wrapf(err, "oauth.NewGenericProvider(%s)", name)
+		}
+		oauthProviders[name] = *provider
+	}
+
+	var outboxStore data.OutboxStore
+	var webhookSecretStore data.WebhookSecretStore
+	var emitter *events.Emitter
+	if cfg.EnableOutbox {
+		outboxStore, err = data.NewOutboxStore(db)
+		if err != nil {
+			return nil, errors.Wrap(err, "NewOutboxStore")
+		}
+		webhookSecretStore, err = data.NewWebhookSecretStore(db)
+		if err != nil {
+			return nil, errors.Wrap(err, "NewWebhookSecretStore")
+		}
+		if len(publishers) > 0 {
+			emitter = events.NewOutboxEmitter(cfg.ErrorReporter, &data.EventOutbox{Store: outboxStore})
+		}
+
+		scheduler.Add(jobs.Job{
+			Name:      "outbox:dispatch",
+			Interval:  time.Second,
+			Exclusive: true,
+			Run: func() error {
+				_, err := services.OutboxDispatcher(outboxStore, webhookSecretStore, cfg, publishers, time.Now())
+				return err
+			},
+		})
+		scheduler.Add(jobs.Job{
+			Name:      "outbox:prune",
+			Interval:  time.Hour,
+			Exclusive: true,
+			Run: func() error {
+				_, err := outboxStore.Prune(time.Now().Add(-cfg.OutboxRetention))
+				return err
+			},
+		})
+	} else {
+		emitter = events.NewEmitter(cfg.ErrorReporter, publishers...)
+	}
+
+	// changes of mode are heard at once through the stream, or else every few seconds
+	modeStore := data.NewModeStore(redis, 5*time.Second)
+	if stream != nil {
+		modeStore = data.NewStreamModeStore(stream, time.Minute, cfg.ErrorReporter)
+	}
+
+	// events are shared with every server's broker through the stream, or else only with this one
+	var broker *events.Broker
+	if cfg.SessionEvents {
+		broker = events.NewBroker()
+		if stream != nil {
+			shared := &data.StreamEvents{Stream: stream}
+			shared.Listen(broker, cfg.ErrorReporter)
+			emitter = emitter.Notify(cfg.ErrorReporter, shared)
+		} else {
+			emitter = emitter.Notify(cfg.ErrorReporter, broker)
+		}
+	}
+
+	if cfg.EnableAccountDeletion {
+		scheduler.Add(jobs.Job{
+			Name:      "accounts:archive",
+			Interval:  time.Minute,
+			Exclusive: true,
+			Run: func() error {
+				archived, err := services.ScheduledArchiver(accountStore, tokenStore, time.Now())
+				for _, id := range archived {
+					emitter.Emit(events.AccountArchived, id)
+				}
+				return err
+			},
+		})
+	}
+
+	if cfg.AccountRestoreWindow > 0 {
+		scheduler.Add(jobs.Job{
+			Name:      "accounts:forget_archived_usernames",
+			Interval:  time.Hour,
+			Exclusive: true,
+			Run: func() error {
+				_, err := accountStore.PurgeArchivedUsernames(time.Now().Add(-cfg.AccountRestoreWindow))
+				return err
+			},
+		})
+	}
+
+	if cfg.AuditLog && cfg.AuditLogRetention > 0 {
+		scheduler.Add(jobs.Job{
+			Name:      "audit:prune",
+			Interval:  time.Hour,
+			Exclusive: true,
+			Run: func() error {
+				_, err := services.AuditLogPruner(auditLog, accountStore, cfg.AuditLogArchive, time.Now().Add(-cfg.AuditLogRetention))
+				return err
+			},
+		})
+	}
+
+	scheduler.Add(jobs.Job{
+		Name:      "accounts:expire",
+		Interval:  time.Minute,
+		Exclusive: true,
+		Run: func() error {
+			archived, err := services.ExpiredArchiver(accountStore, tokenStore, time.Now())
+			for _, id := range archived {
+				emitter.Emit(events.AccountArchived, id)
+			}
+			return err
+		},
+	})
+
+	redisCheck := func() bool { return redis != nil && redis.Ping().Err() == nil }
+	if cfg.LoadShedder != nil {
+		scheduler.Add(jobs.Job{
+			Name:     "health:probe",
+			Interval: time.Second,
+			Run: func() error {
+				cfg.LoadShedder.Probe("db", dbCheck)
+				cfg.LoadShedder.Probe("redis", redisCheck)
+				return nil
+			},
+		})
+	}
+
+	scheduler.Start()
 
 	return &App{
-		DbCheck:           func() bool { return db.Ping() == nil },
-		RedisCheck:        func() bool { return redis != nil && redis.Ping().Err() == nil },
-		Config:            cfg,
-		AccountStore:      accountStore,
-		RefreshTokenStore: tokenStore,
-		KeyStore:          keyStore,
-		Actives:           actives,
-		Reporter:          cfg.ErrorReporter,
-		OauthProviders:    oauthProviders,
+		DbCheck:            dbCheck,
+		RedisCheck:         redisCheck,
+		Config:             cfg,
+		AccountStore:       accountStore,
+		RefreshTokenStore:  tokenStore,
+		AnnotationStore:    annotationStore,
+		ConsentStore:       consentStore,
+		APIKeyStore:        apiKeyStore,
+		OutboxStore:        outboxStore,
+		WebhookSecretStore: webhookSecretStore,
+		KeyStore:           keyStore,
+		AccessTokenStore:   accessTokenStore,
+		Actives:            actives,
+		Signups:            signups,
+		Quotas:             quotas,
+		AuditLog:           auditLog,
+		ModeStore:          modeStore,
+		DPoPProofs:         dpopProofs,
+		Reporter:           cfg.ErrorReporter,
+		OauthProviders:     oauthProviders,
+		Events:             emitter,
+		Broker:             broker,
 	}, nil
 }
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/keratin/authn-server/services"
+)
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// BodyLimit rejects requests with a body larger than max bytes with a 413. Form bodies are parsed
+// here so that a request without a Content-Length is rejected as well, rather than reaching a
+// handler with its form values cut off.
+func BodyLimit(max int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > max {
+				writeTooLarge(w)
+				return
+			}
+
+			body := &limitedBody{ReadCloser: r.Body, remaining: max}
+			r.Body = body
+
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if mediaType == "application/x-www-form-urlencoded" {
+				r.ParseForm()
+				if body.exceeded {
+					writeTooLarge(w)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeTooLarge(w http.ResponseWriter) {
+	w.Header().Set("Connection", "close")
+	WriteJSON(w, http.StatusRequestEntityTooLarge, ServiceErrors{Errors: services.FieldErrors{{"body", services.ErrTooLarge}}})
+}
+
+// limitedBody reads at most the remaining bytes, and remembers whether the client sent more.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	exceeded  bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		b.exceeded = true
+		n = int(b.remaining)
+		b.remaining = 0
+		return n, errBodyTooLarge
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
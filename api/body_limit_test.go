@@ -0,0 +1,68 @@
+package api_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyLimit(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") == "" {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write(body)
+			return
+		}
+		w.Write([]byte(r.FormValue("username")))
+	})
+	handler := api.BodyLimit(20)(echo)
+
+	t.Run("small form", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/", strings.NewReader("username=me"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "me", res.Body.String())
+	})
+
+	t.Run("large form", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/", strings.NewReader("username="+strings.Repeat("x", 100)))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+		assert.Contains(t, res.Body.String(), "TOO_LARGE")
+	})
+
+	t.Run("large form without length", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/", strings.NewReader("username="+strings.Repeat("x", 100)))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.ContentLength = -1
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	})
+
+	t.Run("large body without length", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("x", 100)))
+		req.ContentLength = -1
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		require.Equal(t, http.StatusBadRequest, res.Code)
+	})
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib"
+)
+
+type budgetKey int
+
+// Budget gives each request a budget of REQUEST_BUDGET, shared by every store call that it makes.
+func Budget(app *App) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			budget := lib.NewBudget(app.Config.RequestBudget)
+			if budget == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), budgetKey(0), budget)))
+		})
+	}
+}
+
+// GetBudget returns the request's budget, or nil.
+func GetBudget(r *http.Request) *lib.Budget {
+	budget, _ := r.Context().Value(budgetKey(0)).(*lib.Budget)
+	return budget
+}
+
+// Accounts returns the AccountStore, charged to the request's budget.
+func (app *App) Accounts(r *http.Request) data.AccountStore {
+	return data.NewBudgetAccountStore(app.AccountStore, GetBudget(r))
+}
+
+// RefreshTokens returns the RefreshTokenStore, charged to the request's budget.
+func (app *App) RefreshTokens(r *http.Request) data.RefreshTokenStore {
+	return data.NewBudgetRefreshTokenStore(app.RefreshTokenStore, GetBudget(r))
+}
@@ -0,0 +1,32 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudget(t *testing.T) {
+	app := test.App()
+	var budgeted bool
+	handler := api.Budget(app)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, budgeted = app.Accounts(r).(*data.BudgetAccountStore)
+	}))
+
+	t.Run("without REQUEST_BUDGET", func(t *testing.T) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		assert.False(t, budgeted)
+	})
+
+	t.Run("with REQUEST_BUDGET", func(t *testing.T) {
+		app.Config.RequestBudget = time.Second
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		assert.True(t, budgeted)
+	})
+}
@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+type accountResolver struct {
+	app     *api.App
+	account *models.Account
+}
+
+func (r *accountResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(r.account.ID))
+}
+
+func (r *accountResolver) Username() string {
+	return r.account.Username
+}
+
+func (r *accountResolver) Locked() bool {
+	return r.account.Locked
+}
+
+func (r *accountResolver) ThrottledUntil() *string {
+	if !r.account.Throttled() {
+		return nil
+	}
+	until := r.account.ThrottledUntil.UTC().Format(time.RFC3339)
+	return &until
+}
+
+func (r *accountResolver) LegalHold() bool {
+	return r.account.LegalHold
+}
+
+func (r *accountResolver) Deleted() bool {
+	return r.account.Archived()
+}
+
+func (r *accountResolver) RequireNewPassword() bool {
+	return r.account.RequireNewPassword
+}
+
+func (r *accountResolver) PasswordChangedAt() string {
+	return r.account.PasswordChangedAt.UTC().Format(time.RFC3339)
+}
+
+func (r *accountResolver) CreatedAt() string {
+	return r.account.CreatedAt.UTC().Format(time.RFC3339)
+}
+
+func (r *accountResolver) Sessions() ([]*sessionResolver, error) {
+	tokens, err := r.app.RefreshTokenStore.FindAll(r.account.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "FindAll")
+	}
+
+	sessions := make([]*sessionResolver, 0, len(tokens))
+	for _, token := range tokens {
+		sessions = append(sessions, &sessionResolver{token})
+	}
+	return sessions, nil
+}
+
+func (r *accountResolver) AuditLog(args struct {
+	First int32
+	After *string
+}) (*auditConnectionResolver, error) {
+	return findAuditLog(r.app, r.account.ID, args.First, args.After)
+}
+
+type sessionResolver struct {
+	token models.RefreshToken
+}
+
+// ID identifies a session without revealing its refresh token, which is a bearer credential.
+func (r *sessionResolver) ID() graphql.ID {
+	sum := sha256.Sum256([]byte(r.token))
+	return graphql.ID(hex.EncodeToString(sum[:]))
+}
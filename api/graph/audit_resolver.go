@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"strconv"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+const maxPageSize = 100
+
+// findAuditLog fetches one page of audit events. One extra entry is requested to learn whether
+// another page exists.
+func findAuditLog(app *api.App, accountID int, first int32, after *string) (*auditConnectionResolver, error) {
+	if app.AuditLog == nil {
+		return nil, errors.New("audit log is not enabled")
+	}
+
+	limit := int(first)
+	if limit < 0 || limit > maxPageSize {
+		return nil, errors.Errorf("first must be between 0 and %d", maxPageSize)
+	}
+
+	cursor := ""
+	if after != nil {
+		cursor = *after
+	}
+
+	entries, err := app.AuditLog.Find(accountID, cursor, limit+1)
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+
+	conn := &auditConnectionResolver{}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		conn.hasNextPage = true
+	}
+	conn.entries = entries
+	return conn, nil
+}
+
+type auditConnectionResolver struct {
+	entries     []models.AuditEntry
+	hasNextPage bool
+}
+
+func (r *auditConnectionResolver) Edges() []*auditEdgeResolver {
+	edges := make([]*auditEdgeResolver, 0, len(r.entries))
+	for _, entry := range r.entries {
+		edges = append(edges, &auditEdgeResolver{entry})
+	}
+	return edges
+}
+
+func (r *auditConnectionResolver) PageInfo() *pageInfoResolver {
+	info := &pageInfoResolver{hasNextPage: r.hasNextPage}
+	if len(r.entries) > 0 {
+		info.endCursor = &r.entries[len(r.entries)-1].Cursor
+	}
+	return info
+}
+
+type auditEdgeResolver struct {
+	entry models.AuditEntry
+}
+
+func (r *auditEdgeResolver) Cursor() string {
+	return r.entry.Cursor
+}
+
+func (r *auditEdgeResolver) Node() *auditEventResolver {
+	return &auditEventResolver{r.entry}
+}
+
+type auditEventResolver struct {
+	entry models.AuditEntry
+}
+
+func (r *auditEventResolver) ID() graphql.ID {
+	return graphql.ID(r.entry.Event.ID)
+}
+
+func (r *auditEventResolver) Type() string {
+	return r.entry.Event.Type
+}
+
+func (r *auditEventResolver) AccountID() graphql.ID {
+	return graphql.ID(strconv.Itoa(r.entry.Event.AccountID))
+}
+
+func (r *auditEventResolver) Time() string {
+	return r.entry.Event.Time.UTC().Format(time.RFC3339)
+}
+
+type pageInfoResolver struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	return r.endCursor
+}
@@ -0,0 +1,109 @@
+package graph_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/graph"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []interface{}          `json:"errors"`
+}
+
+func TestGraphQL(t *testing.T) {
+	app := test.App()
+	app.Config.EnableGraphQL = true
+	app.Config.AuthUsername = "username"
+	app.Config.AuthPassword = "password"
+	app.AuditLog = mock.NewAuditLog(app.Config.AuditSigningKey)
+	server := test.Server(app, graph.Routes(app))
+	defer server.Close()
+
+	query := func(q string) response {
+		body, err := json.Marshal(map[string]string{"query": q})
+		require.NoError(t, err)
+		req, err := http.NewRequest("POST", server.URL+"/graphql", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth("username", "password")
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		r := response{}
+		err = json.Unmarshal(test.ReadBody(res), &r)
+		require.NoError(t, err)
+		return r
+	}
+
+	t.Run("without credentials", func(t *testing.T) {
+		res, err := http.Post(server.URL+"/graphql", "application/json", bytes.NewReader([]byte(`{"query":"{}"}`)))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("account query", func(t *testing.T) {
+		account, err := app.AccountStore.Create("query@test.com", []byte("bar"))
+		require.NoError(t, err)
+		_, err = app.RefreshTokenStore.Create(account.ID)
+		require.NoError(t, err)
+
+		res := query(fmt.Sprintf(`{ account(id: "%d") { username locked sessions { id } } }`, account.ID))
+		assert.Empty(t, res.Errors)
+		found := res.Data["account"].(map[string]interface{})
+		assert.Equal(t, "query@test.com", found["username"])
+		assert.Equal(t, false, found["locked"])
+		assert.Len(t, found["sessions"], 1)
+
+		res = query(`{ account(id: "999999") { username } }`)
+		assert.Empty(t, res.Errors)
+		assert.Nil(t, res.Data["account"])
+	})
+
+	t.Run("lock mutation", func(t *testing.T) {
+		account, err := app.AccountStore.Create("lock@test.com", []byte("bar"))
+		require.NoError(t, err)
+
+		res := query(fmt.Sprintf(`mutation { lockAccount(id: "%d") { locked } }`, account.ID))
+		assert.Empty(t, res.Errors)
+		assert.Equal(t, map[string]interface{}{"locked": true}, res.Data["lockAccount"])
+
+		account, err = app.AccountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.True(t, account.Locked)
+	})
+
+	t.Run("unknown account mutation", func(t *testing.T) {
+		res := query(`mutation { lockAccount(id: "999999") { locked } }`)
+		assert.NotEmpty(t, res.Errors)
+	})
+
+	t.Run("audit log pagination", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			app.AuditLog.Append(events.Event{ID: fmt.Sprint(i), Type: events.AccountUpdated, AccountID: 42})
+		}
+
+		res := query(`{ auditLog(accountId: "42", first: 2) { edges { node { id } } pageInfo { hasNextPage endCursor } } }`)
+		assert.Empty(t, res.Errors)
+		page := res.Data["auditLog"].(map[string]interface{})
+		assert.Len(t, page["edges"], 2)
+		info := page["pageInfo"].(map[string]interface{})
+		assert.Equal(t, true, info["hasNextPage"])
+
+		res = query(fmt.Sprintf(`{ auditLog(accountId: "42", first: 2, after: "%s") { edges { node { id } } pageInfo { hasNextPage } } }`, info["endCursor"]))
+		assert.Empty(t, res.Errors)
+		page = res.Data["auditLog"].(map[string]interface{})
+		assert.Equal(t, []interface{}{map[string]interface{}{"node": map[string]interface{}{"id": "0"}}}, page["edges"])
+		assert.Equal(t, false, page["pageInfo"].(map[string]interface{})["hasNextPage"])
+	})
+}
@@ -0,0 +1,144 @@
+package graph
+
+import (
+	"strconv"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+	"github.com/pkg/errors"
+)
+
+type resolver struct {
+	app *api.App
+}
+
+func parseID(id graphql.ID) (int, error) {
+	accountID, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, services.FieldErrors{{"id", services.ErrNotFound}}
+	}
+	return accountID, nil
+}
+
+func (r *resolver) Account(args struct{ ID graphql.ID }) (*accountResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, nil
+	}
+
+	account, err := r.app.AccountStore.Find(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return nil, nil
+	}
+	return &accountResolver{app: r.app, account: account}, nil
+}
+
+func (r *resolver) AccountByUsername(args struct{ Username string }) (*accountResolver, error) {
+	account, err := r.app.AccountStore.FindByUsername(args.Username)
+	if err != nil {
+		return nil, errors.Wrap(err, "FindByUsername")
+	}
+	if account == nil {
+		return nil, nil
+	}
+	return &accountResolver{app: r.app, account: account}, nil
+}
+
+func (r *resolver) AuditLog(args struct {
+	AccountID *graphql.ID
+	First     int32
+	After     *string
+}) (*auditConnectionResolver, error) {
+	accountID := 0
+	if args.AccountID != nil {
+		id, err := parseID(*args.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		accountID = id
+	}
+	return findAuditLog(r.app, accountID, args.First, args.After)
+}
+
+type idArgs struct {
+	ID graphql.ID
+}
+
+// mutate runs a service against an account and returns the updated account.
+func (r *resolver) mutate(id graphql.ID, eventType string, fn func(accountID int) error) (*accountResolver, error) {
+	accountID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fn(accountID)
+	if err != nil {
+		return nil, err
+	}
+	r.app.Events.Emit(eventType, accountID)
+
+	account, err := services.AccountGetter(r.app.AccountStore, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return &accountResolver{app: r.app, account: account}, nil
+}
+
+func (r *resolver) UpdateAccount(args struct {
+	ID       graphql.ID
+	Username string
+}) (*accountResolver, error) {
+	return r.mutate(args.ID, events.AccountUpdated, func(id int) error {
+		return services.AccountUpdater(r.app.AccountStore, r.app.Config, id, args.Username)
+	})
+}
+
+func (r *resolver) LockAccount(args idArgs) (*accountResolver, error) {
+	return r.mutate(args.ID, events.AccountLocked, func(id int) error {
+		return services.AccountLocker(r.app.AccountStore, r.app.RefreshTokenStore, id)
+	})
+}
+
+func (r *resolver) UnlockAccount(args idArgs) (*accountResolver, error) {
+	return r.mutate(args.ID, events.AccountUnlocked, func(id int) error {
+		return services.AccountUnlocker(r.app.AccountStore, id)
+	})
+}
+
+func (r *resolver) ArchiveAccount(args idArgs) (*accountResolver, error) {
+	return r.mutate(args.ID, events.AccountArchived, func(id int) error {
+		return services.AccountArchiver(r.app.AccountStore, r.app.RefreshTokenStore, id)
+	})
+}
+
+func (r *resolver) ExpirePassword(args idArgs) (*accountResolver, error) {
+	return r.mutate(args.ID, events.PasswordExpired, func(id int) error {
+		return services.PasswordExpirer(r.app.AccountStore, r.app.RefreshTokenStore, id)
+	})
+}
+
+func (r *resolver) RevokeSessions(args idArgs) (*accountResolver, error) {
+	return r.mutate(args.ID, events.SessionRevoked, func(id int) error {
+		_, err := services.AccountGetter(r.app.AccountStore, id)
+		if err != nil {
+			return err
+		}
+
+		tokens, err := r.app.RefreshTokenStore.FindAll(id)
+		if err != nil {
+			return errors.Wrap(err, "FindAll")
+		}
+		for _, token := range tokens {
+			err = r.app.RefreshTokenStore.Revoke(token)
+			if err != nil {
+				return errors.Wrap(err, "Revoke")
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,24 @@
+package graph
+
+import (
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
+)
+
+func Routes(app *api.App) []*route.HandledRoute {
+	if !app.Config.EnableGraphQL {
+		return nil
+	}
+
+	accountAdmin := api.PrivateSecurity(app, models.ScopeAccountAdmin)
+	parsed := graphql.MustParseSchema(schema, &resolver{app: app})
+
+	return []*route.HandledRoute{
+		route.Post("/graphql").
+			SecuredWith(accountAdmin).
+			Handle(&relay.Handler{Schema: parsed}),
+	}
+}
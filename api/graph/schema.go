@@ -0,0 +1,65 @@
+// Package graph is an optional GraphQL endpoint for admin tooling. It offers the same account
+// operations as the private API, plus session listings and audit log queries.
+package graph
+
+const schema = `
+schema {
+	query: Query
+	mutation: Mutation
+}
+
+type Query {
+	account(id: ID!): Account
+	accountByUsername(username: String!): Account
+	auditLog(accountId: ID, first: Int = 20, after: String): AuditEventConnection!
+}
+
+type Mutation {
+	updateAccount(id: ID!, username: String!): Account!
+	lockAccount(id: ID!): Account!
+	unlockAccount(id: ID!): Account!
+	archiveAccount(id: ID!): Account!
+	expirePassword(id: ID!): Account!
+	revokeSessions(id: ID!): Account!
+}
+
+type Account {
+	id: ID!
+	username: String!
+	locked: Boolean!
+	throttledUntil: String
+	legalHold: Boolean!
+	deleted: Boolean!
+	requireNewPassword: Boolean!
+	passwordChangedAt: String!
+	createdAt: String!
+	sessions: [Session!]!
+	auditLog(first: Int = 20, after: String): AuditEventConnection!
+}
+
+type Session {
+	id: ID!
+}
+
+type AuditEventConnection {
+	edges: [AuditEventEdge!]!
+	pageInfo: PageInfo!
+}
+
+type AuditEventEdge {
+	cursor: String!
+	node: AuditEvent!
+}
+
+type AuditEvent {
+	id: ID!
+	type: String!
+	accountId: ID!
+	time: String!
+}
+
+type PageInfo {
+	hasNextPage: Boolean!
+	endCursor: String
+}
+`
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/models"
+)
+
+// Middleware wraps the router, e.g. to add custom headers.
+type Middleware func(http.Handler) http.Handler
+
+// SignupValidator may reject a signup before the account is created. The error message is returned
+// to the client as a validation error on the username field.
+type SignupValidator func(r *http.Request, username string) error
+
+// AccountHook is called after an account has been created.
+type AccountHook func(r *http.Request, account *models.Account)
+
+// Hooks are the extension points for teams that embed AuthN as a library. A nil *Hooks is valid and
+// extends nothing.
+type Hooks struct {
+	Middleware       []Middleware
+	SignupValidators []SignupValidator
+	AccountCreated   []AccountHook
+}
+
+// Wrap applies every Middleware to the handler. The first Middleware is outermost.
+func (h *Hooks) Wrap(handler http.Handler) http.Handler {
+	if h == nil {
+		return handler
+	}
+	for i := len(h.Middleware) - 1; i >= 0; i-- {
+		handler = h.Middleware[i](handler)
+	}
+	return handler
+}
+
+// ValidateSignup runs every SignupValidator, returning the first error.
+func (h *Hooks) ValidateSignup(r *http.Request, username string) error {
+	if h == nil {
+		return nil
+	}
+	for _, fn := range h.SignupValidators {
+		if err := fn(r, username); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AfterAccountCreated runs every AccountHook.
+func (h *Hooks) AfterAccountCreated(r *http.Request, account *models.Account) {
+	if h == nil {
+		return
+	}
+	for _, fn := range h.AccountCreated {
+		fn(r, account)
+	}
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/services"
+)
+
+// sheddableRoutes are refused while the server sheds load, so that logins and refreshes keep what
+// capacity remains.
+var sheddableRoutes = map[string]bool{
+	"POST /accounts":          true,
+	"GET /accounts/available": true,
+	"POST /accounts/import":   true,
+	"POST /accounts/bulk":     true,
+	"GET /accounts":           true,
+	"GET /account/logins":     true,
+	"GET /stats":              true,
+	"GET /admin":              true,
+	"GET /audit/verify":       true,
+	"POST /graphql":           true,
+}
+
+// LoadShedding refuses non-critical requests with a 503 while a dependency is degraded.
+func LoadShedding(app *App) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !sheddableRoutes[r.Method+" "+mountedPath(app, r)] || !app.Config.LoadShedder.Shedding() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Retry-After", "1")
+			WriteJSON(w, http.StatusServiceUnavailable, ServiceErrors{Errors: services.FieldErrors{{"server", services.ErrOverloaded}}})
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadShedding(t *testing.T) {
+	app := test.App()
+	app.Config.MountedPath = "/auth"
+	handler := api.LoadShedding(app)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(method string, path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(method, "/auth"+path, nil))
+		return res
+	}
+
+	t.Run("without thresholds", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("POST", "/accounts").Code)
+	})
+
+	shedder := lib.NewLoadShedder(map[string]time.Duration{"db": 100 * time.Millisecond})
+	app.Config.LoadShedder = shedder
+
+	t.Run("while healthy", func(t *testing.T) {
+		shedder.Observe("db", time.Millisecond, true)
+		assert.Equal(t, http.StatusOK, request("POST", "/accounts").Code)
+		assert.Equal(t, http.StatusOK, request("GET", "/stats").Code)
+	})
+
+	t.Run("while degraded", func(t *testing.T) {
+		shedder.Observe("db", time.Second, true)
+
+		testCases := []struct {
+			method string
+			path   string
+			code   int
+		}{
+			{"POST", "/accounts", http.StatusServiceUnavailable},
+			{"GET", "/accounts/available", http.StatusServiceUnavailable},
+			{"GET", "/stats", http.StatusServiceUnavailable},
+			{"POST", "/session", http.StatusOK},
+			{"GET", "/session/refresh", http.StatusOK},
+			{"DELETE", "/session", http.StatusOK},
+			{"GET", "/health", http.StatusOK},
+		}
+		for _, tc := range testCases {
+			res := request(tc.method, tc.path)
+			assert.Equal(t, tc.code, res.Code, "%s %s", tc.method, tc.path)
+		}
+
+		res := request("POST", "/accounts")
+		assert.Equal(t, "1", res.Header().Get("Retry-After"))
+		assert.Contains(t, res.Body.String(), "OVERLOADED")
+	})
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/services"
+)
+
+var modeRanks = map[string]int{
+	data.ModeNormal:      0,
+	data.ModeReadOnly:    1,
+	data.ModeMaintenance: 2,
+}
+
+// readOnlyRoutes may change state, but are still allowed in read-only mode so that users stay
+// logged in. Reads are also allowed, unless they change state.
+var readOnlyRoutes = map[string]bool{
+	"POST /session":            true,
+	"POST /session/confirm":    true,
+	"POST /session/mfa":        true,
+	"DELETE /session":          true,
+	"POST /introspect":         true,
+	"POST /debug/pprof/symbol": true,
+	"PATCH /mode":              true,
+}
+
+// isWritingRead is true for reads that change state, which are refused in read-only mode like
+// other changes. OAuth logins may create accounts and identities, and a password reset request
+// sends a token that could not be used.
+func isWritingRead(path string) bool {
+	return strings.HasPrefix(path, "/oauth/") || path == "/password/reset"
+}
+
+// maintenanceRoutes are allowed in maintenance mode, so that operators may monitor the server and
+// switch the mode back.
+var maintenanceRoutes = map[string]bool{
+	"GET /health":  true,
+	"GET /metrics": true,
+	"GET /mode":    true,
+	"PATCH /mode":  true,
+}
+
+// CurrentMode is the more restrictive of the mode set through the private API and the
+// MAINTENANCE_MODE of this server.
+func CurrentMode(app *App) (string, error) {
+	mode := data.ModeNormal
+	var err error
+	if app.ModeStore != nil {
+		var stored string
+		stored, err = app.ModeStore.Get()
+		if modeRanks[stored] > modeRanks[mode] {
+			mode = stored
+		}
+	}
+	if modeRanks[app.Config.MaintenanceMode] > modeRanks[mode] {
+		mode = app.Config.MaintenanceMode
+	}
+	return mode, err
+}
+
+// Maintenance refuses requests that are not allowed in the current mode with a 503.
+func Maintenance(app *App) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mode, err := CurrentMode(app)
+			if err != nil {
+				app.Reporter.ReportRequestError(err, r)
+			}
+			if mode == data.ModeNormal || allowedInMode(mode, r.Method, mountedPath(app, r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reason := services.ErrMaintenance
+			if mode == data.ModeReadOnly {
+				reason = services.ErrReadOnly
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(int(app.Config.MaintenanceRetryAfter.Seconds())))
+			WriteJSON(w, http.StatusServiceUnavailable, ServiceErrors{Errors: services.FieldErrors{{"server", reason}}})
+		})
+	}
+}
+
+func allowedInMode(mode string, method string, path string) bool {
+	route := method + " " + path
+	if maintenanceRoutes[route] || strings.HasPrefix(path, "/debug/") {
+		return true
+	}
+	if mode != data.ModeReadOnly {
+		return false
+	}
+	if readOnlyRoutes[route] {
+		return true
+	}
+	return (method == "GET" || method == "HEAD" || method == "OPTIONS") && !isWritingRead(path)
+}
+
+// mountedPath is the request path within AuthN's MOUNTED_PATH.
+func mountedPath(app *App, r *http.Request) string {
+	path := r.URL.Path
+	if prefix := strings.TrimSuffix(app.Config.MountedPath, "/"); prefix != "" {
+		path = strings.TrimPrefix(path, prefix)
+	}
+	return path
+}
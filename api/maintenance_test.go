@@ -0,0 +1,70 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenance(t *testing.T) {
+	app := test.App()
+	app.Config.MaintenanceRetryAfter = time.Minute
+	app.Config.MountedPath = "/auth"
+	handler := api.Maintenance(app)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(method string, path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(method, "/auth"+path, nil))
+		return res
+	}
+
+	testCases := []struct {
+		mode   string
+		method string
+		path   string
+		code   int
+	}{
+		{data.ModeNormal, "POST", "/accounts", http.StatusOK},
+		{data.ModeReadOnly, "POST", "/accounts", http.StatusServiceUnavailable},
+		{data.ModeReadOnly, "PATCH", "/accounts/1/lock", http.StatusServiceUnavailable},
+		{data.ModeReadOnly, "POST", "/session", http.StatusOK},
+		{data.ModeReadOnly, "GET", "/session/refresh", http.StatusOK},
+		{data.ModeReadOnly, "DELETE", "/session", http.StatusOK},
+		{data.ModeReadOnly, "PATCH", "/mode", http.StatusOK},
+		{data.ModeReadOnly, "GET", "/accounts/1", http.StatusOK},
+		{data.ModeReadOnly, "GET", "/oauth/google", http.StatusServiceUnavailable},
+		{data.ModeReadOnly, "GET", "/oauth/google/return", http.StatusServiceUnavailable},
+		{data.ModeReadOnly, "GET", "/password/reset", http.StatusServiceUnavailable},
+		{data.ModeMaintenance, "POST", "/session", http.StatusServiceUnavailable},
+		{data.ModeMaintenance, "GET", "/session/refresh", http.StatusServiceUnavailable},
+		{data.ModeMaintenance, "GET", "/health", http.StatusOK},
+		{data.ModeMaintenance, "PATCH", "/mode", http.StatusOK},
+	}
+	for _, tc := range testCases {
+		require.NoError(t, app.ModeStore.Set(tc.mode))
+		res := request(tc.method, tc.path)
+		assert.Equal(t, tc.code, res.Code, "%s %s %s", tc.mode, tc.method, tc.path)
+	}
+
+	t.Run("refusal", func(t *testing.T) {
+		require.NoError(t, app.ModeStore.Set(data.ModeReadOnly))
+		res := request("POST", "/accounts")
+		assert.Equal(t, "60", res.Header().Get("Retry-After"))
+		assert.Contains(t, res.Body.String(), "READ_ONLY")
+	})
+
+	t.Run("with MAINTENANCE_MODE", func(t *testing.T) {
+		require.NoError(t, app.ModeStore.Set(data.ModeNormal))
+		app.Config.MaintenanceMode = data.ModeReadOnly
+		res := request("POST", "/accounts")
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	})
+}
@@ -0,0 +1,20 @@
+package meta
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/api/views"
+)
+
+func getAdmin(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		views.Admin(&buf)
+
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+	}
+}
@@ -0,0 +1,47 @@
+package meta_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/meta"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAdmin(t *testing.T) {
+	app := test.App()
+	app.Config.AdminDashboard = true
+	server := test.Server(app, meta.Routes(app))
+	defer server.Close()
+
+	t.Run("without credentials", func(t *testing.T) {
+		res, err := route.NewClient(server.URL).Get("/admin")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("with credentials", func(t *testing.T) {
+		client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+		res, err := client.Get("/admin")
+		require.NoError(t, err)
+		body := test.ReadBody(res)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, []string{"text/html"}, res.Header["Content-Type"])
+		assert.Contains(t, string(body), "AuthN Admin")
+	})
+}
+
+func TestGetAdminDisabled(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, meta.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+	res, err := client.Get("/admin")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
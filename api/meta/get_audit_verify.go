@@ -0,0 +1,29 @@
+package meta
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+)
+
+// getAuditVerify walks the whole audit log, so that tampering may be detected after an incident.
+func getAuditVerify(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := services.AuditLogVerifier(app.AuditLog, app.Config.AuditSigningKey)
+		if err != nil {
+			panic(err)
+		}
+
+		var brokenAt *string
+		if result.BrokenAt != "" {
+			brokenAt = &result.BrokenAt
+		}
+		api.WriteData(w, http.StatusOK, map[string]interface{}{
+			"valid":     result.BrokenAt == "",
+			"verified":  result.Verified,
+			"unsigned":  result.Unsigned,
+			"broken_at": brokenAt,
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package meta_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api/meta"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAuditVerify(t *testing.T) {
+	app := test.App()
+	app.Config.AuditSigningKey = []byte("key")
+	app.AuditLog = mock.NewAuditLog(app.Config.AuditSigningKey)
+	server := test.Server(app, meta.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("intact chain", func(t *testing.T) {
+		for _, id := range []string{"a", "b"} {
+			err := app.AuditLog.Append(events.Event{ID: id, Type: events.AccountUpdated, AccountID: 1, Time: time.Now()})
+			require.NoError(t, err)
+		}
+
+		res, err := client.Get("/audit/verify")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, map[string]interface{}{
+			"valid":     true,
+			"verified":  2,
+			"unsigned":  0,
+			"broken_at": nil,
+		})
+	})
+
+	t.Run("with another key", func(t *testing.T) {
+		app.Config.AuditSigningKey = []byte("rotated")
+		defer func() { app.Config.AuditSigningKey = []byte("key") }()
+
+		res, err := client.Get("/audit/verify")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, map[string]interface{}{
+			"valid":     false,
+			"verified":  0,
+			"unsigned":  0,
+			"broken_at": "1",
+		})
+	})
+}
+
+func TestGetAuditVerifyWithoutAuditLog(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, meta.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+	res, err := client.Get("/audit/verify")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
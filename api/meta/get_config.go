@@ -0,0 +1,34 @@
+package meta
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+)
+
+type setting struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Source  string `json:"source"`
+	Default string `json:"default,omitempty"`
+}
+
+// getConfig reports every variable that the configuration read when the server started, with
+// secrets masked, so that operators may see what a server is actually running with.
+func getConfig(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		settings := make([]setting, 0, len(app.Config.Settings))
+		for _, s := range app.Config.Settings {
+			settings = append(settings, setting{
+				Name:    s.Name,
+				Value:   s.Value,
+				Source:  s.Source,
+				Default: s.Default,
+			})
+		}
+
+		api.WriteData(w, http.StatusOK, map[string]interface{}{
+			"settings": settings,
+		})
+	}
+}
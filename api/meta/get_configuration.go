@@ -15,6 +15,11 @@ func getConfiguration(app *api.App) http.HandlerFunc {
 			"id_token_signing_alg_values_supported": []string{"RS256"},
 			"claims_supported":                      []string{"iss", "sub", "aud", "exp", "iat", "auth_time"},
 			"jwks_uri":                              app.Config.AuthNURL.String() + "/jwks",
+			"end_session_endpoint":                  app.Config.AuthNURL.String() + "/session/logout",
+			"frontchannel_logout_supported":         true,
+			"frontchannel_logout_session_supported": true,
+			"backchannel_logout_supported":          true,
+			"backchannel_logout_session_supported":  true,
 		})
 	}
 }
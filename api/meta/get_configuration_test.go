@@ -32,8 +32,10 @@ func TestGetConfiguration(t *testing.T) {
 	assert.Equal(t, []string{"application/json"}, res.Header["Content-Type"])
 
 	data := struct {
-		JWKSURI string `json:"jwks_uri"`
+		JWKSURI            string `json:"jwks_uri"`
+		EndSessionEndpoint string `json:"end_session_endpoint"`
 	}{}
 	json.Unmarshal(body, &data)
 	assert.Equal(t, "https://authn.example.com/foo/jwks", data.JWKSURI)
+	assert.Equal(t, "https://authn.example.com/foo/session/logout", data.EndSessionEndpoint)
 }
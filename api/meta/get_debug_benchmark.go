@@ -0,0 +1,129 @@
+package meta
+
+import (
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/tokens/identities"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// benchmarks are expensive by design, so requests are bounded to keep them from becoming a denial
+// of service on the host they measure.
+const (
+	benchmarkMaxCost    = 16
+	benchmarkMaxSamples = 10
+	benchmarkMaxSeconds = 10
+)
+
+// getDebugBenchmark measures bcrypt and token signing on this host, and reports the results in the
+// same format as /metrics. Operators may use it to choose BCRYPT_COST and to plan capacity.
+func getDebugBenchmark(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		costs := benchmarkCosts(r.FormValue("costs"), app.Config.BcryptCost)
+		samples := boundedInt(r.FormValue("samples"), 3, benchmarkMaxSamples)
+		seconds := boundedInt(r.FormValue("seconds"), 1, benchmarkMaxSeconds)
+
+		registry := prometheus.NewRegistry()
+		bcryptSeconds := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "authn_benchmark_bcrypt_seconds",
+			Help: "Mean time to hash a password with bcrypt, partitioned by cost",
+		}, []string{"cost"})
+		configuredCost := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "authn_benchmark_bcrypt_configured_cost",
+			Help: "The configured BCRYPT_COST",
+		})
+		signingSeconds := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "authn_benchmark_token_signing_seconds",
+			Help: "Mean time to sign an identity token",
+		})
+		signingRate := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "authn_benchmark_token_signing_per_second",
+			Help: "Identity tokens signed per second on one CPU",
+		})
+		cpus := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "authn_benchmark_cpus",
+			Help: "CPUs available to the server (GOMAXPROCS)",
+		})
+		registry.MustRegister(bcryptSeconds, configuredCost, signingSeconds, signingRate, cpus)
+
+		password := []byte("benchmark password")
+		for _, cost := range costs {
+			start := time.Now()
+			for i := 0; i < samples; i++ {
+				_, err := bcrypt.GenerateFromPassword(password, cost)
+				if err != nil {
+					panic(err)
+				}
+			}
+			bcryptSeconds.WithLabelValues(strconv.Itoa(cost)).Set(time.Since(start).Seconds() / float64(samples))
+		}
+		configuredCost.Set(float64(app.Config.BcryptCost))
+
+		key := app.KeyStore.Key()
+		claims := &identities.Claims{
+			Claims: jwt.Claims{
+				Issuer:   app.Config.AuthNURL.String(),
+				Subject:  "0",
+				Audience: jwt.Audience{"benchmark"},
+				IssuedAt: jwt.NewNumericDate(time.Now()),
+			},
+		}
+		signed := 0
+		start := time.Now()
+		deadline := start.Add(time.Duration(seconds) * time.Second)
+		for signed == 0 || time.Now().Before(deadline) {
+			_, err := claims.Sign(key)
+			if err != nil {
+				panic(err)
+			}
+			signed++
+		}
+		elapsed := time.Since(start).Seconds()
+		signingSeconds.Set(elapsed / float64(signed))
+		signingRate.Set(float64(signed) / elapsed)
+		cpus.Set(float64(runtime.GOMAXPROCS(0)))
+
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	}
+}
+
+// benchmarkCosts parses a comma-delimited list of bcrypt costs. The default brackets the configured
+// cost, so that the report shows the price of raising or lowering it.
+func benchmarkCosts(param string, configured int) []int {
+	costs := []int{}
+	if param == "" {
+		for cost := configured - 1; cost <= configured+1; cost++ {
+			if cost >= bcrypt.MinCost && cost <= benchmarkMaxCost {
+				costs = append(costs, cost)
+			}
+		}
+		return costs
+	}
+	for _, val := range strings.Split(param, ",") {
+		cost, err := strconv.Atoi(strings.TrimSpace(val))
+		if err == nil && cost >= bcrypt.MinCost && cost <= benchmarkMaxCost {
+			costs = append(costs, cost)
+		}
+	}
+	return costs
+}
+
+// boundedInt parses a positive integer, falling back to a default and capping it at a maximum.
+func boundedInt(param string, fallback int, max int) int {
+	val, err := strconv.Atoi(param)
+	if err != nil || val < 1 {
+		return fallback
+	}
+	if val > max {
+		return max
+	}
+	return val
+}
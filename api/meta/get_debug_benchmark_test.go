@@ -0,0 +1,42 @@
+package meta_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/meta"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDebugBenchmark(t *testing.T) {
+	app := test.App()
+	app.Config.DebugEndpoints = true
+	server := test.Server(app, meta.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+	res, err := client.Get("/debug/benchmark?costs=4,5,99&samples=1&seconds=1")
+	require.NoError(t, err)
+	body := string(test.ReadBody(res))
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, body, `authn_benchmark_bcrypt_seconds{cost="4"}`)
+	assert.Contains(t, body, `authn_benchmark_bcrypt_seconds{cost="5"}`)
+	assert.NotContains(t, body, `cost="99"`)
+	assert.Contains(t, body, "authn_benchmark_bcrypt_configured_cost 4")
+	assert.Contains(t, body, "authn_benchmark_token_signing_per_second")
+}
+
+func TestGetDebugBenchmarkDisabled(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, meta.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+	res, err := client.Get("/debug/benchmark")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
@@ -0,0 +1,18 @@
+package meta
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+)
+
+// getDebugPprofProfile serves a named runtime profile (heap, goroutine, block, etc). It relies on
+// pprof.Handler rather than pprof.Index, since Index expects to be mounted at /debug/pprof/ and
+// would not find the profile name when AuthN is mounted under a path.
+func getDebugPprofProfile(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(mux.Vars(r)["profile"]).ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,26 @@
+package meta
+
+import (
+	"expvar"
+	"net/http"
+	"runtime"
+
+	"github.com/keratin/authn-server/api"
+)
+
+func init() {
+	expvar.Publish("goroutines", expvar.Func(func() interface{} {
+		return runtime.NumGoroutine()
+	}))
+	expvar.Publish("cpus", expvar.Func(func() interface{} {
+		return runtime.NumCPU()
+	}))
+}
+
+// getDebugVars serves expvar's JSON report, which includes memstats and the command line in
+// addition to the goroutine and CPU counts published above.
+func getDebugVars(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		expvar.Handler().ServeHTTP(w, r)
+	}
+}
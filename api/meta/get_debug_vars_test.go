@@ -0,0 +1,46 @@
+package meta_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/meta"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDebugVars(t *testing.T) {
+	app := test.App()
+	app.Config.DebugEndpoints = true
+	server := test.Server(app, meta.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+	res, err := client.Get("/debug/vars")
+	require.NoError(t, err)
+	body := test.ReadBody(res)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	data := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(body, &data))
+	assert.NotEmpty(t, data["memstats"])
+	assert.NotEmpty(t, data["goroutines"])
+}
+
+func TestGetDebugVarsDisabled(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, meta.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+	res, err := client.Get("/debug/vars")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res, err = client.Get("/debug/pprof/heap")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
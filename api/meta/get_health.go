@@ -7,9 +7,17 @@ import (
 )
 
 type health struct {
-	HTTP  bool `json:"http"`
-	Db    bool `json:"db"`
-	Redis bool `json:"redis"`
+	HTTP         bool          `json:"http"`
+	Db           bool          `json:"db"`
+	Redis        bool          `json:"redis"`
+	LoadShedding *loadShedding `json:"load_shedding,omitempty"`
+}
+
+// loadShedding is reported when LOAD_SHED_* thresholds are configured.
+type loadShedding struct {
+	Shedding  bool           `json:"shedding"`
+	Degraded  []string       `json:"degraded"`
+	LatencyMs map[string]int `json:"latency_ms"`
 }
 
 func getHealth(app *api.App) http.HandlerFunc {
@@ -20,6 +28,19 @@ func getHealth(app *api.App) http.HandlerFunc {
 			Db:    app.DbCheck(),
 		}
 
+		if shedder := app.Config.LoadShedder; shedder != nil {
+			degraded := shedder.Degraded()
+			latencies := map[string]int{}
+			for name, latency := range shedder.Latencies() {
+				latencies[name] = int(latency.Milliseconds())
+			}
+			h.LoadShedding = &loadShedding{
+				Shedding:  len(degraded) > 0,
+				Degraded:  degraded,
+				LatencyMs: latencies,
+			}
+		}
+
 		api.WriteJSON(w, http.StatusOK, h)
 	}
 }
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/keratin/authn-server/api"
 	"github.com/keratin/authn-server/api/meta"
 	"github.com/keratin/authn-server/api/test"
 	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/lib"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -30,3 +32,22 @@ func TestGetHealth(t *testing.T) {
 	assert.Equal(t, []string{"application/json"}, res.Header["Content-Type"])
 	assert.Equal(t, `{"http":true,"db":true,"redis":true}`, string(body))
 }
+
+func TestGetHealthWhileSheddingLoad(t *testing.T) {
+	shedder := lib.NewLoadShedder(map[string]time.Duration{"db": 100 * time.Millisecond})
+	shedder.Observe("db", 250*time.Millisecond, true)
+	app := &api.App{
+		DbCheck:    func() bool { return true },
+		RedisCheck: func() bool { return true },
+		Config:     &config.Config{LoadShedder: shedder},
+	}
+	server := test.Server(app, meta.Routes(app))
+	defer server.Close()
+
+	res, err := http.Get(fmt.Sprintf("%s/health", server.URL))
+	require.NoError(t, err)
+	body := test.ReadBody(res)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `{"http":true,"db":true,"redis":true,"load_shedding":{"shedding":true,"degraded":["db"],"latency_ms":{"db":250}}}`, string(body))
+}
@@ -17,7 +17,7 @@ import (
 )
 
 func TestGetJWKs(t *testing.T) {
-	rsaKey, err := rsa.GenerateKey(rand.Reader, 512)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
 	require.NoError(t, err)
 	app := &api.App{
 		KeyStore: mock.NewKeyStore(rsaKey),
@@ -0,0 +1,20 @@
+package meta
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+)
+
+func getMode(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mode, err := api.CurrentMode(app)
+		if err != nil {
+			panic(err)
+		}
+
+		api.WriteData(w, http.StatusOK, map[string]string{
+			"mode": mode,
+		})
+	}
+}
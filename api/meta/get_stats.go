@@ -33,8 +33,37 @@ func getStats(app *api.App) http.HandlerFunc {
 			Monthly: monthly,
 		}
 
-		api.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		stats := map[string]interface{}{
 			"actives": actives,
-		})
+		}
+
+		if app.Signups != nil {
+			daily, err := app.Signups.SignupsByDay()
+			if err != nil {
+				panic(err)
+			}
+
+			weekly, err := app.Signups.SignupsByWeek()
+			if err != nil {
+				panic(err)
+			}
+
+			monthly, err := app.Signups.SignupsByMonth()
+			if err != nil {
+				panic(err)
+			}
+
+			stats["signups"] = struct {
+				Daily   map[string]int `json:"daily"`
+				Weekly  map[string]int `json:"weekly"`
+				Monthly map[string]int `json:"monthly"`
+			}{
+				Daily:   daily,
+				Weekly:  weekly,
+				Monthly: monthly,
+			}
+		}
+
+		api.WriteJSON(w, http.StatusOK, stats)
 	}
 }
@@ -17,6 +17,7 @@ func TestGetStats(t *testing.T) {
 	defer server.Close()
 
 	app.Actives.Track(1)
+	app.Signups.Track()
 
 	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
 
@@ -27,6 +28,7 @@ func TestGetStats(t *testing.T) {
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 	assert.Equal(t, []string{"application/json"}, res.Header["Content-Type"])
 	assert.NotEmpty(t, body)
+	assert.Contains(t, string(body), `"signups"`)
 }
 
 func TestGetStatsWithoutRedis(t *testing.T) {
@@ -0,0 +1,37 @@
+package meta
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/services"
+)
+
+func patchMode(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mode := r.FormValue("mode")
+		if mode == "" {
+			api.WriteErrors(w, services.FieldErrors{{"mode", services.ErrMissing}})
+			return
+		}
+		if !data.ValidMode(mode) {
+			api.WriteErrors(w, services.FieldErrors{{"mode", services.ErrFormatInvalid}})
+			return
+		}
+
+		err := app.ModeStore.Set(mode)
+		if err != nil {
+			panic(err)
+		}
+
+		// MAINTENANCE_MODE may keep this server more restrictive than requested
+		current, err := api.CurrentMode(app)
+		if err != nil {
+			panic(err)
+		}
+		api.WriteData(w, http.StatusOK, map[string]string{
+			"mode": current,
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package meta_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/keratin/authn-server/api/meta"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchMode(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, meta.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("switching to read-only", func(t *testing.T) {
+		res, err := client.Patch("/mode", url.Values{"mode": []string{"read-only"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, map[string]interface{}{"mode": "read-only"})
+
+		mode, err := app.ModeStore.Get()
+		require.NoError(t, err)
+		assert.Equal(t, data.ModeReadOnly, mode)
+	})
+
+	t.Run("with MAINTENANCE_MODE", func(t *testing.T) {
+		app.Config.MaintenanceMode = data.ModeMaintenance
+		defer func() { app.Config.MaintenanceMode = "" }()
+
+		res, err := client.Patch("/mode", url.Values{"mode": []string{"normal"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, map[string]interface{}{"mode": "maintenance"})
+	})
+
+	t.Run("with unknown mode", func(t *testing.T) {
+		res, err := client.Patch("/mode", url.Values{"mode": []string{"offline"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"mode", services.ErrFormatInvalid}})
+	})
+
+	t.Run("getting the mode", func(t *testing.T) {
+		res, err := client.Get("/mode")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		test.AssertData(t, res, map[string]interface{}{"mode": "normal"})
+	})
+}
@@ -1,8 +1,12 @@
 package meta
 
 import (
+	"net/http"
+	"net/http/pprof"
+
 	"github.com/keratin/authn-server/api"
 	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -15,14 +19,15 @@ func PublicRoutes(app *api.App) []*route.HandledRoute {
 }
 
 func Routes(app *api.App) []*route.HandledRoute {
-	authentication := route.BasicAuthSecurity(app.Config.AuthUsername, app.Config.AuthPassword, "Private AuthN Realm")
+	stats := api.PrivateSecurity(app, models.ScopeStats)
+	admin := api.PrivateSecurity(app, models.ScopeAdmin)
 
 	routes := PublicRoutes(app)
 
 	if app.Actives != nil {
 		routes = append(routes,
 			route.Get("/stats").
-				SecuredWith(authentication).
+				SecuredWith(stats).
 				Handle(getStats(app)),
 		)
 	}
@@ -38,9 +43,75 @@ func Routes(app *api.App) []*route.HandledRoute {
 			SecuredWith(route.Unsecured()).
 			Handle(getConfiguration(app)),
 		route.Get("/metrics").
-			SecuredWith(authentication).
+			SecuredWith(stats).
 			Handle(promhttp.Handler()),
+		route.Get("/config").
+			SecuredWith(admin).
+			Handle(getConfig(app)),
+		route.Get("/mode").
+			SecuredWith(admin).
+			Handle(getMode(app)),
+		route.Patch("/mode").
+			SecuredWith(admin).
+			Handle(patchMode(app)),
 	)
 
+	if app.AuditLog != nil {
+		routes = append(routes,
+			route.Get("/audit/verify").
+				SecuredWith(admin).
+				Handle(getAuditVerify(app)).
+				WithTimeout(0),
+		)
+	}
+
+	if app.Config.AdminDashboard {
+		routes = append(routes,
+			route.Get("/admin").
+				SecuredWith(admin).
+				Handle(getAdmin(app)),
+		)
+	}
+
+	if app.Config.DebugEndpoints {
+		routes = append(routes,
+			route.Get("/debug/vars").
+				SecuredWith(admin).
+				Handle(getDebugVars(app)),
+
+			route.Get("/debug/benchmark").
+				SecuredWith(admin).
+				Handle(getDebugBenchmark(app)).
+				WithTimeout(0),
+
+			route.Get("/debug/pprof/cmdline").
+				SecuredWith(admin).
+				Handle(http.HandlerFunc(pprof.Cmdline)),
+
+			route.Get("/debug/pprof/profile").
+				SecuredWith(admin).
+				Handle(http.HandlerFunc(pprof.Profile)).
+				WithTimeout(0),
+
+			route.Get("/debug/pprof/symbol").
+				SecuredWith(admin).
+				Handle(http.HandlerFunc(pprof.Symbol)),
+
+			route.Post("/debug/pprof/symbol").
+				SecuredWith(admin).
+				Handle(http.HandlerFunc(pprof.Symbol)),
+
+			route.Get("/debug/pprof/trace").
+				SecuredWith(admin).
+				Handle(http.HandlerFunc(pprof.Trace)).
+				WithTimeout(0),
+
+			route.Get("/debug/pprof/{profile}").
+				SecuredWith(admin).
+				Handle(getDebugPprofProfile(app)).
+				WithTimeout(0),
+		)
+	}
+
 	return routes
 }
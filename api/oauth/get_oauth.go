@@ -10,6 +10,7 @@ import (
 
 	"github.com/keratin/authn-server/api"
 	"github.com/keratin/authn-server/tokens/oauth"
+	"golang.org/x/oauth2"
 )
 
 func getOauth(app *api.App, providerName string) http.HandlerFunc {
@@ -48,7 +49,12 @@ func getOauth(app *api.App, providerName string) http.HandlerFunc {
 		}
 		state, err := stateToken.Sign(app.Config.OAuthSigningKey)
 
+		var opts []oauth2.AuthCodeOption
+		if provider.PKCE {
+			opts = codeChallenge(app.Config, nonce)
+		}
+
 		returnURL := app.Config.AuthNURL.String() + "/oauth/" + providerName + "/return"
-		http.Redirect(w, r, provider.Config(returnURL).AuthCodeURL(state), http.StatusSeeOther)
+		http.Redirect(w, r, provider.Config(returnURL).AuthCodeURL(state, opts...), http.StatusSeeOther)
 	}
 }
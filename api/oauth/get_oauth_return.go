@@ -9,6 +9,8 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"golang.org/x/oauth2"
 )
 
 func getOauthReturn(app *api.App, providerName string) http.HandlerFunc {
@@ -32,8 +34,13 @@ func getOauthReturn(app *api.App, providerName string) http.HandlerFunc {
 		}
 
 		// exchange code for tokens and user info
+		var opts []oauth2.AuthCodeOption
+		if provider.PKCE {
+			nonce, _ := r.Cookie(app.Config.OAuthCookieName)
+			opts = append(opts, oauth2.SetAuthURLParam("code_verifier", codeVerifier(app.Config, nonce.Value)))
+		}
 		returnURL := app.Config.AuthNURL.String() + "/oauth/" + providerName + "/return"
-		tok, err := provider.Config(returnURL).Exchange(context.TODO(), r.FormValue("code"))
+		tok, err := provider.Config(returnURL).Exchange(context.TODO(), r.FormValue("code"), opts...)
 		if err != nil {
 			fail(errors.Wrap(err, "Exchange"))
 			return
@@ -46,27 +53,45 @@ func getOauthReturn(app *api.App, providerName string) http.HandlerFunc {
 
 		// attempt to reconcile oauth identity information into an authn account
 		sessionAccountID := api.GetSessionAccountID(r)
-		account, err := services.IdentityReconciler(app.AccountStore, app.Config, providerName, providerUser, tok, sessionAccountID)
+		account, err := services.IdentityReconciler(app.Accounts(r), app.AnnotationStore, app.Config, providerName, providerUser, tok, sessionAccountID)
 		if err != nil {
 			fail(err)
 			return
 		}
 
+		// logging in cancels a scheduled deletion
+		canceled, err := services.AccountDeletionCanceler(app.Accounts(r), account)
+		if err != nil {
+			fail(errors.Wrap(err, "AccountDeletionCanceler"))
+			return
+		}
+		if canceled {
+			app.Events.Emit(events.AccountDeletionCanceled, account.ID)
+		}
+
 		// clean up any existing session
-		err = api.RevokeSession(app.RefreshTokenStore, app.Config, r)
+		err = api.RevokeSession(app.RefreshTokens(r), app.Config, r)
 		if err != nil {
 			app.Reporter.ReportRequestError(err, r)
 		}
 
-		// identityToken is not returned in this flow. it must be imported by the frontend like a SSO session.
-		sessionToken, _, err := api.NewSession(app.RefreshTokenStore, app.KeyStore, app.Actives, app.Config, account.ID, &app.Config.ApplicationDomains[0])
+		// identityToken is not returned in this flow. it must be imported by the frontend like a SSO session,
+		// unless ACCESS_TOKEN_COOKIE_NAME mirrors it into a cookie.
+		sessionToken, identityToken, err := api.NewSession(app.RefreshTokens(r), app.KeyStore, app.AccessTokenStore, app.Accounts(r), app.Actives, app.Config, account.ID, &app.Config.ApplicationDomains[0], api.SessionFingerprint(app.Config, r), "", []string{"oauth"})
 		if err != nil {
 			fail(errors.Wrap(err, "NewSession"))
 			return
 		}
 
+		location, err := services.LocationFinder(app.Config.GeoIPLocator, r.RemoteAddr)
+		if err != nil {
+			app.Reporter.ReportRequestError(err, r)
+		}
+		app.Events.EmitRequest(events.SessionCreated, account.ID, location, r)
+
 		// Return the signed session in a cookie
-		api.SetSession(app.Config, w, sessionToken)
+		api.SetSession(app.Config, w, &app.Config.ApplicationDomains[0], sessionToken)
+		api.SetAccessToken(app.Config, w, &app.Config.ApplicationDomains[0], identityToken)
 
 		// redirect back to frontend (success or failure)
 		http.Redirect(w, r, state.Destination, http.StatusSeeOther)
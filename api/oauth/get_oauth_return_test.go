@@ -1,6 +1,8 @@
 package oauth_test
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -131,3 +133,46 @@ func TestGetOauthReturn(t *testing.T) {
 		test.AssertRedirect(t, res, "http://test.com")
 	})
 }
+
+func TestGetOauthReturnPKCE(t *testing.T) {
+	// start a fake oauth provider that records the code verifier
+	var verifier string
+	providerServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifier = r.FormValue("code_verifier")
+		test.ProviderApp()(w, r)
+	}))
+	defer providerServer.Close()
+
+	providerClient := oauthlib.NewTestProvider(providerServer)
+	providerClient.PKCE = true
+
+	app := test.App()
+	app.OauthProviders["test"] = *providerClient
+	server := test.Server(app, oauth.Routes(app))
+	defer server.Close()
+
+	http.DefaultClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	client := route.NewClient(server.URL)
+
+	res, err := client.Get("/oauth/test?redirect_uri=http://test.com/finish")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSeeOther, res.StatusCode)
+	nonce := test.ReadCookie(res.Cookies(), app.Config.OAuthCookieName)
+	require.NotNil(t, nonce)
+	location, err := res.Location()
+	require.NoError(t, err)
+	challenge := location.Query().Get("code_challenge")
+	require.NotEmpty(t, challenge)
+	assert.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+
+	res, err = client.WithCookie(nonce).Get("/oauth/test/return?code=pkce@keratin.tech&state=" + location.Query().Get("state"))
+	require.NoError(t, err)
+	if test.AssertRedirect(t, res, "http://test.com/finish") {
+		test.AssertSession(t, app.Config, res.Cookies())
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	assert.Equal(t, challenge, base64.RawURLEncoding.EncodeToString(sum[:]))
+}
@@ -42,6 +42,14 @@ func TestGetOauth(t *testing.T) {
 		require.Equal(t, "https://authn.example.com/oauth/test/return", location.Query().Get("redirect_uri"))
 	})
 
+	t.Run("without PKCE", func(t *testing.T) {
+		res, err := client.Get("/oauth/test?redirect_uri=http://test.com/finish")
+		require.NoError(t, err)
+		location, err := res.Location()
+		require.NoError(t, err)
+		assert.Empty(t, location.Query().Get("code_challenge"))
+	})
+
 	t.Run("unknown provider", func(t *testing.T) {
 		res, err := client.Get("/oauth/unknown")
 		require.NoError(t, err)
@@ -54,3 +62,19 @@ func TestGetOauth(t *testing.T) {
 		test.AssertRedirect(t, res, "http://test.com")
 	})
 }
+
+func TestGetOauthDisabled(t *testing.T) {
+	providerServer := httptest.NewServer(test.ProviderApp())
+	defer providerServer.Close()
+
+	app := test.App()
+	app.Config.DisableOAuth = true
+	app.OauthProviders["test"] = *oauthlib.NewTestProvider(providerServer)
+	server := test.Server(app, oauth.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+	res, err := client.Get("/oauth/test?redirect_uri=http://test.com/finish")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
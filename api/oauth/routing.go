@@ -6,6 +6,9 @@ import (
 )
 
 func PublicRoutes(app *api.App) []*route.HandledRoute {
+	if app.Config.DisableOAuth {
+		return nil
+	}
 
 	var routes []*route.HandledRoute
 
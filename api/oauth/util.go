@@ -1,6 +1,9 @@
 package oauth
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"net/http"
 	"net/url"
 	"time"
@@ -8,6 +11,7 @@ import (
 	"github.com/keratin/authn-server/config"
 	"github.com/keratin/authn-server/tokens/oauth"
 	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
 )
 
 // nonceCookie creates or deletes a cookie containing val (the nonce)
@@ -50,3 +54,20 @@ func redirectFailure(w http.ResponseWriter, r *http.Request, destination string)
 	url.RawQuery = query.Encode()
 	http.Redirect(w, r, url.String(), http.StatusSeeOther)
 }
+
+// codeVerifier derives a PKCE code verifier from the nonce. It needs no storage, but unlike the
+// nonce it is never sent through the browser, and cannot be computed without the OAuth signing key.
+func codeVerifier(cfg *config.Config, nonce string) string {
+	mac := hmac.New(sha256.New, cfg.OAuthSigningKey)
+	mac.Write([]byte("pkce:" + nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// codeChallenge adds a PKCE code challenge (S256) to the authorization request.
+func codeChallenge(cfg *config.Config, nonce string) []oauth2.AuthCodeOption {
+	sum := sha256.Sum256([]byte(codeVerifier(cfg, nonce)))
+	return []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(sum[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	}
+}
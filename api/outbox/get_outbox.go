@@ -0,0 +1,23 @@
+package outbox
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/models"
+)
+
+func getOutbox(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		counts, err := app.OutboxStore.CountByStatus()
+		if err != nil {
+			panic(err)
+		}
+
+		api.WriteData(w, http.StatusOK, map[string]int{
+			models.OutboxPending:   counts[models.OutboxPending],
+			models.OutboxDelivered: counts[models.OutboxDelivered],
+			models.OutboxFailed:    counts[models.OutboxFailed],
+		})
+	}
+}
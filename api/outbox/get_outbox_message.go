@@ -0,0 +1,30 @@
+package outbox
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+)
+
+func getOutboxMessage(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "message")
+			return
+		}
+
+		msg, err := app.OutboxStore.Find(id)
+		if err != nil {
+			panic(err)
+		}
+		if msg == nil {
+			api.WriteNotFound(w, "message")
+			return
+		}
+
+		api.WriteData(w, http.StatusOK, messageData(msg))
+	}
+}
@@ -0,0 +1,49 @@
+package outbox_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/outbox"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOutboxMessage(t *testing.T) {
+	app := outboxApp()
+	server := test.Server(app, outbox.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("failed message", func(t *testing.T) {
+		msg, err := app.OutboxStore.Create(models.OutboxWebhook, "password_changed", "account_id=1")
+		require.NoError(t, err)
+		require.NoError(t, app.OutboxStore.Failed(msg.ID, 503, "Status Code: 503", nil))
+
+		res, err := client.Get(fmt.Sprintf("/outbox/%d", msg.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		body := string(test.ReadBody(res))
+		assert.Contains(t, body, `"kind":"webhook"`)
+		assert.Contains(t, body, `"destination":"password_changed"`)
+		assert.Contains(t, body, `"status":"failed"`)
+		assert.Contains(t, body, `"attempts":1`)
+		assert.Contains(t, body, `"response_code":503`)
+		assert.Contains(t, body, `"last_error":"Status Code: 503"`)
+		assert.NotContains(t, body, "account_id=1")
+	})
+
+	t.Run("unknown message", func(t *testing.T) {
+		res, err := client.Get("/outbox/999")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"message", services.ErrNotFound}})
+	})
+}
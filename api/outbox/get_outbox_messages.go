@@ -0,0 +1,51 @@
+package outbox
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
+)
+
+const (
+	defaultMessagesLimit = 50
+	maxMessagesLimit     = 500
+)
+
+func getOutboxMessages(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		kind := r.FormValue("kind")
+		status := r.FormValue("status")
+
+		if kind != "" && kind != models.OutboxEvent && kind != models.OutboxWebhook {
+			api.WriteErrors(w, services.FieldErrors{{"kind", services.ErrFormatInvalid}})
+			return
+		}
+		if status != "" && status != models.OutboxPending && status != models.OutboxDelivered && status != models.OutboxFailed {
+			api.WriteErrors(w, services.FieldErrors{{"status", services.ErrFormatInvalid}})
+			return
+		}
+		limit := defaultMessagesLimit
+		if r.FormValue("limit") != "" {
+			val, err := strconv.Atoi(r.FormValue("limit"))
+			if err != nil || val < 1 || val > maxMessagesLimit {
+				api.WriteErrors(w, services.FieldErrors{{"limit", services.ErrFormatInvalid}})
+				return
+			}
+			limit = val
+		}
+
+		msgs, err := app.OutboxStore.FindRecent(kind, status, limit)
+		if err != nil {
+			panic(err)
+		}
+
+		result := []map[string]interface{}{}
+		for _, msg := range msgs {
+			result = append(result, messageData(msg))
+		}
+		api.WriteData(w, http.StatusOK, result)
+	}
+}
@@ -0,0 +1,69 @@
+package outbox_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/outbox"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOutboxMessages(t *testing.T) {
+	app := outboxApp()
+	server := test.Server(app, outbox.Routes(app))
+	defer server.Close()
+
+	_, err := app.OutboxStore.Create(models.OutboxEvent, "", "{}")
+	require.NoError(t, err)
+	failed, err := app.OutboxStore.Create(models.OutboxWebhook, "password_changed", "account_id=1")
+	require.NoError(t, err)
+	require.NoError(t, app.OutboxStore.Failed(failed.ID, 500, "Status Code: 500", nil))
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("recent messages", func(t *testing.T) {
+		res, err := client.Get("/outbox/messages")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var body struct {
+			Result []struct {
+				ID     int    `json:"id"`
+				Kind   string `json:"kind"`
+				Status string `json:"status"`
+			} `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(test.ReadBody(res), &body))
+		require.Len(t, body.Result, 2)
+		assert.Equal(t, failed.ID, body.Result[0].ID)
+		assert.Equal(t, models.OutboxEvent, body.Result[1].Kind)
+	})
+
+	t.Run("failed webhooks", func(t *testing.T) {
+		res, err := client.Get("/outbox/messages?kind=webhook&status=failed&limit=10")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		body := string(test.ReadBody(res))
+		assert.Contains(t, body, `"status":"failed"`)
+		assert.NotContains(t, body, `"kind":"event"`)
+		assert.NotContains(t, body, "account_id=1")
+	})
+
+	t.Run("invalid filters", func(t *testing.T) {
+		res, err := client.Get("/outbox/messages?status=lost")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"status", services.ErrFormatInvalid}})
+
+		res, err = client.Get("/outbox/messages?limit=1000")
+		require.NoError(t, err)
+		test.AssertErrors(t, res, services.FieldErrors{{"limit", services.ErrFormatInvalid}})
+	})
+}
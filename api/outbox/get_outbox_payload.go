@@ -0,0 +1,49 @@
+package outbox
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/models"
+)
+
+// getOutboxPayload returns what was (or will be) delivered: the JSON of an event, or the params of
+// a webhook.
+func getOutboxPayload(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "message")
+			return
+		}
+
+		msg, err := app.OutboxStore.Find(id)
+		if err != nil {
+			panic(err)
+		}
+		if msg == nil {
+			api.WriteNotFound(w, "message")
+			return
+		}
+
+		var payload interface{}
+		if msg.Kind == models.OutboxWebhook {
+			payload, err = url.ParseQuery(msg.Payload)
+		} else {
+			payload = json.RawMessage(msg.Payload)
+		}
+		if err != nil {
+			panic(err)
+		}
+
+		api.WriteData(w, http.StatusOK, map[string]interface{}{
+			"id":      msg.ID,
+			"kind":    msg.Kind,
+			"payload": payload,
+		})
+	}
+}
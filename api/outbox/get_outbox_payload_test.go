@@ -0,0 +1,54 @@
+package outbox_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/outbox"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOutboxPayload(t *testing.T) {
+	app := outboxApp()
+	server := test.Server(app, outbox.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("event", func(t *testing.T) {
+		msg, err := app.OutboxStore.Create(models.OutboxEvent, "", `{"id":"abc","type":"account.locked"}`)
+		require.NoError(t, err)
+
+		res, err := client.Get(fmt.Sprintf("/outbox/%d/payload", msg.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t,
+			fmt.Sprintf(`{"result":{"id":%d,"kind":"event","payload":{"id":"abc","type":"account.locked"}}}`, msg.ID),
+			string(test.ReadBody(res)),
+		)
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		msg, err := app.OutboxStore.Create(models.OutboxWebhook, "password_changed", "account_id=42")
+		require.NoError(t, err)
+
+		res, err := client.Get(fmt.Sprintf("/outbox/%d/payload", msg.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t,
+			fmt.Sprintf(`{"result":{"id":%d,"kind":"webhook","payload":{"account_id":["42"]}}}`, msg.ID),
+			string(test.ReadBody(res)),
+		)
+	})
+
+	t.Run("unknown message", func(t *testing.T) {
+		res, err := client.Get("/outbox/999/payload")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -0,0 +1,53 @@
+package outbox_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/api/outbox"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func outboxApp() *api.App {
+	app := test.App()
+	app.Config.EnableOutbox = true
+	app.OutboxStore = mock.NewOutboxStore()
+	app.WebhookSecretStore = mock.NewWebhookSecretStore()
+	app.Config.DBEncryptionKey = []byte("DLz2TNDRdWWA5w8YNeCJ7uzcS4WDzQmB")
+	return app
+}
+
+func TestGetOutbox(t *testing.T) {
+	app := outboxApp()
+	server := test.Server(app, outbox.Routes(app))
+	defer server.Close()
+
+	delivered, err := app.OutboxStore.Create(models.OutboxEvent, "", "{}")
+	require.NoError(t, err)
+	require.NoError(t, app.OutboxStore.Delivered(delivered.ID, 0))
+	_, err = app.OutboxStore.Create(models.OutboxEvent, "", "{}")
+	require.NoError(t, err)
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+	res, err := client.Get("/outbox")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `{"result":{"delivered":1,"failed":0,"pending":1}}`, string(test.ReadBody(res)))
+}
+
+func TestGetOutboxDisabled(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, outbox.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+	res, err := client.Get("/outbox")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
@@ -0,0 +1,34 @@
+package outbox
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+)
+
+func postOutboxRedeliver(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			api.WriteNotFound(w, "message")
+			return
+		}
+
+		ok, err := app.OutboxStore.Redeliver(id)
+		if err != nil {
+			panic(err)
+		}
+		if !ok {
+			api.WriteNotFound(w, "message")
+			return
+		}
+
+		msg, err := app.OutboxStore.Find(id)
+		if err != nil {
+			panic(err)
+		}
+		api.WriteData(w, http.StatusOK, messageData(msg))
+	}
+}
@@ -0,0 +1,46 @@
+package outbox_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/keratin/authn-server/api/outbox"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostOutboxRedeliver(t *testing.T) {
+	app := outboxApp()
+	server := test.Server(app, outbox.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("failed message", func(t *testing.T) {
+		msg, err := app.OutboxStore.Create(models.OutboxWebhook, "password_changed", "account_id=1")
+		require.NoError(t, err)
+		require.NoError(t, app.OutboxStore.Failed(msg.ID, 500, "Status Code: 500", nil))
+
+		res, err := client.PostForm(fmt.Sprintf("/outbox/%d/redeliver", msg.ID), url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		body := string(test.ReadBody(res))
+		assert.Contains(t, body, `"status":"pending"`)
+		assert.Contains(t, body, `"attempts":0`)
+
+		found, err := app.OutboxStore.Find(msg.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.OutboxPending, found.Status)
+	})
+
+	t.Run("unknown message", func(t *testing.T) {
+		res, err := client.PostForm("/outbox/999/redeliver", url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -0,0 +1,55 @@
+package outbox
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+)
+
+// defaultSecretOverlap is how long a rotated secret continues to sign webhooks, unless specified.
+const defaultSecretOverlap = 24 * time.Hour
+
+func postWebhookSecret(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		endpoint := mux.Vars(r)["endpoint"]
+		known := false
+		for _, name := range services.WebhookEndpoints {
+			if endpoint == name {
+				known = true
+			}
+		}
+		if !known {
+			api.WriteNotFound(w, "endpoint")
+			return
+		}
+
+		overlap := defaultSecretOverlap
+		if r.FormValue("overlap") != "" {
+			val, err := strconv.Atoi(r.FormValue("overlap"))
+			if err != nil {
+				api.WriteErrors(w, services.FieldErrors{{"overlap", services.ErrFormatInvalid}})
+				return
+			}
+			overlap = time.Duration(val) * time.Second
+		}
+
+		secret, err := services.WebhookSecretRotator(app.WebhookSecretStore, app.Config, endpoint, overlap)
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		api.WriteData(w, http.StatusCreated, map[string]interface{}{
+			"endpoint": endpoint,
+			"secret":   secret,
+		})
+	}
+}
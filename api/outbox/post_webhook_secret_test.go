@@ -0,0 +1,61 @@
+package outbox_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api/outbox"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostWebhookSecret(t *testing.T) {
+	app := outboxApp()
+	server := test.Server(app, outbox.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	t.Run("rotating a secret", func(t *testing.T) {
+		res, err := client.PostForm("/outbox/secrets/password_changed", url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		var body struct {
+			Result struct {
+				Endpoint string `json:"endpoint"`
+				Secret   string `json:"secret"`
+			} `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(test.ReadBody(res), &body))
+		assert.Equal(t, "password_changed", body.Result.Endpoint)
+		assert.NotEmpty(t, body.Result.Secret)
+
+		res, err = client.PostForm("/outbox/secrets/password_changed", url.Values{"overlap": []string{"0"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		secrets, err := app.WebhookSecretStore.FindActive("password_changed", time.Now().Add(time.Second))
+		require.NoError(t, err)
+		assert.Len(t, secrets, 1)
+		assert.NotContains(t, secrets[0].Secret, body.Result.Secret)
+	})
+
+	t.Run("unknown endpoint", func(t *testing.T) {
+		res, err := client.PostForm("/outbox/secrets/account_created", url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("invalid overlap", func(t *testing.T) {
+		res, err := client.PostForm("/outbox/secrets/password_changed", url.Values{"overlap": []string{"-1"}})
+		require.NoError(t, err)
+		test.AssertErrors(t, res, services.FieldErrors{{"overlap", services.ErrFormatInvalid}})
+	})
+}
@@ -0,0 +1,58 @@
+package outbox
+
+import (
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
+)
+
+func Routes(app *api.App) []*route.HandledRoute {
+	if !app.Config.EnableOutbox {
+		return nil
+	}
+
+	stats := api.PrivateSecurity(app, models.ScopeStats)
+	admin := api.PrivateSecurity(app, models.ScopeAdmin)
+
+	return []*route.HandledRoute{
+		route.Get("/outbox").
+			SecuredWith(stats).
+			Handle(getOutbox(app)),
+
+		route.Get("/outbox/messages").
+			SecuredWith(stats).
+			Handle(getOutboxMessages(app)),
+
+		route.Get("/outbox/{id:[0-9]+}").
+			SecuredWith(stats).
+			Handle(getOutboxMessage(app)),
+
+		route.Get("/outbox/{id:[0-9]+}/payload").
+			SecuredWith(admin).
+			Handle(getOutboxPayload(app)),
+
+		route.Post("/outbox/{id:[0-9]+}/redeliver").
+			SecuredWith(admin).
+			Handle(postOutboxRedeliver(app)),
+
+		route.Post("/outbox/secrets/{endpoint}").
+			SecuredWith(admin).
+			Handle(postWebhookSecret(app)),
+	}
+}
+
+// messageData describes the delivery status of a message, without its payload.
+func messageData(msg *models.OutboxMessage) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              msg.ID,
+		"kind":            msg.Kind,
+		"destination":     msg.Destination,
+		"status":          msg.Status,
+		"attempts":        msg.Attempts,
+		"response_code":   msg.ResponseCode,
+		"last_error":      msg.LastError,
+		"created_at":      msg.CreatedAt,
+		"next_attempt_at": msg.NextAttemptAt,
+		"delivered_at":    msg.DeliveredAt,
+	}
+}
@@ -4,18 +4,22 @@ import (
 	"net/http"
 
 	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
 	"github.com/keratin/authn-server/services"
 )
 
 func getPasswordReset(app *api.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		account, err := app.AccountStore.FindByUsername(r.FormValue("username"))
+		account, err := app.Accounts(r).FindByUsername(r.FormValue("username"))
 		if err != nil {
 			panic(err)
 		}
 
 		// run in the background so that a timing attack can't enumerate usernames
 		go func() {
+			if account != nil {
+				app.Events.Emit(events.PasswordResetRequested, account.ID)
+			}
 			err := services.PasswordResetSender(app.Config, account)
 			if err != nil {
 				app.Reporter.ReportRequestError(err, r)
@@ -3,9 +3,11 @@ package passwords_test
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/keratin/authn-server/api/passwords"
 	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/events"
 	"github.com/keratin/authn-server/lib/route"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -13,20 +15,30 @@ import (
 
 func TestGetPasswordReset(t *testing.T) {
 	app := test.App()
+	broker := events.NewBroker()
+	app.Events = app.Events.Notify(app.Reporter, broker)
 	server := test.Server(app, passwords.Routes(app))
 	defer server.Close()
 
 	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
 
 	t.Run("known account", func(t *testing.T) {
-		_, err := app.AccountStore.Create("known@keratin.tech", []byte("pwd"))
+		account, err := app.AccountStore.Create("known@keratin.tech", []byte("pwd"))
 		require.NoError(t, err)
+		received, unsubscribe := broker.Subscribe(account.ID)
+		defer unsubscribe()
 
 		res, err := client.Get("/password/reset?username=known@keratin.tech")
 		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, res.StatusCode)
 
-		// TODO: assert go routine?
+		// the event is emitted in the background, with the email
+		select {
+		case e := <-received:
+			assert.Equal(t, events.PasswordResetRequested, e.Type)
+		case <-time.After(time.Second):
+			t.Fatal("no event was emitted")
+		}
 	})
 
 	t.Run("unknown account", func(t *testing.T) {
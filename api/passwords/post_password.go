@@ -4,21 +4,40 @@ import (
 	"net/http"
 
 	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
 	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
 	"github.com/keratin/authn-server/services"
+	"github.com/pkg/errors"
 )
 
 func postPassword(app *api.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var err error
+		jkt, err := api.DPoPThumbprint(app.Config, app.DPoPProofs, r)
+		if err != nil {
+			api.WriteErrors(w, services.FieldErrors{{"dpop", services.ErrInvalidOrExpired}})
+			return
+		}
+
+		// with PASSWORD_CHANGE_KEEP_SESSION, the current session survives when other sessions of the
+		// account are revoked
+		var keep models.RefreshToken
+		session := api.GetSession(r)
+		if app.Config.PasswordChangeKeepSession && session != nil {
+			keep = models.RefreshToken(session.Subject)
+		}
+
 		var accountID int
 		if r.FormValue("token") != "" {
 			accountID, err = services.PasswordResetter(
-				app.AccountStore,
+				app.Accounts(r),
+				app.RefreshTokens(r),
+				app.OutboxStore,
 				app.Reporter,
 				app.Config,
 				r.FormValue("token"),
 				r.FormValue("password"),
+				keep,
 			)
 		} else {
 			accountID = api.GetSessionAccountID(r)
@@ -27,12 +46,15 @@ func postPassword(app *api.App) http.HandlerFunc {
 				return
 			}
 			err = services.PasswordChanger(
-				app.AccountStore,
+				app.Accounts(r),
+				app.RefreshTokens(r),
+				app.OutboxStore,
 				app.Reporter,
 				app.Config,
 				accountID,
 				r.FormValue("currentPassword"),
 				r.FormValue("password"),
+				keep,
 			)
 		}
 
@@ -45,18 +67,39 @@ func postPassword(app *api.App) http.HandlerFunc {
 			panic(err)
 		}
 
-		err = api.RevokeSession(app.RefreshTokenStore, app.Config, r)
+		app.Events.Emit(events.PasswordChanged, accountID)
+
+		if keep != "" && api.GetSessionAccountID(r) == accountID {
+			identityToken, err := api.IdentityForSession(app.KeyStore, app.AccessTokenStore, app.Accounts(r), app.Actives, app.Config, session, accountID, route.MatchedDomain(r))
+			if err != nil {
+				panic(errors.Wrap(err, "IdentityForSession"))
+			}
+			api.SetAccessToken(app.Config, w, route.MatchedDomain(r), identityToken)
+
+			api.WriteData(w, http.StatusCreated, map[string]string{
+				"id_token": identityToken,
+			})
+			return
+		}
+
+		err = api.RevokeSession(app.RefreshTokens(r), app.Config, r)
 		if err != nil {
 			app.Reporter.ReportRequestError(err, r)
 		}
 
-		sessionToken, identityToken, err := api.NewSession(app.RefreshTokenStore, app.KeyStore, app.Actives, app.Config, accountID, route.MatchedDomain(r))
+		sessionToken, identityToken, err := api.NewSession(app.RefreshTokens(r), app.KeyStore, app.AccessTokenStore, app.Accounts(r), app.Actives, app.Config, accountID, route.MatchedDomain(r), api.SessionFingerprint(app.Config, r), jkt, []string{"pwd"})
 		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
 			panic(err)
 		}
 
 		// Return the signed session in a cookie
-		api.SetSession(app.Config, w, sessionToken)
+		api.SetSession(app.Config, w, route.MatchedDomain(r), sessionToken)
+		api.SetAccessToken(app.Config, w, route.MatchedDomain(r), identityToken)
 
 		// Return the signed identity token in the body
 		api.WriteData(w, http.StatusCreated, map[string]string{
@@ -105,6 +105,38 @@ func TestPostPassword(t *testing.T) {
 		assert.Empty(t, id)
 	})
 
+	t.Run("valid session with other sessions", func(t *testing.T) {
+		// given an account
+		account, err := factory("other.sessions@authn.tech", "oldpwd")
+		require.NoError(t, err)
+
+		// given a session on this device and another
+		session := test.CreateSession(app.RefreshTokenStore, app.Config, account.ID)
+		other := test.CreateSession(app.RefreshTokenStore, app.Config, account.ID)
+
+		// invoking the endpoint
+		res, err := client.WithCookie(session).PostForm("/password", url.Values{
+			"currentPassword": []string{"oldpwd"},
+			"password":        []string{"0a0b0c0d0"},
+		})
+		require.NoError(t, err)
+
+		// works
+		assertSuccess(t, res, account)
+
+		// invalidates the other session
+		claims, err := sessions.Parse(other.Value, app.Config)
+		require.NoError(t, err)
+		id, err := app.RefreshTokenStore.Find(models.RefreshToken(claims.Subject))
+		require.NoError(t, err)
+		assert.Empty(t, id)
+
+		// keeps the new session
+		tokens, err := app.RefreshTokenStore.FindAll(account.ID)
+		require.NoError(t, err)
+		assert.Len(t, tokens, 1)
+	})
+
 	t.Run("valid session and bad password", func(t *testing.T) {
 		// given an account
 		account, err := factory("bad.password@authn.tech", "oldpwd")
@@ -186,3 +218,57 @@ func TestPostPassword(t *testing.T) {
 		assertSuccess(t, res, tokenAccount)
 	})
 }
+
+func TestPostPasswordWithoutPasswordLogin(t *testing.T) {
+	app := test.App()
+	app.Config.DisablePasswordLogin = true
+	server := test.Server(app, passwords.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+	res, err := client.PostForm("/password", url.Values{
+		"token":    []string{"token"},
+		"password": []string{"0a0b0c0"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestPostPasswordKeepSession(t *testing.T) {
+	app := test.App()
+	app.Config.PasswordChangeKeepSession = true
+	server := test.Server(app, passwords.Routes(app))
+	defer server.Close()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("oldpwd"), app.Config.BcryptCost)
+	require.NoError(t, err)
+	account, err := app.AccountStore.Create("kept.session@authn.tech", hash)
+	require.NoError(t, err)
+
+	session := test.CreateSession(app.RefreshTokenStore, app.Config, account.ID)
+	other := test.CreateSession(app.RefreshTokenStore, app.Config, account.ID)
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+	res, err := client.WithCookie(session).PostForm("/password", url.Values{
+		"currentPassword": []string{"oldpwd"},
+		"password":        []string{"0a0b0c0d0"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	test.AssertIDTokenResponse(t, res, app.KeyStore, app.Config)
+
+	// keeps the current session instead of issuing a new one
+	assert.Nil(t, test.ReadCookie(res.Cookies(), app.Config.SessionCookieName))
+	claims, err := sessions.Parse(session.Value, app.Config)
+	require.NoError(t, err)
+	id, err := app.RefreshTokenStore.Find(models.RefreshToken(claims.Subject))
+	require.NoError(t, err)
+	assert.Equal(t, account.ID, id)
+
+	// invalidates the other session
+	claims, err = sessions.Parse(other.Value, app.Config)
+	require.NoError(t, err)
+	id, err = app.RefreshTokenStore.Find(models.RefreshToken(claims.Subject))
+	require.NoError(t, err)
+	assert.Empty(t, id)
+}
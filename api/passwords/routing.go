@@ -6,6 +6,11 @@ import (
 )
 
 func PublicRoutes(app *api.App) []*route.HandledRoute {
+	// Password resets log in as well, so they go with password logins.
+	if app.Config.DisablePasswordLogin {
+		return nil
+	}
+
 	originSecurity := route.OriginSecurity(app.Config.ApplicationDomains)
 
 	routes := []*route.HandledRoute{
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
+)
+
+// PrivateSecurity is the SecurityHandler of the private API. It accepts the shared
+// HTTP_AUTH_USERNAME and HTTP_AUTH_PASSWORD, which grant every scope, or the credentials of an API
+// key. Keys that do not grant the scope are forbidden.
+func PrivateSecurity(app *App, scope string) route.SecurityHandler {
+	shared := route.BasicAuthSecurity(app.Config.AuthUsername, app.Config.AuthPassword, "Private AuthN Realm")
+
+	return func(h http.Handler) http.Handler {
+		sharedHandler := shared(h)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			if !ok || app.APIKeyStore == nil || !strings.HasPrefix(user, models.APIKeyUsernamePrefix) {
+				sharedHandler.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := services.APIKeyVerifier(app.APIKeyStore, user, pass)
+			if err != nil {
+				panic(err)
+			}
+			if key == nil {
+				sharedHandler.ServeHTTP(w, r)
+				return
+			}
+			if !key.Allows(scope) {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte("Forbidden.\n"))
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
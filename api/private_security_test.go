@@ -0,0 +1,68 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivateSecurity(t *testing.T) {
+	app := test.App()
+	app.Config.AuthUsername = "admin"
+	app.Config.AuthPassword = "secret"
+	handler := api.PrivateSecurity(app, models.ScopeReadOnly)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(username string, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/accounts/1", nil)
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	reader, readerSecret, err := services.APIKeyCreator(app.APIKeyStore, "reader", []string{models.ScopeReadOnly})
+	require.NoError(t, err)
+	manager, managerSecret, err := services.APIKeyCreator(app.APIKeyStore, "manager", []string{models.ScopeAccountAdmin})
+	require.NoError(t, err)
+	monitor, monitorSecret, err := services.APIKeyCreator(app.APIKeyStore, "monitor", []string{models.ScopeStats})
+	require.NoError(t, err)
+	revoked, revokedSecret, err := services.APIKeyCreator(app.APIKeyStore, "revoked", []string{models.ScopeAdmin})
+	require.NoError(t, err)
+	_, err = app.APIKeyStore.Revoke(revoked.ID)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		username string
+		password string
+		code     int
+	}{
+		{"shared credentials", "admin", "secret", http.StatusOK},
+		{"no credentials", "", "", http.StatusUnauthorized},
+		{"wrong shared password", "admin", "guess", http.StatusUnauthorized},
+		{"key with scope", reader.Username(), readerSecret, http.StatusOK},
+		{"key with broader scope", manager.Username(), managerSecret, http.StatusOK},
+		{"key without scope", monitor.Username(), monitorSecret, http.StatusForbidden},
+		{"wrong key secret", reader.Username(), monitorSecret, http.StatusUnauthorized},
+		{"revoked key", revoked.Username(), revokedSecret, http.StatusUnauthorized},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := request(tc.username, tc.password)
+			assert.Equal(t, tc.code, res.Code)
+			if tc.code == http.StatusUnauthorized {
+				assert.NotEmpty(t, res.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
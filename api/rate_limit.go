@@ -0,0 +1,28 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/keratin/authn-server/services"
+)
+
+// SetRateLimit describes a client's limit in the RateLimit-* headers of the IETF draft, so that
+// well-behaved clients may slow down before they are refused. An exceeded limit also sets
+// Retry-After.
+func SetRateLimit(w http.ResponseWriter, rl *services.RateLimit) {
+	if rl == nil {
+		return
+	}
+
+	reset := strconv.Itoa(int(math.Ceil(rl.Reset.Seconds())))
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(rl.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(rl.Remaining))
+	w.Header().Set("RateLimit-Reset", reset)
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", rl.Limit, int(rl.Window.Seconds())))
+	if rl.Exceeded() {
+		w.Header().Set("Retry-After", reset)
+	}
+}
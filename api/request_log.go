@@ -0,0 +1,180 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveNames are redacted from every param and header that contains them, regardless of
+// LOG_REDACT_PARAMS or LOG_LEVEL.
+var sensitiveNames = []string{"password", "token", "secret", "cookie", "authorization", "dpop", "code", "state"}
+
+// RequestLog logs each request as a structured entry. Routes that match a LOG_SAMPLING rule are
+// logged at its rate, but requests that fail are always logged. Params and headers are only
+// logged at the debug level, and anything sensitive is redacted.
+func RequestLog(app *App) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			path := mountedPath(app, r)
+			query := r.URL.Query()
+
+			res := &loggedResponse{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(res, r)
+
+			if res.status < http.StatusBadRequest && !sampled(app, r.Method, path) {
+				return
+			}
+
+			redact := func(name string) bool { return isRedacted(app, name) }
+			fields := logrus.Fields{
+				"method":      r.Method,
+				"path":        r.URL.Path,
+				"status":      res.status,
+				"bytes":       res.size,
+				"duration_ms": time.Since(start).Seconds() * 1000,
+				"remote_addr": r.RemoteAddr,
+				"user_agent":  r.UserAgent(),
+			}
+			if len(query) > 0 {
+				fields["query"] = redactValues(query, redact).Encode()
+			}
+			if referer := r.Referer(); referer != "" {
+				fields["referer"] = redactURL(referer, redact)
+			}
+			if logrus.GetLevel() >= logrus.DebugLevel {
+				fields["params"] = flatten(redactValues(requestParams(r, query), redact))
+				fields["headers"] = flatten(redactHeaders(r.Header, redact))
+			}
+
+			entry := logrus.WithFields(fields)
+			if res.status >= http.StatusInternalServerError {
+				entry.Error("request")
+			} else {
+				entry.Info("request")
+			}
+		})
+	}
+}
+
+// sampled decides whether to log a successful request.
+func sampled(app *App, method string, path string) bool {
+	for _, rule := range app.Config.LogSampling {
+		if rule.Matches(method, path) {
+			return rand.Float64() < rule.Rate
+		}
+	}
+	return true
+}
+
+func isRedacted(app *App, name string) bool {
+	lower := strings.ToLower(name)
+	for _, sensitive := range sensitiveNames {
+		if strings.Contains(lower, sensitive) {
+			return true
+		}
+	}
+	for _, configured := range app.Config.LogRedactedParams {
+		if strings.EqualFold(name, configured) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestParams are the params that the handler parsed, or the query when it parsed none.
+func requestParams(r *http.Request, query url.Values) url.Values {
+	if r.Form != nil {
+		return r.Form
+	}
+	return query
+}
+
+func redactValues(values url.Values, redact func(string) bool) url.Values {
+	clean := url.Values{}
+	for name, vals := range values {
+		if redact(name) {
+			clean[name] = []string{redacted}
+		} else {
+			clean[name] = vals
+		}
+	}
+	return clean
+}
+
+// redactHeaders also redacts the query and credentials of headers that carry a URL.
+func redactHeaders(header http.Header, redact func(string) bool) url.Values {
+	clean := redactValues(url.Values(header), redact)
+	for _, name := range []string{"Referer", "Origin"} {
+		if vals, ok := clean[name]; ok {
+			redactedVals := make([]string, len(vals))
+			for i, val := range vals {
+				redactedVals[i] = redactURL(val, redact)
+			}
+			clean[name] = redactedVals
+		}
+	}
+	return clean
+}
+
+func redactURL(raw string, redact func(string) bool) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+	u.User = nil
+	if u.RawQuery != "" {
+		u.RawQuery = redactValues(u.Query(), redact).Encode()
+	}
+	u.Fragment = ""
+	return u.String()
+}
+
+func flatten(values url.Values) map[string]string {
+	flat := map[string]string{}
+	for name, vals := range values {
+		flat[name] = strings.Join(vals, ",")
+	}
+	return flat
+}
+
+// loggedResponse remembers the status and size of a response.
+type loggedResponse struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (l *loggedResponse) WriteHeader(status int) {
+	l.status = status
+	l.ResponseWriter.WriteHeader(status)
+}
+
+func (l *loggedResponse) Write(b []byte) (int, error) {
+	n, err := l.ResponseWriter.Write(b)
+	l.size += n
+	return n, err
+}
+
+func (l *loggedResponse) Flush() {
+	if f, ok := l.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (l *loggedResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := l.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("response does not support hijacking")
+}
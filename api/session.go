@@ -47,7 +47,7 @@ func Session(app *App) func(http.Handler) http.Handler {
 						return
 					}
 
-					accountID, err = app.RefreshTokenStore.Find(models.RefreshToken(session.Subject))
+					accountID, err = app.RefreshTokens(r).Find(models.RefreshToken(session.Subject))
 					if err != nil {
 						app.Reporter.ReportRequestError(errors.Wrap(err, "Find"), r)
 					}
@@ -1,29 +1,50 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net"
 	"net/http"
+	"net/url"
+	"regexp"
+	"time"
 
 	"github.com/keratin/authn-server/config"
 	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/geoip"
 	"github.com/keratin/authn-server/lib/route"
 	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/dpop"
 	"github.com/keratin/authn-server/tokens/identities"
 	"github.com/keratin/authn-server/tokens/sessions"
 	"github.com/pkg/errors"
 )
 
-func NewSession(refreshTokenStore data.RefreshTokenStore, keyStore data.KeyStore, actives data.Actives, cfg *config.Config, accountID int, authorizedAudience *route.Domain) (string, string, error) {
+func NewSession(refreshTokenStore data.RefreshTokenStore, keyStore data.KeyStore, accessTokenStore data.AccessTokenStore, accountStore data.AccountStore, actives data.Actives, cfg *config.Config, accountID int, authorizedAudience *route.Domain, fingerprint string, jkt string, amr []string) (string, string, error) {
 	session, err := sessions.New(refreshTokenStore, cfg, accountID, authorizedAudience.String())
-	if err != nil {
+	if err == sessions.ErrLimitReached {
+		return "", "", services.FieldErrors{{"account", services.ErrLimitReached}}
+	} else if err != nil {
 		return "", "", errors.Wrap(err, "New")
 	}
+	session.Fingerprint = fingerprint
+	session.Amr = amr
+	for _, method := range amr {
+		if method == "mfa" {
+			session.StepUp()
+		}
+	}
+	if jkt != "" {
+		session.Cnf = &sessions.Confirmation{Jkt: jkt}
+	}
 
 	sessionToken, err := session.Sign(cfg.SessionSigningKey)
 	if err != nil {
 		return "", "", errors.Wrap(err, "Sign")
 	}
 
-	identityToken, err := IdentityForSession(keyStore, actives, cfg, session, accountID, authorizedAudience)
+	identityToken, err := IdentityForSession(keyStore, accessTokenStore, accountStore, actives, cfg, session, accountID, authorizedAudience)
 	if err != nil {
 		return "", "", errors.Wrap(err, "IdentityForSession")
 	}
@@ -31,6 +52,43 @@ func NewSession(refreshTokenStore data.RefreshTokenStore, keyStore data.KeyStore
 	return sessionToken, identityToken, nil
 }
 
+var versionPattern = regexp.MustCompile(`[0-9]+`)
+
+// SessionFingerprint summarizes the client that a session will be bound to, when SESSION_BINDING is
+// enabled. It considers the network prefix of the IP address (/24 for IPv4, /48 for IPv6) and the
+// user agent without version numbers, so that moving within a network or updating a browser will
+// not end a session.
+func SessionFingerprint(cfg *config.Config, r *http.Request) string {
+	if !cfg.SessionBinding {
+		return ""
+	}
+
+	prefix := ""
+	if ip := geoip.ParseRemoteAddr(r.RemoteAddr); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			prefix = ip4.Mask(net.CIDRMask(24, 32)).String()
+		} else {
+			prefix = ip.Mask(net.CIDRMask(48, 128)).String()
+		}
+	}
+	agent := versionPattern.ReplaceAllString(r.UserAgent(), "")
+
+	sum := sha256.Sum256([]byte(prefix + "\n" + agent))
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+// DPoPThumbprint verifies the DPoP proof of a request, if any, and returns the thumbprint of the
+// client's key. It returns an empty string when the request has no proof. Each proof may only be
+// used once.
+func DPoPThumbprint(cfg *config.Config, proofs dpop.Proofs, r *http.Request) (string, error) {
+	proof := r.Header.Get("DPoP")
+	if proof == "" {
+		return "", nil
+	}
+	htu := url.URL{Scheme: cfg.AuthNURL.Scheme, Host: cfg.AuthNURL.Host, Path: r.URL.Path}
+	return dpop.ParseOnce(proof, r.Method, htu.String(), time.Now(), proofs)
+}
+
 func RevokeSession(refreshTokenStore data.RefreshTokenStore, cfg *config.Config, r *http.Request) (err error) {
 	oldSession := GetSession(r)
 	if oldSession == nil {
@@ -39,7 +97,7 @@ func RevokeSession(refreshTokenStore data.RefreshTokenStore, cfg *config.Config,
 	return refreshTokenStore.Revoke(models.RefreshToken(oldSession.Subject))
 }
 
-func SetSession(cfg *config.Config, w http.ResponseWriter, val string) {
+func SetSession(cfg *config.Config, w http.ResponseWriter, audience *route.Domain, val string) {
 	cookie := &http.Cookie{
 		Name:     cfg.SessionCookieName,
 		Value:    val,
@@ -47,20 +105,84 @@ func SetSession(cfg *config.Config, w http.ResponseWriter, val string) {
 		Secure:   cfg.ForceSSL,
 		HttpOnly: true,
 	}
+	if audience != nil {
+		cookie.SameSite = cfg.Application(audience.String()).SameSite
+	}
+	if val == "" {
+		cookie.MaxAge = -1
+		SetAccessToken(cfg, w, audience, "")
+	}
+	http.SetCookie(w, cookie)
+}
+
+// SetAccessToken mirrors an identity token into a cookie that JavaScript may read, when
+// ACCESS_TOKEN_COOKIE_NAME is configured. It expires with the token, so legacy frontends that can
+// not call the refresh endpoint will find it missing and must redirect through AuthN again.
+func SetAccessToken(cfg *config.Config, w http.ResponseWriter, audience *route.Domain, val string) {
+	if cfg.AccessTokenCookieName == "" {
+		return
+	}
+	cookie := &http.Cookie{
+		Name:   cfg.AccessTokenCookieName,
+		Value:  val,
+		Path:   "/",
+		Secure: cfg.ForceSSL,
+	}
+	if audience != nil {
+		cookie.SameSite = cfg.Application(audience.String()).SameSite
+		cookie.MaxAge = int(cfg.AccessTokenTTLFor(audience.String()).Seconds())
+	}
 	if val == "" {
 		cookie.MaxAge = -1
 	}
 	http.SetCookie(w, cookie)
 }
 
-func IdentityForSession(keyStore data.KeyStore, actives data.Actives, cfg *config.Config, session *sessions.Claims, accountID int, audience *route.Domain) (string, error) {
+func IdentityForSession(keyStore data.KeyStore, accessTokenStore data.AccessTokenStore, accountStore data.AccountStore, actives data.Actives, cfg *config.Config, session *sessions.Claims, accountID int, audience *route.Domain) (string, error) {
 	if actives != nil {
 		actives.Track(accountID)
 	}
-	identityToken, err := identities.New(cfg, session, accountID, audience.String()).Sign(keyStore.Key())
+	identity := identities.New(cfg, session, accountID, audience.String())
+	if identities.Allows(cfg, "username") || identities.Allows(cfg, "password_changed_at") {
+		account, err := accountStore.Find(accountID)
+		if err != nil {
+			return "", errors.Wrap(err, "Find")
+		}
+		if account != nil {
+			identity.WithAccount(cfg, account)
+		}
+	}
+
+	if cfg.OpaqueAccessTokens {
+		meta := &models.AccessToken{
+			AccountID: accountID,
+			Session:   models.RefreshToken(session.Subject),
+			Audience:  audience.String(),
+			Scope:     identity.Scope,
+			IssuedAt:  identity.IssuedAt.Time(),
+			ExpiresAt: identity.Expiry.Time(),
+		}
+		if identity.Cnf != nil {
+			meta.Jkt = identity.Cnf.Jkt
+		}
+		accessToken, err := accessTokenStore.Create(meta)
+		if err != nil {
+			return "", errors.Wrap(err, "Create")
+		}
+		return accessToken, nil
+	}
+
+	identityToken, err := identity.Sign(keyStore.Key())
 	if err != nil {
 		return "", errors.Wrap(err, "New")
 	}
 
+	if key, ok := cfg.IdentityEncryptionKeys[audience.String()]; ok {
+		identityToken, err = identities.Encrypt(identityToken, key)
+		if err != nil {
+			return "", errors.Wrap(err, "Encrypt")
+		}
+	}
+
 	return identityToken, nil
 }
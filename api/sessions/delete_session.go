@@ -4,16 +4,27 @@ import (
 	"net/http"
 
 	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+	"github.com/pkg/errors"
 )
 
 func deleteSession(app *api.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		err := api.RevokeSession(app.RefreshTokenStore, app.Config, r)
+		accountID := api.GetSessionAccountID(r)
+		err := api.RevokeSession(app.RefreshTokens(r), app.Config, r)
 		if err != nil {
 			app.Reporter.ReportRequestError(err, r)
+		} else if accountID != 0 {
+			app.Events.Emit(events.SessionRevoked, accountID)
+
+			err = services.LogoutNotifier(app.Config, app.KeyStore, app.Reporter, api.GetSession(r).Subject, accountID)
+			if err != nil {
+				app.Reporter.ReportRequestError(errors.Wrap(err, "LogoutNotifier"), r)
+			}
 		}
 
-		api.SetSession(app.Config, w, "")
+		api.SetSession(app.Config, w, nil, "")
 
 		w.WriteHeader(http.StatusOK)
 	}
@@ -0,0 +1,126 @@
+package sessions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/models"
+)
+
+// sessionEventTypes are the account events that a session is told about. Others describe details
+// that the client has no use for, or that another session should not learn.
+var sessionEventTypes = map[string]bool{
+	events.AccountUpdated:  true,
+	events.AccountLocked:   true,
+	events.AccountArchived: true,
+	events.PasswordChanged: true,
+	events.PasswordExpired: true,
+}
+
+// sessionEventsHeartbeat is how often the stream is kept alive, and the session is checked for a
+// revocation that was not emitted in this process.
+const sessionEventsHeartbeat = 15 * time.Second
+
+// sessionEventsDuration is how long a stream lasts without a SERVER_WRITE_TIMEOUT. The client will
+// reconnect.
+const sessionEventsDuration = 5 * time.Minute
+
+type sessionEvent struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+}
+
+func getSessionEvents(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// check for valid session with live token
+		accountID := api.GetSessionAccountID(r)
+		if accountID == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		session := api.GetSession(r)
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			panic("getSessionEvents: response does not support flushing")
+		}
+
+		ch, unsubscribe := app.Broker.Subscribe(accountID)
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "retry: 1000\n\n")
+		flusher.Flush()
+
+		// end the stream before the server would cut it off
+		deadline := time.NewTimer(sessionEventsLifetime(app.Config))
+		defer deadline.Stop()
+		heartbeat := time.NewTicker(sessionEventsHeartbeat)
+		defer heartbeat.Stop()
+
+		// the token is checked without the request's budget, which a stream would soon spend
+		revoked := func() bool {
+			id, err := app.RefreshTokenStore.Find(models.RefreshToken(session.Subject))
+			if err != nil {
+				app.Reporter.ReportRequestError(err, r)
+				return false
+			}
+			return id != accountID
+		}
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-deadline.C:
+				return
+			case <-heartbeat.C:
+				if revoked() {
+					writeSessionEvent(w, sessionEvent{Type: events.SessionRevoked, Time: time.Now().UTC()})
+					flusher.Flush()
+					return
+				}
+				fmt.Fprint(w, ": heartbeat\n\n")
+				flusher.Flush()
+			case event := <-ch:
+				if event.Type == events.SessionRevoked {
+					// another of the account's sessions may have been revoked
+					if revoked() {
+						writeSessionEvent(w, sessionEvent{Type: event.Type, Time: event.Time})
+						flusher.Flush()
+						return
+					}
+					continue
+				}
+				if sessionEventTypes[event.Type] {
+					writeSessionEvent(w, sessionEvent{Type: event.Type, Time: event.Time})
+					flusher.Flush()
+				}
+			}
+		}
+	}
+}
+
+func writeSessionEvent(w http.ResponseWriter, event sessionEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+}
+
+// sessionEventsLifetime leaves time within SERVER_WRITE_TIMEOUT to end the stream cleanly.
+func sessionEventsLifetime(cfg *config.Config) time.Duration {
+	if cfg.ServerWriteTimeout <= 0 {
+		return sessionEventsDuration
+	}
+	return cfg.ServerWriteTimeout * 9 / 10
+}
@@ -0,0 +1,88 @@
+package sessions_test
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+	"testing"
+
+	apiSessions "github.com/keratin/authn-server/api/sessions"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSessionEvents(t *testing.T) {
+	app := test.App()
+	app.Broker = events.NewBroker()
+	app.Events = app.Events.Notify(app.Reporter, app.Broker)
+	server := test.Server(app, apiSessions.Routes(app))
+	defer server.Close()
+
+	// readEvent returns the name and data of the next event, skipping comments and hints.
+	readEvent := func(t *testing.T, r *bufio.Reader) (string, string) {
+		var name, data string
+		for {
+			line, err := r.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "" && name != "":
+				return name, data
+			case strings.HasPrefix(line, "event: "):
+				name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+
+	t.Run("without a session", func(t *testing.T) {
+		client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+		res, err := client.Get("/session/events")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("with a session", func(t *testing.T) {
+		accountID := 6543
+		session := test.CreateSession(app.RefreshTokenStore, app.Config, accountID)
+		client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0]).WithCookie(session)
+		res, err := client.Get("/session/events")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+		assert.Equal(t, "no-store", res.Header.Get("Cache-Control"))
+		body := bufio.NewReader(res.Body)
+
+		// events that the session is not told about, or that belong to other accounts
+		app.Events.Emit(events.SessionCreated, accountID)
+		app.Events.Emit(events.PasswordChanged, accountID+1)
+
+		app.Events.Emit(events.PasswordChanged, accountID)
+		name, data := readEvent(t, body)
+		assert.Equal(t, events.PasswordChanged, name)
+		assert.Contains(t, data, `"type":"password.changed"`)
+		assert.NotContains(t, data, "account_id")
+
+		// another of the account's sessions
+		other := test.CreateSession(app.RefreshTokenStore, app.Config, accountID)
+		test.RevokeSession(app.RefreshTokenStore, app.Config, other)
+		app.Events.Emit(events.SessionRevoked, accountID)
+		app.Events.Emit(events.AccountUpdated, accountID)
+		name, _ = readEvent(t, body)
+		assert.Equal(t, events.AccountUpdated, name)
+
+		// this session
+		test.RevokeSession(app.RefreshTokenStore, app.Config, session)
+		app.Events.Emit(events.SessionRevoked, accountID)
+		name, _ = readEvent(t, body)
+		assert.Equal(t, events.SessionRevoked, name)
+
+		_, err = body.ReadString('\n')
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,120 @@
+package sessions
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/identities"
+	"github.com/pkg/errors"
+)
+
+// frontchannelLogout loads every front-channel logout URI in a hidden iframe, then continues to the
+// post-logout redirect once they have finished loading.
+var frontchannelLogout = template.Must(template.New("logout").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>Logging out</title>
+<noscript><meta http-equiv="refresh" content="3;url={{.Redirect}}"></noscript>
+</head>
+<body>
+{{range .Frames}}<iframe src="{{.}}" style="display:none"></iframe>
+{{end}}<script>window.onload = function() { window.location.replace({{.Redirect}}); };</script>
+</body>
+</html>
+`))
+
+// getSessionLogout implements OIDC RP-initiated logout. The id_token_hint proves which account the
+// relying party is logging out, so that a cross-site link can not end someone else's session.
+func getSessionLogout(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hint := r.FormValue("id_token_hint")
+		if hint == "" {
+			api.WriteErrors(w, services.FieldErrors{{"id_token_hint", services.ErrMissing}})
+			return
+		}
+		claims, err := identities.ParseHint(hint, app.Config, app.KeyStore.Keys())
+		if err != nil {
+			api.WriteErrors(w, services.FieldErrors{{"id_token_hint", services.ErrInvalidOrExpired}})
+			return
+		}
+
+		var sid string
+		accountID := api.GetSessionAccountID(r)
+		if accountID != 0 {
+			if claims.Subject != strconv.Itoa(accountID) {
+				api.WriteErrors(w, services.FieldErrors{{"id_token_hint", services.ErrInvalidOrExpired}})
+				return
+			}
+
+			refreshToken := api.GetSession(r).Subject
+			sid = identities.SessionID(refreshToken)
+			err = api.RevokeSession(app.RefreshTokens(r), app.Config, r)
+			if err != nil {
+				panic(errors.Wrap(err, "RevokeSession"))
+			}
+			app.Events.Emit(events.SessionRevoked, accountID)
+
+			err = services.LogoutNotifier(app.Config, app.KeyStore, app.Reporter, refreshToken, accountID)
+			if err != nil {
+				app.Reporter.ReportRequestError(errors.Wrap(err, "LogoutNotifier"), r)
+			}
+		}
+		api.SetSession(app.Config, w, nil, "")
+
+		redirect := postLogoutRedirect(app, r)
+
+		var frames []string
+		for _, application := range app.Config.Applications {
+			if application.FrontchannelLogoutURI == nil || sid == "" {
+				continue
+			}
+			frame := *application.FrontchannelLogoutURI
+			query := frame.Query()
+			query.Set("iss", app.Config.AuthNURL.String())
+			query.Set("sid", sid)
+			frame.RawQuery = query.Encode()
+			frames = append(frames, frame.String())
+		}
+		if len(frames) == 0 {
+			http.Redirect(w, r, redirect, http.StatusSeeOther)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		err = frontchannelLogout.Execute(w, struct {
+			Frames   []string
+			Redirect string
+		}{frames, redirect})
+		if err != nil {
+			panic(errors.Wrap(err, "Execute"))
+		}
+	}
+}
+
+// postLogoutRedirect returns the requested post_logout_redirect_uri when it belongs to an
+// APP_DOMAINS domain, or else the first domain. The state is passed along unchanged.
+func postLogoutRedirect(app *api.App, r *http.Request) string {
+	var redirect *url.URL
+	requested := r.FormValue("post_logout_redirect_uri")
+	if requested != "" && route.FindDomain(requested, app.Config.ApplicationDomains) != nil {
+		redirect, _ = url.Parse(requested)
+	}
+	if redirect == nil {
+		failsafe := app.Config.ApplicationDomains[0].URL()
+		redirect = &failsafe
+	}
+
+	if state := r.FormValue("state"); state != "" {
+		query := redirect.Query()
+		query.Set("state", state)
+		redirect.RawQuery = query.Encode()
+	}
+	return redirect.String()
+}
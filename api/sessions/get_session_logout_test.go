@@ -0,0 +1,117 @@
+package sessions_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	apiSessions "github.com/keratin/authn-server/api/sessions"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/identities"
+	"github.com/keratin/authn-server/tokens/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSessionLogout(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, apiSessions.Routes(app))
+	defer server.Close()
+
+	// don't follow redirects
+	http.DefaultClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	defer func() { http.DefaultClient.CheckRedirect = nil }()
+
+	idTokenHint := func(accountID int) string {
+		session := test.CreateSession(app.RefreshTokenStore, app.Config, accountID)
+		claims, err := sessions.Parse(session.Value, app.Config)
+		require.NoError(t, err)
+		identity, err := identities.New(app.Config, claims, accountID, "test.com").Sign(app.KeyStore.Key())
+		require.NoError(t, err)
+		return identity
+	}
+
+	t.Run("without a hint", func(t *testing.T) {
+		res, err := route.NewClient(server.URL).Get("/session/logout")
+		require.NoError(t, err)
+		test.AssertErrors(t, res, services.FieldErrors{{"id_token_hint", services.ErrMissing}})
+	})
+
+	t.Run("with an invalid hint", func(t *testing.T) {
+		res, err := route.NewClient(server.URL).Get("/session/logout?id_token_hint=nope")
+		require.NoError(t, err)
+		test.AssertErrors(t, res, services.FieldErrors{{"id_token_hint", services.ErrInvalidOrExpired}})
+	})
+
+	t.Run("with a hint for another account", func(t *testing.T) {
+		session := test.CreateSession(app.RefreshTokenStore, app.Config, 1)
+		client := route.NewClient(server.URL).WithCookie(session)
+		res, err := client.Get("/session/logout?id_token_hint=" + idTokenHint(2))
+		require.NoError(t, err)
+		test.AssertErrors(t, res, services.FieldErrors{{"id_token_hint", services.ErrInvalidOrExpired}})
+
+		claims, err := sessions.Parse(session.Value, app.Config)
+		require.NoError(t, err)
+		id, err := app.RefreshTokenStore.Find(models.RefreshToken(claims.Subject))
+		require.NoError(t, err)
+		assert.Equal(t, 1, id)
+	})
+
+	t.Run("revokes the session and redirects", func(t *testing.T) {
+		session := test.CreateSession(app.RefreshTokenStore, app.Config, 3)
+		client := route.NewClient(server.URL).WithCookie(session)
+		res, err := client.Get("/session/logout?id_token_hint=" + idTokenHint(3) +
+			"&post_logout_redirect_uri=" + url.QueryEscape("http://test.com/goodbye") + "&state=xyz")
+		require.NoError(t, err)
+		test.AssertRedirect(t, res, "http://test.com/goodbye?state=xyz")
+		assert.Empty(t, test.ReadCookie(res.Cookies(), app.Config.SessionCookieName).Value)
+
+		claims, err := sessions.Parse(session.Value, app.Config)
+		require.NoError(t, err)
+		id, err := app.RefreshTokenStore.Find(models.RefreshToken(claims.Subject))
+		require.NoError(t, err)
+		assert.Empty(t, id)
+	})
+
+	t.Run("ignores a foreign redirect", func(t *testing.T) {
+		res, err := route.NewClient(server.URL).Get("/session/logout?id_token_hint=" + idTokenHint(4) +
+			"&post_logout_redirect_uri=" + url.QueryEscape("http://evil.com/"))
+		require.NoError(t, err)
+		test.AssertRedirect(t, res, "http://test.com")
+	})
+}
+
+func TestGetSessionLogoutFrontchannel(t *testing.T) {
+	app := test.App()
+	frontchannel := &url.URL{Scheme: "https", Host: "test.com", Path: "/logout"}
+	app.Config.Applications = []config.ApplicationDomain{
+		{Domain: app.Config.ApplicationDomains[0], FrontchannelLogoutURI: frontchannel},
+	}
+	server := test.Server(app, apiSessions.Routes(app))
+	defer server.Close()
+
+	session := test.CreateSession(app.RefreshTokenStore, app.Config, 1)
+	claims, err := sessions.Parse(session.Value, app.Config)
+	require.NoError(t, err)
+	identity, err := identities.New(app.Config, claims, 1, "test.com").Sign(app.KeyStore.Key())
+	require.NoError(t, err)
+
+	client := route.NewClient(server.URL).WithCookie(session)
+	res, err := client.Get("/session/logout?id_token_hint=" + identity)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", res.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	sid := url.QueryEscape(identities.SessionID(claims.Subject))
+	assert.Contains(t, string(body), `<iframe src="https://test.com/logout?iss=`+url.QueryEscape(app.Config.AuthNURL.String())+`&amp;sid=`+sid+`"`)
+	assert.Contains(t, string(body), `window.location.replace("http://test.com")`)
+}
@@ -4,35 +4,89 @@ import (
 	"net/http"
 
 	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
 	"github.com/keratin/authn-server/lib/route"
 	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
 	"github.com/pkg/errors"
 )
 
+// errUnauthorized means that the client has no session that it may refresh.
+var errUnauthorized = errors.New("unauthorized")
+
 func getSessionRefresh(app *api.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// check for valid session with live token
-		accountID := api.GetSessionAccountID(r)
-		if accountID == 0 {
-			w.WriteHeader(http.StatusUnauthorized)
-			return
+		identityToken, err := refresh(app, w, r, route.MatchedDomain(r))
+		if err != nil {
+			if err == errUnauthorized {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+			panic(err)
 		}
+		api.SetAccessToken(app.Config, w, route.MatchedDomain(r), identityToken)
 
-		// refresh the refresh token
-		session := api.GetSession(r)
-		err := app.RefreshTokenStore.Touch(models.RefreshToken(session.Subject), accountID)
+		api.WriteData(w, http.StatusCreated, map[string]string{
+			"id_token": identityToken,
+		})
+	}
+}
+
+// refresh issues a fresh identity token for the current session, for the audience. It returns
+// errUnauthorized when the client may not refresh, and FieldErrors when the request is invalid.
+func refresh(app *api.App, w http.ResponseWriter, r *http.Request, audience *route.Domain) (string, error) {
+	// check for valid session with live token
+	accountID := api.GetSessionAccountID(r)
+	if accountID == 0 {
+		return "", errUnauthorized
+	}
+
+	// check that the session is still held by the client that created it
+	session := api.GetSession(r)
+	if session.Fingerprint != "" && app.Config.SessionBinding && session.Fingerprint != api.SessionFingerprint(app.Config, r) {
+		err := app.RefreshTokens(r).Revoke(models.RefreshToken(session.Subject))
 		if err != nil {
-			panic(errors.Wrap(err, "Touch"))
+			return "", errors.Wrap(err, "Revoke")
 		}
+		app.Events.Emit(events.SessionUnbound, accountID)
 
-		// generate the requested identity token
-		identityToken, err := api.IdentityForSession(app.KeyStore, app.Actives, app.Config, session, accountID, route.MatchedDomain(r))
-		if err != nil {
-			panic(errors.Wrap(err, "IdentityForSession"))
+		api.SetSession(app.Config, w, nil, "")
+		return "", errUnauthorized
+	}
+
+	// check that the client holds the key that the session is bound to
+	if session.Cnf != nil {
+		jkt, err := api.DPoPThumbprint(app.Config, app.DPoPProofs, r)
+		if err != nil || jkt != session.Cnf.Jkt {
+			return "", errUnauthorized
 		}
+	}
 
-		api.WriteData(w, http.StatusCreated, map[string]string{
-			"id_token": identityToken,
-		})
+	// narrow the scopes of the identity token, if requested
+	scopes, err := services.ScopeNarrower(session.Scopes, r.FormValue("scope"))
+	if err == nil && r.FormValue("scope") != "" {
+		err = services.StepUpChecker(app.Config, session, scopes)
+	}
+	if err != nil {
+		return "", err
+	}
+	scoped := *session
+	scoped.Scopes = scopes
+
+	// refresh the refresh token
+	err = app.RefreshTokens(r).Touch(models.RefreshToken(session.Subject), accountID)
+	if err != nil {
+		return "", errors.Wrap(err, "Touch")
+	}
+
+	// generate the requested identity token
+	identityToken, err := api.IdentityForSession(app.KeyStore, app.AccessTokenStore, app.Accounts(r), app.Actives, app.Config, &scoped, accountID, audience)
+	if err != nil {
+		return "", errors.Wrap(err, "IdentityForSession")
 	}
+	return identityToken, nil
 }
@@ -0,0 +1,72 @@
+package sessions
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/pkg/errors"
+)
+
+// refreshFrame posts the result of a refresh to the parent window. The target origin limits who may
+// read the message, so that a page that frames this one can not receive the token unless it is the
+// application that asked for it.
+var refreshFrame = template.Must(template.New("refresh").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>Refreshing session</title>
+</head>
+<body>
+<script>window.parent.postMessage({{.Message}}, {{.Origin}});</script>
+</body>
+</html>
+`))
+
+// getSessionRefreshFrame refreshes the session for a single-page application that loads it in a
+// hidden iframe, rather than calling the refresh endpoint with CORS. The application names its
+// origin, which must belong to APP_DOMAINS, and is the only page allowed to frame the response or
+// to read its message.
+func getSessionRefreshFrame(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin, err := url.Parse(r.FormValue("origin"))
+		domain := route.FindDomain(r.FormValue("origin"), app.Config.ApplicationDomains)
+		if err != nil || domain == nil || origin.Scheme == "" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("Origin is not a trusted host."))
+			return
+		}
+		targetOrigin := origin.Scheme + "://" + origin.Host
+
+		status := http.StatusOK
+		message := map[string]interface{}{"type": "authn:refresh"}
+		identityToken, err := refresh(app, w, r, domain)
+		if err == errUnauthorized {
+			status = http.StatusUnauthorized
+			message["error"] = "unauthorized"
+		} else if fe, ok := err.(services.FieldErrors); ok {
+			status = http.StatusUnprocessableEntity
+			message["errors"] = fe
+		} else if err != nil {
+			panic(err)
+		} else {
+			api.SetAccessToken(app.Config, w, domain, identityToken)
+			message["id_token"] = identityToken
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Security-Policy", "frame-ancestors "+targetOrigin)
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.WriteHeader(status)
+		err = refreshFrame.Execute(w, struct {
+			Message map[string]interface{}
+			Origin  string
+		}{message, targetOrigin})
+		if err != nil {
+			panic(errors.Wrap(err, "Execute"))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package sessions_test
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	apiSessions "github.com/keratin/authn-server/api/sessions"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/tokens/identities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestGetSessionRefreshFrame(t *testing.T) {
+	app := test.App()
+	app.Config.SessionScopes = []string{"profile", "billing"}
+	server := test.Server(app, apiSessions.Routes(app))
+	defer server.Close()
+
+	idToken := regexp.MustCompile(`"id_token":"([^"]+)"`)
+
+	t.Run("untrusted origin", func(t *testing.T) {
+		client := route.NewClient(server.URL).WithCookie(test.CreateSession(app.RefreshTokenStore, app.Config, 1))
+		res, err := client.Get("/session/refresh/frame?origin=http://evil.com")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		assert.NotRegexp(t, idToken, string(test.ReadBody(res)))
+	})
+
+	t.Run("without a session", func(t *testing.T) {
+		res, err := route.NewClient(server.URL).Get("/session/refresh/frame?origin=http://test.com")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, "frame-ancestors http://test.com", res.Header.Get("Content-Security-Policy"))
+		body := string(test.ReadBody(res))
+		assert.Contains(t, body, `"error":"unauthorized"`)
+		assert.Contains(t, body, `"http://test.com"`)
+	})
+
+	t.Run("with a session", func(t *testing.T) {
+		client := route.NewClient(server.URL).WithCookie(test.CreateSession(app.RefreshTokenStore, app.Config, 8642))
+		res, err := client.Get("/session/refresh/frame?origin=http://test.com/callback&scope=billing")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/html; charset=utf-8", res.Header.Get("Content-Type"))
+		assert.Equal(t, "no-store", res.Header.Get("Cache-Control"))
+		assert.Equal(t, "frame-ancestors http://test.com", res.Header.Get("Content-Security-Policy"))
+
+		match := idToken.FindStringSubmatch(string(test.ReadBody(res)))
+		require.Len(t, match, 2)
+		tok, err := jwt.ParseSigned(match[1])
+		require.NoError(t, err)
+		claims := identities.Claims{}
+		require.NoError(t, tok.Claims(app.KeyStore.Key().Public(), &claims))
+		assert.Equal(t, "8642", claims.Subject)
+		assert.Equal(t, "billing", claims.Scope)
+		assert.True(t, claims.Audience.Contains("test.com"))
+	})
+
+	t.Run("ungranted scope", func(t *testing.T) {
+		client := route.NewClient(server.URL).WithCookie(test.CreateSession(app.RefreshTokenStore, app.Config, 8643))
+		res, err := client.Get("/session/refresh/frame?origin=http://test.com&scope=admin")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		assert.Contains(t, string(test.ReadBody(res)), `"message":"NOT_GRANTED"`)
+	})
+}
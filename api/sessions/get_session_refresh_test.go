@@ -1,11 +1,16 @@
 package sessions_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"net/http"
 	"net/url"
 	"testing"
 	"time"
 
+	"golang.org/x/crypto/bcrypt"
+
 	"github.com/keratin/authn-server/api"
 	apiSessions "github.com/keratin/authn-server/api/sessions"
 	"github.com/keratin/authn-server/api/test"
@@ -15,8 +20,11 @@ import (
 	"github.com/keratin/authn-server/data/sqlite3"
 	"github.com/keratin/authn-server/lib/route"
 	"github.com/keratin/authn-server/ops"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/identities"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
 )
 
 func BenchmarkGetSessionRefresh(b *testing.B) {
@@ -54,7 +62,7 @@ func BenchmarkGetSessionRefresh(b *testing.B) {
 		app := test.App()
 		server := test.Server(app, apiSessions.Routes(app))
 		defer server.Close()
-		app.RefreshTokenStore = &sqlite3.RefreshTokenStore{sqliteDB, time.Hour}
+		app.RefreshTokenStore = &sqlite3.RefreshTokenStore{DB: sqliteDB, TTL: time.Hour}
 		client := route.NewClient(server.URL).
 			Referred(&app.Config.ApplicationDomains[0]).
 			WithCookie(test.CreateSession(app.RefreshTokenStore, app.Config, 12345))
@@ -146,3 +154,220 @@ func TestGetSessionRefreshFailure(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
 	}
 }
+
+func TestGetSessionRefreshBinding(t *testing.T) {
+	app := test.App()
+	app.Config.SessionBinding = true
+	server := test.Server(app, apiSessions.Routes(app))
+	defer server.Close()
+
+	b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+	app.AccountStore.Create("foo", b)
+
+	login := func(t *testing.T) *http.Cookie {
+		client := route.NewClient(server.URL).
+			Referred(&app.Config.ApplicationDomains[0]).
+			WithHeader("User-Agent", "Browser/1.0")
+		res, err := client.PostForm("/session", url.Values{
+			"username": []string{"foo"},
+			"password": []string{"bar"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		return test.ReadCookie(res.Cookies(), app.Config.SessionCookieName)
+	}
+
+	t.Run("same client", func(t *testing.T) {
+		client := route.NewClient(server.URL).
+			Referred(&app.Config.ApplicationDomains[0]).
+			WithHeader("User-Agent", "Browser/2.0").
+			WithCookie(login(t))
+		res, err := client.Get("/session/refresh")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("different client", func(t *testing.T) {
+		session := login(t)
+		client := route.NewClient(server.URL).
+			Referred(&app.Config.ApplicationDomains[0]).
+			WithHeader("User-Agent", "Scraper/1.0").
+			WithCookie(session)
+		res, err := client.Get("/session/refresh")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		// the session has been revoked
+		client = route.NewClient(server.URL).
+			Referred(&app.Config.ApplicationDomains[0]).
+			WithHeader("User-Agent", "Browser/1.0").
+			WithCookie(session)
+		res, err = client.Get("/session/refresh")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+}
+
+func TestGetSessionRefreshDPoP(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, apiSessions.Routes(app))
+	defer server.Close()
+
+	b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+	app.AccountStore.Create("foo", b)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	res, err := client.
+		WithHeader("DPoP", test.DPoPProof(key, "POST", app.Config.AuthNURL.String()+"/session")).
+		PostForm("/session", url.Values{
+			"username": []string{"foo"},
+			"password": []string{"bar"},
+		})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	session := test.ReadCookie(res.Cookies(), app.Config.SessionCookieName)
+
+	responseData := struct {
+		IDToken string `json:"id_token"`
+	}{}
+	require.NoError(t, test.ExtractResult(res, &responseData))
+	tok, err := jwt.ParseSigned(responseData.IDToken)
+	require.NoError(t, err)
+	claims := identities.Claims{}
+	require.NoError(t, tok.Claims(app.KeyStore.Key().Public(), &claims))
+	require.NotNil(t, claims.Cnf)
+	assert.NotEmpty(t, claims.Cnf.Jkt)
+
+	refreshURL := app.Config.AuthNURL.String() + "/session/refresh"
+
+	t.Run("without proof", func(t *testing.T) {
+		res, err := client.WithCookie(session).Get("/session/refresh")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("with proof from another key", func(t *testing.T) {
+		res, err := client.WithCookie(session).
+			WithHeader("DPoP", test.DPoPProof(otherKey, "GET", refreshURL)).
+			Get("/session/refresh")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("with proof from the bound key", func(t *testing.T) {
+		res, err := client.WithCookie(session).
+			WithHeader("DPoP", test.DPoPProof(key, "GET", refreshURL)).
+			Get("/session/refresh")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("with a replayed proof", func(t *testing.T) {
+		proof := test.DPoPProof(key, "GET", refreshURL)
+		res, err := client.WithCookie(session).WithHeader("DPoP", proof).Get("/session/refresh")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		res, err = client.WithCookie(session).WithHeader("DPoP", proof).Get("/session/refresh")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("login with an invalid proof", func(t *testing.T) {
+		res, err := client.
+			WithHeader("DPoP", test.DPoPProof(key, "GET", app.Config.AuthNURL.String()+"/session")).
+			PostForm("/session", url.Values{
+				"username": []string{"foo"},
+				"password": []string{"bar"},
+			})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	})
+}
+
+func TestGetSessionRefreshScope(t *testing.T) {
+	app := test.App()
+	app.Config.SessionScopes = []string{"profile", "billing"}
+	server := test.Server(app, apiSessions.Routes(app))
+	defer server.Close()
+
+	session := test.CreateSession(app.RefreshTokenStore, app.Config, 82594)
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0]).WithCookie(session)
+
+	scopeOf := func(res *http.Response) string {
+		responseData := struct {
+			IDToken string `json:"id_token"`
+		}{}
+		require.NoError(t, test.ExtractResult(res, &responseData))
+		tok, err := jwt.ParseSigned(responseData.IDToken)
+		require.NoError(t, err)
+		claims := identities.Claims{}
+		require.NoError(t, tok.Claims(app.KeyStore.Key().Public(), &claims))
+		return claims.Scope
+	}
+
+	t.Run("all granted scopes", func(t *testing.T) {
+		res, err := client.Get("/session/refresh")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, "profile billing", scopeOf(res))
+	})
+
+	t.Run("down-scoped", func(t *testing.T) {
+		res, err := client.Get("/session/refresh?scope=billing")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, "billing", scopeOf(res))
+	})
+
+	t.Run("ungranted scope", func(t *testing.T) {
+		res, err := client.Get("/session/refresh?scope=billing+admin")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"scope", services.ErrNotGranted}})
+	})
+}
+
+func TestGetSessionRefreshAccessTokenCookie(t *testing.T) {
+	app := test.App()
+	app.Config.AccessTokenTTL = time.Hour
+	app.Config.AccessTokenCookieName = "authn-access"
+	server := test.Server(app, apiSessions.Routes(app))
+	defer server.Close()
+
+	session := test.CreateSession(app.RefreshTokenStore, app.Config, 82594)
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0]).WithCookie(session)
+
+	t.Run("refreshing", func(t *testing.T) {
+		res, err := client.Get("/session/refresh")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+
+		responseData := struct {
+			IDToken string `json:"id_token"`
+		}{}
+		require.NoError(t, test.ExtractResult(res, &responseData))
+
+		cookie := test.ReadCookie(res.Cookies(), "authn-access")
+		require.NotNil(t, cookie)
+		assert.Equal(t, responseData.IDToken, cookie.Value)
+		assert.False(t, cookie.HttpOnly)
+		assert.Equal(t, 3600, cookie.MaxAge)
+	})
+
+	t.Run("logging out", func(t *testing.T) {
+		res, err := client.Delete("/session")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		cookie := test.ReadCookie(res.Cookies(), "authn-access")
+		require.NotNil(t, cookie)
+		assert.Empty(t, cookie.Value)
+		assert.True(t, cookie.MaxAge < 0)
+	})
+}
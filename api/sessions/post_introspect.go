@@ -0,0 +1,70 @@
+package sessions
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/tokens/sessions"
+	"github.com/pkg/errors"
+)
+
+type introspection struct {
+	Active    bool                   `json:"active"`
+	TokenType string                 `json:"token_type,omitempty"`
+	Scope     string                 `json:"scope,omitempty"`
+	Subject   string                 `json:"sub,omitempty"`
+	Audience  string                 `json:"aud,omitempty"`
+	Issuer    string                 `json:"iss,omitempty"`
+	IssuedAt  int64                  `json:"iat,omitempty"`
+	Expiry    int64                  `json:"exp,omitempty"`
+	Cnf       *sessions.Confirmation `json:"cnf,omitempty"`
+}
+
+// postIntrospect validates an opaque access token, in the style of RFC 7662. A token is only active
+// while the session that issued it remains active, so that logging out or revoking sessions takes
+// effect immediately.
+func postIntrospect(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		api.WriteJSON(w, http.StatusOK, introspect(app, r, r.FormValue("token")))
+	}
+}
+
+func introspect(app *api.App, r *http.Request, token string) introspection {
+	meta, err := app.AccessTokenStore.Find(token)
+	if err != nil {
+		panic(errors.Wrap(err, "Find"))
+	}
+	if meta == nil {
+		return introspection{Active: false}
+	}
+
+	accountID, err := app.RefreshTokens(r).Find(meta.Session)
+	if err != nil {
+		panic(errors.Wrap(err, "Find"))
+	}
+	return introspected(app, meta, accountID)
+}
+
+// introspected describes a token whose session was found to belong to accountID.
+func introspected(app *api.App, meta *models.AccessToken, accountID int) introspection {
+	if accountID != meta.AccountID {
+		return introspection{Active: false}
+	}
+
+	result := introspection{
+		Active:    true,
+		TokenType: "Bearer",
+		Scope:     meta.Scope,
+		Subject:   strconv.Itoa(meta.AccountID),
+		Audience:  meta.Audience,
+		Issuer:    app.Config.AuthNURL.String(),
+		IssuedAt:  meta.IssuedAt.Unix(),
+		Expiry:    meta.ExpiresAt.Unix(),
+	}
+	if meta.Jkt != "" {
+		result.Cnf = &sessions.Confirmation{Jkt: meta.Jkt}
+	}
+	return result
+}
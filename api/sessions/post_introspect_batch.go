@@ -0,0 +1,61 @@
+package sessions
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
+	"github.com/pkg/errors"
+)
+
+// maxIntrospectBatch bounds the lookups that one request may cause.
+const maxIntrospectBatch = 100
+
+// postIntrospectBatch validates many opaque access tokens in one round trip, for gateways that
+// terminate connections for many users at once. Results are in the same order as the tokens. The
+// tokens and then their sessions are each found in one lookup, however many tokens there are.
+func postIntrospectBatch(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseForm()
+		if err != nil {
+			api.WriteErrors(w, services.FieldErrors{{"token", services.ErrFormatInvalid}})
+			return
+		}
+		tokens := r.PostForm["token"]
+		if len(tokens) == 0 {
+			api.WriteErrors(w, services.FieldErrors{{"token", services.ErrMissing}})
+			return
+		}
+		if len(tokens) > maxIntrospectBatch {
+			api.WriteErrors(w, services.FieldErrors{{"token", services.ErrTooLarge}})
+			return
+		}
+
+		metas, err := app.AccessTokenStore.FindMany(tokens)
+		if err != nil {
+			panic(errors.Wrap(err, "FindMany"))
+		}
+		sessions := make([]models.RefreshToken, 0, len(metas))
+		for _, meta := range metas {
+			if meta != nil {
+				sessions = append(sessions, meta.Session)
+			}
+		}
+		accountIDs, err := app.RefreshTokens(r).FindMany(sessions)
+		if err != nil {
+			panic(errors.Wrap(err, "FindMany"))
+		}
+
+		results := make([]introspection, len(tokens))
+		for i, meta := range metas {
+			if meta == nil {
+				results[i] = introspection{Active: false}
+				continue
+			}
+			results[i] = introspected(app, meta, accountIDs[0])
+			accountIDs = accountIDs[1:]
+		}
+		api.WriteJSON(w, http.StatusOK, results)
+	}
+}
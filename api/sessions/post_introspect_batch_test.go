@@ -0,0 +1,81 @@
+package sessions_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	apiSessions "github.com/keratin/authn-server/api/sessions"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPostIntrospectBatch(t *testing.T) {
+	app := test.App()
+	app.Config.OpaqueAccessTokens = true
+	app.Config.AccessTokenTTL = time.Hour
+	server := test.Server(app, apiSessions.Routes(app))
+	defer server.Close()
+
+	b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+	account, err := app.AccountStore.Create("foo", b)
+	require.NoError(t, err)
+
+	publicClient := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+	privateClient := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	res, err := publicClient.PostForm("/session", url.Values{
+		"username": []string{"foo"},
+		"password": []string{"bar"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	responseData := struct {
+		IDToken string `json:"id_token"`
+	}{}
+	require.NoError(t, test.ExtractResult(res, &responseData))
+
+	t.Run("mixed tokens", func(t *testing.T) {
+		res, err := privateClient.PostForm("/introspect/batch", url.Values{
+			"token": []string{"unknown", responseData.IDToken},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		results := []map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(test.ReadBody(res), &results))
+		require.Len(t, results, 2)
+		assert.Equal(t, map[string]interface{}{"active": false}, results[0])
+		assert.Equal(t, true, results[1]["active"])
+		assert.Equal(t, strconv.Itoa(account.ID), results[1]["sub"])
+	})
+
+	t.Run("without tokens", func(t *testing.T) {
+		res, err := privateClient.PostForm("/introspect/batch", url.Values{})
+		require.NoError(t, err)
+		test.AssertErrors(t, res, services.FieldErrors{{"token", services.ErrMissing}})
+	})
+
+	t.Run("too many tokens", func(t *testing.T) {
+		tokens := make([]string, 101)
+		for i := range tokens {
+			tokens[i] = strconv.Itoa(i)
+		}
+		res, err := privateClient.PostForm("/introspect/batch", url.Values{"token": tokens})
+		require.NoError(t, err)
+		test.AssertErrors(t, res, services.FieldErrors{{"token", services.ErrTooLarge}})
+	})
+
+	t.Run("without authentication", func(t *testing.T) {
+		res, err := publicClient.PostForm("/introspect/batch", url.Values{"token": []string{responseData.IDToken}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+}
@@ -0,0 +1,81 @@
+package sessions_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	apiSessions "github.com/keratin/authn-server/api/sessions"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPostIntrospect(t *testing.T) {
+	app := test.App()
+	app.Config.OpaqueAccessTokens = true
+	app.Config.AccessTokenTTL = time.Hour
+	server := test.Server(app, apiSessions.Routes(app))
+	defer server.Close()
+
+	b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+	account, err := app.AccountStore.Create("foo", b)
+	require.NoError(t, err)
+
+	publicClient := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+	privateClient := route.NewClient(server.URL).Authenticated(app.Config.AuthUsername, app.Config.AuthPassword)
+
+	introspect := func(token string) map[string]interface{} {
+		res, err := privateClient.PostForm("/introspect", url.Values{"token": []string{token}})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		result := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(test.ReadBody(res), &result))
+		return result
+	}
+
+	res, err := publicClient.PostForm("/session", url.Values{
+		"username": []string{"foo"},
+		"password": []string{"bar"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	session := test.ReadCookie(res.Cookies(), app.Config.SessionCookieName)
+	responseData := struct {
+		IDToken string `json:"id_token"`
+	}{}
+	require.NoError(t, test.ExtractResult(res, &responseData))
+
+	t.Run("active token", func(t *testing.T) {
+		result := introspect(responseData.IDToken)
+		assert.Equal(t, true, result["active"])
+		assert.Equal(t, "Bearer", result["token_type"])
+		assert.Equal(t, strconv.Itoa(account.ID), result["sub"])
+		assert.Equal(t, app.Config.ApplicationDomains[0].String(), result["aud"])
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		result := introspect("unknown")
+		assert.Equal(t, map[string]interface{}{"active": false}, result)
+	})
+
+	t.Run("after logout", func(t *testing.T) {
+		res, err := publicClient.WithCookie(session).Delete("/session")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		result := introspect(responseData.IDToken)
+		assert.Equal(t, false, result["active"])
+	})
+
+	t.Run("without authentication", func(t *testing.T) {
+		res, err := publicClient.PostForm("/introspect", url.Values{"token": []string{responseData.IDToken}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+}
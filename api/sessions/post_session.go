@@ -2,21 +2,82 @@ package sessions
 
 import (
 	"net/http"
+	"regexp"
 
 	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/events"
 	"github.com/keratin/authn-server/lib/route"
 	"github.com/keratin/authn-server/services"
 )
 
 func postSession(app *api.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		jkt, err := api.DPoPThumbprint(app.Config, app.DPoPProofs, r)
+		if err != nil {
+			api.WriteErrors(w, services.FieldErrors{{"dpop", services.ErrInvalidOrExpired}})
+			return
+		}
+
+		location, err := services.LocationFinder(app.Config.GeoIPLocator, r.RemoteAddr)
+		if err != nil {
+			app.Reporter.ReportRequestError(err, r)
+		}
+
 		// Check the password
 		account, err := services.CredentialsVerifier(
-			app.AccountStore,
+			app.Accounts(r),
 			app.Config,
 			r.FormValue("username"),
 			r.FormValue("password"),
 		)
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				if fe[0].Message == services.ErrFailed && app.Quotas != nil {
+					username := r.FormValue("username")
+					trackFailure := func(store data.AccountStore) {
+						err := services.LoginFailureTracker(store, app.Quotas, app.Config, username)
+						if err != nil {
+							app.Reporter.ReportRequestError(err, r)
+						}
+					}
+					// only registered usernames are tracked, so the work is kept out of the response
+					// time when preventing enumeration
+					if app.Config.PreventEnumeration {
+						go trackFailure(app.AccountStore)
+					} else {
+						trackFailure(app.Accounts(r))
+					}
+				}
+				if fe[0].Message == services.ErrFailed {
+					app.Events.EmitRequest(events.SessionFailed, 0, location, r)
+				}
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+		if app.Quotas != nil {
+			err = services.LoginFailureResetter(app.Quotas, app.Config, account.ID)
+			if err != nil {
+				app.Reporter.ReportRequestError(err, r)
+			}
+		}
+
+		// Check the location
+		err = services.LocationValidator(app.Config, location)
+		if err != nil {
+			api.WriteErrors(w, err.(services.FieldErrors))
+			return
+		}
+
+		// Check the terms of service
+		marketingOptIn, err := regexp.MatchString("^(?i:t|true|yes)$", r.FormValue("marketing_opt_in"))
+		if err != nil {
+			panic(err)
+		}
+		err = services.ConsentRecorder(app.ConsentStore, app.Config, account.ID, r.FormValue("terms_version"), marketingOptIn, r.RemoteAddr)
 		if err != nil {
 			if fe, ok := err.(services.FieldErrors); ok {
 				api.WriteErrors(w, fe)
@@ -26,18 +87,70 @@ func postSession(app *api.App) http.HandlerFunc {
 			panic(err)
 		}
 
-		err = api.RevokeSession(app.RefreshTokenStore, app.Config, r)
+		// Hold suspicious logins until they are confirmed by email
+		if app.Config.AppLoginChallengeURL != nil {
+			reasons, err := services.LoginRiskAssessor(app.AuditLog, app.Config, account.ID, location, r.RemoteAddr)
+			if err != nil {
+				app.Reporter.ReportRequestError(err, r)
+			}
+			if len(reasons) > 0 {
+				err = services.LoginChallengeSender(app.Config, account, reasons, location)
+				if err != nil {
+					panic(err)
+				}
+				app.Events.EmitRequest(events.SessionChallenged, account.ID, location, r)
+
+				api.WriteData(w, http.StatusAccepted, map[string]interface{}{
+					"challenge": "email",
+					"reasons":   reasons,
+				})
+				return
+			}
+		}
+
+		// Hold the login until the push authenticator approves it
+		if app.Config.PushMFAProvider != nil {
+			token, err := services.PushMFARequester(app.Config, account, r.RemoteAddr, r.UserAgent())
+			if err != nil {
+				panic(err)
+			}
+			if token != "" {
+				api.WriteData(w, http.StatusAccepted, map[string]string{
+					"mfa":   "push",
+					"token": token,
+				})
+				return
+			}
+		}
+
+		canceled, err := services.AccountDeletionCanceler(app.Accounts(r), account)
+		if err != nil {
+			panic(err)
+		}
+		if canceled {
+			app.Events.Emit(events.AccountDeletionCanceled, account.ID)
+		}
+
+		err = api.RevokeSession(app.RefreshTokens(r), app.Config, r)
 		if err != nil {
 			app.Reporter.ReportRequestError(err, r)
 		}
 
-		sessionToken, identityToken, err := api.NewSession(app.RefreshTokenStore, app.KeyStore, app.Actives, app.Config, account.ID, route.MatchedDomain(r))
+		sessionToken, identityToken, err := api.NewSession(app.RefreshTokens(r), app.KeyStore, app.AccessTokenStore, app.Accounts(r), app.Actives, app.Config, account.ID, route.MatchedDomain(r), api.SessionFingerprint(app.Config, r), jkt, []string{"pwd"})
 		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
 			panic(err)
 		}
 
+		app.Events.EmitRequest(events.SessionCreated, account.ID, location, r)
+
 		// Return the signed session in a cookie
-		api.SetSession(app.Config, w, sessionToken)
+		api.SetSession(app.Config, w, route.MatchedDomain(r), sessionToken)
+		api.SetAccessToken(app.Config, w, route.MatchedDomain(r), identityToken)
 
 		// Return the signed identity token in the body
 		api.WriteData(w, http.StatusCreated, map[string]string{
@@ -0,0 +1,49 @@
+package sessions_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api/sessions"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/challenges"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostSessionConfirm(t *testing.T) {
+	app := test.App()
+	app.Config.AppLoginChallengeURL = &url.URL{Scheme: "https", Host: "app.example.com"}
+	app.Config.LoginChallengeSigningKey = []byte("challenge-a-reno")
+	app.Config.LoginChallengeTokenTTL = time.Hour
+	server := test.Server(app, sessions.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("valid token", func(t *testing.T) {
+		account, err := app.AccountStore.Create("valid@test.com", []byte("bar"))
+		require.NoError(t, err)
+		claims, err := challenges.New(app.Config, account.ID, account.PasswordChangedAt)
+		require.NoError(t, err)
+		token, err := claims.Sign(app.Config.LoginChallengeSigningKey)
+		require.NoError(t, err)
+
+		res, err := client.PostForm("/session/confirm", url.Values{"token": []string{token}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		test.AssertSession(t, app.Config, res.Cookies())
+		test.AssertIDTokenResponse(t, res, app.KeyStore, app.Config)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		res, err := client.PostForm("/session/confirm", url.Values{"token": []string{"invalid"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"token", services.ErrInvalidOrExpired}})
+	})
+}
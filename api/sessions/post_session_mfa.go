@@ -0,0 +1,69 @@
+package sessions
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+)
+
+func postSessionMFA(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jkt, err := api.DPoPThumbprint(app.Config, app.DPoPProofs, r)
+		if err != nil {
+			api.WriteErrors(w, services.FieldErrors{{"dpop", services.ErrInvalidOrExpired}})
+			return
+		}
+
+		account, err := services.PushMFAVerifier(app.Accounts(r), app.Config, r.FormValue("token"))
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		location, err := services.LocationFinder(app.Config.GeoIPLocator, r.RemoteAddr)
+		if err != nil {
+			app.Reporter.ReportRequestError(err, r)
+		}
+
+		canceled, err := services.AccountDeletionCanceler(app.Accounts(r), account)
+		if err != nil {
+			panic(err)
+		}
+		if canceled {
+			app.Events.Emit(events.AccountDeletionCanceled, account.ID)
+		}
+
+		err = api.RevokeSession(app.RefreshTokens(r), app.Config, r)
+		if err != nil {
+			app.Reporter.ReportRequestError(err, r)
+		}
+
+		sessionToken, identityToken, err := api.NewSession(app.RefreshTokens(r), app.KeyStore, app.AccessTokenStore, app.Accounts(r), app.Actives, app.Config, account.ID, route.MatchedDomain(r), api.SessionFingerprint(app.Config, r), jkt, []string{"pwd", "mfa"})
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		app.Events.EmitRequest(events.SessionCreated, account.ID, location, r)
+
+		// Return the signed session in a cookie
+		api.SetSession(app.Config, w, route.MatchedDomain(r), sessionToken)
+		api.SetAccessToken(app.Config, w, route.MatchedDomain(r), identityToken)
+
+		// Return the signed identity token in the body
+		api.WriteData(w, http.StatusCreated, map[string]string{
+			"id_token": identityToken,
+		})
+	}
+}
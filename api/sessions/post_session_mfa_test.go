@@ -0,0 +1,95 @@
+package sessions_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/keratin/authn-server/api/sessions"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/mfa"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostSessionMFA(t *testing.T) {
+	provider := mfa.StaticProvider{"enrolled": mfa.StatusPending}
+	app := test.App()
+	app.Config.PushMFAProvider = provider
+	app.Config.PushMFASigningKey = []byte("push-a-reno")
+	app.Config.PushMFATimeout = time.Minute
+	server := test.Server(app, sessions.Routes(app))
+	defer server.Close()
+
+	b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+	_, err := app.AccountStore.Create("enrolled", b)
+	require.NoError(t, err)
+	_, err = app.AccountStore.Create("unenrolled", b)
+	require.NoError(t, err)
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+	login := func(username string) *http.Response {
+		res, err := client.PostForm("/session", url.Values{
+			"username": []string{username},
+			"password": []string{"bar"},
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("unenrolled account", func(t *testing.T) {
+		res := login("unenrolled")
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		test.AssertSession(t, app.Config, res.Cookies())
+	})
+
+	res := login("enrolled")
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+	assert.Nil(t, test.ReadCookie(res.Cookies(), app.Config.SessionCookieName))
+	held := struct {
+		MFA   string `json:"mfa"`
+		Token string `json:"token"`
+	}{}
+	require.NoError(t, test.ExtractResult(res, &held))
+	assert.Equal(t, "push", held.MFA)
+	require.NotEmpty(t, held.Token)
+
+	complete := func() *http.Response {
+		res, err := client.PostForm("/session/mfa", url.Values{"token": []string{held.Token}})
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("pending approval", func(t *testing.T) {
+		res := complete()
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"mfa", services.ErrPending}})
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		provider["enrolled"] = mfa.StatusDenied
+		res := complete()
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"mfa", services.ErrDenied}})
+	})
+
+	t.Run("approved", func(t *testing.T) {
+		provider["enrolled"] = mfa.StatusApproved
+		res := complete()
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		test.AssertSession(t, app.Config, res.Cookies())
+		test.AssertIDTokenResponse(t, res, app.KeyStore, app.Config)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		res, err := client.PostForm("/session/mfa", url.Values{"token": []string{"invalid"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"token", services.ErrInvalidOrExpired}})
+	})
+}
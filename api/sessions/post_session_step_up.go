@@ -0,0 +1,35 @@
+package sessions
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+)
+
+// postSessionStepUp asks the push authenticator to re-verify the current session's user, without a
+// password, so that the session may be issued STEP_UP_SCOPES.
+func postSessionStepUp(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID := api.GetSessionAccountID(r)
+		if accountID == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		token, err := services.StepUpRequester(app.Accounts(r), app.Config, accountID, r.RemoteAddr, r.UserAgent())
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		api.WriteData(w, http.StatusAccepted, map[string]string{
+			"mfa":   "push",
+			"token": token,
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package sessions
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+)
+
+// postSessionStepUpConfirm completes a step-up once the push authenticator has approved it. The
+// session keeps its refresh token, but records when the second factor was verified.
+func postSessionStepUpConfirm(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID := api.GetSessionAccountID(r)
+		if accountID == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		// check that the client holds the key that the session is bound to
+		session := api.GetSession(r)
+		if session.Cnf != nil {
+			jkt, err := api.DPoPThumbprint(app.Config, app.DPoPProofs, r)
+			if err != nil || jkt != session.Cnf.Jkt {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+
+		err := services.StepUpVerifier(app.Accounts(r), app.Config, accountID, r.FormValue("token"))
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		steppedUp := *session
+		steppedUp.StepUp()
+		sessionToken, err := steppedUp.Sign(app.Config.SessionSigningKey)
+		if err != nil {
+			panic(err)
+		}
+
+		identityToken, err := api.IdentityForSession(app.KeyStore, app.AccessTokenStore, app.Accounts(r), app.Actives, app.Config, &steppedUp, accountID, route.MatchedDomain(r))
+		if err != nil {
+			panic(err)
+		}
+
+		app.Events.EmitRequest(events.SessionSteppedUp, accountID, nil, r)
+
+		api.SetSession(app.Config, w, route.MatchedDomain(r), sessionToken)
+		api.SetAccessToken(app.Config, w, route.MatchedDomain(r), identityToken)
+
+		api.WriteData(w, http.StatusCreated, map[string]string{
+			"id_token": identityToken,
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package sessions_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	apiSessions "github.com/keratin/authn-server/api/sessions"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/mfa"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/identities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestPostSessionStepUp(t *testing.T) {
+	provider := mfa.StaticProvider{"enrolled": mfa.StatusPending}
+	app := test.App()
+	app.Config.PushMFAProvider = provider
+	app.Config.PushMFASigningKey = []byte("push-a-reno")
+	app.Config.PushMFATimeout = time.Minute
+	app.Config.SessionScopes = []string{"profile", "billing"}
+	app.Config.StepUpScopes = []string{"billing"}
+	app.Config.StepUpMaxAge = 5 * time.Minute
+	server := test.Server(app, apiSessions.Routes(app))
+	defer server.Close()
+
+	enrolled, err := app.AccountStore.Create("enrolled", []byte("bar"))
+	require.NoError(t, err)
+	unenrolled, err := app.AccountStore.Create("unenrolled", []byte("bar"))
+	require.NoError(t, err)
+
+	clientFor := func(session *http.Cookie) *route.Client {
+		return route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0]).WithCookie(session)
+	}
+	client := clientFor(test.CreateSession(app.RefreshTokenStore, app.Config, enrolled.ID))
+
+	scopeOf := func(res *http.Response) string {
+		responseData := struct {
+			IDToken string `json:"id_token"`
+		}{}
+		require.NoError(t, test.ExtractResult(res, &responseData))
+		tok, err := jwt.ParseSigned(responseData.IDToken)
+		require.NoError(t, err)
+		claims := identities.Claims{}
+		require.NoError(t, tok.Claims(app.KeyStore.Key().Public(), &claims))
+		return claims.Scope
+	}
+	stepUp := func(client *route.Client) *http.Response {
+		res, err := client.PostForm("/session/step_up", url.Values{})
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("without a session", func(t *testing.T) {
+		res := stepUp(route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0]))
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("unenrolled account", func(t *testing.T) {
+		res := stepUp(clientFor(test.CreateSession(app.RefreshTokenStore, app.Config, unenrolled.ID)))
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"mfa", services.ErrNotEnrolled}})
+	})
+
+	t.Run("refresh before stepping up", func(t *testing.T) {
+		res, err := client.Get("/session/refresh")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, "profile", scopeOf(res))
+
+		res, err = client.Get("/session/refresh?scope=billing")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"scope", services.ErrStepUpRequired}})
+	})
+
+	res := stepUp(client)
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+	held := struct {
+		MFA   string `json:"mfa"`
+		Token string `json:"token"`
+	}{}
+	require.NoError(t, test.ExtractResult(res, &held))
+	assert.Equal(t, "push", held.MFA)
+	require.NotEmpty(t, held.Token)
+
+	confirm := func(client *route.Client, token string) *http.Response {
+		res, err := client.PostForm("/session/step_up/confirm", url.Values{"token": []string{token}})
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("pending approval", func(t *testing.T) {
+		res := confirm(client, held.Token)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"mfa", services.ErrPending}})
+	})
+
+	t.Run("another account's session", func(t *testing.T) {
+		provider["enrolled"] = mfa.StatusApproved
+		other := clientFor(test.CreateSession(app.RefreshTokenStore, app.Config, unenrolled.ID))
+		res := confirm(other, held.Token)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"token", services.ErrInvalidOrExpired}})
+	})
+
+	t.Run("login approval token", func(t *testing.T) {
+		login, err := services.PushMFARequester(app.Config, enrolled, "127.0.0.1", "test")
+		require.NoError(t, err)
+		res := confirm(client, login)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"token", services.ErrInvalidOrExpired}})
+	})
+
+	t.Run("approved", func(t *testing.T) {
+		provider["enrolled"] = mfa.StatusApproved
+		res := confirm(client, held.Token)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, "profile billing", scopeOf(res))
+		session := test.ReadCookie(res.Cookies(), app.Config.SessionCookieName)
+		require.NotNil(t, session)
+
+		res, err = clientFor(session).Get("/session/refresh?scope=billing")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, "billing", scopeOf(res))
+	})
+}
@@ -2,17 +2,24 @@ package sessions_test
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"testing"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/keratin/authn-server/api"
 	"github.com/keratin/authn-server/api/sessions"
 	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/lib/geoip"
 	"github.com/keratin/authn-server/lib/route"
 	"github.com/keratin/authn-server/services"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
 )
 
 func TestPostSessionSuccess(t *testing.T) {
@@ -64,6 +71,43 @@ func TestPostSessionSuccessWithSession(t *testing.T) {
 	assert.Empty(t, id)
 }
 
+func TestPostSessionApplicationSettings(t *testing.T) {
+	app := test.App()
+	app.Config.AccessTokenTTL = time.Hour
+	app.Config.Applications = []config.ApplicationDomain{{
+		Domain:         app.Config.ApplicationDomains[0],
+		AccessTokenTTL: time.Minute,
+		SameSite:       http.SameSiteStrictMode,
+	}}
+	server := test.Server(app, sessions.Routes(app))
+	defer server.Close()
+
+	b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+	app.AccountStore.Create("foo", b)
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+	res, err := client.PostForm("/session", url.Values{
+		"username": []string{"foo"},
+		"password": []string{"bar"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+	cookie := test.ReadCookie(res.Cookies(), app.Config.SessionCookieName)
+	require.NotNil(t, cookie)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+
+	result := struct {
+		IDToken string `json:"id_token"`
+	}{}
+	require.NoError(t, test.ExtractResult(res, &result))
+	tok, err := jwt.ParseSigned(result.IDToken)
+	require.NoError(t, err)
+	claims := jwt.Claims{}
+	require.NoError(t, tok.Claims(app.KeyStore.Key().Public(), &claims))
+	assert.WithinDuration(t, time.Now().Add(time.Minute), claims.Expiry.Time(), 5*time.Second)
+}
+
 func TestPostSessionFailure(t *testing.T) {
 	app := test.App()
 	server := test.Server(app, sessions.Routes(app))
@@ -89,3 +133,265 @@ func TestPostSessionFailure(t *testing.T) {
 		test.AssertErrors(t, res, tc.errors)
 	}
 }
+
+func TestPostSessionThrottle(t *testing.T) {
+	app := test.App()
+	app.Config.LoginThrottleAttempts = 2
+	app.Config.LoginThrottleDuration = time.Minute
+	app.Config.LoginThrottleMaxDuration = time.Hour
+	server := test.Server(app, sessions.Routes(app))
+	defer server.Close()
+
+	b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+	account, err := app.AccountStore.Create("foo", b)
+	require.NoError(t, err)
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+	login := func(password string) *http.Response {
+		res, err := client.PostForm("/session", url.Values{
+			"username": []string{"foo"},
+			"password": []string{password},
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	res := login("wrong")
+	test.AssertErrors(t, res, services.FieldErrors{{"credentials", "FAILED"}})
+	res = login("bar")
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+	// the successful login reset the count
+	res = login("wrong")
+	test.AssertErrors(t, res, services.FieldErrors{{"credentials", "FAILED"}})
+	res = login("wrong")
+	test.AssertErrors(t, res, services.FieldErrors{{"credentials", "FAILED"}})
+
+	res = login("bar")
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	test.AssertErrors(t, res, services.FieldErrors{{"account", "THROTTLED"}})
+
+	found, err := app.AccountStore.Find(account.ID)
+	require.NoError(t, err)
+	assert.True(t, found.Throttled())
+	assert.False(t, found.Locked)
+}
+
+func TestPostSessionCancelsDeletion(t *testing.T) {
+	app := test.App()
+	server := test.Server(app, sessions.Routes(app))
+	defer server.Close()
+
+	b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+	account, err := app.AccountStore.Create("foo", b)
+	require.NoError(t, err)
+	err = app.AccountStore.ScheduleArchive(account.ID, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+	res, err := client.PostForm("/session", url.Values{
+		"username": []string{"foo"},
+		"password": []string{"bar"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+	found, err := app.AccountStore.Find(account.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.ArchiveAt)
+}
+
+func TestPostSessionTerms(t *testing.T) {
+	app := test.App()
+	app.Config.TermsVersion = "2"
+	server := test.Server(app, sessions.Routes(app))
+	defer server.Close()
+
+	b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+	account, err := app.AccountStore.Create("foo", b)
+	require.NoError(t, err)
+	_, err = app.ConsentStore.Create(account.ID, "1", false, "127.0.0.1")
+	require.NoError(t, err)
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("without accepting new terms", func(t *testing.T) {
+		res, err := client.PostForm("/session", url.Values{
+			"username": []string{"foo"},
+			"password": []string{"bar"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"terms_version", services.ErrMissing}})
+	})
+
+	t.Run("accepting new terms", func(t *testing.T) {
+		res, err := client.PostForm("/session", url.Values{
+			"username":      []string{"foo"},
+			"password":      []string{"bar"},
+			"terms_version": []string{"2"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("after accepting", func(t *testing.T) {
+		res, err := client.PostForm("/session", url.Values{
+			"username": []string{"foo"},
+			"password": []string{"bar"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+}
+
+func TestPostSessionBlockedCountry(t *testing.T) {
+	app := test.App()
+	app.Config.GeoIPLocator = geoip.StaticLocator{
+		"127.0.0.1": &geoip.Location{Country: "KP"},
+	}
+	server := test.Server(app, sessions.Routes(app))
+	defer server.Close()
+
+	b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+	app.AccountStore.Create("foo", b)
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("allowed country", func(t *testing.T) {
+		res, err := client.PostForm("/session", url.Values{
+			"username": []string{"foo"},
+			"password": []string{"bar"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("blocked country", func(t *testing.T) {
+		app.Config.BlockedCountries = []string{"KP"}
+		res, err := client.PostForm("/session", url.Values{
+			"username": []string{"foo"},
+			"password": []string{"bar"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"location", services.ErrBlocked}})
+	})
+}
+
+func TestPostSessionChallenge(t *testing.T) {
+	var received url.Values
+	remoteApp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		received = r.PostForm
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer remoteApp.Close()
+
+	app := test.App()
+	app.Config.AppLoginChallengeURL, _ = url.Parse(remoteApp.URL)
+	app.Config.LoginChallengeSigningKey = []byte("challenge-a-reno")
+	app.Config.LoginChallengeTokenTTL = time.Hour
+	app.Config.LoginChallengeRules = []string{services.RiskTor}
+	server := test.Server(app, sessions.Routes(app))
+	defer server.Close()
+
+	b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+	account, err := app.AccountStore.Create("foo", b)
+	require.NoError(t, err)
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("unsuspicious login", func(t *testing.T) {
+		res, err := client.PostForm("/session", url.Values{
+			"username": []string{"foo"},
+			"password": []string{"bar"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Nil(t, received)
+	})
+
+	t.Run("suspicious login", func(t *testing.T) {
+		app.Config.TorExitNodes = geoip.IPList{"127.0.0.1": true}
+		res, err := client.PostForm("/session", url.Values{
+			"username": []string{"foo"},
+			"password": []string{"bar"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, res.StatusCode)
+		assert.Nil(t, test.ReadCookie(res.Cookies(), app.Config.SessionCookieName))
+		test.AssertData(t, res, map[string]interface{}{
+			"challenge": "email",
+			"reasons":   []interface{}{"tor"},
+		})
+
+		require.NotNil(t, received)
+		assert.Equal(t, strconv.Itoa(account.ID), received.Get("account_id"))
+		assert.Equal(t, "tor", received.Get("reasons"))
+		assert.NotEmpty(t, received.Get("token"))
+	})
+}
+
+func TestPostSessionLimit(t *testing.T) {
+	login := func(t *testing.T, server *httptest.Server, app *api.App) *http.Response {
+		client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+		res, err := client.PostForm("/session", url.Values{
+			"username": []string{"foo"},
+			"password": []string{"bar"},
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("revoking the oldest session", func(t *testing.T) {
+		app := test.App()
+		app.Config.MaxSessionsPerAccount = 1
+		server := test.Server(app, sessions.Routes(app))
+		defer server.Close()
+		b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+		account, _ := app.AccountStore.Create("foo", b)
+
+		assert.Equal(t, http.StatusCreated, login(t, server, app).StatusCode)
+		assert.Equal(t, http.StatusCreated, login(t, server, app).StatusCode)
+
+		tokens, err := app.RefreshTokenStore.FindAll(account.ID)
+		require.NoError(t, err)
+		assert.Len(t, tokens, 1)
+	})
+
+	t.Run("rejecting new sessions", func(t *testing.T) {
+		app := test.App()
+		app.Config.MaxSessionsPerAccount = 1
+		app.Config.RejectExcessSessions = true
+		server := test.Server(app, sessions.Routes(app))
+		defer server.Close()
+		b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+		app.AccountStore.Create("foo", b)
+
+		assert.Equal(t, http.StatusCreated, login(t, server, app).StatusCode)
+
+		res := login(t, server, app)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"account", services.ErrLimitReached}})
+	})
+}
+
+func TestPostSessionWithoutPasswordLogin(t *testing.T) {
+	app := test.App()
+	app.Config.DisablePasswordLogin = true
+	server := test.Server(app, sessions.Routes(app))
+	defer server.Close()
+
+	b, _ := bcrypt.GenerateFromPassword([]byte("bar"), 4)
+	app.AccountStore.Create("foo", b)
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+	res, err := client.PostForm("/session", url.Values{
+		"username": []string{"foo"},
+		"password": []string{"bar"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Empty(t, res.Cookies())
+}
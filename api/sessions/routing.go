@@ -3,16 +3,13 @@ package sessions
 import (
 	"github.com/keratin/authn-server/api"
 	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
 )
 
 func PublicRoutes(app *api.App) []*route.HandledRoute {
 	originSecurity := route.OriginSecurity(app.Config.ApplicationDomains)
 
-	return []*route.HandledRoute{
-		route.Post("/session").
-			SecuredWith(originSecurity).
-			Handle(postSession(app)),
-
+	routes := []*route.HandledRoute{
 		route.Delete("/session").
 			SecuredWith(originSecurity).
 			Handle(deleteSession(app)),
@@ -20,9 +17,85 @@ func PublicRoutes(app *api.App) []*route.HandledRoute {
 		route.Get("/session/refresh").
 			SecuredWith(originSecurity).
 			Handle(getSessionRefresh(app)),
+
+		// framed by the application, which is named by a param rather than the Origin header
+		route.Get("/session/refresh/frame").
+			SecuredWith(route.Unsecured()).
+			Handle(getSessionRefreshFrame(app)),
+
+		route.Get("/session/logout").
+			SecuredWith(route.Unsecured()).
+			Handle(getSessionLogout(app)),
+	}
+
+	if app.Broker != nil {
+		routes = append(routes,
+			route.Get("/session/events").
+				SecuredWith(originSecurity).
+				Handle(getSessionEvents(app)).
+				WithTimeout(0),
+		)
+	}
+
+	// Any session may step up with its push authenticator, however it logged in.
+	if app.Config.PushMFAProvider != nil {
+		routes = append(routes,
+			route.Post("/session/step_up").
+				SecuredWith(originSecurity).
+				Handle(postSessionStepUp(app)),
+
+			route.Post("/session/step_up/confirm").
+				SecuredWith(originSecurity).
+				Handle(postSessionStepUpConfirm(app)),
+		)
+	}
+
+	// Sessions from OAuth may still be refreshed and logged out, but the password login
+	// and the challenges that continue it are gone.
+	if app.Config.DisablePasswordLogin {
+		return routes
 	}
+
+	routes = append(routes,
+		route.Post("/session").
+			SecuredWith(originSecurity).
+			Handle(postSession(app)),
+	)
+
+	if app.Config.AppLoginChallengeURL != nil {
+		routes = append(routes,
+			route.Post("/session/confirm").
+				SecuredWith(originSecurity).
+				Handle(postSessionConfirm(app)),
+		)
+	}
+
+	if app.Config.PushMFAProvider != nil {
+		routes = append(routes,
+			route.Post("/session/mfa").
+				SecuredWith(originSecurity).
+				Handle(postSessionMFA(app)),
+		)
+	}
+
+	return routes
 }
 
 func Routes(app *api.App) []*route.HandledRoute {
-	return PublicRoutes(app)
+	readOnly := api.PrivateSecurity(app, models.ScopeReadOnly)
+
+	routes := PublicRoutes(app)
+
+	if app.Config.OpaqueAccessTokens {
+		routes = append(routes,
+			route.Post("/introspect").
+				SecuredWith(readOnly).
+				Handle(postIntrospect(app)),
+			route.Post("/introspect/batch").
+				SecuredWith(readOnly).
+				Handle(postIntrospectBatch(app)),
+		)
+	}
+
+	return routes
 }
@@ -0,0 +1,85 @@
+package api_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/tokens/identities"
+	"github.com/keratin/authn-server/tokens/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestSessionFingerprint(t *testing.T) {
+	fingerprint := func(cfg *config.Config, remoteAddr string, userAgent string) string {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("User-Agent", userAgent)
+		return api.SessionFingerprint(cfg, r)
+	}
+
+	t.Run("without binding", func(t *testing.T) {
+		assert.Empty(t, fingerprint(&config.Config{}, "192.0.2.1:1234", "Browser/1.0"))
+	})
+
+	t.Run("with binding", func(t *testing.T) {
+		cfg := &config.Config{SessionBinding: true}
+		original := fingerprint(cfg, "192.0.2.1:1234", "Browser/1.0")
+		assert.NotEmpty(t, original)
+
+		assert.Equal(t, original, fingerprint(cfg, "192.0.2.200:5678", "Browser/1.0"))
+		assert.Equal(t, original, fingerprint(cfg, "192.0.2.1:1234", "Browser/2.1"))
+		assert.NotEqual(t, original, fingerprint(cfg, "198.51.100.1:1234", "Browser/1.0"))
+		assert.NotEqual(t, original, fingerprint(cfg, "192.0.2.1:1234", "Scraper/1.0"))
+
+		ipv6 := fingerprint(cfg, "[2001:db8:1::1]:1234", "Browser/1.0")
+		assert.Equal(t, ipv6, fingerprint(cfg, "[2001:db8:1:2::1]:1234", "Browser/1.0"))
+		assert.NotEqual(t, ipv6, fingerprint(cfg, "[2001:db8:2::1]:1234", "Browser/1.0"))
+	})
+}
+
+func TestIdentityForSession(t *testing.T) {
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rpKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		AuthNURL:          &url.URL{Scheme: "http", Host: "authn.example.com"},
+		SessionSigningKey: []byte("key-a-reno"),
+		IdentityEncryptionKeys: map[string]*rsa.PublicKey{
+			"private.example.com": &rpKey.PublicKey,
+		},
+	}
+	keyStore := mock.NewKeyStore(signingKey)
+	session, err := sessions.New(mock.NewRefreshTokenStore(), cfg, 1, "example.com")
+	require.NoError(t, err)
+
+	t.Run("without a registered key", func(t *testing.T) {
+		token, err := api.IdentityForSession(keyStore, nil, nil, nil, cfg, session, 1, &route.Domain{Hostname: "example.com"})
+		require.NoError(t, err)
+		_, err = jwt.ParseSigned(token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("with a registered key", func(t *testing.T) {
+		token, err := api.IdentityForSession(keyStore, nil, nil, nil, cfg, session, 1, &route.Domain{Hostname: "private.example.com"})
+		require.NoError(t, err)
+		nested, err := jwt.ParseSignedAndEncrypted(token)
+		require.NoError(t, err)
+		signed, err := nested.Decrypt(rpKey)
+		require.NoError(t, err)
+
+		claims := identities.Claims{}
+		require.NoError(t, signed.Claims(signingKey.Public(), &claims))
+		assert.Equal(t, "1", claims.Subject)
+	})
+}
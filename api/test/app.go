@@ -7,6 +7,7 @@ import (
 
 	"github.com/keratin/authn-server/api"
 	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
 	"github.com/keratin/authn-server/data/mock"
 	"github.com/keratin/authn-server/lib/oauth"
 	"github.com/keratin/authn-server/lib/route"
@@ -19,7 +20,7 @@ func App() *api.App {
 		panic(err)
 	}
 
-	weakKey, err := rsa.GenerateKey(rand.Reader, 512)
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
 	if err != nil {
 		panic(err)
 	}
@@ -39,9 +40,17 @@ func App() *api.App {
 	return &api.App{
 		Config:            &cfg,
 		KeyStore:          mock.NewKeyStore(weakKey),
+		AccessTokenStore:  mock.NewAccessTokenStore(),
 		AccountStore:      mock.NewAccountStore(),
 		RefreshTokenStore: mock.NewRefreshTokenStore(),
+		AnnotationStore:   mock.NewAnnotationStore(),
+		ConsentStore:      mock.NewConsentStore(),
+		APIKeyStore:       mock.NewAPIKeyStore(),
 		Actives:           mock.NewActives(),
+		Signups:           mock.NewSignups(),
+		Quotas:            mock.NewQuotas(),
+		ModeStore:         data.NewModeStore(nil, 0),
+		DPoPProofs:        mock.NewDPoPProofs(),
 		Reporter:          &ops.LogReporter{},
 		OauthProviders:    map[string]oauth.Provider{},
 	}
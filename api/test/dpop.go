@@ -0,0 +1,41 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"time"
+
+	"github.com/keratin/authn-server/lib"
+	"github.com/keratin/authn-server/tokens/dpop"
+	jose "gopkg.in/square/go-jose.v2"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+// DPoPProof signs a DPoP proof for a request with the client's key.
+func DPoPProof(key *ecdsa.PrivateKey, method string, url string) string {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: key},
+		(&jose.SignerOptions{EmbedJWK: true}).WithType("dpop+jwt"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	jti, err := lib.GenerateToken()
+	if err != nil {
+		panic(err)
+	}
+
+	proof, err := jwt.Signed(signer).Claims(dpop.Claims{
+		Htm: method,
+		Htu: url,
+		Claims: jwt.Claims{
+			ID:       hex.EncodeToString(jti),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+	}).CompactSerialize()
+	if err != nil {
+		panic(err)
+	}
+	return proof
+}
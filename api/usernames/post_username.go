@@ -0,0 +1,37 @@
+package usernames
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/services"
+)
+
+func postUsername(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID := api.GetSessionAccountID(r)
+		if accountID == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := services.UsernameChangeRequester(app.Accounts(r), app.Config, accountID, r.FormValue("username"))
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		go func() {
+			err := services.UsernameChangeSender(app.Config, claims)
+			if err != nil {
+				app.Reporter.ReportRequestError(err, r)
+			}
+		}()
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
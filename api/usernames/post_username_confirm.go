@@ -0,0 +1,40 @@
+package usernames
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+)
+
+func postUsernameConfirm(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		revert, err := services.UsernameChangeConfirmer(app.Accounts(r), app.Config, r.FormValue("token"))
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		accountID, err := strconv.Atoi(revert.Subject)
+		if err != nil {
+			panic(err)
+		}
+		app.Events.Emit(events.AccountUpdated, accountID)
+
+		// the old username may undo the change for a grace period
+		go func() {
+			err := services.UsernameChangeSender(app.Config, revert)
+			if err != nil {
+				app.Reporter.ReportRequestError(err, r)
+			}
+		}()
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,58 @@
+package usernames_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/api/usernames"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	tokens "github.com/keratin/authn-server/tokens/usernames"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostUsernameConfirm(t *testing.T) {
+	app := test.App()
+	app.Config.AppUsernameChangeURL = &url.URL{Scheme: "https", Host: "app.example.com"}
+	app.Config.UsernameChangeSigningKey = []byte("change-a-reno")
+	app.Config.UsernameChangeTokenTTL = time.Hour
+	app.Config.UsernameRevertTTL = time.Hour
+	server := test.Server(app, usernames.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("valid token", func(t *testing.T) {
+		account, err := app.AccountStore.Create("before@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		claims, err := tokens.NewChange(app.Config, account.ID, "before@keratin.tech", "after@keratin.tech")
+		require.NoError(t, err)
+		token, err := claims.Sign(app.Config.UsernameChangeSigningKey)
+		require.NoError(t, err)
+
+		res, err := client.PostForm("/username/confirm", url.Values{"token": []string{token}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		found, err := app.AccountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "after@keratin.tech", found.Username)
+
+		// can not be used twice
+		res, err = client.PostForm("/username/confirm", url.Values{"token": []string{token}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"token", services.ErrInvalidOrExpired}})
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		res, err := client.PostForm("/username/confirm", url.Values{"token": []string{"invalid"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"token", services.ErrInvalidOrExpired}})
+	})
+}
@@ -0,0 +1,28 @@
+package usernames
+
+import (
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+)
+
+func postUsernameRevert(app *api.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := services.UsernameChangeReverter(app.Accounts(r), app.RefreshTokens(r), app.Config, r.FormValue("token"))
+		if err != nil {
+			if fe, ok := err.(services.FieldErrors); ok {
+				api.WriteErrors(w, fe)
+				return
+			}
+
+			panic(err)
+		}
+
+		app.Events.Emit(events.AccountUpdated, accountID)
+		app.Events.Emit(events.PasswordExpired, accountID)
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
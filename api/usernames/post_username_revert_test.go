@@ -0,0 +1,58 @@
+package usernames_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/api/usernames"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	tokens "github.com/keratin/authn-server/tokens/usernames"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostUsernameRevert(t *testing.T) {
+	app := test.App()
+	app.Config.AppUsernameChangeURL = &url.URL{Scheme: "https", Host: "app.example.com"}
+	app.Config.UsernameChangeSigningKey = []byte("change-a-reno")
+	app.Config.UsernameRevertTTL = time.Hour
+	server := test.Server(app, usernames.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("valid token", func(t *testing.T) {
+		account, err := app.AccountStore.Create("attacker@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		session := test.CreateSession(app.RefreshTokenStore, app.Config, account.ID)
+		claims, err := tokens.NewRevert(app.Config, account.ID, "attacker@keratin.tech", "victim@keratin.tech")
+		require.NoError(t, err)
+		token, err := claims.Sign(app.Config.UsernameChangeSigningKey)
+		require.NoError(t, err)
+
+		res, err := client.PostForm("/username/revert", url.Values{"token": []string{token}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		found, err := app.AccountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "victim@keratin.tech", found.Username)
+		assert.True(t, found.RequireNewPassword)
+
+		// revokes the attacker's session
+		res, err = client.WithCookie(session).PostForm("/username", url.Values{"username": []string{"again@keratin.tech"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		res, err := client.PostForm("/username/revert", url.Values{"token": []string{"invalid"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"token", services.ErrInvalidOrExpired}})
+	})
+}
@@ -0,0 +1,64 @@
+package usernames_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/api/usernames"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostUsername(t *testing.T) {
+	app := test.App()
+	app.Config.AppUsernameChangeURL = &url.URL{Scheme: "https", Host: "app.example.com"}
+	app.Config.UsernameChangeSigningKey = []byte("change-a-reno")
+	server := test.Server(app, usernames.Routes(app))
+	defer server.Close()
+
+	client := route.NewClient(server.URL).Referred(&app.Config.ApplicationDomains[0])
+
+	t.Run("valid session", func(t *testing.T) {
+		account, err := app.AccountStore.Create("before@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		session := test.CreateSession(app.RefreshTokenStore, app.Config, account.ID)
+
+		res, err := client.WithCookie(session).PostForm("/username", url.Values{
+			"username": []string{"after@keratin.tech"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		// waits for confirmation
+		found, err := app.AccountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "before@keratin.tech", found.Username)
+	})
+
+	t.Run("taken username", func(t *testing.T) {
+		account, err := app.AccountStore.Create("mine@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		_, err = app.AccountStore.Create("theirs@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		session := test.CreateSession(app.RefreshTokenStore, app.Config, account.ID)
+
+		res, err := client.WithCookie(session).PostForm("/username", url.Values{
+			"username": []string{"theirs@keratin.tech"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		test.AssertErrors(t, res, services.FieldErrors{{"username", services.ErrTaken}})
+	})
+
+	t.Run("without a session", func(t *testing.T) {
+		res, err := client.PostForm("/username", url.Values{
+			"username": []string{"anonymous@keratin.tech"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+}
@@ -0,0 +1,32 @@
+package usernames
+
+import (
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/route"
+)
+
+func PublicRoutes(app *api.App) []*route.HandledRoute {
+	originSecurity := route.OriginSecurity(app.Config.ApplicationDomains)
+
+	routes := []*route.HandledRoute{}
+
+	if app.Config.AppUsernameChangeURL != nil {
+		routes = append(routes,
+			route.Post("/username").
+				SecuredWith(originSecurity).
+				Handle(postUsername(app)),
+			route.Post("/username/confirm").
+				SecuredWith(originSecurity).
+				Handle(postUsernameConfirm(app)),
+			route.Post("/username/revert").
+				SecuredWith(originSecurity).
+				Handle(postUsernameRevert(app)),
+		)
+	}
+
+	return routes
+}
+
+func Routes(app *api.App) []*route.HandledRoute {
+	return PublicRoutes(app)
+}
@@ -0,0 +1,48 @@
+package authnmiddleware_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keratin/authn-server/authnmiddleware"
+	"github.com/keratin/authn-server/authntest"
+	"github.com/keratin/authn-server/tokens/identities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	authn := authntest.NewServer()
+	defer authn.Close()
+
+	interceptor := newVerifier(authn).UnaryServerInterceptor()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return authnmiddleware.FromContext(ctx).Subject, nil
+	}
+
+	t.Run("with a valid token", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			"authorization", "Bearer "+sign(t, authn, func(*identities.Claims) {}),
+		))
+		res, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+		require.NoError(t, err)
+		assert.Equal(t, "1234", res)
+	})
+
+	t.Run("without a token", func(t *testing.T) {
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("with an invalid token", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			"authorization", "Bearer "+sign(t, authn, func(c *identities.Claims) { c.Audience = nil }),
+		))
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
@@ -0,0 +1,66 @@
+package authnmiddleware
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type claimsKey int
+
+// NewContext returns a context that carries verified claims.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey(0), claims)
+}
+
+// FromContext returns the verified claims of the current request, or nil.
+func FromContext(ctx context.Context) *Claims {
+	claims, _ := ctx.Value(claimsKey(0)).(*Claims)
+	return claims
+}
+
+// Handler rejects requests without a valid `Authorization: Bearer` access token with 401
+// Unauthorized. Tokens bound to a DPoP key must instead be sent as `Authorization: DPoP` with a
+// proof in the `DPoP` header. Verified claims are available to the next handler through FromContext.
+func (v *Verifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := v.verifyRequest(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), claims)))
+	})
+}
+
+func (v *Verifier) verifyRequest(r *http.Request) (*Claims, error) {
+	header := r.Header.Get("Authorization")
+	if len(header) < 5 || !strings.EqualFold(header[:5], "DPoP ") {
+		return v.verifyHeader(header)
+	}
+	return v.VerifyDPoP(strings.TrimSpace(header[5:]), r.Header.Get("DPoP"), r.Method, requestURL(r))
+}
+
+// requestURL is the URL that a DPoP proof for the request must name. Behind a proxy that
+// terminates TLS, the scheme is taken from X-Forwarded-Proto.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	u := url.URL{Scheme: scheme, Host: r.Host, Path: r.URL.Path}
+	return u.String()
+}
+
+func (v *Verifier) verifyHeader(header string) (*Claims, error) {
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return nil, ErrMissingHeader
+	}
+	return v.Verify(strings.TrimSpace(header[7:]))
+}
@@ -0,0 +1,72 @@
+package authnmiddleware_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keratin/authn-server/authnmiddleware"
+	"github.com/keratin/authn-server/authntest"
+	"github.com/keratin/authn-server/tokens/identities"
+	"github.com/keratin/authn-server/tokens/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	authn := authntest.NewServer()
+	defer authn.Close()
+
+	handler := newVerifier(authn).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(authnmiddleware.FromContext(r.Context()).Subject))
+	}))
+
+	t.Run("with a valid token", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+sign(t, authn, func(*identities.Claims) {}))
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "1234", res.Body.String())
+	})
+
+	t.Run("without a token", func(t *testing.T) {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+		assert.Equal(t, `Bearer error="invalid_token"`, res.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("with an invalid token", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+sign(t, authn, func(c *identities.Claims) { c.Issuer = "bogus" }))
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+	})
+
+	t.Run("with a DPoP-bound token", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		proof, jkt := prove(t, key, "GET", "https://api.example.com/things")
+		token := sign(t, authn, func(c *identities.Claims) { c.Cnf = &sessions.Confirmation{Jkt: jkt} })
+
+		req := httptest.NewRequest("GET", "https://api.example.com/things", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+		req.Header.Set("Authorization", "DPoP "+token)
+		req.Header.Set("DPoP", proof)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+}
@@ -0,0 +1,129 @@
+package authnmiddleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// DefaultCacheTTL is how long fetched keys are trusted before the JWKS is fetched again.
+const DefaultCacheTTL = time.Hour
+
+// refetchInterval limits how often an unknown key ID may trigger a fetch, so that tokens with bogus
+// key IDs can not be used to flood AuthN.
+const refetchInterval = 10 * time.Second
+
+// keySet caches AuthN's public keys. An unknown key ID triggers a fetch, since AuthN may have
+// rotated keys since the last one. When a fetch fails, known keys are still served and the
+// error is kept until a fetch succeeds. Fetches run without holding the lock, and concurrent
+// lookups wait for the one in flight rather than starting their own.
+type keySet struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]jose.JSONWebKey
+	fetchedAt time.Time
+	failedAt  time.Time
+	err       error
+	fetching  chan struct{}
+}
+
+func newKeySet(url string) *keySet {
+	return &keySet{
+		url:    url,
+		ttl:    DefaultCacheTTL,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *keySet) find(kid string) (*jose.JSONWebKey, error) {
+	s.mu.Lock()
+	key, found := s.keys[kid]
+	stale := time.Since(s.fetchedAt) > s.ttl
+	wanted := stale || (!found && time.Since(s.fetchedAt) > refetchInterval)
+	if !wanted || time.Since(s.failedAt) <= refetchInterval {
+		s.mu.Unlock()
+		if !found {
+			return nil, ErrUnknownKey
+		}
+		return &key, nil
+	}
+	done := s.refresh()
+	s.mu.Unlock()
+	<-done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, found = s.keys[kid]
+	if !found {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, ErrUnknownKey
+	}
+	return &key, nil
+}
+
+// refresh starts a fetch unless one is already in flight, and returns a channel that is closed
+// when it completes. The caller must hold s.mu.
+func (s *keySet) refresh() <-chan struct{} {
+	if s.fetching != nil {
+		return s.fetching
+	}
+
+	done := make(chan struct{})
+	s.fetching = done
+	go func() {
+		keys, err := s.fetch()
+
+		s.mu.Lock()
+		if err != nil {
+			s.failedAt = time.Now()
+		} else {
+			s.keys = keys
+			s.fetchedAt = time.Now()
+		}
+		s.err = err
+		s.fetching = nil
+		s.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+func (s *keySet) fetchError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *keySet) fetch() (map[string]jose.JSONWebKey, error) {
+	res, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, errors.Wrap(err, "Get")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %v", res.StatusCode)
+	}
+
+	var jwks jose.JSONWebKeySet
+	err = json.NewDecoder(res.Body).Decode(&jwks)
+	if err != nil {
+		return nil, errors.Wrap(err, "Decode")
+	}
+
+	keys := make(map[string]jose.JSONWebKey, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		keys[key.KeyID] = key
+	}
+	return keys, nil
+}
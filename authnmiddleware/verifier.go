@@ -0,0 +1,198 @@
+// Package authnmiddleware verifies access tokens issued by AuthN, so that resource servers written
+// in Go can authenticate requests without calling AuthN for each one.
+//
+// A Verifier fetches AuthN's public keys from the JWKS endpoint and caches them. Tokens are checked
+// for a trusted signature, the issuer, one of the expected audiences, and expiration, with some
+// leeway for clock skew. Tokens bound to a DPoP key (RFC 9449) are refused unless they are
+// verified together with a proof from that key. The Handler and gRPC interceptors apply a Verifier
+// to incoming requests and make the verified Claims available from the request context.
+package authnmiddleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/keratin/authn-server/tokens/dpop"
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+// DefaultLeeway is the allowed clock skew between AuthN and the resource server.
+const DefaultLeeway = 30 * time.Second
+
+var (
+	ErrMalformed     = errors.New("malformed token")
+	ErrAlgorithm     = errors.New("unexpected signing algorithm")
+	ErrUnknownKey    = errors.New("unknown signing key")
+	ErrIssuer        = errors.New("unexpected issuer")
+	ErrAudience      = errors.New("unexpected audience")
+	ErrExpired       = errors.New("token is expired")
+	ErrNotValidYet   = errors.New("token is not valid yet")
+	ErrMissingHeader = errors.New("missing bearer token")
+	ErrBound         = errors.New("token is bound to a DPoP key")
+	ErrProof         = errors.New("invalid DPoP proof")
+)
+
+// Claims are the verified contents of an AuthN access token. The subject is the account ID.
+type Claims struct {
+	Amr      []string `json:"amr,omitempty"`
+	Sid      string   `json:"sid,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Scope    string   `json:"scope,omitempty"`
+	// Cnf is set when the token is bound to a DPoP key, and may only be used with a proof from it.
+	Cnf *Confirmation `json:"cnf,omitempty"`
+	jwt.Claims
+}
+
+// Confirmation identifies the key that a token is bound to by its JWK thumbprint.
+type Confirmation struct {
+	Jkt string `json:"jkt"`
+}
+
+// HasScope reports whether the token was granted a scope.
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range strings.Fields(c.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Verifier checks access tokens from a single AuthN issuer.
+type Verifier struct {
+	Issuer    string
+	Audiences []string
+	Leeway    time.Duration
+	keys      *keySet
+}
+
+// Option configures a Verifier.
+type Option func(*Verifier)
+
+// WithJWKSURL fetches keys from a URL other than the issuer's /jwks, for example when the resource
+// server reaches AuthN on a private network address.
+func WithJWKSURL(jwksURL string) Option {
+	return func(v *Verifier) {
+		v.keys.url = jwksURL
+	}
+}
+
+// WithLeeway changes the allowed clock skew for exp and nbf claims.
+func WithLeeway(leeway time.Duration) Option {
+	return func(v *Verifier) {
+		v.Leeway = leeway
+	}
+}
+
+// WithCacheTTL changes how long fetched keys are trusted before the JWKS is fetched again.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(v *Verifier) {
+		v.keys.ttl = ttl
+	}
+}
+
+// NewVerifier returns a Verifier for tokens from the issuer (AUTHN_URL) that were issued to any of
+// the audiences (APP_DOMAINS).
+func NewVerifier(issuer string, audiences []string, opts ...Option) *Verifier {
+	v := &Verifier{
+		Issuer:    issuer,
+		Audiences: audiences,
+		Leeway:    DefaultLeeway,
+		keys:      newKeySet(strings.TrimSuffix(issuer, "/") + "/jwks"),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Verify parses a compact access token and returns its claims when it is valid. Tokens bound to a
+// DPoP key are refused with ErrBound, since a bearer could have stolen them; see VerifyDPoP.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	claims, err := v.verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Cnf != nil {
+		return nil, ErrBound
+	}
+	return claims, nil
+}
+
+// VerifyDPoP is Verify for a token presented with a DPoP proof for the request's method and URL.
+// When the token is bound, the proof must be valid and signed by the bound key. Proofs are not
+// checked for replay within their few minutes of validity.
+func (v *Verifier) VerifyDPoP(token string, proof string, method string, requestURL string) (*Claims, error) {
+	claims, err := v.verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Cnf == nil {
+		return claims, nil
+	}
+	thumbprint, err := dpop.Parse(proof, method, requestURL, time.Now())
+	if err != nil || thumbprint != claims.Cnf.Jkt {
+		return nil, ErrProof
+	}
+	return claims, nil
+}
+
+// FetchError returns the error of the last JWKS fetch when it failed, or nil. Cached keys are
+// still trusted while fetches fail.
+func (v *Verifier) FetchError() error {
+	return v.keys.fetchError()
+}
+
+func (v *Verifier) verify(token string) (*Claims, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if len(parsed.Headers) != 1 {
+		return nil, ErrMalformed
+	}
+	header := parsed.Headers[0]
+	if header.Algorithm != string(jose.RS256) {
+		return nil, ErrAlgorithm
+	}
+
+	key, err := v.keys.find(header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := Claims{}
+	err = parsed.Claims(key.Key, &claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "Claims")
+	}
+
+	now := time.Now()
+	if claims.Issuer != v.Issuer {
+		return nil, ErrIssuer
+	}
+	if !v.allows(claims.Audience) {
+		return nil, ErrAudience
+	}
+	if claims.Expiry == 0 || now.Add(-v.Leeway).After(claims.Expiry.Time()) {
+		return nil, ErrExpired
+	}
+	if claims.NotBefore != 0 && now.Add(v.Leeway).Before(claims.NotBefore.Time()) {
+		return nil, ErrNotValidYet
+	}
+
+	return &claims, nil
+}
+
+func (v *Verifier) allows(audience jwt.Audience) bool {
+	for _, aud := range v.Audiences {
+		for _, a := range audience {
+			if a == aud {
+				return true
+			}
+		}
+	}
+	return false
+}
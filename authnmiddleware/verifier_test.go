@@ -0,0 +1,255 @@
+package authnmiddleware_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/authnmiddleware"
+	"github.com/keratin/authn-server/authntest"
+	"github.com/keratin/authn-server/tokens/dpop"
+	"github.com/keratin/authn-server/tokens/identities"
+	"github.com/keratin/authn-server/tokens/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jose "gopkg.in/square/go-jose.v2"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+func newVerifier(authn *authntest.Server, opts ...authnmiddleware.Option) *authnmiddleware.Verifier {
+	opts = append([]authnmiddleware.Option{authnmiddleware.WithJWKSURL(authn.URL + "/jwks")}, opts...)
+	return authnmiddleware.NewVerifier(
+		authn.App.Config.AuthNURL.String(),
+		[]string{authn.Domain().String()},
+		opts...,
+	)
+}
+
+func sign(t *testing.T, authn *authntest.Server, fn func(*identities.Claims)) string {
+	claims := &identities.Claims{
+		Claims: jwt.Claims{
+			Issuer:   authn.App.Config.AuthNURL.String(),
+			Subject:  "1234",
+			Audience: jwt.Audience{authn.Domain().String()},
+			Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+	}
+	fn(claims)
+	token, err := claims.Sign(authn.App.KeyStore.Key())
+	require.NoError(t, err)
+	return token
+}
+
+// prove returns a DPoP proof signed by key, and the key's thumbprint.
+func prove(t *testing.T, key *ecdsa.PrivateKey, method string, requestURL string) (string, string) {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: key},
+		(&jose.SignerOptions{EmbedJWK: true}).WithType("dpop+jwt"),
+	)
+	require.NoError(t, err)
+	proof, err := jwt.Signed(signer).Claims(dpop.Claims{
+		Htm:    method,
+		Htu:    requestURL,
+		Claims: jwt.Claims{ID: "abc123", IssuedAt: jwt.NewNumericDate(time.Now())},
+	}).CompactSerialize()
+	require.NoError(t, err)
+
+	thumbprint, err := (&jose.JSONWebKey{Key: key.Public()}).Thumbprint(crypto.SHA256)
+	require.NoError(t, err)
+	return proof, base64.RawURLEncoding.EncodeToString(thumbprint)
+}
+
+func TestVerifier(t *testing.T) {
+	authn := authntest.NewServer()
+	defer authn.Close()
+	verifier := newVerifier(authn)
+
+	t.Run("valid token", func(t *testing.T) {
+		account, err := authn.CreateAccount("test@example.com", "password")
+		require.NoError(t, err)
+		_, identityToken, err := authn.Login(account.ID)
+		require.NoError(t, err)
+
+		claims, err := verifier.Verify(identityToken)
+		require.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(account.ID), claims.Subject)
+	})
+
+	t.Run("scopes", func(t *testing.T) {
+		token := sign(t, authn, func(c *identities.Claims) { c.Scope = "read write" })
+		claims, err := verifier.Verify(token)
+		require.NoError(t, err)
+		assert.True(t, claims.HasScope("write"))
+		assert.False(t, claims.HasScope("admin"))
+	})
+
+	t.Run("malformed token", func(t *testing.T) {
+		_, err := verifier.Verify("not.a.token")
+		assert.Equal(t, authnmiddleware.ErrMalformed, err)
+	})
+
+	t.Run("unexpected issuer", func(t *testing.T) {
+		token := sign(t, authn, func(c *identities.Claims) { c.Issuer = "https://evil.example.com" })
+		_, err := verifier.Verify(token)
+		assert.Equal(t, authnmiddleware.ErrIssuer, err)
+	})
+
+	t.Run("unexpected audience", func(t *testing.T) {
+		token := sign(t, authn, func(c *identities.Claims) { c.Audience = jwt.Audience{"other.com"} })
+		_, err := verifier.Verify(token)
+		assert.Equal(t, authnmiddleware.ErrAudience, err)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		token := sign(t, authn, func(c *identities.Claims) { c.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Minute)) })
+		_, err := verifier.Verify(token)
+		assert.Equal(t, authnmiddleware.ErrExpired, err)
+	})
+
+	t.Run("expired within leeway", func(t *testing.T) {
+		token := sign(t, authn, func(c *identities.Claims) { c.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Minute)) })
+		_, err := newVerifier(authn, authnmiddleware.WithLeeway(2*time.Minute)).Verify(token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("token from an unknown key", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+
+		claims := &identities.Claims{
+			Claims: jwt.Claims{
+				Issuer:   authn.App.Config.AuthNURL.String(),
+				Subject:  "1234",
+				Audience: jwt.Audience{authn.Domain().String()},
+				Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+		token, err := claims.Sign(key)
+		require.NoError(t, err)
+
+		_, err = verifier.Verify(token)
+		assert.Equal(t, authnmiddleware.ErrUnknownKey, err)
+	})
+
+	t.Run("token bound to a DPoP key", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		proof, jkt := prove(t, key, "GET", "https://api.example.com/things")
+		otherProof, _ := prove(t, other, "GET", "https://api.example.com/things")
+		token := sign(t, authn, func(c *identities.Claims) { c.Cnf = &sessions.Confirmation{Jkt: jkt} })
+
+		_, err = verifier.Verify(token)
+		assert.Equal(t, authnmiddleware.ErrBound, err)
+
+		claims, err := verifier.VerifyDPoP(token, proof, "GET", "https://api.example.com/things")
+		require.NoError(t, err)
+		assert.Equal(t, jkt, claims.Cnf.Jkt)
+
+		_, err = verifier.VerifyDPoP(token, otherProof, "GET", "https://api.example.com/things")
+		assert.Equal(t, authnmiddleware.ErrProof, err)
+
+		_, err = verifier.VerifyDPoP(token, proof, "POST", "https://api.example.com/things")
+		assert.Equal(t, authnmiddleware.ErrProof, err)
+
+		_, err = verifier.VerifyDPoP(token, "", "GET", "https://api.example.com/things")
+		assert.Equal(t, authnmiddleware.ErrProof, err)
+	})
+}
+
+func TestVerifierFetchError(t *testing.T) {
+	authn := authntest.NewServer()
+	defer authn.Close()
+
+	target, err := url.Parse(authn.URL)
+	require.NoError(t, err)
+	var failing int32
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	defer jwks.Close()
+
+	// every verification finds the keys stale and fetches them again
+	verifier := authnmiddleware.NewVerifier(
+		authn.App.Config.AuthNURL.String(),
+		[]string{authn.Domain().String()},
+		authnmiddleware.WithJWKSURL(jwks.URL+"/jwks"),
+		authnmiddleware.WithCacheTTL(0),
+	)
+	token := sign(t, authn, func(*identities.Claims) {})
+
+	_, err = verifier.Verify(token)
+	require.NoError(t, err)
+	assert.NoError(t, verifier.FetchError())
+
+	atomic.StoreInt32(&failing, 1)
+	_, err = verifier.Verify(token)
+	assert.NoError(t, err)
+	assert.Error(t, verifier.FetchError())
+}
+
+func TestVerifierConcurrentFetch(t *testing.T) {
+	authn := authntest.NewServer()
+	defer authn.Close()
+
+	target, err := url.Parse(authn.URL)
+	require.NoError(t, err)
+	var fetches int32
+	release := make(chan struct{})
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		proxy.ServeHTTP(w, r)
+	}))
+	defer jwks.Close()
+
+	verifier := newVerifier(authn, authnmiddleware.WithJWKSURL(jwks.URL+"/jwks"))
+	token := sign(t, authn, func(*identities.Claims) {})
+
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := verifier.Verify(token)
+			errs <- err
+		}()
+	}
+
+	for atomic.LoadInt32(&fetches) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the fetch in flight does not hold up other callers
+	fetchErr := make(chan error)
+	go func() { fetchErr <- verifier.FetchError() }()
+	select {
+	case err := <-fetchErr:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("FetchError waited for the fetch")
+	}
+
+	close(release)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, <-errs)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
@@ -0,0 +1,109 @@
+// Package authntest runs a complete AuthN server in-process, backed by in-memory stores, so that
+// applications integrating with AuthN can write end-to-end tests without Docker or databases.
+//
+// AuthN does not send email or SMS itself. It delivers password reset tokens and other
+// notifications to the application by webhook, and the Server records every webhook so that tests
+// may assert on them.
+package authntest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/server"
+	"github.com/keratin/authn-server/services"
+	"github.com/pkg/errors"
+)
+
+// Credentials for the private API of a test Server.
+const (
+	Username = "authntest"
+	Password = "authntest"
+)
+
+// Server is an in-process AuthN server.
+type Server struct {
+	*httptest.Server
+	App      *api.App
+	Webhooks *WebhookRecorder
+}
+
+// NewServer starts a Server. The App may be modified before the first request, for example to
+// enable optional features in App.Config.
+func NewServer() *Server {
+	webhooks := NewWebhookRecorder()
+
+	app := test.App()
+	app.Config.AuthUsername = Username
+	app.Config.AuthPassword = Password
+	app.Config.ResetSigningKey = []byte("authntest-reset-signing-key")
+	app.Config.AppPasswordResetURL = webhooks.URL("/password_reset")
+	app.Config.AppPasswordChangedURL = webhooks.URL("/password_changed")
+
+	return &Server{
+		Server:   httptest.NewServer(server.Router(app)),
+		App:      app,
+		Webhooks: webhooks,
+	}
+}
+
+// Close shuts down the server and the webhook recorder.
+func (s *Server) Close() {
+	s.Server.Close()
+	s.Webhooks.Close()
+}
+
+// Domain is the application domain that public requests must come from.
+func (s *Server) Domain() *route.Domain {
+	return &s.App.Config.ApplicationDomains[0]
+}
+
+// PublicClient returns a client for public endpoints, with a trusted Origin header.
+func (s *Server) PublicClient() *route.Client {
+	return route.NewClient(s.URL).Referred(s.Domain())
+}
+
+// PrivateClient returns a client for private endpoints, with basic auth credentials.
+func (s *Server) PrivateClient() *route.Client {
+	return route.NewClient(s.URL).Authenticated(Username, Password)
+}
+
+// CreateAccount creates an account directly in the store, skipping password policies.
+func (s *Server) CreateAccount(username string, password string) (*models.Account, error) {
+	return services.AccountImporter(s.App.AccountStore, s.App.Config, username, password, false)
+}
+
+// Login mints a session cookie and identity token for an account, as if it had just logged in.
+func (s *Server) Login(accountID int) (*http.Cookie, string, error) {
+	sessionToken, identityToken, err := api.NewSession(
+		s.App.RefreshTokenStore,
+		s.App.KeyStore,
+		s.App.AccessTokenStore,
+		s.App.AccountStore,
+		s.App.Actives,
+		s.App.Config,
+		accountID,
+		s.Domain(),
+		"",
+		"",
+		nil,
+	)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "NewSession")
+	}
+
+	return &http.Cookie{Name: s.App.Config.SessionCookieName, Value: sessionToken}, identityToken, nil
+}
+
+func mustParse(str string) *url.URL {
+	u, err := url.Parse(str)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
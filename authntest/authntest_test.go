@@ -0,0 +1,57 @@
+package authntest_test
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/authntest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	server := authntest.NewServer()
+	defer server.Close()
+
+	account, err := server.CreateAccount("test@example.com", "password")
+	require.NoError(t, err)
+
+	t.Run("logging in", func(t *testing.T) {
+		res, err := server.PublicClient().PostForm("/session", url.Values{
+			"username": []string{"test@example.com"},
+			"password": []string{"password"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("minting sessions", func(t *testing.T) {
+		session, identityToken, err := server.Login(account.ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, identityToken)
+
+		res, err := server.PublicClient().WithCookie(session).Get("/session/refresh")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("private API", func(t *testing.T) {
+		res, err := server.PrivateClient().Get("/accounts/" + strconv.Itoa(account.ID))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("recording webhooks", func(t *testing.T) {
+		res, err := server.PublicClient().Get("/password/reset?username=test@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		hook, err := server.Webhooks.Next(time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "/password_reset", hook.Path)
+		assert.NotEmpty(t, hook.Values.Get("token"))
+	})
+}
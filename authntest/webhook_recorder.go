@@ -0,0 +1,73 @@
+package authntest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Webhook is a request that AuthN sent to the application.
+type Webhook struct {
+	Path   string
+	Values url.Values
+}
+
+// WebhookRecorder is a fake application backend that records every webhook it receives.
+type WebhookRecorder struct {
+	server   *httptest.Server
+	mutex    sync.Mutex
+	received []Webhook
+	arrivals chan Webhook
+}
+
+// NewWebhookRecorder starts a WebhookRecorder.
+func NewWebhookRecorder() *WebhookRecorder {
+	r := &WebhookRecorder{arrivals: make(chan Webhook, 100)}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		hook := Webhook{Path: req.URL.Path, Values: req.PostForm}
+
+		r.mutex.Lock()
+		r.received = append(r.received, hook)
+		r.mutex.Unlock()
+
+		select {
+		case r.arrivals <- hook:
+		default:
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	return r
+}
+
+// URL returns the address of a path on the recorder.
+func (r *WebhookRecorder) URL(path string) *url.URL {
+	return mustParse(r.server.URL + path)
+}
+
+// Received returns every webhook so far.
+func (r *WebhookRecorder) Received() []Webhook {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Webhook{}, r.received...)
+}
+
+// Next waits for the next webhook to arrive. Webhooks are delivered in the background, so tests
+// should wait rather than check Received immediately after a request.
+func (r *WebhookRecorder) Next(timeout time.Duration) (*Webhook, error) {
+	select {
+	case hook := <-r.arrivals:
+		return &hook, nil
+	case <-time.After(timeout):
+		return nil, errors.New("timed out waiting for webhook")
+	}
+}
+
+// Close shuts down the recorder.
+func (r *WebhookRecorder) Close() {
+	r.server.Close()
+}
@@ -0,0 +1,521 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-redis/redis"
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/compat"
+	"github.com/keratin/authn-server/lib/hashes"
+	"github.com/keratin/authn-server/lib/importers"
+	"github.com/keratin/authn-server/lib/jobs"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/server"
+	"github.com/keratin/authn-server/services"
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+
+	dataRedis "github.com/keratin/authn-server/data/redis"
+)
+
+// connect opens the configured databases for a one-off command. Redis is nil when not configured.
+func connect(cfg *config.Config) (*sqlx.DB, *redis.Client, error) {
+	db, err := data.NewDB(cfg.DatabaseURL)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "data.NewDB")
+	}
+
+	var client *redis.Client
+	if cfg.RedisURL != nil {
+		client, err = dataRedis.New(cfg.RedisURL, nil, cfg.RedisKeyPrefix)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "redis.New")
+		}
+	}
+
+	return db, client, nil
+}
+
+// exit reports an error from a command and exits with a failure code.
+func exit(err error) {
+	os.Stderr.WriteString(fmt.Sprintf("%v\n", err))
+	os.Exit(1)
+}
+
+func createAccount(args []string) {
+	flags := flag.NewFlagSet("create-account", flag.ExitOnError)
+	username := flags.String("username", "", "username for the new account")
+	password := flags.String("password", "", "password (or bcrypt hash) for the new account")
+	locked := flags.Bool("locked", false, "create the account in a locked state")
+	flags.Parse(args)
+
+	cfg := config.ReadEnv()
+	db, _, err := connect(cfg)
+	if err != nil {
+		exit(err)
+	}
+	accountStore, err := data.NewAccountStore(db)
+	if err != nil {
+		exit(err)
+	}
+
+	account, err := services.AccountImporter(accountStore, cfg, *username, *password, *locked)
+	if err != nil {
+		exit(err)
+	}
+	fmt.Println(fmt.Sprintf("Created account %d.", account.ID))
+}
+
+func lockAccount(args []string) {
+	if len(args) != 1 {
+		exit(fmt.Errorf("usage: lock <account id>"))
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		exit(fmt.Errorf("invalid account id: %s", args[0]))
+	}
+
+	cfg := config.ReadEnv()
+	db, client, err := connect(cfg)
+	if err != nil {
+		exit(err)
+	}
+	accountStore, err := data.NewAccountStore(db)
+	if err != nil {
+		exit(err)
+	}
+	tokenStore, err := data.NewRefreshTokenStore(db, client, jobs.NewScheduler(nil, cfg.ErrorReporter), cfg.RefreshTokenTTL)
+	if err != nil {
+		exit(err)
+	}
+
+	err = services.AccountLocker(accountStore, tokenStore, id)
+	if err != nil {
+		exit(err)
+	}
+	fmt.Println(fmt.Sprintf("Locked account %d and revoked its sessions.", id))
+	err = publishAccountChange(cfg, db, client, id)
+	if err != nil {
+		exit(errors.Wrap(err, "servers may serve the account from their caches until ACCOUNT_CACHE_TTL passes"))
+	}
+}
+
+// publishAccountChange tells running AuthN servers to drop the account from their caches, as the
+// server does after its own changes. Otherwise they would see the change after ACCOUNT_CACHE_TTL.
+func publishAccountChange(cfg *config.Config, db *sqlx.DB, client *redis.Client, id int) error {
+	if cfg.AccountCacheTTL <= 0 {
+		return nil
+	}
+	var stream *dataRedis.Stream
+	if cfg.RedisStreams {
+		stream = &dataRedis.Stream{Client: client, Name: cfg.InstanceName}
+	}
+	changes := data.NewAccountChanges(db, client, cfg.RedisKeyPrefix, stream)
+	if changes == nil {
+		return nil
+	}
+	return changes.Publish(id)
+}
+
+func importAccounts(args []string) {
+	flags := flag.NewFlagSet("import-accounts", flag.ExitOnError)
+	from := flags.String("from", "", "format of the export: auth0, firebase, or devise")
+	file := flags.String("file", "", "path to the export")
+	signerKey := flags.String("firebase-signer-key", "", "base64 signer key from the Firebase password hash parameters")
+	saltSeparator := flags.String("firebase-salt-separator", "", "base64 salt separator from the Firebase password hash parameters")
+	rounds := flags.Int("firebase-rounds", 8, "rounds from the Firebase password hash parameters")
+	memCost := flags.Int("firebase-mem-cost", 14, "mem_cost from the Firebase password hash parameters")
+	peppered := flags.Bool("devise-peppered", false, "the Devise application configured a pepper (see DEVISE_PEPPER)")
+	flags.Parse(args)
+
+	in, err := os.Open(*file)
+	if err != nil {
+		exit(err)
+	}
+	defer in.Close()
+
+	var read func(func(importers.Record) error) error
+	switch *from {
+	case "auth0":
+		read = func(fn func(importers.Record) error) error { return importers.Auth0(in, fn) }
+	case "firebase":
+		params := hashes.FirebaseParams{Rounds: *rounds, MemCost: *memCost}
+		params.SignerKey, err = base64.StdEncoding.DecodeString(*signerKey)
+		if err != nil || len(params.SignerKey) == 0 {
+			exit(fmt.Errorf("invalid -firebase-signer-key"))
+		}
+		params.SaltSeparator, err = base64.StdEncoding.DecodeString(*saltSeparator)
+		if err != nil {
+			exit(fmt.Errorf("invalid -firebase-salt-separator"))
+		}
+		read = func(fn func(importers.Record) error) error { return importers.Firebase(in, params, fn) }
+	case "devise":
+		read = func(fn func(importers.Record) error) error { return importers.Devise(in, *peppered, fn) }
+	default:
+		exit(fmt.Errorf("unsupported -from: %q", *from))
+	}
+
+	cfg := config.ReadEnv()
+	db, _, err := connect(cfg)
+	if err != nil {
+		exit(err)
+	}
+	accountStore, err := data.NewAccountStore(db)
+	if err != nil {
+		exit(err)
+	}
+	annotationStore, err := data.NewAnnotationStore(db)
+	if err != nil {
+		exit(err)
+	}
+
+	imported, failed := 0, 0
+	err = read(func(record importers.Record) error {
+		metadata := map[string]string{"import_source": *from}
+		if record.ID != "" {
+			metadata["import_id"] = record.ID
+		}
+		_, err := services.AccountProvisioner(accountStore, annotationStore, cfg, services.Provision{
+			Username:      record.Username,
+			Password:      record.PasswordHash,
+			Locked:        record.Locked,
+			EmailVerified: record.EmailVerified,
+			Metadata:      metadata,
+		})
+		if fe, ok := err.(services.FieldErrors); ok {
+			failed++
+			os.Stderr.WriteString(fmt.Sprintf("skipped %s (%s): %v\n", record.ID, record.Username, fe))
+			return nil
+		} else if err != nil {
+			return err
+		}
+		imported++
+		return nil
+	})
+	if err != nil {
+		exit(err)
+	}
+	fmt.Println(fmt.Sprintf("Imported %d accounts. Skipped %d.", imported, failed))
+}
+
+func exportAccounts(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "jsonl", "output format: jsonl or csv")
+	includeOauth := flags.Bool("include-oauth", false, "include linked OAuth accounts (without access tokens)")
+	includeMetadata := flags.Bool("include-metadata", false, "include account metadata")
+	includeHashes := flags.Bool("include-hashes", false, "include password hashes")
+	flags.Parse(args)
+
+	cfg := config.ReadEnv()
+	db, _, err := connect(cfg)
+	if err != nil {
+		exit(err)
+	}
+	accountStore, err := data.NewAccountStore(db)
+	if err != nil {
+		exit(err)
+	}
+	annotationStore, err := data.NewAnnotationStore(db)
+	if err != nil {
+		exit(err)
+	}
+	if cfg.FieldKeyring != nil {
+		annotationStore = data.NewEncryptedAnnotationStore(annotationStore, cfg.FieldKeyring)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	err = services.AccountExporter(
+		func(fn func(*models.Account) error) error { return data.EachAccount(db, fn) },
+		accountStore,
+		annotationStore,
+		out,
+		services.ExportOptions{
+			Format:          *format,
+			IncludeOauth:    *includeOauth,
+			IncludeMetadata: *includeMetadata,
+			IncludeHashes:   *includeHashes,
+		},
+	)
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		exit(err)
+	}
+}
+
+func reencrypt() {
+	cfg := config.ReadEnv()
+	if cfg.FieldKeyring == nil {
+		exit(fmt.Errorf("FIELD_ENCRYPTION_KEYS is not configured"))
+	}
+	db, _, err := connect(cfg)
+	if err != nil {
+		exit(err)
+	}
+	annotationStore, err := data.NewAnnotationStore(db)
+	if err != nil {
+		exit(err)
+	}
+	store := data.NewEncryptedAnnotationStore(annotationStore, cfg.FieldKeyring)
+
+	accounts, values := 0, 0
+	err = data.EachAccount(db, func(account *models.Account) error {
+		count, err := store.Reencrypt(account.ID)
+		if err != nil {
+			return errors.Wrapf(err, "account %d", account.ID)
+		}
+		if count > 0 {
+			accounts++
+			values += count
+		}
+		return nil
+	})
+	if err != nil {
+		exit(err)
+	}
+	fmt.Println(fmt.Sprintf("Re-encrypted %d values for %d accounts with key version %d.", values, accounts, cfg.FieldKeyring.Current()))
+}
+
+func usernameCollisions() {
+	cfg := config.ReadEnv()
+	db, _, err := connect(cfg)
+	if err != nil {
+		exit(err)
+	}
+
+	collisions, err := data.FindUsernameCollisions(db)
+	if err != nil {
+		exit(err)
+	}
+	if len(collisions) == 0 {
+		fmt.Println("No usernames differ only by case.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USERNAME\tID\tSTORED AS\tARCHIVED")
+	for _, collision := range collisions {
+		for _, account := range collision.Accounts {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%v\n", collision.Username, account.ID, account.Username, account.Archived())
+		}
+	}
+	w.Flush()
+	fmt.Println(fmt.Sprintf("\n%d usernames differ only by case. Rename or archive all but one account for each, then run migrations again.", len(collisions)))
+	os.Exit(1)
+}
+
+func seed() {
+	cfg := config.ReadEnv()
+	db, _, err := connect(cfg)
+	if err != nil {
+		exit(err)
+	}
+	accountStore, err := data.NewAccountStore(db)
+	if err != nil {
+		exit(err)
+	}
+
+	accounts, err := services.AccountSeeder(accountStore, cfg)
+	if err != nil {
+		exit(err)
+	}
+	printSeeds(accounts)
+}
+
+func printSeeds(accounts []*models.Account) {
+	fmt.Println(fmt.Sprintf("Seeded accounts (password: %q):", services.SeedPassword))
+	for _, account := range accounts {
+		fmt.Println(fmt.Sprintf("  %d: %s", account.ID, account.Username))
+	}
+}
+
+func rotateKeys() {
+	cfg := config.ReadEnv()
+	if cfg.IdentitySigningKey != nil {
+		exit(fmt.Errorf("RSA_PRIVATE_KEY or PKCS11_MODULE is configured, so keys are not rotated automatically"))
+	}
+
+	db, client, err := connect(cfg)
+	if err != nil {
+		exit(err)
+	}
+	blobStore, err := data.NewBlobStore(cfg.AccessTokenTTL, client, db, jobs.NewScheduler(nil, cfg.ErrorReporter))
+	if err != nil {
+		exit(err)
+	}
+
+	rotater := data.NewKeyStoreRotater(data.NewEncryptedBlobStore(blobStore, cfg.DBEncryptionKey), cfg.AccessTokenTTL)
+	key, at, err := rotater.GenerateNext()
+	if err != nil {
+		exit(err)
+	}
+	keyID, err := compat.KeyID(key.Public())
+	if err != nil {
+		exit(err)
+	}
+	fmt.Println(fmt.Sprintf("Key %s will be used for signing from %s.", keyID, at))
+}
+
+func genSecret() {
+	secret, err := generateSecret()
+	if err != nil {
+		exit(err)
+	}
+	fmt.Println(secret)
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, 64)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// keys is the output of keygen
+type keys struct {
+	SecretKeyBase string          `json:"secret_key_base"`
+	RSAPrivateKey string          `json:"rsa_private_key"`
+	PublicJWK     jose.JSONWebKey `json:"public_jwk"`
+}
+
+func keygen(args []string) {
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	format := flags.String("format", "env", "output format: env or json")
+	bits := flags.Int("bits", 2048, "size of the RSA key")
+	vaultPath := flags.String("vault", "", "also write the secrets to this Vault KV v2 path, using VAULT_ADDR and VAULT_TOKEN")
+	flags.Parse(args)
+
+	secret, err := generateSecret()
+	if err != nil {
+		exit(err)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, *bits)
+	if err != nil {
+		exit(err)
+	}
+	keyID, err := compat.KeyID(key.Public())
+	if err != nil {
+		exit(err)
+	}
+	generated := keys{
+		SecretKeyBase: secret,
+		RSAPrivateKey: string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key),
+		})),
+		PublicJWK: jose.JSONWebKey{
+			Key:       key.Public(),
+			Use:       "sig",
+			Algorithm: "RS256",
+			KeyID:     keyID,
+		},
+	}
+
+	if *vaultPath != "" {
+		err = writeVault(*vaultPath, map[string]string{
+			"SECRET_KEY_BASE": generated.SecretKeyBase,
+			"RSA_PRIVATE_KEY": generated.RSAPrivateKey,
+		})
+		if err != nil {
+			exit(err)
+		}
+	}
+
+	switch *format {
+	case "json":
+		out, err := json.MarshalIndent(generated, "", "  ")
+		if err != nil {
+			exit(err)
+		}
+		fmt.Println(string(out))
+	case "env":
+		// RSA_PRIVATE_KEY accepts literal \n sequences, so that it fits on one line
+		fmt.Println(fmt.Sprintf("SECRET_KEY_BASE=%s", generated.SecretKeyBase))
+		fmt.Println(fmt.Sprintf("RSA_PRIVATE_KEY=%s", strings.Replace(generated.RSAPrivateKey, "\n", `\n`, -1)))
+	default:
+		exit(fmt.Errorf("unknown format: %s", *format))
+	}
+}
+
+// writeVault stores secrets in a HashiCorp Vault KV v2 engine, e.g. at "secret/data/authn".
+func writeVault(path string, secrets map[string]string) error {
+	addr, ok := os.LookupEnv("VAULT_ADDR")
+	if !ok {
+		return fmt.Errorf("VAULT_ADDR is required to write to Vault")
+	}
+	body, err := json.Marshal(map[string]interface{}{"data": secrets})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimRight(addr, "/")+"/v1/"+strings.TrimLeft(path, "/"), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "Vault")
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("Vault responded with %s", res.Status)
+	}
+	return nil
+}
+
+func checkConfig() {
+	cfg, err := config.Check()
+	if err != nil {
+		exit(errors.Wrap(err, "configuration"))
+	}
+	fmt.Println("Configuration: ok")
+
+	db, client, err := connect(cfg)
+	if err != nil {
+		exit(err)
+	}
+	if err = db.Ping(); err != nil {
+		exit(errors.Wrap(err, "database"))
+	}
+	fmt.Println("Database: ok")
+
+	if client != nil {
+		if err = client.Ping().Err(); err != nil {
+			exit(errors.Wrap(err, "redis"))
+		}
+		fmt.Println("Redis: ok")
+	}
+}
+
+// printDiagnosis prints a report from server.Diagnose as an aligned table.
+func printDiagnosis(report []server.Diagnosis) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDETAIL")
+	for _, d := range report {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.Check, d.Status, d.Detail)
+	}
+	w.Flush()
+}
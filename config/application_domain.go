@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keratin/authn-server/lib/route"
+)
+
+// ApplicationDomain is an APP_DOMAINS entry with optional settings that override the global
+// configuration for sessions on that domain. Zero values inherit the global configuration.
+type ApplicationDomain struct {
+	route.Domain
+	AccessTokenTTL time.Duration
+	SameSite       http.SameSite
+
+	// OIDC logout URIs for the application. See
+	// https://openid.net/specs/openid-connect-frontchannel-1_0.html and
+	// https://openid.net/specs/openid-connect-backchannel-1_0.html
+	FrontchannelLogoutURI *url.URL
+	BackchannelLogoutURI  *url.URL
+}
+
+// ParseApplicationDomain parses an APP_DOMAINS entry: a domain followed by optional settings, each
+// prefixed with a semicolon. For example: app.example.com;access_token_ttl=300;same_site=strict
+//
+// A logout URI may not contain a semicolon or a comma, and may not be set for a wildcard domain,
+// because the logout would not know which subdomain's sessions it is for.
+func ParseApplicationDomain(entry string) (ApplicationDomain, error) {
+	pieces := strings.Split(entry, ";")
+	app := ApplicationDomain{Domain: route.ParseDomain(pieces[0])}
+	for _, setting := range pieces[1:] {
+		kv := strings.SplitN(strings.TrimSpace(setting), "=", 2)
+		if len(kv) != 2 {
+			return app, fmt.Errorf("%s: expected key=value, got %s", app.Hostname, setting)
+		}
+
+		switch kv[0] {
+		case "access_token_ttl":
+			ttl, err := strconv.Atoi(kv[1])
+			if err != nil || ttl <= 0 {
+				return app, fmt.Errorf("%s: access_token_ttl must be a positive number of seconds", app.Hostname)
+			}
+			app.AccessTokenTTL = time.Duration(ttl) * time.Second
+		case "same_site":
+			switch strings.ToLower(kv[1]) {
+			case "strict":
+				app.SameSite = http.SameSiteStrictMode
+			case "lax":
+				app.SameSite = http.SameSiteLaxMode
+			case "none":
+				app.SameSite = http.SameSiteNoneMode
+			default:
+				return app, fmt.Errorf("%s: same_site must be strict, lax, or none", app.Hostname)
+			}
+		case "frontchannel_logout_uri", "backchannel_logout_uri":
+			if app.IsWildcard() {
+				return app, fmt.Errorf("%s: %s may not be set for a wildcard domain", app.Hostname, kv[0])
+			}
+			uri, err := url.Parse(kv[1])
+			if err != nil || (uri.Scheme != "http" && uri.Scheme != "https") || uri.Host == "" {
+				return app, fmt.Errorf("%s: %s must be an absolute URL", app.Hostname, kv[0])
+			}
+			if kv[0] == "frontchannel_logout_uri" {
+				app.FrontchannelLogoutURI = uri
+			} else {
+				app.BackchannelLogoutURI = uri
+			}
+		default:
+			return app, fmt.Errorf("%s: unknown setting %s", app.Hostname, kv[0])
+		}
+	}
+	return app, nil
+}
+
+// Application finds the APP_DOMAINS entry for an audience, which is the String of a Domain that
+// was found by route.FindDomain. It returns an ApplicationDomain with no overrides when there is
+// none.
+func (c *Config) Application(audience string) ApplicationDomain {
+	for _, app := range c.Applications {
+		if app.MatchesAudience(audience) {
+			return app
+		}
+	}
+	return ApplicationDomain{}
+}
+
+// AccessTokenTTLFor returns the lifetime of access tokens for an audience.
+func (c *Config) AccessTokenTTLFor(audience string) time.Duration {
+	if ttl := c.Application(audience).AccessTokenTTL; ttl > 0 {
+		return ttl
+	}
+	return c.AccessTokenTTL
+}
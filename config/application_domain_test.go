@@ -0,0 +1,78 @@
+package config_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseApplicationDomain(t *testing.T) {
+	testCases := []struct {
+		entry string
+		app   config.ApplicationDomain
+	}{
+		{"example.com", config.ApplicationDomain{Domain: route.Domain{Hostname: "example.com"}}},
+		{"app.example.com;access_token_ttl=300", config.ApplicationDomain{
+			Domain:         route.Domain{Hostname: "app.example.com"},
+			AccessTokenTTL: 5 * time.Minute,
+		}},
+		{"https://*.example.com; same_site=Strict ;access_token_ttl=60", config.ApplicationDomain{
+			Domain:         route.Domain{Scheme: "https", Hostname: "*.example.com"},
+			AccessTokenTTL: time.Minute,
+			SameSite:       http.SameSiteStrictMode,
+		}},
+		{"legacy.example.com:3000;same_site=none", config.ApplicationDomain{
+			Domain:   route.Domain{Hostname: "legacy.example.com", Port: "3000"},
+			SameSite: http.SameSiteNoneMode,
+		}},
+		{"app.example.com;frontchannel_logout_uri=https://app.example.com/logout?from=authn;backchannel_logout_uri=https://api.example.com/logout", config.ApplicationDomain{
+			Domain:                route.Domain{Hostname: "app.example.com"},
+			FrontchannelLogoutURI: &url.URL{Scheme: "https", Host: "app.example.com", Path: "/logout", RawQuery: "from=authn"},
+			BackchannelLogoutURI:  &url.URL{Scheme: "https", Host: "api.example.com", Path: "/logout"},
+		}},
+	}
+	for _, tc := range testCases {
+		app, err := config.ParseApplicationDomain(tc.entry)
+		require.NoError(t, err, tc.entry)
+		assert.Equal(t, tc.app, app, tc.entry)
+	}
+
+	for _, entry := range []string{
+		"example.com;access_token_ttl",
+		"example.com;access_token_ttl=soon",
+		"example.com;access_token_ttl=-1",
+		"example.com;same_site=sometimes",
+		"example.com;color=blue",
+		"example.com;backchannel_logout_uri=/logout",
+		"example.com;frontchannel_logout_uri=javascript:alert(1)",
+		"*.example.com;backchannel_logout_uri=https://api.example.com/logout",
+	} {
+		_, err := config.ParseApplicationDomain(entry)
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestAccessTokenTTLFor(t *testing.T) {
+	spa, err := config.ParseApplicationDomain("app.example.com;access_token_ttl=300")
+	require.NoError(t, err)
+	previews, err := config.ParseApplicationDomain("*.preview.example.com:8080;access_token_ttl=60")
+	require.NoError(t, err)
+	legacy, err := config.ParseApplicationDomain("legacy.example.com")
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		AccessTokenTTL: time.Hour,
+		Applications:   []config.ApplicationDomain{spa, previews, legacy},
+	}
+	assert.Equal(t, 5*time.Minute, cfg.AccessTokenTTLFor("app.example.com"))
+	assert.Equal(t, time.Minute, cfg.AccessTokenTTLFor("pr-1.preview.example.com:8080"))
+	assert.Equal(t, time.Hour, cfg.AccessTokenTTLFor("pr-1.preview.example.com"))
+	assert.Equal(t, time.Hour, cfg.AccessTokenTTLFor("legacy.example.com"))
+	assert.Equal(t, time.Hour, cfg.AccessTokenTTLFor("unknown.example.com"))
+}
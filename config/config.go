@@ -1,78 +1,220 @@
 package config
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"net/url"
 	"os"
+	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/airbrake/gobrake"
 	raven "github.com/getsentry/raven-go"
-	// a .env file is extremely useful during development
-	_ "github.com/joho/godotenv/autoload"
+	"github.com/keratin/authn-server/lib"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/fieldcrypt"
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/keratin/authn-server/lib/hashes"
+	"github.com/keratin/authn-server/lib/hsm"
+	"github.com/keratin/authn-server/lib/mfa"
 	"github.com/keratin/authn-server/lib/oauth"
 	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/lib/s3"
 	"github.com/keratin/authn-server/ops"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/crypto/pbkdf2"
 )
 
 type Config struct {
-	AppPasswordResetURL      *url.URL
-	AppPasswordChangedURL    *url.URL
-	ApplicationDomains       []route.Domain
-	BcryptCost               int
-	UsernameIsEmail          bool
-	UsernameMinLength        int
-	UsernameDomains          []string
-	PasswordMinComplexity    int
-	RefreshTokenTTL          time.Duration
-	RedisURL                 *url.URL
-	DatabaseURL              *url.URL
-	SessionCookieName        string
-	OAuthCookieName          string
-	SessionSigningKey        []byte
-	ResetSigningKey          []byte
-	DBEncryptionKey          []byte
-	OAuthSigningKey          []byte
-	ResetTokenTTL            time.Duration
-	IdentitySigningKey       *rsa.PrivateKey
-	AuthNURL                 *url.URL
-	ForceSSL                 bool
-	MountedPath              string
-	AccessTokenTTL           time.Duration
-	AuthUsername             string
-	AuthPassword             string
-	EnableSignup             bool
-	StatisticsTimeZone       *time.Location
-	DailyActivesRetention    int
-	WeeklyActivesRetention   int
-	ErrorReporter            ops.ErrorReporter
-	ServerPort               int
-	PublicPort               int
-	Proxied                  bool
-	GoogleOauthCredentials   *oauth.Credentials
-	GitHubOauthCredentials   *oauth.Credentials
-	FacebookOauthCredentials *oauth.Credentials
+	AppPasswordResetURL       *url.URL
+	AppPasswordChangedURL     *url.URL
+	AppUsernameChangeURL      *url.URL
+	AppAccountProvisioningURL *url.URL
+	AppSignupVetoURL          *url.URL
+	SignupVetoTimeout         time.Duration
+	SignupVetoFailOpen        bool
+	SignupQuotaPerIP          int
+	SignupQuotaPerDomain      int
+	SignupHoneypotField       string
+	SignupMinFillTime         time.Duration
+	SignupBotAction           string
+	LoginThrottleAttempts     int
+	LoginThrottleDuration     time.Duration
+	LoginThrottleMaxDuration  time.Duration
+	PreventEnumeration        bool
+	ApplicationDomains        []route.Domain
+	Applications              []ApplicationDomain
+	BcryptCost                int
+	PasswordHashAlgorithm     string
+	DummyPasswordHash         []byte
+	DevisePepper              string
+	BcryptLimiter             *lib.ConcurrencyLimiter
+	UsernameIsEmail           bool
+	UsernameMinLength         int
+	UsernameDomains           []string
+	PasswordMinComplexity     int
+	RefreshTokenTTL           time.Duration
+	SessionBinding            bool
+	PasswordChangeKeepSession bool
+	SessionScopes             []string
+	MaxSessionsPerAccount     int
+	RejectExcessSessions      bool
+	RedisURL                  *url.URL
+	RedisKeyPrefix            string
+	SessionReplicaURLs        []*url.URL
+	RedisStreams              bool
+	InstanceName              string
+	DatabaseURL               *url.URL
+	AccountCacheTTL           time.Duration
+	DatabaseBreaker           *lib.CircuitBreaker
+	RedisBreaker              *lib.CircuitBreaker
+	LoadShedder               *lib.LoadShedder
+	RequestBudget             time.Duration
+	SessionCookieName         string
+	AccessTokenCookieName     string
+	OAuthCookieName           string
+	SessionSigningKey         []byte
+	ResetSigningKey           []byte
+	DBEncryptionKey           []byte
+	OAuthSigningKey           []byte
+	ResetTokenTTL             time.Duration
+	UsernameChangeSigningKey  []byte
+	UsernameChangeTokenTTL    time.Duration
+	UsernameRevertTTL         time.Duration
+	UsernameChangeCooldown    time.Duration
+	TakeoutSigningKey         []byte
+	TakeoutTTL                time.Duration
+	ActionLinkSigningKey      []byte
+	ActionLinkTTL             time.Duration
+	IdentitySigningKey        crypto.Signer
+	IdentityEncryptionKeys    map[string]*rsa.PublicKey
+	FieldKeyring              *fieldcrypt.Keyring
+	AuthNURL                  *url.URL
+	ForceSSL                  bool
+	MountedPath               string
+	AccessTokenTTL            time.Duration
+	OpaqueAccessTokens        bool
+	AccessTokenClaims         []string
+	AuthUsername              string
+	AuthPassword              string
+	EnableSignup              bool
+	DisablePasswordLogin      bool
+	DisableOAuth              bool
+	EnableAccountDeletion     bool
+	TermsVersion              string
+	ProfileFields             []ProfileField
+	AccountDeletionGrace      time.Duration
+	AccountRestoreWindow      time.Duration
+	StatisticsTimeZone        *time.Location
+	DailyActivesRetention     int
+	WeeklyActivesRetention    int
+	ErrorReporter             ops.ErrorReporter
+	Alerts                    *ops.Alerts
+	EventPublishers           []events.Publisher
+	ServerPort                int
+	PublicPort                int
+	ServerReadHeaderTimeout   time.Duration
+	ServerReadTimeout         time.Duration
+	ServerWriteTimeout        time.Duration
+	ServerIdleTimeout         time.Duration
+	ServerKeepAlive           bool
+	HTTP2                     bool
+	HTTP2MaxConcurrentStreams int
+	RequestTimeout            time.Duration
+	MaxRequestBodySize        int64
+	Proxied                   bool
+	GeoIPLocator              geoip.Locator
+	BlockedCountries          []string
+	TorExitNodes              geoip.IPList
+	AppLoginChallengeURL      *url.URL
+	LoginChallengeRules       []string
+	LoginChallengeSigningKey  []byte
+	LoginChallengeTokenTTL    time.Duration
+	PushMFAProvider           mfa.PushProvider
+	PushMFASigningKey         []byte
+	AuditSigningKey           []byte
+	PushMFATimeout            time.Duration
+	StepUpScopes              []string
+	StepUpMaxAge              time.Duration
+	DebugEndpoints            bool
+	MaintenanceMode           string
+	MaintenanceRetryAfter     time.Duration
+	LogLevel                  logrus.Level
+	LogSampling               []LogSampleRule
+	LogRedactedParams         []string
+	AdminDashboard            bool
+	AuditLog                  bool
+	AuditLogRetention         time.Duration
+	AuditLogArchive           *s3.Bucket
+	EnableOutbox              bool
+	OutboxRetention           time.Duration
+	SessionEvents             bool
+	EnableGraphQL             bool
+	DevSeed                   bool
+	GoogleOauthCredentials    *oauth.Credentials
+	GitHubOauthCredentials    *oauth.Credentials
+	FacebookOauthCredentials  *oauth.Credentials
+	MicrosoftOauthCredentials *oauth.Credentials
+	MicrosoftOauthTenant      string
+	DiscordOauthCredentials   *oauth.Credentials
+	TwitterOauthCredentials   *oauth.Credentials
+	LinkedInOauthCredentials  *oauth.Credentials
+	OauthProviderSettings     map[string]*oauth.GenericSettings
+	OauthAttributeMappings    map[string]map[string]string
+	Settings                  []Setting
 }
 
+// AccessTokenClaimNames are the optional claims that ACCESS_TOKEN_CLAIMS may include.
+var AccessTokenClaimNames = []string{"auth_time", "amr", "mfa_time", "sid", "username", "password_changed_at"}
+
+// DefaultAccessTokenClaims are included when ACCESS_TOKEN_CLAIMS is not specified.
+var DefaultAccessTokenClaims = []string{"auth_time"}
+
 var configurers = []configurer{
 	// The APP_DOMAINS are a list of domains that may refer traffic and be valid JWT audiences. If
 	// the domain includes a port, it must match referred traffic. If the domain does not include a
 	// port, it will match any referred traffic port. Ports 80 and 443 are matched against schemes.
+	// If the domain includes a scheme, it must also match.
+	//
+	// A domain like *.example.com matches any subdomain, and the audience will be the subdomain that
+	// referred the traffic. The first domain is used as a fallback redirect, so it may not be a
+	// wildcard.
+	//
+	// Each domain may be followed by settings that override the global configuration for sessions
+	// on that domain, e.g. app.example.com;access_token_ttl=300;same_site=strict. See
+	// ParseApplicationDomain.
 	func(c *Config) error {
 		val, err := requireEnv("APP_DOMAINS")
 		if err == nil {
 			c.ApplicationDomains = make([]route.Domain, 0)
-			for _, domain := range strings.Split(val, ",") {
-				c.ApplicationDomains = append(c.ApplicationDomains, route.ParseDomain(domain))
+			for _, entry := range strings.Split(val, ",") {
+				if strings.TrimSpace(entry) == "" {
+					continue
+				}
+				app, err := ParseApplicationDomain(entry)
+				if err != nil {
+					return fmt.Errorf("APP_DOMAINS: %v", err)
+				}
+				c.ApplicationDomains = append(c.ApplicationDomains, app.Domain)
+				c.Applications = append(c.Applications, app)
+			}
+			if len(c.ApplicationDomains) == 0 {
+				return ErrMissingEnvVar("APP_DOMAINS")
+			}
+			if c.ApplicationDomains[0].IsWildcard() {
+				return fmt.Errorf("APP_DOMAINS: the first domain may not be a wildcard")
 			}
 		}
 		return err
@@ -112,13 +254,28 @@ var configurers = []configurer{
 	// But it does help in case the key base has less entropy than might be ideal,
 	// and it does protect from escalating an attack on one derived key into an
 	// attack on all of the derived keys.
+	//
+	// DERIVED_KEY_CACHE is an optional path where the derived keys are cached in an encrypted file,
+	// for environments where the derivations make boot slow. The cache is replaced when the base
+	// changes.
 	func(c *Config) error {
 		val, err := requireEnv("SECRET_KEY_BASE")
 		if err == nil {
-			c.SessionSigningKey = derive([]byte(val), "session-key-salt")
-			c.ResetSigningKey = derive([]byte(val), "password-reset-token-key-salt")
-			c.DBEncryptionKey = derive([]byte(val), "db-encryption-key-salt")[:32]
-			c.OAuthSigningKey = derive([]byte(val), "oauth-key-salt")
+			keys := newKeyCache([]byte(val), getEnv("DERIVED_KEY_CACHE"))
+			c.SessionSigningKey = keys.derive("session-key-salt")
+			c.ResetSigningKey = keys.derive("password-reset-token-key-salt")
+			c.DBEncryptionKey = keys.derive("db-encryption-key-salt")[:32]
+			c.OAuthSigningKey = keys.derive("oauth-key-salt")
+			c.UsernameChangeSigningKey = keys.derive("username-change-token-key-salt")
+			c.LoginChallengeSigningKey = keys.derive("login-challenge-token-key-salt")
+			c.PushMFASigningKey = keys.derive("push-mfa-token-key-salt")
+			c.AuditSigningKey = keys.derive("audit-log-key-salt")
+			c.TakeoutSigningKey = keys.derive("takeout-token-key-salt")
+			c.ActionLinkSigningKey = keys.derive("action-link-token-key-salt")
+			err = keys.save()
+			if err != nil {
+				return fmt.Errorf("DERIVED_KEY_CACHE: %v", err)
+			}
 		}
 		return err
 	},
@@ -143,6 +300,61 @@ var configurers = []configurer{
 		return err
 	},
 
+	// BCRYPT_MAX_CONCURRENCY limits how many bcrypt operations (hashing and verifying passwords) may
+	// run at the same time. A burst of logins will otherwise claim every CPU and starve cheap but
+	// important work like token refreshes. The default is the number of CPUs. A value of 0 removes
+	// the limit.
+	//
+	// BCRYPT_QUEUE_TIMEOUT is how many seconds a request may wait for a free slot before giving up
+	// with a 503. The default is 5.
+	func(c *Config) error {
+		max, err := lookupInt("BCRYPT_MAX_CONCURRENCY", runtime.NumCPU())
+		if err != nil {
+			return err
+		}
+		timeout, err := lookupInt("BCRYPT_QUEUE_TIMEOUT", 5)
+		if err != nil {
+			return err
+		}
+		c.BcryptLimiter = lib.NewConcurrencyLimiter(max, time.Duration(timeout)*time.Second)
+		return nil
+	},
+
+	// PASSWORD_HASH_ALGORITHM chooses how new passwords are hashed: bcrypt (the default) or
+	// argon2id. Accounts with a hash of any other scheme, including accounts that were imported from
+	// other systems, are upgraded to this algorithm when they next log in.
+	func(c *Config) error {
+		val := lookupString("PASSWORD_HASH_ALGORITHM", hashes.Bcrypt)
+		if val != hashes.Bcrypt && val != hashes.Argon2id {
+			return fmt.Errorf("PASSWORD_HASH_ALGORITHM: unsupported algorithm %s", val)
+		}
+		c.PasswordHashAlgorithm = val
+		return nil
+	},
+
+	// DummyPasswordHash is a hash of a random password with the configured algorithm and cost. It
+	// is verified in place of a real hash when a username is unknown, so that the response time
+	// does not reveal whether the username exists.
+	func(c *Config) error {
+		password, err := lib.GenerateToken()
+		if err != nil {
+			return err
+		}
+		if c.PasswordHashAlgorithm == hashes.Argon2id {
+			c.DummyPasswordHash, err = hashes.NewArgon2id(password)
+		} else {
+			c.DummyPasswordHash, err = bcrypt.GenerateFromPassword(password, c.BcryptCost)
+		}
+		return err
+	},
+
+	// DEVISE_PEPPER is the `config.pepper` of a Devise application whose accounts were imported. It
+	// is needed to verify their passwords until each user logs in and is upgraded to a normal hash.
+	func(c *Config) error {
+		c.DevisePepper = getEnv("DEVISE_PEPPER")
+		return nil
+	},
+
 	// PASSWORD_POLICY_SCORE is a minimum complexity score that a password must get
 	// from the zxcvbn algorithm, where:
 	//
@@ -188,6 +400,122 @@ var configurers = []configurer{
 		return err
 	},
 
+	// REDIS_KEY_PREFIX is prepended to every key that AuthN reads or writes, so that multiple
+	// instances or other applications can safely share a Redis database.
+	//
+	// Example: authn:
+	func(c *Config) error {
+		if val, ok := lookupEnv("REDIS_KEY_PREFIX"); ok {
+			c.RedisKeyPrefix = val
+		}
+		return nil
+	},
+
+	// SESSION_REPLICA_URLS is a comma-separated list of Redis servers in other regions. Every refresh
+	// token that is created, refreshed, or revoked here is copied to them in the background, so that
+	// a session may be refreshed in any region after failover. It requires REDIS_URL.
+	//
+	// Example: redis://redis.eu-west-1.example.com:6379/11,redis://redis.ap-south-1.example.com:6379/11
+	func(c *Config) error {
+		val, ok := lookupEnv("SESSION_REPLICA_URLS")
+		if !ok {
+			return nil
+		}
+		if c.RedisURL == nil {
+			return fmt.Errorf("SESSION_REPLICA_URLS requires REDIS_URL")
+		}
+		for _, str := range strings.Split(val, ",") {
+			if str = strings.TrimSpace(str); str == "" {
+				continue
+			}
+			replica, err := url.Parse(str)
+			if err != nil {
+				return fmt.Errorf("SESSION_REPLICA_URLS: %v", err)
+			}
+			c.SessionReplicaURLs = append(c.SessionReplicaURLs, replica)
+		}
+		return nil
+	},
+
+	// REDIS_STREAMS is a flag that shares notifications between AuthN servers through a Redis
+	// stream: account changes for ACCOUNT_CACHE_TTL, events for SESSION_EVENTS, changes of mode, and
+	// new signing keys. Each server reads the stream as INSTANCE_NAME, and catches up on what it
+	// missed after a restart. It requires REDIS_URL.
+	//
+	// INSTANCE_NAME identifies this server, and is required with REDIS_STREAMS. It must be unique,
+	// and the same after a restart. A hostname would not do, since containers get a new one when
+	// they restart: the server would miss what was sent meanwhile, and leave a stale group behind.
+	func(c *Config) error {
+		val, err := lookupBool("REDIS_STREAMS", false)
+		if err != nil || !val {
+			return err
+		}
+		if c.RedisURL == nil {
+			return fmt.Errorf("REDIS_STREAMS requires REDIS_URL")
+		}
+		c.RedisStreams = val
+
+		c.InstanceName = lookupString("INSTANCE_NAME", "")
+		if c.InstanceName == "" {
+			return fmt.Errorf("REDIS_STREAMS requires INSTANCE_NAME")
+		}
+		return nil
+	},
+
+	// CIRCUIT_BREAKER_THRESHOLD is how many consecutive failures from the database or Redis will
+	// open a circuit breaker. While open, requests that need that dependency fail fast with a 503
+	// instead of waiting on connection timeouts. The default is 5. A value of 0 disables the breakers.
+	//
+	// CIRCUIT_BREAKER_COOLDOWN is how many seconds to wait before trying an open dependency again.
+	// Each failed attempt doubles the wait, up to one minute. The default is 1.
+	func(c *Config) error {
+		threshold, err := lookupInt("CIRCUIT_BREAKER_THRESHOLD", 5)
+		if err != nil {
+			return err
+		}
+		cooldown, err := lookupInt("CIRCUIT_BREAKER_COOLDOWN", 1)
+		if err != nil {
+			return err
+		}
+		c.DatabaseBreaker = lib.NewCircuitBreaker(threshold, time.Duration(cooldown)*time.Second, time.Minute)
+		c.RedisBreaker = lib.NewCircuitBreaker(threshold, time.Duration(cooldown)*time.Second, time.Minute)
+		return nil
+	},
+
+	// LOAD_SHED_DB_LATENCY and LOAD_SHED_REDIS_LATENCY are how many milliseconds the database or
+	// Redis may take to respond to a health probe. While either is slower, or failing, non-critical
+	// endpoints like signup and stats refuse requests with a 503 so that logins and refreshes keep
+	// working. The default of 0 never sheds load.
+	func(c *Config) error {
+		dbLatency, err := lookupInt("LOAD_SHED_DB_LATENCY", 0)
+		if err != nil {
+			return err
+		}
+		redisLatency, err := lookupInt("LOAD_SHED_REDIS_LATENCY", 0)
+		if err != nil {
+			return err
+		}
+		if redisLatency > 0 && c.RedisURL == nil {
+			return fmt.Errorf("LOAD_SHED_REDIS_LATENCY requires REDIS_URL")
+		}
+		c.LoadShedder = lib.NewLoadShedder(map[string]time.Duration{
+			"db":    time.Duration(dbLatency) * time.Millisecond,
+			"redis": time.Duration(redisLatency) * time.Millisecond,
+		})
+		return nil
+	},
+
+	// ACCOUNT_CACHE_TTL enables a short-lived in-memory cache of account lookups, measured
+	// in seconds. This reduces database load on high-traffic deployments, but changes made
+	// by other AuthN servers may not be visible until the TTL passes.
+	func(c *Config) error {
+		ttl, err := lookupInt("ACCOUNT_CACHE_TTL", 0)
+		if err == nil {
+			c.AccountCacheTTL = time.Duration(ttl) * time.Second
+		}
+		return err
+	},
+
 	// USERNAME_IS_EMAIL is a truthy string ("t", "true", "yes") that enables the
 	// email validations for username fields. By default, usernames are just
 	// strings.
@@ -209,220 +537,1032 @@ var configurers = []configurer{
 		return err
 	},
 
-	// EMAIL_USERNAME_DOMAINS is a comma-delimited list of domains that an email
-	// username must contain for signup. If missing, then any domain is a valid
-	// signup.
-	//
-	// This setting only has effect if USERNAME_IS_EMAIL has been set.
+	// DISABLE_SIGNUP may be set to a truthy value ("t", "true", "yes") to remove the
+	// public signup endpoints. It takes precedence over ENABLE_SIGNUP, for deployments
+	// that are invitation-only and must never expose signup.
 	func(c *Config) error {
-		if val, ok := os.LookupEnv("EMAIL_USERNAME_DOMAINS"); ok {
-			c.UsernameDomains = strings.Split(val, ",")
+		disableSignup, err := lookupBool("DISABLE_SIGNUP", false)
+		if err == nil && disableSignup {
+			c.EnableSignup = false
 		}
-		return nil
+		return err
 	},
 
-	// REFRESH_TOKEN_TTL determines how long a refresh token will live after its
-	// last touch. This is necessary to prevent years-long Redis bloat from
-	// inactive sessions, where users close the window rather than log out.
+	// DISABLE_PASSWORD_LOGIN may be set to a truthy value ("t", "true", "yes") to remove
+	// the endpoints that log in or reset with a password, for SSO-only deployments.
 	func(c *Config) error {
-		ttl, err := lookupInt("REFRESH_TOKEN_TTL", 86400*30)
+		disablePasswordLogin, err := lookupBool("DISABLE_PASSWORD_LOGIN", false)
 		if err == nil {
-			c.RefreshTokenTTL = time.Duration(ttl) * time.Second
+			c.DisablePasswordLogin = disablePasswordLogin
 		}
 		return err
 	},
 
-	// PASSWORD_RESET_TOKEN_TTL determines how long a password reset token (as JWT)
-	// will be valid from when it is generated. These tokens should not live much
-	// longer than it takes for an attentive user to act in a reasonably expedient
-	// manner. If a user loses control of a password reset token, they will lose
-	// control of their account.
+	// DISABLE_OAUTH may be set to a truthy value ("t", "true", "yes") to remove the OAuth
+	// endpoints even when providers are configured.
 	func(c *Config) error {
-		ttl, err := lookupInt("PASSWORD_RESET_TOKEN_TTL", 1800)
+		disableOAuth, err := lookupBool("DISABLE_OAUTH", false)
 		if err == nil {
-			c.ResetTokenTTL = time.Duration(ttl) * time.Second
+			c.DisableOAuth = disableOAuth
 		}
 		return err
 	},
 
-	// ACCESS_TOKEN_TTL determines how long an access token (as JWT) will remain
-	// valid. This is a hard limit, to limit the potential damage of an exposed
-	// access token.
-	//
-	// New access tokens can be generated using the refresh token for as long as
-	// the refresh token remains valid. This is an important mechanism because it
-	// allows the authentication server to revoke sessions (e.g. on logout) with
-	// confidence that any related access tokens will expire soon.
-	//
-	// Note that revoking a refresh token will not invalidate access tokens until
-	// this TTL passes, so shorter is better.
+	// ENABLE_ACCOUNT_DELETION may be set to a truthy value ("t", "true", "yes") to let
+	// users delete their own account.
 	func(c *Config) error {
-		ttl, err := lookupInt("ACCESS_TOKEN_TTL", 3600)
+		enabled, err := lookupBool("ENABLE_ACCOUNT_DELETION", false)
 		if err == nil {
-			c.AccessTokenTTL = time.Duration(ttl) * time.Second
+			c.EnableAccountDeletion = enabled
 		}
 		return err
 	},
 
-	// HTTP_AUTH_USERNAME and HTTP_AUTH_PASSWORD specify the basic auth credentials
-	// that must be provided to access private endpoints.
-	//
-	// This security pattern requires communication with AuthN to use SSL.
+	// ACCOUNT_DELETION_GRACE_PERIOD is how long AuthN waits before archiving an account
+	// that a user has deleted. Logging in during this time will cancel the deletion.
 	func(c *Config) error {
-		if val, ok := os.LookupEnv("HTTP_AUTH_USERNAME"); ok {
-			c.AuthUsername = val
-		} else {
-			i, err := rand.Int(rand.Reader, big.NewInt(99999999))
-			if err != nil {
-				return err
-			}
-			c.AuthUsername = i.String()
+		grace, err := lookupInt("ACCOUNT_DELETION_GRACE_PERIOD", 2592000)
+		if err == nil {
+			c.AccountDeletionGrace = time.Duration(grace) * time.Second
 		}
-		if val, ok := os.LookupEnv("HTTP_AUTH_PASSWORD"); ok {
-			c.AuthPassword = val
-		} else {
-			i, err := rand.Int(rand.Reader, big.NewInt(99999999))
+		return err
+	},
+
+	// ACCOUNT_RESTORE_WINDOW is how long after archiving an account that it may be restored, in
+	// case it was archived by accident. Usernames of archived accounts are kept (encrypted) for
+	// this long. The default of 0 keeps nothing.
+	func(c *Config) error {
+		window, err := lookupInt("ACCOUNT_RESTORE_WINDOW", 0)
+		if err == nil {
+			c.AccountRestoreWindow = time.Duration(window) * time.Second
+		}
+		return err
+	},
+
+	// TERMS_VERSION identifies the current terms of service. When set, users must accept
+	// this version at signup, and again at their next login whenever it changes.
+	func(c *Config) error {
+		if val, ok := lookupEnv("TERMS_VERSION"); ok {
+			c.TermsVersion = val
+		}
+		return nil
+	},
+
+	// PROFILE_FIELDS is a JSON object that defines additional fields for users to provide, like a
+	// name or birthdate. Required fields must be provided at signup, and the others may be
+	// collected later. Values are validated by AuthN and stored in the account's metadata. See
+	// ParseProfileFields.
+	func(c *Config) error {
+		if val, ok := lookupEnv("PROFILE_FIELDS"); ok {
+			fields, err := ParseProfileFields(val)
 			if err != nil {
-				return err
+				return fmt.Errorf("PROFILE_FIELDS: %v", err)
 			}
-			c.AuthPassword = i.String()
+			c.ProfileFields = fields
 		}
 		return nil
 	},
 
-	// APP_PASSWORD_CHANGED_URL is an endpoint that will be notified when an account
-	// has changed its password. This notification may be used to deliver an email
-	// confirmation.
+	// APP_SIGNUP_VETO_URL is an endpoint that will be consulted before an account is created by
+	// signup. It receives the candidate username and request metadata, and may reject the signup
+	// by responding with a 4xx status.
 	//
 	// For security, this URL should specify https and include a basic auth username
 	// and password.
 	func(c *Config) error {
-		val, err := lookupURL("APP_PASSWORD_CHANGED_URL")
+		val, err := lookupURL("APP_SIGNUP_VETO_URL")
 		if err == nil && val != nil {
-			c.AppPasswordChangedURL = val
+			c.AppSignupVetoURL = val
 		}
 		return err
 	},
 
-	// APP_PASSWORD_RESET_URL is an endpoint that will be notified when an account
-	// has requested a password reset. The endpoint is expected to deliver an email
-	// with the given password reset token, then respond with a 2xx HTTP status.
-	//
-	// For security, this URL should specify https and include a basic auth username
-	// and password.
+	// APP_SIGNUP_VETO_TIMEOUT is how long (in seconds) a signup will wait for the veto endpoint.
 	func(c *Config) error {
-		val, err := lookupURL("APP_PASSWORD_RESET_URL")
-		if err == nil && val != nil {
-			c.AppPasswordResetURL = val
+		timeout, err := lookupInt("APP_SIGNUP_VETO_TIMEOUT", 3)
+		if err == nil {
+			c.SignupVetoTimeout = time.Duration(timeout) * time.Second
 		}
 		return err
 	},
 
-	// RSA_PRIVATE_KEY is a RSA private key in PEM format. If provided as a single
-	// line string, any literal \n sequences will be converted to real linebreaks.
-	// When provided, it will be used for signing identity tokens, and the public
-	// key will be published for audiences to verify. When not provided, AuthN will
-	// generate and manage keys itself, using Redis for coordination and
-	// persistence.
+	// APP_SIGNUP_VETO_FAIL_OPEN allows signups to proceed when the veto endpoint fails or times
+	// out. By default, signups are refused until the endpoint recovers.
 	func(c *Config) error {
-		if str, ok := os.LookupEnv("RSA_PRIVATE_KEY"); ok {
-			str = strings.Replace(str, `\n`, "\n", -1)
-			block, _ := pem.Decode([]byte(str))
-			key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
-			if err != nil {
-				return err
-			}
-			c.IdentitySigningKey = key
+		val, err := lookupBool("APP_SIGNUP_VETO_FAIL_OPEN", false)
+		if err == nil {
+			c.SignupVetoFailOpen = val
 		}
-		return nil
+		return err
 	},
 
-	// TIME_ZONE is the IANA name of a location that should be used when calculating
-	// which day it is when tracking key stats. It defaults to UTC.
+	// SIGNUP_QUOTA_PER_IP limits how many accounts may be created from a single IP address per
+	// hour. SIGNUP_QUOTA_PER_DOMAIN limits how many accounts may be created with usernames from a
+	// single email domain per day. Quotas are tracked in Redis. A value of 0 (the default) disables
+	// the quota.
 	func(c *Config) error {
-		name, ok := os.LookupEnv("TIME_ZONE")
-		if !ok {
-			name = "UTC"
+		perIP, err := lookupInt("SIGNUP_QUOTA_PER_IP", 0)
+		if err != nil {
+			return err
 		}
-
-		tz, err := time.LoadLocation(name)
+		perDomain, err := lookupInt("SIGNUP_QUOTA_PER_DOMAIN", 0)
 		if err != nil {
 			return err
 		}
-		c.StatisticsTimeZone = tz
+		c.SignupQuotaPerIP = perIP
+		c.SignupQuotaPerDomain = perDomain
 		return nil
 	},
 
-	// DAILY_ACTIVES_RETENTION is how many daily records of the number of active accounts to keep.
-	// The default is 365 (~1 year).
+	// SIGNUP_HONEYPOT_FIELD names a form field that should be hidden from humans in your signup
+	// form. Bots tend to fill every field, so a signup with a value in this field is treated as a
+	// bot.
 	func(c *Config) error {
-		num, err := lookupInt("DAILY_ACTIVES_RETENTION", 365)
-		if err == nil {
-			c.DailyActivesRetention = num
+		if val, ok := lookupEnv("SIGNUP_HONEYPOT_FIELD"); ok {
+			c.SignupHoneypotField = val
 		}
-		return err
+		return nil
 	},
 
-	// WEEKLY_ACTIVES_RETENTION is how many weekly records of the number of active accounts to keep.
-	// The default is 104 (~2 years).
+	// SIGNUP_MIN_FILL_TIME is how many seconds a human needs to fill out your signup form. When
+	// configured, signups must include the unix time the form was rendered as `form_started_at`,
+	// and faster signups are treated as bots.
 	func(c *Config) error {
-		num, err := lookupInt("WEEKLY_ACTIVES_RETENTION", 104)
+		seconds, err := lookupInt("SIGNUP_MIN_FILL_TIME", 0)
 		if err == nil {
-			c.WeeklyActivesRetention = num
+			c.SignupMinFillTime = time.Duration(seconds) * time.Second
 		}
 		return err
 	},
 
-	// SENTRY_DSN is a configuration string for the Sentry error reporting backend. When provided,
-	// errors and panics will be reported asynchronously.
+	// SIGNUP_BOT_ACTION decides what happens to signups that look like bots. "reject" (the default)
+	// refuses the signup with the same error as a veto, so that bots learn nothing. "flag" allows
+	// the signup but tags the account for review.
 	func(c *Config) error {
-		if val, ok := os.LookupEnv("SENTRY_DSN"); ok {
-			client, err := raven.New(val)
-			if err != nil {
-				return err
-			}
-			c.ErrorReporter = &ops.SentryReporter{Client: client}
+		val := lookupString("SIGNUP_BOT_ACTION", "reject")
+		if val != "reject" && val != "flag" {
+			return fmt.Errorf("SIGNUP_BOT_ACTION must be reject or flag: %v", val)
 		}
+		c.SignupBotAction = val
 		return nil
 	},
 
-	// AIRBRAKE_CREDENTIALS is a configuration string for the Airbrake error reporting backend. When
-	// provided, errors and panics will be reported asynchronously.
+	// LOGIN_THROTTLE_ATTEMPTS is how many failed logins an account may have within an hour before
+	// it is temporarily locked. Each further temporary lock within a day lasts twice as long as the
+	// last, starting from LOGIN_THROTTLE_DURATION seconds (default: 60) up to
+	// LOGIN_THROTTLE_MAX_DURATION seconds (default: 86400). Failures are tracked in Redis. A value
+	// of 0 (the default) disables throttling.
 	func(c *Config) error {
-		if val, ok := os.LookupEnv("AIRBRAKE_CREDENTIALS"); ok {
-			bits := strings.SplitN(val, ":", 2)
-			projectID, err := strconv.Atoi(bits[0])
-			if err != nil {
-				return err
-			}
-			projectKey := bits[1]
-
-			client := gobrake.NewNotifier(int64(projectID), projectKey)
-			c.ErrorReporter = &ops.AirbrakeReporter{Notifier: client}
+		attempts, err := lookupInt("LOGIN_THROTTLE_ATTEMPTS", 0)
+		if err != nil {
+			return err
+		}
+		duration, err := lookupInt("LOGIN_THROTTLE_DURATION", 60)
+		if err != nil {
+			return err
 		}
+		maxDuration, err := lookupInt("LOGIN_THROTTLE_MAX_DURATION", 86400)
+		if err != nil {
+			return err
+		}
+		if attempts < 0 || duration <= 0 || maxDuration < duration {
+			return fmt.Errorf("LOGIN_THROTTLE_* must be positive, with MAX_DURATION at least DURATION")
+		}
+		if attempts > 0 && c.RedisURL == nil {
+			return fmt.Errorf("LOGIN_THROTTLE_ATTEMPTS requires REDIS_URL")
+		}
+		c.LoginThrottleAttempts = attempts
+		c.LoginThrottleDuration = time.Duration(duration) * time.Second
+		c.LoginThrottleMaxDuration = time.Duration(maxDuration) * time.Second
 		return nil
 	},
 
-	// PORT is the local port the AuthN server listens to. The default is taken from AUTHN_URL, but
-	// may be different for port mapping scenarios as with containers and load balancers.
+	// PREVENT_USERNAME_ENUMERATION makes logins, password reset requests, and availability checks
+	// respond the same whether or not a username is registered. Logins to throttled accounts fail
+	// as if the password were wrong, and availability checks always report the username as
+	// available.
 	func(c *Config) error {
-		defaultPort, err := strconv.Atoi(c.AuthNURL.Port())
-		val, err := lookupInt("PORT", defaultPort)
+		val, err := lookupBool("PREVENT_USERNAME_ENUMERATION", false)
 		if err == nil {
-			c.ServerPort = val
+			c.PreventEnumeration = val
 		}
 		return err
 	},
 
-	// PUBLIC_PORT is an extra local port the AuthN server listens to with only public routes. This
-	// is useful to avoid exposing admin routes to the public, since you can configure a proxy or
-	// load balancer to forward to only the appropriate port.
+	// EMAIL_USERNAME_DOMAINS is a comma-delimited list of domains that an email
+	// username must contain for signup. If missing, then any domain is a valid
+	// signup.
+	//
+	// This setting only has effect if USERNAME_IS_EMAIL has been set.
 	func(c *Config) error {
-		val, err := lookupInt("PUBLIC_PORT", 0)
-		if err == nil {
-			c.PublicPort = val
+		if val, ok := lookupEnv("EMAIL_USERNAME_DOMAINS"); ok {
+			c.UsernameDomains = strings.Split(val, ",")
 		}
-		return err
+		return nil
+	},
+
+	// REFRESH_TOKEN_TTL determines how long a refresh token will live after its
+	// last touch. This is necessary to prevent years-long Redis bloat from
+	// inactive sessions, where users close the window rather than log out.
+	func(c *Config) error {
+		ttl, err := lookupInt("REFRESH_TOKEN_TTL", 86400*30)
+		if err == nil {
+			c.RefreshTokenTTL = time.Duration(ttl) * time.Second
+		}
+		return err
+	},
+
+	// PASSWORD_CHANGE_KEEP_SESSION keeps the session that changed or reset a password, while every
+	// other session of the account is revoked. Otherwise the client is given a new session.
+	func(c *Config) error {
+		val, err := lookupBool("PASSWORD_CHANGE_KEEP_SESSION", false)
+		if err == nil {
+			c.PasswordChangeKeepSession = val
+		}
+		return err
+	},
+
+	// SESSION_BINDING binds sessions to the client that created them, by the network prefix of its
+	// IP address and its user agent. A refresh from a different client will require the user to log
+	// in again, which limits the damage of a stolen session cookie.
+	func(c *Config) error {
+		val, err := lookupBool("SESSION_BINDING", false)
+		if err == nil {
+			c.SessionBinding = val
+		}
+		return err
+	},
+
+	// MAX_SESSIONS_PER_ACCOUNT caps how many sessions an account may have at once. This prevents
+	// unbounded growth of session storage from bot-driven logins, and supports per-seat licensing.
+	// The default is 0 (unlimited).
+	//
+	// MAX_SESSIONS_POLICY decides what happens when a new session would exceed the cap: either
+	// "revoke_oldest" (the default) to revoke the least recently used session, or "reject" to
+	// refuse the new login.
+	func(c *Config) error {
+		val, err := lookupInt("MAX_SESSIONS_PER_ACCOUNT", 0)
+		if err != nil {
+			return err
+		}
+		c.MaxSessionsPerAccount = val
+
+		policy := lookupString("MAX_SESSIONS_POLICY", "revoke_oldest")
+		switch policy {
+		case "revoke_oldest":
+			c.RejectExcessSessions = false
+		case "reject":
+			c.RejectExcessSessions = true
+		default:
+			return fmt.Errorf("MAX_SESSIONS_POLICY: unknown policy %s", policy)
+		}
+		return nil
+	},
+
+	// SESSION_SCOPES is a comma-separated list of scopes granted to every session. Access tokens
+	// carry all of them by default, but a refresh may request a subset, so that frontends can hand
+	// narrowly scoped tokens to third-party widgets.
+	func(c *Config) error {
+		if val, ok := lookupEnv("SESSION_SCOPES"); ok {
+			for _, s := range strings.Split(val, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					c.SessionScopes = append(c.SessionScopes, s)
+				}
+			}
+		}
+		return nil
+	},
+
+	// PASSWORD_RESET_TOKEN_TTL determines how long a password reset token (as JWT)
+	// will be valid from when it is generated. These tokens should not live much
+	// longer than it takes for an attentive user to act in a reasonably expedient
+	// manner. If a user loses control of a password reset token, they will lose
+	// control of their account.
+	func(c *Config) error {
+		ttl, err := lookupInt("PASSWORD_RESET_TOKEN_TTL", 1800)
+		if err == nil {
+			c.ResetTokenTTL = time.Duration(ttl) * time.Second
+		}
+		return err
+	},
+
+	// USERNAME_CHANGE_TOKEN_TTL determines how long a token that confirms a username
+	// change will be valid. The token is delivered to the new username, so it only
+	// needs to live long enough for the user to check that inbox.
+	func(c *Config) error {
+		ttl, err := lookupInt("USERNAME_CHANGE_TOKEN_TTL", 3600)
+		if err == nil {
+			c.UsernameChangeTokenTTL = time.Duration(ttl) * time.Second
+		}
+		return err
+	},
+
+	// USERNAME_CHANGE_REVERT_TTL is the grace period after a username change during
+	// which the old username may revert it. This is what protects an account from a
+	// takeover that swaps the username for one controlled by an attacker, so it
+	// should be long enough for a user to notice the change.
+	func(c *Config) error {
+		ttl, err := lookupInt("USERNAME_CHANGE_REVERT_TTL", 604800)
+		if err == nil {
+			c.UsernameRevertTTL = time.Duration(ttl) * time.Second
+		}
+		return err
+	},
+
+	// USERNAME_CHANGE_COOLDOWN is the minimum time between changes to an account's username by its
+	// user. This limits handle recycling, where a user quickly releases a username for someone
+	// else to claim. Admin changes are not limited.
+	func(c *Config) error {
+		val, err := lookupInt("USERNAME_CHANGE_COOLDOWN", 0)
+		if err == nil {
+			c.UsernameChangeCooldown = time.Duration(val) * time.Second
+		}
+		return err
+	},
+
+	// TAKEOUT_TTL determines how long a link to download an account's data will be valid. The link
+	// is a bearer credential for personal data, so it should only live long enough to be delivered.
+	func(c *Config) error {
+		ttl, err := lookupInt("TAKEOUT_TTL", 86400)
+		if err == nil {
+			c.TakeoutTTL = time.Duration(ttl) * time.Second
+		}
+		return err
+	},
+
+	// ACTION_LINK_TTL determines how long a link that performs an action on an account will be
+	// valid. The link is the only credential needed, so it should only live as long as the ticket
+	// that it is attached to is likely to wait for the user.
+	func(c *Config) error {
+		ttl, err := lookupInt("ACTION_LINK_TTL", 86400)
+		if err == nil {
+			c.ActionLinkTTL = time.Duration(ttl) * time.Second
+		}
+		return err
+	},
+
+	// ACCESS_TOKEN_TTL determines how long an access token (as JWT) will remain
+	// valid. This is a hard limit, to limit the potential damage of an exposed
+	// access token.
+	//
+	// New access tokens can be generated using the refresh token for as long as
+	// the refresh token remains valid. This is an important mechanism because it
+	// allows the authentication server to revoke sessions (e.g. on logout) with
+	// confidence that any related access tokens will expire soon.
+	//
+	// Note that revoking a refresh token will not invalidate access tokens until
+	// this TTL passes, so shorter is better.
+	func(c *Config) error {
+		ttl, err := lookupInt("ACCESS_TOKEN_TTL", 3600)
+		if err == nil {
+			c.AccessTokenTTL = time.Duration(ttl) * time.Second
+		}
+		return err
+	},
+
+	// Signing keys rotate with the ACCESS_TOKEN_TTL, so an APP_DOMAINS override may shorten the TTL
+	// but not extend it. Otherwise tokens would outlive the keys that verify them.
+	func(c *Config) error {
+		for _, app := range c.Applications {
+			if app.AccessTokenTTL > c.AccessTokenTTL {
+				return fmt.Errorf("APP_DOMAINS: access_token_ttl for %s exceeds ACCESS_TOKEN_TTL", app.Hostname)
+			}
+		}
+		return nil
+	},
+
+	// ACCESS_TOKEN_CLAIMS is a comma-separated allowlist of optional claims to include in access
+	// tokens. Session claims are auth_time, amr (authentication methods), mfa_time (when a second
+	// factor was last verified), and sid (a session ID that does not reveal the refresh token).
+	// Account claims are username and password_changed_at, and cost an account lookup for every
+	// token. When not specified, only auth_time is included.
+	func(c *Config) error {
+		if val, ok := lookupEnv("ACCESS_TOKEN_CLAIMS"); ok {
+			c.AccessTokenClaims = []string{}
+			for _, claim := range strings.Split(val, ",") {
+				claim = strings.TrimSpace(claim)
+				if claim == "" {
+					continue
+				}
+				if !includes(AccessTokenClaimNames, claim) {
+					return fmt.Errorf("ACCESS_TOKEN_CLAIMS: unknown claim %s", claim)
+				}
+				c.AccessTokenClaims = append(c.AccessTokenClaims, claim)
+			}
+		}
+		return nil
+	},
+
+	// ACCESS_TOKEN_FORMAT may be "jwt" (the default) or "opaque". Opaque access tokens are random
+	// strings backed by metadata in Redis, and must be validated with the introspection endpoint.
+	// This costs a lookup for every validation, but revoking a session takes effect immediately.
+	func(c *Config) error {
+		val := lookupString("ACCESS_TOKEN_FORMAT", "jwt")
+		if val == "jwt" {
+			return nil
+		}
+		if val != "opaque" {
+			return fmt.Errorf("ACCESS_TOKEN_FORMAT: unknown format %s", val)
+		}
+		if c.RedisURL == nil {
+			return fmt.Errorf("ACCESS_TOKEN_FORMAT=opaque requires REDIS_URL")
+		}
+		c.OpaqueAccessTokens = true
+		return nil
+	},
+
+	// SESSION_COOKIE_NAME renames the HttpOnly cookie that holds the refresh token (default: "authn").
+	func(c *Config) error {
+		c.SessionCookieName = lookupString("SESSION_COOKIE_NAME", c.SessionCookieName)
+		return nil
+	},
+
+	// ACCESS_TOKEN_COOKIE_NAME enables a fallback for legacy frontends that can not call the refresh
+	// endpoint with XHR. Every identity token is also set in a cookie by this name that JavaScript may
+	// read, and that expires with the token.
+	func(c *Config) error {
+		if val, ok := lookupEnv("ACCESS_TOKEN_COOKIE_NAME"); ok {
+			if val == c.SessionCookieName {
+				return fmt.Errorf("ACCESS_TOKEN_COOKIE_NAME: must differ from SESSION_COOKIE_NAME")
+			}
+			c.AccessTokenCookieName = val
+		}
+		return nil
+	},
+
+	// HTTP_AUTH_USERNAME and HTTP_AUTH_PASSWORD specify the basic auth credentials
+	// that must be provided to access private endpoints.
+	//
+	// This security pattern requires communication with AuthN to use SSL.
+	func(c *Config) error {
+		if val, ok := lookupEnv("HTTP_AUTH_USERNAME"); ok {
+			c.AuthUsername = val
+		} else {
+			i, err := rand.Int(rand.Reader, big.NewInt(99999999))
+			if err != nil {
+				return err
+			}
+			c.AuthUsername = i.String()
+		}
+		if val, ok := lookupEnv("HTTP_AUTH_PASSWORD"); ok {
+			c.AuthPassword = val
+		} else {
+			i, err := rand.Int(rand.Reader, big.NewInt(99999999))
+			if err != nil {
+				return err
+			}
+			c.AuthPassword = i.String()
+		}
+		return nil
+	},
+
+	// APP_PASSWORD_CHANGED_URL is an endpoint that will be notified when an account
+	// has changed its password. This notification may be used to deliver an email
+	// confirmation.
+	//
+	// For security, this URL should specify https and include a basic auth username
+	// and password.
+	func(c *Config) error {
+		val, err := lookupURL("APP_PASSWORD_CHANGED_URL")
+		if err == nil && val != nil {
+			c.AppPasswordChangedURL = val
+		}
+		return err
+	},
+
+	// APP_PASSWORD_RESET_URL is an endpoint that will be notified when an account
+	// has requested a password reset. The endpoint is expected to deliver an email
+	// with the given password reset token, then respond with a 2xx HTTP status.
+	//
+	// For security, this URL should specify https and include a basic auth username
+	// and password.
+	func(c *Config) error {
+		val, err := lookupURL("APP_PASSWORD_RESET_URL")
+		if err == nil && val != nil {
+			c.AppPasswordResetURL = val
+		}
+		return err
+	},
+
+	// APP_USERNAME_CHANGE_URL is an endpoint that will be notified when an account
+	// has requested or confirmed a username change. The endpoint is expected to
+	// deliver an email with the given token to the given username, then respond with
+	// a 2xx HTTP status. When not configured, usernames may only be changed through
+	// the private API.
+	//
+	// For security, this URL should specify https and include a basic auth username
+	// and password.
+	func(c *Config) error {
+		val, err := lookupURL("APP_USERNAME_CHANGE_URL")
+		if err == nil && val != nil {
+			c.AppUsernameChangeURL = val
+		}
+		return err
+	},
+
+	// APP_ACCOUNT_PROVISIONING_URL is an endpoint that will be notified when an account is created.
+	// It may respond with the ID of the account in another system, which AuthN will remember as the
+	// account's external ID.
+	//
+	// For security, this URL should specify https and include a basic auth username
+	// and password.
+	func(c *Config) error {
+		val, err := lookupURL("APP_ACCOUNT_PROVISIONING_URL")
+		if err == nil && val != nil {
+			c.AppAccountProvisioningURL = val
+		}
+		return err
+	},
+
+	// RSA_PRIVATE_KEY is a RSA private key in PEM format. If provided as a single
+	// line string, any literal \n sequences will be converted to real linebreaks.
+	// When provided, it will be used for signing identity tokens, and the public
+	// key will be published for audiences to verify. When not provided, AuthN will
+	// generate and manage keys itself, using Redis for coordination and
+	// persistence.
+	func(c *Config) error {
+		if str, ok := lookupEnv("RSA_PRIVATE_KEY"); ok {
+			str = strings.Replace(str, `\n`, "\n", -1)
+			block, _ := pem.Decode([]byte(str))
+			key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return err
+			}
+			c.IdentitySigningKey = key
+		}
+		return nil
+	},
+
+	// PKCS11_MODULE is the path to a PKCS#11 library for a hardware security module or smart card
+	// that holds the RSA key for signing identity tokens. The key never leaves the device: AuthN reads
+	// the public key to publish it, and asks the device to sign each token. Like RSA_PRIVATE_KEY, this
+	// disables automatic key rotation.
+	//
+	// The token is found by PKCS11_SLOT (default: 0) and logged in with PKCS11_PIN. The key pair is
+	// found by PKCS11_KEY_LABEL, or may be unlabeled when the token holds only one RSA key pair.
+	func(c *Config) error {
+		if val, ok := lookupEnv("PKCS11_MODULE"); ok {
+			if c.IdentitySigningKey != nil {
+				return fmt.Errorf("PKCS11_MODULE conflicts with RSA_PRIVATE_KEY")
+			}
+			slot, err := lookupInt("PKCS11_SLOT", 0)
+			if err != nil {
+				return err
+			}
+			key, err := hsm.Open(val, uint(slot), getEnv("PKCS11_PIN"), getEnv("PKCS11_KEY_LABEL"))
+			if err != nil {
+				return fmt.Errorf("PKCS11_MODULE: %v", err)
+			}
+			c.IdentitySigningKey = key
+		}
+		return nil
+	},
+
+	// ID_TOKEN_ENCRYPTION_KEYS is a comma-separated list of domain=path pairs, where each path is an
+	// RSA public key in PEM format registered by the relying party for that domain. Identity tokens
+	// issued to a listed audience will be encrypted to its key (a signed JWT nested in a JWE), so
+	// that claims are not readable by intermediaries.
+	func(c *Config) error {
+		if val, ok := lookupEnv("ID_TOKEN_ENCRYPTION_KEYS"); ok {
+			c.IdentityEncryptionKeys = make(map[string]*rsa.PublicKey)
+			for _, pair := range strings.Split(val, ",") {
+				bits := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(bits) != 2 {
+					return fmt.Errorf("ID_TOKEN_ENCRYPTION_KEYS: expected domain=path, got %s", pair)
+				}
+				key, err := readPublicKey(bits[1])
+				if err != nil {
+					return fmt.Errorf("ID_TOKEN_ENCRYPTION_KEYS: %s: %v", bits[0], err)
+				}
+				c.IdentityEncryptionKeys[bits[0]] = key
+			}
+		}
+		return nil
+	},
+
+	// FIELD_ENCRYPTION_KEYS is a comma-separated list of version=key pairs, where each key is 32 bytes
+	// encoded in base64. When provided, account metadata values are encrypted at rest with the
+	// highest version. Older versions remain to decrypt values until `authn reencrypt` has run.
+	func(c *Config) error {
+		if val, ok := lookupEnv("FIELD_ENCRYPTION_KEYS"); ok {
+			keys := map[int][]byte{}
+			for _, pair := range strings.Split(val, ",") {
+				bits := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(bits) != 2 {
+					return fmt.Errorf("FIELD_ENCRYPTION_KEYS: expected version=key, got %s", pair)
+				}
+				version, err := strconv.Atoi(bits[0])
+				if err != nil {
+					return fmt.Errorf("FIELD_ENCRYPTION_KEYS: invalid version %s", bits[0])
+				}
+				key, err := base64.StdEncoding.DecodeString(bits[1])
+				if err != nil {
+					return fmt.Errorf("FIELD_ENCRYPTION_KEYS: version %d: %v", version, err)
+				}
+				keys[version] = key
+			}
+			keyring, err := fieldcrypt.NewKeyring(keys)
+			if err != nil {
+				return fmt.Errorf("FIELD_ENCRYPTION_KEYS: %v", err)
+			}
+			c.FieldKeyring = keyring
+		}
+		return nil
+	},
+
+	// TIME_ZONE is the IANA name of a location that should be used when calculating
+	// which day it is when tracking key stats. It defaults to UTC.
+	func(c *Config) error {
+		name := lookupString("TIME_ZONE", "UTC")
+
+		tz, err := time.LoadLocation(name)
+		if err != nil {
+			return err
+		}
+		c.StatisticsTimeZone = tz
+		return nil
+	},
+
+	// DAILY_ACTIVES_RETENTION is how many daily records of the number of active accounts to keep.
+	// The default is 365 (~1 year).
+	func(c *Config) error {
+		num, err := lookupInt("DAILY_ACTIVES_RETENTION", 365)
+		if err == nil {
+			c.DailyActivesRetention = num
+		}
+		return err
+	},
+
+	// WEEKLY_ACTIVES_RETENTION is how many weekly records of the number of active accounts to keep.
+	// The default is 104 (~2 years).
+	func(c *Config) error {
+		num, err := lookupInt("WEEKLY_ACTIVES_RETENTION", 104)
+		if err == nil {
+			c.WeeklyActivesRetention = num
+		}
+		return err
+	},
+
+	// SENTRY_DSN is a configuration string for the Sentry error reporting backend. When provided,
+	// errors and panics will be reported asynchronously.
+	func(c *Config) error {
+		if val, ok := lookupEnv("SENTRY_DSN"); ok {
+			client, err := raven.New(val)
+			if err != nil {
+				return err
+			}
+			c.ErrorReporter = &ops.SentryReporter{Client: client}
+		}
+		return nil
+	},
+
+	// AIRBRAKE_CREDENTIALS is a configuration string for the Airbrake error reporting backend. When
+	// provided, errors and panics will be reported asynchronously.
+	func(c *Config) error {
+		if val, ok := lookupEnv("AIRBRAKE_CREDENTIALS"); ok {
+			bits := strings.SplitN(val, ":", 2)
+			projectID, err := strconv.Atoi(bits[0])
+			if err != nil {
+				return err
+			}
+			projectKey := bits[1]
+
+			client := gobrake.NewNotifier(int64(projectID), projectKey)
+			c.ErrorReporter = &ops.AirbrakeReporter{Notifier: client}
+		}
+		return nil
+	},
+
+	// ALERT_SLACK_URL is a Slack incoming webhook, and ALERT_PAGERDUTY_KEY is a PagerDuty Events API
+	// routing key. When either is provided, critical security events will be alerted to them:
+	// accounts locked by an administrator, key rotation failures, outbox messages that exhaust their
+	// retries, and ALERT_FAILED_LOGINS failed logins within a minute (default: 100, or 0 to disable).
+	// Failed logins are counted by each server, not across servers.
+	//
+	// ALERT_COOLDOWN is how many seconds to wait before repeating an alert (default: 900).
+	func(c *Config) error {
+		var alerters []ops.Alerter
+		if val, ok := lookupEnv("ALERT_SLACK_URL"); ok {
+			alerters = append(alerters, &ops.SlackAlerter{URL: val})
+		}
+		if val, ok := lookupEnv("ALERT_PAGERDUTY_KEY"); ok {
+			alerters = append(alerters, &ops.PagerDutyAlerter{RoutingKey: val, Source: c.AuthNURL.Host})
+		}
+		if len(alerters) == 0 {
+			return nil
+		}
+		failedLogins, err := lookupInt("ALERT_FAILED_LOGINS", 100)
+		if err != nil {
+			return err
+		}
+		cooldown, err := lookupInt("ALERT_COOLDOWN", 900)
+		if err != nil {
+			return err
+		}
+		c.Alerts = ops.NewAlerts(c.ErrorReporter, time.Duration(cooldown)*time.Second, alerters...)
+		c.EventPublishers = append(c.EventPublishers, events.NewAlertPublisher(c.Alerts, failedLogins))
+		return nil
+	},
+
+	// KAFKA_BROKERS is a comma-separated list of Kafka brokers (host:port). When provided, account
+	// events will be published as JSON to KAFKA_TOPIC (default: "authn-events").
+	func(c *Config) error {
+		if val, ok := lookupEnv("KAFKA_BROKERS"); ok {
+			topic, ok := lookupEnv("KAFKA_TOPIC")
+			if !ok {
+				topic = "authn-events"
+			}
+			c.EventPublishers = append(c.EventPublishers, events.NewKafkaPublisher(strings.Split(val, ","), topic))
+		}
+		return nil
+	},
+
+	// NATS_URL is the address of a NATS server (nats://host:port). When provided, account events
+	// will be published as JSON to NATS_SUBJECT (default: "authn.events").
+	func(c *Config) error {
+		if val, ok := lookupEnv("NATS_URL"); ok {
+			subject, ok := lookupEnv("NATS_SUBJECT")
+			if !ok {
+				subject = "authn.events"
+			}
+			publisher, err := events.NewNATSPublisher(val, subject)
+			if err != nil {
+				return err
+			}
+			c.EventPublishers = append(c.EventPublishers, publisher)
+		}
+		return nil
+	},
+
+	// SYSLOG_URL is the address of a syslog collector (udp://, tcp://, or tls://host:port). When
+	// provided, account events will be forwarded as audit records for SIEM ingestion.
+	//
+	// SYSLOG_FORMAT may be "cef" (default) or "json".
+	func(c *Config) error {
+		val, err := lookupURL("SYSLOG_URL")
+		if err != nil || val == nil {
+			return err
+		}
+		format := lookupString("SYSLOG_FORMAT", "cef")
+		publisher, err := events.NewSyslogPublisher(val, format)
+		if err != nil {
+			return err
+		}
+		c.EventPublishers = append(c.EventPublishers, publisher)
+		return nil
+	},
+
+	// ANALYTICS_SECRET enables an anonymized export of events for product analytics. Account IDs are
+	// replaced with an HMAC using a salt derived from this secret, rotated every
+	// ANALYTICS_SALT_ROTATION seconds (default: 1 day). Countries are only exported once
+	// ANALYTICS_MIN_GROUP_SIZE events (default: 10) share them within a rotation.
+	//
+	// Events are sent to ANALYTICS_TOPIC (default: "authn-analytics") with Kafka or, failing that,
+	// with NATS.
+	func(c *Config) error {
+		secret, ok := lookupEnv("ANALYTICS_SECRET")
+		if !ok {
+			return nil
+		}
+		rotation, err := lookupInt("ANALYTICS_SALT_ROTATION", 86400)
+		if err != nil {
+			return err
+		}
+		if rotation <= 0 {
+			return fmt.Errorf("ANALYTICS_SALT_ROTATION must be positive")
+		}
+		minGroupSize, err := lookupInt("ANALYTICS_MIN_GROUP_SIZE", 10)
+		if err != nil {
+			return err
+		}
+		topic := lookupString("ANALYTICS_TOPIC", "authn-analytics")
+
+		var sender events.Sender
+		if brokers, ok := lookupEnv("KAFKA_BROKERS"); ok {
+			sender = events.NewKafkaPublisher(strings.Split(brokers, ","), topic)
+		} else if natsURL, ok := lookupEnv("NATS_URL"); ok {
+			sender, err = events.NewNATSPublisher(natsURL, topic)
+			if err != nil {
+				return err
+			}
+		} else {
+			return fmt.Errorf("ANALYTICS_SECRET requires KAFKA_BROKERS or NATS_URL")
+		}
+		c.EventPublishers = append(c.EventPublishers, events.NewAnalyticsPublisher(
+			sender,
+			[]byte(secret),
+			time.Duration(rotation)*time.Second,
+			minGroupSize,
+		))
+		return nil
+	},
+
+	// AUDIT_LOG is a flag that records account events in Redis, so that they may be queried through
+	// the GraphQL endpoint. It requires REDIS_URL.
+	func(c *Config) error {
+		val, err := lookupBool("AUDIT_LOG", false)
+		if err != nil {
+			return err
+		}
+		if val && c.RedisURL == nil {
+			return fmt.Errorf("AUDIT_LOG requires REDIS_URL")
+		}
+		c.AuditLog = val
+		return nil
+	},
+
+	// AUDIT_LOG_RETENTION is how long audit events are kept, in seconds. Older events are pruned
+	// hourly. The default keeps events forever.
+	func(c *Config) error {
+		val, err := lookupInt("AUDIT_LOG_RETENTION", 0)
+		if err != nil {
+			return err
+		}
+		if val > 0 && !c.AuditLog {
+			return fmt.Errorf("AUDIT_LOG_RETENTION requires AUDIT_LOG")
+		}
+		c.AuditLogRetention = time.Duration(val) * time.Second
+		return nil
+	},
+
+	// AUDIT_LOG_ARCHIVE_URL is an S3-compatible bucket where audit events are archived before they
+	// are pruned. It requires AUDIT_LOG_RETENTION.
+	func(c *Config) error {
+		val, err := lookupURL("AUDIT_LOG_ARCHIVE_URL")
+		if err != nil || val == nil {
+			return err
+		}
+		if c.AuditLogRetention == 0 {
+			return fmt.Errorf("AUDIT_LOG_ARCHIVE_URL requires AUDIT_LOG_RETENTION")
+		}
+		bucket, err := s3.NewBucket(val)
+		if err != nil {
+			return fmt.Errorf("AUDIT_LOG_ARCHIVE_URL: %v", err)
+		}
+		c.AuditLogArchive = bucket
+		return nil
+	},
+
+	// ENABLE_OUTBOX is a flag that persists events and the password changed webhook in the
+	// database until they are delivered. They are written after the change that caused them.
+	func(c *Config) error {
+		val, err := lookupBool("ENABLE_OUTBOX", false)
+		if err != nil {
+			return err
+		}
+		c.EnableOutbox = val
+		return nil
+	},
+
+	// OUTBOX_RETENTION is how long delivered outbox messages are kept, in seconds.
+	func(c *Config) error {
+		val, err := lookupInt("OUTBOX_RETENTION", 604800)
+		if err != nil {
+			return err
+		}
+		c.OutboxRetention = time.Duration(val) * time.Second
+		return nil
+	},
+
+	// SESSION_EVENTS is a flag that streams account events to the client's session, so that a
+	// frontend may react when its session is revoked or its account changes.
+	func(c *Config) error {
+		val, err := lookupBool("SESSION_EVENTS", false)
+		if err != nil {
+			return err
+		}
+		c.SessionEvents = val
+		return nil
+	},
+
+	// PORT is the local port the AuthN server listens to. The default is taken from AUTHN_URL, but
+	// may be different for port mapping scenarios as with containers and load balancers.
+	func(c *Config) error {
+		defaultPort, err := strconv.Atoi(c.AuthNURL.Port())
+		val, err := lookupInt("PORT", defaultPort)
+		if err == nil {
+			c.ServerPort = val
+		}
+		return err
+	},
+
+	// PUBLIC_PORT is an extra local port the AuthN server listens to with only public routes. This
+	// is useful to avoid exposing admin routes to the public, since you can configure a proxy or
+	// load balancer to forward to only the appropriate port.
+	func(c *Config) error {
+		val, err := lookupInt("PUBLIC_PORT", 0)
+		if err == nil {
+			c.PublicPort = val
+		}
+		return err
+	},
+
+	// SERVER_READ_HEADER_TIMEOUT, SERVER_READ_TIMEOUT, and SERVER_WRITE_TIMEOUT are how many seconds
+	// a connection may take to send its request headers, to send its whole request, and to receive
+	// its response. They protect the listeners from slow clients (e.g. slowloris) that hold
+	// connections open. A value of 0 removes the limit.
+	func(c *Config) error {
+		header, err := lookupInt("SERVER_READ_HEADER_TIMEOUT", 5)
+		if err != nil {
+			return err
+		}
+		read, err := lookupInt("SERVER_READ_TIMEOUT", 30)
+		if err != nil {
+			return err
+		}
+		write, err := lookupInt("SERVER_WRITE_TIMEOUT", 60)
+		if err != nil {
+			return err
+		}
+		c.ServerReadHeaderTimeout = time.Duration(header) * time.Second
+		c.ServerReadTimeout = time.Duration(read) * time.Second
+		c.ServerWriteTimeout = time.Duration(write) * time.Second
+		return nil
+	},
+
+	// SERVER_IDLE_TIMEOUT is how many seconds a keep-alive connection may wait for its next request
+	// before it is closed. The default is 120.
+	//
+	// SERVER_KEEP_ALIVE may be disabled to close every connection after one response, e.g. when a
+	// load balancer holds connections that should be spread across instances.
+	func(c *Config) error {
+		idle, err := lookupInt("SERVER_IDLE_TIMEOUT", 120)
+		if err != nil {
+			return err
+		}
+		keepAlive, err := lookupBool("SERVER_KEEP_ALIVE", true)
+		if err != nil {
+			return err
+		}
+		c.ServerIdleTimeout = time.Duration(idle) * time.Second
+		c.ServerKeepAlive = keepAlive
+		return nil
+	},
+
+	// HTTP2 is a flag that enables cleartext HTTP/2 (h2c), for load balancers and proxies that
+	// multiplex many clients over a few connections. HTTP/1.1 clients are unaffected.
+	//
+	// HTTP2_MAX_CONCURRENT_STREAMS is how many requests one HTTP/2 connection may have in flight. The
+	// default is 250.
+	func(c *Config) error {
+		enabled, err := lookupBool("HTTP2", false)
+		if err != nil {
+			return err
+		}
+		streams, err := lookupInt("HTTP2_MAX_CONCURRENT_STREAMS", 250)
+		if err != nil {
+			return err
+		}
+		if streams < 1 {
+			return fmt.Errorf("HTTP2_MAX_CONCURRENT_STREAMS must be positive")
+		}
+		c.HTTP2 = enabled
+		c.HTTP2MaxConcurrentStreams = streams
+		return nil
+	},
+
+	// REQUEST_TIMEOUT is how many seconds a handler may work on a request before AuthN gives up with
+	// a 503. Long-running diagnostic endpoints are exempt. A value of 0 removes the limit.
+	func(c *Config) error {
+		val, err := lookupInt("REQUEST_TIMEOUT", 30)
+		if err != nil {
+			return err
+		}
+		c.RequestTimeout = time.Duration(val) * time.Second
+		if c.ServerWriteTimeout > 0 && c.RequestTimeout > c.ServerWriteTimeout {
+			return fmt.Errorf("REQUEST_TIMEOUT must not be longer than SERVER_WRITE_TIMEOUT")
+		}
+		return nil
+	},
+
+	// REQUEST_BUDGET is how many milliseconds one request may spend waiting on the database and
+	// Redis, across every call that it makes. A request that exceeds its budget gives up with a 503,
+	// so that one slow dependency does not hold a handler until REQUEST_TIMEOUT. The default of 0
+	// imposes no budget.
+	func(c *Config) error {
+		val, err := lookupInt("REQUEST_BUDGET", 0)
+		if err != nil {
+			return err
+		}
+		c.RequestBudget = time.Duration(val) * time.Millisecond
+		if c.RequestTimeout > 0 && c.RequestBudget >= c.RequestTimeout {
+			return fmt.Errorf("REQUEST_BUDGET must be shorter than REQUEST_TIMEOUT")
+		}
+		return nil
+	},
+
+	// MAX_REQUEST_BODY_SIZE is the largest request body, in bytes, that AuthN will read. Larger
+	// requests are rejected with a 413. The default is 1 MiB.
+	func(c *Config) error {
+		val, err := lookupInt("MAX_REQUEST_BODY_SIZE", 1024*1024)
+		if err != nil {
+			return err
+		}
+		if val < 1 {
+			return fmt.Errorf("MAX_REQUEST_BODY_SIZE must be positive")
+		}
+		c.MaxRequestBodySize = int64(val)
+		return nil
 	},
 
 	// PROXIED is a flag that indicates AuthN is behind a proxy. When set, AuthN will read IP
@@ -435,10 +1575,276 @@ var configurers = []configurer{
 		return err
 	},
 
+	// GEOIP_DATABASE is the path to a MaxMind GeoIP2 or GeoLite2 database (.mmdb). When provided,
+	// account and session events will include the country and city of the request.
+	func(c *Config) error {
+		if val, ok := lookupEnv("GEOIP_DATABASE"); ok {
+			locator, err := geoip.Open(val)
+			if err != nil {
+				return fmt.Errorf("GEOIP_DATABASE: %v", err)
+			}
+			c.GeoIPLocator = locator
+		}
+		return nil
+	},
+
+	// BLOCKED_COUNTRIES is a comma-separated list of ISO country codes (e.g. "KP,IR"). Logins from
+	// these countries will be refused. Requires GEOIP_DATABASE.
+	func(c *Config) error {
+		if val, ok := lookupEnv("BLOCKED_COUNTRIES"); ok {
+			if c.GeoIPLocator == nil {
+				return fmt.Errorf("BLOCKED_COUNTRIES requires GEOIP_DATABASE")
+			}
+			for _, country := range strings.Split(val, ",") {
+				c.BlockedCountries = append(c.BlockedCountries, strings.ToUpper(strings.TrimSpace(country)))
+			}
+		}
+		return nil
+	},
+
+	// TOR_EXIT_NODES is the path to a file of TOR exit node IP addresses, one per line, such as the
+	// list published at https://check.torproject.org/torbulkexitlist. It is read at boot.
+	func(c *Config) error {
+		if val, ok := lookupEnv("TOR_EXIT_NODES"); ok {
+			list, err := geoip.ReadIPList(val)
+			if err != nil {
+				return fmt.Errorf("TOR_EXIT_NODES: %v", err)
+			}
+			c.TorExitNodes = list
+		}
+		return nil
+	},
+
+	// APP_LOGIN_CHALLENGE_URL is an endpoint that will be notified when a login looks suspicious.
+	// The login is held until the user confirms it, so the endpoint is expected to deliver an email
+	// with a confirmation link containing the given token, then respond with a 2xx HTTP status.
+	// When not configured, logins are never challenged.
+	//
+	// For security, this URL should specify https and include a basic auth username and password.
+	func(c *Config) error {
+		val, err := lookupURL("APP_LOGIN_CHALLENGE_URL")
+		if err == nil && val != nil {
+			c.AppLoginChallengeURL = val
+		}
+		return err
+	},
+
+	// LOGIN_CHALLENGE_RULES is a comma-separated list of the heuristics that decide when a login is
+	// suspicious: "new_country" for a country that the account has not recently logged in from
+	// (requires GEOIP_DATABASE and AUDIT_LOG), and "tor" for a TOR exit node (requires
+	// TOR_EXIT_NODES). The default is every rule whose requirements are configured.
+	func(c *Config) error {
+		if c.AppLoginChallengeURL == nil {
+			return nil
+		}
+
+		val, ok := lookupEnv("LOGIN_CHALLENGE_RULES")
+		if !ok {
+			if c.GeoIPLocator != nil && c.AuditLog {
+				c.LoginChallengeRules = append(c.LoginChallengeRules, "new_country")
+			}
+			if c.TorExitNodes != nil {
+				c.LoginChallengeRules = append(c.LoginChallengeRules, "tor")
+			}
+			return nil
+		}
+
+		for _, rule := range strings.Split(val, ",") {
+			rule = strings.TrimSpace(rule)
+			switch rule {
+			case "new_country":
+				if c.GeoIPLocator == nil || !c.AuditLog {
+					return fmt.Errorf("LOGIN_CHALLENGE_RULES=new_country requires GEOIP_DATABASE and AUDIT_LOG")
+				}
+			case "tor":
+				if c.TorExitNodes == nil {
+					return fmt.Errorf("LOGIN_CHALLENGE_RULES=tor requires TOR_EXIT_NODES")
+				}
+			case "":
+				continue
+			default:
+				return fmt.Errorf("LOGIN_CHALLENGE_RULES has an unknown rule: %v", rule)
+			}
+			c.LoginChallengeRules = append(c.LoginChallengeRules, rule)
+		}
+		return nil
+	},
+
+	// LOGIN_CHALLENGE_TOKEN_TTL determines how long a user has to confirm a suspicious login. The
+	// default is 15 minutes.
+	func(c *Config) error {
+		ttl, err := lookupInt("LOGIN_CHALLENGE_TOKEN_TTL", 900)
+		if err == nil {
+			c.LoginChallengeTokenTTL = time.Duration(ttl) * time.Second
+		}
+		return err
+	},
+
+	// PUSH_MFA_URL is a custom authenticator service that approves logins as a second factor. When
+	// configured, a login for an enrolled account is held until the service approves it. See
+	// mfa.WebhookProvider for the protocol.
+	//
+	// For security, this URL should specify https and include a basic auth username and password.
+	func(c *Config) error {
+		val, err := lookupURL("PUSH_MFA_URL")
+		if err == nil && val != nil {
+			c.PushMFAProvider = mfa.NewWebhookProvider(val)
+		}
+		return err
+	},
+
+	// PUSH_MFA_TIMEOUT determines how long a user has to approve a login with their authenticator.
+	// The default is 2 minutes.
+	func(c *Config) error {
+		timeout, err := lookupInt("PUSH_MFA_TIMEOUT", 120)
+		if err == nil {
+			c.PushMFATimeout = time.Duration(timeout) * time.Second
+		}
+		return err
+	},
+
+	// STEP_UP_SCOPES is a comma-separated list of SESSION_SCOPES that access tokens only carry while
+	// the session has verified a second factor within STEP_UP_MAX_AGE, either at login or by
+	// stepping up. This requires PUSH_MFA_URL, since there would be no way to step up.
+	func(c *Config) error {
+		if val, ok := lookupEnv("STEP_UP_SCOPES"); ok {
+			for _, s := range strings.Split(val, ",") {
+				if s = strings.TrimSpace(s); s == "" {
+					continue
+				}
+				if !includes(c.SessionScopes, s) {
+					return fmt.Errorf("STEP_UP_SCOPES: %s is not in SESSION_SCOPES", s)
+				}
+				c.StepUpScopes = append(c.StepUpScopes, s)
+			}
+			if len(c.StepUpScopes) > 0 && c.PushMFAProvider == nil {
+				return fmt.Errorf("STEP_UP_SCOPES: requires PUSH_MFA_URL")
+			}
+		}
+		return nil
+	},
+
+	// STEP_UP_MAX_AGE determines how long after verifying a second factor a session may be issued
+	// STEP_UP_SCOPES. The default is 5 minutes.
+	func(c *Config) error {
+		maxAge, err := lookupInt("STEP_UP_MAX_AGE", 300)
+		if err == nil {
+			c.StepUpMaxAge = time.Duration(maxAge) * time.Second
+		}
+		return err
+	},
+
+	// MAINTENANCE_MODE starts this server in read-only mode (logins and refreshes only) or in full
+	// maintenance. The mode may also be switched at runtime through the private API, and this server
+	// will use whichever mode is more restrictive.
+	//
+	// MAINTENANCE_RETRY_AFTER is how many seconds clients are told to wait when they are refused.
+	// The default is 300.
+	func(c *Config) error {
+		if val, ok := lookupEnv("MAINTENANCE_MODE"); ok {
+			if val != "normal" && val != "read-only" && val != "maintenance" {
+				return fmt.Errorf("MAINTENANCE_MODE must be normal, read-only, or maintenance")
+			}
+			c.MaintenanceMode = val
+		}
+		val, err := lookupInt("MAINTENANCE_RETRY_AFTER", 300)
+		if err != nil {
+			return err
+		}
+		c.MaintenanceRetryAfter = time.Duration(val) * time.Second
+		return nil
+	},
+
+	// LOG_LEVEL is the minimum level of the logs: debug, info, warn, or error. The default is info.
+	// At debug, request logs include the params and headers, with secrets redacted.
+	func(c *Config) error {
+		level, err := logrus.ParseLevel(lookupString("LOG_LEVEL", "info"))
+		if err != nil {
+			return fmt.Errorf("LOG_LEVEL: %v", err)
+		}
+		c.LogLevel = level
+		return nil
+	},
+
+	// LOG_SAMPLING is a comma-separated list of routes that only log a fraction of their successful
+	// requests, e.g. "GET /health=0.01,/session/refresh=0.1". The first matching rule applies. See
+	// ParseLogSampleRule.
+	func(c *Config) error {
+		if val, ok := lookupEnv("LOG_SAMPLING"); ok {
+			for _, entry := range strings.Split(val, ",") {
+				if strings.TrimSpace(entry) == "" {
+					continue
+				}
+				rule, err := ParseLogSampleRule(entry)
+				if err != nil {
+					return fmt.Errorf("LOG_SAMPLING: %v", err)
+				}
+				c.LogSampling = append(c.LogSampling, rule)
+			}
+		}
+		return nil
+	},
+
+	// LOG_REDACT_PARAMS is a comma-separated list of additional params and headers to redact from
+	// request logs. Anything named like a password, token, secret, or cookie is always redacted.
+	func(c *Config) error {
+		if val, ok := lookupEnv("LOG_REDACT_PARAMS"); ok {
+			for _, name := range strings.Split(val, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					c.LogRedactedParams = append(c.LogRedactedParams, name)
+				}
+			}
+		}
+		return nil
+	},
+
+	// DEBUG_ENDPOINTS is a flag that enables pprof profiling and expvar runtime stats on the private
+	// routes. These endpoints are protected by the same basic auth as other private routes, but
+	// profiling adds overhead, so they should only be enabled while investigating a problem.
+	func(c *Config) error {
+		val, err := lookupBool("DEBUG_ENDPOINTS", false)
+		if err == nil {
+			c.DebugEndpoints = val
+		}
+		return err
+	},
+
+	// ADMIN_DASHBOARD enables a web page on the private port for support staff to search accounts
+	// and lock, unlock, archive, or expire them. It uses the private API, so it requires the same
+	// HTTP_AUTH_USERNAME and HTTP_AUTH_PASSWORD.
+	func(c *Config) error {
+		val, err := lookupBool("ADMIN_DASHBOARD", false)
+		if err == nil {
+			c.AdminDashboard = val
+		}
+		return err
+	},
+
+	// ENABLE_GRAPHQL adds a GraphQL endpoint on the private port for admin tooling, with account
+	// queries and mutations, session listings, and audit log queries (when AUDIT_LOG is enabled).
+	// It requires the same HTTP_AUTH_USERNAME and HTTP_AUTH_PASSWORD as the private API.
+	func(c *Config) error {
+		val, err := lookupBool("ENABLE_GRAPHQL", false)
+		if err == nil {
+			c.EnableGraphQL = val
+		}
+		return err
+	},
+
+	// DEV_SEED is a flag that creates a fixed set of test accounts when the server starts, for local
+	// development and QA environments. Never enable it in production.
+	func(c *Config) error {
+		val, err := lookupBool("DEV_SEED", false)
+		if err == nil {
+			c.DevSeed = val
+		}
+		return err
+	},
+
 	// GOOGLE_OAUTH_CREDENTIALS is a credential pair in the format `id:secret`. When specified,
 	// AuthN will enable routes for Google OAuth signin.
 	func(c *Config) error {
-		if val, ok := os.LookupEnv("GOOGLE_OAUTH_CREDENTIALS"); ok {
+		if val, ok := lookupEnv("GOOGLE_OAUTH_CREDENTIALS"); ok {
 			credentials, err := oauth.NewCredentials(val)
 			if err == nil {
 				c.GoogleOauthCredentials = credentials
@@ -451,7 +1857,7 @@ var configurers = []configurer{
 	// GITHUB_OAUTH_CREDENTIALS is a credential pair in the format `id:secret`. When specified,
 	// AuthN will enable routes for GitHub OAuth signin.
 	func(c *Config) error {
-		if val, ok := os.LookupEnv("GITHUB_OAUTH_CREDENTIALS"); ok {
+		if val, ok := lookupEnv("GITHUB_OAUTH_CREDENTIALS"); ok {
 			credentials, err := oauth.NewCredentials(val)
 			if err == nil {
 				c.GitHubOauthCredentials = credentials
@@ -464,7 +1870,7 @@ var configurers = []configurer{
 	// FACEBOOK_OAUTH_CREDENTIALS is a credential pair in the format `id:secret`. When specified,
 	// AuthN will enable routes for Facebook OAuth signin.
 	func(c *Config) error {
-		if val, ok := os.LookupEnv("FACEBOOK_OAUTH_CREDENTIALS"); ok {
+		if val, ok := lookupEnv("FACEBOOK_OAUTH_CREDENTIALS"); ok {
 			credentials, err := oauth.NewCredentials(val)
 			if err == nil {
 				c.FacebookOauthCredentials = credentials
@@ -473,10 +1879,166 @@ var configurers = []configurer{
 		}
 		return nil
 	},
+
+	// MICROSOFT_OAUTH_CREDENTIALS is a credential pair in the format `id:secret`. When specified,
+	// AuthN will enable routes for Microsoft OAuth signin.
+	func(c *Config) error {
+		if val, ok := lookupEnv("MICROSOFT_OAUTH_CREDENTIALS"); ok {
+			credentials, err := oauth.NewCredentials(val)
+			if err == nil {
+				c.MicrosoftOauthCredentials = credentials
+			}
+			return err
+		}
+		return nil
+	},
+
+	// MICROSOFT_OAUTH_TENANT restricts Microsoft OAuth signin to a directory's ID or domain, or to
+	// `organizations` (work and school accounts) or `consumers` (personal accounts). The default of
+	// `common` allows any Microsoft account.
+	func(c *Config) error {
+		c.MicrosoftOauthTenant = "common"
+		if val, ok := lookupEnv("MICROSOFT_OAUTH_TENANT"); ok && val != "" {
+			c.MicrosoftOauthTenant = val
+		}
+		return nil
+	},
+
+	// DISCORD_OAUTH_CREDENTIALS is a credential pair in the format `id:secret`. When specified,
+	// AuthN will enable routes for Discord OAuth signin.
+	func(c *Config) error {
+		if val, ok := lookupEnv("DISCORD_OAUTH_CREDENTIALS"); ok {
+			credentials, err := oauth.NewCredentials(val)
+			if err == nil {
+				c.DiscordOauthCredentials = credentials
+			}
+			return err
+		}
+		return nil
+	},
+
+	// TWITTER_OAUTH_CREDENTIALS is a credential pair in the format `id:secret`. When specified,
+	// AuthN will enable routes for Twitter OAuth signin.
+	func(c *Config) error {
+		if val, ok := lookupEnv("TWITTER_OAUTH_CREDENTIALS"); ok {
+			credentials, err := oauth.NewCredentials(val)
+			if err == nil {
+				c.TwitterOauthCredentials = credentials
+			}
+			return err
+		}
+		return nil
+	},
+
+	// LINKEDIN_OAUTH_CREDENTIALS is a credential pair in the format `id:secret`. When specified,
+	// AuthN will enable routes for LinkedIn OAuth signin.
+	func(c *Config) error {
+		if val, ok := lookupEnv("LINKEDIN_OAUTH_CREDENTIALS"); ok {
+			credentials, err := oauth.NewCredentials(val)
+			if err == nil {
+				c.LinkedInOauthCredentials = credentials
+			}
+			return err
+		}
+		return nil
+	},
+
+	// OAUTH_<NAME>_CLIENT_ID, OAUTH_<NAME>_CLIENT_SECRET, OAUTH_<NAME>_DISCOVERY_URL, and the optional
+	// OAUTH_<NAME>_SCOPES configure any OpenID Connect provider. AuthN will enable routes for each
+	// provider under its lowercased name.
+	func(c *Config) error {
+		for _, env := range os.Environ() {
+			match := oauthClientIDPattern.FindStringSubmatch(env)
+			if match == nil {
+				continue
+			}
+			reads.record("OAUTH_"+match[1]+"_CLIENT_ID", match[2], true)
+			prefix := "OAUTH_" + match[1] + "_"
+			name := strings.ToLower(match[1])
+			builtin := map[string]*oauth.Credentials{
+				"google":    c.GoogleOauthCredentials,
+				"github":    c.GitHubOauthCredentials,
+				"facebook":  c.FacebookOauthCredentials,
+				"microsoft": c.MicrosoftOauthCredentials,
+				"discord":   c.DiscordOauthCredentials,
+				"twitter":   c.TwitterOauthCredentials,
+				"linkedin":  c.LinkedInOauthCredentials,
+			}
+			if builtin[name] != nil {
+				return fmt.Errorf("%sCLIENT_ID conflicts with %s_OAUTH_CREDENTIALS", prefix, match[1])
+			}
+
+			secret, ok := lookupEnv(prefix + "CLIENT_SECRET")
+			if !ok {
+				return fmt.Errorf("%sCLIENT_SECRET is required", prefix)
+			}
+			discoveryURL, ok := lookupEnv(prefix + "DISCOVERY_URL")
+			if !ok {
+				return fmt.Errorf("%sDISCOVERY_URL is required", prefix)
+			}
+			if _, err := url.ParseRequestURI(discoveryURL); err != nil {
+				return fmt.Errorf("%sDISCOVERY_URL is not a URL: %v", prefix, err)
+			}
+			scopes := []string{"openid", "email"}
+			if val, ok := lookupEnv(prefix + "SCOPES"); ok {
+				scopes = nil
+				for _, s := range strings.Split(val, ",") {
+					if s = strings.TrimSpace(s); s != "" {
+						scopes = append(scopes, s)
+					}
+				}
+			}
+
+			if c.OauthProviderSettings == nil {
+				c.OauthProviderSettings = map[string]*oauth.GenericSettings{}
+			}
+			c.OauthProviderSettings[name] = &oauth.GenericSettings{
+				Credentials:  oauth.Credentials{ID: match[2], Secret: secret},
+				Scopes:       scopes,
+				DiscoveryURL: discoveryURL,
+			}
+		}
+		return nil
+	},
+
+	// OAUTH_<NAME>_ATTRIBUTES maps profile fields from an OAuth provider into account metadata when
+	// an account signs up through that provider. The format is a comma-separated list of
+	// `metadata_name:profile_field` pairs, e.g. `avatar:picture,locale:locale`.
+	func(c *Config) error {
+		for _, env := range os.Environ() {
+			match := oauthAttributesPattern.FindStringSubmatch(env)
+			if match == nil {
+				continue
+			}
+			reads.record("OAUTH_"+match[1]+"_ATTRIBUTES", match[2], true)
+			name := strings.ToLower(match[1])
+
+			mapping := map[string]string{}
+			for _, pair := range strings.Split(match[2], ",") {
+				if pair = strings.TrimSpace(pair); pair == "" {
+					continue
+				}
+				parts := strings.SplitN(pair, ":", 2)
+				if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+					return fmt.Errorf("OAUTH_%s_ATTRIBUTES has an invalid pair: %s", match[1], pair)
+				}
+				mapping[parts[0]] = parts[1]
+			}
+
+			if c.OauthAttributeMappings == nil {
+				c.OauthAttributeMappings = map[string]map[string]string{}
+			}
+			c.OauthAttributeMappings[name] = mapping
+		}
+		return nil
+	},
 }
 
+var oauthClientIDPattern = regexp.MustCompile(`^OAUTH_([A-Z0-9_]+)_CLIENT_ID=(.*)$`)
+var oauthAttributesPattern = regexp.MustCompile(`^OAUTH_([A-Z0-9_]+)_ATTRIBUTES=(.*)$`)
+
 func ReadEnv() *Config {
-	config, err := configure(configurers)
+	config, err := Check()
 	if err != nil {
 		panic(err)
 	}
@@ -484,7 +2046,50 @@ func ReadEnv() *Config {
 	return config
 }
 
+// Check reads the configuration from ENV and returns any error instead of panicking.
+func Check() (*Config, error) {
+	return configure(configurers)
+}
+
+// StepUpScope reports whether access tokens carry the scope only after a recent second factor.
+func (c *Config) StepUpScope(scope string) bool {
+	return includes(c.StepUpScopes, scope)
+}
+
 // 20k iterations of PBKDF2 HMAC SHA-256
 func derive(base []byte, salt string) []byte {
 	return pbkdf2.Key(base, []byte(salt), 2e4, 128, sha256.New)
 }
+
+func includes(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// readPublicKey loads an RSA public key from a PEM file in either PKIX or PKCS1 format.
+func readPublicKey(path string) (*rsa.PublicKey, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(bytes)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not an RSA public key")
+	}
+	return rsaKey, nil
+}
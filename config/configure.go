@@ -1,10 +1,22 @@
 package config
 
-import "github.com/keratin/authn-server/ops"
+import (
+	"sync"
+
+	"github.com/keratin/authn-server/ops"
+)
 
 type configurer func(c *Config) error
 
+// configureMutex guards reads, so that each configuration records only its own settings.
+var configureMutex sync.Mutex
+
 func configure(fns []configurer) (*Config, error) {
+	configureMutex.Lock()
+	defer configureMutex.Unlock()
+	reads = settingsLog{}
+	defer func() { reads = nil }()
+
 	var err error
 	c := Config{
 		ErrorReporter:     &ops.LogReporter{},
@@ -18,5 +30,6 @@ func configure(fns []configurer) (*Config, error) {
 			return nil, err
 		}
 	}
+	c.Settings = reads.list()
 	return &c, nil
 }
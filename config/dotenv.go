@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+// fileVars are the variables that were set by a .env file, which is extremely useful during
+// development. Like godotenv's autoload, the file never overrides the environment.
+var fileVars = map[string]bool{}
+
+func init() {
+	vars, err := godotenv.Read()
+	if err != nil {
+		return
+	}
+	for name, val := range vars {
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		if os.Setenv(name, val) == nil {
+			fileVars[name] = true
+		}
+	}
+}
@@ -13,16 +13,39 @@ func (name ErrMissingEnvVar) Error() string {
 	return "missing environment variable: " + string(name)
 }
 
+// lookupEnv reads a variable like os.LookupEnv, and records the read in the configuration's
+// Settings.
+func lookupEnv(name string) (string, bool) {
+	val, ok := os.LookupEnv(name)
+	reads.record(name, val, ok)
+	return val, ok
+}
+
+func getEnv(name string) string {
+	val, _ := lookupEnv(name)
+	return val
+}
+
 func requireEnv(name string) (string, error) {
-	if val, ok := os.LookupEnv(name); ok {
+	if val, ok := lookupEnv(name); ok {
 		return val, nil
 	}
 
 	return "", ErrMissingEnvVar(name)
 }
 
+// lookupString returns the variable, or the default when it is not set.
+func lookupString(name string, def string) string {
+	reads.setDefault(name, def)
+	if val, ok := lookupEnv(name); ok {
+		return val
+	}
+	return def
+}
+
 func lookupInt(name string, def int) (int, error) {
-	if val, ok := os.LookupEnv(name); ok {
+	reads.setDefault(name, strconv.Itoa(def))
+	if val, ok := lookupEnv(name); ok {
 		return strconv.Atoi(val)
 	}
 
@@ -30,7 +53,8 @@ func lookupInt(name string, def int) (int, error) {
 }
 
 func lookupBool(name string, def bool) (bool, error) {
-	if val, ok := os.LookupEnv(name); ok {
+	reads.setDefault(name, strconv.FormatBool(def))
+	if val, ok := lookupEnv(name); ok {
 		return regexp.MatchString("^(?i:t|true|yes)$", val)
 	}
 
@@ -38,7 +62,7 @@ func lookupBool(name string, def bool) (bool, error) {
 }
 
 func lookupURL(name string) (*url.URL, error) {
-	if val, ok := os.LookupEnv(name); ok {
+	if val, ok := lookupEnv(name); ok {
 		return url.Parse(val)
 	}
 	return nil, nil
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/keratin/authn-server/lib/compat"
+	"github.com/pkg/errors"
+)
+
+// keyCache remembers keys derived from SECRET_KEY_BASE in an encrypted file, so that a restart
+// performs one derivation instead of one per key. The file is encrypted with a key that is itself
+// derived from the base, so it can not be read without the base, and a cache written for a
+// different base fails to decrypt and is replaced.
+type keyCache struct {
+	base  []byte
+	path  string
+	key   []byte
+	keys  map[string][]byte
+	dirty bool
+}
+
+// newKeyCache reads the cache at path. An empty path disables caching. A cache that is missing or
+// can not be decrypted is treated as empty.
+func newKeyCache(base []byte, path string) *keyCache {
+	kc := &keyCache{base: base, path: path, keys: map[string][]byte{}}
+	if path == "" {
+		return kc
+	}
+
+	kc.key = derive(base, "derived-key-cache-salt")[:32]
+	encrypted, err := ioutil.ReadFile(path)
+	if err != nil {
+		return kc
+	}
+	plaintext, err := compat.Decrypt(encrypted, kc.key)
+	if err != nil {
+		return kc
+	}
+	keys := map[string][]byte{}
+	if json.Unmarshal([]byte(plaintext), &keys) == nil {
+		kc.keys = keys
+	}
+	return kc
+}
+
+// derive returns the key for salt from the cache, or derives and remembers it.
+func (kc *keyCache) derive(salt string) []byte {
+	if key, ok := kc.keys[salt]; ok {
+		return key
+	}
+	key := derive(kc.base, salt)
+	kc.keys[salt] = key
+	kc.dirty = true
+	return key
+}
+
+// save writes the cache when new keys were derived. The file is replaced atomically, and like any
+// temp file it is only readable by the current user.
+func (kc *keyCache) save() error {
+	if kc.path == "" || !kc.dirty {
+		return nil
+	}
+
+	plaintext, err := json.Marshal(kc.keys)
+	if err != nil {
+		return errors.Wrap(err, "Marshal")
+	}
+	encrypted, err := compat.Encrypt(plaintext, kc.key)
+	if err != nil {
+		return errors.Wrap(err, "Encrypt")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(kc.path), filepath.Base(kc.path))
+	if err != nil {
+		return errors.Wrap(err, "TempFile")
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(encrypted)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "Write")
+	}
+	err = os.Rename(tmp.Name(), kc.path)
+	if err != nil {
+		return errors.Wrap(err, "Rename")
+	}
+
+	kc.dirty = false
+	return nil
+}
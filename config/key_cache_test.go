@@ -0,0 +1,55 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "authn-key-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keys")
+
+	t.Run("without a path", func(t *testing.T) {
+		kc := newKeyCache([]byte("base"), "")
+		assert.Equal(t, derive([]byte("base"), "salt"), kc.derive("salt"))
+		require.NoError(t, kc.save())
+	})
+
+	t.Run("caching derived keys", func(t *testing.T) {
+		kc := newKeyCache([]byte("base"), path)
+		key := kc.derive("salt")
+		assert.Equal(t, derive([]byte("base"), "salt"), key)
+		require.NoError(t, kc.save())
+
+		encrypted, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, string(encrypted), string(key))
+
+		cached := newKeyCache([]byte("base"), path)
+		assert.Equal(t, key, cached.keys["salt"])
+		assert.Equal(t, key, cached.derive("salt"))
+		assert.False(t, cached.dirty)
+	})
+
+	t.Run("changing the base", func(t *testing.T) {
+		kc := newKeyCache([]byte("new base"), path)
+		assert.Empty(t, kc.keys)
+		assert.Equal(t, derive([]byte("new base"), "salt"), kc.derive("salt"))
+		require.NoError(t, kc.save())
+
+		assert.Equal(t, derive([]byte("new base"), "salt"), newKeyCache([]byte("new base"), path).keys["salt"])
+	})
+
+	t.Run("corrupt cache", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(path, []byte("garbage"), 0600))
+		kc := newKeyCache([]byte("base"), path)
+		assert.Empty(t, kc.keys)
+	})
+}
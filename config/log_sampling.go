@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LogSampleRule is a LOG_SAMPLING entry that logs a fraction of the successful requests to a
+// route. Requests that fail are always logged.
+type LogSampleRule struct {
+	// Method is empty to match any method.
+	Method string
+	// Path may end with * to match any path with that prefix.
+	Path string
+	Rate float64
+}
+
+// Matches reports whether a request to the path (without the MountedPath) is subject to the rule.
+func (r LogSampleRule) Matches(method string, path string) bool {
+	if r.Method != "" && r.Method != method {
+		return false
+	}
+	if strings.HasSuffix(r.Path, "*") {
+		return strings.HasPrefix(path, strings.TrimSuffix(r.Path, "*"))
+	}
+	return r.Path == path
+}
+
+// ParseLogSampleRule parses a LOG_SAMPLING entry: an optional method, a path, and the rate from 0
+// to 1. For example: GET /health=0.01
+func ParseLogSampleRule(entry string) (LogSampleRule, error) {
+	var rule LogSampleRule
+	kv := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+	if len(kv) != 2 {
+		return rule, fmt.Errorf("expected route=rate, got %s", entry)
+	}
+
+	rate, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return rule, fmt.Errorf("%s: rate must be a number from 0 to 1", kv[0])
+	}
+	rule.Rate = rate
+
+	fields := strings.Fields(kv[0])
+	switch len(fields) {
+	case 1:
+		rule.Path = fields[0]
+	case 2:
+		rule.Method = strings.ToUpper(fields[0])
+		rule.Path = fields[1]
+	default:
+		return rule, fmt.Errorf("expected [METHOD] /path, got %s", kv[0])
+	}
+	if !strings.HasPrefix(rule.Path, "/") {
+		return rule, fmt.Errorf("%s: path must begin with /", kv[0])
+	}
+
+	return rule, nil
+}
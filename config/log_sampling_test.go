@@ -0,0 +1,48 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogSampleRule(t *testing.T) {
+	testCases := []struct {
+		entry string
+		rule  config.LogSampleRule
+	}{
+		{"GET /health=0.01", config.LogSampleRule{Method: "GET", Path: "/health", Rate: 0.01}},
+		{" /session/refresh = 0.5 ", config.LogSampleRule{Path: "/session/refresh", Rate: 0.5}},
+		{"get /debug/*=0", config.LogSampleRule{Method: "GET", Path: "/debug/*", Rate: 0}},
+	}
+	for _, tc := range testCases {
+		rule, err := config.ParseLogSampleRule(tc.entry)
+		require.NoError(t, err, tc.entry)
+		assert.Equal(t, tc.rule, rule, tc.entry)
+	}
+
+	for _, entry := range []string{
+		"/health",
+		"/health=often",
+		"/health=2",
+		"health=0.1",
+		"GET POST /health=0.1",
+	} {
+		_, err := config.ParseLogSampleRule(entry)
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestLogSampleRuleMatches(t *testing.T) {
+	rule := config.LogSampleRule{Method: "GET", Path: "/health"}
+	assert.True(t, rule.Matches("GET", "/health"))
+	assert.False(t, rule.Matches("POST", "/health"))
+	assert.False(t, rule.Matches("GET", "/health/more"))
+
+	rule = config.LogSampleRule{Path: "/debug/*"}
+	assert.True(t, rule.Matches("GET", "/debug/vars"))
+	assert.True(t, rule.Matches("POST", "/debug/pprof/symbol"))
+	assert.False(t, rule.Matches("GET", "/debugger"))
+}
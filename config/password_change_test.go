@@ -0,0 +1,29 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordChangeKeepSession(t *testing.T) {
+	t.Setenv("APP_DOMAINS", "example.com")
+	t.Setenv("AUTHN_URL", "https://authn.example.com")
+	t.Setenv("SECRET_KEY_BASE", "a-long-and-very-secret-key-base")
+	t.Setenv("DATABASE_URL", "sqlite3://localhost/test")
+
+	t.Run("by default", func(t *testing.T) {
+		c, err := config.Check()
+		require.NoError(t, err)
+		assert.False(t, c.PasswordChangeKeepSession)
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		t.Setenv("PASSWORD_CHANGE_KEEP_SESSION", "true")
+		c, err := config.Check()
+		require.NoError(t, err)
+		assert.True(t, c.PasswordChangeKeepSession)
+	})
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultProfileFieldMaxLength limits string fields that do not set max_length.
+const DefaultProfileFieldMaxLength = 255
+
+var profileFieldName = regexp.MustCompile(`\A[a-z][a-z0-9_]*\z`)
+
+// ProfileField is a PROFILE_FIELDS entry: an additional field that users provide at signup or
+// later, validated by AuthN and stored in the account's metadata under its name.
+type ProfileField struct {
+	Name string
+	// Type is "string" or "date" (YYYY-MM-DD).
+	Type string
+	// Required fields must be provided at signup. Other fields may be collected later.
+	Required  bool
+	MaxLength int
+	Pattern   *regexp.Regexp
+	Enum      []string
+}
+
+type profileFieldSchema struct {
+	Type      string   `json:"type"`
+	Required  bool     `json:"required"`
+	MaxLength int      `json:"max_length"`
+	Pattern   string   `json:"pattern"`
+	Enum      []string `json:"enum"`
+}
+
+// ParseProfileFields parses PROFILE_FIELDS: a JSON object from field names to their schemas. For
+// example: {"name": {"required": true, "max_length": 100}, "birthdate": {"type": "date"}}
+//
+// The fields are returned in order of name.
+func ParseProfileFields(val string) ([]ProfileField, error) {
+	schemas := map[string]profileFieldSchema{}
+	decoder := json.NewDecoder(strings.NewReader(val))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&schemas); err != nil {
+		return nil, err
+	}
+
+	fields := make([]ProfileField, 0, len(schemas))
+	for name, schema := range schemas {
+		if !profileFieldName.MatchString(name) {
+			return nil, fmt.Errorf("%s: names must be lowercase letters, numbers, and underscores", name)
+		}
+
+		field := ProfileField{
+			Name:      name,
+			Type:      schema.Type,
+			Required:  schema.Required,
+			MaxLength: schema.MaxLength,
+			Enum:      schema.Enum,
+		}
+		switch field.Type {
+		case "":
+			field.Type = "string"
+		case "string", "date":
+		default:
+			return nil, fmt.Errorf("%s: type must be string or date", name)
+		}
+		if field.MaxLength < 0 {
+			return nil, fmt.Errorf("%s: max_length must be positive", name)
+		} else if field.MaxLength == 0 {
+			field.MaxLength = DefaultProfileFieldMaxLength
+		}
+		if schema.Pattern != "" {
+			pattern, err := regexp.Compile(schema.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			field.Pattern = pattern
+		}
+		if field.Type == "date" && (field.Pattern != nil || len(field.Enum) > 0) {
+			return nil, fmt.Errorf("%s: dates may not have a pattern or enum", name)
+		}
+		fields = append(fields, field)
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields, nil
+}
@@ -0,0 +1,49 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProfileFields(t *testing.T) {
+	t.Run("valid schema", func(t *testing.T) {
+		fields, err := config.ParseProfileFields(`{
+			"name": {"required": true, "max_length": 100},
+			"birthdate": {"type": "date"},
+			"locale": {"pattern": "^[a-z]{2}$", "enum": ["en", "fr"]}
+		}`)
+		require.NoError(t, err)
+		require.Len(t, fields, 3)
+
+		assert.Equal(t, "birthdate", fields[0].Name)
+		assert.Equal(t, "date", fields[0].Type)
+		assert.False(t, fields[0].Required)
+
+		assert.Equal(t, "locale", fields[1].Name)
+		assert.Equal(t, "string", fields[1].Type)
+		assert.Equal(t, config.DefaultProfileFieldMaxLength, fields[1].MaxLength)
+		assert.Equal(t, "^[a-z]{2}$", fields[1].Pattern.String())
+		assert.Equal(t, []string{"en", "fr"}, fields[1].Enum)
+
+		assert.Equal(t, "name", fields[2].Name)
+		assert.True(t, fields[2].Required)
+		assert.Equal(t, 100, fields[2].MaxLength)
+	})
+
+	invalid := []string{
+		`not json`,
+		`{"Name": {}}`,
+		`{"name": {"type": "number"}}`,
+		`{"name": {"max_length": -1}}`,
+		`{"name": {"pattern": "("}}`,
+		`{"name": {"minimum": 1}}`,
+		`{"birthdate": {"type": "date", "enum": ["2000-01-01"]}}`,
+	}
+	for _, val := range invalid {
+		_, err := config.ParseProfileFields(val)
+		assert.Error(t, err, val)
+	}
+}
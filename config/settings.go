@@ -0,0 +1,101 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Sources of a Setting's value.
+const (
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceDefault = "default"
+)
+
+const redacted = "[REDACTED]"
+
+// secretPattern matches the names of variables whose values must never be shown.
+// ALERT_SLACK_URL is listed because the path of a Slack webhook is itself the credential.
+var secretPattern = regexp.MustCompile(`^(SECRET_KEY_BASE|ALERT_SLACK_URL)$|_(SECRET|PASSWORD|PEPPER|PIN|KEY|CREDENTIALS|DSN|ENCRYPTION_KEYS)$`)
+
+// Setting is a variable that the configuration read, with where its value came from. Secrets are
+// masked, as are the passwords of URLs. Default is only known for variables with a simple default.
+type Setting struct {
+	Name    string
+	Value   string
+	Default string
+	Source  string
+}
+
+// settingsLog records the variables read while configuring.
+type settingsLog map[string]*Setting
+
+// reads is only set while configure runs.
+var reads settingsLog
+
+func (l settingsLog) find(name string) *Setting {
+	s, ok := l[name]
+	if !ok {
+		s = &Setting{Name: name}
+		l[name] = s
+	}
+	return s
+}
+
+func (l settingsLog) record(name string, val string, ok bool) {
+	if l == nil {
+		return
+	}
+	s := l.find(name)
+	if ok {
+		s.Value = mask(name, val)
+		s.Source = SourceEnv
+		if fileVars[name] {
+			s.Source = SourceFile
+		}
+	} else if s.Source == "" {
+		s.Value = s.Default
+		s.Source = SourceDefault
+	}
+}
+
+func (l settingsLog) setDefault(name string, def string) {
+	if l == nil {
+		return
+	}
+	l.find(name).Default = mask(name, def)
+}
+
+// list returns the settings in alphabetical order.
+func (l settingsLog) list() []Setting {
+	settings := make([]Setting, 0, len(l))
+	for _, s := range l {
+		settings = append(settings, *s)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Name < settings[j].Name })
+	return settings
+}
+
+func mask(name string, val string) string {
+	if val == "" {
+		return val
+	}
+	if secretPattern.MatchString(name) {
+		return redacted
+	}
+	if strings.Contains(val, "://") {
+		// lists of URLs, such as SESSION_REPLICA_URLS, are masked one by one
+		urls := strings.Split(val, ",")
+		for i, str := range urls {
+			u, err := url.Parse(strings.TrimSpace(str))
+			if err != nil {
+				return redacted
+			}
+			urls[i] = u.Redacted()
+		}
+		return strings.Join(urls, ",")
+	}
+	return val
+}
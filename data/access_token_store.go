@@ -0,0 +1,19 @@
+package data
+
+import "github.com/keratin/authn-server/models"
+
+type AccessTokenStore interface {
+	// Generates a random token and persists its metadata until the token expires.
+	Create(meta *models.AccessToken) (string, error)
+
+	// Finds the metadata for a token, if the token is registered and unexpired. A nil value
+	// indicates that no active token was found.
+	Find(token string) (*models.AccessToken, error)
+
+	// Finds the metadata for many tokens at once, in the same order. A nil value indicates that no
+	// active token was found in that position.
+	FindMany(tokens []string) ([]*models.AccessToken, error)
+
+	// Revokes the token. Doesn't error if the token is unknown or already revoked.
+	Revoke(token string) error
+}
@@ -0,0 +1,33 @@
+package data
+
+import (
+	"github.com/go-redis/redis"
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/data/postgres"
+	dataRedis "github.com/keratin/authn-server/data/redis"
+)
+
+// AccountChanges broadcasts the IDs of changed accounts between AuthN servers, so that each may
+// drop what it remembers about them.
+type AccountChanges interface {
+	Publish(id int) error
+	// Subscribe calls the handler with every published ID, including those published by this
+	// server. It blocks until the subscription fails.
+	Subscribe(handler func(id int)) error
+}
+
+// NewAccountChanges broadcasts through a Redis stream when one is given, or else through PostgreSQL
+// when it holds the accounts, or else through Redis when it is available. Otherwise it returns nil.
+// The db is nil when accounts are not held in SQL.
+func NewAccountChanges(db *sqlx.DB, redis *redis.Client, prefix string, stream *dataRedis.Stream) AccountChanges {
+	if stream != nil {
+		return &dataRedis.StreamAccountChanges{Stream: stream}
+	}
+	if db != nil && db.DriverName() == "postgres" {
+		return &postgres.AccountChanges{DB: db}
+	}
+	if redis != nil {
+		return &dataRedis.AccountChanges{Client: redis, Prefix: prefix}
+	}
+	return nil
+}
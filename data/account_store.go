@@ -1,7 +1,9 @@
 package data
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/keratin/authn-server/data/postgres"
 
@@ -14,6 +16,8 @@ import (
 type AccountStore interface {
 	Create(u string, p []byte) (*models.Account, error)
 	Find(id int) (*models.Account, error)
+	// Returns the accounts with the given IDs in order of ID, skipping IDs that are not found.
+	FindMany(ids []int) ([]*models.Account, error)
 	FindByUsername(u string) (*models.Account, error)
 	FindByOauthAccount(p string, pid string) (*models.Account, error)
 	AddOauthAccount(id int, p string, pid string, tok string) error
@@ -23,7 +27,36 @@ type AccountStore interface {
 	Unlock(id int) error
 	RequireNewPassword(id int) error
 	SetPassword(id int, p []byte) error
+	// Replaces the password hash with an equivalent hash of the same password, without marking the
+	// password as changed.
+	UpgradePassword(id int, p []byte) error
+	// Changes the username and records the change in the account's username history.
 	UpdateUsername(id int, u string) error
+	// Returns the account's username changes, oldest first.
+	GetUsernameHistory(id int) ([]*models.UsernameChange, error)
+	ScheduleArchive(id int, at time.Time) error
+	CancelArchive(id int) error
+	FindScheduledArchives(before time.Time) ([]int, error)
+	SetExpiry(id int, at *time.Time) error
+	FindExpired(before time.Time) ([]int, error)
+	SetThrottledUntil(id int, until *time.Time) error
+	// Places or releases a legal hold, which keeps the account from being archived or purged.
+	SetLegalHold(id int, held bool) error
+	// Sets the ID of the account in another system. External IDs are unique.
+	SetExternalID(id int, externalID string) error
+	FindByExternalID(externalID string) (*models.Account, error)
+	// Keeps the username of an account that is being archived, so that the archive may be undone.
+	SaveArchivedUsername(id int, u []byte) error
+	// Returns the kept username of an archived account, or nil.
+	FindArchivedUsername(id int) ([]byte, error)
+	// Undoes Archive with the given username, and forgets the kept username. The password was
+	// erased by Archive, so a new one is required.
+	Restore(id int, u string) error
+	// Forgets usernames kept before the given time, and returns how many were forgotten.
+	PurgeArchivedUsernames(before time.Time) (int, error)
+	// Increments the account's version only if it still matches, so that concurrent updates may
+	// detect each other. Returns false when the account has moved on.
+	ClaimVersion(id int, version int) (bool, error)
 }
 
 func NewAccountStore(db *sqlx.DB) (AccountStore, error) {
@@ -38,3 +71,25 @@ func NewAccountStore(db *sqlx.DB) (AccountStore, error) {
 		return nil, fmt.Errorf("unsupported driver: %v", db.DriverName())
 	}
 }
+
+// contextAccountStore is an AccountStore that can pass a context to the store that it wraps.
+type contextAccountStore interface {
+	WithContext(ctx context.Context) AccountStore
+}
+
+// accountStoreWithContext returns a copy of the store whose calls are canceled with ctx. A store
+// that can not be canceled is returned as it is.
+func accountStoreWithContext(store AccountStore, ctx context.Context) AccountStore {
+	switch s := store.(type) {
+	case *sqlite3.AccountStore:
+		return s.WithContext(ctx)
+	case *mysql.AccountStore:
+		return s.WithContext(ctx)
+	case *postgres.AccountStore:
+		return s.WithContext(ctx)
+	case contextAccountStore:
+		return s.WithContext(ctx)
+	default:
+		return store
+	}
+}
@@ -0,0 +1,55 @@
+package data
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/data/mysql"
+	"github.com/keratin/authn-server/data/postgres"
+	"github.com/keratin/authn-server/data/sqlite3"
+	"github.com/keratin/authn-server/models"
+)
+
+// AnnotationStore keeps tags and admin notes for accounts, so that support and trust & safety
+// teams may annotate users.
+type AnnotationStore interface {
+	// Adds a tag to the account. Doesn't error if the tag already exists.
+	Tag(accountID int, tag string) error
+
+	// Removes a tag from the account. Doesn't error if the tag is unknown.
+	Untag(accountID int, tag string) error
+
+	// Returns the account's tags in alphabetical order.
+	GetTags(accountID int) ([]string, error)
+
+	// Returns the IDs of all accounts with the tag, in ascending order.
+	FindByTag(tag string) ([]int, error)
+
+	// Adds a note to the account.
+	AddNote(accountID int, body string) (*models.AccountNote, error)
+
+	// Returns the account's notes, oldest first.
+	GetNotes(accountID int) ([]*models.AccountNote, error)
+
+	// Deletes a note from the account. Doesn't error if the note is unknown.
+	DeleteNote(accountID int, noteID int) error
+
+	// Sets metadata on the account, replacing any existing values for the same names.
+	SetMetadata(accountID int, metadata map[string]string) error
+
+	// Returns the account's metadata.
+	GetMetadata(accountID int) (map[string]string, error)
+}
+
+func NewAnnotationStore(db *sqlx.DB) (AnnotationStore, error) {
+	switch db.DriverName() {
+	case "sqlite3":
+		return &sqlite3.AnnotationStore{DB: db}, nil
+	case "mysql":
+		return &mysql.AnnotationStore{DB: db}, nil
+	case "postgres":
+		return &postgres.AnnotationStore{DB: db}, nil
+	default:
+		return nil, fmt.Errorf("unsupported driver: %v", db.DriverName())
+	}
+}
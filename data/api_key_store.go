@@ -0,0 +1,40 @@
+package data
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/data/mysql"
+	"github.com/keratin/authn-server/data/postgres"
+	"github.com/keratin/authn-server/data/sqlite3"
+	"github.com/keratin/authn-server/models"
+)
+
+// APIKeyStore keeps the API keys that authenticate backend services on the private API. Revoked
+// keys are kept for reference.
+type APIKeyStore interface {
+	// Creates a key with space-delimited scopes and the hash of its secret.
+	Create(name string, scopes string, secretHash string) (*models.APIKey, error)
+
+	// Returns the key, or nil.
+	Find(id int) (*models.APIKey, error)
+
+	// Returns every key, oldest first.
+	FindAll() ([]*models.APIKey, error)
+
+	// Revokes the key. Returns false if the key does not exist or was already revoked.
+	Revoke(id int) (bool, error)
+}
+
+func NewAPIKeyStore(db *sqlx.DB) (APIKeyStore, error) {
+	switch db.DriverName() {
+	case "sqlite3":
+		return &sqlite3.APIKeyStore{DB: db}, nil
+	case "mysql":
+		return &mysql.APIKeyStore{DB: db}, nil
+	case "postgres":
+		return &postgres.APIKeyStore{DB: db}, nil
+	default:
+		return nil, fmt.Errorf("unsupported driver: %v", db.DriverName())
+	}
+}
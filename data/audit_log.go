@@ -0,0 +1,43 @@
+package data
+
+import (
+	"time"
+
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/models"
+)
+
+// AuditLog records account events so that they may be reviewed later.
+type AuditLog interface {
+	// Appends an event to the log, chained to the entry before it.
+	Append(e events.Event) error
+
+	// Finds up to limit events, newest first. When accountID is non-zero, only events for that
+	// account are returned. A cursor from a previously returned entry continues after that entry.
+	Find(accountID int, after string, limit int) ([]models.AuditEntry, error)
+
+	// Finds up to limit events after the cursor, oldest first. An empty cursor starts from the
+	// oldest event.
+	FindAfter(after string, limit int) ([]models.AuditEntry, error)
+
+	// Finds up to limit of the oldest events that happened before the time, oldest first. A cursor
+	// from a previously returned entry continues after that entry.
+	FindBefore(before time.Time, after string, limit int) ([]models.AuditEntry, error)
+
+	// Removes entries from the log.
+	Delete(entries []models.AuditEntry) error
+}
+
+// AuditPublisher is an events.Publisher that records every event in an AuditLog.
+type AuditPublisher struct {
+	Log AuditLog
+}
+
+// Publish appends the event to the log. Anonymous events are skipped, since they belong to no
+// account.
+func (p *AuditPublisher) Publish(e events.Event) error {
+	if e.AccountID == 0 {
+		return nil
+	}
+	return p.Log.Append(e)
+}
@@ -6,9 +6,10 @@ import (
 
 	"github.com/go-redis/redis"
 	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/data/dynamodb"
 	dataRedis "github.com/keratin/authn-server/data/redis"
 	"github.com/keratin/authn-server/data/sqlite3"
-	"github.com/keratin/authn-server/ops"
+	"github.com/keratin/authn-server/lib/jobs"
 )
 
 type BlobStore interface {
@@ -19,7 +20,9 @@ type BlobStore interface {
 	WriteNX(name string, blob []byte) (bool, error)
 }
 
-func NewBlobStore(interval time.Duration, redis *redis.Client, db *sqlx.DB, reporter ops.ErrorReporter) (BlobStore, error) {
+// blobLifetimes returns how long keys rotated at the interval should live, and how long a server
+// may take to write one.
+func blobLifetimes(interval time.Duration) (time.Duration, time.Duration) {
 	// the lifetime of a key should be slightly more than two intervals
 	ttl := interval*2 + 10*time.Second
 
@@ -28,6 +31,12 @@ func NewBlobStore(interval time.Duration, redis *redis.Client, db *sqlx.DB, repo
 	// may get the lock and generate a competing key.
 	lockTime := time.Duration(1500) * time.Millisecond
 
+	return ttl, lockTime
+}
+
+func NewBlobStore(interval time.Duration, redis *redis.Client, db *sqlx.DB, scheduler *jobs.Scheduler) (BlobStore, error) {
+	ttl, lockTime := blobLifetimes(interval)
+
 	if redis != nil {
 		return &dataRedis.BlobStore{
 			TTL:      ttl,
@@ -43,9 +52,23 @@ func NewBlobStore(interval time.Duration, redis *redis.Client, db *sqlx.DB, repo
 			LockTime: lockTime,
 			DB:       db,
 		}
-		store.Clean(reporter)
+		scheduler.Add(jobs.Job{
+			Name:     "blobs:clean",
+			Interval: time.Minute,
+			Run:      store.Clean,
+		})
 		return store, nil
 	default:
 		return nil, fmt.Errorf("unsupported driver: %v", db.DriverName())
 	}
 }
+
+// NewDynamoDBBlobStore keeps blobs in DynamoDB, which expires them without a cleaning job.
+func NewDynamoDBBlobStore(interval time.Duration, db *dynamodb.DB) BlobStore {
+	ttl, lockTime := blobLifetimes(interval)
+	return &dynamodb.BlobStore{
+		TTL:      ttl,
+		LockTime: lockTime,
+		DB:       db,
+	}
+}
@@ -0,0 +1,206 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/keratin/authn-server/lib"
+	"github.com/keratin/authn-server/models"
+)
+
+// BreakerAccountStore is an AccountStore that fails fast with lib.ErrCircuitOpen while the
+// database is down, rather than letting every request wait on connection timeouts.
+type BreakerAccountStore struct {
+	AccountStore
+	breaker *lib.CircuitBreaker
+}
+
+// NewBreakerAccountStore wraps an AccountStore with a circuit breaker.
+func NewBreakerAccountStore(store AccountStore, breaker *lib.CircuitBreaker) *BreakerAccountStore {
+	return &BreakerAccountStore{AccountStore: store, breaker: breaker}
+}
+
+// WithContext passes ctx to the wrapped store.
+func (s *BreakerAccountStore) WithContext(ctx context.Context) AccountStore {
+	return &BreakerAccountStore{AccountStore: accountStoreWithContext(s.AccountStore, ctx), breaker: s.breaker}
+}
+
+// uniqueness errors prove that the database is responding, and a canceled call only proves that
+// the request ran out of budget
+func isDatabaseFailure(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return !IsUniquenessError(err)
+}
+
+func (s *BreakerAccountStore) Create(u string, p []byte) (account *models.Account, err error) {
+	err = s.breaker.Do(func() error {
+		account, err = s.AccountStore.Create(u, p)
+		return err
+	}, isDatabaseFailure)
+	return account, err
+}
+
+func (s *BreakerAccountStore) Find(id int) (account *models.Account, err error) {
+	err = s.breaker.Do(func() error {
+		account, err = s.AccountStore.Find(id)
+		return err
+	}, isDatabaseFailure)
+	return account, err
+}
+
+func (s *BreakerAccountStore) FindMany(ids []int) (accounts []*models.Account, err error) {
+	err = s.breaker.Do(func() error {
+		accounts, err = s.AccountStore.FindMany(ids)
+		return err
+	}, isDatabaseFailure)
+	return accounts, err
+}
+
+func (s *BreakerAccountStore) FindByUsername(u string) (account *models.Account, err error) {
+	err = s.breaker.Do(func() error {
+		account, err = s.AccountStore.FindByUsername(u)
+		return err
+	}, isDatabaseFailure)
+	return account, err
+}
+
+func (s *BreakerAccountStore) FindByOauthAccount(p string, pid string) (account *models.Account, err error) {
+	err = s.breaker.Do(func() error {
+		account, err = s.AccountStore.FindByOauthAccount(p, pid)
+		return err
+	}, isDatabaseFailure)
+	return account, err
+}
+
+func (s *BreakerAccountStore) AddOauthAccount(id int, p string, pid string, tok string) error {
+	return s.breaker.Do(func() error {
+		return s.AccountStore.AddOauthAccount(id, p, pid, tok)
+	}, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) GetOauthAccounts(id int) (accounts []*models.OauthAccount, err error) {
+	err = s.breaker.Do(func() error {
+		accounts, err = s.AccountStore.GetOauthAccounts(id)
+		return err
+	}, isDatabaseFailure)
+	return accounts, err
+}
+
+func (s *BreakerAccountStore) Archive(id int) error {
+	return s.breaker.Do(func() error { return s.AccountStore.Archive(id) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) Lock(id int) error {
+	return s.breaker.Do(func() error { return s.AccountStore.Lock(id) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) Unlock(id int) error {
+	return s.breaker.Do(func() error { return s.AccountStore.Unlock(id) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) RequireNewPassword(id int) error {
+	return s.breaker.Do(func() error { return s.AccountStore.RequireNewPassword(id) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) SetPassword(id int, p []byte) error {
+	return s.breaker.Do(func() error { return s.AccountStore.SetPassword(id, p) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) UpgradePassword(id int, p []byte) error {
+	return s.breaker.Do(func() error { return s.AccountStore.UpgradePassword(id, p) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) UpdateUsername(id int, u string) error {
+	return s.breaker.Do(func() error { return s.AccountStore.UpdateUsername(id, u) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) SaveArchivedUsername(id int, u []byte) error {
+	return s.breaker.Do(func() error { return s.AccountStore.SaveArchivedUsername(id, u) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) FindArchivedUsername(id int) (username []byte, err error) {
+	err = s.breaker.Do(func() error {
+		username, err = s.AccountStore.FindArchivedUsername(id)
+		return err
+	}, isDatabaseFailure)
+	return username, err
+}
+
+func (s *BreakerAccountStore) Restore(id int, u string) error {
+	return s.breaker.Do(func() error { return s.AccountStore.Restore(id, u) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) PurgeArchivedUsernames(before time.Time) (count int, err error) {
+	err = s.breaker.Do(func() error {
+		count, err = s.AccountStore.PurgeArchivedUsernames(before)
+		return err
+	}, isDatabaseFailure)
+	return count, err
+}
+
+func (s *BreakerAccountStore) ClaimVersion(id int, version int) (claimed bool, err error) {
+	err = s.breaker.Do(func() error {
+		claimed, err = s.AccountStore.ClaimVersion(id, version)
+		return err
+	}, isDatabaseFailure)
+	return claimed, err
+}
+
+func (s *BreakerAccountStore) GetUsernameHistory(id int) (changes []*models.UsernameChange, err error) {
+	err = s.breaker.Do(func() error {
+		changes, err = s.AccountStore.GetUsernameHistory(id)
+		return err
+	}, isDatabaseFailure)
+	return changes, err
+}
+
+func (s *BreakerAccountStore) ScheduleArchive(id int, at time.Time) error {
+	return s.breaker.Do(func() error { return s.AccountStore.ScheduleArchive(id, at) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) CancelArchive(id int) error {
+	return s.breaker.Do(func() error { return s.AccountStore.CancelArchive(id) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) FindScheduledArchives(before time.Time) (ids []int, err error) {
+	err = s.breaker.Do(func() error {
+		ids, err = s.AccountStore.FindScheduledArchives(before)
+		return err
+	}, isDatabaseFailure)
+	return ids, err
+}
+
+func (s *BreakerAccountStore) SetExpiry(id int, at *time.Time) error {
+	return s.breaker.Do(func() error { return s.AccountStore.SetExpiry(id, at) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) SetLegalHold(id int, held bool) error {
+	return s.breaker.Do(func() error { return s.AccountStore.SetLegalHold(id, held) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) FindExpired(before time.Time) (ids []int, err error) {
+	err = s.breaker.Do(func() error {
+		ids, err = s.AccountStore.FindExpired(before)
+		return err
+	}, isDatabaseFailure)
+	return ids, err
+}
+
+func (s *BreakerAccountStore) SetThrottledUntil(id int, until *time.Time) error {
+	return s.breaker.Do(func() error { return s.AccountStore.SetThrottledUntil(id, until) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) SetExternalID(id int, externalID string) error {
+	return s.breaker.Do(func() error { return s.AccountStore.SetExternalID(id, externalID) }, isDatabaseFailure)
+}
+
+func (s *BreakerAccountStore) FindByExternalID(externalID string) (account *models.Account, err error) {
+	err = s.breaker.Do(func() error {
+		account, err = s.AccountStore.FindByExternalID(externalID)
+		return err
+	}, isDatabaseFailure)
+	return account, err
+}
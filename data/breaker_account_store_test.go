@@ -0,0 +1,39 @@
+package data_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/keratin/authn-server/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakerAccountStore(t *testing.T) {
+	for _, tester := range testers.AccountStoreTesters {
+		breaker := lib.NewCircuitBreaker(1, time.Minute, time.Minute)
+		store := data.NewBreakerAccountStore(mock.NewAccountStore(), breaker)
+		tester(t, store)
+	}
+
+	t.Run("exceeding a budget", func(t *testing.T) {
+		slow := &slowAccountStore{AccountStore: mock.NewAccountStore(), delay: 50 * time.Millisecond}
+		account, err := slow.Create("authn@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		breaker := lib.NewCircuitBreaker(1, time.Minute, time.Minute)
+		store := data.NewBreakerAccountStore(slow, breaker)
+		for i := 0; i < 3; i++ {
+			_, err = data.NewBudgetAccountStore(store, lib.NewBudget(10*time.Millisecond)).Find(account.ID)
+			assert.Equal(t, lib.ErrBudgetExceeded, err)
+		}
+		assert.False(t, breaker.Open())
+
+		found, err := store.Find(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, account.ID, found.ID)
+	})
+}
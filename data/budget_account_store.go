@@ -0,0 +1,277 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/keratin/authn-server/lib"
+	"github.com/keratin/authn-server/models"
+)
+
+// BudgetAccountStore is an AccountStore that charges each call to a request's budget. A call that
+// exceeds the budget is canceled through the wrapped store's context, and returns
+// lib.ErrBudgetExceeded.
+type BudgetAccountStore struct {
+	AccountStore
+	budget *lib.Budget
+}
+
+// NewBudgetAccountStore wraps an AccountStore with a budget. A nil budget returns the store itself.
+func NewBudgetAccountStore(store AccountStore, budget *lib.Budget) AccountStore {
+	if budget == nil {
+		return store
+	}
+	return &BudgetAccountStore{AccountStore: store, budget: budget}
+}
+
+func (s *BudgetAccountStore) Create(u string, p []byte) (*models.Account, error) {
+	var account *models.Account
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		account, err = accountStoreWithContext(s.AccountStore, ctx).Create(u, p)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return nil, err
+	}
+	return account, err
+}
+
+func (s *BudgetAccountStore) Find(id int) (*models.Account, error) {
+	var account *models.Account
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		account, err = accountStoreWithContext(s.AccountStore, ctx).Find(id)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return nil, err
+	}
+	return account, err
+}
+
+func (s *BudgetAccountStore) FindMany(ids []int) ([]*models.Account, error) {
+	var accounts []*models.Account
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		accounts, err = accountStoreWithContext(s.AccountStore, ctx).FindMany(ids)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return nil, err
+	}
+	return accounts, err
+}
+
+func (s *BudgetAccountStore) FindByUsername(u string) (*models.Account, error) {
+	var account *models.Account
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		account, err = accountStoreWithContext(s.AccountStore, ctx).FindByUsername(u)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return nil, err
+	}
+	return account, err
+}
+
+func (s *BudgetAccountStore) FindByOauthAccount(p string, pid string) (*models.Account, error) {
+	var account *models.Account
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		account, err = accountStoreWithContext(s.AccountStore, ctx).FindByOauthAccount(p, pid)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return nil, err
+	}
+	return account, err
+}
+
+func (s *BudgetAccountStore) AddOauthAccount(id int, p string, pid string, tok string) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).AddOauthAccount(id, p, pid, tok)
+	})
+}
+
+func (s *BudgetAccountStore) GetOauthAccounts(id int) ([]*models.OauthAccount, error) {
+	var accounts []*models.OauthAccount
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		accounts, err = accountStoreWithContext(s.AccountStore, ctx).GetOauthAccounts(id)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return nil, err
+	}
+	return accounts, err
+}
+
+func (s *BudgetAccountStore) Archive(id int) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).Archive(id)
+	})
+}
+
+func (s *BudgetAccountStore) Lock(id int) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).Lock(id)
+	})
+}
+
+func (s *BudgetAccountStore) Unlock(id int) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).Unlock(id)
+	})
+}
+
+func (s *BudgetAccountStore) RequireNewPassword(id int) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).RequireNewPassword(id)
+	})
+}
+
+func (s *BudgetAccountStore) SetPassword(id int, p []byte) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).SetPassword(id, p)
+	})
+}
+
+func (s *BudgetAccountStore) UpgradePassword(id int, p []byte) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).UpgradePassword(id, p)
+	})
+}
+
+func (s *BudgetAccountStore) UpdateUsername(id int, u string) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).UpdateUsername(id, u)
+	})
+}
+
+func (s *BudgetAccountStore) GetUsernameHistory(id int) ([]*models.UsernameChange, error) {
+	var changes []*models.UsernameChange
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		changes, err = accountStoreWithContext(s.AccountStore, ctx).GetUsernameHistory(id)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return nil, err
+	}
+	return changes, err
+}
+
+func (s *BudgetAccountStore) ScheduleArchive(id int, at time.Time) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).ScheduleArchive(id, at)
+	})
+}
+
+func (s *BudgetAccountStore) CancelArchive(id int) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).CancelArchive(id)
+	})
+}
+
+func (s *BudgetAccountStore) FindScheduledArchives(before time.Time) ([]int, error) {
+	var ids []int
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		ids, err = accountStoreWithContext(s.AccountStore, ctx).FindScheduledArchives(before)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return nil, err
+	}
+	return ids, err
+}
+
+func (s *BudgetAccountStore) SetExpiry(id int, at *time.Time) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).SetExpiry(id, at)
+	})
+}
+
+func (s *BudgetAccountStore) SetLegalHold(id int, held bool) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).SetLegalHold(id, held)
+	})
+}
+
+func (s *BudgetAccountStore) FindExpired(before time.Time) ([]int, error) {
+	var ids []int
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		ids, err = accountStoreWithContext(s.AccountStore, ctx).FindExpired(before)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return nil, err
+	}
+	return ids, err
+}
+
+func (s *BudgetAccountStore) SetThrottledUntil(id int, until *time.Time) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).SetThrottledUntil(id, until)
+	})
+}
+
+func (s *BudgetAccountStore) SetExternalID(id int, externalID string) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).SetExternalID(id, externalID)
+	})
+}
+
+func (s *BudgetAccountStore) FindByExternalID(externalID string) (*models.Account, error) {
+	var account *models.Account
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		account, err = accountStoreWithContext(s.AccountStore, ctx).FindByExternalID(externalID)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return nil, err
+	}
+	return account, err
+}
+
+func (s *BudgetAccountStore) SaveArchivedUsername(id int, u []byte) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).SaveArchivedUsername(id, u)
+	})
+}
+
+func (s *BudgetAccountStore) FindArchivedUsername(id int) ([]byte, error) {
+	var username []byte
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		username, err = accountStoreWithContext(s.AccountStore, ctx).FindArchivedUsername(id)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return nil, err
+	}
+	return username, err
+}
+
+func (s *BudgetAccountStore) Restore(id int, u string) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return accountStoreWithContext(s.AccountStore, ctx).Restore(id, u)
+	})
+}
+
+func (s *BudgetAccountStore) PurgeArchivedUsernames(before time.Time) (int, error) {
+	var count int
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		count, err = accountStoreWithContext(s.AccountStore, ctx).PurgeArchivedUsernames(before)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return 0, err
+	}
+	return count, err
+}
+
+func (s *BudgetAccountStore) ClaimVersion(id int, version int) (bool, error) {
+	var claimed bool
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		claimed, err = accountStoreWithContext(s.AccountStore, ctx).ClaimVersion(id, version)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return false, err
+	}
+	return claimed, err
+}
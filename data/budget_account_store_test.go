@@ -0,0 +1,74 @@
+package data_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/keratin/authn-server/lib"
+	"github.com/keratin/authn-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowAccountStore takes a while to find accounts, unless its context is canceled first.
+type slowAccountStore struct {
+	data.AccountStore
+	delay time.Duration
+	ctx   context.Context
+}
+
+func (s *slowAccountStore) WithContext(ctx context.Context) data.AccountStore {
+	return &slowAccountStore{AccountStore: s.AccountStore, delay: s.delay, ctx: ctx}
+}
+
+func (s *slowAccountStore) Find(id int) (*models.Account, error) {
+	if s.ctx == nil {
+		time.Sleep(s.delay)
+		return s.AccountStore.Find(id)
+	}
+	select {
+	case <-time.After(s.delay):
+		return s.AccountStore.Find(id)
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+func TestBudgetAccountStore(t *testing.T) {
+	for _, tester := range testers.AccountStoreTesters {
+		store := data.NewBudgetAccountStore(mock.NewAccountStore(), lib.NewBudget(time.Minute))
+		tester(t, store)
+	}
+
+	t.Run("without a budget", func(t *testing.T) {
+		store := mock.NewAccountStore()
+		assert.Equal(t, store, data.NewBudgetAccountStore(store, nil))
+	})
+
+	t.Run("exceeding the budget", func(t *testing.T) {
+		slow := &slowAccountStore{AccountStore: mock.NewAccountStore(), delay: 50 * time.Millisecond}
+		account, err := slow.Create("authn@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		store := data.NewBudgetAccountStore(slow, lib.NewBudget(10*time.Millisecond))
+		start := time.Now()
+		found, err := store.Find(account.ID)
+		assert.Equal(t, lib.ErrBudgetExceeded, err)
+		assert.Nil(t, found)
+		assert.True(t, time.Since(start) < 50*time.Millisecond)
+
+		_, err = store.FindByUsername("authn@keratin.tech")
+		assert.Equal(t, lib.ErrBudgetExceeded, err)
+	})
+}
+
+func TestBudgetRefreshTokenStore(t *testing.T) {
+	for _, tester := range testers.RefreshTokenStoreTesters {
+		store := data.NewBudgetRefreshTokenStore(mock.NewRefreshTokenStore(), lib.NewBudget(time.Minute))
+		tester(t, store)
+	}
+}
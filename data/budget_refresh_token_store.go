@@ -0,0 +1,95 @@
+package data
+
+import (
+	"context"
+
+	"github.com/keratin/authn-server/lib"
+	"github.com/keratin/authn-server/models"
+)
+
+// BudgetRefreshTokenStore is a RefreshTokenStore that charges each call to a request's budget.
+type BudgetRefreshTokenStore struct {
+	RefreshTokenStore
+	budget *lib.Budget
+}
+
+// NewBudgetRefreshTokenStore wraps a RefreshTokenStore with a budget. A nil budget returns the store
+// itself.
+func NewBudgetRefreshTokenStore(store RefreshTokenStore, budget *lib.Budget) RefreshTokenStore {
+	if budget == nil {
+		return store
+	}
+	return &BudgetRefreshTokenStore{RefreshTokenStore: store, budget: budget}
+}
+
+func (s *BudgetRefreshTokenStore) Create(accountID int) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		token, err = refreshTokenStoreWithContext(s.RefreshTokenStore, ctx).Create(accountID)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return "", err
+	}
+	return token, err
+}
+
+func (s *BudgetRefreshTokenStore) CreateLimited(accountID int, max int, evict bool) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		token, err = refreshTokenStoreWithContext(s.RefreshTokenStore, ctx).CreateLimited(accountID, max, evict)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return "", err
+	}
+	return token, err
+}
+
+func (s *BudgetRefreshTokenStore) Find(t models.RefreshToken) (int, error) {
+	var accountID int
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		accountID, err = refreshTokenStoreWithContext(s.RefreshTokenStore, ctx).Find(t)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return 0, err
+	}
+	return accountID, err
+}
+
+func (s *BudgetRefreshTokenStore) FindMany(ts []models.RefreshToken) ([]int, error) {
+	var accountIDs []int
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		accountIDs, err = refreshTokenStoreWithContext(s.RefreshTokenStore, ctx).FindMany(ts)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return nil, err
+	}
+	return accountIDs, err
+}
+
+func (s *BudgetRefreshTokenStore) Touch(t models.RefreshToken, accountID int) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return refreshTokenStoreWithContext(s.RefreshTokenStore, ctx).Touch(t, accountID)
+	})
+}
+
+func (s *BudgetRefreshTokenStore) FindAll(accountID int) ([]models.RefreshToken, error) {
+	var tokens []models.RefreshToken
+	err := s.budget.Do(func(ctx context.Context) (err error) {
+		tokens, err = refreshTokenStoreWithContext(s.RefreshTokenStore, ctx).FindAll(accountID)
+		return err
+	})
+	if err == lib.ErrBudgetExceeded {
+		return nil, err
+	}
+	return tokens, err
+}
+
+func (s *BudgetRefreshTokenStore) Revoke(t models.RefreshToken) error {
+	return s.budget.Do(func(ctx context.Context) error {
+		return refreshTokenStoreWithContext(s.RefreshTokenStore, ctx).Revoke(t)
+	})
+}
@@ -0,0 +1,254 @@
+package data
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/ops"
+	"github.com/pkg/errors"
+)
+
+// CachedAccountStore is an AccountStore that keeps recent results of Find and FindByUsername in
+// memory for a short TTL. This reduces database load from high-traffic lookups, at the cost of
+// serving data that may be up to one TTL stale when changed by another AuthN server.
+//
+// Any change made through this store invalidates the cached account once it is written. A read
+// that was already in flight may have seen the old row, so it is not cached when any account was
+// invalidated while it read. Changes made by other AuthN servers are invalidated as soon as they
+// are heard, once the store is listening.
+type CachedAccountStore struct {
+	AccountStore
+	*accountCache
+}
+
+// accountCache is shared by a CachedAccountStore and its copies from WithContext.
+type accountCache struct {
+	ttl         time.Duration
+	mutex       sync.Mutex
+	byID        map[int]cachedAccount
+	byUsername  map[string]cachedAccount
+	lastSweptAt time.Time
+	// invalidations counts calls to Invalidate and Flush, so that reads may tell whether they raced.
+	invalidations uint64
+	changes       AccountChanges
+	reporter      ops.ErrorReporter
+}
+
+type cachedAccount struct {
+	account   models.Account
+	expiresAt time.Time
+}
+
+// NewCachedAccountStore wraps an AccountStore with a read-through cache.
+func NewCachedAccountStore(store AccountStore, ttl time.Duration) *CachedAccountStore {
+	return &CachedAccountStore{
+		AccountStore: store,
+		accountCache: &accountCache{
+			ttl:         ttl,
+			byID:        map[int]cachedAccount{},
+			byUsername:  map[string]cachedAccount{},
+			lastSweptAt: time.Now(),
+		},
+	}
+}
+
+// WithContext passes ctx to the wrapped store, and shares the cache.
+func (s *CachedAccountStore) WithContext(ctx context.Context) AccountStore {
+	return &CachedAccountStore{
+		AccountStore: accountStoreWithContext(s.AccountStore, ctx),
+		accountCache: s.accountCache,
+	}
+}
+
+// Find returns a cached account by ID, or reads through to the underlying store.
+func (s *CachedAccountStore) Find(id int) (*models.Account, error) {
+	if account := s.read(func() cachedAccount { return s.byID[id] }); account != nil {
+		return account, nil
+	}
+	since := s.generation()
+	account, err := s.AccountStore.Find(id)
+	if err == nil && account != nil {
+		s.write(account, since)
+	}
+	return account, err
+}
+
+// FindByUsername returns a cached account by username, or reads through to the underlying store.
+func (s *CachedAccountStore) FindByUsername(u string) (*models.Account, error) {
+	if account := s.read(func() cachedAccount { return s.byUsername[u] }); account != nil {
+		return account, nil
+	}
+	since := s.generation()
+	account, err := s.AccountStore.FindByUsername(u)
+	if err == nil && account != nil {
+		s.write(account, since)
+	}
+	return account, err
+}
+
+func (s *CachedAccountStore) Archive(id int) error {
+	return s.changed(id, s.AccountStore.Archive(id))
+}
+
+func (s *CachedAccountStore) Lock(id int) error {
+	return s.changed(id, s.AccountStore.Lock(id))
+}
+
+func (s *CachedAccountStore) Unlock(id int) error {
+	return s.changed(id, s.AccountStore.Unlock(id))
+}
+
+func (s *CachedAccountStore) RequireNewPassword(id int) error {
+	return s.changed(id, s.AccountStore.RequireNewPassword(id))
+}
+
+func (s *CachedAccountStore) SetPassword(id int, p []byte) error {
+	return s.changed(id, s.AccountStore.SetPassword(id, p))
+}
+
+func (s *CachedAccountStore) UpgradePassword(id int, p []byte) error {
+	return s.changed(id, s.AccountStore.UpgradePassword(id, p))
+}
+
+func (s *CachedAccountStore) UpdateUsername(id int, u string) error {
+	return s.changed(id, s.AccountStore.UpdateUsername(id, u))
+}
+
+func (s *CachedAccountStore) ScheduleArchive(id int, at time.Time) error {
+	return s.changed(id, s.AccountStore.ScheduleArchive(id, at))
+}
+
+func (s *CachedAccountStore) CancelArchive(id int) error {
+	return s.changed(id, s.AccountStore.CancelArchive(id))
+}
+
+func (s *CachedAccountStore) SetExpiry(id int, at *time.Time) error {
+	return s.changed(id, s.AccountStore.SetExpiry(id, at))
+}
+
+func (s *CachedAccountStore) SetLegalHold(id int, held bool) error {
+	return s.changed(id, s.AccountStore.SetLegalHold(id, held))
+}
+
+func (s *CachedAccountStore) SetThrottledUntil(id int, until *time.Time) error {
+	return s.changed(id, s.AccountStore.SetThrottledUntil(id, until))
+}
+
+// Listen shares changes with other AuthN servers. Every change made through this store will be
+// published, and every published change will be invalidated. When the subscription fails, it is
+// reported and retried after a second, and the whole cache is flushed in case changes were missed.
+func (s *CachedAccountStore) Listen(changes AccountChanges, reporter ops.ErrorReporter) {
+	s.changes = changes
+	s.reporter = reporter
+	go func() {
+		for {
+			err := changes.Subscribe(s.Invalidate)
+			reporter.ReportError(errors.Wrap(err, "Subscribe"))
+			s.Flush()
+			time.Sleep(time.Second)
+		}
+	}()
+}
+
+// changed invalidates an account after a change was attempted, and publishes it when successful.
+// Failing to publish does not fail the change, which has already been made, so it is only
+// reported. Other servers will notice after one TTL.
+func (s *CachedAccountStore) changed(id int, err error) error {
+	s.Invalidate(id)
+	if err == nil && s.changes != nil {
+		if pubErr := s.changes.Publish(id); pubErr != nil {
+			s.reporter.ReportError(errors.Wrap(pubErr, "Publish"))
+		}
+	}
+	return err
+}
+
+// Flush removes every account from the cache.
+func (s *CachedAccountStore) Flush() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.byID = map[int]cachedAccount{}
+	s.byUsername = map[string]cachedAccount{}
+	s.invalidations++
+}
+
+// Invalidate removes an account from the cache.
+func (s *CachedAccountStore) Invalidate(id int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if entry, ok := s.byID[id]; ok {
+		delete(s.byUsername, entry.account.Username)
+	}
+	delete(s.byID, id)
+	s.invalidations++
+}
+
+func (s *CachedAccountStore) generation() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.invalidations
+}
+
+// read returns a copy of the cached account, so that callers may not modify the cache.
+func (s *CachedAccountStore) read(lookup func() cachedAccount) *models.Account {
+	s.mutex.Lock()
+	entry := lookup()
+	s.mutex.Unlock()
+
+	if entry.expiresAt.IsZero() || time.Now().After(entry.expiresAt) {
+		return nil
+	}
+	account := entry.account
+	return &account
+}
+
+// write caches an account that was read since the given generation, unless it may be stale.
+func (s *CachedAccountStore) write(account *models.Account, since uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.invalidations != since {
+		return
+	}
+
+	now := time.Now()
+	entry := cachedAccount{account: *account, expiresAt: now.Add(s.ttl)}
+	s.byID[account.ID] = entry
+	if account.Username != "" {
+		s.byUsername[account.Username] = entry
+	}
+
+	// expired entries are ignored on read, but must be swept out eventually
+	if now.Sub(s.lastSweptAt) > s.ttl {
+		for id, e := range s.byID {
+			if now.After(e.expiresAt) {
+				delete(s.byID, id)
+			}
+		}
+		for u, e := range s.byUsername {
+			if now.After(e.expiresAt) {
+				delete(s.byUsername, u)
+			}
+		}
+		s.lastSweptAt = now
+	}
+}
+
+func (s *CachedAccountStore) SetExternalID(id int, externalID string) error {
+	return s.changed(id, s.AccountStore.SetExternalID(id, externalID))
+}
+
+func (s *CachedAccountStore) Restore(id int, u string) error {
+	return s.changed(id, s.AccountStore.Restore(id, u))
+}
+
+func (s *CachedAccountStore) ClaimVersion(id int, version int) (bool, error) {
+	ok, err := s.AccountStore.ClaimVersion(id, version)
+	if ok {
+		err = s.changed(id, err)
+	}
+	return ok, err
+}
@@ -0,0 +1,144 @@
+package data_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/ops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedAccountStore(t *testing.T) {
+	for _, tester := range testers.AccountStoreTesters {
+		store := data.NewCachedAccountStore(mock.NewAccountStore(), time.Minute)
+		tester(t, store)
+	}
+
+	t.Run("invalidating on update", func(t *testing.T) {
+		store := data.NewCachedAccountStore(mock.NewAccountStore(), time.Minute)
+		account, err := store.Create("authn@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		found, err := store.FindByUsername("authn@keratin.tech")
+		require.NoError(t, err)
+		assert.False(t, found.Locked)
+
+		err = store.Lock(account.ID)
+		require.NoError(t, err)
+
+		found, err = store.FindByUsername("authn@keratin.tech")
+		require.NoError(t, err)
+		assert.True(t, found.Locked)
+		found, err = store.Find(account.ID)
+		require.NoError(t, err)
+		assert.True(t, found.Locked)
+	})
+
+	t.Run("expiring cached accounts", func(t *testing.T) {
+		inner := mock.NewAccountStore()
+		store := data.NewCachedAccountStore(inner, time.Millisecond)
+		account, err := store.Create("authn@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		_, err = store.Find(account.ID)
+		require.NoError(t, err)
+		err = inner.Lock(account.ID)
+		require.NoError(t, err)
+
+		found, err := store.Find(account.ID)
+		require.NoError(t, err)
+		assert.False(t, found.Locked)
+
+		time.Sleep(2 * time.Millisecond)
+		found, err = store.Find(account.ID)
+		require.NoError(t, err)
+		assert.True(t, found.Locked)
+	})
+}
+
+// pausedFinds holds each Find after it has read from the store, until released.
+type pausedFinds struct {
+	data.AccountStore
+	read    chan struct{}
+	release chan struct{}
+}
+
+func (s *pausedFinds) Find(id int) (*models.Account, error) {
+	account, err := s.AccountStore.Find(id)
+	s.read <- struct{}{}
+	<-s.release
+	return account, err
+}
+
+func TestCachedAccountStoreRace(t *testing.T) {
+	inner := mock.NewAccountStore()
+	account, err := inner.Create("authn@keratin.tech", []byte("password"))
+	require.NoError(t, err)
+	paused := &pausedFinds{AccountStore: inner, read: make(chan struct{}), release: make(chan struct{})}
+	store := data.NewCachedAccountStore(paused, time.Minute)
+
+	// a read sees the account before it is locked, and finishes after
+	stale := make(chan *models.Account)
+	go func() {
+		found, _ := store.Find(account.ID)
+		stale <- found
+	}()
+	<-paused.read
+	require.NoError(t, store.Lock(account.ID))
+	close(paused.release)
+	assert.False(t, (<-stale).Locked)
+
+	go func() { <-paused.read }()
+	found, err := store.Find(account.ID)
+	require.NoError(t, err)
+	assert.True(t, found.Locked)
+}
+
+func TestCachedAccountStoreListen(t *testing.T) {
+	inner := mock.NewAccountStore()
+	changes := &mock.AccountChanges{}
+	reporter := &ops.LogReporter{}
+	here := data.NewCachedAccountStore(inner, time.Minute)
+	here.Listen(changes, reporter)
+	there := data.NewCachedAccountStore(inner, time.Minute)
+	there.Listen(changes, reporter)
+	require.Eventually(t, func() bool { return changes.Subscribers() == 2 }, time.Second, time.Millisecond)
+
+	account, err := here.Create("authn@keratin.tech", []byte("password"))
+	require.NoError(t, err)
+	found, err := there.Find(account.ID)
+	require.NoError(t, err)
+	assert.False(t, found.Locked)
+
+	t.Run("invalidating changes from another server", func(t *testing.T) {
+		err := here.Lock(account.ID)
+		require.NoError(t, err)
+
+		found, err := there.Find(account.ID)
+		require.NoError(t, err)
+		assert.True(t, found.Locked)
+		found, err = there.FindByUsername("authn@keratin.tech")
+		require.NoError(t, err)
+		assert.True(t, found.Locked)
+	})
+
+	t.Run("ignoring failed changes", func(t *testing.T) {
+		_, err := there.Find(account.ID)
+		require.NoError(t, err)
+		err = inner.Unlock(account.ID)
+		require.NoError(t, err)
+
+		ok, err := here.ClaimVersion(account.ID, 99)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		found, err := there.Find(account.ID)
+		require.NoError(t, err)
+		assert.True(t, found.Locked)
+	})
+}
@@ -0,0 +1,37 @@
+package data
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/data/mysql"
+	"github.com/keratin/authn-server/data/postgres"
+	"github.com/keratin/authn-server/data/sqlite3"
+	"github.com/keratin/authn-server/models"
+)
+
+// ConsentStore keeps the history of each account's consents. Records are never updated, so that
+// the history shows what was agreed to and when.
+type ConsentStore interface {
+	// Records a consent.
+	Create(accountID int, termsVersion string, marketingOptIn bool, ip string) (*models.Consent, error)
+
+	// Returns the account's most recent consent, or nil.
+	Latest(accountID int) (*models.Consent, error)
+
+	// Returns the account's consents, oldest first.
+	FindAll(accountID int) ([]*models.Consent, error)
+}
+
+func NewConsentStore(db *sqlx.DB) (ConsentStore, error) {
+	switch db.DriverName() {
+	case "sqlite3":
+		return &sqlite3.ConsentStore{DB: db}, nil
+	case "mysql":
+		return &mysql.ConsentStore{DB: db}, nil
+	case "postgres":
+		return &postgres.ConsentStore{DB: db}, nil
+	default:
+		return nil, fmt.Errorf("unsupported driver: %v", db.DriverName())
+	}
+}
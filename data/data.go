@@ -1,18 +1,22 @@
 package data
 
 import (
+	"context"
 	"fmt"
 	"net/url"
-
-	"github.com/lib/pq"
+	"strings"
 
 	my "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/data/dynamodb"
 	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/data/mongodb"
 	"github.com/keratin/authn-server/data/mysql"
 	"github.com/keratin/authn-server/data/postgres"
 	"github.com/keratin/authn-server/data/sqlite3"
 	sq3 "github.com/mattn/go-sqlite3"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func NewDB(url *url.URL) (*sqlx.DB, error) {
@@ -37,8 +41,7 @@ func MigrateDB(url *url.URL) error {
 		}
 		defer db.Close()
 
-		sqlite3.MigrateDB(db)
-		return nil
+		return sqlite3.MigrateDB(db)
 	case "mysql":
 		db, err := mysql.NewDB(url)
 		if err != nil {
@@ -46,16 +49,27 @@ func MigrateDB(url *url.URL) error {
 		}
 		defer db.Close()
 
-		mysql.MigrateDB(db)
-		return nil
+		return mysql.MigrateDB(db)
 	case "postgresql", "postgres":
 		db, err := postgres.NewDB(url)
 		if err != nil {
 			return err
 		}
 		defer db.Close()
-		postgres.MigrateDB(db)
-		return nil
+		return postgres.MigrateDB(db)
+	case "mongodb":
+		db, err := mongodb.NewDB(url)
+		if err != nil {
+			return err
+		}
+		defer db.Client().Disconnect(context.Background())
+		return mongodb.MigrateDB(db)
+	case "dynamodb":
+		db, err := dynamodb.NewDB(url)
+		if err != nil {
+			return err
+		}
+		return dynamodb.MigrateDB(db)
 	default:
 		return fmt.Errorf("Unsupported database")
 	}
@@ -67,11 +81,14 @@ func IsUniquenessError(err error) bool {
 		return i.ExtendedCode == sq3.ErrConstraintUnique
 	case *my.MySQLError:
 		return i.Number == 1062
-	case *pq.Error:
-		return i.Code.Class().Name() == "integrity_constraint_violation"
+	case *pgconn.PgError:
+		// class 23: integrity_constraint_violation
+		return strings.HasPrefix(i.Code, "23")
 	case mock.Error:
 		return i.Code == mock.ErrNotUnique
+	case dynamodb.UniquenessError:
+		return true
 	default:
-		return false
+		return mongo.IsDuplicateKeyError(err)
 	}
 }
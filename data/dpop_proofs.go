@@ -0,0 +1,47 @@
+package data
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	dataRedis "github.com/keratin/authn-server/data/redis"
+	"github.com/keratin/authn-server/tokens/dpop"
+)
+
+// NewDPoPProofs remembers accepted DPoP proofs in Redis when it is available, so that a proof can
+// not be replayed on any server. Otherwise proofs are remembered by this server only.
+func NewDPoPProofs(redis *redis.Client) dpop.Proofs {
+	if redis == nil {
+		return &memoryDPoPProofs{expires: map[string]time.Time{}, lastSweptAt: time.Now()}
+	}
+	return &dataRedis.DPoPProofs{Client: redis}
+}
+
+type memoryDPoPProofs struct {
+	mutex       sync.Mutex
+	expires     map[string]time.Time
+	lastSweptAt time.Time
+}
+
+func (p *memoryDPoPProofs) Use(key string, ttl time.Duration) (bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	if expires, ok := p.expires[key]; ok && now.Before(expires) {
+		return false, nil
+	}
+	p.expires[key] = now.Add(ttl)
+
+	// expired proofs are ignored, but must be swept out eventually
+	if now.Sub(p.lastSweptAt) > ttl {
+		for k, expires := range p.expires {
+			if !now.Before(expires) {
+				delete(p.expires, k)
+			}
+		}
+		p.lastSweptAt = now
+	}
+	return true, nil
+}
@@ -0,0 +1,33 @@
+package data_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDPoPProofs(t *testing.T) {
+	t.Run("in memory", func(t *testing.T) {
+		proofs := data.NewDPoPProofs(nil)
+
+		ok, err := proofs.Use("jkt:jti", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = proofs.Use("jkt:jti", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		ok, err = proofs.Use("jkt:other", 10*time.Millisecond)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		time.Sleep(20 * time.Millisecond)
+		ok, err = proofs.Use("jkt:other", 10*time.Millisecond)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+}
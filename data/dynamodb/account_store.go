@@ -0,0 +1,539 @@
+package dynamodb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/keratin/authn-server/models"
+)
+
+// UniquenessError is returned when a write would duplicate a username, external ID, or OAuth
+// identity. DynamoDB has no unique indexes, so each unique value is claimed by an item of its own.
+type UniquenessError struct {
+	Key string
+}
+
+func (e UniquenessError) Error() string {
+	return fmt.Sprintf("not unique: %s", e.Key)
+}
+
+// AccountStore keeps an account and everything about it in the account's partition, and claims
+// unique values with items in partitions of their own.
+type AccountStore struct {
+	*DB
+}
+
+func accountPK(id int) string {
+	return "account#" + strconv.Itoa(id)
+}
+
+func accountKey(id int) item {
+	return key(accountPK(id), "account")
+}
+
+// usernameKey folds case, so that usernames are unique and found regardless of case.
+func usernameKey(u string) item {
+	return key("username#"+strings.ToLower(u), "username")
+}
+
+func externalIDKey(externalID string) item {
+	return key("external_id#"+externalID, "external_id")
+}
+
+func oauthKey(provider string, providerID string) item {
+	return key("oauth#"+provider+"#"+providerID, "oauth")
+}
+
+// claim is an item that reserves a unique value for an account.
+func claim(k item, accountID int) item {
+	k["account_id"] = num(int64(accountID))
+	return k
+}
+
+// listed puts an item on a list, in order of time and then ID.
+func listed(i item, list string, at time.Time, id int) item {
+	i["list"] = str(list)
+	i["order"] = str(at.UTC().Format(timeFormat) + "#" + paddedID(id))
+	i["account_id"] = num(int64(id))
+	return i
+}
+
+func accountFromItem(i item) *models.Account {
+	account := &models.Account{
+		ID:                 i.int("id"),
+		Username:           i.str("username"),
+		Password:           i.bytes("password"),
+		Locked:             i.bool("locked"),
+		RequireNewPassword: i.bool("require_new_password"),
+		PasswordChangedAt:  i.time("password_changed_at"),
+		CreatedAt:          i.time("created_at"),
+		UpdatedAt:          i.time("updated_at"),
+		DeletedAt:          i.optionalTime("deleted_at"),
+		ArchiveAt:          i.optionalTime("archive_at"),
+		ExpiresAt:          i.optionalTime("expires_at"),
+		ThrottledUntil:     i.optionalTime("throttled_until"),
+		LegalHold:          i.bool("legal_hold"),
+		Version:            i.int("lock_version"),
+	}
+	if _, ok := i["external_id"]; ok {
+		externalID := i.str("external_id")
+		account.ExternalID = &externalID
+	}
+	return account
+}
+
+// findClaimed follows a claim to its account.
+func (db *AccountStore) findClaimed(k item) (*models.Account, error) {
+	c, err := db.get(k)
+	if c == nil || err != nil {
+		return nil, err
+	}
+	i, err := db.get(accountKey(c.int("account_id")))
+	if i == nil || err != nil {
+		return nil, err
+	}
+	return accountFromItem(i), nil
+}
+
+func (db *AccountStore) Find(id int) (*models.Account, error) {
+	i, err := db.get(accountKey(id))
+	if i == nil || err != nil {
+		return nil, err
+	}
+	account := accountFromItem(i)
+	if account.DeletedAt != nil {
+		account.Username = ""
+	}
+	return account, nil
+}
+
+// FindMany reads the accounts in batches, since each account is kept in its own partition.
+func (db *AccountStore) FindMany(ids []int) ([]*models.Account, error) {
+	// a batch may not repeat a key
+	keys := []item{}
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			keys = append(keys, accountKey(id))
+		}
+	}
+	items, err := db.getMany(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]*models.Account, len(items))
+	for i, found := range items {
+		accounts[i] = accountFromItem(found)
+		if accounts[i].DeletedAt != nil {
+			accounts[i].Username = ""
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+	return accounts, nil
+}
+
+func (db *AccountStore) FindByUsername(u string) (*models.Account, error) {
+	account, err := db.findClaimed(usernameKey(u))
+	if account == nil || account.DeletedAt != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (db *AccountStore) FindByOauthAccount(provider string, providerID string) (*models.Account, error) {
+	return db.findClaimed(oauthKey(provider, providerID))
+}
+
+func (db *AccountStore) Create(u string, p []byte) (*models.Account, error) {
+	id, err := db.nextID("accounts")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	account := &models.Account{
+		ID:                id,
+		Username:          u,
+		Password:          p,
+		PasswordChangedAt: now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	i := accountKey(id)
+	i["id"] = num(int64(id))
+	i["username"] = str(u)
+	i["password"] = bin(p)
+	i["locked"] = boolean(false)
+	i["require_new_password"] = boolean(false)
+	i["password_changed_at"] = timestamp(now)
+	i["created_at"] = timestamp(now)
+	i["updated_at"] = timestamp(now)
+	i["lock_version"] = num(0)
+
+	ok, err := db.transact(putNew(i), putNew(claim(usernameKey(u), id)))
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, UniquenessError{"username"}
+	}
+	return account, nil
+}
+
+// AddOauthAccount allows one identity per provider for each account, and one account for each
+// identity.
+func (db *AccountStore) AddOauthAccount(accountID int, provider string, providerID string, accessToken string) error {
+	id, err := db.nextID("oauth_accounts")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	i := key(accountPK(accountID), "oauth#"+provider)
+	i["id"] = num(int64(id))
+	i["provider"] = str(provider)
+	i["provider_id"] = str(providerID)
+	i["access_token"] = str(accessToken)
+	i["created_at"] = timestamp(now)
+	i["updated_at"] = timestamp(now)
+
+	ok, err := db.transact(putNew(i), putNew(claim(oauthKey(provider, providerID), accountID)))
+	if err != nil {
+		return err
+	} else if !ok {
+		return UniquenessError{"oauth"}
+	}
+	return nil
+}
+
+func (db *AccountStore) GetOauthAccounts(accountID int) ([]*models.OauthAccount, error) {
+	accounts := []*models.OauthAccount{}
+	items, err := db.query(accountPK(accountID), "oauth#", true, 0)
+	if err != nil {
+		return accounts, err
+	}
+	for _, i := range items {
+		accounts = append(accounts, &models.OauthAccount{
+			ID:          i.int("id"),
+			AccountID:   accountID,
+			Provider:    i.str("provider"),
+			ProviderID:  i.str("provider_id"),
+			AccessToken: i.str("access_token"),
+			CreatedAt:   i.time("created_at"),
+			UpdatedAt:   i.time("updated_at"),
+		})
+	}
+	return accounts, nil
+}
+
+func (db *AccountStore) Archive(id int) error {
+	i, err := db.get(accountKey(id))
+	if i == nil || err != nil {
+		return err
+	}
+	identities, err := db.query(accountPK(id), "oauth#", true, 0)
+	if err != nil {
+		return err
+	}
+	placeholder := make([]byte, 16)
+	if _, err := rand.Read(placeholder); err != nil {
+		return err
+	}
+
+	writes := []types.TransactWriteItem{
+		updateExisting(accountKey(id), map[string]types.AttributeValue{
+			"username":   str("@" + hex.EncodeToString(placeholder)),
+			"password":   bin(nil),
+			"deleted_at": timestamp(time.Now()),
+		}),
+		remove(usernameKey(i.str("username"))),
+		remove(key(accountPK(id), "list#archive")),
+		remove(key(accountPK(id), "list#expire")),
+	}
+	for _, identity := range identities {
+		writes = append(writes,
+			remove(key(accountPK(id), identity.str("sk"))),
+			remove(oauthKey(identity.str("provider"), identity.str("provider_id"))),
+		)
+	}
+	_, err = db.transact(writes...)
+	return err
+}
+
+func (db *AccountStore) Lock(id int) error {
+	return db.update(accountKey(id), map[string]types.AttributeValue{
+		"locked":     boolean(true),
+		"updated_at": timestamp(time.Now()),
+	})
+}
+
+func (db *AccountStore) Unlock(id int) error {
+	return db.update(accountKey(id), map[string]types.AttributeValue{
+		"locked":     boolean(false),
+		"updated_at": timestamp(time.Now()),
+	})
+}
+
+func (db *AccountStore) RequireNewPassword(id int) error {
+	return db.update(accountKey(id), map[string]types.AttributeValue{
+		"require_new_password": boolean(true),
+		"updated_at":           timestamp(time.Now()),
+	})
+}
+
+func (db *AccountStore) SetPassword(id int, p []byte) error {
+	return db.update(accountKey(id), map[string]types.AttributeValue{
+		"password":             bin(p),
+		"require_new_password": boolean(false),
+		"password_changed_at":  timestamp(time.Now()),
+		"updated_at":           timestamp(time.Now()),
+	})
+}
+
+func (db *AccountStore) UpgradePassword(id int, p []byte) error {
+	return db.update(accountKey(id), map[string]types.AttributeValue{
+		"password":   bin(p),
+		"updated_at": timestamp(time.Now()),
+	})
+}
+
+func (db *AccountStore) UpdateUsername(id int, u string) error {
+	i, err := db.get(accountKey(id))
+	if i == nil || err != nil {
+		return err
+	}
+	from := i.str("username")
+	if from == u {
+		return nil
+	}
+	changeID, err := db.nextID("username_changes")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	change := key(accountPK(id), "username_change#"+paddedID(changeID))
+	change["id"] = num(int64(changeID))
+	change["from_username"] = str(from)
+	change["to_username"] = str(u)
+	change["created_at"] = timestamp(now)
+
+	writes := []types.TransactWriteItem{
+		updateExisting(accountKey(id), map[string]types.AttributeValue{
+			"username":   str(u),
+			"updated_at": timestamp(now),
+		}),
+		putNew(change),
+	}
+	// a change of case alone keeps the same claim
+	if strings.ToLower(from) != strings.ToLower(u) {
+		writes = append(writes, putNew(claim(usernameKey(u), id)), remove(usernameKey(from)))
+	}
+	ok, err := db.transact(writes...)
+	if err != nil {
+		return err
+	} else if !ok {
+		return UniquenessError{"username"}
+	}
+	return nil
+}
+
+func (db *AccountStore) GetUsernameHistory(id int) ([]*models.UsernameChange, error) {
+	changes := []*models.UsernameChange{}
+	items, err := db.query(accountPK(id), "username_change#", true, 0)
+	if err != nil {
+		return changes, err
+	}
+	for _, i := range items {
+		changes = append(changes, &models.UsernameChange{
+			ID:        i.int("id"),
+			AccountID: id,
+			From:      i.str("from_username"),
+			To:        i.str("to_username"),
+			CreatedAt: i.time("created_at"),
+		})
+	}
+	return changes, nil
+}
+
+// schedule sets a time on the account, and lists the account under that time so that it may be
+// found when the time comes. A nil time unlists the account.
+func (db *AccountStore) schedule(id int, attribute string, list string, at *time.Time) error {
+	listKey := key(accountPK(id), "list#"+list)
+	listing := remove(listKey)
+	if at != nil {
+		listing = putAny(listed(listKey, list, *at, id))
+	}
+	_, err := db.transact(
+		updateExisting(accountKey(id), map[string]types.AttributeValue{
+			attribute:    optionalTime(at),
+			"updated_at": timestamp(time.Now()),
+		}),
+		listing,
+	)
+	return err
+}
+
+// findListed returns the IDs of accounts listed at or before the time, in ascending order.
+func (db *AccountStore) findListed(list string, before time.Time) ([]int, error) {
+	items, err := db.queryList(list, "0", before.UTC().Format(timeFormat)+"#~")
+	if err != nil {
+		return nil, err
+	}
+	ids := []int{}
+	for _, i := range items {
+		ids = append(ids, i.int("account_id"))
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (db *AccountStore) ScheduleArchive(id int, at time.Time) error {
+	return db.schedule(id, "archive_at", "archive", &at)
+}
+
+func (db *AccountStore) CancelArchive(id int) error {
+	return db.schedule(id, "archive_at", "archive", nil)
+}
+
+// FindScheduledArchives does not find archived accounts, since Archive unlists them.
+func (db *AccountStore) FindScheduledArchives(before time.Time) ([]int, error) {
+	return db.findListed("archive", before)
+}
+
+func (db *AccountStore) SetExpiry(id int, at *time.Time) error {
+	return db.schedule(id, "expires_at", "expire", at)
+}
+
+func (db *AccountStore) FindExpired(before time.Time) ([]int, error) {
+	return db.findListed("expire", before)
+}
+
+func (db *AccountStore) SetThrottledUntil(id int, until *time.Time) error {
+	return db.update(accountKey(id), map[string]types.AttributeValue{
+		"throttled_until": optionalTime(until),
+		"updated_at":      timestamp(time.Now()),
+	})
+}
+
+func (db *AccountStore) SetLegalHold(id int, held bool) error {
+	return db.update(accountKey(id), map[string]types.AttributeValue{
+		"legal_hold": boolean(held),
+		"updated_at": timestamp(time.Now()),
+	})
+}
+
+func (db *AccountStore) SetExternalID(id int, externalID string) error {
+	i, err := db.get(accountKey(id))
+	if i == nil || err != nil {
+		return err
+	}
+	_, hadExternalID := i["external_id"]
+	from := i.str("external_id")
+	if hadExternalID && from == externalID {
+		return nil
+	}
+
+	writes := []types.TransactWriteItem{
+		updateExisting(accountKey(id), map[string]types.AttributeValue{
+			"external_id": str(externalID),
+			"updated_at":  timestamp(time.Now()),
+		}),
+		putNew(claim(externalIDKey(externalID), id)),
+	}
+	if hadExternalID {
+		writes = append(writes, remove(externalIDKey(from)))
+	}
+	ok, err := db.transact(writes...)
+	if err != nil {
+		return err
+	} else if !ok {
+		return UniquenessError{"external_id"}
+	}
+	return nil
+}
+
+func (db *AccountStore) FindByExternalID(externalID string) (*models.Account, error) {
+	return db.findClaimed(externalIDKey(externalID))
+}
+
+func (db *AccountStore) SaveArchivedUsername(id int, u []byte) error {
+	i := listed(key(accountPK(id), "archived_username"), "archived_username", time.Now(), id)
+	i["username"] = bin(u)
+	return db.put(i)
+}
+
+func (db *AccountStore) FindArchivedUsername(id int) ([]byte, error) {
+	i, err := db.get(key(accountPK(id), "archived_username"))
+	if i == nil || err != nil {
+		return nil, err
+	}
+	return i.bytes("username"), nil
+}
+
+func (db *AccountStore) Restore(id int, u string) error {
+	ok, err := db.transact(
+		updateExisting(accountKey(id), map[string]types.AttributeValue{
+			"username":             str(u),
+			"deleted_at":           nil,
+			"archive_at":           nil,
+			"require_new_password": boolean(true),
+			"updated_at":           timestamp(time.Now()),
+		}),
+		putNew(claim(usernameKey(u), id)),
+		remove(key(accountPK(id), "archived_username")),
+	)
+	if err != nil {
+		return err
+	} else if !ok {
+		return UniquenessError{"username"}
+	}
+	return nil
+}
+
+func (db *AccountStore) PurgeArchivedUsernames(before time.Time) (int, error) {
+	items, err := db.queryList("archived_username", "0", before.UTC().Format(timeFormat))
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, i := range items {
+		account, err := db.Find(i.int("account_id"))
+		if err != nil {
+			return count, err
+		}
+		if account != nil && account.LegalHold {
+			continue
+		}
+		if err := db.delete(key(i.str("pk"), i.str("sk"))); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (db *AccountStore) ClaimVersion(id int, version int) (bool, error) {
+	_, err := db.Client.UpdateItem(context.Background(), &ddb.UpdateItemInput{
+		TableName:                 aws.String(db.Table),
+		Key:                       accountKey(id),
+		UpdateExpression:          aws.String("SET #version = #version + :one"),
+		ConditionExpression:       aws.String("#version = :version"),
+		ExpressionAttributeNames:  map[string]string{"#version": "lock_version"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":one": num(1), ":version": num(int64(version))},
+	})
+	if isConditionFailed(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,70 @@
+package dynamodb_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/keratin/authn-server/data/dynamodb"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDB() (*dynamodb.DB, error) {
+	str, ok := os.LookupEnv("TEST_DYNAMODB_URL")
+	if !ok {
+		return nil, fmt.Errorf("set TEST_DYNAMODB_URL for DynamoDB tests")
+	}
+
+	dbURL, err := url.Parse(str)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := dynamodb.NewDB(dbURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewDB")
+	}
+
+	if err := dynamodb.MigrateDB(db); err != nil {
+		return nil, errors.Wrap(err, "MigrateDB")
+	}
+
+	return db, nil
+}
+
+// deleteAll empties the table without dropping it.
+func deleteAll(db *dynamodb.DB) {
+	paginator := ddb.NewScanPaginator(db.Client, &ddb.ScanInput{
+		TableName:            aws.String(db.Table),
+		ProjectionExpression: aws.String("pk, sk"),
+	})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return
+		}
+		for _, i := range out.Items {
+			db.Client.DeleteItem(context.Background(), &ddb.DeleteItemInput{
+				TableName: aws.String(db.Table),
+				Key:       map[string]types.AttributeValue{"pk": i["pk"], "sk": i["sk"]},
+			})
+		}
+	}
+}
+
+func TestAccountStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &dynamodb.AccountStore{db}
+	for _, tester := range testers.AccountStoreTesters {
+		deleteAll(db)
+		tester(t, store)
+	}
+}
@@ -0,0 +1,132 @@
+package dynamodb
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/keratin/authn-server/models"
+)
+
+// AnnotationStore keeps tags, notes, and metadata in the account's partition. Tags are also listed
+// by name, so that accounts may be found by tag.
+type AnnotationStore struct {
+	*DB
+}
+
+func tagKey(accountID int, tag string) item {
+	return key(accountPK(accountID), "tag#"+tag)
+}
+
+func noteKey(accountID int, noteID int) item {
+	return key(accountPK(accountID), "note#"+paddedID(noteID))
+}
+
+func (db *AnnotationStore) Tag(accountID int, tag string) error {
+	i := tagKey(accountID, tag)
+	i["tag"] = str(tag)
+	i["list"] = str("tag#" + tag)
+	i["order"] = str(paddedID(accountID))
+	i["account_id"] = num(int64(accountID))
+	return db.put(i)
+}
+
+func (db *AnnotationStore) Untag(accountID int, tag string) error {
+	return db.delete(tagKey(accountID, tag))
+}
+
+func (db *AnnotationStore) GetTags(accountID int) ([]string, error) {
+	tags := []string{}
+	items, err := db.query(accountPK(accountID), "tag#", true, 0)
+	if err != nil {
+		return tags, err
+	}
+	for _, i := range items {
+		tags = append(tags, strings.TrimPrefix(i.str("sk"), "tag#"))
+	}
+	return tags, nil
+}
+
+func (db *AnnotationStore) FindByTag(tag string) ([]int, error) {
+	ids := []int{}
+	items, err := db.queryList("tag#"+tag, "0", "~")
+	if err != nil {
+		return ids, err
+	}
+	for _, i := range items {
+		ids = append(ids, i.int("account_id"))
+	}
+	return ids, nil
+}
+
+func (db *AnnotationStore) AddNote(accountID int, body string) (*models.AccountNote, error) {
+	id, err := db.nextID("account_notes")
+	if err != nil {
+		return nil, err
+	}
+
+	note := &models.AccountNote{ID: id, AccountID: accountID, Body: body, CreatedAt: time.Now()}
+	i := noteKey(accountID, id)
+	i["id"] = num(int64(id))
+	i["body"] = str(body)
+	i["created_at"] = timestamp(note.CreatedAt)
+	if err := db.put(i); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (db *AnnotationStore) GetNotes(accountID int) ([]*models.AccountNote, error) {
+	notes := []*models.AccountNote{}
+	items, err := db.query(accountPK(accountID), "note#", true, 0)
+	if err != nil {
+		return notes, err
+	}
+	for _, i := range items {
+		notes = append(notes, &models.AccountNote{
+			ID:        i.int("id"),
+			AccountID: accountID,
+			Body:      i.str("body"),
+			CreatedAt: i.time("created_at"),
+		})
+	}
+	return notes, nil
+}
+
+func (db *AnnotationStore) DeleteNote(accountID int, noteID int) error {
+	return db.delete(noteKey(accountID, noteID))
+}
+
+// SetMetadata writes in transactions of up to 100 names, which is as many as DynamoDB allows.
+func (db *AnnotationStore) SetMetadata(accountID int, metadata map[string]string) error {
+	writes := []types.TransactWriteItem{}
+	for name, value := range metadata {
+		i := key(accountPK(accountID), "metadata#"+name)
+		i["name"] = str(name)
+		i["value"] = str(value)
+		writes = append(writes, putAny(i))
+	}
+	for len(writes) > 0 {
+		n := len(writes)
+		if n > 100 {
+			n = 100
+		}
+		if _, err := db.transact(writes[:n]...); err != nil {
+			return err
+		}
+		writes = writes[n:]
+	}
+	return nil
+}
+
+func (db *AnnotationStore) GetMetadata(accountID int) (map[string]string, error) {
+	items, err := db.query(accountPK(accountID), "metadata#", true, 0)
+	if err != nil {
+		return nil, err
+	}
+	metadata := map[string]string{}
+	for _, i := range items {
+		metadata[i.str("name")] = i.str("value")
+	}
+	return metadata, nil
+}
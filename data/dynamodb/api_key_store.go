@@ -0,0 +1,86 @@
+package dynamodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/keratin/authn-server/models"
+)
+
+// APIKeyStore keeps every key in one partition. There are few keys, and a partition may be read
+// consistently.
+type APIKeyStore struct {
+	*DB
+}
+
+const apiKeysPK = "api_keys"
+
+func apiKeyKey(id int) item {
+	return key(apiKeysPK, "api_key#"+paddedID(id))
+}
+
+func apiKeyFromItem(i item) *models.APIKey {
+	return &models.APIKey{
+		ID:         i.int("id"),
+		Name:       i.str("name"),
+		Scopes:     i.str("scopes"),
+		SecretHash: i.str("secret_hash"),
+		CreatedAt:  i.time("created_at"),
+		RevokedAt:  i.optionalTime("revoked_at"),
+	}
+}
+
+func (db *APIKeyStore) Create(name string, scopes string, secretHash string) (*models.APIKey, error) {
+	id, err := db.nextID("api_keys")
+	if err != nil {
+		return nil, err
+	}
+
+	i := apiKeyKey(id)
+	i["id"] = num(int64(id))
+	i["name"] = str(name)
+	i["scopes"] = str(scopes)
+	i["secret_hash"] = str(secretHash)
+	i["created_at"] = timestamp(time.Now())
+	if err := db.put(i); err != nil {
+		return nil, err
+	}
+	return apiKeyFromItem(i), nil
+}
+
+func (db *APIKeyStore) Find(id int) (*models.APIKey, error) {
+	i, err := db.get(apiKeyKey(id))
+	if i == nil || err != nil {
+		return nil, err
+	}
+	return apiKeyFromItem(i), nil
+}
+
+func (db *APIKeyStore) FindAll() ([]*models.APIKey, error) {
+	items, err := db.query(apiKeysPK, "api_key#", true, 0)
+	if err != nil {
+		return nil, err
+	}
+	keys := []*models.APIKey{}
+	for _, i := range items {
+		keys = append(keys, apiKeyFromItem(i))
+	}
+	return keys, nil
+}
+
+func (db *APIKeyStore) Revoke(id int) (bool, error) {
+	_, err := db.Client.UpdateItem(context.Background(), &ddb.UpdateItemInput{
+		TableName:                 aws.String(db.Table),
+		Key:                       apiKeyKey(id),
+		UpdateExpression:          aws.String("SET revoked_at = :now"),
+		ConditionExpression:       aws.String("attribute_exists(pk) AND attribute_not_exists(revoked_at)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":now": timestamp(time.Now())},
+	})
+	if isConditionFailed(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
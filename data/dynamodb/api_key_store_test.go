@@ -0,0 +1,19 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/dynamodb"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &dynamodb.APIKeyStore{db}
+	for _, tester := range testers.APIKeyStoreTesters {
+		deleteAll(db)
+		tester(t, store)
+	}
+}
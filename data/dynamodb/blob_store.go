@@ -0,0 +1,37 @@
+package dynamodb
+
+import (
+	"time"
+)
+
+var placeholder = "generating"
+
+type BlobStore struct {
+	*DB
+	TTL      time.Duration
+	LockTime time.Duration
+}
+
+func blobKey(name string) item {
+	return key("blob#"+name, "blob")
+}
+
+func (s *BlobStore) Read(name string) ([]byte, error) {
+	i, err := s.get(blobKey(name))
+	if i == nil || err != nil || i.expired(time.Now()) {
+		return nil, err
+	}
+	blob := i.bytes("blob")
+	if string(blob) == placeholder {
+		return nil, nil
+	}
+	return blob, nil
+}
+
+// WriteNX also replaces a blob that has expired but that DynamoDB has yet to delete.
+func (s *BlobStore) WriteNX(name string, blob []byte) (bool, error) {
+	now := time.Now()
+	i := expiring(blobKey(name), now.Add(s.TTL))
+	i["blob"] = bin(blob)
+	return s.putUnlessLive(i, now)
+}
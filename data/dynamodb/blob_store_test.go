@@ -0,0 +1,24 @@
+package dynamodb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data/dynamodb"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &dynamodb.BlobStore{
+		DB:       db,
+		TTL:      time.Second,
+		LockTime: time.Second,
+	}
+	for _, tester := range testers.BlobStoreTesters {
+		deleteAll(db)
+		tester(t, store)
+	}
+}
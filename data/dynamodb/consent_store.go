@@ -0,0 +1,60 @@
+package dynamodb
+
+import (
+	"time"
+
+	"github.com/keratin/authn-server/models"
+)
+
+type ConsentStore struct {
+	*DB
+}
+
+func consentFromItem(accountID int, i item) *models.Consent {
+	return &models.Consent{
+		ID:             i.int("id"),
+		AccountID:      accountID,
+		TermsVersion:   i.str("terms_version"),
+		MarketingOptIn: i.bool("marketing_opt_in"),
+		IP:             i.str("ip"),
+		CreatedAt:      i.time("created_at"),
+	}
+}
+
+func (db *ConsentStore) Create(accountID int, termsVersion string, marketingOptIn bool, ip string) (*models.Consent, error) {
+	id, err := db.nextID("account_consents")
+	if err != nil {
+		return nil, err
+	}
+
+	i := key(accountPK(accountID), "consent#"+paddedID(id))
+	i["id"] = num(int64(id))
+	i["terms_version"] = str(termsVersion)
+	i["marketing_opt_in"] = boolean(marketingOptIn)
+	i["ip"] = str(ip)
+	i["created_at"] = timestamp(time.Now())
+	if err := db.put(i); err != nil {
+		return nil, err
+	}
+	return consentFromItem(accountID, i), nil
+}
+
+func (db *ConsentStore) Latest(accountID int) (*models.Consent, error) {
+	items, err := db.query(accountPK(accountID), "consent#", false, 1)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return consentFromItem(accountID, items[0]), nil
+}
+
+func (db *ConsentStore) FindAll(accountID int) ([]*models.Consent, error) {
+	consents := []*models.Consent{}
+	items, err := db.query(accountPK(accountID), "consent#", true, 0)
+	if err != nil {
+		return consents, err
+	}
+	for _, i := range items {
+		consents = append(consents, consentFromItem(accountID, i))
+	}
+	return consents, nil
+}
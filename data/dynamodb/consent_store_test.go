@@ -0,0 +1,19 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/dynamodb"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsentStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &dynamodb.ConsentStore{db}
+	for _, tester := range testers.ConsentStoreTesters {
+		deleteAll(db)
+		tester(t, store)
+	}
+}
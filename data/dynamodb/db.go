@@ -0,0 +1,428 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+)
+
+// DB is a single DynamoDB table shared by every store. Items are keyed by a partition key (pk) and
+// a sort key (sk), so that an account's related items share its partition. Items that must be
+// found across partitions also have a list and an order, which are indexed by listIndex. Like any
+// global secondary index, the list index is eventually consistent.
+//
+// Items with an expires attribute are deleted by DynamoDB's TTL some time after they expire, so
+// readers must still check it.
+type DB struct {
+	Client *ddb.Client
+	Table  string
+}
+
+const listIndex = "lists"
+
+// timeFormat has a fixed width, so that times sort as strings.
+const timeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// NewDB connects to the table named by the URL's path in the region named by its host, as in
+// dynamodb://us-east-1/authn. Credentials are found in the usual AWS places. An endpoint param
+// connects to another endpoint, such as DynamoDB Local.
+func NewDB(url *url.URL) (*DB, error) {
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(url.Host))
+	if err != nil {
+		return nil, err
+	}
+	client := ddb.NewFromConfig(cfg, func(o *ddb.Options) {
+		if endpoint := url.Query().Get("endpoint"); endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+
+	table := strings.TrimPrefix(url.Path, "/")
+	if table == "" {
+		table = "authn"
+	}
+	return &DB{Client: client, Table: table}, nil
+}
+
+// Ping checks that the table exists and may be described.
+func (db *DB) Ping() error {
+	_, err := db.Client.DescribeTable(context.Background(), &ddb.DescribeTableInput{TableName: aws.String(db.Table)})
+	return err
+}
+
+type item map[string]types.AttributeValue
+
+func key(pk string, sk string) item {
+	return item{"pk": str(pk), "sk": str(sk)}
+}
+
+func str(s string) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: s}
+}
+
+func num(n int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
+
+func bin(b []byte) types.AttributeValue {
+	if b == nil {
+		b = []byte{}
+	}
+	return &types.AttributeValueMemberB{Value: b}
+}
+
+func boolean(b bool) types.AttributeValue {
+	return &types.AttributeValueMemberBOOL{Value: b}
+}
+
+func timestamp(t time.Time) types.AttributeValue {
+	return str(t.UTC().Format(timeFormat))
+}
+
+// optionalTime is nil for a nil time, so that an update removes the attribute.
+func optionalTime(t *time.Time) types.AttributeValue {
+	if t == nil {
+		return nil
+	}
+	return timestamp(*t)
+}
+
+// expiring sets when the item expires. DynamoDB's TTL reads the epoch time in expires, while
+// readers check the more precise expires_at.
+func expiring(i item, t time.Time) item {
+	i["expires"] = num(t.Unix())
+	i["expires_at"] = timestamp(t)
+	return i
+}
+
+// paddedID sorts as a string in the same order as the number.
+func paddedID(id int) string {
+	return fmt.Sprintf("%020d", id)
+}
+
+func (i item) str(name string) string {
+	if v, ok := i[name].(*types.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
+
+func (i item) int(name string) int {
+	if v, ok := i[name].(*types.AttributeValueMemberN); ok {
+		n, _ := strconv.Atoi(v.Value)
+		return n
+	}
+	return 0
+}
+
+func (i item) bytes(name string) []byte {
+	if v, ok := i[name].(*types.AttributeValueMemberB); ok {
+		return v.Value
+	}
+	return nil
+}
+
+func (i item) bool(name string) bool {
+	if v, ok := i[name].(*types.AttributeValueMemberBOOL); ok {
+		return v.Value
+	}
+	return false
+}
+
+func (i item) time(name string) time.Time {
+	t, _ := time.Parse(timeFormat, i.str(name))
+	return t
+}
+
+func (i item) optionalTime(name string) *time.Time {
+	if _, ok := i[name]; !ok {
+		return nil
+	}
+	t := i.time(name)
+	return &t
+}
+
+// expired is true when the item's TTL has passed but DynamoDB has not yet deleted it.
+func (i item) expired(now time.Time) bool {
+	_, ok := i["expires_at"]
+	return ok && !i.time("expires_at").After(now)
+}
+
+// expression builds an update that sets every value, or removes it when the value is nil.
+func expression(values map[string]types.AttributeValue) (string, map[string]string, map[string]types.AttributeValue) {
+	names := map[string]string{}
+	vals := map[string]types.AttributeValue{}
+	sets := []string{}
+	removes := []string{}
+
+	// sorted, for a stable expression
+	attrs := make([]string, 0, len(values))
+	for name := range values {
+		attrs = append(attrs, name)
+	}
+	sort.Strings(attrs)
+
+	for i, name := range attrs {
+		placeholder := fmt.Sprintf("a%d", i)
+		names["#"+placeholder] = name
+		if values[name] == nil {
+			removes = append(removes, "#"+placeholder)
+		} else {
+			vals[":"+placeholder] = values[name]
+			sets = append(sets, fmt.Sprintf("#%s = :%s", placeholder, placeholder))
+		}
+	}
+
+	expr := ""
+	if len(sets) > 0 {
+		expr += "SET " + strings.Join(sets, ", ")
+	}
+	if len(removes) > 0 {
+		expr += " REMOVE " + strings.Join(removes, ", ")
+	}
+	if len(vals) == 0 {
+		vals = nil
+	}
+	return strings.TrimSpace(expr), names, vals
+}
+
+func (db *DB) get(k item) (item, error) {
+	out, err := db.Client.GetItem(context.Background(), &ddb.GetItemInput{
+		TableName:      aws.String(db.Table),
+		Key:            k,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil || out.Item == nil {
+		return nil, err
+	}
+	return out.Item, nil
+}
+
+// batchGetLimit is how many keys DynamoDB reads in one BatchGetItem.
+const batchGetLimit = 100
+
+// getMany reads the items with the given keys, in batches. Items that do not exist are skipped, and
+// the rest are in no particular order.
+func (db *DB) getMany(keys []item) ([]item, error) {
+	items := []item{}
+	for start := 0; start < len(keys); start += batchGetLimit {
+		end := start + batchGetLimit
+		if end > len(keys) {
+			end = len(keys)
+		}
+		batch := make([]map[string]types.AttributeValue, 0, end-start)
+		for _, k := range keys[start:end] {
+			batch = append(batch, k)
+		}
+
+		// DynamoDB may leave some keys unprocessed when the batch is too large or throttled
+		request := map[string]types.KeysAndAttributes{
+			db.Table: {Keys: batch, ConsistentRead: aws.Bool(true)},
+		}
+		for len(request) > 0 {
+			out, err := db.Client.BatchGetItem(context.Background(), &ddb.BatchGetItemInput{RequestItems: request})
+			if err != nil {
+				return nil, err
+			}
+			for _, i := range out.Responses[db.Table] {
+				items = append(items, i)
+			}
+			request = out.UnprocessedKeys
+		}
+	}
+	return items, nil
+}
+
+func (db *DB) put(i item) error {
+	_, err := db.Client.PutItem(context.Background(), &ddb.PutItemInput{
+		TableName: aws.String(db.Table),
+		Item:      i,
+	})
+	return err
+}
+
+func (db *DB) delete(k item) error {
+	_, err := db.Client.DeleteItem(context.Background(), &ddb.DeleteItemInput{
+		TableName: aws.String(db.Table),
+		Key:       k,
+	})
+	return err
+}
+
+// update changes an existing item. It does nothing when the item does not exist.
+func (db *DB) update(k item, values map[string]types.AttributeValue) error {
+	expr, names, vals := expression(values)
+	_, err := db.Client.UpdateItem(context.Background(), &ddb.UpdateItemInput{
+		TableName:                 aws.String(db.Table),
+		Key:                       k,
+		UpdateExpression:          aws.String(expr),
+		ConditionExpression:       aws.String("attribute_exists(pk)"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: vals,
+	})
+	if isConditionFailed(err) {
+		return nil
+	}
+	return err
+}
+
+// query returns every item in the partition whose sort key has the prefix, in order.
+func (db *DB) query(pk string, skPrefix string, forward bool, limit int32) ([]item, error) {
+	input := &ddb.QueryInput{
+		TableName:              aws.String(db.Table),
+		KeyConditionExpression: aws.String("pk = :pk AND begins_with(sk, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": str(pk),
+			":sk": str(skPrefix),
+		},
+		ConsistentRead:   aws.Bool(true),
+		ScanIndexForward: aws.Bool(forward),
+	}
+	if limit > 0 {
+		input.Limit = aws.Int32(limit)
+	}
+	return db.collect(input, limit)
+}
+
+// queryList returns every item on the list whose order is between the bounds, in order.
+func (db *DB) queryList(list string, from string, to string) ([]item, error) {
+	return db.collect(&ddb.QueryInput{
+		TableName:              aws.String(db.Table),
+		IndexName:              aws.String(listIndex),
+		KeyConditionExpression: aws.String("#list = :list AND #order BETWEEN :from AND :to"),
+		ExpressionAttributeNames: map[string]string{
+			"#list":  "list",
+			"#order": "order",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":list": str(list),
+			":from": str(from),
+			":to":   str(to),
+		},
+	}, 0)
+}
+
+func (db *DB) collect(input *ddb.QueryInput, limit int32) ([]item, error) {
+	items := []item{}
+	paginator := ddb.NewQueryPaginator(db.Client, input)
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range out.Items {
+			items = append(items, i)
+		}
+		if limit > 0 && len(items) >= int(limit) {
+			break
+		}
+	}
+	return items, nil
+}
+
+// transact writes every item or none of them. It reports false when a condition failed.
+func (db *DB) transact(writes ...types.TransactWriteItem) (bool, error) {
+	for i := range writes {
+		switch {
+		case writes[i].Put != nil:
+			writes[i].Put.TableName = aws.String(db.Table)
+		case writes[i].Update != nil:
+			writes[i].Update.TableName = aws.String(db.Table)
+		case writes[i].Delete != nil:
+			writes[i].Delete.TableName = aws.String(db.Table)
+		case writes[i].ConditionCheck != nil:
+			writes[i].ConditionCheck.TableName = aws.String(db.Table)
+		}
+	}
+	_, err := db.Client.TransactWriteItems(context.Background(), &ddb.TransactWriteItemsInput{
+		TransactItems: writes,
+	})
+	if isConditionFailed(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// putUnlessLive writes the item unless an unexpired item has the same key.
+func (db *DB) putUnlessLive(i item, now time.Time) (bool, error) {
+	_, err := db.Client.PutItem(context.Background(), &ddb.PutItemInput{
+		TableName:                 aws.String(db.Table),
+		Item:                      i,
+		ConditionExpression:       aws.String("attribute_not_exists(pk) OR #expires_at <= :now"),
+		ExpressionAttributeNames:  map[string]string{"#expires_at": "expires_at"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":now": timestamp(now)},
+	})
+	if isConditionFailed(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func putNew(i item) types.TransactWriteItem {
+	return types.TransactWriteItem{Put: &types.Put{
+		Item:                i,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	}}
+}
+
+func putAny(i item) types.TransactWriteItem {
+	return types.TransactWriteItem{Put: &types.Put{Item: i}}
+}
+
+func updateExisting(k item, values map[string]types.AttributeValue) types.TransactWriteItem {
+	expr, names, vals := expression(values)
+	return types.TransactWriteItem{Update: &types.Update{
+		Key:                       k,
+		UpdateExpression:          aws.String(expr),
+		ConditionExpression:       aws.String("attribute_exists(pk)"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: vals,
+	}}
+}
+
+func remove(k item) types.TransactWriteItem {
+	return types.TransactWriteItem{Delete: &types.Delete{Key: k}}
+}
+
+// nextID increments and returns a named sequence, so that items may have the same integer IDs as
+// rows in the SQL stores.
+func (db *DB) nextID(name string) (int, error) {
+	out, err := db.Client.UpdateItem(context.Background(), &ddb.UpdateItemInput{
+		TableName:                 aws.String(db.Table),
+		Key:                       key("counter#"+name, "counter"),
+		UpdateExpression:          aws.String("ADD #seq :one"),
+		ExpressionAttributeNames:  map[string]string{"#seq": "seq"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":one": num(1)},
+		ReturnValues:              types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return item(out.Attributes).int("seq"), nil
+}
+
+func isConditionFailed(err error) bool {
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return true
+	}
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) {
+		for _, reason := range canceled.CancellationReasons {
+			if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+				return true
+			}
+		}
+	}
+	return false
+}
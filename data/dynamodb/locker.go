@@ -0,0 +1,15 @@
+package dynamodb
+
+import (
+	"time"
+)
+
+// Locker claims short-lived locks, so that only one AuthN server performs a given piece of work.
+type Locker struct {
+	*DB
+}
+
+func (l *Locker) Lock(name string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	return l.putUnlessLive(expiring(key("lock#"+name, "lock"), now.Add(ttl)), now)
+}
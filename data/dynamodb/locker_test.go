@@ -0,0 +1,35 @@
+package dynamodb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocker(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	deleteAll(db)
+	locker := &dynamodb.Locker{DB: db}
+
+	ok, err := locker.Lock("job", 100*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = locker.Lock("job", time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = locker.Lock("other", time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// expired, though not yet deleted
+	time.Sleep(200 * time.Millisecond)
+	ok, err = locker.Lock("job", time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,68 @@
+package dynamodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+)
+
+// MigrateDB creates the table and its list index if they do not exist, and then enables TTL on the
+// expires attribute. Tables are billed per request, and may be changed after they are created.
+func MigrateDB(db *DB) error {
+	ctx := context.Background()
+	_, err := db.Client.CreateTable(ctx, &ddb.CreateTableInput{
+		TableName:   aws.String(db.Table),
+		BillingMode: types.BillingModePayPerRequest,
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("sk"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("list"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("order"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("sk"), KeyType: types.KeyTypeRange},
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{{
+			IndexName: aws.String(listIndex),
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("list"), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String("order"), KeyType: types.KeyTypeRange},
+			},
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+		}},
+	})
+	var exists *types.ResourceInUseException
+	if err != nil && !errors.As(err, &exists) {
+		return errors.Wrap(err, "CreateTable")
+	}
+
+	waiter := ddb.NewTableExistsWaiter(db.Client)
+	err = waiter.Wait(ctx, &ddb.DescribeTableInput{TableName: aws.String(db.Table)}, 5*time.Minute)
+	if err != nil {
+		return errors.Wrap(err, "TableExistsWaiter")
+	}
+
+	ttl, err := db.Client.DescribeTimeToLive(ctx, &ddb.DescribeTimeToLiveInput{TableName: aws.String(db.Table)})
+	if err != nil {
+		return errors.Wrap(err, "DescribeTimeToLive")
+	}
+	if ttl.TimeToLiveDescription != nil {
+		switch ttl.TimeToLiveDescription.TimeToLiveStatus {
+		case types.TimeToLiveStatusEnabled, types.TimeToLiveStatusEnabling:
+			return nil
+		}
+	}
+	_, err = db.Client.UpdateTimeToLive(ctx, &ddb.UpdateTimeToLiveInput{
+		TableName: aws.String(db.Table),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String("expires"),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	return errors.Wrap(err, "UpdateTimeToLive")
+}
@@ -0,0 +1,230 @@
+package dynamodb
+
+import (
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/keratin/authn-server/lib"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// RefreshTokenStore keeps each token in its own partition, so that it may be found, and in the
+// account's partition, so that the account's tokens may be found. DynamoDB's TTL deletes both once
+// the token expires, so there is nothing to clean.
+type RefreshTokenStore struct {
+	*DB
+	TTL time.Duration
+}
+
+// createLimitedAttempts is how many times CreateLimited retries when other logins race it.
+const createLimitedAttempts = 5
+
+func tokenKey(t models.RefreshToken) item {
+	return key("refresh_token#"+string(t), "refresh_token")
+}
+
+func accountTokenKey(accountID int, t models.RefreshToken) item {
+	return key(accountPK(accountID), "refresh_token#"+string(t))
+}
+
+// tokenLockKey versions the account's tokens, so that CreateLimited may notice concurrent changes.
+func tokenLockKey(accountID int) item {
+	return key(accountPK(accountID), "refresh_token_lock")
+}
+
+func (s *RefreshTokenStore) Find(t models.RefreshToken) (int, error) {
+	if _, err := hex.DecodeString(string(t)); err != nil {
+		return 0, err
+	}
+	i, err := s.get(tokenKey(t))
+	if i == nil || err != nil || i.expired(time.Now()) {
+		return 0, err
+	}
+	return i.int("account_id"), nil
+}
+
+// FindMany reads the tokens in batches, since each token is kept in its own partition.
+func (s *RefreshTokenStore) FindMany(ts []models.RefreshToken) ([]int, error) {
+	// a batch may not repeat a key
+	keys := []item{}
+	seen := map[models.RefreshToken]bool{}
+	for _, t := range ts {
+		if _, err := hex.DecodeString(string(t)); err != nil {
+			return nil, err
+		}
+		if !seen[t] {
+			seen[t] = true
+			keys = append(keys, tokenKey(t))
+		}
+	}
+	items, err := s.getMany(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	found := map[string]int{}
+	for _, i := range items {
+		if !i.expired(now) {
+			found[i.str("pk")] = i.int("account_id")
+		}
+	}
+	ids := make([]int, len(ts))
+	for i, t := range ts {
+		ids[i] = found[tokenKey(t).str("pk")]
+	}
+	return ids, nil
+}
+
+func (s *RefreshTokenStore) Touch(t models.RefreshToken, accountID int) error {
+	if _, err := hex.DecodeString(string(t)); err != nil {
+		return err
+	}
+	now := time.Now()
+	_, err := s.transact(touching(tokenKey(t), now, s.TTL), touching(accountTokenKey(accountID, t), now, s.TTL))
+	return err
+}
+
+// touching extends an unexpired item, without reviving one that DynamoDB has yet to delete.
+func touching(k item, now time.Time, ttl time.Duration) types.TransactWriteItem {
+	expiry := expiring(item{}, now.Add(ttl))
+	return types.TransactWriteItem{Update: &types.Update{
+		Key:                      k,
+		UpdateExpression:         aws.String("SET #expires = :expires, #expires_at = :expires_at"),
+		ConditionExpression:      aws.String("#expires_at > :now"),
+		ExpressionAttributeNames: map[string]string{"#expires": "expires", "#expires_at": "expires_at"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":expires":    expiry["expires"],
+			":expires_at": expiry["expires_at"],
+			":now":        timestamp(now),
+		},
+	}}
+}
+
+// FindAll returns the account's tokens, least recently used first.
+func (s *RefreshTokenStore) FindAll(accountID int) ([]models.RefreshToken, error) {
+	items, err := s.active(accountID)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]models.RefreshToken, 0, len(items))
+	for _, i := range items {
+		tokens = append(tokens, models.RefreshToken(strings.TrimPrefix(i.str("sk"), "refresh_token#")))
+	}
+	return tokens, nil
+}
+
+// active returns the account's unexpired tokens, least recently used first.
+func (s *RefreshTokenStore) active(accountID int) ([]item, error) {
+	items, err := s.query(accountPK(accountID), "refresh_token#", true, 0)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	active := make([]item, 0, len(items))
+	for _, i := range items {
+		if !i.expired(now) {
+			active = append(active, i)
+		}
+	}
+	sort.SliceStable(active, func(a, b int) bool {
+		return active[a].str("expires_at") < active[b].str("expires_at")
+	})
+	return active, nil
+}
+
+func (s *RefreshTokenStore) Create(accountID int) (models.RefreshToken, error) {
+	t, writes, err := s.creating(accountID)
+	if err != nil {
+		return "", err
+	}
+	if _, err = s.transact(writes...); err != nil {
+		return "", err
+	}
+	return t, nil
+}
+
+// creating generates a token and the writes that would persist it.
+func (s *RefreshTokenStore) creating(accountID int) (models.RefreshToken, []types.TransactWriteItem, error) {
+	binToken, err := lib.GenerateToken()
+	if err != nil {
+		return "", nil, err
+	}
+	t := models.RefreshToken(hex.EncodeToString(binToken))
+
+	expiry := time.Now().Add(s.TTL)
+	token := expiring(tokenKey(t), expiry)
+	token["account_id"] = num(int64(accountID))
+	return t, []types.TransactWriteItem{
+		putAny(token),
+		putAny(expiring(accountTokenKey(accountID, t), expiry)),
+	}, nil
+}
+
+// CreateLimited counts the account's tokens and then writes in a transaction that fails if the
+// account's token version has changed since, retrying a few times before it gives up.
+func (s *RefreshTokenStore) CreateLimited(accountID int, max int, evict bool) (models.RefreshToken, error) {
+	for attempt := 0; attempt < createLimitedAttempts; attempt++ {
+		lock, err := s.get(tokenLockKey(accountID))
+		if err != nil {
+			return "", err
+		}
+		version := 0
+		if lock != nil {
+			version = lock.int("version")
+		}
+
+		active, err := s.active(accountID)
+		if err != nil {
+			return "", err
+		}
+		t, writes, err := s.creating(accountID)
+		if err != nil {
+			return "", err
+		}
+		if max > 0 && len(active) >= max {
+			if !evict {
+				return "", nil
+			}
+			for _, i := range active[:len(active)-max+1] {
+				evicted := models.RefreshToken(strings.TrimPrefix(i.str("sk"), "refresh_token#"))
+				writes = append(writes, remove(tokenKey(evicted)), remove(accountTokenKey(accountID, evicted)))
+			}
+		}
+
+		writes = append(writes, types.TransactWriteItem{Update: &types.Update{
+			Key:                      tokenLockKey(accountID),
+			UpdateExpression:         aws.String("SET #version = :next"),
+			ConditionExpression:      aws.String("attribute_not_exists(#version) OR #version = :version"),
+			ExpressionAttributeNames: map[string]string{"#version": "version"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":version": num(int64(version)),
+				":next":    num(int64(version + 1)),
+			},
+		}})
+		ok, err := s.transact(writes...)
+		if err != nil {
+			return "", err
+		} else if ok {
+			return t, nil
+		}
+	}
+	return "", errors.New("CreateLimited: too much contention")
+}
+
+func (s *RefreshTokenStore) Revoke(t models.RefreshToken) error {
+	accountID, err := s.Find(t)
+	if err != nil {
+		return err
+	}
+	if accountID == 0 {
+		return nil
+	}
+	_, err = s.transact(remove(tokenKey(t)), remove(accountTokenKey(accountID, t)))
+	return err
+}
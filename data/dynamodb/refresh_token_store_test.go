@@ -0,0 +1,41 @@
+package dynamodb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data/dynamodb"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshTokenStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &dynamodb.RefreshTokenStore{DB: db, TTL: time.Second}
+	for _, tester := range testers.RefreshTokenStoreTesters {
+		deleteAll(db)
+		tester(t, store)
+	}
+}
+
+func TestRefreshTokenStoreExpiry(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	deleteAll(db)
+	store := &dynamodb.RefreshTokenStore{DB: db, TTL: 100 * time.Millisecond}
+
+	token, err := store.Create(123)
+	require.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+
+	// expired, though not yet deleted
+	id, err := store.Find(token)
+	require.NoError(t, err)
+	assert.Empty(t, id)
+	require.NoError(t, store.Touch(token, 123))
+	tokens, err := store.FindAll(123)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+}
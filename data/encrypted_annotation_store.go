@@ -0,0 +1,74 @@
+package data
+
+import (
+	"github.com/keratin/authn-server/lib/fieldcrypt"
+	"github.com/pkg/errors"
+)
+
+// EncryptedAnnotationStore is an AnnotationStore that encrypts metadata values at rest, since
+// applications may store personal details such as phone numbers there. Metadata names remain
+// plaintext.
+type EncryptedAnnotationStore struct {
+	AnnotationStore
+	keyring *fieldcrypt.Keyring
+}
+
+// NewEncryptedAnnotationStore wraps an AnnotationStore with field encryption.
+func NewEncryptedAnnotationStore(store AnnotationStore, keyring *fieldcrypt.Keyring) *EncryptedAnnotationStore {
+	return &EncryptedAnnotationStore{
+		AnnotationStore: store,
+		keyring:         keyring,
+	}
+}
+
+// SetMetadata encrypts each value with the current key.
+func (s *EncryptedAnnotationStore) SetMetadata(accountID int, metadata map[string]string) error {
+	encrypted := make(map[string]string, len(metadata))
+	for name, value := range metadata {
+		val, err := s.keyring.Encrypt(value)
+		if err != nil {
+			return errors.Wrap(err, "Encrypt")
+		}
+		encrypted[name] = val
+	}
+	return s.AnnotationStore.SetMetadata(accountID, encrypted)
+}
+
+// GetMetadata decrypts each value. Values stored before encryption was enabled are returned as is.
+func (s *EncryptedAnnotationStore) GetMetadata(accountID int) (map[string]string, error) {
+	metadata, err := s.AnnotationStore.GetMetadata(accountID)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range metadata {
+		metadata[name], err = s.keyring.Decrypt(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Decrypt %s", name)
+		}
+	}
+	return metadata, nil
+}
+
+// Reencrypt rewrites any of the account's metadata values that are plaintext or encrypted with an
+// old key, and returns how many were rewritten.
+func (s *EncryptedAnnotationStore) Reencrypt(accountID int) (int, error) {
+	metadata, err := s.AnnotationStore.GetMetadata(accountID)
+	if err != nil {
+		return 0, errors.Wrap(err, "GetMetadata")
+	}
+
+	stale := map[string]string{}
+	for name, value := range metadata {
+		if !s.keyring.Stale(value) {
+			continue
+		}
+		stale[name], err = s.keyring.Decrypt(value)
+		if err != nil {
+			return 0, errors.Wrapf(err, "Decrypt %s", name)
+		}
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+	return len(stale), s.SetMetadata(accountID, stale)
+}
@@ -0,0 +1,54 @@
+package data_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/fieldcrypt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedAnnotationStore(t *testing.T) {
+	v1, err := fieldcrypt.NewKeyring(map[int][]byte{1: bytes.Repeat([]byte{1}, 32)})
+	require.NoError(t, err)
+	v2, err := fieldcrypt.NewKeyring(map[int][]byte{1: bytes.Repeat([]byte{1}, 32), 2: bytes.Repeat([]byte{2}, 32)})
+	require.NoError(t, err)
+
+	store := mock.NewAnnotationStore()
+
+	t.Run("encrypting metadata", func(t *testing.T) {
+		err := data.NewEncryptedAnnotationStore(store, v1).SetMetadata(1, map[string]string{"phone": "555-0100"})
+		require.NoError(t, err)
+
+		raw, err := store.GetMetadata(1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, fieldcrypt.Version(raw["phone"]))
+
+		metadata, err := data.NewEncryptedAnnotationStore(store, v2).GetMetadata(1)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"phone": "555-0100"}, metadata)
+	})
+
+	t.Run("re-encrypting metadata", func(t *testing.T) {
+		require.NoError(t, store.SetMetadata(2, map[string]string{"plan": "pro"}))
+		require.NoError(t, data.NewEncryptedAnnotationStore(store, v1).SetMetadata(2, map[string]string{"phone": "555-0100"}))
+		require.NoError(t, data.NewEncryptedAnnotationStore(store, v2).SetMetadata(2, map[string]string{"country": "NZ"}))
+
+		count, err := data.NewEncryptedAnnotationStore(store, v2).Reencrypt(2)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		raw, err := store.GetMetadata(2)
+		require.NoError(t, err)
+		for _, value := range raw {
+			assert.Equal(t, 2, fieldcrypt.Version(value))
+		}
+
+		metadata, err := data.NewEncryptedAnnotationStore(store, v2).GetMetadata(2)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"plan": "pro", "phone": "555-0100", "country": "NZ"}, metadata)
+	})
+}
@@ -0,0 +1,30 @@
+package data
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// EachAccount streams every account in ID order, including archived accounts, without loading the
+// table into memory. It stops at the first error returned by fn.
+func EachAccount(db *sqlx.DB, fn func(*models.Account) error) error {
+	rows, err := db.Queryx("SELECT * FROM accounts ORDER BY id")
+	if err != nil {
+		return errors.Wrap(err, "Queryx")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		account := models.Account{}
+		err = rows.StructScan(&account)
+		if err != nil {
+			return errors.Wrap(err, "StructScan")
+		}
+		err = fn(&account)
+		if err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(rows.Err(), "Next")
+}
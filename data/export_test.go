@@ -0,0 +1,38 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/sqlite3"
+	"github.com/keratin/authn-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEachAccount(t *testing.T) {
+	db, err := sqlite3.TestDB()
+	require.NoError(t, err)
+	store := &sqlite3.AccountStore{DB: db}
+
+	first, err := store.Create("first", []byte("hash"))
+	require.NoError(t, err)
+	second, err := store.Create("second", []byte("hash"))
+	require.NoError(t, err)
+	require.NoError(t, store.Lock(second.ID))
+
+	ids := []int{}
+	err = data.EachAccount(db, func(account *models.Account) error {
+		if account.ID != first.ID && account.ID != second.ID {
+			return nil
+		}
+		ids = append(ids, account.ID)
+		if account.ID == second.ID {
+			assert.True(t, account.Locked)
+			assert.Equal(t, "second", account.Username)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{first.ID, second.ID}, ids)
+}
@@ -1,12 +1,12 @@
 package data
 
 import (
-	"crypto/rsa"
+	"crypto"
 )
 
 type KeyStore interface {
 	// Returns the current key
-	Key() *rsa.PrivateKey
+	Key() crypto.Signer
 	// Returns recent keys (including current key)
-	Keys() []*rsa.PrivateKey
+	Keys() []crypto.Signer
 }
@@ -6,16 +6,20 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
-	"github.com/keratin/authn-server/lib"
-
+	dataRedis "github.com/keratin/authn-server/data/redis"
 	"github.com/keratin/authn-server/lib/compat"
+	"github.com/keratin/authn-server/lib/jobs"
 	"github.com/keratin/authn-server/ops"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
+const keyRotationsTopic = "key_rotations"
+
 // NewKeyStoreRotater creates a KeyStoreRotater.
 //
 // The rotation interval should match the lifetime of an access token. This means a key can be used
@@ -35,11 +39,24 @@ type KeyStoreRotater struct {
 	interval    time.Duration
 	keyStrength int
 	store       *EncryptedBlobStore
+
+	// Alerts is told when a scheduled rotation fails, since the server will soon have no key that
+	// is valid for signing.
+	Alerts *ops.Alerts
+
+	// Stream announces each key that this server generates to the other servers, which then rotate
+	// it in at once. Otherwise a server whose scheduled rotation failed waits for the next one.
+	Stream *dataRedis.Stream
+	// Reporter is told when the Stream can not be read.
+	Reporter ops.ErrorReporter
+
+	rotating sync.Mutex
 }
 
-// Maintain will restore and rotate a keyStore at periodic intervals. It will return an error only
-// for issues during startup. Any issues that arise later during background work will be reported.
-func (m *KeyStoreRotater) Maintain(ks *RotatingKeyStore, r ops.ErrorReporter) error {
+// Maintain will restore a keyStore and schedule rotation at periodic intervals. It will return an
+// error only for issues during startup. Any issues that arise later during background work will be
+// reported by the scheduler.
+func (m *KeyStoreRotater) Maintain(ks *RotatingKeyStore, scheduler *jobs.Scheduler) error {
 	// fetch current keys
 	keys, err := m.restore()
 	if err != nil {
@@ -48,7 +65,7 @@ func (m *KeyStoreRotater) Maintain(ks *RotatingKeyStore, r ops.ErrorReporter) er
 
 	// rotate in the previous key
 	if keys[0] != nil {
-		ks.Rotate(keys[0])
+		m.rotateIn(ks, keys[0])
 
 		keyID, _ := compat.KeyID(keys[0].Public())
 		log.WithFields(log.Fields{"keyID": keyID}).Info("previous key restored")
@@ -56,41 +73,89 @@ func (m *KeyStoreRotater) Maintain(ks *RotatingKeyStore, r ops.ErrorReporter) er
 
 	// ensure and rotate in the current key
 	if keys[1] != nil {
-		ks.Rotate(keys[1])
+		m.rotateIn(ks, keys[1])
 
 		keyID, _ := compat.KeyID(keys[1].Public())
 		log.WithFields(log.Fields{"keyID": keyID}).Info("current key restored")
 	} else {
-		newKey, err := m.generate()
+		newKey, err := m.generate(m.currentBucket())
 		if err != nil {
 			return errors.Wrap(err, "generate")
 		}
-		ks.Rotate(newKey)
+		m.rotateIn(ks, newKey)
+	}
+
+	if m.Stream != nil {
+		go m.listen(ks)
 	}
 
-	go func() {
-		intervals := lib.EpochIntervalTick(m.interval)
-		for range intervals {
-			err = m.rotate(ks)
+	// every server must rotate its own keyStore, so this job is not exclusive
+	scheduler.Add(jobs.Job{
+		Name:     "keys:rotate",
+		Interval: m.interval,
+		Aligned:  true,
+		Run: func() error {
+			err := m.rotate(ks)
 			if err != nil {
-				r.ReportError(err)
+				m.Alerts.Alert(ops.Alert{
+					Key:     "keys:rotate",
+					Summary: "Signing key rotation failed",
+					Details: map[string]string{"error": err.Error()},
+				})
 			}
-		}
-	}()
+			return err
+		},
+	})
 
 	return nil
 }
 
 func (m *KeyStoreRotater) rotate(ks *RotatingKeyStore) error {
-	newKey, err := m.generate()
+	newKey, err := m.generate(m.currentBucket())
 	if err != nil {
 		return errors.Wrap(err, "generate")
 	}
-	ks.Rotate(newKey)
+	m.rotateIn(ks, newKey)
 
 	return nil
 }
 
+// rotateIn rotates a key into the keyStore unless it is already the current key, since a key may be
+// both announced and found by the scheduled rotation.
+func (m *KeyStoreRotater) rotateIn(ks *RotatingKeyStore, key *rsa.PrivateKey) {
+	m.rotating.Lock()
+	defer m.rotating.Unlock()
+
+	if current, ok := ks.Key().(*rsa.PrivateKey); ok && current.Equal(key) {
+		return
+	}
+	ks.Rotate(key)
+}
+
+// listen rotates in the current key when another server announces it. Announcements of keys
+// generated ahead of time are ignored, since they are found by the scheduled rotation. When reading
+// fails, it is reported and retried after a second.
+func (m *KeyStoreRotater) listen(ks *RotatingKeyStore) {
+	for {
+		err := m.Stream.Read(keyRotationsTopic, func(payload string) {
+			bucket, err := strconv.ParseInt(payload, 10, 64)
+			if err != nil || bucket != m.currentBucket() {
+				return
+			}
+			key, err := m.find(bucket)
+			if err != nil {
+				m.Reporter.ReportError(errors.Wrap(err, "find"))
+				return
+			}
+			if key != nil {
+				m.rotateIn(ks, key)
+			}
+		})
+		m.Reporter.ReportError(errors.Wrap(err, "Read"))
+		time.Sleep(time.Second)
+	}
+}
+
 // restore will query the blob store for the previous and current keys. It returns keys in the
 // proper sorting order, with the newest (current) key in last position. missing keys will leave a
 // blank slot, so that the caller may choose what to do.
@@ -113,10 +178,21 @@ func (m *KeyStoreRotater) restore() ([]*rsa.PrivateKey, error) {
 	return keys, nil
 }
 
+// GenerateNext will create the key for the next interval ahead of time, so that AuthN servers
+// will find and use it when they rotate. If the key already exists, it is returned instead.
+func (m *KeyStoreRotater) GenerateNext() (*rsa.PrivateKey, time.Time, error) {
+	bucket := m.currentBucket() + 1
+	key, err := m.generate(bucket)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "generate")
+	}
+	return key, time.Unix(bucket*int64(m.interval/time.Second), 0), nil
+}
+
 // generate will create a new key and store it as an encrypted blob. It relies on a write lock to
 // coordinate with other AuthN servers.
-func (m *KeyStoreRotater) generate() (*rsa.PrivateKey, error) {
-	keyName := fmt.Sprintf("rsa:%d", m.currentBucket())
+func (m *KeyStoreRotater) generate(bucket int64) (*rsa.PrivateKey, error) {
+	keyName := fmt.Sprintf("rsa:%d", bucket)
 	key, err := rsa.GenerateKey(rand.Reader, m.keyStrength)
 	if err != nil {
 		return nil, err
@@ -131,6 +207,13 @@ func (m *KeyStoreRotater) generate() (*rsa.PrivateKey, error) {
 	if ok {
 		keyID, _ := compat.KeyID(key.Public())
 		log.WithFields(log.Fields{"keyID": keyID, "keyName": keyName}).Info("new key generated")
+
+		if m.Stream != nil {
+			err = m.Stream.Add(keyRotationsTopic, strconv.FormatInt(bucket, 10))
+			if err != nil {
+				m.Reporter.ReportError(errors.Wrap(err, "Add"))
+			}
+		}
 	} else {
 		keyBlob, err := m.store.Read(keyName)
 		if err != nil {
@@ -1,6 +1,7 @@
 package data_test
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"testing"
@@ -8,13 +9,15 @@ import (
 
 	"github.com/keratin/authn-server/data"
 	"github.com/keratin/authn-server/data/mock"
+	dataRedis "github.com/keratin/authn-server/data/redis"
+	"github.com/keratin/authn-server/lib/jobs"
 	"github.com/keratin/authn-server/ops"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestKeyStoreRotater(t *testing.T) {
-	reporter := &ops.LogReporter{}
+	scheduler := jobs.NewScheduler(nil, &ops.LogReporter{})
 	secret := []byte("32bigbytesofsuperultimatesecrecy")
 	interval := time.Hour
 
@@ -22,7 +25,7 @@ func TestKeyStoreRotater(t *testing.T) {
 		blobStore := data.NewEncryptedBlobStore(mock.NewBlobStore(interval*2+time.Second, time.Second), secret)
 		store := data.NewRotatingKeyStore()
 		rotater := data.NewKeyStoreRotater(blobStore, interval)
-		err := rotater.Maintain(store, reporter)
+		err := rotater.Maintain(store, scheduler)
 		require.NoError(t, err)
 
 		assert.NotEmpty(t, store.Keys())
@@ -34,13 +37,13 @@ func TestKeyStoreRotater(t *testing.T) {
 		blobStore := data.NewEncryptedBlobStore(mock.NewBlobStore(interval*2+time.Second, time.Second), secret)
 
 		store1 := data.NewRotatingKeyStore()
-		err := data.NewKeyStoreRotater(blobStore, interval).Maintain(store1, reporter)
+		err := data.NewKeyStoreRotater(blobStore, interval).Maintain(store1, scheduler)
 		require.NoError(t, err)
 		key1 := store1.Key()
 		assert.NotEmpty(t, key1)
 
 		store2 := data.NewRotatingKeyStore()
-		err = data.NewKeyStoreRotater(blobStore, interval).Maintain(store2, reporter)
+		err = data.NewKeyStoreRotater(blobStore, interval).Maintain(store2, scheduler)
 		require.NoError(t, err)
 		assert.Len(t, store2.Keys(), 1)
 		assert.Equal(t, key1, store2.Key())
@@ -51,19 +54,66 @@ func TestKeyStoreRotater(t *testing.T) {
 		blobStore := data.NewEncryptedBlobStore(mock.NewBlobStore(interval*2+time.Second, time.Second), secret)
 		store := data.NewRotatingKeyStore()
 		rotater := data.NewKeyStoreRotater(blobStore, interval)
-		err := rotater.Maintain(store, reporter)
+		err := rotater.Maintain(store, scheduler)
 		require.NoError(t, err)
 
 		firstKey := store.Keys()[0]
 
-		secondKey, err := rsa.GenerateKey(rand.Reader, 256)
+		secondKey, err := rsa.GenerateKey(rand.Reader, 1024)
 		require.NoError(t, err)
 		store.Rotate(secondKey)
-		assert.Equal(t, []*rsa.PrivateKey{firstKey, secondKey}, store.Keys())
+		assert.Equal(t, []crypto.Signer{firstKey, secondKey}, store.Keys())
 
-		thirdKey, err := rsa.GenerateKey(rand.Reader, 256)
+		thirdKey, err := rsa.GenerateKey(rand.Reader, 1024)
 		require.NoError(t, err)
 		store.Rotate(thirdKey)
-		assert.Equal(t, []*rsa.PrivateKey{secondKey, thirdKey}, store.Keys())
+		assert.Equal(t, []crypto.Signer{secondKey, thirdKey}, store.Keys())
+	})
+	t.Run("generating next key", func(t *testing.T) {
+		blobStore := data.NewEncryptedBlobStore(mock.NewBlobStore(interval*2+time.Second, time.Second), secret)
+		rotater := data.NewKeyStoreRotater(blobStore, interval)
+
+		next, at, err := rotater.GenerateNext()
+		require.NoError(t, err)
+		assert.True(t, at.After(time.Now()))
+		assert.True(t, at.Before(time.Now().Add(interval)))
+
+		again, _, err := rotater.GenerateNext()
+		require.NoError(t, err)
+		assert.Equal(t, 0, next.N.Cmp(again.N))
+	})
+	t.Run("announcing through a stream", func(t *testing.T) {
+		client, err := dataRedis.TestDB()
+		require.NoError(t, err)
+		client.FlushDB()
+		// the first server has read the stream before
+		require.NoError(t, client.XGroupCreateMkStream("bus", "first:key_rotations", "$").Err())
+
+		interval := 2 * time.Second
+		blobStore := data.NewEncryptedBlobStore(mock.NewBlobStore(interval*2+time.Second, time.Second), secret)
+		// begin early in an interval, so that the next one is not close
+		time.Sleep(interval - time.Duration(time.Now().UnixNano())%interval + 100*time.Millisecond)
+
+		store1 := data.NewRotatingKeyStore()
+		rotater1 := data.NewKeyStoreRotater(blobStore, interval)
+		rotater1.Stream = &dataRedis.Stream{Client: client, Name: "first"}
+		rotater1.Reporter = &ops.LogReporter{}
+		require.NoError(t, rotater1.Maintain(store1, scheduler))
+		firstKey := store1.Key()
+
+		// the first server's scheduled rotation fails, but the second server generates the key
+		time.Sleep(interval)
+		store2 := data.NewRotatingKeyStore()
+		rotater2 := data.NewKeyStoreRotater(blobStore, interval)
+		rotater2.Stream = &dataRedis.Stream{Client: client, Name: "second"}
+		rotater2.Reporter = &ops.LogReporter{}
+		require.NoError(t, rotater2.Maintain(store2, scheduler))
+		secondKey := store2.Key()
+		require.NotEqual(t, firstKey, secondKey)
+
+		assert.Eventually(t, func() bool {
+			return len(store1.Keys()) == 2 && store1.Key().(*rsa.PrivateKey).Equal(secondKey)
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, firstKey, store1.Keys()[0])
 	})
 }
@@ -0,0 +1,50 @@
+package mock
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/keratin/authn-server/lib"
+	"github.com/keratin/authn-server/models"
+)
+
+type accessTokenStore struct {
+	tokens map[string]models.AccessToken
+}
+
+func NewAccessTokenStore() *accessTokenStore {
+	return &accessTokenStore{
+		tokens: make(map[string]models.AccessToken),
+	}
+}
+
+func (s *accessTokenStore) Create(meta *models.AccessToken) (string, error) {
+	bytes, err := lib.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(bytes)
+	s.tokens[token] = *meta
+	return token, nil
+}
+
+func (s *accessTokenStore) Find(token string) (*models.AccessToken, error) {
+	meta, ok := s.tokens[token]
+	if !ok || !meta.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	return &meta, nil
+}
+
+func (s *accessTokenStore) FindMany(tokens []string) ([]*models.AccessToken, error) {
+	metas := make([]*models.AccessToken, len(tokens))
+	for i, token := range tokens {
+		metas[i], _ = s.Find(token)
+	}
+	return metas, nil
+}
+
+func (s *accessTokenStore) Revoke(token string) error {
+	delete(s.tokens, token)
+	return nil
+}
@@ -0,0 +1,14 @@
+package mock_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/data/testers"
+)
+
+func TestAccessTokenStore(t *testing.T) {
+	for _, tester := range testers.AccessTokenStoreTesters {
+		tester(t, mock.NewAccessTokenStore())
+	}
+}
@@ -0,0 +1,36 @@
+package mock
+
+import "sync"
+
+// AccountChanges delivers published IDs to every subscriber in this process.
+type AccountChanges struct {
+	mutex    sync.Mutex
+	handlers []func(id int)
+}
+
+func (s *AccountChanges) Publish(id int) error {
+	s.mutex.Lock()
+	handlers := append([]func(id int){}, s.handlers...)
+	s.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(id)
+	}
+	return nil
+}
+
+// Subscribe never fails, so it never returns.
+func (s *AccountChanges) Subscribe(handler func(id int)) error {
+	s.mutex.Lock()
+	s.handlers = append(s.handlers, handler)
+	s.mutex.Unlock()
+
+	select {}
+}
+
+// Subscribers counts the handlers that have subscribed.
+func (s *AccountChanges) Subscribers() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.handlers)
+}
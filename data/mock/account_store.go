@@ -2,6 +2,8 @@ package mock
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/keratin/authn-server/models"
@@ -22,6 +24,13 @@ type accountStore struct {
 	idByUsername      map[string]int
 	oauthAccountsByID map[int][]*models.OauthAccount
 	idByOauthID       map[string]int
+	usernameChanges   []*models.UsernameChange
+	archivedUsernames map[int]archivedUsername
+}
+
+type archivedUsername struct {
+	username  []byte
+	createdAt time.Time
 }
 
 func NewAccountStore() *accountStore {
@@ -30,6 +39,7 @@ func NewAccountStore() *accountStore {
 		oauthAccountsByID: make(map[int][]*models.OauthAccount),
 		idByUsername:      make(map[string]int),
 		idByOauthID:       make(map[string]int),
+		archivedUsernames: make(map[int]archivedUsername),
 	}
 }
 
@@ -41,8 +51,24 @@ func (s *accountStore) Find(id int) (*models.Account, error) {
 	return nil, nil
 }
 
+func (s *accountStore) FindMany(ids []int) ([]*models.Account, error) {
+	sorted := append([]int{}, ids...)
+	sort.Ints(sorted)
+
+	accounts := []*models.Account{}
+	for i, id := range sorted {
+		if i > 0 && id == sorted[i-1] {
+			continue
+		}
+		if s.accountsByID[id] != nil {
+			accounts = append(accounts, dupAccount(*s.accountsByID[id]))
+		}
+	}
+	return accounts, nil
+}
+
 func (s *accountStore) FindByUsername(u string) (*models.Account, error) {
-	id := s.idByUsername[u]
+	id := s.idByUsername[strings.ToLower(u)]
 	if id == 0 {
 		return nil, nil
 	}
@@ -60,7 +86,7 @@ func (s *accountStore) FindByOauthAccount(provider string, providerID string) (*
 }
 
 func (s *accountStore) Create(u string, p []byte) (*models.Account, error) {
-	if s.idByUsername[u] != 0 {
+	if s.idByUsername[strings.ToLower(u)] != 0 {
 		return nil, Error{ErrNotUnique}
 	}
 
@@ -74,7 +100,7 @@ func (s *accountStore) Create(u string, p []byte) (*models.Account, error) {
 		UpdatedAt:         now,
 	}
 	s.accountsByID[acc.ID] = &acc
-	s.idByUsername[acc.Username] = acc.ID
+	s.idByUsername[strings.ToLower(acc.Username)] = acc.ID
 	return dupAccount(acc), nil
 }
 
@@ -111,7 +137,7 @@ func (s *accountStore) GetOauthAccounts(accountID int) ([]*models.OauthAccount,
 func (s *accountStore) Archive(id int) error {
 	account := s.accountsByID[id]
 	if account != nil {
-		delete(s.idByUsername, account.Username)
+		delete(s.idByUsername, strings.ToLower(account.Username))
 		now := time.Now()
 		account.Username = ""
 		account.Password = []byte("")
@@ -165,18 +191,189 @@ func (s *accountStore) SetPassword(id int, p []byte) error {
 	return nil
 }
 
-func (s *accountStore) UpdateUsername(id int, u string) error {
+func (s *accountStore) UpgradePassword(id int, p []byte) error {
 	account := s.accountsByID[id]
 	if account != nil {
+		account.Password = p
+		account.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *accountStore) UpdateUsername(id int, u string) error {
+	if other := s.idByUsername[strings.ToLower(u)]; other != 0 && other != id {
+		return Error{ErrNotUnique}
+	}
+
+	account := s.accountsByID[id]
+	if account != nil && account.Username != u {
+		s.usernameChanges = append(s.usernameChanges, &models.UsernameChange{
+			ID:        len(s.usernameChanges) + 1,
+			AccountID: id,
+			From:      account.Username,
+			To:        u,
+			CreatedAt: time.Now(),
+		})
+		delete(s.idByUsername, strings.ToLower(account.Username))
 		account.Username = u
 		account.UpdatedAt = time.Now()
+		s.idByUsername[strings.ToLower(u)] = id
+	}
+	return nil
+}
+
+func (s *accountStore) GetUsernameHistory(id int) ([]*models.UsernameChange, error) {
+	changes := []*models.UsernameChange{}
+	for _, change := range s.usernameChanges {
+		if change.AccountID == id {
+			dup := *change
+			changes = append(changes, &dup)
+		}
+	}
+	return changes, nil
+}
+
+func (s *accountStore) ScheduleArchive(id int, at time.Time) error {
+	account := s.accountsByID[id]
+	if account != nil {
+		account.ArchiveAt = &at
+		account.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *accountStore) CancelArchive(id int) error {
+	account := s.accountsByID[id]
+	if account != nil {
+		account.ArchiveAt = nil
+		account.UpdatedAt = time.Now()
 	}
 	return nil
 }
 
+func (s *accountStore) FindScheduledArchives(before time.Time) ([]int, error) {
+	ids := []int{}
+	for id, account := range s.accountsByID {
+		if account.ArchiveAt != nil && !account.ArchiveAt.After(before) && account.DeletedAt == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (s *accountStore) SetExpiry(id int, at *time.Time) error {
+	account := s.accountsByID[id]
+	if account != nil {
+		account.ExpiresAt = at
+		account.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *accountStore) SetThrottledUntil(id int, until *time.Time) error {
+	account := s.accountsByID[id]
+	if account != nil {
+		account.ThrottledUntil = until
+		account.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *accountStore) SetLegalHold(id int, held bool) error {
+	account := s.accountsByID[id]
+	if account != nil {
+		account.LegalHold = held
+		account.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *accountStore) FindExpired(before time.Time) ([]int, error) {
+	ids := []int{}
+	for id, account := range s.accountsByID {
+		if account.ExpiresAt != nil && !account.ExpiresAt.After(before) && account.DeletedAt == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
 // i think this works? i want to avoid accidentally giving callers the ability
 // to reach into the memory map and modify things or see changes without relying
 // on the store api.
 func dupAccount(acct models.Account) *models.Account {
 	return &acct
 }
+
+func (s *accountStore) SetExternalID(id int, externalID string) error {
+	for _, other := range s.accountsByID {
+		if other.ID != id && other.ExternalID != nil && *other.ExternalID == externalID {
+			return Error{ErrNotUnique}
+		}
+	}
+	account := s.accountsByID[id]
+	if account != nil {
+		account.ExternalID = &externalID
+		account.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *accountStore) FindByExternalID(externalID string) (*models.Account, error) {
+	for _, account := range s.accountsByID {
+		if account.ExternalID != nil && *account.ExternalID == externalID {
+			return dupAccount(*account), nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *accountStore) SaveArchivedUsername(id int, u []byte) error {
+	s.archivedUsernames[id] = archivedUsername{username: u, createdAt: time.Now()}
+	return nil
+}
+
+func (s *accountStore) FindArchivedUsername(id int) ([]byte, error) {
+	return s.archivedUsernames[id].username, nil
+}
+
+func (s *accountStore) Restore(id int, u string) error {
+	if other := s.idByUsername[strings.ToLower(u)]; other != 0 && other != id {
+		return Error{ErrNotUnique}
+	}
+
+	account := s.accountsByID[id]
+	if account != nil {
+		account.Username = u
+		account.DeletedAt = nil
+		account.ArchiveAt = nil
+		account.RequireNewPassword = true
+		account.UpdatedAt = time.Now()
+		s.idByUsername[strings.ToLower(u)] = id
+	}
+	delete(s.archivedUsernames, id)
+	return nil
+}
+
+func (s *accountStore) PurgeArchivedUsernames(before time.Time) (int, error) {
+	count := 0
+	for id, archived := range s.archivedUsernames {
+		account := s.accountsByID[id]
+		if archived.createdAt.Before(before) && (account == nil || !account.LegalHold) {
+			delete(s.archivedUsernames, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *accountStore) ClaimVersion(id int, version int) (bool, error) {
+	account := s.accountsByID[id]
+	if account == nil || account.Version != version {
+		return false, nil
+	}
+	account.Version++
+	return true, nil
+}
@@ -0,0 +1,137 @@
+package mock
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/keratin/authn-server/models"
+)
+
+type annotationStore struct {
+	mutex        sync.Mutex
+	tagsByID     map[int]map[string]bool
+	notesByID    map[int][]*models.AccountNote
+	lastNoteID   int
+	metadataByID map[int]map[string]string
+}
+
+func NewAnnotationStore() *annotationStore {
+	return &annotationStore{
+		tagsByID:     make(map[int]map[string]bool),
+		notesByID:    make(map[int][]*models.AccountNote),
+		metadataByID: make(map[int]map[string]string),
+	}
+}
+
+func (s *annotationStore) Tag(accountID int, tag string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.tagsByID[accountID] == nil {
+		s.tagsByID[accountID] = make(map[string]bool)
+	}
+	s.tagsByID[accountID][tag] = true
+	return nil
+}
+
+func (s *annotationStore) Untag(accountID int, tag string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.tagsByID[accountID], tag)
+	return nil
+}
+
+func (s *annotationStore) GetTags(accountID int) ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tags := []string{}
+	for tag := range s.tagsByID[accountID] {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+func (s *annotationStore) FindByTag(tag string) ([]int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ids := []int{}
+	for id, tags := range s.tagsByID {
+		if tags[tag] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (s *annotationStore) AddNote(accountID int, body string) (*models.AccountNote, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastNoteID++
+	note := &models.AccountNote{
+		ID:        s.lastNoteID,
+		AccountID: accountID,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+	s.notesByID[accountID] = append(s.notesByID[accountID], note)
+
+	dup := *note
+	return &dup, nil
+}
+
+func (s *annotationStore) GetNotes(accountID int) ([]*models.AccountNote, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	notes := []*models.AccountNote{}
+	for _, note := range s.notesByID[accountID] {
+		dup := *note
+		notes = append(notes, &dup)
+	}
+	return notes, nil
+}
+
+func (s *annotationStore) DeleteNote(accountID int, noteID int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	notes := s.notesByID[accountID]
+	for i, note := range notes {
+		if note.ID == noteID {
+			s.notesByID[accountID] = append(notes[:i], notes[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *annotationStore) SetMetadata(accountID int, metadata map[string]string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.metadataByID[accountID] == nil {
+		s.metadataByID[accountID] = make(map[string]string)
+	}
+	for name, value := range metadata {
+		s.metadataByID[accountID][name] = value
+	}
+	return nil
+}
+
+func (s *annotationStore) GetMetadata(accountID int) (map[string]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	metadata := map[string]string{}
+	for name, value := range s.metadataByID[accountID] {
+		metadata[name] = value
+	}
+	return metadata, nil
+}
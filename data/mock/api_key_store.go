@@ -0,0 +1,68 @@
+package mock
+
+import (
+	"sync"
+	"time"
+
+	"github.com/keratin/authn-server/models"
+)
+
+type apiKeyStore struct {
+	mutex sync.Mutex
+	keys  []*models.APIKey
+}
+
+func NewAPIKeyStore() *apiKeyStore {
+	return &apiKeyStore{}
+}
+
+func (s *apiKeyStore) Create(name string, scopes string, secretHash string) (*models.APIKey, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := &models.APIKey{
+		ID:         len(s.keys) + 1,
+		Name:       name,
+		Scopes:     scopes,
+		SecretHash: secretHash,
+		CreatedAt:  time.Now(),
+	}
+	s.keys = append(s.keys, key)
+	dup := *key
+	return &dup, nil
+}
+
+func (s *apiKeyStore) Find(id int) (*models.APIKey, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if id < 1 || id > len(s.keys) {
+		return nil, nil
+	}
+	dup := *s.keys[id-1]
+	return &dup, nil
+}
+
+func (s *apiKeyStore) FindAll() ([]*models.APIKey, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	keys := []*models.APIKey{}
+	for _, key := range s.keys {
+		dup := *key
+		keys = append(keys, &dup)
+	}
+	return keys, nil
+}
+
+func (s *apiKeyStore) Revoke(id int) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if id < 1 || id > len(s.keys) || s.keys[id-1].RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	s.keys[id-1].RevokedAt = &now
+	return true, nil
+}
@@ -0,0 +1,14 @@
+package mock_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/data/testers"
+)
+
+func TestAPIKeyStore(t *testing.T) {
+	for _, tester := range testers.APIKeyStoreTesters {
+		tester(t, mock.NewAPIKeyStore())
+	}
+}
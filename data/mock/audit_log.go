@@ -0,0 +1,137 @@
+package mock
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/models"
+)
+
+type auditLog struct {
+	key     []byte
+	mutex   sync.Mutex
+	seq     int
+	head    string
+	entries []models.AuditEntry
+}
+
+func NewAuditLog(key []byte) *auditLog {
+	return &auditLog{key: key}
+}
+
+func (l *auditLog) Append(e events.Event) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	cursor := strconv.Itoa(l.seq + 1)
+	hash, err := models.AuditHash(l.key, l.head, cursor, e)
+	if err != nil {
+		return err
+	}
+	l.seq++
+	l.entries = append(l.entries, models.AuditEntry{
+		Cursor:   cursor,
+		Event:    e,
+		PrevHash: l.head,
+		Hash:     hash,
+	})
+	l.head = hash
+	return nil
+}
+
+func (l *auditLog) Find(accountID int, after string, limit int) ([]models.AuditEntry, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	max := l.seq + 1
+	if after != "" {
+		seq, err := strconv.Atoi(after)
+		if err != nil {
+			return nil, err
+		}
+		max = seq
+	}
+
+	found := []models.AuditEntry{}
+	for i := len(l.entries) - 1; i >= 0 && len(found) < limit; i-- {
+		seq, _ := strconv.Atoi(l.entries[i].Cursor)
+		if seq >= max {
+			continue
+		}
+		if accountID == 0 || l.entries[i].Event.AccountID == accountID {
+			found = append(found, l.entries[i])
+		}
+	}
+	return found, nil
+}
+
+func (l *auditLog) FindAfter(after string, limit int) ([]models.AuditEntry, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	min := 0
+	if after != "" {
+		seq, err := strconv.Atoi(after)
+		if err != nil {
+			return nil, err
+		}
+		min = seq
+	}
+
+	found := []models.AuditEntry{}
+	for _, entry := range l.entries {
+		if len(found) >= limit {
+			break
+		}
+		if seq, _ := strconv.Atoi(entry.Cursor); seq > min {
+			found = append(found, entry)
+		}
+	}
+	return found, nil
+}
+
+func (l *auditLog) FindBefore(before time.Time, after string, limit int) ([]models.AuditEntry, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	min := 0
+	if after != "" {
+		seq, err := strconv.Atoi(after)
+		if err != nil {
+			return nil, err
+		}
+		min = seq
+	}
+
+	found := []models.AuditEntry{}
+	for _, entry := range l.entries {
+		if seq, _ := strconv.Atoi(entry.Cursor); seq <= min {
+			continue
+		}
+		if len(found) >= limit || !entry.Event.Time.Before(before) {
+			break
+		}
+		found = append(found, entry)
+	}
+	return found, nil
+}
+
+func (l *auditLog) Delete(entries []models.AuditEntry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	deleted := map[string]bool{}
+	for _, entry := range entries {
+		deleted[entry.Cursor] = true
+	}
+	kept := []models.AuditEntry{}
+	for _, entry := range l.entries {
+		if !deleted[entry.Cursor] {
+			kept = append(kept, entry)
+		}
+	}
+	l.entries = kept
+	return nil
+}
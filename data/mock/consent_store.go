@@ -0,0 +1,62 @@
+package mock
+
+import (
+	"sync"
+	"time"
+
+	"github.com/keratin/authn-server/models"
+)
+
+type consentStore struct {
+	mutex         sync.Mutex
+	consentsByID  map[int][]*models.Consent
+	lastConsentID int
+}
+
+func NewConsentStore() *consentStore {
+	return &consentStore{
+		consentsByID: make(map[int][]*models.Consent),
+	}
+}
+
+func (s *consentStore) Create(accountID int, termsVersion string, marketingOptIn bool, ip string) (*models.Consent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastConsentID++
+	consent := &models.Consent{
+		ID:             s.lastConsentID,
+		AccountID:      accountID,
+		TermsVersion:   termsVersion,
+		MarketingOptIn: marketingOptIn,
+		IP:             ip,
+		CreatedAt:      time.Now(),
+	}
+	s.consentsByID[accountID] = append(s.consentsByID[accountID], consent)
+	dup := *consent
+	return &dup, nil
+}
+
+func (s *consentStore) Latest(accountID int) (*models.Consent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	consents := s.consentsByID[accountID]
+	if len(consents) == 0 {
+		return nil, nil
+	}
+	dup := *consents[len(consents)-1]
+	return &dup, nil
+}
+
+func (s *consentStore) FindAll(accountID int) ([]*models.Consent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	consents := []*models.Consent{}
+	for _, consent := range s.consentsByID[accountID] {
+		dup := *consent
+		consents = append(consents, &dup)
+	}
+	return consents, nil
+}
@@ -0,0 +1,27 @@
+package mock
+
+import (
+	"sync"
+	"time"
+)
+
+type dpopProofs struct {
+	mutex   sync.Mutex
+	expires map[string]time.Time
+}
+
+func NewDPoPProofs() *dpopProofs {
+	return &dpopProofs{expires: map[string]time.Time{}}
+}
+
+func (p *dpopProofs) Use(key string, ttl time.Duration) (bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	if expires, ok := p.expires[key]; ok && now.Before(expires) {
+		return false, nil
+	}
+	p.expires[key] = now.Add(ttl)
+	return true, nil
+}
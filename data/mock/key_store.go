@@ -1,19 +1,19 @@
 package mock
 
-import "crypto/rsa"
+import "crypto"
 
 type keyStore struct {
-	key *rsa.PrivateKey
+	key crypto.Signer
 }
 
-func NewKeyStore(key *rsa.PrivateKey) *keyStore {
+func NewKeyStore(key crypto.Signer) *keyStore {
 	return &keyStore{key}
 }
 
-func (ks *keyStore) Key() *rsa.PrivateKey {
+func (ks *keyStore) Key() crypto.Signer {
 	return ks.key
 }
 
-func (ks *keyStore) Keys() []*rsa.PrivateKey {
-	return []*rsa.PrivateKey{ks.key}
+func (ks *keyStore) Keys() []crypto.Signer {
+	return []crypto.Signer{ks.key}
 }
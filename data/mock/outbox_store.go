@@ -0,0 +1,152 @@
+package mock
+
+import (
+	"sync"
+	"time"
+
+	"github.com/keratin/authn-server/models"
+)
+
+type outboxStore struct {
+	mutex    sync.Mutex
+	lastID   int
+	messages map[int]*models.OutboxMessage
+}
+
+func NewOutboxStore() *outboxStore {
+	return &outboxStore{messages: map[int]*models.OutboxMessage{}}
+}
+
+func (s *outboxStore) Create(kind string, destination string, payload string) (*models.OutboxMessage, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	s.lastID++
+	msg := &models.OutboxMessage{
+		ID:            s.lastID,
+		Kind:          kind,
+		Destination:   destination,
+		Payload:       payload,
+		Status:        models.OutboxPending,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+	s.messages[msg.ID] = msg
+	dup := *msg
+	return &dup, nil
+}
+
+func (s *outboxStore) Find(id int) (*models.OutboxMessage, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	msg, ok := s.messages[id]
+	if !ok {
+		return nil, nil
+	}
+	dup := *msg
+	return &dup, nil
+}
+
+func (s *outboxStore) FindDue(now time.Time, limit int) ([]*models.OutboxMessage, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	msgs := []*models.OutboxMessage{}
+	for id := 1; id <= s.lastID && len(msgs) < limit; id++ {
+		msg, ok := s.messages[id]
+		if ok && msg.Status == models.OutboxPending && !msg.NextAttemptAt.After(now) {
+			dup := *msg
+			msgs = append(msgs, &dup)
+		}
+	}
+	return msgs, nil
+}
+
+func (s *outboxStore) Delivered(id int, responseCode int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if msg, ok := s.messages[id]; ok {
+		now := time.Now()
+		msg.Status = models.OutboxDelivered
+		msg.Attempts++
+		msg.ResponseCode = responseCode
+		msg.LastError = ""
+		msg.DeliveredAt = &now
+	}
+	return nil
+}
+
+func (s *outboxStore) Failed(id int, responseCode int, lastError string, retryAt *time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if msg, ok := s.messages[id]; ok {
+		msg.Attempts++
+		msg.ResponseCode = responseCode
+		msg.LastError = lastError
+		if retryAt == nil {
+			msg.Status = models.OutboxFailed
+		} else {
+			msg.NextAttemptAt = *retryAt
+		}
+	}
+	return nil
+}
+
+func (s *outboxStore) FindRecent(kind string, status string, limit int) ([]*models.OutboxMessage, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	msgs := []*models.OutboxMessage{}
+	for id := s.lastID; id > 0 && len(msgs) < limit; id-- {
+		msg, ok := s.messages[id]
+		if ok && (kind == "" || msg.Kind == kind) && (status == "" || msg.Status == status) {
+			dup := *msg
+			msgs = append(msgs, &dup)
+		}
+	}
+	return msgs, nil
+}
+
+func (s *outboxStore) Redeliver(id int) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	msg, ok := s.messages[id]
+	if !ok {
+		return false, nil
+	}
+	msg.Status = models.OutboxPending
+	msg.Attempts = 0
+	msg.NextAttemptAt = time.Now()
+	msg.DeliveredAt = nil
+	return true, nil
+}
+
+func (s *outboxStore) CountByStatus() (map[string]int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	counts := map[string]int{}
+	for _, msg := range s.messages {
+		counts[msg.Status]++
+	}
+	return counts, nil
+}
+
+func (s *outboxStore) Prune(before time.Time) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pruned := 0
+	for id, msg := range s.messages {
+		if msg.Status == models.OutboxDelivered && msg.CreatedAt.Before(before) {
+			delete(s.messages, id)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
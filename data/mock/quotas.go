@@ -0,0 +1,54 @@
+package mock
+
+import (
+	"strconv"
+	"time"
+)
+
+type quotas struct {
+	hits map[string][]time.Time
+}
+
+func NewQuotas() *quotas {
+	return &quotas{
+		hits: make(map[string][]time.Time, 0),
+	}
+}
+
+func (q *quotas) Count(key string, window time.Duration) (int, error) {
+	return len(q.inWindow(key, window)), nil
+}
+
+func (q *quotas) Hit(key string, window time.Duration) error {
+	k := windowKey(key, window)
+	q.hits[k] = append(q.inWindow(key, window), time.Now())
+	return nil
+}
+
+func (q *quotas) Reset(key string, window time.Duration) error {
+	delete(q.hits, windowKey(key, window))
+	return nil
+}
+
+func (q *quotas) Expiry(key string, window time.Duration) (time.Time, error) {
+	hits := q.inWindow(key, window)
+	if len(hits) == 0 {
+		return time.Time{}, nil
+	}
+	return hits[0].Add(window), nil
+}
+
+func (q *quotas) inWindow(key string, window time.Duration) []time.Time {
+	since := time.Now().Add(-window)
+	hits := []time.Time{}
+	for _, hit := range q.hits[windowKey(key, window)] {
+		if hit.After(since) {
+			hits = append(hits, hit)
+		}
+	}
+	return hits
+}
+
+func windowKey(key string, window time.Duration) string {
+	return key + ":" + strconv.FormatInt(int64(window/time.Millisecond), 10)
+}
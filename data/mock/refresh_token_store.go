@@ -30,11 +30,35 @@ func (s *refreshTokenStore) Create(accountID int) (models.RefreshToken, error) {
 	return token, nil
 }
 
+func (s *refreshTokenStore) CreateLimited(accountID int, max int, evict bool) (models.RefreshToken, error) {
+	if max > 0 && len(s.tokensByAccount[accountID]) >= max {
+		if !evict {
+			return "", nil
+		}
+		for _, t := range append([]models.RefreshToken{}, s.tokensByAccount[accountID][:len(s.tokensByAccount[accountID])-max+1]...) {
+			s.Revoke(t)
+		}
+	}
+	return s.Create(accountID)
+}
+
 func (s *refreshTokenStore) Find(t models.RefreshToken) (int, error) {
 	return s.accountByToken[t], nil
 }
 
+func (s *refreshTokenStore) FindMany(ts []models.RefreshToken) ([]int, error) {
+	ids := make([]int, len(ts))
+	for i, t := range ts {
+		ids[i] = s.accountByToken[t]
+	}
+	return ids, nil
+}
+
+// Touch moves the token to the end of the account's list, which is kept in order of use.
 func (s *refreshTokenStore) Touch(t models.RefreshToken, accountID int) error {
+	if s.accountByToken[t] == accountID && accountID != 0 {
+		s.tokensByAccount[accountID] = append(without(t, s.tokensByAccount[accountID]), t)
+	}
 	return nil
 }
 
@@ -0,0 +1,48 @@
+package mock
+
+import (
+	"time"
+)
+
+type signups struct {
+	byDay   map[string]int
+	byWeek  map[string]int
+	byMonth map[string]int
+}
+
+func NewSignups() *signups {
+	return &signups{
+		byDay:   make(map[string]int, 0),
+		byWeek:  make(map[string]int, 0),
+		byMonth: make(map[string]int, 0),
+	}
+}
+
+func (s *signups) Track() error {
+	t := time.Now().In(time.UTC)
+	s.byDay[dayKey(t)]++
+	s.byWeek[weekKey(t)]++
+	s.byMonth[monthKey(t)]++
+
+	return nil
+}
+
+func (s *signups) SignupsByDay() (map[string]int, error) {
+	return copyCounts(s.byDay), nil
+}
+
+func (s *signups) SignupsByWeek() (map[string]int, error) {
+	return copyCounts(s.byWeek), nil
+}
+
+func (s *signups) SignupsByMonth() (map[string]int, error) {
+	return copyCounts(s.byMonth), nil
+}
+
+func copyCounts(data map[string]int) map[string]int {
+	counts := make(map[string]int, len(data))
+	for k, v := range data {
+		counts[k] = v
+	}
+	return counts
+}
@@ -0,0 +1,15 @@
+package mock_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/data/testers"
+)
+
+func TestSignups(t *testing.T) {
+	for _, tester := range testers.SignupsTesters {
+		mStore := mock.NewSignups()
+		tester(t, mStore)
+	}
+}
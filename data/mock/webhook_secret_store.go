@@ -0,0 +1,53 @@
+package mock
+
+import (
+	"sync"
+	"time"
+
+	"github.com/keratin/authn-server/models"
+)
+
+type webhookSecretStore struct {
+	mutex   sync.Mutex
+	secrets []*models.WebhookSecret
+}
+
+func NewWebhookSecretStore() *webhookSecretStore {
+	return &webhookSecretStore{}
+}
+
+func (s *webhookSecretStore) Rotate(endpoint string, secret string, expireOthersAt time.Time) (*models.WebhookSecret, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, other := range s.secrets {
+		if other.Endpoint == endpoint && (other.ExpiresAt == nil || other.ExpiresAt.After(expireOthersAt)) {
+			at := expireOthersAt
+			other.ExpiresAt = &at
+		}
+	}
+	created := &models.WebhookSecret{
+		ID:        len(s.secrets) + 1,
+		Endpoint:  endpoint,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}
+	s.secrets = append(s.secrets, created)
+	dup := *created
+	return &dup, nil
+}
+
+func (s *webhookSecretStore) FindActive(endpoint string, now time.Time) ([]*models.WebhookSecret, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	secrets := []*models.WebhookSecret{}
+	for i := len(s.secrets) - 1; i >= 0; i-- {
+		secret := s.secrets[i]
+		if secret.Endpoint == endpoint && (secret.ExpiresAt == nil || secret.ExpiresAt.After(now)) {
+			dup := *secret
+			secrets = append(secrets, &dup)
+		}
+	}
+	return secrets, nil
+}
@@ -0,0 +1,133 @@
+package data
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	dataRedis "github.com/keratin/authn-server/data/redis"
+	"github.com/keratin/authn-server/ops"
+	"github.com/pkg/errors"
+)
+
+const modeTopic = "mode"
+
+// Modes of operation. A read-only server refuses changes but still allows logins and refreshes, so
+// that the database may be migrated safely. A server in maintenance refuses nearly everything.
+const (
+	ModeNormal      = "normal"
+	ModeReadOnly    = "read-only"
+	ModeMaintenance = "maintenance"
+)
+
+// ValidMode reports whether a mode is known.
+func ValidMode(mode string) bool {
+	return mode == ModeNormal || mode == ModeReadOnly || mode == ModeMaintenance
+}
+
+// ModeStore holds the server's mode of operation, so that it may be switched at runtime.
+type ModeStore interface {
+	// Get returns the current mode, or "" when none has been set.
+	Get() (string, error)
+	Set(mode string) error
+}
+
+// NewModeStore shares the mode through Redis when it is available. Otherwise the mode only applies
+// to this server. Reads are cached for the TTL, so other servers notice a change within that time.
+func NewModeStore(redis *redis.Client, ttl time.Duration) ModeStore {
+	if redis == nil {
+		return &memoryModeStore{}
+	}
+	return &cachedModeStore{ModeStore: &dataRedis.ModeStore{Client: redis}, ttl: ttl}
+}
+
+// NewStreamModeStore is NewModeStore for servers that share a stream. Each change is also sent on
+// the stream, and every server reads the mode again as soon as it hears of one. The TTL still
+// applies in case a change is not heard. Failures to send or read are reported.
+func NewStreamModeStore(stream *dataRedis.Stream, ttl time.Duration, reporter ops.ErrorReporter) ModeStore {
+	s := &cachedModeStore{
+		ModeStore: &dataRedis.ModeStore{Client: stream.Client},
+		ttl:       ttl,
+		stream:    stream,
+		reporter:  reporter,
+	}
+	go func() {
+		for {
+			err := stream.Read(modeTopic, func(string) { s.expire() })
+			reporter.ReportError(errors.Wrap(err, "Read"))
+			time.Sleep(time.Second)
+		}
+	}()
+	return s
+}
+
+type memoryModeStore struct {
+	mutex sync.RWMutex
+	mode  string
+}
+
+func (s *memoryModeStore) Get() (string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.mode, nil
+}
+
+func (s *memoryModeStore) Set(mode string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.mode = mode
+	return nil
+}
+
+// cachedModeStore avoids a round trip to Redis on every request. When Redis fails, it continues
+// with the last mode that it read.
+type cachedModeStore struct {
+	ModeStore
+	ttl       time.Duration
+	stream    *dataRedis.Stream
+	reporter  ops.ErrorReporter
+	mutex     sync.Mutex
+	mode      string
+	expiresAt time.Time
+}
+
+func (s *cachedModeStore) Get() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if time.Now().Before(s.expiresAt) {
+		return s.mode, nil
+	}
+
+	mode, err := s.ModeStore.Get()
+	s.expiresAt = time.Now().Add(s.ttl)
+	if err != nil {
+		return s.mode, err
+	}
+	s.mode = mode
+	return mode, nil
+}
+
+func (s *cachedModeStore) Set(mode string) error {
+	err := s.ModeStore.Set(mode)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	s.mode = mode
+	s.expiresAt = time.Now().Add(s.ttl)
+	s.mutex.Unlock()
+
+	// the mode has been set, and other servers will notice within the TTL regardless
+	if s.stream != nil {
+		if err := s.stream.Add(modeTopic, mode); err != nil {
+			s.reporter.ReportError(errors.Wrap(err, "Add"))
+		}
+	}
+	return nil
+}
+
+func (s *cachedModeStore) expire() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.expiresAt = time.Time{}
+}
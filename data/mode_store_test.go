@@ -0,0 +1,78 @@
+package data_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	dataRedis "github.com/keratin/authn-server/data/redis"
+	"github.com/keratin/authn-server/ops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModeStore(t *testing.T) {
+	t.Run("in memory", func(t *testing.T) {
+		store := data.NewModeStore(nil, time.Minute)
+
+		mode, err := store.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "", mode)
+
+		require.NoError(t, store.Set(data.ModeReadOnly))
+		mode, err = store.Get()
+		require.NoError(t, err)
+		assert.Equal(t, data.ModeReadOnly, mode)
+	})
+
+	t.Run("in redis", func(t *testing.T) {
+		client, err := dataRedis.TestDB()
+		require.NoError(t, err)
+		client.FlushDB()
+		store := data.NewModeStore(client, 50*time.Millisecond)
+		other := data.NewModeStore(client, 50*time.Millisecond)
+
+		mode, err := other.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "", mode)
+
+		require.NoError(t, store.Set(data.ModeMaintenance))
+		mode, err = store.Get()
+		require.NoError(t, err)
+		assert.Equal(t, data.ModeMaintenance, mode)
+
+		// the other server notices after its cache expires
+		mode, err = other.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "", mode)
+		time.Sleep(60 * time.Millisecond)
+		mode, err = other.Get()
+		require.NoError(t, err)
+		assert.Equal(t, data.ModeMaintenance, mode)
+	})
+
+	t.Run("through a stream", func(t *testing.T) {
+		client, err := dataRedis.TestDB()
+		require.NoError(t, err)
+		client.FlushDB()
+		// the other server has read the stream before
+		require.NoError(t, client.XGroupCreateMkStream("bus", "there:mode", "$").Err())
+		reporter := &ops.LogReporter{}
+		store := data.NewStreamModeStore(&dataRedis.Stream{Client: client, Name: "here"}, time.Hour, reporter)
+		other := data.NewStreamModeStore(&dataRedis.Stream{Client: client, Name: "there"}, time.Hour, reporter)
+
+		mode, err := other.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "", mode)
+
+		// the other server notices long before its cache expires
+		require.NoError(t, store.Set(data.ModeReadOnly))
+		assert.Eventually(t, func() bool {
+			mode, err := other.Get()
+			return err == nil && mode == data.ModeReadOnly
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	assert.True(t, data.ValidMode(data.ModeNormal))
+	assert.False(t, data.ValidMode("offline"))
+}
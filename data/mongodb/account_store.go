@@ -0,0 +1,403 @@
+package mongodb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/keratin/authn-server/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AccountStore keeps each account in a single document, along with its OAuth identities, username
+// history, and archived username. Changes that span several tables in the SQL stores are therefore
+// atomic without a transaction.
+type AccountStore struct {
+	*mongo.Database
+}
+
+type accountDoc struct {
+	ID                 int                 `bson:"_id"`
+	Username           string              `bson:"username"`
+	Password           []byte              `bson:"password"`
+	Locked             bool                `bson:"locked"`
+	RequireNewPassword bool                `bson:"require_new_password"`
+	PasswordChangedAt  time.Time           `bson:"password_changed_at"`
+	CreatedAt          time.Time           `bson:"created_at"`
+	UpdatedAt          time.Time           `bson:"updated_at"`
+	DeletedAt          *time.Time          `bson:"deleted_at"`
+	ArchiveAt          *time.Time          `bson:"archive_at"`
+	ExpiresAt          *time.Time          `bson:"expires_at"`
+	ThrottledUntil     *time.Time          `bson:"throttled_until"`
+	ExternalID         *string             `bson:"external_id,omitempty"`
+	LegalHold          bool                `bson:"legal_hold"`
+	Version            int                 `bson:"lock_version"`
+	OauthAccounts      []oauthAccountDoc   `bson:"oauth_accounts"`
+	UsernameChanges    []usernameChangeDoc `bson:"username_changes"`
+	ArchivedUsername   *archivedUsername   `bson:"archived_username,omitempty"`
+}
+
+type oauthAccountDoc struct {
+	ID          int       `bson:"id"`
+	Provider    string    `bson:"provider"`
+	ProviderID  string    `bson:"provider_id"`
+	AccessToken string    `bson:"access_token"`
+	CreatedAt   time.Time `bson:"created_at"`
+	UpdatedAt   time.Time `bson:"updated_at"`
+}
+
+type usernameChangeDoc struct {
+	ID        int       `bson:"id"`
+	From      string    `bson:"from"`
+	To        string    `bson:"to"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type archivedUsername struct {
+	Username  []byte    `bson:"username"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func (doc *accountDoc) account() *models.Account {
+	return &models.Account{
+		ID:                 doc.ID,
+		Username:           doc.Username,
+		Password:           doc.Password,
+		Locked:             doc.Locked,
+		RequireNewPassword: doc.RequireNewPassword,
+		PasswordChangedAt:  doc.PasswordChangedAt,
+		CreatedAt:          doc.CreatedAt,
+		UpdatedAt:          doc.UpdatedAt,
+		DeletedAt:          doc.DeletedAt,
+		ArchiveAt:          doc.ArchiveAt,
+		ExpiresAt:          doc.ExpiresAt,
+		ThrottledUntil:     doc.ThrottledUntil,
+		ExternalID:         doc.ExternalID,
+		LegalHold:          doc.LegalHold,
+		Version:            doc.Version,
+	}
+}
+
+func (db *AccountStore) accounts() *mongo.Collection {
+	return db.Collection("accounts")
+}
+
+// findDoc returns the matching document, or nil.
+func (db *AccountStore) findDoc(filter bson.M, opts ...*options.FindOneOptions) (*accountDoc, error) {
+	doc := accountDoc{}
+	err := db.accounts().FindOne(context.Background(), filter, opts...).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (db *AccountStore) findAccount(filter bson.M, opts ...*options.FindOneOptions) (*models.Account, error) {
+	doc, err := db.findDoc(filter, opts...)
+	if doc == nil || err != nil {
+		return nil, err
+	}
+	return doc.account(), nil
+}
+
+func (db *AccountStore) update(id int, set bson.M) error {
+	set["updated_at"] = time.Now()
+	_, err := db.accounts().UpdateOne(context.Background(), bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+func (db *AccountStore) Find(id int) (*models.Account, error) {
+	account, err := db.findAccount(bson.M{"_id": id})
+	if account != nil && account.DeletedAt != nil {
+		account.Username = ""
+	}
+	return account, err
+}
+
+// FindMany finds every account in one query.
+func (db *AccountStore) FindMany(ids []int) ([]*models.Account, error) {
+	ctx := context.Background()
+	cursor, err := db.accounts().Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	docs := []accountDoc{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	accounts := make([]*models.Account, len(docs))
+	for i := range docs {
+		accounts[i] = docs[i].account()
+		if accounts[i].DeletedAt != nil {
+			accounts[i].Username = ""
+		}
+	}
+	return accounts, nil
+}
+
+func (db *AccountStore) FindByUsername(u string) (*models.Account, error) {
+	return db.findAccount(
+		bson.M{"username": u, "deleted_at": nil},
+		options.FindOne().SetCollation(caseInsensitive),
+	)
+}
+
+func (db *AccountStore) FindByOauthAccount(provider string, providerID string) (*models.Account, error) {
+	return db.findAccount(bson.M{
+		"oauth_accounts": bson.M{"$elemMatch": bson.M{"provider": provider, "provider_id": providerID}},
+	})
+}
+
+func (db *AccountStore) Create(u string, p []byte) (*models.Account, error) {
+	id, err := nextID(db.Database, "accounts")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	doc := &accountDoc{
+		ID:                id,
+		Username:          u,
+		Password:          p,
+		PasswordChangedAt: now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		OauthAccounts:     []oauthAccountDoc{},
+		UsernameChanges:   []usernameChangeDoc{},
+	}
+	_, err = db.accounts().InsertOne(context.Background(), doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.account(), nil
+}
+
+// AddOauthAccount allows one identity per provider. The unique index only applies across
+// accounts, so the account's own identities are checked by the update.
+func (db *AccountStore) AddOauthAccount(accountID int, provider string, providerID string, accessToken string) error {
+	id, err := nextID(db.Database, "oauth_accounts")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result, err := db.accounts().UpdateOne(
+		context.Background(),
+		bson.M{"_id": accountID, "oauth_accounts.provider": bson.M{"$ne": provider}},
+		bson.M{"$push": bson.M{"oauth_accounts": oauthAccountDoc{
+			ID:          id,
+			Provider:    provider,
+			ProviderID:  providerID,
+			AccessToken: accessToken,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		doc, err := db.findDoc(bson.M{"_id": accountID})
+		if err != nil || doc == nil {
+			return err
+		}
+		return errDuplicate(fmt.Sprintf("account %d already has a %s identity", accountID, provider))
+	}
+	return nil
+}
+
+func (db *AccountStore) GetOauthAccounts(accountID int) ([]*models.OauthAccount, error) {
+	accounts := []*models.OauthAccount{}
+	doc, err := db.findDoc(bson.M{"_id": accountID})
+	if doc == nil || err != nil {
+		return accounts, err
+	}
+	for _, oa := range doc.OauthAccounts {
+		accounts = append(accounts, &models.OauthAccount{
+			ID:          oa.ID,
+			AccountID:   accountID,
+			Provider:    oa.Provider,
+			ProviderID:  oa.ProviderID,
+			AccessToken: oa.AccessToken,
+			CreatedAt:   oa.CreatedAt,
+			UpdatedAt:   oa.UpdatedAt,
+		})
+	}
+	return accounts, nil
+}
+
+func (db *AccountStore) Archive(id int) error {
+	placeholder := make([]byte, 16)
+	if _, err := rand.Read(placeholder); err != nil {
+		return err
+	}
+	_, err := db.accounts().UpdateOne(context.Background(), bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"username":       "@" + hex.EncodeToString(placeholder),
+		"password":       []byte{},
+		"deleted_at":     time.Now(),
+		"oauth_accounts": []oauthAccountDoc{},
+	}})
+	return err
+}
+
+func (db *AccountStore) Lock(id int) error {
+	return db.update(id, bson.M{"locked": true})
+}
+
+func (db *AccountStore) Unlock(id int) error {
+	return db.update(id, bson.M{"locked": false})
+}
+
+func (db *AccountStore) RequireNewPassword(id int) error {
+	return db.update(id, bson.M{"require_new_password": true})
+}
+
+func (db *AccountStore) SetPassword(id int, p []byte) error {
+	return db.update(id, bson.M{"password": p, "require_new_password": false, "password_changed_at": time.Now()})
+}
+
+func (db *AccountStore) UpgradePassword(id int, p []byte) error {
+	return db.update(id, bson.M{"password": p})
+}
+
+// UpdateUsername only applies when the username is still the one that was read, so that a
+// concurrent change can not be recorded with the wrong history.
+func (db *AccountStore) UpdateUsername(id int, u string) error {
+	doc, err := db.findDoc(bson.M{"_id": id}, options.FindOne().SetProjection(bson.M{"username": 1}))
+	if doc == nil || err != nil {
+		return err
+	}
+	if doc.Username == u {
+		return nil
+	}
+	changeID, err := nextID(db.Database, "username_changes")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = db.accounts().UpdateOne(
+		context.Background(),
+		bson.M{"_id": id, "username": doc.Username},
+		bson.M{
+			"$set":  bson.M{"username": u, "updated_at": now},
+			"$push": bson.M{"username_changes": usernameChangeDoc{ID: changeID, From: doc.Username, To: u, CreatedAt: now}},
+		},
+	)
+	return err
+}
+
+func (db *AccountStore) GetUsernameHistory(id int) ([]*models.UsernameChange, error) {
+	changes := []*models.UsernameChange{}
+	doc, err := db.findDoc(bson.M{"_id": id}, options.FindOne().SetProjection(bson.M{"username_changes": 1}))
+	if doc == nil || err != nil {
+		return changes, err
+	}
+	for _, change := range doc.UsernameChanges {
+		changes = append(changes, &models.UsernameChange{
+			ID:        change.ID,
+			AccountID: id,
+			From:      change.From,
+			To:        change.To,
+			CreatedAt: change.CreatedAt,
+		})
+	}
+	return changes, nil
+}
+
+func (db *AccountStore) ScheduleArchive(id int, at time.Time) error {
+	return db.update(id, bson.M{"archive_at": at})
+}
+
+func (db *AccountStore) CancelArchive(id int) error {
+	return db.update(id, bson.M{"archive_at": nil})
+}
+
+func (db *AccountStore) FindScheduledArchives(before time.Time) ([]int, error) {
+	return findIDs(db.accounts(), bson.M{"archive_at": bson.M{"$lte": before}, "deleted_at": nil})
+}
+
+func (db *AccountStore) SetExpiry(id int, at *time.Time) error {
+	return db.update(id, bson.M{"expires_at": at})
+}
+
+func (db *AccountStore) FindExpired(before time.Time) ([]int, error) {
+	return findIDs(db.accounts(), bson.M{"expires_at": bson.M{"$lte": before}, "deleted_at": nil})
+}
+
+func (db *AccountStore) SetThrottledUntil(id int, until *time.Time) error {
+	return db.update(id, bson.M{"throttled_until": until})
+}
+
+func (db *AccountStore) SetLegalHold(id int, held bool) error {
+	return db.update(id, bson.M{"legal_hold": held})
+}
+
+func (db *AccountStore) SetExternalID(id int, externalID string) error {
+	return db.update(id, bson.M{"external_id": externalID})
+}
+
+func (db *AccountStore) FindByExternalID(externalID string) (*models.Account, error) {
+	return db.findAccount(bson.M{"external_id": externalID})
+}
+
+func (db *AccountStore) SaveArchivedUsername(id int, u []byte) error {
+	_, err := db.accounts().UpdateOne(context.Background(), bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"archived_username": archivedUsername{Username: u, CreatedAt: time.Now()},
+	}})
+	return err
+}
+
+func (db *AccountStore) FindArchivedUsername(id int) ([]byte, error) {
+	doc, err := db.findDoc(bson.M{"_id": id}, options.FindOne().SetProjection(bson.M{"archived_username": 1}))
+	if doc == nil || doc.ArchivedUsername == nil || err != nil {
+		return nil, err
+	}
+	return doc.ArchivedUsername.Username, nil
+}
+
+func (db *AccountStore) Restore(id int, u string) error {
+	_, err := db.accounts().UpdateOne(context.Background(), bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"username":             u,
+			"deleted_at":           nil,
+			"archive_at":           nil,
+			"require_new_password": true,
+			"updated_at":           time.Now(),
+		},
+		"$unset": bson.M{"archived_username": ""},
+	})
+	return err
+}
+
+func (db *AccountStore) PurgeArchivedUsernames(before time.Time) (int, error) {
+	result, err := db.accounts().UpdateMany(
+		context.Background(),
+		bson.M{"archived_username.created_at": bson.M{"$lt": before}, "legal_hold": bson.M{"$ne": true}},
+		bson.M{"$unset": bson.M{"archived_username": ""}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.ModifiedCount), nil
+}
+
+func (db *AccountStore) ClaimVersion(id int, version int) (bool, error) {
+	result, err := db.accounts().UpdateOne(
+		context.Background(),
+		bson.M{"_id": id, "lock_version": version},
+		bson.M{"$inc": bson.M{"lock_version": 1}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
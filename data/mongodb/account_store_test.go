@@ -0,0 +1,56 @@
+package mongodb_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/keratin/authn-server/data/mongodb"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func newTestDB() (*mongo.Database, error) {
+	str, ok := os.LookupEnv("TEST_MONGODB_URL")
+	if !ok {
+		return nil, fmt.Errorf("set TEST_MONGODB_URL for MongoDB tests")
+	}
+
+	dbURL, err := url.Parse(str)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := mongodb.NewDB(dbURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewDB")
+	}
+
+	if err := mongodb.MigrateDB(db); err != nil {
+		return nil, errors.Wrap(err, "MigrateDB")
+	}
+
+	return db, nil
+}
+
+// deleteAll empties the collections without dropping their indexes.
+func deleteAll(db *mongo.Database, collections ...string) {
+	for _, collection := range collections {
+		db.Collection(collection).DeleteMany(context.Background(), bson.M{})
+	}
+}
+
+func TestAccountStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &mongodb.AccountStore{db}
+	for _, tester := range testers.AccountStoreTesters {
+		deleteAll(db, "accounts")
+		tester(t, store)
+	}
+}
@@ -0,0 +1,149 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/keratin/authn-server/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AnnotationStore struct {
+	*mongo.Database
+}
+
+type tagDoc struct {
+	AccountID int    `bson:"account_id"`
+	Tag       string `bson:"tag"`
+}
+
+type noteDoc struct {
+	ID        int       `bson:"_id"`
+	AccountID int       `bson:"account_id"`
+	Body      string    `bson:"body"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type metadataDoc struct {
+	Name  string `bson:"name"`
+	Value string `bson:"value"`
+}
+
+func (db *AnnotationStore) Tag(accountID int, tag string) error {
+	doc := tagDoc{AccountID: accountID, Tag: tag}
+	_, err := db.Collection("account_tags").UpdateOne(
+		context.Background(),
+		doc,
+		bson.M{"$setOnInsert": bson.M{"created_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (db *AnnotationStore) Untag(accountID int, tag string) error {
+	_, err := db.Collection("account_tags").DeleteOne(context.Background(), tagDoc{AccountID: accountID, Tag: tag})
+	return err
+}
+
+func (db *AnnotationStore) GetTags(accountID int) ([]string, error) {
+	docs, err := db.findTags(bson.M{"account_id": accountID}, bson.M{"tag": 1})
+	tags := []string{}
+	for _, doc := range docs {
+		tags = append(tags, doc.Tag)
+	}
+	return tags, err
+}
+
+func (db *AnnotationStore) FindByTag(tag string) ([]int, error) {
+	docs, err := db.findTags(bson.M{"tag": tag}, bson.M{"account_id": 1})
+	ids := []int{}
+	for _, doc := range docs {
+		ids = append(ids, doc.AccountID)
+	}
+	return ids, err
+}
+
+func (db *AnnotationStore) findTags(filter bson.M, sort bson.M) ([]tagDoc, error) {
+	ctx := context.Background()
+	cursor, err := db.Collection("account_tags").Find(ctx, filter, options.Find().SetSort(sort))
+	if err != nil {
+		return nil, err
+	}
+	docs := []tagDoc{}
+	err = cursor.All(ctx, &docs)
+	return docs, err
+}
+
+func (db *AnnotationStore) AddNote(accountID int, body string) (*models.AccountNote, error) {
+	id, err := nextID(db.Database, "account_notes")
+	if err != nil {
+		return nil, err
+	}
+
+	doc := noteDoc{ID: id, AccountID: accountID, Body: body, CreatedAt: time.Now()}
+	_, err = db.Collection("account_notes").InsertOne(context.Background(), doc)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AccountNote{ID: doc.ID, AccountID: doc.AccountID, Body: doc.Body, CreatedAt: doc.CreatedAt}, nil
+}
+
+func (db *AnnotationStore) GetNotes(accountID int) ([]*models.AccountNote, error) {
+	ctx := context.Background()
+	cursor, err := db.Collection("account_notes").Find(ctx, bson.M{"account_id": accountID}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	docs := []noteDoc{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	notes := []*models.AccountNote{}
+	for _, doc := range docs {
+		notes = append(notes, &models.AccountNote{ID: doc.ID, AccountID: doc.AccountID, Body: doc.Body, CreatedAt: doc.CreatedAt})
+	}
+	return notes, nil
+}
+
+func (db *AnnotationStore) DeleteNote(accountID int, noteID int) error {
+	_, err := db.Collection("account_notes").DeleteOne(context.Background(), bson.M{"_id": noteID, "account_id": accountID})
+	return err
+}
+
+// SetMetadata keeps each name in its own document, since names may contain characters that are
+// not allowed in field names.
+func (db *AnnotationStore) SetMetadata(accountID int, metadata map[string]string) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+	writes := []mongo.WriteModel{}
+	for name, value := range metadata {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"account_id": accountID, "name": name}).
+			SetUpdate(bson.M{"$set": bson.M{"value": value}}).
+			SetUpsert(true))
+	}
+	_, err := db.Collection("account_metadata").BulkWrite(context.Background(), writes)
+	return err
+}
+
+func (db *AnnotationStore) GetMetadata(accountID int) (map[string]string, error) {
+	ctx := context.Background()
+	cursor, err := db.Collection("account_metadata").Find(ctx, bson.M{"account_id": accountID})
+	if err != nil {
+		return nil, err
+	}
+	docs := []metadataDoc{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]string{}
+	for _, doc := range docs {
+		metadata[doc.Name] = doc.Value
+	}
+	return metadata, nil
+}
@@ -0,0 +1,19 @@
+package mongodb_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mongodb"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotationStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &mongodb.AnnotationStore{db}
+	for _, tester := range testers.AnnotationStoreTesters {
+		deleteAll(db, "account_tags", "account_notes", "account_metadata")
+		tester(t, store)
+	}
+}
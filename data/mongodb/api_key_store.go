@@ -0,0 +1,96 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/keratin/authn-server/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyStore struct {
+	*mongo.Database
+}
+
+type apiKeyDoc struct {
+	ID         int        `bson:"_id"`
+	Name       string     `bson:"name"`
+	Scopes     string     `bson:"scopes"`
+	SecretHash string     `bson:"secret_hash"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	RevokedAt  *time.Time `bson:"revoked_at"`
+}
+
+func (doc *apiKeyDoc) key() *models.APIKey {
+	return &models.APIKey{
+		ID:         doc.ID,
+		Name:       doc.Name,
+		Scopes:     doc.Scopes,
+		SecretHash: doc.SecretHash,
+		CreatedAt:  doc.CreatedAt,
+		RevokedAt:  doc.RevokedAt,
+	}
+}
+
+func (db *APIKeyStore) Create(name string, scopes string, secretHash string) (*models.APIKey, error) {
+	id, err := nextID(db.Database, "api_keys")
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &apiKeyDoc{
+		ID:         id,
+		Name:       name,
+		Scopes:     scopes,
+		SecretHash: secretHash,
+		CreatedAt:  time.Now(),
+	}
+	_, err = db.Collection("api_keys").InsertOne(context.Background(), doc)
+	if err != nil {
+		return nil, err
+	}
+	return doc.key(), nil
+}
+
+func (db *APIKeyStore) Find(id int) (*models.APIKey, error) {
+	doc := apiKeyDoc{}
+	err := db.Collection("api_keys").FindOne(context.Background(), bson.M{"_id": id}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return doc.key(), nil
+}
+
+func (db *APIKeyStore) FindAll() ([]*models.APIKey, error) {
+	ctx := context.Background()
+	cursor, err := db.Collection("api_keys").Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	docs := []apiKeyDoc{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	keys := []*models.APIKey{}
+	for _, doc := range docs {
+		keys = append(keys, doc.key())
+	}
+	return keys, nil
+}
+
+func (db *APIKeyStore) Revoke(id int) (bool, error) {
+	result, err := db.Collection("api_keys").UpdateOne(
+		context.Background(),
+		bson.M{"_id": id, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
@@ -0,0 +1,19 @@
+package mongodb_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mongodb"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &mongodb.APIKeyStore{db}
+	for _, tester := range testers.APIKeyStoreTesters {
+		deleteAll(db, "api_keys")
+		tester(t, store)
+	}
+}
@@ -0,0 +1,89 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/keratin/authn-server/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ConsentStore struct {
+	*mongo.Database
+}
+
+type consentDoc struct {
+	ID             int       `bson:"_id"`
+	AccountID      int       `bson:"account_id"`
+	TermsVersion   string    `bson:"terms_version"`
+	MarketingOptIn bool      `bson:"marketing_opt_in"`
+	IP             string    `bson:"ip"`
+	CreatedAt      time.Time `bson:"created_at"`
+}
+
+func (doc *consentDoc) consent() *models.Consent {
+	return &models.Consent{
+		ID:             doc.ID,
+		AccountID:      doc.AccountID,
+		TermsVersion:   doc.TermsVersion,
+		MarketingOptIn: doc.MarketingOptIn,
+		IP:             doc.IP,
+		CreatedAt:      doc.CreatedAt,
+	}
+}
+
+func (db *ConsentStore) Create(accountID int, termsVersion string, marketingOptIn bool, ip string) (*models.Consent, error) {
+	id, err := nextID(db.Database, "account_consents")
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &consentDoc{
+		ID:             id,
+		AccountID:      accountID,
+		TermsVersion:   termsVersion,
+		MarketingOptIn: marketingOptIn,
+		IP:             ip,
+		CreatedAt:      time.Now(),
+	}
+	_, err = db.Collection("account_consents").InsertOne(context.Background(), doc)
+	if err != nil {
+		return nil, err
+	}
+	return doc.consent(), nil
+}
+
+func (db *ConsentStore) Latest(accountID int) (*models.Consent, error) {
+	doc := consentDoc{}
+	err := db.Collection("account_consents").FindOne(
+		context.Background(),
+		bson.M{"account_id": accountID},
+		options.FindOne().SetSort(bson.M{"_id": -1}),
+	).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return doc.consent(), nil
+}
+
+func (db *ConsentStore) FindAll(accountID int) ([]*models.Consent, error) {
+	ctx := context.Background()
+	cursor, err := db.Collection("account_consents").Find(ctx, bson.M{"account_id": accountID}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	docs := []consentDoc{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	consents := []*models.Consent{}
+	for _, doc := range docs {
+		consents = append(consents, doc.consent())
+	}
+	return consents, nil
+}
@@ -0,0 +1,19 @@
+package mongodb_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mongodb"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsentStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &mongodb.ConsentStore{db}
+	for _, tester := range testers.ConsentStoreTesters {
+		deleteAll(db, "account_consents")
+		tester(t, store)
+	}
+}
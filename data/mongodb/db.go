@@ -0,0 +1,74 @@
+package mongodb
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// caseInsensitive compares strings regardless of case, so that usernames are unique and found
+// regardless of case. Queries must use the same collation as the index to be served by it.
+var caseInsensitive = &options.Collation{Locale: "en", Strength: 2}
+
+// NewDB connects to the database named by the URL's path, or to "authn" when it has none.
+func NewDB(url *url.URL) (*mongo.Database, error) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(url.String()))
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Ping(context.Background(), nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+
+	name := strings.TrimPrefix(url.Path, "/")
+	if name == "" {
+		name = "authn"
+	}
+	return client.Database(name), nil
+}
+
+// nextID increments and returns a named sequence, so that documents may have the same integer IDs
+// as rows in the SQL stores.
+func nextID(db *mongo.Database, name string) (int, error) {
+	counter := struct {
+		Seq int `bson:"seq"`
+	}{}
+	err := db.Collection("counters").FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": name},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	return counter.Seq, err
+}
+
+// findIDs returns the _id of every matching document, in ascending order.
+func findIDs(collection *mongo.Collection, filter bson.M) ([]int, error) {
+	ctx := context.Background()
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}).SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	docs := []struct {
+		ID int `bson:"_id"`
+	}{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	return ids, nil
+}
+
+// errDuplicate looks like a violation of a unique index, so that data.IsUniquenessError also
+// recognizes uniqueness that the stores check for themselves.
+func errDuplicate(message string) error {
+	return mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: message}}}
+}
@@ -0,0 +1,62 @@
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MigrateDB creates the indexes that the stores depend on. Collections are created as they are
+// written, and creating an index that already exists does nothing.
+func MigrateDB(db *mongo.Database) error {
+	indexes := map[string][]mongo.IndexModel{
+		"accounts": {
+			{
+				Keys:    bson.D{{Key: "username", Value: 1}},
+				Options: options.Index().SetUnique(true).SetCollation(caseInsensitive),
+			},
+			{
+				Keys: bson.D{{Key: "external_id", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"external_id": bson.M{"$exists": true}}),
+			},
+			{
+				Keys: bson.D{{Key: "oauth_accounts.provider", Value: 1}, {Key: "oauth_accounts.provider_id", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"oauth_accounts.provider": bson.M{"$exists": true}}),
+			},
+			{Keys: bson.D{{Key: "archive_at", Value: 1}}},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+			{Keys: bson.D{{Key: "archived_username.created_at", Value: 1}}},
+		},
+		"account_tags": {
+			{
+				Keys:    bson.D{{Key: "account_id", Value: 1}, {Key: "tag", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{Keys: bson.D{{Key: "tag", Value: 1}, {Key: "account_id", Value: 1}}},
+		},
+		"account_notes": {
+			{Keys: bson.D{{Key: "account_id", Value: 1}}},
+		},
+		"account_metadata": {
+			{
+				Keys:    bson.D{{Key: "account_id", Value: 1}, {Key: "name", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"account_consents": {
+			{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "_id", Value: 1}}},
+		},
+	}
+
+	for collection, models := range indexes {
+		_, err := db.Collection(collection).Indexes().CreateMany(context.Background(), models)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package mysql
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/models"
+)
+
+type AnnotationStore struct {
+	*sqlx.DB
+}
+
+func (db *AnnotationStore) Tag(accountID int, tag string) error {
+	_, err := db.Exec("INSERT IGNORE INTO account_tags (account_id, tag, created_at) VALUES (?, ?, ?)", accountID, tag, time.Now())
+	return err
+}
+
+func (db *AnnotationStore) Untag(accountID int, tag string) error {
+	_, err := db.Exec("DELETE FROM account_tags WHERE account_id = ? AND tag = ?", accountID, tag)
+	return err
+}
+
+func (db *AnnotationStore) GetTags(accountID int) ([]string, error) {
+	tags := []string{}
+	err := db.Select(&tags, "SELECT tag FROM account_tags WHERE account_id = ? ORDER BY tag", accountID)
+	return tags, err
+}
+
+func (db *AnnotationStore) FindByTag(tag string) ([]int, error) {
+	ids := []int{}
+	err := db.Select(&ids, "SELECT account_id FROM account_tags WHERE tag = ? ORDER BY account_id", tag)
+	return ids, err
+}
+
+func (db *AnnotationStore) AddNote(accountID int, body string) (*models.AccountNote, error) {
+	note := &models.AccountNote{
+		AccountID: accountID,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+
+	result, err := db.NamedExec(`
+        INSERT INTO account_notes (account_id, body, created_at)
+        VALUES (:account_id, :body, :created_at)
+    `, note)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	note.ID = int(id)
+
+	return note, nil
+}
+
+func (db *AnnotationStore) GetNotes(accountID int) ([]*models.AccountNote, error) {
+	notes := []*models.AccountNote{}
+	err := db.Select(&notes, "SELECT * FROM account_notes WHERE account_id = ? ORDER BY id", accountID)
+	return notes, err
+}
+
+func (db *AnnotationStore) DeleteNote(accountID int, noteID int) error {
+	_, err := db.Exec("DELETE FROM account_notes WHERE account_id = ? AND id = ?", accountID, noteID)
+	return err
+}
+
+func (db *AnnotationStore) SetMetadata(accountID int, metadata map[string]string) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	for name, value := range metadata {
+		_, err = tx.Exec("INSERT INTO account_metadata (account_id, name, value) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE value = VALUES(value)", accountID, name, value)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (db *AnnotationStore) GetMetadata(accountID int) (map[string]string, error) {
+	return getMetadata(db.DB, "SELECT name, value FROM account_metadata WHERE account_id = ?", accountID)
+}
+
+func getMetadata(db *sqlx.DB, query string, accountID int) (map[string]string, error) {
+	rows, err := db.Query(query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metadata := map[string]string{}
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		metadata[name] = value
+	}
+	return metadata, rows.Err()
+}
@@ -0,0 +1,20 @@
+package mysql_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mysql"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotationStore(t *testing.T) {
+	db, err := mysql.TestDB()
+	require.NoError(t, err)
+	store := &mysql.AnnotationStore{db}
+	for _, tester := range testers.AnnotationStoreTesters {
+		db.MustExec("TRUNCATE account_tags")
+		db.MustExec("TRUNCATE account_notes")
+		tester(t, store)
+	}
+}
@@ -0,0 +1,19 @@
+package mysql_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mysql"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyStore(t *testing.T) {
+	db, err := mysql.TestDB()
+	require.NoError(t, err)
+	store := &mysql.APIKeyStore{db}
+	for _, tester := range testers.APIKeyStoreTesters {
+		db.MustExec("TRUNCATE api_keys")
+		tester(t, store)
+	}
+}
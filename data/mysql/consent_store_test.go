@@ -0,0 +1,19 @@
+package mysql_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mysql"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsentStore(t *testing.T) {
+	db, err := mysql.TestDB()
+	require.NoError(t, err)
+	store := &mysql.ConsentStore{db}
+	for _, tester := range testers.ConsentStoreTesters {
+		db.MustExec("TRUNCATE account_consents")
+		tester(t, store)
+	}
+}
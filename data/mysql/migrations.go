@@ -10,6 +10,20 @@ func MigrateDB(db *sqlx.DB) error {
 	migrations := []func(db *sqlx.DB) error{
 		createAccounts,
 		createOauthAccounts,
+		createAccountAnnotations,
+		addAccountArchiveAt,
+		createAccountConsents,
+		addAccountExpiresAt,
+		createAccountMetadata,
+		addAccountThrottledUntil,
+		addAccountExternalID,
+		createAPIKeys,
+		createOutboxMessages,
+		createWebhookSecrets,
+		createUsernameChanges,
+		createArchivedUsernames,
+		addAccountLockVersion,
+		addAccountLegalHold,
 	}
 	for _, m := range migrations {
 		if err := m(db); err != nil {
@@ -55,3 +69,213 @@ func createOauthAccounts(db *sqlx.DB) error {
     `)
 	return err
 }
+
+func createAccountAnnotations(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS account_tags (
+            account_id INT(11) NOT NULL,
+            tag VARCHAR(64) NOT NULL,
+            created_at DATETIME NOT NULL,
+            UNIQUE KEY index_account_tags_by_account_id (account_id, tag),
+            KEY index_account_tags_by_tag (tag)
+        )
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS account_notes (
+            id INT(11) NOT NULL AUTO_INCREMENT,
+            account_id INT(11) NOT NULL,
+            body TEXT NOT NULL,
+            created_at DATETIME NOT NULL,
+            PRIMARY KEY (id),
+            KEY index_account_notes_by_account_id (account_id)
+        )
+    `)
+	return err
+}
+
+func addAccountArchiveAt(db *sqlx.DB) error {
+	exists, err := hasColumn(db, "accounts", "archive_at")
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(`
+        ALTER TABLE accounts ADD COLUMN archive_at DATETIME DEFAULT NULL
+    `)
+	return err
+}
+
+func hasColumn(db *sqlx.DB, table string, column string) (bool, error) {
+	var count int
+	err := db.Get(&count, `
+        SELECT COUNT(*) FROM information_schema.columns
+        WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?
+    `, table, column)
+	return count > 0, err
+}
+
+func createAccountConsents(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS account_consents (
+            id INT(11) NOT NULL AUTO_INCREMENT,
+            account_id INT(11) NOT NULL,
+            terms_version VARCHAR(255) NOT NULL,
+            marketing_opt_in TINYINT(1) NOT NULL DEFAULT '0',
+            ip VARCHAR(255) NOT NULL,
+            created_at DATETIME NOT NULL,
+            PRIMARY KEY (id),
+            KEY index_account_consents_by_account_id (account_id)
+        )
+    `)
+	return err
+}
+
+func addAccountExpiresAt(db *sqlx.DB) error {
+	exists, err := hasColumn(db, "accounts", "expires_at")
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(`
+        ALTER TABLE accounts ADD COLUMN expires_at DATETIME DEFAULT NULL
+    `)
+	return err
+}
+
+func createAccountMetadata(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS account_metadata (
+            account_id INT(11) NOT NULL,
+            name VARCHAR(64) NOT NULL,
+            value TEXT NOT NULL,
+            UNIQUE KEY index_account_metadata_by_account_id (account_id, name)
+        )
+    `)
+	return err
+}
+
+func addAccountThrottledUntil(db *sqlx.DB) error {
+	exists, err := hasColumn(db, "accounts", "throttled_until")
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(`
+        ALTER TABLE accounts ADD COLUMN throttled_until DATETIME DEFAULT NULL
+    `)
+	return err
+}
+
+func addAccountExternalID(db *sqlx.DB) error {
+	exists, err := hasColumn(db, "accounts", "external_id")
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(`
+        ALTER TABLE accounts
+            ADD COLUMN external_id VARCHAR(255) DEFAULT NULL,
+            ADD UNIQUE KEY index_accounts_on_external_id (external_id)
+    `)
+	return err
+}
+
+func createAPIKeys(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS api_keys (
+            id INT(11) NOT NULL AUTO_INCREMENT,
+            name VARCHAR(255) NOT NULL,
+            scopes VARCHAR(255) NOT NULL,
+            secret_hash VARCHAR(255) NOT NULL,
+            created_at DATETIME NOT NULL,
+            revoked_at DATETIME DEFAULT NULL,
+            PRIMARY KEY (id)
+        )
+    `)
+	return err
+}
+
+func createOutboxMessages(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS outbox_messages (
+            id INT(11) NOT NULL AUTO_INCREMENT,
+            kind VARCHAR(16) NOT NULL,
+            destination VARCHAR(2048) NOT NULL,
+            payload TEXT NOT NULL,
+            status VARCHAR(16) NOT NULL,
+            attempts INT(11) NOT NULL,
+            response_code INT(11) NOT NULL,
+            last_error VARCHAR(1024) NOT NULL,
+            created_at DATETIME NOT NULL,
+            next_attempt_at DATETIME NOT NULL,
+            delivered_at DATETIME DEFAULT NULL,
+            PRIMARY KEY (id),
+            KEY index_outbox_messages_by_status (status, next_attempt_at)
+        )
+    `)
+	return err
+}
+
+func createWebhookSecrets(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS webhook_secrets (
+            id INT(11) NOT NULL AUTO_INCREMENT,
+            endpoint VARCHAR(64) NOT NULL,
+            secret VARCHAR(255) NOT NULL,
+            created_at DATETIME NOT NULL,
+            expires_at DATETIME DEFAULT NULL,
+            PRIMARY KEY (id),
+            KEY index_webhook_secrets_by_endpoint (endpoint)
+        )
+    `)
+	return err
+}
+
+func createUsernameChanges(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS username_changes (
+            id INT(11) NOT NULL AUTO_INCREMENT,
+            account_id INT(11) NOT NULL,
+            from_username VARCHAR(255) NOT NULL,
+            to_username VARCHAR(255) NOT NULL,
+            created_at DATETIME NOT NULL,
+            PRIMARY KEY (id),
+            KEY index_username_changes_by_account_id (account_id)
+        )
+    `)
+	return err
+}
+
+func createArchivedUsernames(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS archived_usernames (
+            account_id INT(11) NOT NULL,
+            username VARBINARY(1024) NOT NULL,
+            created_at DATETIME NOT NULL,
+            PRIMARY KEY (account_id),
+            KEY index_archived_usernames_by_created_at (created_at)
+        )
+    `)
+	return err
+}
+
+func addAccountLockVersion(db *sqlx.DB) error {
+	exists, err := hasColumn(db, "accounts", "lock_version")
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(`
+        ALTER TABLE accounts ADD COLUMN lock_version INT(11) NOT NULL DEFAULT 0
+    `)
+	return err
+}
+
+func addAccountLegalHold(db *sqlx.DB) error {
+	exists, err := hasColumn(db, "accounts", "legal_hold")
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(`
+        ALTER TABLE accounts ADD COLUMN legal_hold TINYINT(1) NOT NULL DEFAULT '0'
+    `)
+	return err
+}
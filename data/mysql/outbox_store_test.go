@@ -0,0 +1,19 @@
+package mysql_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mysql"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxStore(t *testing.T) {
+	db, err := mysql.TestDB()
+	require.NoError(t, err)
+	store := &mysql.OutboxStore{db}
+	for _, tester := range testers.OutboxStoreTesters {
+		db.MustExec("TRUNCATE outbox_messages")
+		tester(t, store)
+	}
+}
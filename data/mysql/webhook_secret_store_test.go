@@ -0,0 +1,19 @@
+package mysql_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mysql"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSecretStore(t *testing.T) {
+	db, err := mysql.TestDB()
+	require.NoError(t, err)
+	store := &mysql.WebhookSecretStore{db}
+	for _, tester := range testers.WebhookSecretStoreTesters {
+		db.MustExec("TRUNCATE webhook_secrets")
+		tester(t, store)
+	}
+}
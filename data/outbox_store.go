@@ -0,0 +1,74 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/data/mysql"
+	"github.com/keratin/authn-server/data/postgres"
+	"github.com/keratin/authn-server/data/sqlite3"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/models"
+)
+
+// OutboxStore persists events and webhooks until they are delivered, so that none are lost if
+// AuthN stops before delivering them.
+type OutboxStore interface {
+	// Creates a pending message that is due immediately.
+	Create(kind string, destination string, payload string) (*models.OutboxMessage, error)
+
+	// Returns the message, or nil.
+	Find(id int) (*models.OutboxMessage, error)
+
+	// Returns pending messages that are due by the time, oldest first.
+	FindDue(now time.Time, limit int) ([]*models.OutboxMessage, error)
+
+	// Records a successful attempt.
+	Delivered(id int, responseCode int) error
+
+	// Records a failed attempt. The message is retried at the given time, or fails if it is nil.
+	Failed(id int, responseCode int, lastError string, retryAt *time.Time) error
+
+	// Returns the most recent messages, newest first, optionally of one kind and status.
+	FindRecent(kind string, status string, limit int) ([]*models.OutboxMessage, error)
+
+	// Makes the message pending and due immediately, with a fresh set of attempts. Returns false
+	// if the message does not exist.
+	Redeliver(id int) (bool, error)
+
+	// Counts messages by status.
+	CountByStatus() (map[string]int, error)
+
+	// Deletes delivered messages that were created before the time, and returns how many.
+	Prune(before time.Time) (int, error)
+}
+
+// EventOutbox is an events.Outbox that persists events in an OutboxStore.
+type EventOutbox struct {
+	Store OutboxStore
+}
+
+// Enqueue creates a pending message with the event's JSON.
+func (o *EventOutbox) Enqueue(e events.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = o.Store.Create(models.OutboxEvent, "", string(payload))
+	return err
+}
+
+func NewOutboxStore(db *sqlx.DB) (OutboxStore, error) {
+	switch db.DriverName() {
+	case "sqlite3":
+		return &sqlite3.OutboxStore{DB: db}, nil
+	case "mysql":
+		return &mysql.OutboxStore{DB: db}, nil
+	case "postgres":
+		return &postgres.OutboxStore{DB: db}, nil
+	default:
+		return nil, fmt.Errorf("unsupported driver: %v", db.DriverName())
+	}
+}
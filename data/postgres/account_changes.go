@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+const accountChangesChannel = "authn_account_changes"
+
+// AccountChanges broadcasts account changes with NOTIFY, and hears them with LISTEN.
+type AccountChanges struct {
+	*sqlx.DB
+}
+
+func (db *AccountChanges) Publish(id int) error {
+	_, err := db.Exec("SELECT pg_notify($1, $2)", accountChangesChannel, strconv.Itoa(id))
+	return err
+}
+
+// Subscribe holds one of the pool's connections for as long as it listens.
+func (db *AccountChanges) Subscribe(handler func(id int)) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+		// a closed connection is discarded rather than returned to the pool still listening
+		defer pgxConn.Close(ctx)
+
+		_, err := pgxConn.Exec(ctx, "LISTEN "+accountChangesChannel)
+		if err != nil {
+			return err
+		}
+		for {
+			notification, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+			if id, err := strconv.Atoi(notification.Payload); err == nil {
+				handler(id)
+			}
+		}
+	})
+}
@@ -1,20 +1,35 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jmoiron/sqlx"
 	"github.com/keratin/authn-server/models"
 )
 
 type AccountStore struct {
 	*sqlx.DB
+	ctx context.Context
+}
+
+// WithContext returns a copy of the store whose queries are canceled with ctx.
+func (db *AccountStore) WithContext(ctx context.Context) *AccountStore {
+	return &AccountStore{DB: db.DB, ctx: ctx}
+}
+
+func (db *AccountStore) context() context.Context {
+	if db.ctx == nil {
+		return context.Background()
+	}
+	return db.ctx
 }
 
 func (db *AccountStore) Find(id int) (*models.Account, error) {
 	account := models.Account{}
-	err := db.Get(&account, "SELECT * FROM accounts WHERE id = $1", id)
+	err := db.GetContext(db.context(), &account, "SELECT * FROM accounts WHERE id = $1", id)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	} else if err != nil {
@@ -26,9 +41,31 @@ func (db *AccountStore) Find(id int) (*models.Account, error) {
 	return &account, nil
 }
 
+// FindMany finds every account in one query.
+func (db *AccountStore) FindMany(ids []int) ([]*models.Account, error) {
+	accounts := []*models.Account{}
+	if len(ids) == 0 {
+		return accounts, nil
+	}
+	query, args, err := sqlx.In("SELECT * FROM accounts WHERE id IN (?) ORDER BY id", ids)
+	if err != nil {
+		return nil, err
+	}
+	err = db.SelectContext(db.context(), &accounts, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		if account.DeletedAt != nil {
+			account.Username = ""
+		}
+	}
+	return accounts, nil
+}
+
 func (db *AccountStore) FindByUsername(u string) (*models.Account, error) {
 	account := models.Account{}
-	err := db.Get(&account, "SELECT * FROM accounts WHERE username = $1 AND deleted_at IS NULL", u)
+	err := db.GetContext(db.context(), &account, "SELECT * FROM accounts WHERE LOWER(username) = LOWER($1) AND deleted_at IS NULL", u)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	} else if err != nil {
@@ -39,7 +76,7 @@ func (db *AccountStore) FindByUsername(u string) (*models.Account, error) {
 
 func (db *AccountStore) FindByOauthAccount(provider string, providerID string) (*models.Account, error) {
 	account := models.Account{}
-	err := db.Get(&account, "SELECT a.* FROM accounts a INNER JOIN oauth_accounts oa ON a.id = oa.account_id WHERE oa.provider = $1 AND oa.provider_id = $2", provider, providerID)
+	err := db.GetContext(db.context(), &account, "SELECT a.* FROM accounts a INNER JOIN oauth_accounts oa ON a.id = oa.account_id WHERE oa.provider = $1 AND oa.provider_id = $2", provider, providerID)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	} else if err != nil {
@@ -59,7 +96,8 @@ func (db *AccountStore) Create(u string, p []byte) (*models.Account, error) {
 		UpdatedAt:         now,
 	}
 
-	result, err := db.NamedQuery(
+	result, err := db.NamedQueryContext(
+		db.context(),
 		`INSERT INTO accounts (
 			username, 
 			password, 
@@ -90,7 +128,7 @@ func (db *AccountStore) Create(u string, p []byte) (*models.Account, error) {
 func (db *AccountStore) AddOauthAccount(accountID int, provider string, providerID string, accessToken string) error {
 	now := time.Now()
 
-	_, err := db.NamedExec(`
+	_, err := db.NamedExecContext(db.context(), `
         INSERT INTO oauth_accounts (account_id, provider, provider_id, access_token, created_at, updated_at)
         VALUES (:account_id, :provider, :provider_id, :access_token, :created_at, :updated_at)
     `, map[string]interface{}{
@@ -106,16 +144,16 @@ func (db *AccountStore) AddOauthAccount(accountID int, provider string, provider
 
 func (db *AccountStore) GetOauthAccounts(accountID int) ([]*models.OauthAccount, error) {
 	accounts := []*models.OauthAccount{}
-	err := db.Select(&accounts, `SELECT * FROM oauth_accounts WHERE account_id = $1`, accountID)
+	err := db.SelectContext(db.context(), &accounts, `SELECT * FROM oauth_accounts WHERE account_id = $1`, accountID)
 	return accounts, err
 }
 
 func (db *AccountStore) Archive(id int) error {
-	_, err := db.Exec("DELETE FROM oauth_accounts WHERE account_id = $1", id)
+	_, err := db.ExecContext(db.context(), "DELETE FROM oauth_accounts WHERE account_id = $1", id)
 	if err != nil {
 		return err
 	}
-	_, err = db.Exec(`
+	_, err = db.ExecContext(db.context(), `
 		UPDATE accounts
 		SET
 			username = CONCAT('@', MD5(RANDOM()::TEXT)),
@@ -126,22 +164,22 @@ func (db *AccountStore) Archive(id int) error {
 }
 
 func (db *AccountStore) Lock(id int) error {
-	_, err := db.Exec("UPDATE accounts SET locked = $1, updated_at = $2 WHERE id = $3", true, time.Now(), id)
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET locked = $1, updated_at = $2 WHERE id = $3", true, time.Now(), id)
 	return err
 }
 
 func (db *AccountStore) Unlock(id int) error {
-	_, err := db.Exec("UPDATE accounts SET locked = $1, updated_at = $2 WHERE id = $3", false, time.Now(), id)
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET locked = $1, updated_at = $2 WHERE id = $3", false, time.Now(), id)
 	return err
 }
 
 func (db *AccountStore) RequireNewPassword(id int) error {
-	_, err := db.Exec("UPDATE accounts SET require_new_password = $1, updated_at = $2 WHERE id = $3", true, time.Now(), id)
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET require_new_password = $1, updated_at = $2 WHERE id = $3", true, time.Now(), id)
 	return err
 }
 
 func (db *AccountStore) SetPassword(id int, p []byte) error {
-	_, err := db.Exec(`
+	_, err := db.ExecContext(db.context(), `
 		UPDATE accounts 
 		SET
 			password = $1,
@@ -153,7 +191,135 @@ func (db *AccountStore) SetPassword(id int, p []byte) error {
 	return err
 }
 
+func (db *AccountStore) UpgradePassword(id int, p []byte) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET password = $1, updated_at = $2 WHERE id = $3", p, time.Now(), id)
+	return err
+}
+
 func (db *AccountStore) UpdateUsername(id int, u string) error {
-	_, err := db.Exec("UPDATE accounts SET username = $1, updated_at = $2 WHERE id = $3", u, time.Now(), id)
+	tx, err := db.BeginTxx(db.context(), nil)
+	if err != nil {
+		return err
+	}
+	var from string
+	err = tx.GetContext(db.context(), &from, "SELECT username FROM accounts WHERE id = $1", id)
+	if err == sql.ErrNoRows {
+		return tx.Rollback()
+	} else if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if from == u {
+		return tx.Rollback()
+	}
+	now := time.Now()
+	_, err = tx.ExecContext(db.context(), "UPDATE accounts SET username = $1, updated_at = $2 WHERE id = $3", u, now, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.ExecContext(db.context(), "INSERT INTO username_changes (account_id, from_username, to_username, created_at) VALUES ($1, $2, $3, $4)", id, from, u, now)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *AccountStore) GetUsernameHistory(id int) ([]*models.UsernameChange, error) {
+	changes := []*models.UsernameChange{}
+	err := db.SelectContext(db.context(), &changes, "SELECT * FROM username_changes WHERE account_id = $1 ORDER BY id", id)
+	return changes, err
+}
+
+func (db *AccountStore) ScheduleArchive(id int, at time.Time) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET archive_at = $1, updated_at = $2 WHERE id = $3", at, time.Now(), id)
 	return err
 }
+
+func (db *AccountStore) CancelArchive(id int) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET archive_at = NULL, updated_at = $1 WHERE id = $2", time.Now(), id)
+	return err
+}
+
+func (db *AccountStore) FindScheduledArchives(before time.Time) ([]int, error) {
+	ids := []int{}
+	err := db.SelectContext(db.context(), &ids, "SELECT id FROM accounts WHERE archive_at <= $1 AND deleted_at IS NULL ORDER BY id", before)
+	return ids, err
+}
+
+func (db *AccountStore) SetExpiry(id int, at *time.Time) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET expires_at = $1, updated_at = $2 WHERE id = $3", at, time.Now(), id)
+	return err
+}
+
+func (db *AccountStore) SetLegalHold(id int, held bool) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET legal_hold = $1, updated_at = $2 WHERE id = $3", held, time.Now(), id)
+	return err
+}
+
+func (db *AccountStore) FindExpired(before time.Time) ([]int, error) {
+	ids := []int{}
+	err := db.SelectContext(db.context(), &ids, "SELECT id FROM accounts WHERE expires_at <= $1 AND deleted_at IS NULL ORDER BY id", before)
+	return ids, err
+}
+
+func (db *AccountStore) SetThrottledUntil(id int, until *time.Time) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET throttled_until = $1, updated_at = $2 WHERE id = $3", until, time.Now(), id)
+	return err
+}
+
+func (db *AccountStore) SetExternalID(id int, externalID string) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET external_id = $1, updated_at = $2 WHERE id = $3", externalID, time.Now(), id)
+	return err
+}
+
+func (db *AccountStore) FindByExternalID(externalID string) (*models.Account, error) {
+	account := models.Account{}
+	err := db.GetContext(db.context(), &account, "SELECT * FROM accounts WHERE external_id = $1", externalID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (db *AccountStore) SaveArchivedUsername(id int, u []byte) error {
+	_, err := db.ExecContext(db.context(), "INSERT INTO archived_usernames (account_id, username, created_at) VALUES ($1, $2, $3) ON CONFLICT (account_id) DO UPDATE SET username = EXCLUDED.username, created_at = EXCLUDED.created_at", id, u, time.Now())
+	return err
+}
+
+func (db *AccountStore) FindArchivedUsername(id int) ([]byte, error) {
+	var username []byte
+	err := db.GetContext(db.context(), &username, "SELECT username FROM archived_usernames WHERE account_id = $1", id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return username, err
+}
+
+func (db *AccountStore) Restore(id int, u string) error {
+	batch := &pgx.Batch{}
+	batch.Queue("UPDATE accounts SET username = $1, deleted_at = NULL, archive_at = NULL, require_new_password = $2, updated_at = $3 WHERE id = $4", u, true, time.Now(), id)
+	batch.Queue("DELETE FROM archived_usernames WHERE account_id = $1", id)
+	return sendBatch(db.context(), db.DB, batch)
+}
+
+func (db *AccountStore) PurgeArchivedUsernames(before time.Time) (int, error) {
+	result, err := db.ExecContext(db.context(), "DELETE FROM archived_usernames WHERE created_at < $1 AND account_id NOT IN (SELECT id FROM accounts WHERE legal_hold = $2)", before, true)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+func (db *AccountStore) ClaimVersion(id int, version int) (bool, error) {
+	result, err := db.ExecContext(db.context(), "UPDATE accounts SET lock_version = lock_version + 1 WHERE id = $1 AND lock_version = $2", id, version)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count == 1, err
+}
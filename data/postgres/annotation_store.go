@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/models"
+)
+
+type AnnotationStore struct {
+	*sqlx.DB
+}
+
+func (db *AnnotationStore) Tag(accountID int, tag string) error {
+	_, err := db.Exec("INSERT INTO account_tags (account_id, tag, created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING", accountID, tag, time.Now())
+	return err
+}
+
+func (db *AnnotationStore) Untag(accountID int, tag string) error {
+	_, err := db.Exec("DELETE FROM account_tags WHERE account_id = $1 AND tag = $2", accountID, tag)
+	return err
+}
+
+func (db *AnnotationStore) GetTags(accountID int) ([]string, error) {
+	tags := []string{}
+	err := db.Select(&tags, "SELECT tag FROM account_tags WHERE account_id = $1 ORDER BY tag", accountID)
+	return tags, err
+}
+
+func (db *AnnotationStore) FindByTag(tag string) ([]int, error) {
+	ids := []int{}
+	err := db.Select(&ids, "SELECT account_id FROM account_tags WHERE tag = $1 ORDER BY account_id", tag)
+	return ids, err
+}
+
+func (db *AnnotationStore) AddNote(accountID int, body string) (*models.AccountNote, error) {
+	note := &models.AccountNote{
+		AccountID: accountID,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+
+	result, err := db.NamedQuery(`
+        INSERT INTO account_notes (account_id, body, created_at)
+        VALUES (:account_id, :body, :created_at)
+        RETURNING id
+    `, note)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+	result.Next()
+	err = result.Scan(&note.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+func (db *AnnotationStore) GetNotes(accountID int) ([]*models.AccountNote, error) {
+	notes := []*models.AccountNote{}
+	err := db.Select(&notes, "SELECT * FROM account_notes WHERE account_id = $1 ORDER BY id", accountID)
+	return notes, err
+}
+
+func (db *AnnotationStore) DeleteNote(accountID int, noteID int) error {
+	_, err := db.Exec("DELETE FROM account_notes WHERE account_id = $1 AND id = $2", accountID, noteID)
+	return err
+}
+
+func (db *AnnotationStore) SetMetadata(accountID int, metadata map[string]string) error {
+	batch := &pgx.Batch{}
+	for name, value := range metadata {
+		batch.Queue("INSERT INTO account_metadata (account_id, name, value) VALUES ($1, $2, $3) ON CONFLICT (account_id, name) DO UPDATE SET value = EXCLUDED.value", accountID, name, value)
+	}
+	return sendBatch(context.Background(), db.DB, batch)
+}
+
+func (db *AnnotationStore) GetMetadata(accountID int) (map[string]string, error) {
+	return getMetadata(db.DB, "SELECT name, value FROM account_metadata WHERE account_id = $1", accountID)
+}
+
+func getMetadata(db *sqlx.DB, query string, accountID int) (map[string]string, error) {
+	rows, err := db.Query(query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metadata := map[string]string{}
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		metadata[name] = value
+	}
+	return metadata, rows.Err()
+}
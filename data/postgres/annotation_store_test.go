@@ -0,0 +1,20 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/postgres"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotationStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &postgres.AnnotationStore{db}
+	for _, tester := range testers.AnnotationStoreTesters {
+		db.MustExec("TRUNCATE account_tags")
+		db.MustExec("TRUNCATE account_notes")
+		tester(t, store)
+	}
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/models"
+)
+
+type APIKeyStore struct {
+	*sqlx.DB
+}
+
+func (db *APIKeyStore) Create(name string, scopes string, secretHash string) (*models.APIKey, error) {
+	key := &models.APIKey{
+		Name:       name,
+		Scopes:     scopes,
+		SecretHash: secretHash,
+		CreatedAt:  time.Now(),
+	}
+
+	result, err := db.NamedQuery(`
+        INSERT INTO api_keys (name, scopes, secret_hash, created_at)
+        VALUES (:name, :scopes, :secret_hash, :created_at)
+        RETURNING id
+    `, key)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+	result.Next()
+	err = result.Scan(&key.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (db *APIKeyStore) Find(id int) (*models.APIKey, error) {
+	key := models.APIKey{}
+	err := db.Get(&key, "SELECT * FROM api_keys WHERE id = $1", id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (db *APIKeyStore) FindAll() ([]*models.APIKey, error) {
+	keys := []*models.APIKey{}
+	err := db.Select(&keys, "SELECT * FROM api_keys ORDER BY id")
+	return keys, err
+}
+
+func (db *APIKeyStore) Revoke(id int) (bool, error) {
+	result, err := db.Exec("UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL", time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
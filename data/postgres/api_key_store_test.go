@@ -0,0 +1,19 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/postgres"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &postgres.APIKeyStore{db}
+	for _, tester := range testers.APIKeyStoreTesters {
+		db.MustExec("TRUNCATE api_keys")
+		tester(t, store)
+	}
+}
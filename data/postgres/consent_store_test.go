@@ -0,0 +1,19 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/postgres"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsentStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &postgres.ConsentStore{db}
+	for _, tester := range testers.ConsentStoreTesters {
+		db.MustExec("TRUNCATE account_consents")
+		tester(t, store)
+	}
+}
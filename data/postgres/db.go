@@ -1,13 +1,41 @@
 package postgres
 
 import (
+	"context"
 	"net/url"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
-	// load pq library with side effects
-	_ "github.com/lib/pq"
 )
 
+// NewDB connects through pgx, which prepares and caches each distinct statement per connection.
+// Connection parameters such as statement_cache_capacity and default_query_exec_mode may be
+// given in the URL's query string.
 func NewDB(url *url.URL) (*sqlx.DB, error) {
-	return sqlx.Connect("postgres", url.String())
+	config, err := pgx.ParseConfig(url.String())
+	if err != nil {
+		return nil, err
+	}
+	// keep the "postgres" driver name, which selects this package's stores and $N placeholders
+	db := sqlx.NewDb(stdlib.OpenDB(*config), "postgres")
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// sendBatch pipelines every queued statement in a single round trip. PostgreSQL runs a batch in
+// an implicit transaction, so either all of its statements apply or none do.
+func sendBatch(ctx context.Context, db *sqlx.DB, batch *pgx.Batch) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		return driverConn.(*stdlib.Conn).Conn().SendBatch(ctx, batch).Close()
+	})
 }
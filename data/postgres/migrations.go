@@ -1,6 +1,10 @@
 package postgres
 
-import "github.com/jmoiron/sqlx"
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
 
 // MigrateDB is committed to doing the work necessary to converge the database
 // in a safe, production-grade fashion. This will mean conditional logic as it
@@ -10,6 +14,22 @@ func MigrateDB(db *sqlx.DB) error {
 	migrations := []func(db *sqlx.DB) error{
 		migrateAccounts,
 		createOauthAccounts,
+		createAccountAnnotations,
+		addAccountArchiveAt,
+		createAccountConsents,
+		addAccountExpiresAt,
+		createAccountMetadata,
+		addAccountThrottledUntil,
+		addAccountExternalID,
+		createAPIKeys,
+		createOutboxMessages,
+		createWebhookSecrets,
+		createUsernameChanges,
+		createArchivedUsernames,
+		addAccountLockVersion,
+		addAccountLegalHold,
+		// fails while usernames collide, so it runs after every migration that can not
+		addAccountFoldedUsernameIndex,
 	}
 	for _, m := range migrations {
 		if err := m(db); err != nil {
@@ -51,3 +71,227 @@ func createOauthAccounts(db *sqlx.DB) error {
     `)
 	return err
 }
+
+func createAccountAnnotations(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS account_tags (
+            account_id INTEGER NOT NULL,
+            tag TEXT NOT NULL,
+            created_at timestamptz NOT NULL,
+            UNIQUE(account_id, tag)
+        )
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE INDEX IF NOT EXISTS account_tags_by_tag ON account_tags (tag)
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS account_notes (
+            id SERIAL PRIMARY KEY,
+            account_id INTEGER NOT NULL,
+            body TEXT NOT NULL,
+            created_at timestamptz NOT NULL
+        )
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE INDEX IF NOT EXISTS account_notes_by_account_id ON account_notes (account_id)
+    `)
+	return err
+}
+
+func addAccountArchiveAt(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        ALTER TABLE accounts ADD COLUMN IF NOT EXISTS archive_at timestamptz DEFAULT NULL
+    `)
+	return err
+}
+
+func createAccountConsents(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS account_consents (
+            id SERIAL PRIMARY KEY,
+            account_id INTEGER NOT NULL,
+            terms_version TEXT NOT NULL,
+            marketing_opt_in boolean NOT NULL DEFAULT false,
+            ip TEXT NOT NULL,
+            created_at timestamptz NOT NULL
+        )
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE INDEX IF NOT EXISTS account_consents_by_account_id ON account_consents (account_id)
+    `)
+	return err
+}
+
+func addAccountExpiresAt(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        ALTER TABLE accounts ADD COLUMN IF NOT EXISTS expires_at timestamptz DEFAULT NULL
+    `)
+	return err
+}
+
+func createAccountMetadata(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS account_metadata (
+            account_id INTEGER NOT NULL,
+            name TEXT NOT NULL,
+            value TEXT NOT NULL,
+            UNIQUE(account_id, name)
+        )
+    `)
+	return err
+}
+
+func addAccountThrottledUntil(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        ALTER TABLE accounts ADD COLUMN IF NOT EXISTS throttled_until timestamptz DEFAULT NULL
+    `)
+	return err
+}
+
+func addAccountExternalID(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        ALTER TABLE accounts ADD COLUMN IF NOT EXISTS external_id TEXT DEFAULT NULL
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE UNIQUE INDEX IF NOT EXISTS accounts_by_external_id ON accounts (external_id)
+    `)
+	return err
+}
+
+func createAPIKeys(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS api_keys (
+            id SERIAL PRIMARY KEY,
+            name TEXT NOT NULL,
+            scopes TEXT NOT NULL,
+            secret_hash TEXT NOT NULL,
+            created_at timestamptz NOT NULL,
+            revoked_at timestamptz DEFAULT NULL
+        )
+    `)
+	return err
+}
+
+func createOutboxMessages(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS outbox_messages (
+            id SERIAL PRIMARY KEY,
+            kind TEXT NOT NULL,
+            destination TEXT NOT NULL,
+            payload TEXT NOT NULL,
+            status TEXT NOT NULL,
+            attempts INTEGER NOT NULL,
+            response_code INTEGER NOT NULL,
+            last_error TEXT NOT NULL,
+            created_at timestamptz NOT NULL,
+            next_attempt_at timestamptz NOT NULL,
+            delivered_at timestamptz DEFAULT NULL
+        )
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE INDEX IF NOT EXISTS outbox_messages_by_status ON outbox_messages (status, next_attempt_at)
+    `)
+	return err
+}
+
+func createWebhookSecrets(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS webhook_secrets (
+            id SERIAL PRIMARY KEY,
+            endpoint TEXT NOT NULL,
+            secret TEXT NOT NULL,
+            created_at timestamptz NOT NULL,
+            expires_at timestamptz DEFAULT NULL
+        )
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE INDEX IF NOT EXISTS webhook_secrets_by_endpoint ON webhook_secrets (endpoint)
+    `)
+	return err
+}
+
+func createUsernameChanges(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS username_changes (
+            id SERIAL PRIMARY KEY,
+            account_id INTEGER NOT NULL,
+            from_username TEXT NOT NULL,
+            to_username TEXT NOT NULL,
+            created_at timestamptz NOT NULL
+        )
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE INDEX IF NOT EXISTS username_changes_by_account_id ON username_changes (account_id)
+    `)
+	return err
+}
+
+func createArchivedUsernames(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS archived_usernames (
+            account_id INTEGER PRIMARY KEY,
+            username BYTEA NOT NULL,
+            created_at timestamptz NOT NULL
+        )
+    `)
+	return err
+}
+
+func addAccountLockVersion(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        ALTER TABLE accounts ADD COLUMN IF NOT EXISTS lock_version INTEGER NOT NULL DEFAULT 0
+    `)
+	return err
+}
+
+// addAccountFoldedUsernameIndex makes usernames unique regardless of case. It fails until any
+// existing collisions have been resolved (see `authn username-collisions`).
+func addAccountFoldedUsernameIndex(db *sqlx.DB) error {
+	var collisions int
+	err := db.Get(&collisions, `
+        SELECT COUNT(*) FROM (
+            SELECT 1 FROM accounts GROUP BY LOWER(username) HAVING COUNT(*) > 1
+        ) AS collisions
+    `)
+	if err != nil {
+		return err
+	}
+	if collisions > 0 {
+		return fmt.Errorf("%d usernames differ only by case: resolve them with `authn username-collisions` and migrate again", collisions)
+	}
+	_, err = db.Exec(`
+        CREATE UNIQUE INDEX IF NOT EXISTS accounts_by_folded_username ON accounts (LOWER(username))
+    `)
+	return err
+}
+
+func addAccountLegalHold(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        ALTER TABLE accounts ADD COLUMN IF NOT EXISTS legal_hold boolean NOT NULL DEFAULT false
+    `)
+	return err
+}
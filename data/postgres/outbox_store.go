@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/models"
+)
+
+type OutboxStore struct {
+	*sqlx.DB
+}
+
+func (db *OutboxStore) Create(kind string, destination string, payload string) (*models.OutboxMessage, error) {
+	now := time.Now()
+	msg := &models.OutboxMessage{
+		Kind:          kind,
+		Destination:   destination,
+		Payload:       payload,
+		Status:        models.OutboxPending,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+
+	result, err := db.NamedQuery(`
+        INSERT INTO outbox_messages (kind, destination, payload, status, attempts, response_code, last_error, created_at, next_attempt_at)
+        VALUES (:kind, :destination, :payload, :status, :attempts, :response_code, :last_error, :created_at, :next_attempt_at)
+        RETURNING id
+    `, msg)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+	result.Next()
+	err = result.Scan(&msg.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+func (db *OutboxStore) Find(id int) (*models.OutboxMessage, error) {
+	msg := models.OutboxMessage{}
+	err := db.Get(&msg, "SELECT * FROM outbox_messages WHERE id = $1", id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (db *OutboxStore) FindDue(now time.Time, limit int) ([]*models.OutboxMessage, error) {
+	msgs := []*models.OutboxMessage{}
+	err := db.Select(&msgs, "SELECT * FROM outbox_messages WHERE status = $1 AND next_attempt_at <= $2 ORDER BY id LIMIT $3", models.OutboxPending, now, limit)
+	return msgs, err
+}
+
+func (db *OutboxStore) Delivered(id int, responseCode int) error {
+	_, err := db.Exec(
+		"UPDATE outbox_messages SET status = $1, attempts = attempts + 1, response_code = $2, last_error = '', delivered_at = $3 WHERE id = $4",
+		models.OutboxDelivered, responseCode, time.Now(), id,
+	)
+	return err
+}
+
+func (db *OutboxStore) Failed(id int, responseCode int, lastError string, retryAt *time.Time) error {
+	if retryAt == nil {
+		_, err := db.Exec(
+			"UPDATE outbox_messages SET status = $1, attempts = attempts + 1, response_code = $2, last_error = $3 WHERE id = $4",
+			models.OutboxFailed, responseCode, lastError, id,
+		)
+		return err
+	}
+	_, err := db.Exec(
+		"UPDATE outbox_messages SET attempts = attempts + 1, response_code = $1, last_error = $2, next_attempt_at = $3 WHERE id = $4",
+		responseCode, lastError, *retryAt, id,
+	)
+	return err
+}
+
+func (db *OutboxStore) FindRecent(kind string, status string, limit int) ([]*models.OutboxMessage, error) {
+	msgs := []*models.OutboxMessage{}
+	err := db.Select(&msgs, "SELECT * FROM outbox_messages WHERE ($1 = '' OR kind = $1) AND ($2 = '' OR status = $2) ORDER BY id DESC LIMIT $3", kind, status, limit)
+	return msgs, err
+}
+
+func (db *OutboxStore) Redeliver(id int) (bool, error) {
+	result, err := db.Exec("UPDATE outbox_messages SET status = $1, attempts = 0, next_attempt_at = $2, delivered_at = NULL WHERE id = $3", models.OutboxPending, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
+
+func (db *OutboxStore) CountByStatus() (map[string]int, error) {
+	rows := []struct {
+		Status string
+		Count  int
+	}{}
+	err := db.Select(&rows, "SELECT status, COUNT(*) AS count FROM outbox_messages GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int{}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (db *OutboxStore) Prune(before time.Time) (int, error) {
+	result, err := db.Exec("DELETE FROM outbox_messages WHERE status = $1 AND created_at < $2", models.OutboxDelivered, before)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	return int(count), err
+}
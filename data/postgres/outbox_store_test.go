@@ -0,0 +1,19 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/postgres"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &postgres.OutboxStore{db}
+	for _, tester := range testers.OutboxStoreTesters {
+		db.MustExec("TRUNCATE outbox_messages")
+		tester(t, store)
+	}
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/models"
+)
+
+type WebhookSecretStore struct {
+	*sqlx.DB
+}
+
+func (db *WebhookSecretStore) Rotate(endpoint string, secret string, expireOthersAt time.Time) (*models.WebhookSecret, error) {
+	now := time.Now()
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(
+		"UPDATE webhook_secrets SET expires_at = $1 WHERE endpoint = $2 AND (expires_at IS NULL OR expires_at > $3)",
+		expireOthersAt, endpoint, expireOthersAt,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	var id int
+	err = tx.Get(
+		&id,
+		"INSERT INTO webhook_secrets (endpoint, secret, created_at) VALUES ($1, $2, $3) RETURNING id",
+		endpoint, secret, now,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WebhookSecret{
+		ID:        id,
+		Endpoint:  endpoint,
+		Secret:    secret,
+		CreatedAt: now,
+	}, nil
+}
+
+func (db *WebhookSecretStore) FindActive(endpoint string, now time.Time) ([]*models.WebhookSecret, error) {
+	secrets := []*models.WebhookSecret{}
+	err := db.Select(&secrets, "SELECT * FROM webhook_secrets WHERE endpoint = $1 AND (expires_at IS NULL OR expires_at > $2) ORDER BY id DESC", endpoint, now)
+	return secrets, err
+}
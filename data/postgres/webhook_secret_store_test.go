@@ -0,0 +1,19 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/postgres"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSecretStore(t *testing.T) {
+	db, err := newTestDB()
+	require.NoError(t, err)
+	store := &postgres.WebhookSecretStore{db}
+	for _, tester := range testers.WebhookSecretStoreTesters {
+		db.MustExec("TRUNCATE webhook_secrets")
+		tester(t, store)
+	}
+}
@@ -0,0 +1,15 @@
+package data
+
+import "time"
+
+// Quotas counts events in sliding windows of time, so that callers may limit how often something
+// happens, e.g. signups from a single IP. A window always ends now, so a client can not double a
+// limit by bursting on either side of a boundary. Quotas in Redis are shared by every instance.
+type Quotas interface {
+	Count(key string, window time.Duration) (int, error)
+	Hit(key string, window time.Duration) error
+	Reset(key string, window time.Duration) error
+	// Expiry returns when the oldest hit in the window will leave it, or the zero time when the
+	// window is empty.
+	Expiry(key string, window time.Duration) (time.Time, error)
+}
@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/keratin/authn-server/lib"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+var accessTokensPrefix = "access_tokens:"
+
+type AccessTokenStore struct {
+	Client *redis.Client
+}
+
+// Create persists the metadata under a hash of the token, so that a copy of Redis can not be used
+// to impersonate clients.
+func (s *AccessTokenStore) Create(meta *models.AccessToken) (string, error) {
+	bytes, err := lib.GenerateToken()
+	if err != nil {
+		return "", errors.Wrap(err, "GenerateToken")
+	}
+	token := hex.EncodeToString(bytes)
+
+	val, err := json.Marshal(meta)
+	if err != nil {
+		return "", errors.Wrap(err, "Marshal")
+	}
+
+	err = s.Client.Set(s.key(token), val, time.Until(meta.ExpiresAt)).Err()
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *AccessTokenStore) Find(token string) (*models.AccessToken, error) {
+	val, err := s.Client.Get(s.key(token)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	meta := models.AccessToken{}
+	err = json.Unmarshal(val, &meta)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unmarshal")
+	}
+	return &meta, nil
+}
+
+// FindMany looks up every token in one round trip.
+func (s *AccessTokenStore) FindMany(tokens []string) ([]*models.AccessToken, error) {
+	metas := make([]*models.AccessToken, len(tokens))
+	if len(tokens) == 0 {
+		return metas, nil
+	}
+
+	keys := make([]string, len(tokens))
+	for i, token := range tokens {
+		keys[i] = s.key(token)
+	}
+	vals, err := s.Client.MGet(keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, val := range vals {
+		str, ok := val.(string)
+		if !ok {
+			continue
+		}
+		meta := models.AccessToken{}
+		err = json.Unmarshal([]byte(str), &meta)
+		if err != nil {
+			return nil, errors.Wrap(err, "Unmarshal")
+		}
+		metas[i] = &meta
+	}
+	return metas, nil
+}
+
+func (s *AccessTokenStore) Revoke(token string) error {
+	return s.Client.Del(s.key(token)).Err()
+}
+
+func (s *AccessTokenStore) key(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return accessTokensPrefix + hex.EncodeToString(sum[:])
+}
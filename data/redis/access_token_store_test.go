@@ -0,0 +1,19 @@
+package redis_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/redis"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessTokenStore(t *testing.T) {
+	client, err := redis.TestDB()
+	require.NoError(t, err)
+	store := &redis.AccessTokenStore{Client: client}
+	for _, tester := range testers.AccessTokenStoreTesters {
+		client.FlushDB()
+		tester(t, store)
+	}
+}
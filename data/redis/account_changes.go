@@ -0,0 +1,59 @@
+package redis
+
+import (
+	"strconv"
+
+	"github.com/go-redis/redis"
+)
+
+const accountChangesChannel = "account-changes"
+
+// AccountChanges broadcasts account changes with PUBLISH, and hears them with SUBSCRIBE.
+type AccountChanges struct {
+	Client *redis.Client
+	// Prefix must match the client's namespace, which applies to PUBLISH but not to SUBSCRIBE.
+	Prefix string
+}
+
+func (s *AccountChanges) Publish(id int) error {
+	return s.Client.Publish(accountChangesChannel, strconv.Itoa(id)).Err()
+}
+
+func (s *AccountChanges) Subscribe(handler func(id int)) error {
+	pubsub := s.Client.Subscribe(s.Prefix + accountChangesChannel)
+	defer pubsub.Close()
+
+	// wait for confirmation, so that a failure to subscribe is returned immediately
+	if _, err := pubsub.Receive(); err != nil {
+		return err
+	}
+	for {
+		msg, err := pubsub.ReceiveMessage()
+		if err != nil {
+			return err
+		}
+		if id, err := strconv.Atoi(msg.Payload); err == nil {
+			handler(id)
+		}
+	}
+}
+
+const accountChangesTopic = "account_changes"
+
+// StreamAccountChanges broadcasts account changes through a Stream, so that a server that loses its
+// connection or restarts hears the changes that it missed.
+type StreamAccountChanges struct {
+	Stream *Stream
+}
+
+func (s *StreamAccountChanges) Publish(id int) error {
+	return s.Stream.Add(accountChangesTopic, strconv.Itoa(id))
+}
+
+func (s *StreamAccountChanges) Subscribe(handler func(id int)) error {
+	return s.Stream.Read(accountChangesTopic, func(payload string) {
+		if id, err := strconv.Atoi(payload); err == nil {
+			handler(id)
+		}
+	})
+}
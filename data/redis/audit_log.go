@@ -0,0 +1,218 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+const auditSequenceKey = "audit:seq"
+const auditAllKey = "audit:all"
+const auditHeadKey = "audit:head"
+
+// auditAppendAttempts is how many times Append retries when other servers append concurrently.
+const auditAppendAttempts = 10
+
+func auditAccountKey(accountID int) string {
+	return fmt.Sprintf("audit:account:%d", accountID)
+}
+
+// auditRecord is stored as a sorted set member. The sequence number is both the score and part of
+// the member, so that identical events remain distinct entries.
+type auditRecord struct {
+	Seq      int64  `json:"seq"`
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
+	events.Event
+}
+
+func (r auditRecord) entry() models.AuditEntry {
+	return models.AuditEntry{
+		Cursor:   strconv.FormatInt(r.Seq, 10),
+		Event:    r.Event,
+		PrevHash: r.PrevHash,
+		Hash:     r.Hash,
+	}
+}
+
+// AuditLog stores events in sorted sets scored by a global sequence number: one set for all events
+// and one per account. The hash of the newest event is kept so that the next may chain from it.
+type AuditLog struct {
+	client *redis.Client
+	key    []byte
+}
+
+func NewAuditLog(client *redis.Client, key []byte) *AuditLog {
+	return &AuditLog{client: client, key: key}
+}
+
+// auditAppendScript appends a record unless another server has appended since the sequence was
+// read. It is a script rather than a WATCH transaction so that its keys are namespaced.
+//
+// KEYS: sequence key, head key, all key, account key
+// ARGV: expected sequence, record sequence, record hash, record member
+var auditAppendScript = redis.NewScript(`
+if (redis.call('GET', KEYS[1]) or '0') ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2])
+redis.call('SET', KEYS[2], ARGV[3])
+redis.call('ZADD', KEYS[3], ARGV[2], ARGV[4])
+redis.call('ZADD', KEYS[4], ARGV[2], ARGV[4])
+return 1
+`)
+
+// Append reads the sequence and the newest hash, and appends only if they are unchanged, so that
+// concurrent appends from other servers can not fork the chain.
+func (l *AuditLog) Append(e events.Event) error {
+	for attempt := 0; attempt < auditAppendAttempts; attempt++ {
+		seq, err := l.client.Get(auditSequenceKey).Int64()
+		if err != nil && err != redis.Nil {
+			return errors.Wrap(err, "Get")
+		}
+		prevHash, err := l.client.Get(auditHeadKey).Result()
+		if err != nil && err != redis.Nil {
+			return errors.Wrap(err, "Get")
+		}
+
+		record := auditRecord{Seq: seq + 1, PrevHash: prevHash, Event: e}
+		record.Hash, err = models.AuditHash(l.key, prevHash, strconv.FormatInt(record.Seq, 10), e)
+		if err != nil {
+			return errors.Wrap(err, "AuditHash")
+		}
+		member, err := json.Marshal(record)
+		if err != nil {
+			return errors.Wrap(err, "Marshal")
+		}
+
+		appended, err := auditAppendScript.Run(
+			l.client,
+			[]string{auditSequenceKey, auditHeadKey, auditAllKey, auditAccountKey(e.AccountID)},
+			seq, record.Seq, record.Hash, string(member),
+		).Int()
+		if err != nil {
+			return errors.Wrap(err, "Run")
+		}
+		if appended == 1 {
+			return nil
+		}
+	}
+	return errors.New("Append: too much contention")
+}
+
+func (l *AuditLog) Find(accountID int, after string, limit int) ([]models.AuditEntry, error) {
+	key := auditAllKey
+	if accountID != 0 {
+		key = auditAccountKey(accountID)
+	}
+
+	max := "+inf"
+	if after != "" {
+		if _, err := strconv.ParseInt(after, 10, 64); err != nil {
+			return nil, errors.Wrap(err, "ParseInt")
+		}
+		max = "(" + after
+	}
+
+	members, err := l.client.ZRevRangeByScore(key, redis.ZRangeBy{
+		Max:   max,
+		Min:   "-inf",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "ZRevRangeByScore")
+	}
+
+	return parseAuditRecords(members)
+}
+
+// FindAfter reads the oldest events first, starting after the cursor.
+func (l *AuditLog) FindAfter(after string, limit int) ([]models.AuditEntry, error) {
+	min := "-inf"
+	if after != "" {
+		if _, err := strconv.ParseInt(after, 10, 64); err != nil {
+			return nil, errors.Wrap(err, "ParseInt")
+		}
+		min = "(" + after
+	}
+
+	members, err := l.client.ZRangeByScore(auditAllKey, redis.ZRangeBy{
+		Min:   min,
+		Max:   "+inf",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "ZRangeByScore")
+	}
+	return parseAuditRecords(members)
+}
+
+func parseAuditRecords(members []string) ([]models.AuditEntry, error) {
+	entries := make([]models.AuditEntry, 0, len(members))
+	for _, member := range members {
+		record := auditRecord{}
+		err := json.Unmarshal([]byte(member), &record)
+		if err != nil {
+			return nil, errors.Wrap(err, "Unmarshal")
+		}
+		entries = append(entries, record.entry())
+	}
+	return entries, nil
+}
+
+// FindBefore reads the oldest events first, and stops at the first event that is not before the
+// time. Events are appended in the order they happen, so every later event is newer.
+func (l *AuditLog) FindBefore(before time.Time, after string, limit int) ([]models.AuditEntry, error) {
+	min := "-inf"
+	if after != "" {
+		if _, err := strconv.ParseInt(after, 10, 64); err != nil {
+			return nil, errors.Wrap(err, "ParseInt")
+		}
+		min = "(" + after
+	}
+
+	members, err := l.client.ZRangeByScore(auditAllKey, redis.ZRangeBy{
+		Min:   min,
+		Max:   "+inf",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "ZRangeByScore")
+	}
+
+	entries := make([]models.AuditEntry, 0, len(members))
+	for _, member := range members {
+		record := auditRecord{}
+		err = json.Unmarshal([]byte(member), &record)
+		if err != nil {
+			return nil, errors.Wrap(err, "Unmarshal")
+		}
+		if !record.Time.Before(before) {
+			break
+		}
+		entries = append(entries, record.entry())
+	}
+	return entries, nil
+}
+
+// Delete removes entries by their sequence number from the log of all events and from the log of
+// their account.
+func (l *AuditLog) Delete(entries []models.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	_, err := l.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		for _, entry := range entries {
+			pipe.ZRemRangeByScore(auditAllKey, entry.Cursor, entry.Cursor)
+			pipe.ZRemRangeByScore(auditAccountKey(entry.Event.AccountID), entry.Cursor, entry.Cursor)
+		}
+		return nil
+	})
+	return errors.Wrap(err, "TxPipelined")
+}
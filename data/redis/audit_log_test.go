@@ -0,0 +1,42 @@
+package redis_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/redis"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	client, err := redis.TestDB()
+	require.NoError(t, err)
+	log := redis.NewAuditLog(client, []byte("key"))
+	for _, tester := range testers.AuditLogTesters {
+		client.FlushDB()
+		tester(t, log)
+	}
+}
+
+func TestAuditLogNamespaced(t *testing.T) {
+	client, err := redis.TestDB()
+	require.NoError(t, err)
+	namespaced, err := redis.TestDB()
+	require.NoError(t, err)
+	redis.Namespace(namespaced, "authn:")
+
+	log := redis.NewAuditLog(namespaced, []byte("key"))
+	for _, tester := range testers.AuditLogTesters {
+		client.FlushDB()
+		tester(t, log)
+	}
+
+	client.FlushDB()
+	require.NoError(t, log.Append(events.Event{Type: events.AccountLocked, AccountID: 42}))
+	assert.Equal(t, "1", client.Get("authn:audit:seq").Val())
+	assert.Equal(t, int64(1), client.ZCard("authn:audit:all").Val())
+	assert.Equal(t, int64(1), client.ZCard("authn:audit:account:42").Val())
+	assert.Equal(t, int64(0), client.Exists("audit:seq", "audit:head", "audit:all", "audit:account:42").Val())
+}
@@ -1,15 +1,21 @@
 package redis
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 
 	"github.com/go-redis/redis"
+	"github.com/keratin/authn-server/lib"
 	"github.com/pkg/errors"
 )
 
-func New(url *url.URL) (*redis.Client, error) {
+// New connects to Redis. Connections are dialed through the circuit breaker, so that while Redis is
+// down commands fail fast with lib.ErrCircuitOpen and reconnection is retried with backoff. When a
+// prefix is given, every key will be namespaced with it.
+func New(url *url.URL, breaker *lib.CircuitBreaker, prefix string) (*redis.Client, error) {
 	opts, err := redis.ParseURL(url.String())
 	if url.Port() == "" {
 		opts.Addr = opts.Addr + ":6379"
@@ -17,7 +23,25 @@ func New(url *url.URL) (*redis.Client, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "ParseURL")
 	}
-	return redis.NewClient(opts), nil
+	opts.Dialer = breakerDialer(opts, breaker)
+	client := redis.NewClient(opts)
+	if prefix != "" {
+		Namespace(client, prefix)
+	}
+	return client, nil
+}
+
+func breakerDialer(opts *redis.Options, breaker *lib.CircuitBreaker) func() (net.Conn, error) {
+	return func() (conn net.Conn, err error) {
+		err = breaker.Do(func() error {
+			conn, err = net.DialTimeout("tcp", opts.Addr, opts.DialTimeout)
+			if err == nil && opts.TLSConfig != nil {
+				conn = tls.Client(conn, opts.TLSConfig)
+			}
+			return err
+		}, func(error) bool { return true })
+		return conn, err
+	}
 }
 
 // TODO: move to _test
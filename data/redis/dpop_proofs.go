@@ -0,0 +1,16 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// DPoPProofs remembers accepted DPoP proofs, so that a proof can not be replayed on any AuthN server.
+type DPoPProofs struct {
+	Client *redis.Client
+}
+
+func (p *DPoPProofs) Use(key string, ttl time.Duration) (bool, error) {
+	return p.Client.SetNX("dpop:"+key, 1, ttl).Result()
+}
@@ -0,0 +1,29 @@
+package redis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDPoPProofs(t *testing.T) {
+	client, err := redis.TestDB()
+	require.NoError(t, err)
+	client.FlushDB()
+	proofs := &redis.DPoPProofs{Client: client}
+
+	ok, err := proofs.Use("jkt:jti", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = proofs.Use("jkt:jti", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ttl, err := client.TTL("dpop:jkt:jti").Result()
+	require.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+}
@@ -0,0 +1,16 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Locker claims short-lived locks, so that only one AuthN server performs a given piece of work.
+type Locker struct {
+	Client *redis.Client
+}
+
+func (l *Locker) Lock(name string, ttl time.Duration) (bool, error) {
+	return l.Client.SetNX("lock:"+name, time.Now().Unix(), ttl).Result()
+}
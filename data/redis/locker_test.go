@@ -0,0 +1,29 @@
+package redis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocker(t *testing.T) {
+	client, err := redis.TestDB()
+	require.NoError(t, err)
+	client.FlushDB()
+	locker := &redis.Locker{Client: client}
+
+	ok, err := locker.Lock("job", time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = locker.Lock("job", time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = locker.Lock("other", time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,25 @@
+package redis
+
+import (
+	"github.com/go-redis/redis"
+)
+
+const modeKey = "mode"
+
+// ModeStore shares the server's mode of operation with every AuthN server.
+type ModeStore struct {
+	Client *redis.Client
+}
+
+// Get returns the current mode, or "" when none has been set.
+func (s *ModeStore) Get() (string, error) {
+	mode, err := s.Client.Get(modeKey).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return mode, err
+}
+
+func (s *ModeStore) Set(mode string) error {
+	return s.Client.Set(modeKey, mode, 0).Err()
+}
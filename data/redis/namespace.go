@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis"
+)
+
+// commands that take no keys, or where every argument is a key. Scripts declare their keys. All
+// other commands are expected to take a single key as their first argument.
+var keylessCommands = map[string]bool{"ping": true, "flushdb": true, "flushall": true, "info": true}
+var multiKeyCommands = map[string]bool{"mget": true, "del": true, "exists": true, "unlink": true}
+
+// Namespace prefixes the keys of every command sent through the client, including pipelines, so
+// that several AuthN instances or other applications can share a Redis database.
+func Namespace(client *redis.Client, prefix string) {
+	client.WrapProcess(func(process func(redis.Cmder) error) func(redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			prefixKeys(cmd, prefix)
+			return process(cmd)
+		}
+	})
+	client.WrapProcessPipeline(func(process func([]redis.Cmder) error) func([]redis.Cmder) error {
+		return func(cmds []redis.Cmder) error {
+			for _, cmd := range cmds {
+				prefixKeys(cmd, prefix)
+			}
+			return process(cmds)
+		}
+	})
+}
+
+func prefixKeys(cmd redis.Cmder, prefix string) {
+	name := cmd.Name()
+	args := cmd.Args()
+	if keylessCommands[name] || len(args) < 2 {
+		return
+	}
+
+	first, last := 1, 1
+	if multiKeyCommands[name] {
+		last = len(args) - 1
+	} else if name == "eval" || name == "evalsha" {
+		// eval script numkeys key [key ...] arg [arg ...]
+		numKeys, _ := strconv.Atoi(fmt.Sprint(args[2]))
+		first, last = 3, 2+numKeys
+	} else if name == "xgroup" {
+		// xgroup subcommand key group ...
+		first, last = 2, 2
+	} else if name == "xread" || name == "xreadgroup" {
+		// xread [options] streams key [key ...] id [id ...]
+		// xreadgroup group group consumer [options] streams key [key ...] id [id ...]
+		first, last = 0, -1
+		start := 1
+		if name == "xreadgroup" {
+			start = 4
+		}
+		for i := start; i < len(args); i++ {
+			if strings.ToLower(fmt.Sprint(args[i])) == "streams" {
+				first, last = i+1, i+(len(args)-i-1)/2
+				break
+			}
+		}
+	}
+	for i := first; i <= last && i < len(args); i++ {
+		args[i] = prefix + fmt.Sprint(args[i])
+	}
+}
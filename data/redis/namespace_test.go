@@ -0,0 +1,61 @@
+package redis_test
+
+import (
+	"testing"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+	"github.com/keratin/authn-server/data/redis"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespace(t *testing.T) {
+	client, err := redis.TestDB()
+	require.NoError(t, err)
+	client.FlushDB()
+
+	namespaced, err := redis.TestDB()
+	require.NoError(t, err)
+	redis.Namespace(namespaced, "authn:")
+
+	t.Run("commands", func(t *testing.T) {
+		require.NoError(t, namespaced.Set("foo", "bar", time.Minute).Err())
+		assert.Equal(t, "bar", namespaced.Get("foo").Val())
+		assert.Equal(t, "bar", client.Get("authn:foo").Val())
+		assert.Equal(t, int64(0), client.Exists("foo").Val())
+
+		assert.Equal(t, []interface{}{"bar", nil}, namespaced.MGet("foo", "baz").Val())
+	})
+
+	t.Run("streams", func(t *testing.T) {
+		require.NoError(t, namespaced.XGroupCreateMkStream("stream", "group", "$").Err())
+		require.NoError(t, namespaced.XAdd(&goredis.XAddArgs{Stream: "stream", Values: map[string]interface{}{"foo": "bar"}}).Err())
+		assert.Equal(t, int64(1), client.XLen("authn:stream").Val())
+
+		streams, err := namespaced.XReadGroup(&goredis.XReadGroupArgs{Group: "group", Consumer: "streams", Streams: []string{"stream", ">"}, Block: -1}).Result()
+		require.NoError(t, err)
+		require.Len(t, streams, 1)
+		assert.Equal(t, "authn:stream", streams[0].Stream)
+		require.Len(t, streams[0].Messages, 1)
+		assert.Equal(t, int64(1), namespaced.XAck("stream", "group", streams[0].Messages[0].ID).Val())
+	})
+
+	t.Run("pipelines", func(t *testing.T) {
+		pipe := namespaced.Pipeline()
+		pipe.Incr("counter")
+		pipe.Expire("counter", time.Minute)
+		_, err := pipe.Exec()
+		require.NoError(t, err)
+		assert.Equal(t, "1", client.Get("authn:counter").Val())
+	})
+
+	t.Run("stores", func(t *testing.T) {
+		store := redis.NewQuotas(namespaced)
+		for _, tester := range testers.QuotasTesters {
+			client.FlushDB()
+			tester(t, store)
+		}
+	})
+}
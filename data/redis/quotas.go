@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+var quotasPrefix = "quotas:"
+
+// quotas keeps a sliding log of hits in a sorted set for each key, scored by the microsecond of
+// the hit. Hits older than the window are trimmed as new hits arrive.
+type quotas struct {
+	client *redis.Client
+}
+
+func NewQuotas(client *redis.Client) *quotas {
+	return &quotas{client: client}
+}
+
+// Count returns the number of hits for the key within the window.
+func (q *quotas) Count(key string, window time.Duration) (int, error) {
+	count, err := q.client.ZCount(q.windowKey(key, window), "("+score(time.Now().Add(-window)), "+inf").Result()
+	return int(count), err
+}
+
+// Hit counts an event for the key. The log expires when its newest hit leaves the window.
+func (q *quotas) Hit(key string, window time.Duration) error {
+	now := time.Now()
+	windowKey := q.windowKey(key, window)
+	pipe := q.client.TxPipeline()
+	pipe.ZRemRangeByScore(windowKey, "-inf", score(now.Add(-window)))
+	pipe.ZAdd(windowKey, redis.Z{
+		Score:  float64(now.UnixNano() / int64(time.Microsecond)),
+		Member: strconv.FormatInt(now.UnixNano(), 36) + ":" + strconv.FormatInt(rand.Int63(), 36),
+	})
+	pipe.PExpire(windowKey, window)
+	_, err := pipe.Exec()
+	return err
+}
+
+// Reset clears the hits for the key.
+func (q *quotas) Reset(key string, window time.Duration) error {
+	return q.client.Del(q.windowKey(key, window)).Err()
+}
+
+// Expiry returns when the oldest hit within the window will leave it.
+func (q *quotas) Expiry(key string, window time.Duration) (time.Time, error) {
+	oldest, err := q.client.ZRangeByScoreWithScores(q.windowKey(key, window), redis.ZRangeBy{
+		Min:   "(" + score(time.Now().Add(-window)),
+		Max:   "+inf",
+		Count: 1,
+	}).Result()
+	if err != nil || len(oldest) == 0 {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(oldest[0].Score)*int64(time.Microsecond)).Add(window), nil
+}
+
+func (q *quotas) windowKey(key string, window time.Duration) string {
+	return quotasPrefix + key + ":" + strconv.FormatInt(int64(window/time.Millisecond), 10)
+}
+
+func score(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano()/int64(time.Microsecond), 10)
+}
@@ -0,0 +1,19 @@
+package redis_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/redis"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotas(t *testing.T) {
+	client, err := redis.TestDB()
+	require.NoError(t, err)
+	store := redis.NewQuotas(client)
+	for _, tester := range testers.QuotasTesters {
+		client.FlushDB()
+		tester(t, store)
+	}
+}
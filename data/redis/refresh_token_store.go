@@ -1,8 +1,10 @@
 package redis
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
@@ -16,6 +18,11 @@ type RefreshTokenStore struct {
 	TTL time.Duration
 }
 
+// WithContext returns a copy of the store whose commands are canceled with ctx.
+func (s *RefreshTokenStore) WithContext(ctx context.Context) *RefreshTokenStore {
+	return &RefreshTokenStore{Client: s.Client.WithContext(ctx), TTL: s.TTL}
+}
+
 // Redis key for token => accountID lookup
 func keyForToken(t []byte) string {
 	str := fmt.Sprintf("s:t.%s", t)
@@ -24,10 +31,20 @@ func keyForToken(t []byte) string {
 
 // Redis key for accountID => tokens lookup
 func keyForAccount(id int) string {
-	str := fmt.Sprintf("s:a.%d", id)
+	return keyForAccountPrefix + strconv.Itoa(id)
+}
+
+const keyForAccountPrefix = "s:a."
+
+// Redis key that remembers a revoked token, so that replicated changes can not revive it
+func keyForTombstone(t []byte) string {
+	str := fmt.Sprintf("s:r.%s", t)
 	return str
 }
 
+// Redis key for the set of accountIDs that have tokens, so that Compact can find them
+const keyForAccounts = "s:accounts"
+
 func (s *RefreshTokenStore) Find(hexToken models.RefreshToken) (int, error) {
 	binToken, err := hex.DecodeString(string(hexToken))
 	if err != nil {
@@ -42,6 +59,39 @@ func (s *RefreshTokenStore) Find(hexToken models.RefreshToken) (int, error) {
 	return strconv.Atoi(str)
 }
 
+// FindMany looks up every token in one round trip.
+func (s *RefreshTokenStore) FindMany(hexTokens []models.RefreshToken) ([]int, error) {
+	ids := make([]int, len(hexTokens))
+	if len(hexTokens) == 0 {
+		return ids, nil
+	}
+
+	keys := make([]string, len(hexTokens))
+	for i, hexToken := range hexTokens {
+		binToken, err := hex.DecodeString(string(hexToken))
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = keyForToken(binToken)
+	}
+	vals, err := s.Client.MGet(keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, val := range vals {
+		str, ok := val.(string)
+		if !ok {
+			continue
+		}
+		ids[i], err = strconv.Atoi(str)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
 func (s *RefreshTokenStore) Touch(hexToken models.RefreshToken, accountID int) error {
 	binToken, err := hex.DecodeString(string(hexToken))
 	if err != nil {
@@ -56,20 +106,86 @@ func (s *RefreshTokenStore) Touch(hexToken models.RefreshToken, accountID int) e
 	return err
 }
 
+// FindAll returns the account's tokens, least recently used first. Tokens expire on their own, so
+// any that have expired are also removed from the account's set.
 func (s *RefreshTokenStore) FindAll(accountID int) ([]models.RefreshToken, error) {
 	bins, err := s.Client.SMembers(keyForAccount(accountID)).Result()
 	if err != nil {
 		return nil, err
 	}
 
-	tokens := make([]models.RefreshToken, 0)
-	for _, t := range bins {
+	ttls := make([]*redis.DurationCmd, len(bins))
+	_, err = s.Client.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, t := range bins {
+			ttls[i] = pipe.PTTL(keyForToken([]byte(t)))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]string, 0, len(bins))
+	remaining := make(map[string]time.Duration, len(bins))
+	expired := make([]interface{}, 0)
+	for i, t := range bins {
+		if ttls[i].Val() < 0 {
+			expired = append(expired, t)
+		} else {
+			active = append(active, t)
+			remaining[t] = ttls[i].Val()
+		}
+	}
+	if len(expired) > 0 {
+		err = s.Client.SRem(keyForAccount(accountID), expired...).Err()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(active, func(i, j int) bool {
+		return remaining[active[i]] < remaining[active[j]]
+	})
+	tokens := make([]models.RefreshToken, 0, len(active))
+	for _, t := range active {
 		tokens = append(tokens, models.RefreshToken(hex.EncodeToString([]byte(t))))
 	}
 
 	return tokens, nil
 }
 
+// Compact removes expired tokens from the sets kept for each account. Without compaction, the set
+// for an account that keeps logging in would grow without bound.
+func (s *RefreshTokenStore) Compact() error {
+	var cursor uint64
+	for {
+		ids, next, err := s.Client.SScan(keyForAccounts, cursor, "", 100).Result()
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			accountID, err := strconv.Atoi(id)
+			if err != nil {
+				return err
+			}
+			tokens, err := s.FindAll(accountID)
+			if err != nil {
+				return err
+			}
+			if len(tokens) == 0 {
+				err = s.Client.SRem(keyForAccounts, id).Err()
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
 func (s *RefreshTokenStore) Create(accountID int) (models.RefreshToken, error) {
 	binToken, err := lib.GenerateToken()
 	if err != nil {
@@ -83,6 +199,7 @@ func (s *RefreshTokenStore) Create(accountID int) (models.RefreshToken, error) {
 		// maintain a list of tokens per accountID
 		pipe.SAdd(keyForAccount(accountID), binToken)
 		pipe.Expire(keyForAccount(accountID), s.TTL)
+		pipe.SAdd(keyForAccounts, accountID)
 
 		return nil
 	})
@@ -115,3 +232,132 @@ func (s *RefreshTokenStore) Revoke(hexToken models.RefreshToken) error {
 	})
 	return err
 }
+
+// createLimitedScript creates a token unless the account is at its limit, evicting the least
+// recently used tokens if requested. Expired tokens are removed from the account's set first.
+//
+// KEYS: token key, account key, accounts key, token key prefix
+// ARGV: token, accountID, ttl (ms), max, evict (1 or 0)
+var createLimitedScript = redis.NewScript(`
+local active = {}
+for _, t in ipairs(redis.call('SMEMBERS', KEYS[2])) do
+	local ttl = redis.call('PTTL', KEYS[4] .. t)
+	if ttl < 0 then
+		redis.call('SREM', KEYS[2], t)
+	else
+		table.insert(active, {t, ttl})
+	end
+end
+
+local max = tonumber(ARGV[4])
+if max > 0 and #active >= max then
+	if ARGV[5] ~= '1' then
+		return 0
+	end
+	table.sort(active, function(a, b) return a[2] < b[2] end)
+	for i = 1, #active - max + 1 do
+		redis.call('DEL', KEYS[4] .. active[i][1])
+		redis.call('SREM', KEYS[2], active[i][1])
+	end
+end
+
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+redis.call('SADD', KEYS[2], ARGV[1])
+redis.call('PEXPIRE', KEYS[2], ARGV[3])
+redis.call('SADD', KEYS[3], ARGV[2])
+return 1
+`)
+
+func (s *RefreshTokenStore) CreateLimited(accountID int, max int, evict bool) (models.RefreshToken, error) {
+	binToken, err := lib.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	evictArg := 0
+	if evict {
+		evictArg = 1
+	}
+	created, err := createLimitedScript.Run(
+		s.Client,
+		[]string{keyForToken(binToken), keyForAccount(accountID), keyForAccounts, keyForToken([]byte{})},
+		binToken, accountID, int64(s.TTL/time.Millisecond), max, evictArg,
+	).Int()
+	if err != nil {
+		return "", err
+	}
+	if created == 0 {
+		return "", nil
+	}
+
+	return models.RefreshToken(hex.EncodeToString(binToken)), nil
+}
+
+// replicateScript stores a token from another region, unless it was revoked here or already lasts
+// longer. Replicated changes may arrive out of order, so revocation wins and expiry only extends.
+//
+// KEYS: token key, account key, accounts key, tombstone key
+// ARGV: token, accountID, ttl (ms)
+var replicateScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[4]) == 1 then
+	return 0
+end
+local ttl = tonumber(ARGV[3])
+if redis.call('PTTL', KEYS[1]) >= ttl then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ttl)
+redis.call('SADD', KEYS[2], ARGV[1])
+if redis.call('PTTL', KEYS[2]) < ttl then
+	redis.call('PEXPIRE', KEYS[2], ttl)
+end
+redis.call('SADD', KEYS[3], ARGV[2])
+return 1
+`)
+
+// Replicate stores a token that was created or touched in another region until it expires.
+func (s *RefreshTokenStore) Replicate(hexToken models.RefreshToken, accountID int, expiresAt time.Time) error {
+	binToken, err := hex.DecodeString(string(hexToken))
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return replicateScript.Run(
+		s.Client,
+		[]string{keyForToken(binToken), keyForAccount(accountID), keyForAccounts, keyForTombstone(binToken)},
+		binToken, accountID, int64(ttl/time.Millisecond),
+	).Err()
+}
+
+// tombstoneScript revokes a token and remembers the revocation.
+//
+// KEYS: token key, account key prefix, tombstone key
+// ARGV: token, ttl (ms)
+var tombstoneScript = redis.NewScript(`
+local id = redis.call('GET', KEYS[1])
+if id then
+	redis.call('DEL', KEYS[1])
+	redis.call('SREM', KEYS[2] .. id, ARGV[1])
+end
+redis.call('SET', KEYS[3], 1, 'PX', ARGV[2])
+return 1
+`)
+
+// Tombstone revokes the token and remembers the revocation for one TTL, which outlasts any
+// replicated change that could still arrive for the token.
+func (s *RefreshTokenStore) Tombstone(hexToken models.RefreshToken) error {
+	binToken, err := hex.DecodeString(string(hexToken))
+	if err != nil {
+		return err
+	}
+
+	return tombstoneScript.Run(
+		s.Client,
+		[]string{keyForToken(binToken), keyForAccountPrefix, keyForTombstone(binToken)},
+		binToken, int64(s.TTL/time.Millisecond),
+	).Err()
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/keratin/authn-server/data/redis"
 	"github.com/keratin/authn-server/data/testers"
+	"github.com/keratin/authn-server/models"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -18,3 +20,74 @@ func TestRefreshTokenStore(t *testing.T) {
 		store.FlushDb()
 	}
 }
+
+func TestRefreshTokenStoreCompact(t *testing.T) {
+	client, err := redis.TestDB()
+	require.NoError(t, err)
+	client.FlushDB()
+	store := &redis.RefreshTokenStore{Client: client, TTL: 100 * time.Millisecond}
+
+	_, err = store.Create(123)
+	require.NoError(t, err)
+	store.TTL = time.Minute
+	active, err := store.Create(456)
+	require.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+
+	require.NoError(t, store.Compact())
+
+	accounts, err := client.SMembers("s:accounts").Result()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"456"}, accounts)
+
+	tokens, err := store.FindAll(456)
+	require.NoError(t, err)
+	assert.Equal(t, []models.RefreshToken{active}, tokens)
+}
+
+func TestRefreshTokenStoreReplication(t *testing.T) {
+	client, err := redis.TestDB()
+	require.NoError(t, err)
+	client.FlushDB()
+	store := &redis.RefreshTokenStore{Client: client, TTL: time.Minute}
+
+	t.Run("replicating a token", func(t *testing.T) {
+		token := models.RefreshToken("aabbcc")
+		require.NoError(t, store.Replicate(token, 123, time.Now().Add(time.Minute)))
+
+		id, err := store.Find(token)
+		require.NoError(t, err)
+		assert.Equal(t, 123, id)
+		tokens, err := store.FindAll(123)
+		require.NoError(t, err)
+		assert.Equal(t, []models.RefreshToken{token}, tokens)
+	})
+
+	t.Run("replicating an earlier expiry", func(t *testing.T) {
+		token := models.RefreshToken("ddeeff")
+		require.NoError(t, store.Replicate(token, 123, time.Now().Add(time.Minute)))
+		require.NoError(t, store.Replicate(token, 123, time.Now().Add(time.Second)))
+
+		ttl, err := client.PTTL("s:t.\xdd\xee\xff").Result()
+		require.NoError(t, err)
+		assert.True(t, ttl > time.Second)
+	})
+
+	t.Run("replicating after a revocation", func(t *testing.T) {
+		token, err := store.Create(456)
+		require.NoError(t, err)
+		require.NoError(t, store.Tombstone(token))
+
+		id, err := store.Find(token)
+		require.NoError(t, err)
+		assert.Equal(t, 0, id)
+
+		require.NoError(t, store.Replicate(token, 456, time.Now().Add(time.Minute)))
+		id, err = store.Find(token)
+		require.NoError(t, err)
+		assert.Equal(t, 0, id)
+		tokens, err := store.FindAll(456)
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+}
@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+var signupsPrefix = "signups:"
+
+type signups struct {
+	client  *redis.Client
+	tz      *time.Location
+	days    int
+	dayTTL  time.Duration
+	weeks   int
+	weekTTL time.Duration
+	months  int
+}
+
+func NewSignups(client *redis.Client, tz *time.Location, days int, weeks int, months int) *signups {
+	return &signups{
+		client:  client,
+		tz:      tz,
+		days:    days,
+		dayTTL:  time.Duration(days*24) * time.Hour,
+		weeks:   weeks,
+		weekTTL: time.Duration(weeks*24*7) * time.Hour,
+		months:  months,
+	}
+}
+
+// Track counts a new signup. Unlike actives, signups are unique by nature, so a plain counter will
+// do where actives need a HyperLogLog.
+func (s *signups) Track() error {
+	t := time.Now().In(s.tz)
+	pipe := s.client.Pipeline()
+
+	dayKey := signupsPrefix + dayKey(t)
+	pipe.Incr(dayKey)
+	pipe.Expire(dayKey, s.dayTTL)
+
+	weekKey := signupsPrefix + weekKey(t)
+	pipe.Incr(weekKey)
+	pipe.Expire(weekKey, s.weekTTL)
+
+	monthKey := signupsPrefix + monthKey(t)
+	pipe.Incr(monthKey)
+
+	_, err := pipe.Exec()
+	return err
+}
+
+func (s *signups) SignupsByDay() (map[string]int, error) {
+	now := time.Now().In(s.tz)
+
+	days := make([]string, s.days)
+	for i := range days {
+		days[i] = dayKey(now.Add(time.Duration(i*-24) * time.Hour))
+	}
+
+	return s.report(days)
+}
+
+func (s *signups) SignupsByWeek() (map[string]int, error) {
+	now := time.Now().In(s.tz)
+
+	weeks := make([]string, s.weeks)
+	for i := range weeks {
+		weeks[i] = weekKey(now.AddDate(0, 0, -7*i))
+	}
+
+	return s.report(weeks)
+}
+
+func (s *signups) SignupsByMonth() (map[string]int, error) {
+	now := time.Now().In(s.tz)
+
+	months := make([]string, s.months)
+	for i := range months {
+		months[i] = monthKey(now.AddDate(0, -1*i, 1-now.Day()))
+	}
+
+	return s.report(months)
+}
+
+func (s *signups) report(keys []string) (map[string]int, error) {
+	prefixed := make([]string, len(keys))
+	for i := range keys {
+		prefixed[i] = signupsPrefix + keys[i]
+	}
+
+	vals, err := s.client.MGet(prefixed...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// slice out the oldest entries that are zeroes
+	lastNonZeroIndex := -1
+	for i, val := range vals {
+		if val != nil {
+			lastNonZeroIndex = i
+		}
+	}
+
+	report := make(map[string]int, lastNonZeroIndex+1)
+	for i := 0; i <= lastNonZeroIndex; i++ {
+		count := 0
+		if str, ok := vals[i].(string); ok {
+			count, err = strconv.Atoi(str)
+			if err != nil {
+				return nil, err
+			}
+		}
+		report[keys[i]] = count
+	}
+
+	return report, nil
+}
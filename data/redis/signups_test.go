@@ -0,0 +1,20 @@
+package redis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data/redis"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignups(t *testing.T) {
+	client, err := redis.TestDB()
+	require.NoError(t, err)
+	rStore := redis.NewSignups(client, time.UTC, 365, 52, 12)
+	for _, tester := range testers.SignupsTesters {
+		client.FlushDB()
+		tester(t, rStore)
+	}
+}
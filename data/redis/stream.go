@@ -0,0 +1,78 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const streamKey = "bus"
+
+// streamMaxLen is roughly how many messages the stream keeps. A server that falls further behind
+// misses the oldest.
+const streamMaxLen = 10000
+
+// streamBlock is how long a read waits for new messages before it asks again.
+const streamBlock = 10 * time.Second
+
+// Stream shares messages between AuthN servers through a Redis stream. Every server reads every
+// message on a topic through its own consumer group, so that after a restart or a lost connection it
+// continues from the last message that it handled rather than missing what was sent meanwhile.
+type Stream struct {
+	Client *redis.Client
+	// Name identifies this server. It must be the same after a restart for messages to be replayed.
+	Name string
+}
+
+// Add sends a message on the topic to every server, including this one.
+func (s *Stream) Add(topic string, payload string) error {
+	return s.Client.XAdd(&redis.XAddArgs{
+		Stream:       streamKey,
+		MaxLenApprox: streamMaxLen,
+		Values:       map[string]interface{}{"topic": topic, "payload": payload},
+	}).Err()
+}
+
+// Read calls the handler with every message on the topic, beginning with any that were read but not
+// handled before this server stopped. The first Read for a topic begins with new messages. It blocks
+// until reading fails.
+func (s *Stream) Read(topic string, handler func(payload string)) error {
+	group := s.Name + ":" + topic
+	err := s.Client.XGroupCreateMkStream(streamKey, group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	// "0" reads the group's pending messages, and ">" reads new messages
+	start := "0"
+	for {
+		streams, err := s.Client.XReadGroup(&redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: s.Name,
+			Streams:  []string{streamKey, start},
+			Count:    100,
+			Block:    streamBlock,
+		}).Result()
+		if err == redis.Nil || (err == nil && len(streams) == 0) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		messages := streams[0].Messages
+		if start == "0" && len(messages) == 0 {
+			start = ">"
+			continue
+		}
+		for _, msg := range messages {
+			if msg.Values["topic"] == topic {
+				handler(fmt.Sprint(msg.Values["payload"]))
+			}
+			if err := s.Client.XAck(streamKey, group, msg.ID).Err(); err != nil {
+				return err
+			}
+		}
+	}
+}
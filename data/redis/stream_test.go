@@ -0,0 +1,41 @@
+package redis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	client, err := redis.TestDB()
+	require.NoError(t, err)
+	client.FlushDB()
+
+	first := &redis.Stream{Client: client, Name: "first"}
+	second := &redis.Stream{Client: client, Name: "second"}
+
+	// both servers have read the topic before, and the second has since stopped
+	require.NoError(t, client.XGroupCreateMkStream("bus", "first:topic", "$").Err())
+	require.NoError(t, client.XGroupCreateMkStream("bus", "second:topic", "$").Err())
+
+	require.NoError(t, first.Add("topic", "one"))
+	require.NoError(t, first.Add("other", "ignored"))
+	require.NoError(t, second.Add("topic", "two"))
+
+	for _, stream := range []*redis.Stream{first, second} {
+		received := make(chan string, 10)
+		go stream.Read("topic", func(payload string) { received <- payload })
+
+		for _, expected := range []string{"one", "two"} {
+			select {
+			case payload := <-received:
+				assert.Equal(t, expected, payload)
+			case <-time.After(time.Second):
+				t.Fatalf("%s did not receive %s", stream.Name, expected)
+			}
+		}
+	}
+}
@@ -1,15 +1,15 @@
 package data
 
 import (
+	"context"
 	"fmt"
 	"time"
 
-	"github.com/keratin/authn-server/ops"
-
 	"github.com/go-redis/redis"
 	"github.com/jmoiron/sqlx"
 	dataRedis "github.com/keratin/authn-server/data/redis"
 	"github.com/keratin/authn-server/data/sqlite3"
+	"github.com/keratin/authn-server/lib/jobs"
 	"github.com/keratin/authn-server/models"
 )
 
@@ -17,10 +17,20 @@ type RefreshTokenStore interface {
 	// Generates and persists a token for the given accountID.
 	Create(accountID int) (models.RefreshToken, error)
 
+	// Generates and persists a token for the given accountID, unless the account already has max
+	// active tokens. When evict is true, the least recently used tokens are revoked to make room.
+	// Otherwise an empty value indicates that the account is at its limit. The check and the
+	// creation happen atomically, so that concurrent logins can not exceed the limit.
+	CreateLimited(accountID int, max int, evict bool) (models.RefreshToken, error)
+
 	// Finds the accountID that owns the token, if the token is registered and unexpired. An empty
 	// value indicates that no active token was found.
 	Find(t models.RefreshToken) (int, error)
 
+	// Finds the accountIDs that own many tokens at once, in the same order. An empty value
+	// indicates that no active token was found in that position.
+	FindMany(ts []models.RefreshToken) ([]int, error)
+
 	// Refreshes the lifetime of the token.
 	//
 	// Technically could operate without accountID, but in the expected contexts the caller should
@@ -28,7 +38,7 @@ type RefreshTokenStore interface {
 	// important since touching can be a high traffic activity.
 	Touch(t models.RefreshToken, accountID int) error
 
-	// Returns all tokens that are active for the specified account.
+	// Returns all tokens that are active for the specified account, least recently used first.
 	FindAll(accountID int) ([]models.RefreshToken, error)
 
 	// Revokes the token and removes it from the set of active tokens for the account. Doesn't error
@@ -36,12 +46,18 @@ type RefreshTokenStore interface {
 	Revoke(t models.RefreshToken) error
 }
 
-func NewRefreshTokenStore(db *sqlx.DB, redis *redis.Client, reporter ops.ErrorReporter, ttl time.Duration) (RefreshTokenStore, error) {
+func NewRefreshTokenStore(db *sqlx.DB, redis *redis.Client, scheduler *jobs.Scheduler, ttl time.Duration) (RefreshTokenStore, error) {
 	if redis != nil {
-		return &dataRedis.RefreshTokenStore{
+		store := &dataRedis.RefreshTokenStore{
 			Client: redis,
 			TTL:    ttl,
-		}, nil
+		}
+		scheduler.Add(jobs.Job{
+			Name:     "refresh_tokens:compact",
+			Interval: time.Hour,
+			Run:      store.Compact,
+		})
+		return store, nil
 	}
 
 	switch db.DriverName() {
@@ -50,9 +66,34 @@ func NewRefreshTokenStore(db *sqlx.DB, redis *redis.Client, reporter ops.ErrorRe
 			DB:  db,
 			TTL: ttl,
 		}
-		store.Clean(reporter)
+		scheduler.Add(jobs.Job{
+			Name:     "refresh_tokens:clean",
+			Interval: time.Minute,
+			Run:      store.Clean,
+		})
 		return store, nil
 	default:
 		return nil, fmt.Errorf("unsupported driver: %v", db.DriverName())
 	}
 }
+
+// contextRefreshTokenStore is a RefreshTokenStore that can pass a context to the store that it
+// wraps.
+type contextRefreshTokenStore interface {
+	WithContext(ctx context.Context) RefreshTokenStore
+}
+
+// refreshTokenStoreWithContext returns a copy of the store whose calls are canceled with ctx. A
+// store that can not be canceled is returned as it is.
+func refreshTokenStoreWithContext(store RefreshTokenStore, ctx context.Context) RefreshTokenStore {
+	switch s := store.(type) {
+	case *dataRedis.RefreshTokenStore:
+		return s.WithContext(ctx)
+	case *sqlite3.RefreshTokenStore:
+		return s.WithContext(ctx)
+	case contextRefreshTokenStore:
+		return s.WithContext(ctx)
+	default:
+		return store
+	}
+}
@@ -0,0 +1,197 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/ops"
+	"github.com/pkg/errors"
+)
+
+// TokenReplica is a refresh token store in another region that accepts replicated changes.
+type TokenReplica interface {
+	// Stores a token that was created or touched elsewhere until it expires, unless the token was
+	// revoked or already lasts longer.
+	Replicate(t models.RefreshToken, accountID int, expiresAt time.Time) error
+
+	// Revokes the token and remembers the revocation, so that a change that arrives late can not
+	// revive it.
+	Tombstone(t models.RefreshToken) error
+}
+
+// replicationQueueSize bounds how many changes may wait for each replica before new changes are
+// dropped. Only revocations wait for room, since a dropped revocation would leave a session valid
+// in the other region.
+const replicationQueueSize = 1000
+
+// tombstoneRetry is how long delivery of a revocation first waits before retrying. It doubles after
+// each failure, up to tombstoneRetryMax.
+const (
+	tombstoneRetry    = 100 * time.Millisecond
+	tombstoneRetryMax = 30 * time.Second
+)
+
+// tokenReplication is a change to copy. A zero expiry revokes the token.
+type tokenReplication struct {
+	token     models.RefreshToken
+	accountID int
+	expiresAt time.Time
+}
+
+// ReplicatedRefreshTokenStore is a RefreshTokenStore that writes locally and then copies every
+// change to the stores of other regions in the background, so that a session may be refreshed in
+// any region after failover. Conflicts are settled by the replicas: revocation always wins, and a
+// token's expiry only ever extends.
+//
+// Created and touched tokens that can not be delivered are reported and dropped, which at worst
+// ends a session on failover. Revocations are never dropped: a revoking request waits for room in a
+// full queue, and delivery is retried until the replica accepts it. Limits on sessions per account
+// are only enforced within each region.
+type ReplicatedRefreshTokenStore struct {
+	RefreshTokenStore
+	ttl      time.Duration
+	reporter ops.ErrorReporter
+	queues   []chan tokenReplication
+}
+
+// NewReplicatedRefreshTokenStore starts replicating to each replica. With no replicas it returns
+// the store itself.
+func NewReplicatedRefreshTokenStore(store RefreshTokenStore, ttl time.Duration, reporter ops.ErrorReporter, replicas ...TokenReplica) RefreshTokenStore {
+	if len(replicas) == 0 {
+		return store
+	}
+	s := &ReplicatedRefreshTokenStore{
+		RefreshTokenStore: store,
+		ttl:               ttl,
+		reporter:          reporter,
+	}
+	for _, replica := range replicas {
+		queue := make(chan tokenReplication, replicationQueueSize)
+		s.queues = append(s.queues, queue)
+		go s.deliver(replica, queue)
+	}
+	return s
+}
+
+// WithContext passes ctx to the local store. Replication continues in the background.
+func (s *ReplicatedRefreshTokenStore) WithContext(ctx context.Context) RefreshTokenStore {
+	return &ReplicatedRefreshTokenStore{
+		RefreshTokenStore: refreshTokenStoreWithContext(s.RefreshTokenStore, ctx),
+		ttl:               s.ttl,
+		reporter:          s.reporter,
+		queues:            s.queues,
+	}
+}
+
+func (s *ReplicatedRefreshTokenStore) Create(accountID int) (models.RefreshToken, error) {
+	t, err := s.RefreshTokenStore.Create(accountID)
+	if err == nil {
+		s.enqueue(tokenReplication{token: t, accountID: accountID, expiresAt: time.Now().Add(s.ttl)})
+	}
+	return t, err
+}
+
+// CreateLimited also revokes any tokens that were evicted to make room.
+func (s *ReplicatedRefreshTokenStore) CreateLimited(accountID int, max int, evict bool) (models.RefreshToken, error) {
+	var before []models.RefreshToken
+	if max > 0 && evict {
+		found, err := s.RefreshTokenStore.FindAll(accountID)
+		if err != nil {
+			return "", err
+		}
+		// copied, since the store may reuse the slice when it evicts
+		before = append(before, found...)
+	}
+
+	t, err := s.RefreshTokenStore.CreateLimited(accountID, max, evict)
+	if err != nil || t == "" {
+		return t, err
+	}
+	s.enqueue(tokenReplication{token: t, accountID: accountID, expiresAt: time.Now().Add(s.ttl)})
+
+	if len(before) >= max {
+		after, err := s.RefreshTokenStore.FindAll(accountID)
+		if err != nil {
+			return t, err
+		}
+		active := map[models.RefreshToken]bool{}
+		for _, a := range after {
+			active[a] = true
+		}
+		for _, b := range before {
+			if !active[b] {
+				s.enqueue(tokenReplication{token: b})
+			}
+		}
+	}
+	return t, nil
+}
+
+func (s *ReplicatedRefreshTokenStore) Touch(t models.RefreshToken, accountID int) error {
+	err := s.RefreshTokenStore.Touch(t, accountID)
+	if err == nil {
+		s.enqueue(tokenReplication{token: t, accountID: accountID, expiresAt: time.Now().Add(s.ttl)})
+	}
+	return err
+}
+
+// Revoke also remembers the revocation locally when the store can, so that a change replicated
+// from another region can not revive the token here either.
+func (s *ReplicatedRefreshTokenStore) Revoke(t models.RefreshToken) error {
+	var err error
+	if local, ok := s.RefreshTokenStore.(TokenReplica); ok {
+		err = local.Tombstone(t)
+	} else {
+		err = s.RefreshTokenStore.Revoke(t)
+	}
+	if err == nil {
+		s.enqueue(tokenReplication{token: t})
+	}
+	return err
+}
+
+func (s *ReplicatedRefreshTokenStore) enqueue(r tokenReplication) {
+	for _, queue := range s.queues {
+		if r.expiresAt.IsZero() {
+			queue <- r
+			continue
+		}
+		select {
+		case queue <- r:
+		default:
+			s.reporter.ReportError(errors.New("replication queue full: dropped a refresh token change"))
+		}
+	}
+}
+
+func (s *ReplicatedRefreshTokenStore) deliver(replica TokenReplica, queue chan tokenReplication) {
+	for r := range queue {
+		if r.expiresAt.IsZero() {
+			s.tombstone(replica, r.token)
+			continue
+		}
+		err := replica.Replicate(r.token, r.accountID, r.expiresAt)
+		if err != nil {
+			s.reporter.ReportError(errors.Wrap(err, "replicate refresh token"))
+		}
+	}
+}
+
+// tombstone retries until the replica has remembered the revocation.
+func (s *ReplicatedRefreshTokenStore) tombstone(replica TokenReplica, t models.RefreshToken) {
+	wait := tombstoneRetry
+	for {
+		err := replica.Tombstone(t)
+		if err == nil {
+			return
+		}
+		s.reporter.ReportError(errors.Wrap(err, "replicate refresh token revocation"))
+
+		time.Sleep(wait)
+		wait *= 2
+		if wait > tombstoneRetryMax {
+			wait = tombstoneRetryMax
+		}
+	}
+}
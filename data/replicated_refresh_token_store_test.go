@@ -0,0 +1,177 @@
+package data_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/ops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokenReplica remembers the expiry of each replicated token, and which were revoked. It fails
+// the first tombstones while failures remain, and waits for gate before each change when set.
+type fakeTokenReplica struct {
+	mutex    sync.Mutex
+	expiries map[models.RefreshToken]time.Time
+	revoked  map[models.RefreshToken]bool
+	failures int
+	gate     chan struct{}
+	waiting  int
+}
+
+func newFakeTokenReplica() *fakeTokenReplica {
+	return &fakeTokenReplica{
+		expiries: map[models.RefreshToken]time.Time{},
+		revoked:  map[models.RefreshToken]bool{},
+	}
+}
+
+func (r *fakeTokenReplica) Replicate(t models.RefreshToken, accountID int, expiresAt time.Time) error {
+	r.wait()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.expiries[t] = expiresAt
+	return nil
+}
+
+func (r *fakeTokenReplica) Tombstone(t models.RefreshToken) error {
+	r.wait()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("unavailable")
+	}
+	r.revoked[t] = true
+	return nil
+}
+
+func (r *fakeTokenReplica) wait() {
+	if r.gate == nil {
+		return
+	}
+	r.mutex.Lock()
+	r.waiting++
+	r.mutex.Unlock()
+	<-r.gate
+}
+
+func (r *fakeTokenReplica) isWaiting() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.waiting > 0
+}
+
+func (r *fakeTokenReplica) expiry(t models.RefreshToken) time.Time {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.expiries[t]
+}
+
+func (r *fakeTokenReplica) isRevoked(t models.RefreshToken) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.revoked[t]
+}
+
+func TestReplicatedRefreshTokenStore(t *testing.T) {
+	for _, tester := range testers.RefreshTokenStoreTesters {
+		store := data.NewReplicatedRefreshTokenStore(mock.NewRefreshTokenStore(), time.Hour, &ops.LogReporter{}, newFakeTokenReplica())
+		tester(t, store)
+	}
+
+	t.Run("without replicas", func(t *testing.T) {
+		local := mock.NewRefreshTokenStore()
+		assert.Equal(t, local, data.NewReplicatedRefreshTokenStore(local, time.Hour, &ops.LogReporter{}))
+	})
+
+	replicas := []*fakeTokenReplica{newFakeTokenReplica(), newFakeTokenReplica()}
+	store := data.NewReplicatedRefreshTokenStore(mock.NewRefreshTokenStore(), time.Hour, &ops.LogReporter{}, replicas[0], replicas[1])
+
+	t.Run("replicating creation", func(t *testing.T) {
+		token, err := store.Create(1)
+		require.NoError(t, err)
+		for _, replica := range replicas {
+			require.Eventually(t, func() bool { return !replica.expiry(token).IsZero() }, time.Second, time.Millisecond)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), replica.expiry(token), time.Minute)
+		}
+	})
+
+	t.Run("replicating touches", func(t *testing.T) {
+		token, err := store.Create(1)
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return !replicas[0].expiry(token).IsZero() }, time.Second, time.Millisecond)
+		created := replicas[0].expiry(token)
+
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, store.Touch(token, 1))
+		require.Eventually(t, func() bool { return replicas[0].expiry(token).After(created) }, time.Second, time.Millisecond)
+	})
+
+	t.Run("replicating revocation", func(t *testing.T) {
+		token, err := store.Create(1)
+		require.NoError(t, err)
+		require.NoError(t, store.Revoke(token))
+		for _, replica := range replicas {
+			require.Eventually(t, func() bool { return replica.isRevoked(token) }, time.Second, time.Millisecond)
+		}
+	})
+
+	t.Run("replicating eviction", func(t *testing.T) {
+		oldest, err := store.Create(2)
+		require.NoError(t, err)
+		kept, err := store.Create(2)
+		require.NoError(t, err)
+
+		token, err := store.CreateLimited(2, 2, true)
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+		require.Eventually(t, func() bool { return replicas[0].isRevoked(oldest) }, time.Second, time.Millisecond)
+		require.Eventually(t, func() bool { return !replicas[0].expiry(token).IsZero() }, time.Second, time.Millisecond)
+		assert.False(t, replicas[0].isRevoked(kept))
+	})
+
+	t.Run("retrying revocation", func(t *testing.T) {
+		replica := newFakeTokenReplica()
+		replica.failures = 2
+		store := data.NewReplicatedRefreshTokenStore(mock.NewRefreshTokenStore(), time.Hour, &ops.LogReporter{}, replica)
+
+		token, err := store.Create(1)
+		require.NoError(t, err)
+		require.NoError(t, store.Revoke(token))
+		require.Eventually(t, func() bool { return replica.isRevoked(token) }, 2*time.Second, time.Millisecond)
+	})
+
+	t.Run("revoking with a full queue", func(t *testing.T) {
+		replica := newFakeTokenReplica()
+		replica.gate = make(chan struct{})
+		store := data.NewReplicatedRefreshTokenStore(mock.NewRefreshTokenStore(), time.Hour, &ops.LogReporter{}, replica)
+
+		token, err := store.Create(1)
+		require.NoError(t, err)
+		require.Eventually(t, replica.isWaiting, time.Second, time.Millisecond)
+		for i := 0; i < 1000; i++ {
+			_, err := store.Create(2)
+			require.NoError(t, err)
+		}
+
+		revoked := make(chan error)
+		go func() { revoked <- store.Revoke(token) }()
+		select {
+		case <-revoked:
+			t.Fatal("revocation did not wait for room in the queue")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(replica.gate)
+		require.NoError(t, <-revoked)
+		require.Eventually(t, func() bool { return replica.isRevoked(token) }, time.Second, time.Millisecond)
+	})
+}
@@ -0,0 +1,63 @@
+package data
+
+import (
+	"context"
+
+	"github.com/keratin/authn-server/lib/compat"
+	"github.com/pkg/errors"
+)
+
+// RestorableAccountStore is an AccountStore that keeps an encrypted copy of each username as the
+// account is archived, so that an accidental archive may be restored.
+type RestorableAccountStore struct {
+	AccountStore
+	encryptionKey []byte
+}
+
+// NewRestorableAccountStore wraps an AccountStore to keep archived usernames.
+func NewRestorableAccountStore(store AccountStore, encryptionKey []byte) *RestorableAccountStore {
+	return &RestorableAccountStore{
+		AccountStore:  store,
+		encryptionKey: encryptionKey,
+	}
+}
+
+// WithContext passes ctx to the wrapped store.
+func (s *RestorableAccountStore) WithContext(ctx context.Context) AccountStore {
+	return &RestorableAccountStore{
+		AccountStore:  accountStoreWithContext(s.AccountStore, ctx),
+		encryptionKey: s.encryptionKey,
+	}
+}
+
+// Archive keeps the account's encrypted username before archiving it.
+func (s *RestorableAccountStore) Archive(id int) error {
+	account, err := s.AccountStore.Find(id)
+	if err != nil {
+		return errors.Wrap(err, "Find")
+	}
+	if account != nil && !account.Archived() {
+		encrypted, err := compat.Encrypt([]byte(account.Username), s.encryptionKey)
+		if err != nil {
+			return errors.Wrap(err, "Encrypt")
+		}
+		err = s.AccountStore.SaveArchivedUsername(id, encrypted)
+		if err != nil {
+			return errors.Wrap(err, "SaveArchivedUsername")
+		}
+	}
+	return s.AccountStore.Archive(id)
+}
+
+// FindArchivedUsername decrypts the kept username.
+func (s *RestorableAccountStore) FindArchivedUsername(id int) ([]byte, error) {
+	encrypted, err := s.AccountStore.FindArchivedUsername(id)
+	if err != nil || encrypted == nil {
+		return encrypted, err
+	}
+	username, err := compat.Decrypt(encrypted, s.encryptionKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Decrypt")
+	}
+	return []byte(username), nil
+}
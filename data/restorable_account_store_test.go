@@ -0,0 +1,45 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestorableAccountStore(t *testing.T) {
+	key := []byte("DLz2TNDRdWWA5w8YNeCJ7uzcS4WDzQmB")
+	store := mock.NewAccountStore()
+	restorable := data.NewRestorableAccountStore(store, key)
+
+	account, err := restorable.Create("restorable@keratin.tech", []byte("password"))
+	require.NoError(t, err)
+
+	err = restorable.Archive(account.ID)
+	require.NoError(t, err)
+
+	raw, err := store.FindArchivedUsername(account.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "restorable@keratin.tech")
+
+	username, err := restorable.FindArchivedUsername(account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "restorable@keratin.tech", string(username))
+
+	t.Run("archiving again", func(t *testing.T) {
+		err = restorable.Archive(account.ID)
+		require.NoError(t, err)
+
+		username, err := restorable.FindArchivedUsername(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "restorable@keratin.tech", string(username))
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		username, err := restorable.FindArchivedUsername(999)
+		require.NoError(t, err)
+		assert.Nil(t, username)
+	})
+}
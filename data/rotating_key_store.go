@@ -1,27 +1,27 @@
 package data
 
 import (
-	"crypto/rsa"
+	"crypto"
 	"sync"
 )
 
 // RotatingKeyStore is a KeyStore that may be rotated by a maintainer.
 type RotatingKeyStore struct {
-	keys   []*rsa.PrivateKey
+	keys   []crypto.Signer
 	rwLock *sync.RWMutex
 }
 
 // NewRotatingKeyStore builds a RotatingKeyStore
 func NewRotatingKeyStore() *RotatingKeyStore {
 	return &RotatingKeyStore{
-		keys:   []*rsa.PrivateKey{},
+		keys:   []crypto.Signer{},
 		rwLock: &sync.RWMutex{},
 	}
 }
 
 // Key returns the current key. It relies on the internal keys slice being sorted with the newest
 // key last.
-func (ks *RotatingKeyStore) Key() *rsa.PrivateKey {
+func (ks *RotatingKeyStore) Key() crypto.Signer {
 	ks.rwLock.RLock()
 	defer ks.rwLock.RUnlock()
 
@@ -33,7 +33,7 @@ func (ks *RotatingKeyStore) Key() *rsa.PrivateKey {
 }
 
 // Keys will return the previous and current keys, in that order.
-func (ks *RotatingKeyStore) Keys() []*rsa.PrivateKey {
+func (ks *RotatingKeyStore) Keys() []crypto.Signer {
 	ks.rwLock.RLock()
 	defer ks.rwLock.RUnlock()
 
@@ -42,8 +42,8 @@ func (ks *RotatingKeyStore) Keys() []*rsa.PrivateKey {
 
 // Rotate is responsible for adding a new key to the list. It maintains key order from oldest to
 // newest, and ensures a maximum of two entries.
-func (ks *RotatingKeyStore) Rotate(k *rsa.PrivateKey) {
-	keys := []*rsa.PrivateKey{}
+func (ks *RotatingKeyStore) Rotate(k crypto.Signer) {
+	keys := []crypto.Signer{}
 	if len(ks.keys) > 0 {
 		keys = append(keys, ks.keys[len(ks.keys)-1])
 	}
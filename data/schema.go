@@ -0,0 +1,38 @@
+package data
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/models"
+)
+
+// schemaTables are the tables that the SQL stores depend on, besides accounts.
+var schemaTables = []string{"oauth_accounts", "account_tags", "account_notes", "account_consents", "account_metadata", "api_keys", "outbox_messages", "webhook_secrets", "username_changes", "archived_usernames"}
+
+// CheckSchema reports whether the database has been migrated for this version of AuthN. Migrations
+// are not versioned, so it looks for the tables that the stores depend on and for every column of
+// models.Account.
+func CheckSchema(db *sqlx.DB) error {
+	columns := []string{}
+	accountType := reflect.TypeOf(models.Account{})
+	for i := 0; i < accountType.NumField(); i++ {
+		if column := accountType.Field(i).Tag.Get("db"); column != "" {
+			columns = append(columns, column)
+		}
+	}
+
+	_, err := db.Exec(fmt.Sprintf("SELECT %s FROM accounts LIMIT 0", strings.Join(columns, ", ")))
+	if err != nil {
+		return fmt.Errorf("accounts: %v", err)
+	}
+	for _, table := range schemaTables {
+		_, err := db.Exec(fmt.Sprintf("SELECT * FROM %s LIMIT 0", table))
+		if err != nil {
+			return fmt.Errorf("%s: %v", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSchema(t *testing.T) {
+	t.Run("migrated database", func(t *testing.T) {
+		db, err := sqlite3.TestDB()
+		require.NoError(t, err)
+		assert.NoError(t, data.CheckSchema(db))
+	})
+
+	t.Run("unmigrated database", func(t *testing.T) {
+		db, err := sqlx.Connect("sqlite3", "file:unmigrated?mode=memory")
+		require.NoError(t, err)
+		defer db.Close()
+		_, err = db.Exec("CREATE TABLE accounts (id INTEGER PRIMARY KEY, username TEXT)")
+		require.NoError(t, err)
+
+		err = data.CheckSchema(db)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "accounts")
+	})
+}
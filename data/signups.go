@@ -0,0 +1,8 @@
+package data
+
+type Signups interface {
+	Track() error
+	SignupsByDay() (map[string]int, error)
+	SignupsByWeek() (map[string]int, error)
+	SignupsByMonth() (map[string]int, error)
+}
@@ -1,6 +1,7 @@
 package sqlite3
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -10,11 +11,24 @@ import (
 
 type AccountStore struct {
 	*sqlx.DB
+	ctx context.Context
+}
+
+// WithContext returns a copy of the store whose queries are canceled with ctx.
+func (db *AccountStore) WithContext(ctx context.Context) *AccountStore {
+	return &AccountStore{DB: db.DB, ctx: ctx}
+}
+
+func (db *AccountStore) context() context.Context {
+	if db.ctx == nil {
+		return context.Background()
+	}
+	return db.ctx
 }
 
 func (db *AccountStore) Find(id int) (*models.Account, error) {
 	account := models.Account{}
-	err := db.Get(&account, "SELECT * FROM accounts WHERE id = ?", id)
+	err := db.GetContext(db.context(), &account, "SELECT * FROM accounts WHERE id = ?", id)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	} else if err != nil {
@@ -26,9 +40,31 @@ func (db *AccountStore) Find(id int) (*models.Account, error) {
 	return &account, nil
 }
 
+// FindMany finds every account in one query.
+func (db *AccountStore) FindMany(ids []int) ([]*models.Account, error) {
+	accounts := []*models.Account{}
+	if len(ids) == 0 {
+		return accounts, nil
+	}
+	query, args, err := sqlx.In("SELECT * FROM accounts WHERE id IN (?) ORDER BY id", ids)
+	if err != nil {
+		return nil, err
+	}
+	err = db.SelectContext(db.context(), &accounts, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		if account.DeletedAt != nil {
+			account.Username = ""
+		}
+	}
+	return accounts, nil
+}
+
 func (db *AccountStore) FindByUsername(u string) (*models.Account, error) {
 	account := models.Account{}
-	err := db.Get(&account, "SELECT * FROM accounts WHERE username = ? AND deleted_at IS NULL", u)
+	err := db.GetContext(db.context(), &account, "SELECT * FROM accounts WHERE username = ? COLLATE NOCASE AND deleted_at IS NULL", u)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	} else if err != nil {
@@ -39,7 +75,7 @@ func (db *AccountStore) FindByUsername(u string) (*models.Account, error) {
 
 func (db *AccountStore) FindByOauthAccount(provider string, providerID string) (*models.Account, error) {
 	account := models.Account{}
-	err := db.Get(&account, "SELECT a.* FROM accounts a INNER JOIN oauth_accounts oa ON a.id = oa.account_id WHERE oa.provider = ? AND oa.provider_id = ?", provider, providerID)
+	err := db.GetContext(db.context(), &account, "SELECT a.* FROM accounts a INNER JOIN oauth_accounts oa ON a.id = oa.account_id WHERE oa.provider = ? AND oa.provider_id = ?", provider, providerID)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	} else if err != nil {
@@ -59,7 +95,8 @@ func (db *AccountStore) Create(u string, p []byte) (*models.Account, error) {
 		UpdatedAt:         now,
 	}
 
-	result, err := db.NamedExec(
+	result, err := db.NamedExecContext(
+		db.context(),
 		"INSERT INTO accounts (username, password, locked, require_new_password, password_changed_at, created_at, updated_at) VALUES (:username, :password, :locked, :require_new_password, :password_changed_at, :created_at, :updated_at)",
 		account,
 	)
@@ -79,7 +116,7 @@ func (db *AccountStore) Create(u string, p []byte) (*models.Account, error) {
 func (db *AccountStore) AddOauthAccount(accountID int, provider string, providerID string, accessToken string) error {
 	now := time.Now()
 
-	_, err := db.NamedExec(`
+	_, err := db.NamedExecContext(db.context(), `
         INSERT INTO oauth_accounts (account_id, provider, provider_id, access_token, created_at, updated_at)
         VALUES (:account_id, :provider, :provider_id, :access_token, :created_at, :updated_at)
     `, map[string]interface{}{
@@ -95,40 +132,179 @@ func (db *AccountStore) AddOauthAccount(accountID int, provider string, provider
 
 func (db *AccountStore) GetOauthAccounts(accountID int) ([]*models.OauthAccount, error) {
 	accounts := []*models.OauthAccount{}
-	err := db.Select(&accounts, `SELECT * FROM oauth_accounts WHERE account_id = ?`, accountID)
+	err := db.SelectContext(db.context(), &accounts, `SELECT * FROM oauth_accounts WHERE account_id = ?`, accountID)
 	return accounts, err
 }
 
 func (db *AccountStore) Archive(id int) error {
-	_, err := db.Exec("DELETE FROM oauth_accounts WHERE account_id = ?", id)
+	_, err := db.ExecContext(db.context(), "DELETE FROM oauth_accounts WHERE account_id = ?", id)
 	if err != nil {
 		return err
 	}
-	_, err = db.Exec("UPDATE accounts SET username = '@'||HEX(RANDOMBLOB(16)), password = ?, deleted_at = ? WHERE id = ?", "", time.Now(), id)
+	_, err = db.ExecContext(db.context(), "UPDATE accounts SET username = '@'||HEX(RANDOMBLOB(16)), password = ?, deleted_at = ? WHERE id = ?", "", time.Now(), id)
 	return err
 }
 
 func (db *AccountStore) Lock(id int) error {
-	_, err := db.Exec("UPDATE accounts SET locked = ?, updated_at = ? WHERE id = ?", true, time.Now(), id)
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET locked = ?, updated_at = ? WHERE id = ?", true, time.Now(), id)
 	return err
 }
 
 func (db *AccountStore) Unlock(id int) error {
-	_, err := db.Exec("UPDATE accounts SET locked = ?, updated_at = ? WHERE id = ?", false, time.Now(), id)
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET locked = ?, updated_at = ? WHERE id = ?", false, time.Now(), id)
 	return err
 }
 
 func (db *AccountStore) RequireNewPassword(id int) error {
-	_, err := db.Exec("UPDATE accounts SET require_new_password = ?, updated_at = ? WHERE id = ?", true, time.Now(), id)
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET require_new_password = ?, updated_at = ? WHERE id = ?", true, time.Now(), id)
 	return err
 }
 
 func (db *AccountStore) SetPassword(id int, p []byte) error {
-	_, err := db.Exec("UPDATE accounts SET password = ?, require_new_password = ?, password_changed_at = ?, updated_at = ? WHERE id = ?", p, false, time.Now(), time.Now(), id)
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET password = ?, require_new_password = ?, password_changed_at = ?, updated_at = ? WHERE id = ?", p, false, time.Now(), time.Now(), id)
+	return err
+}
+
+func (db *AccountStore) UpgradePassword(id int, p []byte) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET password = ?, updated_at = ? WHERE id = ?", p, time.Now(), id)
 	return err
 }
 
 func (db *AccountStore) UpdateUsername(id int, u string) error {
-	_, err := db.Exec("UPDATE accounts SET username = ?, updated_at = ? WHERE id = ?", u, time.Now(), id)
+	tx, err := db.BeginTxx(db.context(), nil)
+	if err != nil {
+		return err
+	}
+	var from string
+	err = tx.GetContext(db.context(), &from, "SELECT username FROM accounts WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return tx.Rollback()
+	} else if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if from == u {
+		return tx.Rollback()
+	}
+	now := time.Now()
+	_, err = tx.ExecContext(db.context(), "UPDATE accounts SET username = ?, updated_at = ? WHERE id = ?", u, now, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.ExecContext(db.context(), "INSERT INTO username_changes (account_id, from_username, to_username, created_at) VALUES (?, ?, ?, ?)", id, from, u, now)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *AccountStore) GetUsernameHistory(id int) ([]*models.UsernameChange, error) {
+	changes := []*models.UsernameChange{}
+	err := db.SelectContext(db.context(), &changes, "SELECT * FROM username_changes WHERE account_id = ? ORDER BY id", id)
+	return changes, err
+}
+
+func (db *AccountStore) ScheduleArchive(id int, at time.Time) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET archive_at = ?, updated_at = ? WHERE id = ?", at, time.Now(), id)
+	return err
+}
+
+func (db *AccountStore) CancelArchive(id int) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET archive_at = NULL, updated_at = ? WHERE id = ?", time.Now(), id)
+	return err
+}
+
+func (db *AccountStore) FindScheduledArchives(before time.Time) ([]int, error) {
+	ids := []int{}
+	err := db.SelectContext(db.context(), &ids, "SELECT id FROM accounts WHERE archive_at <= ? AND deleted_at IS NULL ORDER BY id", before)
+	return ids, err
+}
+
+func (db *AccountStore) SetExpiry(id int, at *time.Time) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET expires_at = ?, updated_at = ? WHERE id = ?", at, time.Now(), id)
+	return err
+}
+
+func (db *AccountStore) SetLegalHold(id int, held bool) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET legal_hold = ?, updated_at = ? WHERE id = ?", held, time.Now(), id)
+	return err
+}
+
+func (db *AccountStore) FindExpired(before time.Time) ([]int, error) {
+	ids := []int{}
+	err := db.SelectContext(db.context(), &ids, "SELECT id FROM accounts WHERE expires_at <= ? AND deleted_at IS NULL ORDER BY id", before)
+	return ids, err
+}
+
+func (db *AccountStore) SetThrottledUntil(id int, until *time.Time) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET throttled_until = ?, updated_at = ? WHERE id = ?", until, time.Now(), id)
+	return err
+}
+
+func (db *AccountStore) SetExternalID(id int, externalID string) error {
+	_, err := db.ExecContext(db.context(), "UPDATE accounts SET external_id = ?, updated_at = ? WHERE id = ?", externalID, time.Now(), id)
 	return err
 }
+
+func (db *AccountStore) FindByExternalID(externalID string) (*models.Account, error) {
+	account := models.Account{}
+	err := db.GetContext(db.context(), &account, "SELECT * FROM accounts WHERE external_id = ?", externalID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (db *AccountStore) SaveArchivedUsername(id int, u []byte) error {
+	_, err := db.ExecContext(db.context(), "INSERT OR REPLACE INTO archived_usernames (account_id, username, created_at) VALUES (?, ?, ?)", id, u, time.Now())
+	return err
+}
+
+func (db *AccountStore) FindArchivedUsername(id int) ([]byte, error) {
+	var username []byte
+	err := db.GetContext(db.context(), &username, "SELECT username FROM archived_usernames WHERE account_id = ?", id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return username, err
+}
+
+func (db *AccountStore) Restore(id int, u string) error {
+	tx, err := db.BeginTxx(db.context(), nil)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(db.context(), "UPDATE accounts SET username = ?, deleted_at = NULL, archive_at = NULL, require_new_password = ?, updated_at = ? WHERE id = ?", u, true, time.Now(), id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.ExecContext(db.context(), "DELETE FROM archived_usernames WHERE account_id = ?", id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *AccountStore) PurgeArchivedUsernames(before time.Time) (int, error) {
+	result, err := db.ExecContext(db.context(), "DELETE FROM archived_usernames WHERE created_at < ? AND account_id NOT IN (SELECT id FROM accounts WHERE legal_hold = ?)", before, true)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+func (db *AccountStore) ClaimVersion(id int, version int) (bool, error) {
+	result, err := db.ExecContext(db.context(), "UPDATE accounts SET lock_version = lock_version + 1 WHERE id = ? AND lock_version = ?", id, version)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count == 1, err
+}
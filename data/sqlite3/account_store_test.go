@@ -12,7 +12,7 @@ func TestAccountStore(t *testing.T) {
 	for _, tester := range testers.AccountStoreTesters {
 		db, err := sqlite3.TestDB()
 		require.NoError(t, err)
-		store := &sqlite3.AccountStore{db}
+		store := &sqlite3.AccountStore{DB: db}
 		tester(t, store)
 		store.Close()
 	}
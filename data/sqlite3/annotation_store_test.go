@@ -0,0 +1,19 @@
+package sqlite3_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/sqlite3"
+	"github.com/keratin/authn-server/data/testers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotationStore(t *testing.T) {
+	for _, tester := range testers.AnnotationStoreTesters {
+		db, err := sqlite3.TestDB()
+		require.NoError(t, err)
+		store := &sqlite3.AnnotationStore{db}
+		tester(t, store)
+		store.Close()
+	}
+}
@@ -2,11 +2,9 @@ package sqlite3
 
 import (
 	"database/sql"
-	"math/rand"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	"github.com/keratin/authn-server/ops"
 	sq3 "github.com/mattn/go-sqlite3"
 	"github.com/pkg/errors"
 )
@@ -19,16 +17,10 @@ type BlobStore struct {
 	DB       *sqlx.DB
 }
 
-func (s *BlobStore) Clean(reporter ops.ErrorReporter) {
-	go func() {
-		for range time.Tick(time.Minute + time.Duration(rand.Intn(5))*time.Second) {
-			_, err := s.DB.Exec("DELETE FROM blobs WHERE expires_at < ?", time.Now())
-			if err != nil {
-				reporter.ReportError(errors.Wrap(err, "BlobStore Clean"))
-			}
-			time.Sleep(time.Minute)
-		}
-	}()
+// Clean deletes expired blobs.
+func (s *BlobStore) Clean() error {
+	_, err := s.DB.Exec("DELETE FROM blobs WHERE expires_at < ?", time.Now())
+	return errors.Wrap(err, "BlobStore Clean")
 }
 
 func (s *BlobStore) Read(name string) ([]byte, error) {
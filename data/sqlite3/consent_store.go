@@ -0,0 +1,55 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/models"
+)
+
+type ConsentStore struct {
+	*sqlx.DB
+}
+
+func (db *ConsentStore) Create(accountID int, termsVersion string, marketingOptIn bool, ip string) (*models.Consent, error) {
+	consent := &models.Consent{
+		AccountID:      accountID,
+		TermsVersion:   termsVersion,
+		MarketingOptIn: marketingOptIn,
+		IP:             ip,
+		CreatedAt:      time.Now(),
+	}
+
+	result, err := db.NamedExec(`
+        INSERT INTO account_consents (account_id, terms_version, marketing_opt_in, ip, created_at)
+        VALUES (:account_id, :terms_version, :marketing_opt_in, :ip, :created_at)
+    `, consent)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	consent.ID = int(id)
+
+	return consent, nil
+}
+
+func (db *ConsentStore) Latest(accountID int) (*models.Consent, error) {
+	consent := models.Consent{}
+	err := db.Get(&consent, "SELECT * FROM account_consents WHERE account_id = ? ORDER BY id DESC LIMIT 1", accountID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+func (db *ConsentStore) FindAll(accountID int) ([]*models.Consent, error) {
+	consents := []*models.Consent{}
+	err := db.Select(&consents, "SELECT * FROM account_consents WHERE account_id = ? ORDER BY id", accountID)
+	return consents, err
+}
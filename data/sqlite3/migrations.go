@@ -1,6 +1,10 @@
 package sqlite3
 
-import "github.com/jmoiron/sqlx"
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
 
 // MigrateDB is committed to doing the work necessary to converge the database
 // in a safe, production-grade fashion. This will mean conditional logic as it
@@ -12,6 +16,22 @@ func MigrateDB(db *sqlx.DB) error {
 		createRefreshTokens,
 		createBlobs,
 		createOauthAccounts,
+		createAccountAnnotations,
+		addAccountArchiveAt,
+		createAccountConsents,
+		addAccountExpiresAt,
+		createAccountMetadata,
+		addAccountThrottledUntil,
+		addAccountExternalID,
+		createAPIKeys,
+		createOutboxMessages,
+		createWebhookSecrets,
+		createUsernameChanges,
+		createArchivedUsernames,
+		addAccountLockVersion,
+		addAccountLegalHold,
+		// fails while usernames collide, so it runs after every migration that can not
+		addAccountFoldedUsernameIndex,
 	}
 	for _, m := range migrations {
 		if err := m(db); err != nil {
@@ -82,3 +102,257 @@ func createOauthAccounts(db *sqlx.DB) error {
     `)
 	return err
 }
+
+func createAccountAnnotations(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS account_tags (
+            account_id INTEGER NOT NULL,
+            tag TEXT NOT NULL,
+            created_at DATETIME NOT NULL,
+            UNIQUE(account_id, tag)
+        )
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE INDEX IF NOT EXISTS account_tags_by_tag ON account_tags (tag)
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS account_notes (
+            id INTEGER PRIMARY KEY,
+            account_id INTEGER NOT NULL,
+            body TEXT NOT NULL,
+            created_at DATETIME NOT NULL
+        )
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE INDEX IF NOT EXISTS account_notes_by_account_id ON account_notes (account_id)
+    `)
+	return err
+}
+
+func addAccountArchiveAt(db *sqlx.DB) error {
+	exists, err := hasColumn(db, "accounts", "archive_at")
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(`
+        ALTER TABLE accounts ADD COLUMN archive_at DATETIME
+    `)
+	return err
+}
+
+func hasColumn(db *sqlx.DB, table string, column string) (bool, error) {
+	var count int
+	err := db.Get(&count, "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column)
+	return count > 0, err
+}
+
+func createAccountConsents(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS account_consents (
+            id INTEGER PRIMARY KEY,
+            account_id INTEGER NOT NULL,
+            terms_version TEXT NOT NULL,
+            marketing_opt_in BOOLEAN NOT NULL,
+            ip TEXT NOT NULL,
+            created_at DATETIME NOT NULL
+        )
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE INDEX IF NOT EXISTS account_consents_by_account_id ON account_consents (account_id)
+    `)
+	return err
+}
+
+func addAccountExpiresAt(db *sqlx.DB) error {
+	exists, err := hasColumn(db, "accounts", "expires_at")
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(`
+        ALTER TABLE accounts ADD COLUMN expires_at DATETIME
+    `)
+	return err
+}
+
+func createAccountMetadata(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS account_metadata (
+            account_id INTEGER NOT NULL,
+            name TEXT NOT NULL,
+            value TEXT NOT NULL,
+            UNIQUE(account_id, name)
+        )
+    `)
+	return err
+}
+
+func addAccountThrottledUntil(db *sqlx.DB) error {
+	exists, err := hasColumn(db, "accounts", "throttled_until")
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(`
+        ALTER TABLE accounts ADD COLUMN throttled_until DATETIME
+    `)
+	return err
+}
+
+func addAccountExternalID(db *sqlx.DB) error {
+	exists, err := hasColumn(db, "accounts", "external_id")
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(`
+        ALTER TABLE accounts ADD COLUMN external_id TEXT
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE UNIQUE INDEX IF NOT EXISTS accounts_by_external_id ON accounts (external_id)
+    `)
+	return err
+}
+
+func createAPIKeys(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS api_keys (
+            id INTEGER PRIMARY KEY,
+            name TEXT NOT NULL,
+            scopes TEXT NOT NULL,
+            secret_hash TEXT NOT NULL,
+            created_at DATETIME NOT NULL,
+            revoked_at DATETIME
+        )
+    `)
+	return err
+}
+
+func createOutboxMessages(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS outbox_messages (
+            id INTEGER PRIMARY KEY,
+            kind TEXT NOT NULL,
+            destination TEXT NOT NULL,
+            payload TEXT NOT NULL,
+            status TEXT NOT NULL,
+            attempts INTEGER NOT NULL,
+            response_code INTEGER NOT NULL,
+            last_error TEXT NOT NULL,
+            created_at DATETIME NOT NULL,
+            next_attempt_at DATETIME NOT NULL,
+            delivered_at DATETIME
+        )
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE INDEX IF NOT EXISTS outbox_messages_by_status ON outbox_messages (status, next_attempt_at)
+    `)
+	return err
+}
+
+func createWebhookSecrets(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS webhook_secrets (
+            id INTEGER PRIMARY KEY,
+            endpoint TEXT NOT NULL,
+            secret TEXT NOT NULL,
+            created_at DATETIME NOT NULL,
+            expires_at DATETIME
+        )
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE INDEX IF NOT EXISTS webhook_secrets_by_endpoint ON webhook_secrets (endpoint)
+    `)
+	return err
+}
+
+func createUsernameChanges(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS username_changes (
+            id INTEGER PRIMARY KEY,
+            account_id INTEGER NOT NULL,
+            from_username TEXT NOT NULL,
+            to_username TEXT NOT NULL,
+            created_at DATETIME NOT NULL
+        )
+    `)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+        CREATE INDEX IF NOT EXISTS username_changes_by_account_id ON username_changes (account_id)
+    `)
+	return err
+}
+
+func createArchivedUsernames(db *sqlx.DB) error {
+	_, err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS archived_usernames (
+            account_id INTEGER PRIMARY KEY,
+            username BLOB NOT NULL,
+            created_at DATETIME NOT NULL
+        )
+    `)
+	return err
+}
+
+func addAccountLockVersion(db *sqlx.DB) error {
+	exists, err := hasColumn(db, "accounts", "lock_version")
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(`
+        ALTER TABLE accounts ADD COLUMN lock_version INTEGER NOT NULL DEFAULT 0
+    `)
+	return err
+}
+
+// addAccountFoldedUsernameIndex makes usernames unique regardless of case. It fails until any
+// existing collisions have been resolved (see `authn username-collisions`).
+func addAccountFoldedUsernameIndex(db *sqlx.DB) error {
+	var collisions int
+	err := db.Get(&collisions, `
+        SELECT COUNT(*) FROM (
+            SELECT 1 FROM accounts GROUP BY username COLLATE NOCASE HAVING COUNT(*) > 1
+        ) AS collisions
+    `)
+	if err != nil {
+		return err
+	}
+	if collisions > 0 {
+		return fmt.Errorf("%d usernames differ only by case: resolve them with `authn username-collisions` and migrate again", collisions)
+	}
+	_, err = db.Exec(`
+        CREATE UNIQUE INDEX IF NOT EXISTS accounts_by_folded_username ON accounts (username COLLATE NOCASE)
+    `)
+	return err
+}
+
+func addAccountLegalHold(db *sqlx.DB) error {
+	exists, err := hasColumn(db, "accounts", "legal_hold")
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(`
+        ALTER TABLE accounts ADD COLUMN legal_hold BOOLEAN NOT NULL DEFAULT false
+    `)
+	return err
+}
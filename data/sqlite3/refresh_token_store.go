@@ -1,12 +1,11 @@
 package sqlite3
 
 import (
+	"context"
 	"database/sql"
 	"encoding/hex"
-	"math/rand"
 	"time"
 
-	"github.com/keratin/authn-server/ops"
 	"github.com/pkg/errors"
 
 	"github.com/jmoiron/sqlx"
@@ -17,18 +16,25 @@ import (
 type RefreshTokenStore struct {
 	*sqlx.DB
 	TTL time.Duration
+	ctx context.Context
 }
 
-func (s *RefreshTokenStore) Clean(reporter ops.ErrorReporter) {
-	go func() {
-		for range time.Tick(time.Minute + time.Duration(rand.Intn(5))*time.Second) {
-			_, err := s.Exec("DELETE FROM refresh_tokens WHERE expires_at < ?", time.Now())
-			if err != nil {
-				reporter.ReportError(errors.Wrap(err, "RefreshTokenStore Clean"))
-			}
-			time.Sleep(time.Minute)
-		}
-	}()
+// WithContext returns a copy of the store whose queries are canceled with ctx.
+func (s *RefreshTokenStore) WithContext(ctx context.Context) *RefreshTokenStore {
+	return &RefreshTokenStore{DB: s.DB, TTL: s.TTL, ctx: ctx}
+}
+
+func (s *RefreshTokenStore) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// Clean deletes expired tokens.
+func (s *RefreshTokenStore) Clean() error {
+	_, err := s.Exec("DELETE FROM refresh_tokens WHERE expires_at < ?", time.Now())
+	return errors.Wrap(err, "RefreshTokenStore Clean")
 }
 
 func (s *RefreshTokenStore) Create(accountID int) (models.RefreshToken, error) {
@@ -38,7 +44,8 @@ func (s *RefreshTokenStore) Create(accountID int) (models.RefreshToken, error) {
 	}
 	token := hex.EncodeToString(binToken)
 
-	_, err = s.Exec(
+	_, err = s.ExecContext(
+		s.context(),
 		"INSERT INTO refresh_tokens (account_id, token, expires_at) VALUES (?, ?, ?)",
 		accountID,
 		token,
@@ -50,9 +57,69 @@ func (s *RefreshTokenStore) Create(accountID int) (models.RefreshToken, error) {
 	return models.RefreshToken(token), nil
 }
 
+// CreateLimited counts and creates tokens within a transaction. SQLite allows only one writer at a
+// time, so concurrent logins for an account can not both take the last slot.
+func (s *RefreshTokenStore) CreateLimited(accountID int, max int, evict bool) (models.RefreshToken, error) {
+	binToken, err := lib.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(binToken)
+
+	tx, err := s.BeginTxx(s.context(), nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if max > 0 {
+		var active []string
+		err = tx.SelectContext(
+			s.context(),
+			&active,
+			"SELECT token FROM refresh_tokens WHERE account_id = ? AND expires_at > ? ORDER BY expires_at ASC, rowid ASC",
+			accountID,
+			time.Now(),
+		)
+		if err != nil {
+			return "", err
+		}
+
+		if len(active) >= max {
+			if !evict {
+				return "", nil
+			}
+			for _, t := range active[:len(active)-max+1] {
+				_, err = tx.ExecContext(s.context(), "DELETE FROM refresh_tokens WHERE token = ?", t)
+				if err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(
+		s.context(),
+		"INSERT INTO refresh_tokens (account_id, token, expires_at) VALUES (?, ?, ?)",
+		accountID,
+		token,
+		time.Now().Add(s.TTL),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return "", err
+	}
+	return models.RefreshToken(token), nil
+}
+
 func (s *RefreshTokenStore) Find(token models.RefreshToken) (int, error) {
 	var accountID int
-	err := s.QueryRow(
+	err := s.QueryRowContext(
+		s.context(),
 		"SELECT account_id FROM refresh_tokens WHERE token = ? AND expires_at > ?",
 		token,
 		time.Now(),
@@ -67,8 +134,22 @@ func (s *RefreshTokenStore) Find(token models.RefreshToken) (int, error) {
 	return accountID, nil
 }
 
+// FindMany finds each token in turn, since SQLite is local and a lookup costs no round trip.
+func (s *RefreshTokenStore) FindMany(tokens []models.RefreshToken) ([]int, error) {
+	ids := make([]int, len(tokens))
+	for i, token := range tokens {
+		id, err := s.Find(token)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
 func (s *RefreshTokenStore) Touch(token models.RefreshToken, accountID int) error {
-	_, err := s.Exec(
+	_, err := s.ExecContext(
+		s.context(),
 		"UPDATE refresh_tokens SET expires_at = ? WHERE token = ? AND expires_at > ?",
 		time.Now().Add(s.TTL),
 		token,
@@ -79,8 +160,9 @@ func (s *RefreshTokenStore) Touch(token models.RefreshToken, accountID int) erro
 
 func (s *RefreshTokenStore) FindAll(accountID int) ([]models.RefreshToken, error) {
 	var tokens []models.RefreshToken
-	rows, err := s.Query(
-		"SELECT token FROM refresh_tokens WHERE account_id = ? AND expires_at > ?",
+	rows, err := s.QueryContext(
+		s.context(),
+		"SELECT token FROM refresh_tokens WHERE account_id = ? AND expires_at > ? ORDER BY expires_at ASC, rowid ASC",
 		accountID,
 		time.Now(),
 	)
@@ -101,6 +183,6 @@ func (s *RefreshTokenStore) FindAll(accountID int) ([]models.RefreshToken, error
 }
 
 func (s *RefreshTokenStore) Revoke(token models.RefreshToken) error {
-	_, err := s.Exec("DELETE FROM refresh_tokens WHERE token = ?", token)
+	_, err := s.ExecContext(s.context(), "DELETE FROM refresh_tokens WHERE token = ?", token)
 	return err
 }
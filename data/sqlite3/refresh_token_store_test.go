@@ -13,7 +13,7 @@ func TestRefreshTokenStore(t *testing.T) {
 	for _, tester := range testers.RefreshTokenStoreTesters {
 		db, err := sqlite3.TestDB()
 		require.NoError(t, err)
-		store := &sqlite3.RefreshTokenStore{db, time.Second}
+		store := &sqlite3.RefreshTokenStore{DB: db, TTL: time.Second}
 		tester(t, store)
 		store.Close()
 	}
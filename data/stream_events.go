@@ -0,0 +1,47 @@
+package data
+
+import (
+	"encoding/json"
+	"time"
+
+	dataRedis "github.com/keratin/authn-server/data/redis"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/ops"
+	"github.com/pkg/errors"
+)
+
+const eventsTopic = "events"
+
+// StreamEvents is an events.Publisher that shares events with every AuthN server through a Redis
+// stream, so that each may notify its own subscribers.
+type StreamEvents struct {
+	Stream *dataRedis.Stream
+}
+
+// Publish adds the event's JSON to the stream.
+func (s *StreamEvents) Publish(e events.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.Stream.Add(eventsTopic, string(payload))
+}
+
+// Listen hands every shared event to the broker, including those published by this server. When
+// reading fails, it is reported and retried after a second.
+func (s *StreamEvents) Listen(broker *events.Broker, reporter ops.ErrorReporter) {
+	go func() {
+		for {
+			err := s.Stream.Read(eventsTopic, func(payload string) {
+				var e events.Event
+				if err := json.Unmarshal([]byte(payload), &e); err != nil {
+					reporter.ReportError(errors.Wrap(err, "Unmarshal"))
+					return
+				}
+				broker.Publish(e)
+			})
+			reporter.ReportError(errors.Wrap(err, "Read"))
+			time.Sleep(time.Second)
+		}
+	}()
+}
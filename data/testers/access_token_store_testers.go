@@ -0,0 +1,76 @@
+package testers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var AccessTokenStoreTesters = []func(*testing.T, data.AccessTokenStore){
+	testAccessTokenCreate,
+	testAccessTokenRevoke,
+	testAccessTokenFindMany,
+}
+
+func testAccessTokenCreate(t *testing.T, store data.AccessTokenStore) {
+	meta := &models.AccessToken{
+		AccountID: 123,
+		Session:   models.RefreshToken("session"),
+		Audience:  "example.com",
+		IssuedAt:  time.Now().Truncate(time.Second),
+		ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+	token, err := store.Create(meta)
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	found, err := store.Find(token)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, 123, found.AccountID)
+	assert.Equal(t, models.RefreshToken("session"), found.Session)
+	assert.Equal(t, "example.com", found.Audience)
+	assert.True(t, meta.ExpiresAt.Equal(found.ExpiresAt))
+
+	found, err = store.Find("unknown")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func testAccessTokenRevoke(t *testing.T, store data.AccessTokenStore) {
+	token, err := store.Create(&models.AccessToken{
+		AccountID: 123,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Revoke(token))
+	found, err := store.Find(token)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	require.NoError(t, store.Revoke("unknown"))
+}
+
+func testAccessTokenFindMany(t *testing.T, store data.AccessTokenStore) {
+	found, err := store.FindMany([]string{})
+	require.NoError(t, err)
+	assert.Len(t, found, 0)
+
+	token, err := store.Create(&models.AccessToken{
+		AccountID: 123,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	found, err = store.FindMany([]string{"unknown", token})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Nil(t, found[0])
+	require.NotNil(t, found[1])
+	assert.Equal(t, 123, found[1].AccountID)
+}
@@ -2,6 +2,7 @@ package testers
 
 import (
 	"testing"
+	"time"
 
 	"github.com/keratin/authn-server/data"
 	"github.com/stretchr/testify/assert"
@@ -11,13 +12,24 @@ import (
 var AccountStoreTesters = []func(*testing.T, data.AccountStore){
 	testCreate,
 	testFindByUsername,
+	testFindMany,
 	testLockAndUnlock,
 	testArchive,
 	testArchiveWithOauth,
 	testRequireNewPassword,
 	testSetPassword,
+	testUpgradePassword,
 	testAddOauthAccount,
 	testFindByOauthAccount,
+	testUpdateUsername,
+	testUsernameHistory,
+	testScheduleArchive,
+	testSetExpiry,
+	testSetThrottledUntil,
+	testSetLegalHold,
+	testSetExternalID,
+	testRestore,
+	testClaimVersion,
 }
 
 func testCreate(t *testing.T, store data.AccountStore) {
@@ -51,6 +63,30 @@ func testFindByUsername(t *testing.T, store data.AccountStore) {
 	assert.NotNil(t, account)
 }
 
+func testFindMany(t *testing.T, store data.AccountStore) {
+	found, err := store.FindMany([]int{})
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	first, err := store.Create("first@keratin.tech", []byte("password"))
+	require.NoError(t, err)
+	second, err := store.Create("second@keratin.tech", []byte("password"))
+	require.NoError(t, err)
+	archived, err := store.Create("archived@keratin.tech", []byte("password"))
+	require.NoError(t, err)
+	require.NoError(t, store.Archive(archived.ID))
+
+	found, err = store.FindMany([]int{archived.ID, first.ID, 0, second.ID, first.ID})
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+	assert.Equal(t, first.ID, found[0].ID)
+	assert.Equal(t, "first@keratin.tech", found[0].Username)
+	assert.Equal(t, second.ID, found[1].ID)
+	assert.Equal(t, archived.ID, found[2].ID)
+	assert.Equal(t, "", found[2].Username)
+	assert.NotNil(t, found[2].DeletedAt)
+}
+
 func testLockAndUnlock(t *testing.T, store data.AccountStore) {
 	account, err := store.Create("authn@keratin.tech", []byte("password"))
 	require.NoError(t, err)
@@ -137,6 +173,19 @@ func testSetPassword(t *testing.T, store data.AccountStore) {
 	assert.NotEqual(t, account.PasswordChangedAt, after.PasswordChangedAt)
 }
 
+func testUpgradePassword(t *testing.T, store data.AccountStore) {
+	account, err := store.Create("authn@keratin.tech", []byte("legacy"))
+	require.NoError(t, err)
+
+	err = store.UpgradePassword(account.ID, []byte("upgraded"))
+	require.NoError(t, err)
+
+	after, err := store.Find(account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("upgraded"), after.Password)
+	assert.Equal(t, account.PasswordChangedAt.Unix(), after.PasswordChangedAt.Unix())
+}
+
 func testUpdateUsername(t *testing.T, store data.AccountStore) {
 	account, err := store.Create("old", []byte("old"))
 	require.NoError(t, err)
@@ -147,6 +196,38 @@ func testUpdateUsername(t *testing.T, store data.AccountStore) {
 	after, err := store.Find(account.ID)
 	require.NoError(t, err)
 	assert.Equal(t, "new", after.Username)
+
+	found, err := store.FindByUsername("new")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, account.ID, found.ID)
+
+	other, err := store.Create("other", []byte("other"))
+	require.NoError(t, err)
+	err = store.UpdateUsername(other.ID, "new")
+	assert.True(t, data.IsUniquenessError(err))
+}
+
+func testUsernameHistory(t *testing.T, store data.AccountStore) {
+	account, err := store.Create("first", []byte("password"))
+	require.NoError(t, err)
+
+	changes, err := store.GetUsernameHistory(account.ID)
+	require.NoError(t, err)
+	assert.Len(t, changes, 0)
+
+	require.NoError(t, store.UpdateUsername(account.ID, "second"))
+	require.NoError(t, store.UpdateUsername(account.ID, "second"))
+	require.NoError(t, store.UpdateUsername(account.ID, "third"))
+
+	changes, err = store.GetUsernameHistory(account.ID)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "first", changes[0].From)
+	assert.Equal(t, "second", changes[0].To)
+	assert.Equal(t, "second", changes[1].From)
+	assert.Equal(t, "third", changes[1].To)
+	assert.WithinDuration(t, time.Now(), changes[1].CreatedAt, time.Minute)
 }
 
 func testAddOauthAccount(t *testing.T, store data.AccountStore) {
@@ -197,3 +278,205 @@ func testFindByOauthAccount(t *testing.T, store data.AccountStore) {
 	assert.NoError(t, err)
 	assert.Equal(t, account.ID, found.ID)
 }
+
+func testScheduleArchive(t *testing.T, store data.AccountStore) {
+	now := time.Now()
+
+	due, err := store.Create("due", []byte("password"))
+	require.NoError(t, err)
+	err = store.ScheduleArchive(due.ID, now.Add(-time.Minute))
+	require.NoError(t, err)
+
+	later, err := store.Create("later", []byte("password"))
+	require.NoError(t, err)
+	err = store.ScheduleArchive(later.ID, now.Add(time.Hour))
+	require.NoError(t, err)
+
+	cancelled, err := store.Create("cancelled", []byte("password"))
+	require.NoError(t, err)
+	err = store.ScheduleArchive(cancelled.ID, now.Add(-time.Minute))
+	require.NoError(t, err)
+	err = store.CancelArchive(cancelled.ID)
+	require.NoError(t, err)
+
+	after, err := store.Find(due.ID)
+	require.NoError(t, err)
+	require.NotNil(t, after.ArchiveAt)
+	assert.WithinDuration(t, now.Add(-time.Minute), *after.ArchiveAt, time.Second)
+
+	after, err = store.Find(cancelled.ID)
+	require.NoError(t, err)
+	assert.Nil(t, after.ArchiveAt)
+
+	ids, err := store.FindScheduledArchives(now)
+	require.NoError(t, err)
+	assert.Equal(t, []int{due.ID}, ids)
+
+	err = store.Archive(due.ID)
+	require.NoError(t, err)
+	ids, err = store.FindScheduledArchives(now)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func testSetExpiry(t *testing.T, store data.AccountStore) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	expired, err := store.Create("expired", []byte("password"))
+	require.NoError(t, err)
+	err = store.SetExpiry(expired.ID, &past)
+	require.NoError(t, err)
+
+	temporary, err := store.Create("temporary", []byte("password"))
+	require.NoError(t, err)
+	err = store.SetExpiry(temporary.ID, &future)
+	require.NoError(t, err)
+
+	extended, err := store.Create("extended", []byte("password"))
+	require.NoError(t, err)
+	err = store.SetExpiry(extended.ID, &past)
+	require.NoError(t, err)
+	err = store.SetExpiry(extended.ID, nil)
+	require.NoError(t, err)
+
+	after, err := store.Find(expired.ID)
+	require.NoError(t, err)
+	require.NotNil(t, after.ExpiresAt)
+	assert.WithinDuration(t, past, *after.ExpiresAt, time.Second)
+	assert.True(t, after.Expired())
+
+	after, err = store.Find(extended.ID)
+	require.NoError(t, err)
+	assert.Nil(t, after.ExpiresAt)
+
+	ids, err := store.FindExpired(now)
+	require.NoError(t, err)
+	assert.Equal(t, []int{expired.ID}, ids)
+
+	err = store.Archive(expired.ID)
+	require.NoError(t, err)
+	ids, err = store.FindExpired(now)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func testSetThrottledUntil(t *testing.T, store data.AccountStore) {
+	until := time.Now().Add(time.Minute)
+
+	account, err := store.Create("throttled", []byte("password"))
+	require.NoError(t, err)
+	err = store.SetThrottledUntil(account.ID, &until)
+	require.NoError(t, err)
+
+	after, err := store.Find(account.ID)
+	require.NoError(t, err)
+	require.NotNil(t, after.ThrottledUntil)
+	assert.WithinDuration(t, until, *after.ThrottledUntil, time.Second)
+	assert.True(t, after.Throttled())
+	assert.False(t, after.Locked)
+
+	err = store.SetThrottledUntil(account.ID, nil)
+	require.NoError(t, err)
+	after, err = store.Find(account.ID)
+	require.NoError(t, err)
+	assert.Nil(t, after.ThrottledUntil)
+	assert.False(t, after.Throttled())
+}
+
+func testSetLegalHold(t *testing.T, store data.AccountStore) {
+	account, err := store.Create("held", []byte("password"))
+	require.NoError(t, err)
+	assert.False(t, account.LegalHold)
+
+	require.NoError(t, store.SetLegalHold(account.ID, true))
+	after, err := store.Find(account.ID)
+	require.NoError(t, err)
+	assert.True(t, after.LegalHold)
+
+	require.NoError(t, store.SaveArchivedUsername(account.ID, []byte("held")))
+	count, err := store.PurgeArchivedUsernames(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	username, err := store.FindArchivedUsername(account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("held"), username)
+
+	require.NoError(t, store.SetLegalHold(account.ID, false))
+	after, err = store.Find(account.ID)
+	require.NoError(t, err)
+	assert.False(t, after.LegalHold)
+
+	count, err = store.PurgeArchivedUsernames(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func testSetExternalID(t *testing.T, store data.AccountStore) {
+	account, err := store.Create("correlated", []byte("password"))
+	require.NoError(t, err)
+	other, err := store.Create("other", []byte("password"))
+	require.NoError(t, err)
+
+	found, err := store.FindByExternalID("crm-123")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	err = store.SetExternalID(account.ID, "crm-123")
+	require.NoError(t, err)
+	found, err = store.FindByExternalID("crm-123")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, account.ID, found.ID)
+	require.NotNil(t, found.ExternalID)
+	assert.Equal(t, "crm-123", *found.ExternalID)
+
+	err = store.SetExternalID(other.ID, "crm-123")
+	assert.True(t, data.IsUniquenessError(err))
+}
+
+func testRestore(t *testing.T, store data.AccountStore) {
+	account, err := store.Create("restorable", []byte("password"))
+	require.NoError(t, err)
+	require.NoError(t, store.SaveArchivedUsername(account.ID, []byte("restorable")))
+	require.NoError(t, store.Archive(account.ID))
+
+	username, err := store.FindArchivedUsername(account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("restorable"), username)
+
+	err = store.Restore(account.ID, string(username))
+	require.NoError(t, err)
+	after, err := store.Find(account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "restorable", after.Username)
+	assert.False(t, after.Archived())
+	assert.True(t, after.RequireNewPassword)
+
+	username, err = store.FindArchivedUsername(account.ID)
+	require.NoError(t, err)
+	assert.Nil(t, username)
+
+	require.NoError(t, store.SaveArchivedUsername(account.ID, []byte("restorable")))
+	count, err := store.PurgeArchivedUsernames(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func testClaimVersion(t *testing.T, store data.AccountStore) {
+	account, err := store.Create("versioned", []byte("password"))
+	require.NoError(t, err)
+
+	claimed, err := store.ClaimVersion(account.ID, account.Version)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = store.ClaimVersion(account.ID, account.Version)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	after, err := store.Find(account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, account.Version+1, after.Version)
+}
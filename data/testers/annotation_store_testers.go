@@ -0,0 +1,102 @@
+package testers
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var AnnotationStoreTesters = []func(*testing.T, data.AnnotationStore){
+	testTagAndUntag,
+	testFindByTag,
+	testAddAndGetNotes,
+	testDeleteNote,
+	testSetAndGetMetadata,
+}
+
+func testTagAndUntag(t *testing.T, store data.AnnotationStore) {
+	require.NoError(t, store.Tag(1, "vip"))
+	require.NoError(t, store.Tag(1, "fraud-review"))
+	require.NoError(t, store.Tag(1, "vip"))
+
+	tags, err := store.GetTags(1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fraud-review", "vip"}, tags)
+
+	require.NoError(t, store.Untag(1, "vip"))
+	require.NoError(t, store.Untag(1, "unknown"))
+
+	tags, err = store.GetTags(1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fraud-review"}, tags)
+
+	tags, err = store.GetTags(2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, tags)
+}
+
+func testFindByTag(t *testing.T, store data.AnnotationStore) {
+	require.NoError(t, store.Tag(3, "vip"))
+	require.NoError(t, store.Tag(1, "vip"))
+	require.NoError(t, store.Tag(2, "other"))
+
+	ids, err := store.FindByTag("vip")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, ids)
+
+	ids, err = store.FindByTag("unknown")
+	require.NoError(t, err)
+	assert.Equal(t, []int{}, ids)
+}
+
+func testAddAndGetNotes(t *testing.T, store data.AnnotationStore) {
+	first, err := store.AddNote(1, "called about a chargeback")
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.ID)
+	assert.Equal(t, 1, first.AccountID)
+	assert.Equal(t, "called about a chargeback", first.Body)
+	assert.NotEmpty(t, first.CreatedAt)
+
+	second, err := store.AddNote(1, "refund issued")
+	require.NoError(t, err)
+	_, err = store.AddNote(2, "unrelated")
+	require.NoError(t, err)
+
+	notes, err := store.GetNotes(1)
+	require.NoError(t, err)
+	require.Len(t, notes, 2)
+	assert.Equal(t, first.ID, notes[0].ID)
+	assert.Equal(t, second.ID, notes[1].ID)
+	assert.Equal(t, "refund issued", notes[1].Body)
+}
+
+func testDeleteNote(t *testing.T, store data.AnnotationStore) {
+	note, err := store.AddNote(1, "temporary")
+	require.NoError(t, err)
+
+	require.NoError(t, store.DeleteNote(2, note.ID))
+	notes, err := store.GetNotes(1)
+	require.NoError(t, err)
+	assert.Len(t, notes, 1)
+
+	require.NoError(t, store.DeleteNote(1, note.ID))
+	notes, err = store.GetNotes(1)
+	require.NoError(t, err)
+	assert.Empty(t, notes)
+}
+
+func testSetAndGetMetadata(t *testing.T, store data.AnnotationStore) {
+	metadata, err := store.GetMetadata(1)
+	require.NoError(t, err)
+	assert.Empty(t, metadata)
+
+	require.NoError(t, store.SetMetadata(1, map[string]string{"name": "Jane", "locale": "en"}))
+	require.NoError(t, store.SetMetadata(1, map[string]string{"locale": "fr"}))
+	require.NoError(t, store.SetMetadata(2, map[string]string{"name": "John"}))
+
+	metadata, err = store.GetMetadata(1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "Jane", "locale": "fr"}, metadata)
+}
@@ -0,0 +1,64 @@
+package testers
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var APIKeyStoreTesters = []func(*testing.T, data.APIKeyStore){
+	testCreateAndFindAPIKeys,
+	testRevokeAPIKey,
+}
+
+func testCreateAndFindAPIKeys(t *testing.T, store data.APIKeyStore) {
+	key, err := store.Create("billing", "read-only stats", "abc123")
+	require.NoError(t, err)
+	assert.NotEqual(t, 0, key.ID)
+	assert.NotEmpty(t, key.CreatedAt)
+	assert.False(t, key.Revoked())
+
+	found, err := store.Find(key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "billing", found.Name)
+	assert.Equal(t, []string{"read-only", "stats"}, found.ScopeList())
+	assert.Equal(t, "abc123", found.SecretHash)
+	assert.Nil(t, found.RevokedAt)
+
+	_, err = store.Create("support", "account-admin", "def456")
+	require.NoError(t, err)
+
+	keys, err := store.FindAll()
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "billing", keys[0].Name)
+	assert.Equal(t, "support", keys[1].Name)
+
+	found, err = store.Find(key.ID + 100)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func testRevokeAPIKey(t *testing.T, store data.APIKeyStore) {
+	key, err := store.Create("billing", "read-only", "abc123")
+	require.NoError(t, err)
+
+	ok, err := store.Revoke(key.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	found, err := store.Find(key.ID)
+	require.NoError(t, err)
+	assert.True(t, found.Revoked())
+
+	ok, err = store.Revoke(key.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = store.Revoke(key.ID + 100)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
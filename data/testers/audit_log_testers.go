@@ -0,0 +1,175 @@
+package testers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var AuditLogTesters = []func(*testing.T, data.AuditLog){
+	testAuditLogFind,
+	testAuditLogFindByAccount,
+	testAuditLogPagination,
+	testAuditLogFindAfter,
+	testAuditLogChain,
+	testAuditLogFindBefore,
+	testAuditLogDelete,
+}
+
+func appendEvents(t *testing.T, log data.AuditLog, accountIDs ...int) {
+	for i, id := range accountIDs {
+		err := log.Append(events.Event{
+			ID:        string(rune('a' + i)),
+			Type:      events.AccountUpdated,
+			AccountID: id,
+			Time:      time.Now().UTC().Truncate(time.Second),
+		})
+		require.NoError(t, err)
+	}
+}
+
+func testAuditLogFind(t *testing.T, log data.AuditLog) {
+	appendEvents(t, log, 1, 2, 1)
+
+	entries, err := log.Find(0, "", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "c", entries[0].Event.ID)
+	assert.Equal(t, "b", entries[1].Event.ID)
+	assert.Equal(t, "a", entries[2].Event.ID)
+	assert.Equal(t, events.AccountUpdated, entries[0].Event.Type)
+	assert.NotEmpty(t, entries[0].Cursor)
+}
+
+func testAuditLogFindByAccount(t *testing.T, log data.AuditLog) {
+	appendEvents(t, log, 1, 2, 1)
+
+	entries, err := log.Find(1, "", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "c", entries[0].Event.ID)
+	assert.Equal(t, "a", entries[1].Event.ID)
+
+	entries, err = log.Find(3, "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func testAuditLogPagination(t *testing.T, log data.AuditLog) {
+	appendEvents(t, log, 1, 1, 1)
+
+	page, err := log.Find(1, "", 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "c", page[0].Event.ID)
+	assert.Equal(t, "b", page[1].Event.ID)
+
+	page, err = log.Find(1, page[1].Cursor, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "a", page[0].Event.ID)
+
+	page, err = log.Find(1, page[0].Cursor, 2)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+}
+
+func testAuditLogFindAfter(t *testing.T, log data.AuditLog) {
+	appendEvents(t, log, 1, 2, 1)
+
+	page, err := log.FindAfter("", 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "a", page[0].Event.ID)
+	assert.Equal(t, "b", page[1].Event.ID)
+
+	page, err = log.FindAfter(page[1].Cursor, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "c", page[0].Event.ID)
+
+	page, err = log.FindAfter(page[0].Cursor, 2)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+}
+
+func testAuditLogChain(t *testing.T, log data.AuditLog) {
+	appendEvents(t, log, 1, 2, 1)
+
+	entries, err := log.FindAfter("", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	for i, entry := range entries {
+		assert.NotEmpty(t, entry.Hash)
+		if i > 0 {
+			assert.Equal(t, entries[i-1].Hash, entry.PrevHash)
+		}
+	}
+
+	found, err := log.Find(1, "", 10)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, entries[2], found[0])
+}
+
+func testAuditLogFindBefore(t *testing.T, log data.AuditLog) {
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, age := range []time.Duration{72 * time.Hour, 48 * time.Hour, time.Hour} {
+		err := log.Append(events.Event{
+			ID:        string(rune('a' + i)),
+			Type:      events.AccountUpdated,
+			AccountID: 1,
+			Time:      now.Add(-age),
+		})
+		require.NoError(t, err)
+	}
+
+	entries, err := log.FindBefore(now.Add(-24*time.Hour), "", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "a", entries[0].Event.ID)
+	assert.Equal(t, "b", entries[1].Event.ID)
+
+	entries, err = log.FindBefore(now.Add(-24*time.Hour), "", 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "a", entries[0].Event.ID)
+
+	entries, err = log.FindBefore(now.Add(-24*time.Hour), entries[0].Cursor, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "b", entries[0].Event.ID)
+
+	entries, err = log.FindBefore(now.Add(-96*time.Hour), "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func testAuditLogDelete(t *testing.T, log data.AuditLog) {
+	appendEvents(t, log, 1, 2, 1)
+
+	entries, err := log.Find(0, "", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	err = log.Delete(entries[1:])
+	require.NoError(t, err)
+
+	entries, err = log.Find(0, "", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "c", entries[0].Event.ID)
+
+	entries, err = log.Find(1, "", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "c", entries[0].Event.ID)
+
+	entries, err = log.Find(2, "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,57 @@
+package testers
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ConsentStoreTesters = []func(*testing.T, data.ConsentStore){
+	testCreateAndFindConsents,
+	testLatestConsent,
+}
+
+func testCreateAndFindConsents(t *testing.T, store data.ConsentStore) {
+	consent, err := store.Create(1, "2019-01", true, "127.0.0.1")
+	require.NoError(t, err)
+	assert.NotEqual(t, 0, consent.ID)
+	assert.Equal(t, "2019-01", consent.TermsVersion)
+	assert.True(t, consent.MarketingOptIn)
+	assert.Equal(t, "127.0.0.1", consent.IP)
+	assert.NotEmpty(t, consent.CreatedAt)
+
+	_, err = store.Create(1, "2019-06", false, "127.0.0.2")
+	require.NoError(t, err)
+
+	consents, err := store.FindAll(1)
+	require.NoError(t, err)
+	require.Len(t, consents, 2)
+	assert.Equal(t, "2019-01", consents[0].TermsVersion)
+	assert.Equal(t, "2019-06", consents[1].TermsVersion)
+	assert.False(t, consents[1].MarketingOptIn)
+
+	consents, err = store.FindAll(2)
+	require.NoError(t, err)
+	assert.Empty(t, consents)
+}
+
+func testLatestConsent(t *testing.T, store data.ConsentStore) {
+	consent, err := store.Latest(3)
+	require.NoError(t, err)
+	assert.Nil(t, consent)
+
+	_, err = store.Create(3, "2019-01", false, "127.0.0.1")
+	require.NoError(t, err)
+	_, err = store.Create(3, "2019-06", true, "127.0.0.1")
+	require.NoError(t, err)
+	_, err = store.Create(4, "2019-12", false, "127.0.0.1")
+	require.NoError(t, err)
+
+	consent, err = store.Latest(3)
+	require.NoError(t, err)
+	require.NotNil(t, consent)
+	assert.Equal(t, "2019-06", consent.TermsVersion)
+	assert.True(t, consent.MarketingOptIn)
+}
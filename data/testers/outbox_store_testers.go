@@ -0,0 +1,181 @@
+package testers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var OutboxStoreTesters = []func(*testing.T, data.OutboxStore){
+	testCreateAndFindOutboxMessages,
+	testOutboxDelivery,
+	testPruneOutbox,
+	testFindRecentOutboxMessages,
+	testRedeliverOutboxMessage,
+}
+
+func testCreateAndFindOutboxMessages(t *testing.T, store data.OutboxStore) {
+	msg, err := store.Create(models.OutboxWebhook, "https://app.example.com/events", "account_id=1")
+	require.NoError(t, err)
+	assert.NotEqual(t, 0, msg.ID)
+	assert.Equal(t, models.OutboxPending, msg.Status)
+
+	found, err := store.Find(msg.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, models.OutboxWebhook, found.Kind)
+	assert.Equal(t, "https://app.example.com/events", found.Destination)
+	assert.Equal(t, "account_id=1", found.Payload)
+	assert.Equal(t, models.OutboxPending, found.Status)
+	assert.Equal(t, 0, found.Attempts)
+	assert.Nil(t, found.DeliveredAt)
+
+	found, err = store.Find(msg.ID + 100)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func testOutboxDelivery(t *testing.T, store data.OutboxStore) {
+	first, err := store.Create(models.OutboxEvent, "", `{"id":"1"}`)
+	require.NoError(t, err)
+	second, err := store.Create(models.OutboxEvent, "", `{"id":"2"}`)
+	require.NoError(t, err)
+	third, err := store.Create(models.OutboxEvent, "", `{"id":"3"}`)
+	require.NoError(t, err)
+
+	due, err := store.FindDue(time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 3)
+	assert.Equal(t, first.ID, due[0].ID)
+
+	due, err = store.FindDue(time.Now(), 1)
+	require.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	err = store.Delivered(first.ID, 204)
+	require.NoError(t, err)
+	retryAt := time.Now().Add(time.Hour)
+	err = store.Failed(second.ID, 503, "Status Code: 503", &retryAt)
+	require.NoError(t, err)
+	err = store.Failed(third.ID, 0, "connection refused", nil)
+	require.NoError(t, err)
+
+	due, err = store.FindDue(time.Now(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+	due, err = store.FindDue(time.Now().Add(2*time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, second.ID, due[0].ID)
+
+	found, err := store.Find(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OutboxDelivered, found.Status)
+	assert.Equal(t, 1, found.Attempts)
+	assert.Equal(t, 204, found.ResponseCode)
+	assert.NotNil(t, found.DeliveredAt)
+
+	found, err = store.Find(second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OutboxPending, found.Status)
+	assert.Equal(t, 1, found.Attempts)
+	assert.Equal(t, 503, found.ResponseCode)
+	assert.Equal(t, "Status Code: 503", found.LastError)
+
+	found, err = store.Find(third.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OutboxFailed, found.Status)
+	assert.Equal(t, "connection refused", found.LastError)
+
+	counts, err := store.CountByStatus()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		models.OutboxDelivered: 1,
+		models.OutboxPending:   1,
+		models.OutboxFailed:    1,
+	}, counts)
+}
+
+func testPruneOutbox(t *testing.T, store data.OutboxStore) {
+	delivered, err := store.Create(models.OutboxEvent, "", `{"id":"1"}`)
+	require.NoError(t, err)
+	err = store.Delivered(delivered.ID, 0)
+	require.NoError(t, err)
+	pending, err := store.Create(models.OutboxEvent, "", `{"id":"2"}`)
+	require.NoError(t, err)
+
+	pruned, err := store.Prune(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, pruned)
+
+	pruned, err = store.Prune(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+
+	found, err := store.Find(delivered.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+	found, err = store.Find(pending.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, found)
+}
+
+func testFindRecentOutboxMessages(t *testing.T, store data.OutboxStore) {
+	event, err := store.Create(models.OutboxEvent, "", `{"id":"1"}`)
+	require.NoError(t, err)
+	first, err := store.Create(models.OutboxWebhook, "password_changed", "account_id=1")
+	require.NoError(t, err)
+	second, err := store.Create(models.OutboxWebhook, "password_changed", "account_id=2")
+	require.NoError(t, err)
+	err = store.Failed(first.ID, 500, "Status Code: 500", nil)
+	require.NoError(t, err)
+
+	msgs, err := store.FindRecent("", "", 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, second.ID, msgs[0].ID)
+	assert.Equal(t, event.ID, msgs[2].ID)
+
+	msgs, err = store.FindRecent(models.OutboxWebhook, "", 1)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, second.ID, msgs[0].ID)
+
+	msgs, err = store.FindRecent(models.OutboxWebhook, models.OutboxFailed, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, first.ID, msgs[0].ID)
+
+	msgs, err = store.FindRecent(models.OutboxEvent, models.OutboxFailed, 10)
+	require.NoError(t, err)
+	assert.Empty(t, msgs)
+}
+
+func testRedeliverOutboxMessage(t *testing.T, store data.OutboxStore) {
+	msg, err := store.Create(models.OutboxWebhook, "password_changed", "account_id=1")
+	require.NoError(t, err)
+	err = store.Failed(msg.ID, 500, "Status Code: 500", nil)
+	require.NoError(t, err)
+
+	ok, err := store.Redeliver(msg.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	found, err := store.Find(msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OutboxPending, found.Status)
+	assert.Equal(t, 0, found.Attempts)
+	assert.Equal(t, 500, found.ResponseCode)
+
+	due, err := store.FindDue(time.Now().Add(time.Second), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+
+	ok, err = store.Redeliver(msg.ID + 100)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,78 @@
+package testers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var QuotasTesters = []func(*testing.T, data.Quotas){
+	testQuotasCount,
+	testQuotasReset,
+	testQuotasSlidingWindow,
+	testQuotasExpiry,
+}
+
+func testQuotasCount(t *testing.T, quotas data.Quotas) {
+	count, err := quotas.Count("ip:127.0.0.1", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	require.NoError(t, quotas.Hit("ip:127.0.0.1", time.Hour))
+	require.NoError(t, quotas.Hit("ip:127.0.0.1", time.Hour))
+	require.NoError(t, quotas.Hit("ip:10.0.0.1", time.Hour))
+
+	count, err = quotas.Count("ip:127.0.0.1", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = quotas.Count("ip:10.0.0.1", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func testQuotasReset(t *testing.T, quotas data.Quotas) {
+	require.NoError(t, quotas.Hit("ip:127.0.0.1", time.Hour))
+	require.NoError(t, quotas.Hit("ip:10.0.0.1", time.Hour))
+	require.NoError(t, quotas.Reset("ip:127.0.0.1", time.Hour))
+
+	count, err := quotas.Count("ip:127.0.0.1", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	count, err = quotas.Count("ip:10.0.0.1", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func testQuotasSlidingWindow(t *testing.T, quotas data.Quotas) {
+	window := 200 * time.Millisecond
+	require.NoError(t, quotas.Hit("ip:127.0.0.1", window))
+	time.Sleep(window / 2)
+	require.NoError(t, quotas.Hit("ip:127.0.0.1", window))
+
+	count, err := quotas.Count("ip:127.0.0.1", window)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	time.Sleep(window/2 + 20*time.Millisecond)
+	count, err = quotas.Count("ip:127.0.0.1", window)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func testQuotasExpiry(t *testing.T, quotas data.Quotas) {
+	expiry, err := quotas.Expiry("ip:127.0.0.1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, expiry.IsZero())
+
+	require.NoError(t, quotas.Hit("ip:127.0.0.1", time.Hour))
+	require.NoError(t, quotas.Hit("ip:127.0.0.1", time.Hour))
+
+	expiry, err = quotas.Expiry("ip:127.0.0.1", time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiry, time.Second)
+}
@@ -2,6 +2,7 @@ package testers
 
 import (
 	"testing"
+	"time"
 
 	"github.com/keratin/authn-server/data"
 	"github.com/keratin/authn-server/models"
@@ -11,9 +12,12 @@ import (
 
 var RefreshTokenStoreTesters = []func(*testing.T, data.RefreshTokenStore){
 	testRefreshTokenFind,
+	testRefreshTokenFindMany,
 	testRefreshTokenTouch,
 	testRefreshTokenFindAll,
+	testRefreshTokenFindAllOrder,
 	testRefreshTokenCreate,
+	testRefreshTokenCreateLimited,
 	testRefreshTokenRevoke,
 }
 
@@ -34,6 +38,33 @@ func testRefreshTokenFind(t *testing.T, store data.RefreshTokenStore) {
 	}
 }
 
+func testRefreshTokenFindMany(t *testing.T, store data.RefreshTokenStore) {
+	ids, err := store.FindMany([]models.RefreshToken{})
+	assert.NoError(t, err)
+	assert.Len(t, ids, 0)
+
+	token, err := store.Create(123)
+	require.NoError(t, err)
+	ids, err = store.FindMany([]models.RefreshToken{models.RefreshToken("a1b2c3"), token, token})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{0, 123, 123}, ids)
+	}
+
+	// more tokens than one batch may hold
+	tokens := []models.RefreshToken{}
+	expected := []int{}
+	for i := 0; i < 150; i++ {
+		token, err := store.Create(1000 + i)
+		require.NoError(t, err)
+		tokens = append(tokens, token)
+		expected = append(expected, 1000+i)
+	}
+	ids, err = store.FindMany(tokens)
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, ids)
+	}
+}
+
 // TODO: find way to test for not touching expired tokens
 func testRefreshTokenTouch(t *testing.T, store data.RefreshTokenStore) {
 	err := store.Touch(models.RefreshToken("a1b2c3"), 123)
@@ -58,6 +89,27 @@ func testRefreshTokenFindAll(t *testing.T, store data.RefreshTokenStore) {
 	assert.Equal(t, []models.RefreshToken{token}, tokens2)
 }
 
+func testRefreshTokenFindAllOrder(t *testing.T, store data.RefreshTokenStore) {
+	id := 123
+
+	first, err := store.Create(id)
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	second, err := store.Create(id)
+	require.NoError(t, err)
+
+	tokens, err := store.FindAll(id)
+	require.NoError(t, err)
+	assert.Equal(t, []models.RefreshToken{first, second}, tokens)
+
+	// least recently used first
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, store.Touch(first, id))
+	tokens, err = store.FindAll(id)
+	require.NoError(t, err)
+	assert.Equal(t, []models.RefreshToken{second, first}, tokens)
+}
+
 func testRefreshTokenCreate(t *testing.T, store data.RefreshTokenStore) {
 	id := 123
 
@@ -70,6 +122,45 @@ func testRefreshTokenCreate(t *testing.T, store data.RefreshTokenStore) {
 	assert.Equal(t, []models.RefreshToken{token}, tokens)
 }
 
+func testRefreshTokenCreateLimited(t *testing.T, store data.RefreshTokenStore) {
+	id := 123
+
+	first, err := store.CreateLimited(id, 2, false)
+	require.NoError(t, err)
+	assert.NotEmpty(t, first)
+	time.Sleep(10 * time.Millisecond)
+	second, err := store.CreateLimited(id, 2, false)
+	require.NoError(t, err)
+	assert.NotEmpty(t, second)
+
+	// rejecting at the limit
+	rejected, err := store.CreateLimited(id, 2, false)
+	require.NoError(t, err)
+	assert.Empty(t, rejected)
+
+	// evicting the least recently used
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, store.Touch(first, id))
+	time.Sleep(10 * time.Millisecond)
+	third, err := store.CreateLimited(id, 2, true)
+	require.NoError(t, err)
+	assert.NotEmpty(t, third)
+
+	tokens, err := store.FindAll(id)
+	require.NoError(t, err)
+	assert.Equal(t, []models.RefreshToken{first, third}, tokens)
+	evicted, err := store.Find(second)
+	require.NoError(t, err)
+	assert.Empty(t, evicted)
+
+	// unlimited
+	_, err = store.CreateLimited(id, 0, false)
+	require.NoError(t, err)
+	tokens, err = store.FindAll(id)
+	require.NoError(t, err)
+	assert.Len(t, tokens, 3)
+}
+
 func testRefreshTokenRevoke(t *testing.T, store data.RefreshTokenStore) {
 	id := 123
 
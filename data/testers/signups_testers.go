@@ -0,0 +1,44 @@
+package testers
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var SignupsTesters = []func(*testing.T, data.Signups){
+	testSignupsByDay,
+	testSignupsByWeek,
+	testSignupsByMonth,
+}
+
+func testSignupsByDay(t *testing.T, signups data.Signups) {
+	signups.Track()
+	signups.Track()
+
+	report, err := signups.SignupsByDay()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{time.Now().In(time.UTC).Format("2006-01-02"): 2}, report)
+}
+
+func testSignupsByWeek(t *testing.T, signups data.Signups) {
+	signups.Track()
+
+	report, err := signups.SignupsByWeek()
+	require.NoError(t, err)
+	y, w := time.Now().In(time.UTC).ISOWeek()
+	label := strconv.Itoa(y) + "-W" + strconv.Itoa(w)
+	assert.Equal(t, map[string]int{label: 1}, report)
+}
+
+func testSignupsByMonth(t *testing.T, signups data.Signups) {
+	signups.Track()
+
+	report, err := signups.SignupsByMonth()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{time.Now().In(time.UTC).Format("2006-01"): 1}, report)
+}
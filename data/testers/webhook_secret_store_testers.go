@@ -0,0 +1,59 @@
+package testers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var WebhookSecretStoreTesters = []func(*testing.T, data.WebhookSecretStore){
+	testRotateWebhookSecrets,
+}
+
+func testRotateWebhookSecrets(t *testing.T, store data.WebhookSecretStore) {
+	now := time.Now()
+
+	secrets, err := store.FindActive("password_changed", now)
+	require.NoError(t, err)
+	assert.Empty(t, secrets)
+
+	first, err := store.Rotate("password_changed", "first", now)
+	require.NoError(t, err)
+	assert.NotEqual(t, 0, first.ID)
+	_, err = store.Rotate("other", "other", now)
+	require.NoError(t, err)
+
+	secrets, err = store.FindActive("password_changed", now)
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "first", secrets[0].Secret)
+	assert.Nil(t, secrets[0].ExpiresAt)
+
+	second, err := store.Rotate("password_changed", "second", now.Add(time.Hour))
+	require.NoError(t, err)
+
+	secrets, err = store.FindActive("password_changed", now)
+	require.NoError(t, err)
+	require.Len(t, secrets, 2)
+	assert.Equal(t, second.ID, secrets[0].ID)
+	assert.Equal(t, "second", secrets[0].Secret)
+	assert.Equal(t, "first", secrets[1].Secret)
+	assert.NotNil(t, secrets[1].ExpiresAt)
+
+	secrets, err = store.FindActive("password_changed", now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "second", secrets[0].Secret)
+
+	// a later rotation does not extend an earlier expiry
+	_, err = store.Rotate("password_changed", "third", now.Add(2*time.Hour))
+	require.NoError(t, err)
+	secrets, err = store.FindActive("password_changed", now.Add(90*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, secrets, 2)
+	assert.Equal(t, "third", secrets[0].Secret)
+	assert.Equal(t, "second", secrets[1].Secret)
+}
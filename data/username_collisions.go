@@ -0,0 +1,43 @@
+package data
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// UsernameCollision is a set of accounts with usernames that differ only by case. Usernames are
+// unique regardless of case once every collision has been resolved.
+type UsernameCollision struct {
+	Username string
+	Accounts []models.Account
+}
+
+// FindUsernameCollisions returns the accounts with usernames that differ only by case, grouped by
+// their lowercase username.
+func FindUsernameCollisions(db *sqlx.DB) ([]UsernameCollision, error) {
+	rows := []struct {
+		models.Account
+		Folded string `db:"folded"`
+	}{}
+	err := db.Select(&rows, `
+        SELECT a.*, LOWER(a.username) AS folded FROM accounts a
+        WHERE LOWER(a.username) IN (
+            SELECT LOWER(username) FROM accounts GROUP BY LOWER(username) HAVING COUNT(*) > 1
+        )
+        ORDER BY LOWER(a.username), a.id
+    `)
+	if err != nil {
+		return nil, errors.Wrap(err, "Select")
+	}
+
+	collisions := []UsernameCollision{}
+	for _, row := range rows {
+		if len(collisions) == 0 || collisions[len(collisions)-1].Username != row.Folded {
+			collisions = append(collisions, UsernameCollision{Username: row.Folded})
+		}
+		last := &collisions[len(collisions)-1]
+		last.Accounts = append(last.Accounts, row.Account)
+	}
+	return collisions, nil
+}
@@ -0,0 +1,51 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindUsernameCollisions(t *testing.T) {
+	db, err := sqlx.Connect("sqlite3", "file:collisions?mode=memory")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// accounts that collided before usernames were unique regardless of case
+	_, err = db.Exec("CREATE TABLE accounts (id INTEGER PRIMARY KEY, username TEXT NOT NULL UNIQUE, password BLOB NOT NULL DEFAULT '', locked BOOLEAN NOT NULL DEFAULT 0, require_new_password BOOLEAN NOT NULL DEFAULT 0, password_changed_at DATETIME, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)")
+	require.NoError(t, err)
+	for _, username := range []string{"User@example.com", "other@example.com", "user@example.com", "USER@EXAMPLE.COM"} {
+		_, err = db.Exec("INSERT INTO accounts (username, password_changed_at, created_at, updated_at) VALUES (?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)", username)
+		require.NoError(t, err)
+	}
+	err = sqlite3.MigrateDB(db)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "authn username-collisions")
+
+	collisions, err := data.FindUsernameCollisions(db)
+	require.NoError(t, err)
+	require.Len(t, collisions, 1)
+	assert.Equal(t, "user@example.com", collisions[0].Username)
+	require.Len(t, collisions[0].Accounts, 3)
+	assert.Equal(t, "User@example.com", collisions[0].Accounts[0].Username)
+	assert.Equal(t, "user@example.com", collisions[0].Accounts[1].Username)
+	assert.Equal(t, "USER@EXAMPLE.COM", collisions[0].Accounts[2].Username)
+
+	t.Run("after resolving collisions", func(t *testing.T) {
+		_, err = db.Exec("DELETE FROM accounts WHERE id IN (?, ?)", collisions[0].Accounts[1].ID, collisions[0].Accounts[2].ID)
+		require.NoError(t, err)
+		require.NoError(t, sqlite3.MigrateDB(db))
+
+		collisions, err := data.FindUsernameCollisions(db)
+		require.NoError(t, err)
+		assert.Empty(t, collisions)
+
+		_, err = db.Exec("INSERT INTO accounts (username, password_changed_at, created_at, updated_at) VALUES (?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)", "USER@example.com")
+		assert.True(t, data.IsUniquenessError(err))
+	})
+}
@@ -0,0 +1,35 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keratin/authn-server/data/mysql"
+	"github.com/keratin/authn-server/data/postgres"
+	"github.com/keratin/authn-server/data/sqlite3"
+	"github.com/keratin/authn-server/models"
+)
+
+// WebhookSecretStore keeps the secrets that sign webhooks, by endpoint.
+type WebhookSecretStore interface {
+	// Creates a secret for the endpoint, and expires the endpoint's other secrets at the given
+	// time unless they expire sooner. The secret should already be encrypted.
+	Rotate(endpoint string, secret string, expireOthersAt time.Time) (*models.WebhookSecret, error)
+
+	// Returns the endpoint's secrets that have not expired by the time, newest first.
+	FindActive(endpoint string, now time.Time) ([]*models.WebhookSecret, error)
+}
+
+func NewWebhookSecretStore(db *sqlx.DB) (WebhookSecretStore, error) {
+	switch db.DriverName() {
+	case "sqlite3":
+		return &sqlite3.WebhookSecretStore{DB: db}, nil
+	case "mysql":
+		return &mysql.WebhookSecretStore{DB: db}, nil
+	case "postgres":
+		return &postgres.WebhookSecretStore{DB: db}, nil
+	default:
+		return nil, fmt.Errorf("unsupported driver: %v", db.DriverName())
+	}
+}
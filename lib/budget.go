@@ -0,0 +1,83 @@
+package lib
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is returned by a Budget once its time has been spent.
+var ErrBudgetExceeded = budgetExceededError{}
+
+type budgetExceededError struct{}
+
+func (e budgetExceededError) Error() string {
+	return "budget exceeded: dependencies are too slow"
+}
+
+// Unavailable signals that the request could not be served because a dependency is slow, not
+// because of a bug.
+func (e budgetExceededError) Unavailable() bool {
+	return true
+}
+
+// Timeout signals that the error is a timeout, as with net.Error.
+func (e budgetExceededError) Timeout() bool {
+	return true
+}
+
+// Budget limits how long one request may spend waiting on its dependencies, across every call that
+// it makes. Each call is given a context that is canceled when the budget runs out, so that a slow
+// query or write is aborted rather than left to finish after its caller has given up. Once the
+// budget is spent, further calls fail without running.
+//
+// A nil *Budget is valid and imposes no limit.
+type Budget struct {
+	mutex     sync.Mutex
+	remaining time.Duration
+}
+
+// NewBudget returns a Budget of the given duration. A duration of zero or less returns nil, which
+// imposes no limit.
+func NewBudget(d time.Duration) *Budget {
+	if d <= 0 {
+		return nil
+	}
+	return &Budget{remaining: d}
+}
+
+// Do runs fn with a context that expires with the budget, and charges its duration to the budget.
+// It returns ErrBudgetExceeded if fn fails after its context expired. A call that ignores its
+// context and succeeds anyway returns its result, but spends what remained of the budget.
+func (b *Budget) Do(fn func(ctx context.Context) error) error {
+	if b == nil {
+		return fn(context.Background())
+	}
+
+	remaining := b.Remaining()
+	if remaining <= 0 {
+		return ErrBudgetExceeded
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithDeadline(context.Background(), start.Add(remaining))
+	defer cancel()
+
+	err := fn(ctx)
+
+	b.mutex.Lock()
+	b.remaining -= time.Since(start)
+	b.mutex.Unlock()
+
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return ErrBudgetExceeded
+	}
+	return err
+}
+
+// Remaining returns how much of the budget is left.
+func (b *Budget) Remaining() time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.remaining
+}
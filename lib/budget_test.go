@@ -0,0 +1,64 @@
+package lib_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudget(t *testing.T) {
+	failure := errors.New("connection refused")
+
+	t.Run("nil budget", func(t *testing.T) {
+		var budget *lib.Budget
+		assert.Equal(t, failure, budget.Do(func(ctx context.Context) error { return failure }))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Nil(t, lib.NewBudget(0))
+	})
+
+	t.Run("charging each call", func(t *testing.T) {
+		budget := lib.NewBudget(time.Second)
+		assert.NoError(t, budget.Do(func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}))
+		assert.Equal(t, failure, budget.Do(func(ctx context.Context) error { return failure }))
+		assert.True(t, budget.Remaining() < time.Second-10*time.Millisecond)
+	})
+
+	t.Run("canceling a slow call", func(t *testing.T) {
+		budget := lib.NewBudget(20 * time.Millisecond)
+		start := time.Now()
+		err := budget.Do(func(ctx context.Context) error {
+			select {
+			case <-time.After(time.Second):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		assert.Equal(t, lib.ErrBudgetExceeded, err)
+		assert.True(t, time.Since(start) < time.Second)
+
+		ran := false
+		err = budget.Do(func(ctx context.Context) error { ran = true; return nil })
+		assert.Equal(t, lib.ErrBudgetExceeded, err)
+		assert.False(t, ran)
+	})
+
+	t.Run("finishing a call that ignores its context", func(t *testing.T) {
+		budget := lib.NewBudget(10 * time.Millisecond)
+		err := budget.Do(func(ctx context.Context) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, budget.Remaining() <= 0)
+	})
+}
@@ -0,0 +1,118 @@
+package lib
+
+import (
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by a CircuitBreaker while a dependency is considered down.
+var ErrCircuitOpen = circuitOpenError{}
+
+type circuitOpenError struct{}
+
+func (e circuitOpenError) Error() string {
+	return "circuit open: dependency unavailable"
+}
+
+// Unavailable signals that the request could not be served because a dependency is down, not
+// because of a bug.
+func (e circuitOpenError) Unavailable() bool {
+	return true
+}
+
+// CircuitBreaker stops calling a dependency after a number of consecutive failures, so that
+// requests fail fast instead of hanging until TCP timeouts. After a cooldown it lets a single call
+// through as a trial. Each failed trial doubles the cooldown, up to a maximum, so that an outage is
+// probed with exponential backoff.
+//
+// A nil *CircuitBreaker is valid and never opens.
+type CircuitBreaker struct {
+	threshold   int
+	minCooldown time.Duration
+	maxCooldown time.Duration
+
+	mutex    sync.Mutex
+	failures int
+	cooldown time.Duration
+	openedAt time.Time
+	trial    bool
+}
+
+// NewCircuitBreaker returns a CircuitBreaker that opens after threshold consecutive failures. A
+// threshold of zero or less returns nil, which never opens.
+func NewCircuitBreaker(threshold int, minCooldown time.Duration, maxCooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &CircuitBreaker{
+		threshold:   threshold,
+		minCooldown: minCooldown,
+		maxCooldown: maxCooldown,
+		cooldown:    minCooldown,
+	}
+}
+
+// Do runs fn unless the circuit is open, in which case it returns ErrCircuitOpen. The isFailure
+// func decides which errors from fn count against the dependency, since some errors (like
+// uniqueness violations) prove that it is healthy.
+func (b *CircuitBreaker) Do(fn func() error, isFailure func(error) bool) error {
+	if b == nil {
+		return fn()
+	}
+
+	if !b.allow() {
+		return ErrCircuitOpen
+	}
+	err := fn()
+	b.record(err != nil && isFailure(err))
+	return err
+}
+
+// Open reports whether the circuit is currently refusing calls.
+func (b *CircuitBreaker) Open() bool {
+	if b == nil {
+		return false
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.failures >= b.threshold
+}
+
+func (b *CircuitBreaker) allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.trial || time.Since(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+func (b *CircuitBreaker) record(failed bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	wasTrial := b.trial
+	b.trial = false
+
+	if !failed {
+		b.failures = 0
+		b.cooldown = b.minCooldown
+		return
+	}
+
+	b.failures++
+	if wasTrial {
+		b.cooldown *= 2
+		if b.cooldown > b.maxCooldown {
+			b.cooldown = b.maxCooldown
+		}
+	}
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
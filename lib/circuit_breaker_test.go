@@ -0,0 +1,70 @@
+package lib_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	failure := errors.New("connection refused")
+	always := func(error) bool { return true }
+	never := func(error) bool { return false }
+
+	t.Run("nil breaker", func(t *testing.T) {
+		var breaker *lib.CircuitBreaker
+		err := breaker.Do(func() error { return failure }, always)
+		assert.Equal(t, failure, err)
+		assert.False(t, breaker.Open())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Nil(t, lib.NewCircuitBreaker(0, time.Second, time.Minute))
+	})
+
+	t.Run("opening after consecutive failures", func(t *testing.T) {
+		breaker := lib.NewCircuitBreaker(2, time.Minute, time.Minute)
+
+		assert.Equal(t, failure, breaker.Do(func() error { return failure }, always))
+		assert.NoError(t, breaker.Do(func() error { return nil }, always))
+		assert.Equal(t, failure, breaker.Do(func() error { return failure }, always))
+		assert.False(t, breaker.Open())
+		assert.Equal(t, failure, breaker.Do(func() error { return failure }, always))
+		assert.True(t, breaker.Open())
+
+		ran := false
+		err := breaker.Do(func() error { ran = true; return nil }, always)
+		assert.Equal(t, lib.ErrCircuitOpen, err)
+		assert.False(t, ran)
+	})
+
+	t.Run("ignoring healthy errors", func(t *testing.T) {
+		breaker := lib.NewCircuitBreaker(1, time.Minute, time.Minute)
+		assert.Equal(t, failure, breaker.Do(func() error { return failure }, never))
+		assert.False(t, breaker.Open())
+	})
+
+	t.Run("closing after a successful trial", func(t *testing.T) {
+		breaker := lib.NewCircuitBreaker(1, 10*time.Millisecond, time.Minute)
+		breaker.Do(func() error { return failure }, always)
+		assert.True(t, breaker.Open())
+
+		time.Sleep(20 * time.Millisecond)
+		assert.NoError(t, breaker.Do(func() error { return nil }, always))
+		assert.False(t, breaker.Open())
+	})
+
+	t.Run("backing off after a failed trial", func(t *testing.T) {
+		breaker := lib.NewCircuitBreaker(1, 10*time.Millisecond, time.Minute)
+		breaker.Do(func() error { return failure }, always)
+
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, failure, breaker.Do(func() error { return failure }, always))
+
+		time.Sleep(15 * time.Millisecond)
+		assert.Equal(t, lib.ErrCircuitOpen, breaker.Do(func() error { return nil }, always))
+	})
+}
@@ -57,6 +57,9 @@ func EncryptWithNonce(value []byte, secret []byte, nonce []byte) ([]byte, error)
 // See: ActiveSupport::MessageEncryptor with GCM changes
 func Decrypt(message []byte, key []byte) (string, error) {
 	slices := strings.Split(string(message), "--")
+	if len(slices) != 3 {
+		return "", fmt.Errorf("unexpected encrypted message format")
+	}
 	encryptedData, _ := base64.StdEncoding.DecodeString(slices[0])
 	nonce, _ := base64.StdEncoding.DecodeString(slices[1])
 	authTag, _ := base64.StdEncoding.DecodeString(slices[2])
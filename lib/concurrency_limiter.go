@@ -0,0 +1,63 @@
+package lib
+
+import (
+	"time"
+)
+
+// ErrQueueTimeout is returned by a ConcurrencyLimiter when a caller waited for a slot longer than
+// the configured timeout.
+var ErrQueueTimeout = queueTimeoutError{}
+
+type queueTimeoutError struct{}
+
+func (e queueTimeoutError) Error() string {
+	return "timed out waiting for a free slot"
+}
+
+// Unavailable signals that the request could not be served because of load, not because of a bug.
+func (e queueTimeoutError) Unavailable() bool {
+	return true
+}
+
+// ConcurrencyLimiter is a semaphore that bounds how many callers may run expensive work (like
+// bcrypt) at the same time. Callers that cannot acquire a slot before the timeout will give up
+// with ErrQueueTimeout rather than piling up and starving cheaper work of CPU.
+//
+// A nil *ConcurrencyLimiter is valid and imposes no limit.
+type ConcurrencyLimiter struct {
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+// NewConcurrencyLimiter returns a ConcurrencyLimiter with max slots. A max of zero or less returns
+// nil, which imposes no limit.
+func NewConcurrencyLimiter(max int, timeout time.Duration) *ConcurrencyLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &ConcurrencyLimiter{
+		slots:   make(chan struct{}, max),
+		timeout: timeout,
+	}
+}
+
+// Do runs fn once a slot is available, or returns ErrQueueTimeout.
+func (l *ConcurrencyLimiter) Do(fn func()) error {
+	if l == nil {
+		fn()
+		return nil
+	}
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+	case <-timer.C:
+		return ErrQueueTimeout
+	}
+	defer func() { <-l.slots }()
+
+	fn()
+	return nil
+}
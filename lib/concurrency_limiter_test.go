@@ -0,0 +1,42 @@
+package lib_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	t.Run("nil limiter", func(t *testing.T) {
+		var limiter *lib.ConcurrencyLimiter
+		ran := false
+		err := limiter.Do(func() { ran = true })
+		assert.NoError(t, err)
+		assert.True(t, ran)
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		assert.Nil(t, lib.NewConcurrencyLimiter(0, time.Second))
+	})
+
+	t.Run("queue timeout", func(t *testing.T) {
+		limiter := lib.NewConcurrencyLimiter(1, 10*time.Millisecond)
+
+		started := make(chan bool)
+		release := make(chan bool)
+		go limiter.Do(func() {
+			started <- true
+			<-release
+		})
+		<-started
+
+		err := limiter.Do(func() {})
+		assert.Equal(t, lib.ErrQueueTimeout, err)
+
+		release <- true
+		err = limiter.Do(func() {})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/keratin/authn-server/ops"
+)
+
+// AlertPublisher is a Publisher that raises alerts for events that need someone's attention:
+// accounts locked by an administrator, and spikes in failed logins. Failed logins are counted in
+// memory, so with several servers each counts only the events that it publishes.
+type AlertPublisher struct {
+	alerts *ops.Alerts
+	// failedLogins is how many failed logins in a minute raise an alert. Zero never does.
+	failedLogins int
+
+	mutex  sync.Mutex
+	minute time.Time
+	failed int
+}
+
+// NewAlertPublisher returns an AlertPublisher that raises alerts through alerts.
+func NewAlertPublisher(alerts *ops.Alerts, failedLogins int) *AlertPublisher {
+	return &AlertPublisher{alerts: alerts, failedLogins: failedLogins}
+}
+
+// Publish raises an alert when the event calls for one. It never fails.
+func (p *AlertPublisher) Publish(e Event) error {
+	switch e.Type {
+	case AccountLocked:
+		p.alerts.Alert(ops.Alert{
+			Key:     AccountLocked + ":" + strconv.Itoa(e.AccountID),
+			Summary: "An administrator locked account " + strconv.Itoa(e.AccountID),
+			Details: map[string]string{"account_id": strconv.Itoa(e.AccountID), "time": e.Time.Format(time.RFC3339)},
+		})
+	case SessionFailed:
+		if p.failedLogins <= 0 {
+			return nil
+		}
+		minute := e.Time.Truncate(time.Minute)
+
+		p.mutex.Lock()
+		if !minute.Equal(p.minute) {
+			p.minute = minute
+			p.failed = 0
+		}
+		p.failed++
+		spiked := p.failed == p.failedLogins
+		p.mutex.Unlock()
+
+		if spiked {
+			p.alerts.Alert(ops.Alert{
+				Key:     SessionFailed,
+				Summary: strconv.Itoa(p.failedLogins) + " failed logins within a minute",
+				Details: map[string]string{"minute": minute.Format(time.RFC3339)},
+			})
+		}
+	}
+	return nil
+}
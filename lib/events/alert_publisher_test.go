@@ -0,0 +1,65 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/ops"
+	"github.com/stretchr/testify/assert"
+)
+
+type channelAlerter chan ops.Alert
+
+func (a channelAlerter) Alert(alert ops.Alert) error {
+	a <- alert
+	return nil
+}
+
+func TestAlertPublisher(t *testing.T) {
+	receive := func(t *testing.T, alerter channelAlerter) *ops.Alert {
+		select {
+		case a := <-alerter:
+			return &a
+		case <-time.After(100 * time.Millisecond):
+			return nil
+		}
+	}
+
+	t.Run("locked accounts", func(t *testing.T) {
+		alerter := make(channelAlerter, 10)
+		publisher := events.NewAlertPublisher(ops.NewAlerts(&ops.LogReporter{}, time.Minute, alerter), 0)
+
+		assert.NoError(t, publisher.Publish(events.Event{Type: events.AccountLocked, AccountID: 42, Time: time.Now()}))
+		a := receive(t, alerter)
+		if assert.NotNil(t, a) {
+			assert.Equal(t, "account.locked:42", a.Key)
+			assert.Equal(t, "42", a.Details["account_id"])
+		}
+
+		assert.NoError(t, publisher.Publish(events.Event{Type: events.AccountUnlocked, AccountID: 42, Time: time.Now()}))
+		assert.Nil(t, receive(t, alerter))
+	})
+
+	t.Run("failed logins", func(t *testing.T) {
+		alerter := make(channelAlerter, 10)
+		publisher := events.NewAlertPublisher(ops.NewAlerts(&ops.LogReporter{}, 0, alerter), 3)
+		minute := time.Now().Truncate(time.Minute)
+
+		for i := 0; i < 2; i++ {
+			assert.NoError(t, publisher.Publish(events.Event{Type: events.SessionFailed, Time: minute}))
+		}
+		assert.NoError(t, publisher.Publish(events.Event{Type: events.SessionFailed, Time: minute.Add(time.Minute)}))
+		assert.Nil(t, receive(t, alerter))
+
+		for i := 0; i < 5; i++ {
+			assert.NoError(t, publisher.Publish(events.Event{Type: events.SessionFailed, Time: minute.Add(time.Minute)}))
+		}
+		a := receive(t, alerter)
+		if assert.NotNil(t, a) {
+			assert.Equal(t, events.SessionFailed, a.Key)
+			assert.Equal(t, "3 failed logins within a minute", a.Summary)
+		}
+		assert.Nil(t, receive(t, alerter))
+	})
+}
@@ -0,0 +1,112 @@
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Sender delivers a keyed message. KafkaPublisher and NATSPublisher are Senders.
+type Sender interface {
+	Send(key string, payload []byte) error
+}
+
+// AnalyticsEvent is an Event without personal details, for product analytics.
+type AnalyticsEvent struct {
+	Type    string    `json:"type"`
+	Subject string    `json:"subject,omitempty"`
+	Time    time.Time `json:"time"`
+	Country string    `json:"country,omitempty"`
+}
+
+// AnalyticsPublisher is a Publisher that exports anonymized events, so that funnels such as
+// signup.started → account.created or session.failed → session.created may be counted without
+// exporting personal data.
+//
+// Account IDs are replaced by an HMAC subject with a salt that rotates every period, so that one
+// account's events may be linked within a period but not across periods. The event ID, IP address,
+// user agent, and city are dropped, and times are truncated to the hour. A country is only exported
+// once at least minGroupSize events from that country have been seen in the period, so that rare
+// countries do not single anyone out.
+type AnalyticsPublisher struct {
+	sender       Sender
+	secret       []byte
+	period       time.Duration
+	minGroupSize int
+
+	mutex     sync.Mutex
+	current   int64
+	countries map[string]int
+}
+
+// NewAnalyticsPublisher returns an AnalyticsPublisher that derives salts from secret.
+func NewAnalyticsPublisher(sender Sender, secret []byte, period time.Duration, minGroupSize int) *AnalyticsPublisher {
+	return &AnalyticsPublisher{
+		sender:       sender,
+		secret:       secret,
+		period:       period,
+		minGroupSize: minGroupSize,
+		current:      -1,
+		countries:    map[string]int{},
+	}
+}
+
+// Publish sends the anonymized event as JSON, keyed by subject.
+func (p *AnalyticsPublisher) Publish(e Event) error {
+	a := p.Anonymize(e)
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return p.sender.Send(a.Subject, payload)
+}
+
+// Anonymize converts an Event into an AnalyticsEvent.
+func (p *AnalyticsPublisher) Anonymize(e Event) AnalyticsEvent {
+	a := AnalyticsEvent{
+		Type: e.Type,
+		Time: e.Time.UTC().Truncate(time.Hour),
+	}
+	if e.AccountID != 0 {
+		a.Subject = p.subject(e.Time, e.AccountID)
+	}
+	if e.Location != nil && e.Location.Country != "" {
+		p.mutex.Lock()
+		p.rotate(p.epoch(e.Time))
+		p.countries[e.Location.Country]++
+		if p.countries[e.Location.Country] >= p.minGroupSize {
+			a.Country = e.Location.Country
+		}
+		p.mutex.Unlock()
+	}
+	return a
+}
+
+func (p *AnalyticsPublisher) subject(t time.Time, accountID int) string {
+	mac := hmac.New(sha256.New, p.salt(p.epoch(t)))
+	mac.Write([]byte(strconv.Itoa(accountID)))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+func (p *AnalyticsPublisher) epoch(t time.Time) int64 {
+	return t.UnixNano() / int64(p.period)
+}
+
+// salt is derived from the secret, so that every AuthN process agrees on it without coordination.
+func (p *AnalyticsPublisher) salt(epoch int64) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(strconv.FormatInt(epoch, 10)))
+	return mac.Sum(nil)
+}
+
+// rotate forgets country counts from earlier periods.
+func (p *AnalyticsPublisher) rotate(epoch int64) {
+	if epoch > p.current {
+		p.current = epoch
+		p.countries = map[string]int{}
+	}
+}
@@ -0,0 +1,84 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sentMessage struct {
+	key     string
+	payload []byte
+}
+
+type sliceSender []sentMessage
+
+func (s *sliceSender) Send(key string, payload []byte) error {
+	*s = append(*s, sentMessage{key, payload})
+	return nil
+}
+
+func TestAnalyticsPublisher(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 34, 56, 0, time.UTC)
+	publisher := events.NewAnalyticsPublisher(&sliceSender{}, []byte("secret"), 24*time.Hour, 2)
+
+	t.Run("stripping personal details", func(t *testing.T) {
+		sender := &sliceSender{}
+		p := events.NewAnalyticsPublisher(sender, []byte("secret"), 24*time.Hour, 2)
+		err := p.Publish(events.Event{
+			ID:        "abc",
+			Type:      events.SessionCreated,
+			AccountID: 42,
+			Time:      now,
+			Location:  &geoip.Location{Country: "NZ", City: "Wellington"},
+			IP:        "127.0.0.1",
+			UserAgent: "Mozilla/5.0",
+		})
+		require.NoError(t, err)
+		require.Len(t, *sender, 1)
+
+		var exported map[string]interface{}
+		require.NoError(t, json.Unmarshal((*sender)[0].payload, &exported))
+		assert.Equal(t, events.SessionCreated, exported["type"])
+		assert.Equal(t, "2020-01-01T12:00:00Z", exported["time"])
+		assert.NotEqual(t, "42", exported["subject"])
+		assert.Equal(t, exported["subject"], (*sender)[0].key)
+		assert.NotContains(t, string((*sender)[0].payload), "127.0.0.1")
+		assert.NotContains(t, string((*sender)[0].payload), "Mozilla")
+		assert.NotContains(t, string((*sender)[0].payload), "Wellington")
+		assert.NotContains(t, string((*sender)[0].payload), "abc")
+	})
+
+	t.Run("rotating subjects", func(t *testing.T) {
+		first := publisher.Anonymize(events.Event{Type: events.SessionCreated, AccountID: 42, Time: now})
+		second := publisher.Anonymize(events.Event{Type: events.SessionCreated, AccountID: 42, Time: now.Add(time.Hour)})
+		other := publisher.Anonymize(events.Event{Type: events.SessionCreated, AccountID: 43, Time: now})
+		later := publisher.Anonymize(events.Event{Type: events.SessionCreated, AccountID: 42, Time: now.Add(24 * time.Hour)})
+
+		assert.Equal(t, first.Subject, second.Subject)
+		assert.NotEqual(t, first.Subject, other.Subject)
+		assert.NotEqual(t, first.Subject, later.Subject)
+	})
+
+	t.Run("anonymous events", func(t *testing.T) {
+		a := publisher.Anonymize(events.Event{Type: events.SessionFailed, Time: now})
+		assert.Equal(t, "", a.Subject)
+	})
+
+	t.Run("suppressing rare countries", func(t *testing.T) {
+		p := events.NewAnalyticsPublisher(&sliceSender{}, []byte("secret"), 24*time.Hour, 2)
+		location := &geoip.Location{Country: "NZ"}
+
+		a := p.Anonymize(events.Event{Type: events.SignupStarted, Time: now, Location: location})
+		assert.Equal(t, "", a.Country)
+		a = p.Anonymize(events.Event{Type: events.SignupStarted, Time: now, Location: location})
+		assert.Equal(t, "NZ", a.Country)
+		a = p.Anonymize(events.Event{Type: events.SignupStarted, Time: now.Add(24 * time.Hour), Location: location})
+		assert.Equal(t, "", a.Country)
+	})
+}
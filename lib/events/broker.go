@@ -0,0 +1,64 @@
+package events
+
+import "sync"
+
+// subscriptionSize bounds how many events may wait for a slow subscriber before new events are
+// dropped. Publishing must never block an emitter.
+const subscriptionSize = 16
+
+// Broker hands events to subscribers in this process as they are emitted, by account. It does not
+// persist events or share them with other processes.
+//
+// A nil *Broker is valid and discards every event.
+type Broker struct {
+	mutex       sync.Mutex
+	subscribers map[int]map[chan Event]struct{}
+}
+
+// NewBroker returns a Broker with no subscribers.
+func NewBroker() *Broker {
+	return &Broker{subscribers: map[int]map[chan Event]struct{}{}}
+}
+
+// Subscribe returns a channel of events for the account, and a function that unsubscribes and
+// closes the channel. Events are dropped while the channel is full.
+func (b *Broker) Subscribe(accountID int) (<-chan Event, func()) {
+	ch := make(chan Event, subscriptionSize)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.subscribers[accountID] == nil {
+		b.subscribers[accountID] = map[chan Event]struct{}{}
+	}
+	b.subscribers[accountID][ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+			delete(b.subscribers[accountID], ch)
+			if len(b.subscribers[accountID]) == 0 {
+				delete(b.subscribers, accountID)
+			}
+			close(ch)
+		})
+	}
+}
+
+// Publish hands the event to the account's subscribers without waiting for them. It never fails.
+func (b *Broker) Publish(event Event) error {
+	if b == nil || event.AccountID == 0 {
+		return nil
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for ch := range b.subscribers[event.AccountID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker(t *testing.T) {
+	t.Run("by account", func(t *testing.T) {
+		broker := events.NewBroker()
+		first, unsubscribeFirst := broker.Subscribe(1)
+		defer unsubscribeFirst()
+		second, unsubscribeSecond := broker.Subscribe(2)
+		defer unsubscribeSecond()
+
+		assert.NoError(t, broker.Publish(events.Event{Type: events.PasswordChanged, AccountID: 1}))
+
+		require.Len(t, first, 1)
+		assert.Equal(t, events.PasswordChanged, (<-first).Type)
+		assert.Len(t, second, 0)
+	})
+
+	t.Run("unsubscribing", func(t *testing.T) {
+		broker := events.NewBroker()
+		ch, unsubscribe := broker.Subscribe(1)
+		unsubscribe()
+		unsubscribe()
+
+		assert.NoError(t, broker.Publish(events.Event{Type: events.PasswordChanged, AccountID: 1}))
+		_, open := <-ch
+		assert.False(t, open)
+	})
+
+	t.Run("slow subscribers", func(t *testing.T) {
+		broker := events.NewBroker()
+		ch, unsubscribe := broker.Subscribe(1)
+		defer unsubscribe()
+
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, broker.Publish(events.Event{Type: events.AccountUpdated, AccountID: 1}))
+		}
+		assert.Equal(t, cap(ch), len(ch))
+	})
+
+	t.Run("nil broker", func(t *testing.T) {
+		var broker *events.Broker
+		assert.NoError(t, broker.Publish(events.Event{Type: events.AccountUpdated, AccountID: 1}))
+	})
+}
@@ -0,0 +1,177 @@
+package events
+
+import (
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/keratin/authn-server/lib"
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/keratin/authn-server/ops"
+	"github.com/pkg/errors"
+)
+
+// Event types emitted by AuthN.
+const (
+	AccountCreated           = "account.created"
+	AccountImported          = "account.imported"
+	AccountUpdated           = "account.updated"
+	AccountLocked            = "account.locked"
+	AccountUnlocked          = "account.unlocked"
+	AccountArchived          = "account.archived"
+	AccountRestored          = "account.restored"
+	AccountDeletionRequested = "account.deletion_requested"
+	AccountDeletionCanceled  = "account.deletion_canceled"
+	AccountExported          = "account.exported"
+	AccountHeld              = "account.held"
+	AccountReleased          = "account.released"
+	PasswordExpired          = "password.expired"
+	PasswordChanged          = "password.changed"
+	PasswordResetRequested   = "password.reset_requested"
+	SessionCreated           = "session.created"
+	SessionChallenged        = "session.challenged"
+	SessionRevoked           = "session.revoked"
+	SessionUnbound           = "session.unbound"
+	SessionSteppedUp         = "session.stepped_up"
+
+	// Anonymous events, with no account ID. They describe attempts, so that funnels may be counted.
+	SignupStarted = "signup.started"
+	SessionFailed = "session.failed"
+)
+
+// Event describes something that happened to an account.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	AccountID int             `json:"account_id"`
+	Time      time.Time       `json:"time"`
+	Location  *geoip.Location `json:"location,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	UserAgent string          `json:"user_agent,omitempty"`
+}
+
+// Publisher delivers events to an external system.
+type Publisher interface {
+	Publish(Event) error
+}
+
+// Outbox persists events until they are published, so that they survive a restart once written.
+// It is responsible for publishing them.
+type Outbox interface {
+	Enqueue(Event) error
+}
+
+// queueSize bounds how many events may wait for delivery before new events are dropped. Emitting
+// must never block a request.
+const queueSize = 1000
+
+// Emitter queues events and delivers them to every Publisher in a background routine.
+//
+// A nil *Emitter is valid and discards every event.
+type Emitter struct {
+	publishers []Publisher
+	reporter   ops.ErrorReporter
+	queue      chan Event
+	outbox     Outbox
+	notify     Publisher
+}
+
+// NewEmitter starts an Emitter for the given publishers. With no publishers it returns nil.
+func NewEmitter(reporter ops.ErrorReporter, publishers ...Publisher) *Emitter {
+	if len(publishers) == 0 {
+		return nil
+	}
+	e := &Emitter{
+		publishers: publishers,
+		reporter:   reporter,
+		queue:      make(chan Event, queueSize),
+	}
+	go e.deliver()
+	return e
+}
+
+// NewOutboxEmitter returns an Emitter that persists events in an Outbox rather than queueing them
+// in memory. Emitting waits for the outbox, so that an event is never lost once it is written.
+func NewOutboxEmitter(reporter ops.ErrorReporter, outbox Outbox) *Emitter {
+	return &Emitter{
+		reporter: reporter,
+		outbox:   outbox,
+	}
+}
+
+// Notify returns an Emitter that also publishes each event as soon as it is emitted, before any
+// outbox or queue, so that it may notify a Broker. A nil Emitter returns one that only notifies.
+func (e *Emitter) Notify(reporter ops.ErrorReporter, notify Publisher) *Emitter {
+	if e == nil {
+		e = &Emitter{reporter: reporter}
+	}
+	e.notify = notify
+	return e
+}
+
+// Emit queues an event for delivery.
+func (e *Emitter) Emit(eventType string, accountID int) {
+	e.emit(Event{Type: eventType, AccountID: accountID})
+}
+
+// EmitRequest queues an event for delivery with details of the request that caused it: the
+// location (if known), IP address, and user agent.
+func (e *Emitter) EmitRequest(eventType string, accountID int, location *geoip.Location, r *http.Request) {
+	event := Event{
+		Type:      eventType,
+		AccountID: accountID,
+		Location:  location,
+		UserAgent: r.UserAgent(),
+	}
+	if ip := geoip.ParseRemoteAddr(r.RemoteAddr); ip != nil {
+		event.IP = ip.String()
+	}
+	e.emit(event)
+}
+
+func (e *Emitter) emit(event Event) {
+	if e == nil {
+		return
+	}
+
+	id, err := lib.GenerateToken()
+	if err != nil {
+		e.reporter.ReportError(errors.Wrap(err, "GenerateToken"))
+		return
+	}
+	event.ID = hex.EncodeToString(id)
+	event.Time = time.Now().UTC()
+	if e.notify != nil {
+		if err = e.notify.Publish(event); err != nil {
+			e.reporter.ReportError(errors.Wrapf(err, "Notify %s for account %d", event.Type, event.AccountID))
+		}
+	}
+
+	if e.outbox != nil {
+		err = e.outbox.Enqueue(event)
+		if err != nil {
+			e.reporter.ReportError(errors.Wrapf(err, "Enqueue %s for account %d", event.Type, event.AccountID))
+		}
+		return
+	}
+	if e.queue == nil {
+		return
+	}
+
+	select {
+	case e.queue <- event:
+	default:
+		e.reporter.ReportError(errors.Errorf("event queue full: dropped %s for account %d", event.Type, event.AccountID))
+	}
+}
+
+func (e *Emitter) deliver() {
+	for event := range e.queue {
+		for _, p := range e.publishers {
+			err := p.Publish(event)
+			if err != nil {
+				e.reporter.ReportError(errors.Wrapf(err, "Publish %s", event.Type))
+			}
+		}
+	}
+}
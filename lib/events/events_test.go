@@ -0,0 +1,111 @@
+package events_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/keratin/authn-server/ops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sliceOutbox []events.Event
+
+func (o *sliceOutbox) Enqueue(e events.Event) error {
+	*o = append(*o, e)
+	return nil
+}
+
+type channelPublisher chan events.Event
+
+func (p channelPublisher) Publish(e events.Event) error {
+	p <- e
+	return nil
+}
+
+func TestEmitter(t *testing.T) {
+	t.Run("without publishers", func(t *testing.T) {
+		emitter := events.NewEmitter(&ops.LogReporter{})
+		assert.Nil(t, emitter)
+		emitter.Emit(events.AccountCreated, 1)
+	})
+
+	t.Run("publishing", func(t *testing.T) {
+		first := make(channelPublisher, 1)
+		second := make(channelPublisher, 1)
+		emitter := events.NewEmitter(&ops.LogReporter{}, first, second)
+		emitter.Emit(events.AccountLocked, 42)
+
+		for _, p := range []channelPublisher{first, second} {
+			select {
+			case e := <-p:
+				assert.Equal(t, events.AccountLocked, e.Type)
+				assert.Equal(t, 42, e.AccountID)
+				assert.NotEmpty(t, e.ID)
+				assert.WithinDuration(t, time.Now(), e.Time, time.Second)
+			case <-time.After(time.Second):
+				t.Error("event was not published")
+			}
+		}
+	})
+
+	t.Run("publishing with a request", func(t *testing.T) {
+		p := make(channelPublisher, 1)
+		emitter := events.NewEmitter(&ops.LogReporter{}, p)
+		r := httptest.NewRequest("POST", "/session", nil)
+		r.RemoteAddr = "203.0.113.1:1234"
+		r.Header.Set("User-Agent", "Mozilla/5.0")
+		emitter.EmitRequest(events.SessionCreated, 42, &geoip.Location{Country: "NZ", City: "Auckland"}, r)
+
+		select {
+		case e := <-p:
+			assert.Equal(t, events.SessionCreated, e.Type)
+			assert.Equal(t, &geoip.Location{Country: "NZ", City: "Auckland"}, e.Location)
+			assert.Equal(t, "203.0.113.1", e.IP)
+			assert.Equal(t, "Mozilla/5.0", e.UserAgent)
+		case <-time.After(time.Second):
+			t.Error("event was not published")
+		}
+	})
+	t.Run("with an outbox", func(t *testing.T) {
+		outbox := &sliceOutbox{}
+		emitter := events.NewOutboxEmitter(&ops.LogReporter{}, outbox)
+		emitter.Emit(events.AccountLocked, 42)
+
+		require.Len(t, *outbox, 1)
+		e := (*outbox)[0]
+		assert.Equal(t, events.AccountLocked, e.Type)
+		assert.Equal(t, 42, e.AccountID)
+		assert.NotEmpty(t, e.ID)
+		assert.WithinDuration(t, time.Now(), e.Time, time.Second)
+	})
+
+	t.Run("notifying a broker", func(t *testing.T) {
+		broker := events.NewBroker()
+		ch, unsubscribe := broker.Subscribe(42)
+		defer unsubscribe()
+
+		outbox := &sliceOutbox{}
+		emitter := events.NewOutboxEmitter(&ops.LogReporter{}, outbox).Notify(&ops.LogReporter{}, broker)
+		emitter.Emit(events.PasswordChanged, 42)
+
+		require.Len(t, *outbox, 1)
+		require.Len(t, ch, 1)
+		assert.Equal(t, (*outbox)[0], <-ch)
+	})
+
+	t.Run("notifying a broker without publishers", func(t *testing.T) {
+		broker := events.NewBroker()
+		ch, unsubscribe := broker.Subscribe(42)
+		defer unsubscribe()
+
+		emitter := events.NewEmitter(&ops.LogReporter{}).Notify(&ops.LogReporter{}, broker)
+		emitter.Emit(events.AccountUpdated, 42)
+
+		require.Len(t, ch, 1)
+		assert.Equal(t, events.AccountUpdated, (<-ch).Type)
+	})
+}
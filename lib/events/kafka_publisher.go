@@ -0,0 +1,46 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher is a Publisher for Kafka. Messages are keyed by account ID, so that events for
+// one account stay in order on a single partition.
+type KafkaPublisher struct {
+	*kafka.Writer
+}
+
+// NewKafkaPublisher returns a KafkaPublisher that writes to topic on the given brokers.
+func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+	return &KafkaPublisher{
+		Writer: &kafka.Writer{
+			Addr:     kafka.TCP(brokers...),
+			Topic:    topic,
+			Balancer: &kafka.Hash{},
+			// Send waits for each message, so a batch would only ever hold one. Without this the
+			// writer waits up to a second for more messages before writing it.
+			BatchSize: 1,
+		},
+	}
+}
+
+// Publish writes the event as JSON.
+func (p *KafkaPublisher) Publish(e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return p.Send(strconv.Itoa(e.AccountID), payload)
+}
+
+// Send writes a message with the given key.
+func (p *KafkaPublisher) Send(key string, payload []byte) error {
+	return p.WriteMessages(context.Background(), kafka.Message{
+		Key:   []byte(key),
+		Value: payload,
+	})
+}
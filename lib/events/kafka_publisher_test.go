@@ -0,0 +1,58 @@
+package events_test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/lib/events"
+	kafka "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	metadataAPI "github.com/segmentio/kafka-go/protocol/metadata"
+	produceAPI "github.com/segmentio/kafka-go/protocol/produce"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBroker answers the writer as a broker with one partition of the topic would.
+type fakeBroker struct {
+	produced int32
+}
+
+func (b *fakeBroker) RoundTrip(ctx context.Context, addr net.Addr, req kafka.Request) (protocol.Message, error) {
+	switch r := req.(type) {
+	case *metadataAPI.Request:
+		return &metadataAPI.Response{
+			Brokers: []metadataAPI.ResponseBroker{{NodeID: 1, Host: "localhost", Port: 9092}},
+			Topics: []metadataAPI.ResponseTopic{{
+				Name:       r.TopicNames[0],
+				Partitions: []metadataAPI.ResponsePartition{{PartitionIndex: 0, LeaderID: 1}},
+			}},
+		}, nil
+	case *produceAPI.Request:
+		atomic.AddInt32(&b.produced, 1)
+		return &produceAPI.Response{
+			Topics: []produceAPI.ResponseTopic{{
+				Topic:      r.Topics[0].Topic,
+				Partitions: []produceAPI.ResponsePartition{{Partition: r.Topics[0].Partitions[0].Partition}},
+			}},
+		}, nil
+	}
+	return nil, kafka.UnsupportedVersion
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	broker := &fakeBroker{}
+	publisher := events.NewKafkaPublisher([]string{"localhost:9092"}, "authn")
+	publisher.Transport = broker
+
+	start := time.Now()
+	for i := 1; i <= 20; i++ {
+		require.NoError(t, publisher.Publish(events.Event{Type: events.AccountLocked, AccountID: i, Time: time.Now()}))
+	}
+
+	assert.Equal(t, int32(20), atomic.LoadInt32(&broker.produced))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"encoding/json"
+
+	nats "github.com/nats-io/nats.go"
+)
+
+// NATSPublisher is a Publisher for NATS.
+type NATSPublisher struct {
+	*nats.Conn
+	Subject string
+}
+
+// NewNATSPublisher connects to the NATS server at url. The connection will reconnect on its own
+// after network failures.
+func NewNATSPublisher(url string, subject string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url, nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	return &NATSPublisher{Conn: conn, Subject: subject}, nil
+}
+
+// Publish sends the event as JSON.
+func (p *NATSPublisher) Publish(e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return p.Conn.Publish(p.Subject, payload)
+}
+
+// Send publishes a message to the subject. NATS messages have no key.
+func (p *NATSPublisher) Send(key string, payload []byte) error {
+	return p.Conn.Publish(p.Subject, payload)
+}
@@ -0,0 +1,74 @@
+package events_test
+
+import (
+	"bufio"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatCEF(t *testing.T) {
+	e := events.Event{
+		ID:        "abc",
+		Type:      events.AccountLocked,
+		AccountID: 42,
+		Time:      time.Unix(1500000000, 0),
+	}
+	assert.Equal(t,
+		"CEF:0|Keratin|AuthN|1.0|account.locked|account.locked|7|rt=1500000000000 suid=42 externalId=abc",
+		events.FormatCEF(e),
+	)
+
+	e.IP = "203.0.113.7"
+	e.UserAgent = "Mozilla/5.0 (X11; Linux) a=b"
+	e.Location = &geoip.Location{Country: "DE", City: "Berlin"}
+	assert.Equal(t,
+		"CEF:0|Keratin|AuthN|1.0|account.locked|account.locked|7|rt=1500000000000 suid=42 externalId=abc"+
+			` src=203.0.113.7 requestClientApplication=Mozilla/5.0 (X11; Linux) a\=b`+
+			" cs1Label=country cs1=DE cs2Label=city cs2=Berlin",
+		events.FormatCEF(e),
+	)
+}
+
+func TestSyslogPublisher(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan string)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('|')
+		received <- line
+	}()
+
+	collector, err := url.Parse("tcp://" + listener.Addr().String())
+	require.NoError(t, err)
+	publisher, err := events.NewSyslogPublisher(collector, "cef")
+	require.NoError(t, err)
+
+	err = publisher.Publish(events.Event{Type: events.SessionCreated, AccountID: 1, Time: time.Now()})
+	require.NoError(t, err)
+
+	select {
+	case line := <-received:
+		assert.True(t, strings.Contains(line, "<38>1 "), line)
+		assert.True(t, strings.HasSuffix(line, "CEF:0|"), line)
+	case <-time.After(time.Second):
+		t.Error("nothing received")
+	}
+
+	_, err = events.NewSyslogPublisher(&url.URL{Scheme: "http", Host: "localhost"}, "cef")
+	assert.Error(t, err)
+}
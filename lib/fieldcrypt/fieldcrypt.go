@@ -0,0 +1,173 @@
+// Package fieldcrypt encrypts sensitive database values with AES-256-GCM envelopes. Each value is
+// sealed with a random data key, and the data key is sealed with a versioned key-encryption key.
+// The version is stored with the value, so that old keys may still decrypt while new values use the
+// current key, and values may be re-encrypted after a rotation.
+//
+// Values are stored as `enc:v<version>:<wrapped key>:<ciphertext>`. Values without the `enc:`
+// prefix are treated as plaintext, so that encryption may be enabled on an existing database.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const prefix = "enc:v"
+
+// ErrUnknownVersion means that a value was encrypted with a key that is no longer configured.
+var ErrUnknownVersion = errors.New("unknown key version")
+
+// Keyring holds the key-encryption keys by version. New values are encrypted with the highest
+// version.
+type Keyring struct {
+	keys    map[int][]byte
+	current int
+}
+
+// NewKeyring returns a Keyring for 32-byte keys by version.
+func NewKeyring(keys map[int][]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys")
+	}
+	k := &Keyring{keys: keys}
+	for version, key := range keys {
+		if version < 1 {
+			return nil, fmt.Errorf("invalid version: %d", version)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key version %d must be 32 bytes", version)
+		}
+		if version > k.current {
+			k.current = version
+		}
+	}
+	return k, nil
+}
+
+// Current is the version that new values are encrypted with.
+func (k *Keyring) Current() int {
+	return k.current
+}
+
+// Encrypt seals a value with a new data key, wrapped by the current key.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", errors.Wrap(err, "ReadFull")
+	}
+
+	wrapped, err := seal(k.keys[k.current], dataKey)
+	if err != nil {
+		return "", errors.Wrap(err, "seal key")
+	}
+	ciphertext, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", errors.Wrap(err, "seal value")
+	}
+
+	return prefix + strconv.Itoa(k.current) + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt opens a value from Encrypt. Plaintext values are returned unchanged.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	version := Version(value)
+	if version == 0 {
+		return value, nil
+	}
+	key, ok := k.keys[version]
+	if !ok {
+		return "", ErrUnknownVersion
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) != 4 {
+		return "", fmt.Errorf("unexpected encrypted value format")
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.Wrap(err, "decode key")
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", errors.Wrap(err, "decode value")
+	}
+
+	dataKey, err := open(key, wrapped)
+	if err != nil {
+		return "", errors.Wrap(err, "open key")
+	}
+	plaintext, err := open(dataKey, ciphertext)
+	if err != nil {
+		return "", errors.Wrap(err, "open value")
+	}
+	return string(plaintext), nil
+}
+
+// Stale is true for values that are plaintext or encrypted with an old key, and should be
+// re-encrypted.
+func (k *Keyring) Stale(value string) bool {
+	return Version(value) != k.current
+}
+
+// Version returns the key version of an encrypted value, or 0 for plaintext.
+func Version(value string) int {
+	if !strings.HasPrefix(value, prefix) {
+		return 0
+	}
+	end := strings.IndexByte(value[len(prefix):], ':')
+	if end < 0 {
+		return 0
+	}
+	version, err := strconv.Atoi(value[len(prefix) : len(prefix)+end])
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+// seal encrypts with AES-256-GCM and prepends the nonce.
+func seal(key []byte, plaintext []byte) ([]byte, error) {
+	aesgcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "ReadFull")
+	}
+	return aesgcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key []byte, sealed []byte) ([]byte, error) {
+	aesgcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aesgcm.NonceSize() {
+		return nil, fmt.Errorf("unexpected encrypted value format")
+	}
+	nonce, ciphertext := sealed[:aesgcm.NonceSize()], sealed[aesgcm.NonceSize():]
+	return aesgcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewCipher")
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewGCM")
+	}
+	return aesgcm, nil
+}
@@ -0,0 +1,72 @@
+package fieldcrypt_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/keratin/authn-server/lib/fieldcrypt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyring(t *testing.T) {
+	v1 := bytes.Repeat([]byte{1}, 32)
+	v2 := bytes.Repeat([]byte{2}, 32)
+
+	old, err := fieldcrypt.NewKeyring(map[int][]byte{1: v1})
+	require.NoError(t, err)
+	rotated, err := fieldcrypt.NewKeyring(map[int][]byte{1: v1, 2: v2})
+	require.NoError(t, err)
+	assert.Equal(t, 2, rotated.Current())
+
+	t.Run("round trip", func(t *testing.T) {
+		encrypted, err := rotated.Encrypt("555-0100")
+		require.NoError(t, err)
+		assert.NotContains(t, encrypted, "555-0100")
+		assert.Equal(t, 2, fieldcrypt.Version(encrypted))
+
+		decrypted, err := rotated.Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "555-0100", decrypted)
+	})
+
+	t.Run("values from an old key", func(t *testing.T) {
+		encrypted, err := old.Encrypt("555-0100")
+		require.NoError(t, err)
+		assert.True(t, rotated.Stale(encrypted))
+
+		decrypted, err := rotated.Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "555-0100", decrypted)
+	})
+
+	t.Run("values from an unknown key", func(t *testing.T) {
+		encrypted, err := rotated.Encrypt("555-0100")
+		require.NoError(t, err)
+
+		_, err = old.Decrypt(encrypted)
+		assert.Equal(t, fieldcrypt.ErrUnknownVersion, err)
+	})
+
+	t.Run("plaintext values", func(t *testing.T) {
+		decrypted, err := rotated.Decrypt("555-0100")
+		require.NoError(t, err)
+		assert.Equal(t, "555-0100", decrypted)
+		assert.True(t, rotated.Stale("555-0100"))
+	})
+
+	t.Run("tampered values", func(t *testing.T) {
+		encrypted, err := rotated.Encrypt("555-0100")
+		require.NoError(t, err)
+
+		_, err = rotated.Decrypt(encrypted[:len(encrypted)-2] + "AA")
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		_, err := fieldcrypt.NewKeyring(map[int][]byte{1: []byte("short")})
+		assert.Error(t, err)
+		_, err = fieldcrypt.NewKeyring(map[int][]byte{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,64 @@
+package geoip
+
+import (
+	"net"
+
+	geoip2 "github.com/oschwald/geoip2-golang"
+	"github.com/pkg/errors"
+)
+
+// Location is where an IP address is registered, as far as the GeoIP database knows.
+type Location struct {
+	Country string `json:"country,omitempty"`
+	City    string `json:"city,omitempty"`
+}
+
+// Locator finds the Location of IP addresses. It returns nil when the address is unknown.
+type Locator interface {
+	Locate(ip net.IP) (*Location, error)
+}
+
+// Open reads a MaxMind GeoIP2 or GeoLite2 database in the MMDB format. City databases provide both
+// country and city, while Country databases provide only the country.
+func Open(path string) (Locator, error) {
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Open")
+	}
+	return &mmdbLocator{reader: reader}, nil
+}
+
+type mmdbLocator struct {
+	reader *geoip2.Reader
+}
+
+func (l *mmdbLocator) Locate(ip net.IP) (*Location, error) {
+	record, err := l.reader.City(ip)
+	if err != nil {
+		return nil, errors.Wrap(err, "City")
+	}
+	if record.Country.IsoCode == "" {
+		return nil, nil
+	}
+	return &Location{
+		Country: record.Country.IsoCode,
+		City:    record.City.Names["en"],
+	}, nil
+}
+
+// StaticLocator knows the Location of a fixed set of IP addresses. It is useful for tests and
+// development.
+type StaticLocator map[string]*Location
+
+func (l StaticLocator) Locate(ip net.IP) (*Location, error) {
+	return l[ip.String()], nil
+}
+
+// ParseRemoteAddr finds the IP address of a request's RemoteAddr, which may or may not include a
+// port depending on whether it was read from a proxy header.
+func ParseRemoteAddr(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(addr)
+}
@@ -0,0 +1,46 @@
+package geoip_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRemoteAddr(t *testing.T) {
+	testCases := []struct {
+		addr     string
+		expected net.IP
+	}{
+		{"127.0.0.1:1234", net.ParseIP("127.0.0.1")},
+		{"127.0.0.1", net.ParseIP("127.0.0.1")},
+		{"[::1]:1234", net.ParseIP("::1")},
+		{"::1", net.ParseIP("::1")},
+		{"unknown", nil},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, geoip.ParseRemoteAddr(tc.addr), tc.addr)
+	}
+}
+
+func TestStaticLocator(t *testing.T) {
+	locator := geoip.StaticLocator{
+		"203.0.113.1": &geoip.Location{Country: "NZ", City: "Auckland"},
+	}
+
+	location, err := locator.Locate(net.ParseIP("203.0.113.1"))
+	require.NoError(t, err)
+	assert.Equal(t, &geoip.Location{Country: "NZ", City: "Auckland"}, location)
+
+	location, err = locator.Locate(net.ParseIP("198.51.100.1"))
+	require.NoError(t, err)
+	assert.Nil(t, location)
+}
+
+func TestOpenMissing(t *testing.T) {
+	_, err := geoip.Open("does/not/exist.mmdb")
+	assert.Error(t, err)
+}
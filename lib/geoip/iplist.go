@@ -0,0 +1,46 @@
+package geoip
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IPList is a set of IP addresses, such as the published list of TOR exit nodes.
+type IPList map[string]bool
+
+// ReadIPList reads a file with one IP address per line. Blank lines and lines starting with # are
+// ignored.
+func ReadIPList(path string) (IPList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Open")
+	}
+	defer file.Close()
+
+	list := IPList{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ip := net.ParseIP(line)
+		if ip == nil {
+			return nil, errors.Errorf("invalid IP address: %v", line)
+		}
+		list[ip.String()] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "Scan")
+	}
+	return list, nil
+}
+
+// Contains is true when the list includes the IP address.
+func (l IPList) Contains(ip net.IP) bool {
+	return ip != nil && l[ip.String()]
+}
@@ -0,0 +1,48 @@
+package geoip_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadIPList(t *testing.T) {
+	writeList := func(t *testing.T, content string) string {
+		file, err := ioutil.TempFile("", "iplist")
+		require.NoError(t, err)
+		defer file.Close()
+		_, err = file.WriteString(content)
+		require.NoError(t, err)
+		return file.Name()
+	}
+
+	t.Run("valid list", func(t *testing.T) {
+		path := writeList(t, "# exit nodes\n192.0.2.1\n\n2001:db8::1\n")
+		defer os.Remove(path)
+
+		list, err := geoip.ReadIPList(path)
+		require.NoError(t, err)
+		assert.True(t, list.Contains(net.ParseIP("192.0.2.1")))
+		assert.True(t, list.Contains(net.ParseIP("2001:db8:0::1")))
+		assert.False(t, list.Contains(net.ParseIP("192.0.2.2")))
+		assert.False(t, list.Contains(nil))
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		path := writeList(t, "192.0.2.1\nexample.com\n")
+		defer os.Remove(path)
+
+		_, err := geoip.ReadIPList(path)
+		assert.Error(t, err)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := geoip.ReadIPList("/does/not/exist")
+		assert.Error(t, err)
+	})
+}
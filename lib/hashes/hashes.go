@@ -0,0 +1,387 @@
+// Package hashes identifies and verifies password hashes of every scheme that AuthN understands.
+// New passwords are hashed with bcrypt or argon2id, and other schemes exist so that accounts may
+// be imported from other systems. Every hash is stored in the style of a modular crypt string,
+// where the prefix (e.g. `$firebase-scrypt$...`) identifies the scheme, so that a hash is
+// recognized at login and may be replaced by a hash of the preferred scheme.
+package hashes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Schemes of password hashes.
+const (
+	Bcrypt         = "bcrypt"
+	Argon2id       = "argon2id"
+	FirebaseScrypt = "firebase-scrypt"
+	DeviseBcrypt   = "devise-bcrypt"
+	Scrypt         = "scrypt"
+	PBKDF2SHA1     = "pbkdf2-sha1"
+	PBKDF2SHA256   = "pbkdf2-sha256"
+	PBKDF2SHA512   = "pbkdf2-sha512"
+	SaltedSHA1     = "sha1"
+)
+
+// argon2id parameters for new hashes, as recommended by RFC 9106 for memory-constrained
+// environments.
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2KeyLen  = 32
+)
+
+// minDigestLen is the shortest digest that a hash may have. A shorter digest is malformed, and an
+// empty one would match every password.
+const minDigestLen = 16
+
+// ErrMismatch is returned when a password does not match a hash.
+var ErrMismatch = errors.New("hashes: password does not match")
+
+type comparer func(encoded string, password []byte, pepper string) error
+
+// schemes verify the part of a hash after its `$scheme$` prefix. bcrypt is not here, because its
+// prefixes are versions ($2a$, $2b$, $2y$).
+var schemes = map[string]comparer{
+	Argon2id:       compareArgon2id,
+	FirebaseScrypt: compareFirebase,
+	DeviseBcrypt:   compareDevise,
+	Scrypt:         compareScrypt,
+	PBKDF2SHA1:     comparePBKDF2(sha1.New),
+	PBKDF2SHA256:   comparePBKDF2(sha256.New),
+	PBKDF2SHA512:   comparePBKDF2(sha512.New),
+	SaltedSHA1:     compareSaltedSHA1,
+}
+
+// Scheme identifies the scheme of a hash, or returns "" when it is not understood.
+func Scheme(hash []byte) string {
+	if len(hash) > 4 && bytes.HasPrefix(hash, []byte("$2")) && hash[3] == '$' {
+		return Bcrypt
+	}
+	if len(hash) == 0 || hash[0] != '$' {
+		return ""
+	}
+	pieces := strings.SplitN(string(hash[1:]), "$", 2)
+	if len(pieces) != 2 {
+		return ""
+	}
+	if _, ok := schemes[pieces[0]]; !ok {
+		return ""
+	}
+	return pieces[0]
+}
+
+// IsTagged reports whether a hash is of a known scheme other than bcrypt.
+func IsTagged(hash []byte) bool {
+	scheme := Scheme(hash)
+	return scheme != "" && scheme != Bcrypt
+}
+
+// Compare verifies a password against a hash of any scheme. The pepper is only used for Devise
+// hashes.
+func Compare(hash []byte, password []byte, pepper string) error {
+	scheme := Scheme(hash)
+	switch scheme {
+	case "":
+		return fmt.Errorf("hashes: unknown hash")
+	case Bcrypt:
+		return mismatch(bcrypt.CompareHashAndPassword(hash, password))
+	default:
+		return schemes[scheme](string(hash[len(scheme)+2:]), password, pepper)
+	}
+}
+
+// NewArgon2id hashes a password with argon2id and a random salt, in the PHC string format.
+func NewArgon2id(password []byte) ([]byte, error) {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, errors.Wrap(err, "rand.Read")
+	}
+	key := argon2.IDKey(password, salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return []byte(fmt.Sprintf(
+		"$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		Argon2id, argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)), nil
+}
+
+// FirebaseParams are the hash parameters of a Firebase project, as shown in its console.
+type FirebaseParams struct {
+	SignerKey     []byte
+	SaltSeparator []byte
+	Rounds        int
+	MemCost       int
+}
+
+// Firebase tags a Firebase modified scrypt hash with its project's parameters.
+func Firebase(params FirebaseParams, salt []byte, hash []byte) []byte {
+	enc := base64.StdEncoding.EncodeToString
+	return []byte("$" + FirebaseScrypt + "$" + strings.Join([]string{
+		strconv.Itoa(params.Rounds),
+		strconv.Itoa(params.MemCost),
+		enc(params.SaltSeparator),
+		enc(params.SignerKey),
+		enc(salt),
+		enc(hash),
+	}, "$"))
+}
+
+// Devise tags a bcrypt hash that Devise created with a pepper appended to the password.
+func Devise(hash []byte) []byte {
+	return append([]byte("$"+DeviseBcrypt+"$"), hash...)
+}
+
+func mismatch(err error) error {
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return ErrMismatch
+	}
+	return err
+}
+
+func equal(derived []byte, expected []byte) error {
+	if len(expected) < minDigestLen {
+		return errMalformed
+	}
+	if subtle.ConstantTimeCompare(derived, expected) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+var errMalformed = errors.New("hashes: malformed hash")
+
+// fields splits an encoded hash into the expected number of fields.
+func fields(encoded string, n int) ([]string, error) {
+	f := strings.Split(encoded, "$")
+	if len(f) != n {
+		return nil, errMalformed
+	}
+	return f, nil
+}
+
+// digest decodes the expected key of a hash, which must be long enough to be meaningful.
+func digest(encoded string) ([]byte, error) {
+	key, err := decodeAB64(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "hash")
+	}
+	if len(key) < minDigestLen {
+		return nil, errMalformed
+	}
+	return key, nil
+}
+
+// bounded checks a cost parameter before deriving, so that a malformed hash can neither skip the
+// derivation nor exhaust the server.
+func bounded(name string, value int, min int, max int) error {
+	if value < min || value > max {
+		return fmt.Errorf("hashes: %s must be between %d and %d", name, min, max)
+	}
+	return nil
+}
+
+// params parses comma-delimited name=value integers, as in argon2id and scrypt hashes.
+func params(encoded string) (map[string]int, error) {
+	result := map[string]int{}
+	for _, param := range strings.Split(encoded, ",") {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("hashes: malformed parameter %s", param)
+		}
+		value, err := strconv.Atoi(kv[1])
+		if err != nil {
+			return nil, errors.Wrap(err, kv[0])
+		}
+		result[kv[0]] = value
+	}
+	return result, nil
+}
+
+// decodeAB64 decodes the unpadded base64 of PHC strings, including passlib's variant that uses "."
+// instead of "+".
+func decodeAB64(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(strings.Replace(s, ".", "+", -1), "="))
+}
+
+// compareArgon2id verifies a PHC string: v=19$m=65536,t=3,p=4$salt$hash
+func compareArgon2id(encoded string, password []byte, _ string) error {
+	f, err := fields(encoded, 4)
+	if err != nil {
+		return err
+	}
+	if f[0] != fmt.Sprintf("v=%d", argon2.Version) {
+		return fmt.Errorf("hashes: unsupported argon2 version %s", f[0])
+	}
+	p, err := params(f[1])
+	if err != nil {
+		return err
+	}
+	for _, err := range []error{
+		bounded("t", p["t"], 1, 64),
+		bounded("m", p["m"], 8, 1<<20),
+		bounded("p", p["p"], 1, 255),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	salt, err := decodeAB64(f[2])
+	if err != nil {
+		return errors.Wrap(err, "salt")
+	}
+	key, err := digest(f[3])
+	if err != nil {
+		return err
+	}
+	derived := argon2.IDKey(password, salt, uint32(p["t"]), uint32(p["m"]), uint8(p["p"]), uint32(len(key)))
+	return equal(derived, key)
+}
+
+// compareScrypt verifies a passlib scrypt hash: ln=16,r=8,p=1$salt$hash
+func compareScrypt(encoded string, password []byte, _ string) error {
+	f, err := fields(encoded, 3)
+	if err != nil {
+		return err
+	}
+	p, err := params(f[0])
+	if err != nil {
+		return err
+	}
+	for _, err := range []error{
+		bounded("ln", p["ln"], 1, 20),
+		bounded("r", p["r"], 1, 32),
+		bounded("p", p["p"], 1, 16),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	salt, err := decodeAB64(f[1])
+	if err != nil {
+		return errors.Wrap(err, "salt")
+	}
+	key, err := digest(f[2])
+	if err != nil {
+		return err
+	}
+	derived, err := scrypt.Key(password, salt, 1<<uint(p["ln"]), p["r"], p["p"], len(key))
+	if err != nil {
+		return errors.Wrap(err, "scrypt")
+	}
+	return equal(derived, key)
+}
+
+// comparePBKDF2 verifies a passlib PBKDF2 hash: iterations$salt$hash
+func comparePBKDF2(h func() hash.Hash) comparer {
+	return func(encoded string, password []byte, _ string) error {
+		f, err := fields(encoded, 3)
+		if err != nil {
+			return err
+		}
+		iterations, err := strconv.Atoi(f[0])
+		if err != nil {
+			return errors.Wrap(err, "iterations")
+		}
+		if err = bounded("iterations", iterations, 1, 10000000); err != nil {
+			return err
+		}
+		salt, err := decodeAB64(f[1])
+		if err != nil {
+			return errors.Wrap(err, "salt")
+		}
+		key, err := digest(f[2])
+		if err != nil {
+			return err
+		}
+		return equal(pbkdf2.Key(password, salt, iterations, len(key), h), key)
+	}
+}
+
+// compareSaltedSHA1 verifies salt$hexdigest, where the digest is SHA-1 of the salt followed by the
+// password.
+func compareSaltedSHA1(encoded string, password []byte, _ string) error {
+	f, err := fields(encoded, 2)
+	if err != nil {
+		return err
+	}
+	expected, err := hex.DecodeString(f[1])
+	if err != nil {
+		return errors.Wrap(err, "hash")
+	}
+	if len(expected) != sha1.Size {
+		return errMalformed
+	}
+	digest := sha1.Sum(append([]byte(f[0]), password...))
+	return equal(digest[:], expected)
+}
+
+func compareDevise(encoded string, password []byte, pepper string) error {
+	return mismatch(bcrypt.CompareHashAndPassword([]byte(encoded), append(password, pepper...)))
+}
+
+// compareFirebase implements Firebase's modified scrypt: the scrypt key of the password encrypts
+// the project's signer key with AES-256-CTR, and the result is the hash.
+func compareFirebase(encoded string, password []byte, _ string) error {
+	f, err := fields(encoded, 6)
+	if err != nil {
+		return err
+	}
+	rounds, err := strconv.Atoi(f[0])
+	if err != nil {
+		return errors.Wrap(err, "rounds")
+	}
+	memCost, err := strconv.Atoi(f[1])
+	if err != nil {
+		return errors.Wrap(err, "mem_cost")
+	}
+	if err = bounded("rounds", rounds, 1, 32); err != nil {
+		return err
+	}
+	if err = bounded("mem_cost", memCost, 1, 20); err != nil {
+		return err
+	}
+	decoded := make([][]byte, 4)
+	for i, field := range f[2:] {
+		decoded[i], err = base64.StdEncoding.DecodeString(field)
+		if err != nil {
+			return errors.Wrap(err, "DecodeString")
+		}
+	}
+	saltSeparator, signerKey, salt, hash := decoded[0], decoded[1], decoded[2], decoded[3]
+	if len(hash) < minDigestLen || len(signerKey) != len(hash) {
+		return errMalformed
+	}
+
+	key, err := scrypt.Key(password, append(salt, saltSeparator...), 1<<uint(memCost), rounds, 1, 32)
+	if err != nil {
+		return errors.Wrap(err, "scrypt")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return errors.Wrap(err, "NewCipher")
+	}
+	derived := make([]byte, len(signerKey))
+	cipher.NewCTR(block, make([]byte, aes.BlockSize)).XORKeyStream(derived, signerKey)
+	return equal(derived, hash)
+}
@@ -0,0 +1,170 @@
+package hashes_test
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"strings"
+	"testing"
+
+	"github.com/keratin/authn-server/lib/hashes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+func decode(t *testing.T, s string) []byte {
+	b, err := base64.StdEncoding.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestFirebase(t *testing.T) {
+	// from the documentation of github.com/firebase/scrypt
+	params := hashes.FirebaseParams{
+		SignerKey:     decode(t, "jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA=="),
+		SaltSeparator: decode(t, "Bw=="),
+		Rounds:        8,
+		MemCost:       14,
+	}
+	hash := hashes.Firebase(
+		params,
+		decode(t, "42xEC+ixf3L2lw=="),
+		decode(t, "lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ=="),
+	)
+
+	assert.True(t, hashes.IsTagged(hash))
+	assert.NoError(t, hashes.Compare(hash, []byte("user1password"), ""))
+	assert.Equal(t, hashes.ErrMismatch, hashes.Compare(hash, []byte("wrong"), ""))
+	assert.True(t, len(hash) <= 255, "fits a VARCHAR(255) column")
+}
+
+func TestDevise(t *testing.T) {
+	bcrypted, err := bcrypt.GenerateFromPassword([]byte("secretpepper"), bcrypt.MinCost)
+	require.NoError(t, err)
+	hash := hashes.Devise(bcrypted)
+
+	assert.True(t, hashes.IsTagged(hash))
+	assert.NoError(t, hashes.Compare(hash, []byte("secret"), "pepper"))
+	assert.Equal(t, hashes.ErrMismatch, hashes.Compare(hash, []byte("secret"), ""))
+}
+
+func TestArgon2id(t *testing.T) {
+	hash, err := hashes.NewArgon2id([]byte("secret"))
+	require.NoError(t, err)
+
+	assert.Equal(t, hashes.Argon2id, hashes.Scheme(hash))
+	assert.True(t, strings.HasPrefix(string(hash), "$argon2id$v=19$m=65536,t=3,p=4$"))
+	assert.NoError(t, hashes.Compare(hash, []byte("secret"), ""))
+	assert.Equal(t, hashes.ErrMismatch, hashes.Compare(hash, []byte("wrong"), ""))
+}
+
+func TestScrypt(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	key, err := scrypt.Key([]byte("secret"), salt, 1<<10, 8, 1, 32)
+	require.NoError(t, err)
+	hash := []byte("$scrypt$ln=10,r=8,p=1$" + ab64(salt) + "$" + ab64(key))
+
+	assert.Equal(t, hashes.Scrypt, hashes.Scheme(hash))
+	assert.NoError(t, hashes.Compare(hash, []byte("secret"), ""))
+	assert.Equal(t, hashes.ErrMismatch, hashes.Compare(hash, []byte("wrong"), ""))
+}
+
+func TestPBKDF2(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	testCases := []struct {
+		scheme string
+		hash   func() hash.Hash
+	}{
+		{hashes.PBKDF2SHA1, sha1.New},
+		{hashes.PBKDF2SHA256, sha256.New},
+		{hashes.PBKDF2SHA512, sha512.New},
+	}
+	for _, tc := range testCases {
+		key := pbkdf2.Key([]byte("secret"), salt, 1000, tc.hash().Size(), tc.hash)
+		hash := []byte("$" + tc.scheme + "$1000$" + ab64(salt) + "$" + ab64(key))
+
+		assert.Equal(t, tc.scheme, hashes.Scheme(hash))
+		assert.NoError(t, hashes.Compare(hash, []byte("secret"), ""), tc.scheme)
+		assert.Equal(t, hashes.ErrMismatch, hashes.Compare(hash, []byte("wrong"), ""), tc.scheme)
+	}
+}
+
+func TestSaltedSHA1(t *testing.T) {
+	digest := sha1.Sum([]byte("saltsecret"))
+	hash := []byte("$sha1$salt$" + hex.EncodeToString(digest[:]))
+
+	assert.Equal(t, hashes.SaltedSHA1, hashes.Scheme(hash))
+	assert.NoError(t, hashes.Compare(hash, []byte("secret"), ""))
+	assert.Equal(t, hashes.ErrMismatch, hashes.Compare(hash, []byte("wrong"), ""))
+}
+
+func TestScheme(t *testing.T) {
+	testCases := []struct {
+		hash   string
+		scheme string
+	}{
+		{"$2a$10$W5AiL6r4XBrZHc3NEcMUC.xj52oYl6YQw6YpTP1OkjFLmWfOk7oqC", hashes.Bcrypt},
+		{"$2b$10$W5AiL6r4XBrZHc3NEcMUC.xj52oYl6YQw6YpTP1OkjFLmWfOk7oqC", hashes.Bcrypt},
+		{"$sha1$salt$digest", hashes.SaltedSHA1},
+		{"$md5$salt$digest", ""},
+		{"plaintext", ""},
+		{"", ""},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.scheme, hashes.Scheme([]byte(tc.hash)), tc.hash)
+	}
+
+	assert.False(t, hashes.IsTagged([]byte("$2a$10$W5AiL6r4XBrZHc3NEcMUC.xj52oYl6YQw6YpTP1OkjFLmWfOk7oqC")))
+	assert.Error(t, hashes.Compare([]byte("plaintext"), []byte("secret"), ""))
+	assert.Error(t, hashes.Compare([]byte("$sha1$malformed"), []byte("secret"), ""))
+}
+
+func TestMalformed(t *testing.T) {
+	salt := ab64([]byte("0123456789abcdef"))
+	key := ab64(make([]byte, 32))
+	testCases := []string{
+		// empty or short digests
+		"$pbkdf2-sha256$1000$c2FsdA$",
+		"$pbkdf2-sha256$1000$" + salt + "$" + ab64([]byte("short")),
+		"$scrypt$ln=4,r=8,p=1$c2FsdA$",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$",
+		"$sha1$salt$",
+		"$sha1$salt$abcd",
+		"$firebase-scrypt$8$14$Bw==$$c2FsdA==$",
+		// zero or out-of-range costs
+		"$pbkdf2-sha256$0$" + salt + "$" + key,
+		"$pbkdf2-sha256$-1$" + salt + "$" + key,
+		"$scrypt$ln=0,r=8,p=1$" + salt + "$" + key,
+		"$scrypt$ln=64,r=8,p=1$" + salt + "$" + key,
+		"$scrypt$ln=4,r=0,p=1$" + salt + "$" + key,
+		"$scrypt$ln=4,r=8,p=0$" + salt + "$" + key,
+		"$scrypt$ln=4$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=0,p=0$c2FsdA$",
+		"$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key,
+		"$argon2id$v=19$m=0,t=1,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=4294967296,t=1,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$t=1,p=1$" + salt + "$" + key,
+		"$firebase-scrypt$0$14$Bw==$" + base64.StdEncoding.EncodeToString(make([]byte, 32)) + "$c2FsdA==$" + base64.StdEncoding.EncodeToString(make([]byte, 32)),
+		"$firebase-scrypt$8$0$Bw==$" + base64.StdEncoding.EncodeToString(make([]byte, 32)) + "$c2FsdA==$" + base64.StdEncoding.EncodeToString(make([]byte, 32)),
+	}
+	for _, tc := range testCases {
+		require.True(t, hashes.IsTagged([]byte(tc)), tc)
+		assert.NotPanics(t, func() {
+			err := hashes.Compare([]byte(tc), []byte("anything"), "")
+			assert.Error(t, err, tc)
+			assert.NotEqual(t, hashes.ErrMismatch, err, tc)
+		}, tc)
+	}
+}
+
+// ab64 encodes like passlib: unpadded base64 with "." instead of "+".
+func ab64(b []byte) string {
+	return strings.Replace(base64.RawStdEncoding.EncodeToString(b), "+", ".", -1)
+}
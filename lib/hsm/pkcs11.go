@@ -0,0 +1,135 @@
+// Package hsm signs with RSA keys that are held on a hardware security module or smart card, so
+// that the private key never leaves the device.
+package hsm
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+)
+
+// sha256Prefix is the DER-encoded DigestInfo that PKCS #1 v1.5 signatures wrap around a SHA-256
+// digest. The device is asked for a raw CKM_RSA_PKCS signature, so the prefix is added here.
+var sha256Prefix = []byte{0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20}
+
+// Key is a crypto.Signer for an RSA private key on a PKCS#11 device. It holds one logged-in session
+// for the life of the process, and signs one digest at a time.
+type Key struct {
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	handle  pkcs11.ObjectHandle
+	public  *rsa.PublicKey
+	mutex   sync.Mutex
+}
+
+// Open loads a PKCS#11 module, logs in to the token in the given slot, and finds the RSA key pair
+// with the given label. When the label is empty, the token must hold exactly one RSA key pair.
+func Open(modulePath string, slot uint, pin string, label string) (*Key, error) {
+	ctx := pkcs11.New(modulePath)
+	if ctx == nil {
+		return nil, fmt.Errorf("could not load PKCS#11 module: %s", modulePath)
+	}
+	err := ctx.Initialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "Initialize")
+	}
+
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return nil, errors.Wrap(err, "OpenSession")
+	}
+	err = ctx.Login(session, pkcs11.CKU_USER, pin)
+	if err != nil && err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		return nil, errors.Wrap(err, "Login")
+	}
+
+	handle, err := findObject(ctx, session, pkcs11.CKO_PRIVATE_KEY, label)
+	if err != nil {
+		return nil, errors.Wrap(err, "private key")
+	}
+	publicHandle, err := findObject(ctx, session, pkcs11.CKO_PUBLIC_KEY, label)
+	if err != nil {
+		return nil, errors.Wrap(err, "public key")
+	}
+	attrs, err := ctx.GetAttributeValue(session, publicHandle, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAttributeValue")
+	}
+
+	return &Key{
+		ctx:     ctx,
+		session: session,
+		handle:  handle,
+		public: &rsa.PublicKey{
+			N: new(big.Int).SetBytes(attrs[0].Value),
+			E: int(new(big.Int).SetBytes(attrs[1].Value).Int64()),
+		},
+	}, nil
+}
+
+// Public returns the RSA public key, which was read from the device when it was opened.
+func (k *Key) Public() crypto.PublicKey {
+	return k.public
+}
+
+// Sign creates a PKCS #1 v1.5 signature of a SHA-256 digest. The device does the signing, and
+// ignores rand.
+func (k *Key) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != crypto.SHA256 {
+		return nil, fmt.Errorf("unsupported hash: %v", opts.HashFunc())
+	}
+	if len(digest) != crypto.SHA256.Size() {
+		return nil, fmt.Errorf("unexpected digest length: %d", len(digest))
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	err := k.ctx.SignInit(k.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS, nil)}, k.handle)
+	if err != nil {
+		return nil, errors.Wrap(err, "SignInit")
+	}
+	return k.ctx.Sign(k.session, append(append([]byte{}, sha256Prefix...), digest...))
+}
+
+func findObject(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, class uint, label string) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+	}
+	if label != "" {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, label))
+	}
+
+	err := ctx.FindObjectsInit(session, template)
+	if err != nil {
+		return 0, errors.Wrap(err, "FindObjectsInit")
+	}
+	handles, _, err := ctx.FindObjects(session, 2)
+	if err != nil {
+		ctx.FindObjectsFinal(session)
+		return 0, errors.Wrap(err, "FindObjects")
+	}
+	err = ctx.FindObjectsFinal(session)
+	if err != nil {
+		return 0, errors.Wrap(err, "FindObjectsFinal")
+	}
+
+	switch len(handles) {
+	case 0:
+		return 0, fmt.Errorf("no RSA key found with label %q", label)
+	case 1:
+		return handles[0], nil
+	default:
+		return 0, fmt.Errorf("multiple RSA keys found with label %q", label)
+	}
+}
@@ -0,0 +1,15 @@
+package hsm_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/lib/hsm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpen(t *testing.T) {
+	t.Run("missing module", func(t *testing.T) {
+		_, err := hsm.Open("/nonexistent/libpkcs11.so", 0, "1234", "authn")
+		assert.Error(t, err)
+	})
+}
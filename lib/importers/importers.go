@@ -0,0 +1,213 @@
+// Package importers reads the account exports of other authentication systems. Each adapter
+// streams Records with password hashes in a form that AuthN can verify: bcrypt hashes as they are,
+// and other algorithms tagged by the hashes package so that they are upgraded at login.
+package importers
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/keratin/authn-server/lib/hashes"
+	"github.com/pkg/errors"
+)
+
+// Record is one account read from an export.
+type Record struct {
+	// ID identifies the account in the system it was exported from.
+	ID            string
+	Username      string
+	PasswordHash  string
+	Locked        bool
+	EmailVerified bool
+}
+
+// Auth0 reads an Auth0 bulk user export, which is newline-delimited JSON. Password hashes are only
+// included in exports that were requested from Auth0 support, and are bcrypt.
+func Auth0(r io.Reader, fn func(Record) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var user struct {
+			UserID string `json:"user_id"`
+			OID    struct {
+				OID string `json:"$oid"`
+			} `json:"_id"`
+			Email         string `json:"email"`
+			Username      string `json:"username"`
+			EmailVerified bool   `json:"email_verified"`
+			PasswordHash  string `json:"passwordHash"`
+			Blocked       bool   `json:"blocked"`
+		}
+		err := json.Unmarshal(scanner.Bytes(), &user)
+		if err != nil {
+			return errors.Wrapf(err, "line %d", line)
+		}
+
+		record := Record{
+			ID:            user.UserID,
+			Username:      user.Email,
+			PasswordHash:  user.PasswordHash,
+			Locked:        user.Blocked,
+			EmailVerified: user.EmailVerified,
+		}
+		if record.ID == "" {
+			record.ID = user.OID.OID
+		}
+		if record.Username == "" {
+			record.Username = user.Username
+		}
+		err = fn(record)
+		if err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(scanner.Err(), "Scan")
+}
+
+// Firebase reads the JSON written by `firebase auth:export`. Password hashes are Firebase's
+// modified scrypt, which also needs the project's hash parameters.
+func Firebase(r io.Reader, params hashes.FirebaseParams, fn func(Record) error) error {
+	decoder := json.NewDecoder(r)
+	err := expectDelim(decoder, '{')
+	if err != nil {
+		return err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return errors.Wrap(err, "Token")
+		}
+		if token != "users" {
+			var skipped json.RawMessage
+			err = decoder.Decode(&skipped)
+			if err != nil {
+				return errors.Wrap(err, "Decode")
+			}
+			continue
+		}
+
+		err = expectDelim(decoder, '[')
+		if err != nil {
+			return err
+		}
+		for decoder.More() {
+			var user struct {
+				LocalID       string `json:"localId"`
+				Email         string `json:"email"`
+				EmailVerified bool   `json:"emailVerified"`
+				PasswordHash  string `json:"passwordHash"`
+				Salt          string `json:"salt"`
+				Disabled      bool   `json:"disabled"`
+			}
+			err = decoder.Decode(&user)
+			if err != nil {
+				return errors.Wrap(err, "Decode")
+			}
+
+			record := Record{
+				ID:            user.LocalID,
+				Username:      user.Email,
+				Locked:        user.Disabled,
+				EmailVerified: user.EmailVerified,
+			}
+			if user.PasswordHash != "" {
+				hash, err := decodeBase64(user.PasswordHash)
+				if err != nil {
+					return errors.Wrapf(err, "%s passwordHash", user.LocalID)
+				}
+				salt, err := decodeBase64(user.Salt)
+				if err != nil {
+					return errors.Wrapf(err, "%s salt", user.LocalID)
+				}
+				record.PasswordHash = string(hashes.Firebase(params, salt, hash))
+			}
+			err = fn(record)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = decoder.Token()
+		if err != nil {
+			return errors.Wrap(err, "Token")
+		}
+	}
+	return nil
+}
+
+// Devise reads a CSV export of a Devise users table, with a header row. It needs the email and
+// encrypted_password columns, and understands id, locked_at, and confirmed_at. When the application
+// configured a pepper, the hashes are tagged so that DEVISE_PEPPER is used to verify them.
+func Devise(r io.Reader, peppered bool, fn func(Record) error) error {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return errors.Wrap(err, "header")
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, required := range []string{"email", "encrypted_password"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("missing column: %s", required)
+		}
+	}
+	value := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "Read")
+		}
+
+		record := Record{
+			ID:            value(row, "id"),
+			Username:      value(row, "email"),
+			PasswordHash:  value(row, "encrypted_password"),
+			Locked:        value(row, "locked_at") != "",
+			EmailVerified: value(row, "confirmed_at") != "",
+		}
+		if peppered && record.PasswordHash != "" {
+			record.PasswordHash = string(hashes.Devise([]byte(record.PasswordHash)))
+		}
+		err = fn(record)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// decodeBase64 accepts both encodings, because Firebase tools have written both.
+func decodeBase64(s string) ([]byte, error) {
+	if strings.ContainsAny(s, "-_") {
+		return base64.URLEncoding.DecodeString(s)
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return errors.Wrap(err, "Token")
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
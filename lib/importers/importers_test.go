@@ -0,0 +1,74 @@
+package importers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keratin/authn-server/lib/hashes"
+	"github.com/keratin/authn-server/lib/importers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(records *[]importers.Record) func(importers.Record) error {
+	return func(r importers.Record) error {
+		*records = append(*records, r)
+		return nil
+	}
+}
+
+func TestAuth0(t *testing.T) {
+	export := `{"user_id":"auth0|1","email":"one@example.com","email_verified":true,"passwordHash":"$2b$10$hash"}
+
+{"_id":{"$oid":"5d1"},"username":"two","blocked":true}
+`
+	records := []importers.Record{}
+	err := importers.Auth0(strings.NewReader(export), collect(&records))
+	require.NoError(t, err)
+	assert.Equal(t, []importers.Record{
+		{ID: "auth0|1", Username: "one@example.com", PasswordHash: "$2b$10$hash", EmailVerified: true},
+		{ID: "5d1", Username: "two", Locked: true},
+	}, records)
+
+	err = importers.Auth0(strings.NewReader("not json\n"), collect(&records))
+	assert.Error(t, err)
+}
+
+func TestFirebase(t *testing.T) {
+	export := `{"users": [
+		{"localId":"a1","email":"one@example.com","emailVerified":true,"passwordHash":"lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==","salt":"42xEC+ixf3L2lw=="},
+		{"localId":"a2","email":"two@example.com","disabled":true}
+	]}`
+	params := hashes.FirebaseParams{SignerKey: []byte("signer"), SaltSeparator: []byte{7}, Rounds: 8, MemCost: 14}
+
+	records := []importers.Record{}
+	err := importers.Firebase(strings.NewReader(export), params, collect(&records))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "one@example.com", records[0].Username)
+	assert.True(t, records[0].EmailVerified)
+	assert.True(t, hashes.IsTagged([]byte(records[0].PasswordHash)))
+	assert.Equal(t, importers.Record{ID: "a2", Username: "two@example.com", Locked: true}, records[1])
+}
+
+func TestDevise(t *testing.T) {
+	export := "id,email,encrypted_password,confirmed_at,locked_at\n" +
+		"1,one@example.com,$2a$11$hash,2020-01-01 00:00:00,\n" +
+		"2,two@example.com,$2a$11$hash,,2020-01-01 00:00:00\n"
+
+	records := []importers.Record{}
+	err := importers.Devise(strings.NewReader(export), false, collect(&records))
+	require.NoError(t, err)
+	assert.Equal(t, []importers.Record{
+		{ID: "1", Username: "one@example.com", PasswordHash: "$2a$11$hash", EmailVerified: true},
+		{ID: "2", Username: "two@example.com", PasswordHash: "$2a$11$hash", Locked: true},
+	}, records)
+
+	records = []importers.Record{}
+	err = importers.Devise(strings.NewReader(export), true, collect(&records))
+	require.NoError(t, err)
+	assert.True(t, hashes.IsTagged([]byte(records[0].PasswordHash)))
+
+	err = importers.Devise(strings.NewReader("id,email\n"), false, collect(&records))
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/keratin/authn-server/lib"
+	"github.com/keratin/authn-server/ops"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Job is a periodic task run by a Scheduler.
+type Job struct {
+	Name     string
+	Interval time.Duration
+
+	// Aligned jobs run at the start of each interval since the epoch, so that every AuthN server
+	// runs them at the same moment. Otherwise a job runs one interval after the last run.
+	Aligned bool
+
+	// Exclusive jobs run on only one AuthN server per interval. The server that wins the lock is
+	// the leader for that run. Jobs that maintain in-memory state must not be exclusive.
+	Exclusive bool
+
+	Run func() error
+}
+
+// Locker coordinates exclusive jobs between AuthN servers.
+type Locker interface {
+	// Lock attempts to claim the named lock for the given duration. It returns false if another
+	// server holds the lock.
+	Lock(name string, ttl time.Duration) (bool, error)
+}
+
+// Scheduler runs periodic background jobs, reporting any errors. A nil Locker means that this
+// server is the only one, and will run every exclusive job itself.
+type Scheduler struct {
+	locker   Locker
+	reporter ops.ErrorReporter
+	jobs     []Job
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// NewScheduler returns a Scheduler. Jobs must be added before calling Start.
+func NewScheduler(locker Locker, reporter ops.ErrorReporter) *Scheduler {
+	return &Scheduler{
+		locker:   locker,
+		reporter: reporter,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Add registers a job.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every registered job in the background.
+func (s *Scheduler) Start() {
+	for _, job := range s.jobs {
+		go s.schedule(job)
+	}
+}
+
+// Stop halts all jobs after their current run.
+func (s *Scheduler) Stop() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+func (s *Scheduler) schedule(job Job) {
+	if job.Aligned {
+		ticks := lib.EpochIntervalTick(job.Interval)
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticks:
+				s.run(job)
+			}
+		}
+	}
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.run(job)
+		}
+	}
+}
+
+func (s *Scheduler) run(job Job) {
+	if job.Exclusive && s.locker != nil {
+		// hold the lock for slightly less than the interval, so that it is available again for the
+		// next run on any server.
+		ok, err := s.locker.Lock("jobs:"+job.Name, job.Interval*9/10)
+		if err != nil {
+			s.reporter.ReportError(errors.Wrapf(err, "Lock %s", job.Name))
+			return
+		}
+		if !ok {
+			return
+		}
+	}
+
+	err := job.Run()
+	if err != nil {
+		s.reporter.ReportError(errors.Wrapf(err, "job %s", job.Name))
+		return
+	}
+	log.WithFields(log.Fields{"job": job.Name}).Debug("job complete")
+}
@@ -0,0 +1,78 @@
+package jobs_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/lib/jobs"
+	"github.com/keratin/authn-server/ops"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeLocker struct {
+	mutex sync.Mutex
+	held  map[string]bool
+}
+
+func (l *fakeLocker) Lock(name string, ttl time.Duration) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.held[name] {
+		return false, nil
+	}
+	l.held[name] = true
+	return true, nil
+}
+
+func TestScheduler(t *testing.T) {
+	t.Run("running jobs", func(t *testing.T) {
+		runs := make(chan bool, 10)
+		scheduler := jobs.NewScheduler(nil, &ops.LogReporter{})
+		scheduler.Add(jobs.Job{
+			Name:      "test",
+			Interval:  10 * time.Millisecond,
+			Exclusive: true,
+			Run:       func() error { runs <- true; return nil },
+		})
+		scheduler.Start()
+		defer scheduler.Stop()
+
+		select {
+		case <-runs:
+		case <-time.After(time.Second):
+			t.Error("job did not run")
+		}
+	})
+
+	t.Run("running exclusive jobs on one server", func(t *testing.T) {
+		var mutex sync.Mutex
+		count := 0
+		locker := &fakeLocker{held: map[string]bool{}}
+		job := jobs.Job{
+			Name:      "test",
+			Interval:  10 * time.Millisecond,
+			Exclusive: true,
+			Run: func() error {
+				mutex.Lock()
+				defer mutex.Unlock()
+				count++
+				return nil
+			},
+		}
+
+		first := jobs.NewScheduler(locker, &ops.LogReporter{})
+		first.Add(job)
+		second := jobs.NewScheduler(locker, &ops.LogReporter{})
+		second.Add(job)
+		first.Start()
+		second.Start()
+		time.Sleep(50 * time.Millisecond)
+		first.Stop()
+		second.Stop()
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		assert.Equal(t, 1, count)
+	})
+}
@@ -0,0 +1,108 @@
+package lib
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// LoadShedder tracks how quickly dependencies respond to probes. While any dependency is slower
+// than its threshold, or failing, the server sheds non-critical work so that what capacity remains
+// goes to logins and refreshes.
+//
+// A nil *LoadShedder is valid and never sheds.
+type LoadShedder struct {
+	thresholds map[string]time.Duration
+
+	mutex     sync.RWMutex
+	latencies map[string]time.Duration
+	failing   map[string]bool
+}
+
+// NewLoadShedder returns a LoadShedder with a latency threshold for each named dependency. A
+// threshold of zero or less ignores the dependency, and ignoring every dependency returns nil,
+// which never sheds.
+func NewLoadShedder(thresholds map[string]time.Duration) *LoadShedder {
+	watched := map[string]time.Duration{}
+	for name, threshold := range thresholds {
+		if threshold > 0 {
+			watched[name] = threshold
+		}
+	}
+	if len(watched) == 0 {
+		return nil
+	}
+	return &LoadShedder{
+		thresholds: watched,
+		latencies:  map[string]time.Duration{},
+		failing:    map[string]bool{},
+	}
+}
+
+// Watches reports whether the dependency has a threshold.
+func (s *LoadShedder) Watches(dependency string) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.thresholds[dependency]
+	return ok
+}
+
+// Probe times the check and records the result. It does nothing for unwatched dependencies.
+func (s *LoadShedder) Probe(dependency string, check func() bool) {
+	if !s.Watches(dependency) {
+		return
+	}
+	start := time.Now()
+	ok := check()
+	s.Observe(dependency, time.Since(start), ok)
+}
+
+// Observe records the latest latency of the dependency, and whether it responded successfully.
+func (s *LoadShedder) Observe(dependency string, latency time.Duration, ok bool) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.latencies[dependency] = latency
+	s.failing[dependency] = !ok
+}
+
+// Degraded returns the names of dependencies that are slower than their thresholds or failing, in
+// alphabetical order.
+func (s *LoadShedder) Degraded() []string {
+	names := []string{}
+	if s == nil {
+		return names
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for name, threshold := range s.thresholds {
+		latency, observed := s.latencies[name]
+		if observed && (s.failing[name] || latency > threshold) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Shedding reports whether any dependency is degraded.
+func (s *LoadShedder) Shedding() bool {
+	return len(s.Degraded()) > 0
+}
+
+// Latencies returns the latest latency of each watched dependency that has been observed.
+func (s *LoadShedder) Latencies() map[string]time.Duration {
+	latencies := map[string]time.Duration{}
+	if s == nil {
+		return latencies
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for name, latency := range s.latencies {
+		latencies[name] = latency
+	}
+	return latencies
+}
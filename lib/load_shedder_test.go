@@ -0,0 +1,62 @@
+package lib_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadShedder(t *testing.T) {
+	t.Run("nil shedder", func(t *testing.T) {
+		var shedder *lib.LoadShedder
+		shedder.Observe("db", time.Hour, false)
+		assert.False(t, shedder.Shedding())
+		assert.Empty(t, shedder.Degraded())
+		assert.Empty(t, shedder.Latencies())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Nil(t, lib.NewLoadShedder(map[string]time.Duration{"db": 0, "redis": 0}))
+	})
+
+	t.Run("shedding while slow", func(t *testing.T) {
+		shedder := lib.NewLoadShedder(map[string]time.Duration{"db": 100 * time.Millisecond})
+		assert.False(t, shedder.Shedding())
+
+		shedder.Observe("db", 50*time.Millisecond, true)
+		assert.False(t, shedder.Shedding())
+
+		shedder.Observe("db", 150*time.Millisecond, true)
+		assert.True(t, shedder.Shedding())
+		assert.Equal(t, []string{"db"}, shedder.Degraded())
+		assert.Equal(t, map[string]time.Duration{"db": 150 * time.Millisecond}, shedder.Latencies())
+
+		shedder.Observe("db", 50*time.Millisecond, true)
+		assert.False(t, shedder.Shedding())
+	})
+
+	t.Run("shedding while failing", func(t *testing.T) {
+		shedder := lib.NewLoadShedder(map[string]time.Duration{"db": time.Second, "redis": time.Second})
+		shedder.Observe("db", time.Millisecond, true)
+		shedder.Observe("redis", time.Millisecond, false)
+		assert.Equal(t, []string{"redis"}, shedder.Degraded())
+	})
+
+	t.Run("probing watched dependencies", func(t *testing.T) {
+		shedder := lib.NewLoadShedder(map[string]time.Duration{"db": time.Millisecond})
+		shedder.Probe("db", func() bool {
+			time.Sleep(5 * time.Millisecond)
+			return true
+		})
+		probed := false
+		shedder.Probe("redis", func() bool {
+			probed = true
+			return false
+		})
+
+		assert.False(t, probed)
+		assert.Equal(t, []string{"db"}, shedder.Degraded())
+	})
+}
@@ -0,0 +1,50 @@
+package mfa
+
+import "fmt"
+
+// Statuses of a push approval request.
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusDenied   = "denied"
+)
+
+// PushRequest describes a login that is waiting for approval.
+type PushRequest struct {
+	AccountID int
+	Username  string
+	IP        string
+	UserAgent string
+	// StepUp marks a request to re-verify a user who is already logged in, rather than a login.
+	StepUp bool
+}
+
+// PushProvider sends login approval requests to an external authenticator, such as Duo or a
+// custom app, as a second factor.
+type PushProvider interface {
+	// Request asks the account's authenticator to approve a login. It returns the provider's ID
+	// for the request, or an empty ID when the account has not enrolled an authenticator.
+	Request(req PushRequest) (string, error)
+
+	// Status checks whether a request is pending, approved, or denied.
+	Status(id string) (string, error)
+}
+
+// StaticProvider answers approval requests with a fixed status for each username. It is useful for
+// tests and development. Usernames that are not listed have not enrolled.
+type StaticProvider map[string]string
+
+func (p StaticProvider) Request(req PushRequest) (string, error) {
+	if _, ok := p[req.Username]; !ok {
+		return "", nil
+	}
+	return req.Username, nil
+}
+
+func (p StaticProvider) Status(id string) (string, error) {
+	status, ok := p[id]
+	if !ok {
+		return "", fmt.Errorf("unknown request: %v", id)
+	}
+	return status, nil
+}
@@ -0,0 +1,89 @@
+package mfa
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// webhookTimeout bounds each call to the authenticator, since a login is waiting on it.
+const webhookTimeout = 10 * time.Second
+
+// WebhookProvider is a PushProvider for a custom authenticator service. It POSTs login details to
+// the URL and expects `{"id": "..."}` in return, or 204 No Content when the account has not
+// enrolled. It then polls `GET <url>/<id>` for `{"status": "pending|approved|denied"}`.
+type WebhookProvider struct {
+	URL    *url.URL
+	client *http.Client
+}
+
+// NewWebhookProvider returns a PushProvider that calls a custom authenticator service.
+func NewWebhookProvider(u *url.URL) *WebhookProvider {
+	return &WebhookProvider{
+		URL:    u,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (p *WebhookProvider) Request(req PushRequest) (string, error) {
+	res, err := p.client.PostForm(p.URL.String(), url.Values{
+		"account_id": []string{strconv.Itoa(req.AccountID)},
+		"username":   []string{req.Username},
+		"ip":         []string{req.IP},
+		"user_agent": []string{req.UserAgent},
+		"step_up":    []string{strconv.FormatBool(req.StepUp)},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "PostForm")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNoContent {
+		return "", nil
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return "", fmt.Errorf("unexpected status: %v", res.StatusCode)
+	}
+
+	var body struct {
+		ID string `json:"id"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return "", errors.Wrap(err, "Decode")
+	}
+	return body.ID, nil
+}
+
+func (p *WebhookProvider) Status(id string) (string, error) {
+	statusURL := *p.URL
+	statusURL.Path = statusURL.Path + "/" + id
+	res, err := p.client.Get(statusURL.String())
+	if err != nil {
+		return "", errors.Wrap(err, "Get")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status: %v", res.StatusCode)
+	}
+
+	var body struct {
+		Status string `json:"status"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return "", errors.Wrap(err, "Decode")
+	}
+	switch body.Status {
+	case StatusPending, StatusApproved, StatusDenied:
+		return body.Status, nil
+	default:
+		return "", fmt.Errorf("unknown status: %v", body.Status)
+	}
+}
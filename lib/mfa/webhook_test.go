@@ -0,0 +1,74 @@
+package mfa_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/keratin/authn-server/lib/mfa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "pass", pass)
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/push":
+			r.ParseForm()
+			if r.PostForm.Get("username") == "unenrolled@test.com" {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			assert.Equal(t, "1234", r.PostForm.Get("account_id"))
+			assert.Equal(t, "203.0.113.1", r.PostForm.Get("ip"))
+			assert.Equal(t, "false", r.PostForm.Get("step_up"))
+			json.NewEncoder(w).Encode(map[string]string{"id": "tx 1"})
+		case r.Method == http.MethodGet && r.URL.Path == "/push/tx 1":
+			json.NewEncoder(w).Encode(map[string]string{"status": "approved"})
+		case r.Method == http.MethodGet && r.URL.Path == "/push/bogus":
+			json.NewEncoder(w).Encode(map[string]string{"status": "maybe"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL + "/push")
+	require.NoError(t, err)
+	u.User = url.UserPassword("user", "pass")
+	provider := mfa.NewWebhookProvider(u)
+
+	t.Run("requesting approval", func(t *testing.T) {
+		id, err := provider.Request(mfa.PushRequest{AccountID: 1234, Username: "enrolled@test.com", IP: "203.0.113.1"})
+		require.NoError(t, err)
+		assert.Equal(t, "tx 1", id)
+	})
+
+	t.Run("requesting approval for an unenrolled account", func(t *testing.T) {
+		id, err := provider.Request(mfa.PushRequest{AccountID: 5678, Username: "unenrolled@test.com"})
+		require.NoError(t, err)
+		assert.Empty(t, id)
+	})
+
+	t.Run("checking status", func(t *testing.T) {
+		status, err := provider.Status("tx 1")
+		require.NoError(t, err)
+		assert.Equal(t, mfa.StatusApproved, status)
+	})
+
+	t.Run("checking an unknown request", func(t *testing.T) {
+		_, err := provider.Status("unknown")
+		assert.Error(t, err)
+	})
+
+	t.Run("checking an unknown status", func(t *testing.T) {
+		_, err := provider.Status("bogus")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,61 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"golang.org/x/oauth2"
+)
+
+// NewDiscordProvider returns a AuthN integration for Discord OAuth
+func NewDiscordProvider(credentials *Credentials) *Provider {
+	config := &oauth2.Config{
+		ClientID:     credentials.ID,
+		ClientSecret: credentials.Secret,
+		Scopes:       []string{"identify", "email"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://discord.com/api/oauth2/authorize",
+			TokenURL: "https://discord.com/api/oauth2/token",
+		},
+	}
+
+	return &Provider{
+		config: config,
+		UserInfo: func(t *oauth2.Token) (*UserInfo, error) {
+			client := config.Client(context.TODO(), t)
+			resp, err := client.Get("https://discord.com/api/users/@me")
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return nil, err
+			}
+
+			var user struct {
+				ID       string `json:"id"`
+				Email    string `json:"email"`
+				Verified bool   `json:"verified"`
+			}
+			err = json.Unmarshal(body, &user)
+			if err != nil {
+				return nil, err
+			}
+
+			// Discord does not require users to verify their email address
+			email := ""
+			if user.Verified {
+				email = user.Email
+			}
+
+			return &UserInfo{
+				ID:      user.ID,
+				Email:   email,
+				Profile: ParseProfile(body),
+			}, nil
+		},
+	}
+}
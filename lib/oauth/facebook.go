@@ -2,13 +2,21 @@ package oauth
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io/ioutil"
+	"net/url"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/facebook"
 )
 
+// facebookGraphURL pins a Graph API version, since unversioned requests are served by the oldest
+// version still available to the app and may change without notice.
+const facebookGraphURL = "https://graph.facebook.com/v3.3"
+
 // NewFacebookProvider returns a AuthN integration for Facebook OAuth
 func NewFacebookProvider(credentials *Credentials) *Provider {
 	config := &oauth2.Config{
@@ -21,8 +29,17 @@ func NewFacebookProvider(credentials *Credentials) *Provider {
 	return &Provider{
 		config: config,
 		UserInfo: func(t *oauth2.Token) (*UserInfo, error) {
+			// Apps may require an appsecret_proof with every Graph API call, which proves that the
+			// access token is being used by the app it was issued to.
+			mac := hmac.New(sha256.New, []byte(credentials.Secret))
+			mac.Write([]byte(t.AccessToken))
+			query := url.Values{
+				"fields":          []string{"id,email,name,picture"},
+				"appsecret_proof": []string{hex.EncodeToString(mac.Sum(nil))},
+			}
+
 			client := config.Client(context.TODO(), t)
-			resp, err := client.Get("https://graph.facebook.com/me?fields=id,email")
+			resp, err := client.Get(facebookGraphURL + "/me?" + query.Encode())
 			if err != nil {
 				return nil, err
 			}
@@ -33,8 +50,10 @@ func NewFacebookProvider(credentials *Credentials) *Provider {
 				return nil, err
 			}
 
+			// email is missing when the user has no confirmed address or declines the permission
 			var user UserInfo
 			err = json.Unmarshal(body, &user)
+			user.Profile = ParseProfile(body)
 			return &user, err
 		},
 	}
@@ -0,0 +1,65 @@
+package oauth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keratin/authn-server/lib/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestNewGenericProvider(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{
+				"authorization_endpoint": server.URL + "/authorize",
+				"token_endpoint":         server.URL + "/token",
+				"userinfo_endpoint":      server.URL + "/userinfo",
+			})
+		case "/userinfo":
+			assert.Equal(t, "Bearer ACCESS", r.Header.Get("Authorization"))
+			json.NewEncoder(w).Encode(map[string]string{
+				"sub":   "12345",
+				"email": "user@example.com",
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("with a discovery document", func(t *testing.T) {
+		provider, err := oauth.NewGenericProvider(&oauth.GenericSettings{
+			Credentials:  oauth.Credentials{ID: "id", Secret: "secret"},
+			Scopes:       []string{"openid", "email"},
+			DiscoveryURL: server.URL + "/.well-known/openid-configuration",
+		})
+		require.NoError(t, err)
+
+		config := provider.Config("https://authn.example.com/oauth/example/return")
+		assert.Equal(t, server.URL+"/authorize", config.Endpoint.AuthURL)
+		assert.Equal(t, server.URL+"/token", config.Endpoint.TokenURL)
+		assert.Equal(t, []string{"openid", "email"}, config.Scopes)
+
+		user, err := provider.UserInfo(&oauth2.Token{AccessToken: "ACCESS", TokenType: "Bearer"})
+		require.NoError(t, err)
+		assert.Equal(t, &oauth.UserInfo{
+			ID:      "12345",
+			Email:   "user@example.com",
+			Profile: map[string]string{"sub": "12345", "email": "user@example.com"},
+		}, user)
+	})
+
+	t.Run("without a discovery document", func(t *testing.T) {
+		_, err := oauth.NewGenericProvider(&oauth.GenericSettings{
+			DiscoveryURL: server.URL + "/missing",
+		})
+		assert.Error(t, err)
+	})
+}
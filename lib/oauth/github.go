@@ -48,17 +48,17 @@ func NewGitHubProvider(credentials *Credentials) *Provider {
 		return "", nil
 	}
 
-	getID := func(t *oauth2.Token) (string, error) {
+	getUser := func(t *oauth2.Token) (string, map[string]string, error) {
 		client := config.Client(context.TODO(), t)
 		resp, err := client.Get("https://api.github.com/user")
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 		defer resp.Body.Close()
 
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 
 		var user struct {
@@ -66,15 +66,15 @@ func NewGitHubProvider(credentials *Credentials) *Provider {
 		}
 		err = json.Unmarshal(body, &user)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
-		return strconv.Itoa(user.ID), nil
+		return strconv.Itoa(user.ID), ParseProfile(body), nil
 	}
 
 	return &Provider{
 		config: config,
 		UserInfo: func(t *oauth2.Token) (*UserInfo, error) {
-			id, err := getID(t)
+			id, profile, err := getUser(t)
 			if err != nil {
 				return nil, err
 			}
@@ -85,8 +85,9 @@ func NewGitHubProvider(credentials *Credentials) *Provider {
 			}
 
 			return &UserInfo{
-				ID:    id,
-				Email: email,
+				ID:      id,
+				Email:   email,
+				Profile: profile,
 			}, nil
 		},
 	}
@@ -35,6 +35,7 @@ func NewGoogleProvider(credentials *Credentials) *Provider {
 
 			var user UserInfo
 			err = json.Unmarshal(body, &user)
+			user.Profile = ParseProfile(body)
 			return &user, err
 		},
 	}
@@ -0,0 +1,61 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"golang.org/x/oauth2"
+)
+
+// NewLinkedInProvider returns a AuthN integration for LinkedIn OAuth, using the "Sign In with
+// LinkedIn using OpenID Connect" product.
+func NewLinkedInProvider(credentials *Credentials) *Provider {
+	config := &oauth2.Config{
+		ClientID:     credentials.ID,
+		ClientSecret: credentials.Secret,
+		Scopes:       []string{"openid", "email"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://www.linkedin.com/oauth/v2/authorization",
+			TokenURL: "https://www.linkedin.com/oauth/v2/accessToken",
+		},
+	}
+
+	return &Provider{
+		config: config,
+		UserInfo: func(t *oauth2.Token) (*UserInfo, error) {
+			client := config.Client(context.TODO(), t)
+			resp, err := client.Get("https://api.linkedin.com/v2/userinfo")
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return nil, err
+			}
+
+			var claims struct {
+				Sub           string `json:"sub"`
+				Email         string `json:"email"`
+				EmailVerified bool   `json:"email_verified"`
+			}
+			err = json.Unmarshal(body, &claims)
+			if err != nil {
+				return nil, err
+			}
+
+			email := ""
+			if claims.EmailVerified {
+				email = claims.Email
+			}
+
+			return &UserInfo{
+				ID:      claims.Sub,
+				Email:   email,
+				Profile: ParseProfile(body),
+			}, nil
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// NewMicrosoftProvider returns a AuthN integration for Microsoft OAuth through the Microsoft
+// identity platform (Azure AD v2). The tenant may be a directory's ID or domain, or one of
+// `common`, `organizations`, or `consumers`.
+func NewMicrosoftProvider(credentials *Credentials, tenant string) *Provider {
+	config := &oauth2.Config{
+		ClientID:     credentials.ID,
+		ClientSecret: credentials.Secret,
+		Scopes:       []string{"openid", "email", "User.Read"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://login.microsoftonline.com/" + tenant + "/oauth2/v2.0/authorize",
+			TokenURL: "https://login.microsoftonline.com/" + tenant + "/oauth2/v2.0/token",
+		},
+	}
+
+	return &Provider{
+		config: config,
+		UserInfo: func(t *oauth2.Token) (*UserInfo, error) {
+			client := config.Client(context.TODO(), t)
+			resp, err := client.Get("https://graph.microsoft.com/v1.0/me?$select=id,mail,userPrincipalName,displayName,preferredLanguage")
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return nil, err
+			}
+
+			var user struct {
+				ID                string `json:"id"`
+				Mail              string `json:"mail"`
+				UserPrincipalName string `json:"userPrincipalName"`
+			}
+			err = json.Unmarshal(body, &user)
+			if err != nil {
+				return nil, err
+			}
+
+			// personal accounts and some work accounts have no mail, but sign in with an email
+			// address as their principal name
+			email := user.Mail
+			if email == "" && strings.Contains(user.UserPrincipalName, "@") {
+				email = user.UserPrincipalName
+			}
+
+			return &UserInfo{
+				ID:      user.ID,
+				Email:   email,
+				Profile: ParseProfile(body),
+			}, nil
+		},
+	}
+}
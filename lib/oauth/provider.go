@@ -1,6 +1,9 @@
 package oauth
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"golang.org/x/oauth2"
 )
 
@@ -8,12 +11,17 @@ import (
 type Provider struct {
 	config   *oauth2.Config
 	UserInfo UserInfoFetcher
+	// PKCE is required by providers that expect a code challenge even from confidential clients
+	PKCE bool
 }
 
 // UserInfo is the minimum necessary needed from an OAuth Provider to connect with AuthN accounts
 type UserInfo struct {
 	ID    string `json:"id"`
 	Email string `json:"email"`
+	// Profile holds the provider's profile fields, for mapping into account metadata. Nested fields
+	// are named with dots, e.g. `picture.data.url`.
+	Profile map[string]string `json:"-"`
 }
 
 // UserInfoFetcher is the function signature for fetching UserInfo from a Provider
@@ -21,7 +29,7 @@ type UserInfoFetcher = func(t *oauth2.Token) (*UserInfo, error)
 
 // NewProvider returns a properly configured Provider
 func NewProvider(config *oauth2.Config, userInfo UserInfoFetcher) *Provider {
-	return &Provider{config, userInfo}
+	return &Provider{config: config, UserInfo: userInfo}
 }
 
 // Config returns a complete oauth2.Config after injecting the RedirectURL
@@ -34,3 +42,27 @@ func (p *Provider) Config(redirectURL string) *oauth2.Config {
 		RedirectURL:  redirectURL,
 	}
 }
+
+// ParseProfile flattens a JSON profile into named string fields. Arrays are ignored.
+func ParseProfile(body []byte) map[string]string {
+	var profile map[string]interface{}
+	if err := json.Unmarshal(body, &profile); err != nil {
+		return nil
+	}
+	fields := map[string]string{}
+	flattenProfile("", profile, fields)
+	return fields
+}
+
+func flattenProfile(prefix string, profile map[string]interface{}, fields map[string]string) {
+	for name, val := range profile {
+		switch v := val.(type) {
+		case string:
+			fields[prefix+name] = v
+		case float64, bool:
+			fields[prefix+name] = fmt.Sprint(v)
+		case map[string]interface{}:
+			flattenProfile(prefix+name+".", v, fields)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"golang.org/x/oauth2"
+)
+
+// NewTwitterProvider returns a AuthN integration for Twitter (X) OAuth 2.0, which requires PKCE.
+//
+// Twitter does not share email addresses through OAuth 2.0, so a Twitter identity may only be
+// linked to an existing account.
+func NewTwitterProvider(credentials *Credentials) *Provider {
+	config := &oauth2.Config{
+		ClientID:     credentials.ID,
+		ClientSecret: credentials.Secret,
+		Scopes:       []string{"users.read", "tweet.read"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://twitter.com/i/oauth2/authorize",
+			TokenURL: "https://api.twitter.com/2/oauth2/token",
+		},
+	}
+
+	return &Provider{
+		config: config,
+		PKCE:   true,
+		UserInfo: func(t *oauth2.Token) (*UserInfo, error) {
+			client := config.Client(context.TODO(), t)
+			resp, err := client.Get("https://api.twitter.com/2/users/me?user.fields=profile_image_url")
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return nil, err
+			}
+
+			var user struct {
+				Data struct {
+					ID string `json:"id"`
+				} `json:"data"`
+			}
+			err = json.Unmarshal(body, &user)
+			return &UserInfo{ID: user.Data.ID, Profile: ParseProfile(body)}, err
+		},
+	}
+}
@@ -59,6 +59,17 @@ func (c *Client) WithCookie(cookie *http.Cookie) *Client {
 	}
 }
 
+// WithHeader will set a header on a client's requests.
+func (c *Client) WithHeader(name string, value string) *Client {
+	return &Client{
+		c.BaseURL,
+		append(c.Modifiers, func(req *http.Request) *http.Request {
+			req.Header.Set(name, value)
+			return req
+		}),
+	}
+}
+
 // Authenticated will inject HTTP Basic Auth configuration into a client's requests.
 func (c *Client) Authenticated(username string, password string) *Client {
 	return &Client{
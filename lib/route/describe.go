@@ -0,0 +1,50 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// Security schemes reported by Describe.
+const (
+	SecurityNone      = "none"
+	SecurityOrigin    = "origin"
+	SecurityBasicAuth = "basic"
+)
+
+// Description is a read-only summary of a HandledRoute, suitable for generating documentation
+// from the same route table that is attached to the router.
+type Description struct {
+	Method   string
+	Path     string
+	Security string
+}
+
+// Describe summarizes a HandledRoute. The security scheme is discovered by sending an anonymous
+// request through the route's SecurityHandler (never its handler): an Unsecured route lets it
+// through, BasicAuthSecurity challenges it, and OriginSecurity rejects it.
+func (r *HandledRoute) Describe() Description {
+	return Description{
+		Method:   r.verb,
+		Path:     r.tpl,
+		Security: probeSecurity(r.security),
+	}
+}
+
+func probeSecurity(security SecurityHandler) string {
+	passed := false
+	probe := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		passed = true
+	})
+
+	res := httptest.NewRecorder()
+	security(probe).ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+
+	if passed {
+		return SecurityNone
+	}
+	if res.Header().Get("WWW-Authenticate") != "" {
+		return SecurityBasicAuth
+	}
+	return SecurityOrigin
+}
@@ -0,0 +1,37 @@
+package route_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribe(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called")
+	})
+
+	testCases := []struct {
+		route    *route.HandledRoute
+		expected route.Description
+	}{
+		{
+			route.Get("/health").SecuredWith(route.Unsecured()).Handle(handler),
+			route.Description{"GET", "/health", route.SecurityNone},
+		},
+		{
+			route.Post("/session").SecuredWith(route.OriginSecurity(nil)).Handle(handler),
+			route.Description{"POST", "/session", route.SecurityOrigin},
+		},
+		{
+			route.Patch("/accounts/{id:[0-9]+}").SecuredWith(route.BasicAuthSecurity("u", "p", "Realm")).Handle(handler),
+			route.Description{"PATCH", "/accounts/{id:[0-9]+}", route.SecurityBasicAuth},
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, tc.route.Describe())
+	}
+}
@@ -6,22 +6,35 @@ import (
 )
 
 // Domain is subset of url.URL that enables a fuzzy match. A Domain must always have a Hostname, and
-// may also have a Port.
+// may also have a Scheme and a Port. A Hostname that begins with "*." is a wildcard that matches any
+// subdomain.
 type Domain struct {
+	Scheme   string
 	Hostname string
 	Port     string
 }
 
-// ParseDomain will parse a string containing either host or host:port and return a Domain.
+// ParseDomain will parse a string containing host, host:port, scheme://host, or scheme://host:port
+// and return a Domain. The host may be a wildcard like *.example.com.
 func ParseDomain(domain string) Domain {
+	domain = strings.TrimSpace(domain)
+	scheme := ""
+	if pieces := strings.SplitN(domain, "://", 2); len(pieces) == 2 {
+		scheme = strings.ToLower(pieces[0])
+		domain = pieces[1]
+	}
+	domain = strings.TrimSuffix(domain, "/")
+
 	pieces := strings.SplitN(domain, ":", 2)
 	if len(pieces) == 1 {
 		pieces = append(pieces, "")
 	}
-	return Domain{Hostname: pieces[0], Port: pieces[1]}
+	return Domain{Scheme: scheme, Hostname: strings.ToLower(pieces[0]), Port: pieces[1]}
 }
 
-// FindDomain returns a matching domain if the given string is a URL that matches
+// FindDomain returns a matching domain if the given string is a URL that matches. When the match is
+// a wildcard, the returned Domain has the URL's hostname instead, so that it may be used as an
+// audience.
 func FindDomain(str string, domains []Domain) *Domain {
 	originURL, err := url.Parse(str)
 	if err != nil {
@@ -30,18 +43,32 @@ func FindDomain(str string, domains []Domain) *Domain {
 
 	for _, d := range domains {
 		if d.Matches(originURL) {
+			if d.IsWildcard() {
+				d.Hostname = strings.ToLower(originURL.Hostname())
+			}
 			return &d
 		}
 	}
 	return nil
 }
 
-// Matches will compare the Domain against a given URL. The Hostname must always be a perfect match,
-// and if Port is specified (non-blank) then it must also match. The common ports 80 and 443 will be
-// satisfied by http and https schemes, respectively.
+// Matches will compare the Domain against a given URL. The Hostname must match, either exactly or
+// as a wildcard. If Scheme is specified, it must match. If Port is specified (non-blank) then it
+// must also match. The common ports 80 and 443 will be satisfied by http and https schemes,
+// respectively.
 func (d *Domain) Matches(origin *url.URL) bool {
 	// hostname must always match.
-	if d.Hostname != origin.Hostname() {
+	hostname := strings.ToLower(origin.Hostname())
+	if d.IsWildcard() {
+		if !strings.HasSuffix(hostname, d.Hostname[1:]) {
+			return false
+		}
+	} else if d.Hostname != hostname {
+		return false
+	}
+
+	// if scheme is specified, it must match.
+	if d.Scheme != "" && d.Scheme != origin.Scheme {
 		return false
 	}
 
@@ -62,6 +89,24 @@ func (d *Domain) Matches(origin *url.URL) bool {
 	return false
 }
 
+// MatchesAudience reports whether an audience, which is the String of a Domain that was found by
+// FindDomain, came from this Domain.
+func (d *Domain) MatchesAudience(audience string) bool {
+	other := ParseDomain(audience)
+	if other.Port != d.Port {
+		return false
+	}
+	if d.IsWildcard() {
+		return strings.HasSuffix(other.Hostname, d.Hostname[1:])
+	}
+	return other.Hostname == d.Hostname
+}
+
+// IsWildcard reports whether the Domain matches subdomains rather than a single host.
+func (d *Domain) IsWildcard() bool {
+	return strings.HasPrefix(d.Hostname, "*.")
+}
+
 // String converts a Domain back into a host or host:port string.
 func (d *Domain) String() string {
 	if d.Port == "" {
@@ -72,6 +117,12 @@ func (d *Domain) String() string {
 
 // URL converts a Domain into a URL
 func (d *Domain) URL() url.URL {
+	if d.Scheme != "" {
+		if (d.Scheme == "http" && d.Port == "80") || (d.Scheme == "https" && d.Port == "443") {
+			return url.URL{Scheme: d.Scheme, Host: d.Hostname}
+		}
+		return url.URL{Scheme: d.Scheme, Host: d.String()}
+	}
 	if d.Port == "80" {
 		return url.URL{Scheme: "http", Host: d.Hostname}
 	}
@@ -21,6 +21,21 @@ func TestDomain(t *testing.T) {
 
 		assert.Equal(t, "host", route.ParseDomain(hp).Hostname)
 		assert.Equal(t, "port", route.ParseDomain(hp).Port)
+
+		testCases := []struct {
+			str    string
+			domain route.Domain
+		}{
+			{" example.com ", route.Domain{Hostname: "example.com"}},
+			{"Example.COM", route.Domain{Hostname: "example.com"}},
+			{"https://example.com", route.Domain{Scheme: "https", Hostname: "example.com"}},
+			{"http://localhost:3000/", route.Domain{Scheme: "http", Hostname: "localhost", Port: "3000"}},
+			{"*.example.com", route.Domain{Hostname: "*.example.com"}},
+			{"https://*.example.com:8443", route.Domain{Scheme: "https", Hostname: "*.example.com", Port: "8443"}},
+		}
+		for _, tc := range testCases {
+			assert.Equal(t, tc.domain, route.ParseDomain(tc.str), tc.str)
+		}
 	})
 
 	t.Run("Matches", func(t *testing.T) {
@@ -41,6 +56,17 @@ func TestDomain(t *testing.T) {
 			{"example.com:443", "https://example.com", true},
 			{"example.com:443", "http://example.com", false},
 			{"example.com:443", "https://example.com:3000", false},
+			{"example.com", "http://EXAMPLE.com", true},
+			{"https://example.com", "https://example.com", true},
+			{"https://example.com", "https://example.com:8443", true},
+			{"https://example.com", "http://example.com", false},
+			{"*.example.com", "https://app.example.com", true},
+			{"*.example.com", "https://a.b.example.com", true},
+			{"*.example.com", "https://example.com", false},
+			{"*.example.com", "https://app.badexample.com", false},
+			{"*.example.com", "https://example.com.evil.com", false},
+			{"https://*.example.com:443", "https://app.example.com", true},
+			{"https://*.example.com:443", "http://app.example.com", false},
 		}
 
 		for _, tc := range testCases {
@@ -67,6 +93,9 @@ func TestDomain(t *testing.T) {
 			{route.Domain{Hostname: "example.com", Port: "8080"}, "http://example.com:8080"},
 			{route.Domain{Hostname: "localhost", Port: "3000"}, "http://localhost:3000"},
 			{route.Domain{Hostname: "example.com", Port: "443"}, "https://example.com"},
+			{route.Domain{Scheme: "https", Hostname: "example.com"}, "https://example.com"},
+			{route.Domain{Scheme: "https", Hostname: "example.com", Port: "443"}, "https://example.com"},
+			{route.Domain{Scheme: "https", Hostname: "example.com", Port: "8443"}, "https://example.com:8443"},
 		}
 
 		for _, tc := range testCases {
@@ -82,5 +111,10 @@ func TestDomain(t *testing.T) {
 		assert.Nil(t, route.FindDomain("http://example.com", domains))
 		assert.Nil(t, route.FindDomain("https://example.com:9100", domains))
 		assert.Nil(t, route.FindDomain("https://www.example.com", domains))
+
+		wildcards := []route.Domain{route.ParseDomain("*.example.com")}
+		assert.Equal(t, route.Domain{Hostname: "app.example.com"}, *route.FindDomain("https://app.example.com", wildcards))
+		assert.Equal(t, "*.example.com", wildcards[0].Hostname)
+		assert.Nil(t, route.FindDomain("https://example.com", wildcards))
 	})
 }
@@ -7,6 +7,7 @@ package route
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -55,15 +56,36 @@ type SecuredRoute struct {
 
 // Handle registers a HandlerFunc. The route may now be `Attach`d.
 func (r *SecuredRoute) Handle(h http.Handler) *HandledRoute {
-	return &HandledRoute{r, h}
+	return &HandledRoute{SecuredRoute: r, handler: h}
 }
 
 // HandledRoute is a fully defined route. It is ready to be `Attach`d.
 type HandledRoute struct {
 	*SecuredRoute
 	handler http.Handler
+	timeout *time.Duration
 }
 
+// WithTimeout sets a deadline for the handler, after which the client receives a 503. A timeout of
+// 0 exempts a long-running handler (e.g. a profile) from any default given to DefaultTimeout.
+func (r *HandledRoute) WithTimeout(d time.Duration) *HandledRoute {
+	r.timeout = &d
+	return r
+}
+
+// DefaultTimeout sets a deadline for every route that does not have one yet.
+func DefaultTimeout(d time.Duration, routes ...*HandledRoute) []*HandledRoute {
+	for _, r := range routes {
+		if r.timeout == nil {
+			r.WithTimeout(d)
+		}
+	}
+	return routes
+}
+
+// timeoutBody is returned with the 503 when a handler misses its deadline.
+const timeoutBody = `{"errors":[{"field":"request","message":"TIMEOUT"}]}`
+
 // Attach is the adapter for adding HandledRoutes to a gorilla/mux Router.
 func Attach(router *mux.Router, pathPrefix string, routes ...*HandledRoute) {
 	for _, r := range routes {
@@ -71,6 +93,13 @@ func Attach(router *mux.Router, pathPrefix string, routes ...*HandledRoute) {
 			PathPrefix(pathPrefix).
 			Methods(r.verb).
 			Path(r.tpl).
-			Handler(instrumentRoute(r.verb+" "+r.tpl, r.security(r.handler)))
+			Handler(instrumentRoute(r.verb+" "+r.tpl, r.security(r.deadline(r.handler))))
+	}
+}
+
+func (r *HandledRoute) deadline(h http.Handler) http.Handler {
+	if r.timeout == nil || *r.timeout <= 0 {
+		return h
 	}
+	return http.TimeoutHandler(h, *r.timeout, timeoutBody)
 }
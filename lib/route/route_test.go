@@ -2,9 +2,13 @@ package route_test
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/keratin/authn-server/lib/route"
+	"github.com/stretchr/testify/assert"
 )
 
 func ExampleRoute() {
@@ -24,3 +28,32 @@ func ExampleRoute() {
 			Handle(healthHandler),
 	)
 }
+
+func TestTimeout(t *testing.T) {
+	slowHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("slow"))
+	})
+
+	r := mux.NewRouter()
+	route.Attach(r, "/", route.DefaultTimeout(10*time.Millisecond,
+		route.Get("/default").
+			SecuredWith(route.Unsecured()).
+			Handle(slowHandler),
+
+		route.Get("/exempt").
+			SecuredWith(route.Unsecured()).
+			Handle(slowHandler).
+			WithTimeout(0),
+	)...)
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest("GET", "/default", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Contains(t, res.Body.String(), "TIMEOUT")
+
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest("GET", "/exempt", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "slow", res.Body.String())
+}
@@ -1,31 +1,59 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"path"
+	"strings"
 
-	"github.com/keratin/authn-server/api"
 	"github.com/keratin/authn-server/config"
 	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/server"
+	"github.com/keratin/authn-server/services"
 )
 
 var VERSION string
 
 func main() {
 	var cmd string
-	if len(os.Args) == 1 {
+	args := []string{}
+	if len(os.Args) == 1 || strings.HasPrefix(os.Args[1], "-") {
+		// flags without a command are for the server, e.g. `authn --doctor`
 		cmd = "server"
+		args = os.Args[1:]
 	} else {
 		cmd = os.Args[1]
+		args = os.Args[2:]
 	}
 
 	if cmd == "server" {
-		serve()
+		serve(args)
 	} else if cmd == "migrate" {
 		migrate()
+	} else if cmd == "create-account" {
+		createAccount(args)
+	} else if cmd == "import-accounts" {
+		importAccounts(args)
+	} else if cmd == "export" {
+		exportAccounts(args)
+	} else if cmd == "seed" {
+		seed()
+	} else if cmd == "lock" {
+		lockAccount(args)
+	} else if cmd == "reencrypt" {
+		reencrypt()
+	} else if cmd == "username-collisions" {
+		usernameCollisions()
+	} else if cmd == "rotate-keys" {
+		rotateKeys()
+	} else if cmd == "gen-secret" {
+		genSecret()
+	} else if cmd == "keygen" {
+		keygen(args)
+	} else if cmd == "check-config" {
+		checkConfig()
 	} else {
 		os.Stderr.WriteString(fmt.Sprintf("unexpected invocation\n"))
 		usage()
@@ -33,25 +61,44 @@ func main() {
 	}
 }
 
-func serve() {
+func serve(args []string) {
+	flags := flag.NewFlagSet("server", flag.ExitOnError)
+	doctor := flags.Bool("doctor", false, "check the environment and print a report before serving")
+	flags.Parse(args)
+
+	cfg := config.ReadEnv()
+	if *doctor {
+		report := server.Diagnose(cfg)
+		printDiagnosis(report)
+		if !server.Healthy(report) {
+			os.Exit(1)
+		}
+	}
+
 	// set up connections and configuration
-	app, err := api.NewApp()
+	srv, err := server.New(cfg)
 	if err != nil {
 		panic(err)
 	}
+	app := srv.App
 
 	fmt.Println(fmt.Sprintf("~*~ Keratin AuthN v%s ~*~", VERSION))
 	fmt.Println(fmt.Sprintf("AUTHN_URL: %s", app.Config.AuthNURL))
 	fmt.Println(fmt.Sprintf("PORT: %d", app.Config.ServerPort))
 
+	if app.Config.DevSeed {
+		accounts, err := services.AccountSeeder(app.AccountStore, app.Config)
+		if err != nil {
+			panic(err)
+		}
+		printSeeds(accounts)
+	}
+
 	if app.Config.PublicPort != 0 {
-		go func() {
-			fmt.Println(fmt.Sprintf("PUBLIC_PORT: %d", app.Config.PublicPort))
-			log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", app.Config.PublicPort), publicRouter(app)))
-		}()
+		fmt.Println(fmt.Sprintf("PUBLIC_PORT: %d", app.Config.PublicPort))
 	}
 
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", app.Config.ServerPort), router(app)))
+	log.Fatal(srv.ListenAndServe())
 }
 
 func migrate() {
@@ -60,6 +107,7 @@ func migrate() {
 	err := data.MigrateDB(cfg.DatabaseURL)
 	if err != nil {
 		fmt.Println(err)
+		os.Exit(1)
 	} else {
 		fmt.Println("Migrations complete.")
 	}
@@ -69,7 +117,18 @@ func usage() {
 	exe := path.Base(os.Args[0])
 	fmt.Println(fmt.Sprintf(`
 Usage:
-%s server  - run the server (default)
-%s migrate - run migrations
-`, exe, exe))
+%s server         - run the server (default). --doctor checks the environment first
+%s migrate        - run migrations
+%s create-account - create an account (-username, -password, -locked)
+%s import-accounts - import an Auth0, Firebase, or Devise export (-from, -file)
+%s export         - stream all accounts to stdout (-format jsonl|csv, -include-oauth, -include-metadata, -include-hashes)
+%s seed           - create test accounts for local development
+%s lock <id>      - lock an account and revoke its sessions
+%s reencrypt      - re-encrypt account metadata with the newest FIELD_ENCRYPTION_KEYS version
+%s username-collisions - list accounts with usernames that differ only by case
+%s rotate-keys    - generate the next identity signing key ahead of rotation
+%s gen-secret     - print a new random SECRET_KEY_BASE
+%s keygen         - print a new SECRET_KEY_BASE and RSA_PRIVATE_KEY (-format, -bits, -vault)
+%s check-config   - verify configuration and database connections
+`, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe))
 }
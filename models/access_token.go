@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// AccessToken is the metadata behind an opaque access token. The token itself is only known to
+// the client that received it.
+type AccessToken struct {
+	AccountID int          `json:"account_id"`
+	Session   RefreshToken `json:"session"`
+	Audience  string       `json:"audience"`
+	Scope     string       `json:"scope,omitempty"`
+	Jkt       string       `json:"jkt,omitempty"`
+	IssuedAt  time.Time    `json:"issued_at"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
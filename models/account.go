@@ -12,8 +12,25 @@ type Account struct {
 	CreatedAt          time.Time  `db:"created_at"`
 	UpdatedAt          time.Time  `db:"updated_at"`
 	DeletedAt          *time.Time `db:"deleted_at"`
+	ArchiveAt          *time.Time `db:"archive_at"`
+	ExpiresAt          *time.Time `db:"expires_at"`
+	ThrottledUntil     *time.Time `db:"throttled_until"`
+	ExternalID         *string    `db:"external_id"`
+	LegalHold          bool       `db:"legal_hold"`
+	Version            int        `db:"lock_version"`
 }
 
 func (a Account) Archived() bool {
 	return a.DeletedAt != nil
 }
+
+// Throttled is true while a temporary lock from repeated login failures is in effect. Unlike
+// Locked, it expires on its own.
+func (a Account) Throttled() bool {
+	return a.ThrottledUntil != nil && a.ThrottledUntil.After(time.Now())
+}
+
+// Expired is true for temporary accounts whose expiry has passed.
+func (a Account) Expired() bool {
+	return a.ExpiresAt != nil && !a.ExpiresAt.After(time.Now())
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type AccountNote struct {
+	ID        int       `json:"id"`
+	AccountID int       `db:"account_id" json:"-"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scopes that may be granted to an APIKey.
+const (
+	ScopeReadOnly     = "read-only"
+	ScopeAccountAdmin = "account-admin"
+	ScopeStats        = "stats"
+	ScopeAdmin        = "admin"
+)
+
+// APIKeyScopes are every scope that may be granted to an APIKey.
+var APIKeyScopes = []string{ScopeReadOnly, ScopeAccountAdmin, ScopeStats, ScopeAdmin}
+
+// APIKey authenticates a backend service on the private API with a limited set of scopes. Only a
+// hash of the secret is stored.
+type APIKey struct {
+	ID         int
+	Name       string
+	Scopes     string
+	SecretHash string     `db:"secret_hash"`
+	CreatedAt  time.Time  `db:"created_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+// APIKeyUsernamePrefix distinguishes API keys from the shared HTTP_AUTH_USERNAME.
+const APIKeyUsernamePrefix = "key_"
+
+// Username is presented with the secret as Basic Auth credentials.
+func (k *APIKey) Username() string {
+	return fmt.Sprintf("%s%d", APIKeyUsernamePrefix, k.ID)
+}
+
+// ScopeList splits the space-delimited Scopes.
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// Allows reports whether the key grants a scope. The admin scope grants every scope, and
+// account-admin grants read-only.
+func (k *APIKey) Allows(scope string) bool {
+	for _, granted := range k.ScopeList() {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+		if granted == ScopeAccountAdmin && scope == ScopeReadOnly {
+			return true
+		}
+	}
+	return false
+}
+
+// Revoked reports whether the key may no longer be used.
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/keratin/authn-server/lib/events"
+)
+
+// AuditEntry is an event with the cursor that identifies its position in the audit log. Entries
+// are chained: each Hash covers the PrevHash of the entry before it. Entries recorded before the
+// log was chained have no hashes.
+type AuditEntry struct {
+	Cursor   string
+	Event    events.Event
+	PrevHash string
+	Hash     string
+}
+
+// AuditHash signs an entry's position and event together with the hash of the entry before it, so
+// that no entry may be altered, inserted, or reordered without breaking the chain.
+func AuditHash(key []byte, prevHash string, cursor string, e events.Event) (string, error) {
+	content, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(prevHash + "\n" + cursor + "\n"))
+	mac.Write(content)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Consent records an account's acceptance of a version of the terms of service, along with their
+// marketing preference at the time.
+type Consent struct {
+	ID             int       `json:"-"`
+	AccountID      int       `db:"account_id" json:"-"`
+	TermsVersion   string    `db:"terms_version" json:"terms_version"`
+	MarketingOptIn bool      `db:"marketing_opt_in" json:"marketing_opt_in"`
+	IP             string    `json:"ip"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
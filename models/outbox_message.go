@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Kinds of OutboxMessage.
+const (
+	OutboxEvent   = "event"
+	OutboxWebhook = "webhook"
+)
+
+// Statuses of an OutboxMessage. A pending message is retried until it is delivered or it runs out
+// of attempts and fails.
+const (
+	OutboxPending   = "pending"
+	OutboxDelivered = "delivered"
+	OutboxFailed    = "failed"
+)
+
+// OutboxMessage is an event or webhook that has been persisted for delivery. The payload of an
+// event is its JSON, and the payload of a webhook is its form-encoded body.
+type OutboxMessage struct {
+	ID            int
+	Kind          string
+	Destination   string
+	Payload       string
+	Status        string
+	Attempts      int
+	ResponseCode  int        `db:"response_code"`
+	LastError     string     `db:"last_error"`
+	CreatedAt     time.Time  `db:"created_at"`
+	NextAttemptAt time.Time  `db:"next_attempt_at"`
+	DeliveredAt   *time.Time `db:"delivered_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type UsernameChange struct {
+	ID        int       `json:"-"`
+	AccountID int       `db:"account_id" json:"-"`
+	From      string    `db:"from_username" json:"from"`
+	To        string    `db:"to_username" json:"to"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
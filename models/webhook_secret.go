@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// WebhookSecret signs the webhooks that are delivered to an endpoint. The secret is stored
+// encrypted. After a rotation, the previous secret remains valid until it expires, so that the
+// application can switch over without missing a webhook.
+type WebhookSecret struct {
+	ID        int
+	Endpoint  string
+	Secret    string
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt *time.Time `db:"expires_at"`
+}
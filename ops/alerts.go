@@ -0,0 +1,145 @@
+package ops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Alert describes a problem that needs someone's attention now.
+type Alert struct {
+	// Key identifies the problem, so that repeats of it may be grouped.
+	Key     string
+	Summary string
+	Details map[string]string
+}
+
+// Alerter sends alerts to a paging or chat service.
+type Alerter interface {
+	Alert(a Alert) error
+}
+
+// alertClient bounds each alert, so that a slow service does not pile up requests.
+var alertClient = &http.Client{Timeout: 10 * time.Second}
+
+// Alerts sends each alert to every Alerter in the background, and at most once per key in each
+// cooldown, so that a problem that persists does not page repeatedly. Failures are reported.
+//
+// A nil *Alerts is valid and discards every alert.
+type Alerts struct {
+	alerters []Alerter
+	reporter ErrorReporter
+	cooldown time.Duration
+
+	mutex sync.Mutex
+	sent  map[string]time.Time
+}
+
+// NewAlerts returns Alerts for the given alerters. With no alerters it returns nil.
+func NewAlerts(reporter ErrorReporter, cooldown time.Duration, alerters ...Alerter) *Alerts {
+	if len(alerters) == 0 {
+		return nil
+	}
+	return &Alerts{
+		alerters: alerters,
+		reporter: reporter,
+		cooldown: cooldown,
+		sent:     map[string]time.Time{},
+	}
+}
+
+// Alert sends the alert unless its key was sent within the cooldown. It does not wait for delivery.
+func (a *Alerts) Alert(alert Alert) {
+	if a == nil {
+		return
+	}
+
+	now := time.Now()
+	a.mutex.Lock()
+	// keys may name an account, so forget those whose cooldown has passed
+	for key, last := range a.sent {
+		if now.Sub(last) >= a.cooldown {
+			delete(a.sent, key)
+		}
+	}
+	if _, ok := a.sent[alert.Key]; ok {
+		a.mutex.Unlock()
+		return
+	}
+	a.sent[alert.Key] = now
+	a.mutex.Unlock()
+
+	for _, alerter := range a.alerters {
+		go func(alerter Alerter) {
+			if err := alerter.Alert(alert); err != nil {
+				a.reporter.ReportError(errors.Wrapf(err, "Alert %s", alert.Key))
+			}
+		}(alerter)
+	}
+}
+
+// SlackAlerter posts alerts to a Slack incoming webhook.
+type SlackAlerter struct {
+	URL string
+}
+
+func (s *SlackAlerter) Alert(a Alert) error {
+	lines := []string{":rotating_light: " + a.Summary}
+	keys := make([]string, 0, len(a.Details))
+	for k := range a.Details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("*%s*: %s", k, a.Details[k]))
+	}
+	return postAlert(s.URL, map[string]string{"text": strings.Join(lines, "\n")})
+}
+
+// PagerDutyEventsURL is the PagerDuty Events API (v2).
+var PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyAlerter triggers critical PagerDuty incidents. Repeats of an alert's key are grouped
+// into one incident until it is resolved.
+type PagerDutyAlerter struct {
+	RoutingKey string
+	// Source names this AuthN deployment.
+	Source string
+}
+
+func (p *PagerDutyAlerter) Alert(a Alert) error {
+	return postAlert(PagerDutyEventsURL, map[string]interface{}{
+		"routing_key":  p.RoutingKey,
+		"event_action": "trigger",
+		"dedup_key":    "authn:" + a.Key,
+		"payload": map[string]interface{}{
+			"summary":        a.Summary,
+			"source":         p.Source,
+			"severity":       "critical",
+			"custom_details": a.Details,
+		},
+	})
+}
+
+func postAlert(url string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err, "Marshal")
+	}
+	res, err := alertClient.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "Post")
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package ops
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type discardAlerter struct{}
+
+func (discardAlerter) Alert(a Alert) error {
+	return nil
+}
+
+func TestAlertsSweep(t *testing.T) {
+	alerts := NewAlerts(&LogReporter{}, 10*time.Millisecond, discardAlerter{})
+
+	alerts.Alert(Alert{Key: "account_locked:1"})
+	alerts.Alert(Alert{Key: "account_locked:2"})
+	assert.Len(t, alerts.sent, 2)
+
+	// each alert forgets the keys whose cooldown has passed
+	time.Sleep(20 * time.Millisecond)
+	alerts.Alert(Alert{Key: "account_locked:3"})
+	assert.Equal(t, []string{"account_locked:3"}, keys(alerts.sent))
+}
+
+func keys(sent map[string]time.Time) []string {
+	result := []string{}
+	for key := range sent {
+		result = append(result, key)
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package ops_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/ops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlerts(t *testing.T) {
+	bodies := make(chan map[string]interface{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	receive := func(t *testing.T) map[string]interface{} {
+		select {
+		case body := <-bodies:
+			return body
+		case <-time.After(time.Second):
+			t.Fatal("alert was not sent")
+			return nil
+		}
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		alerts := ops.NewAlerts(&ops.LogReporter{}, time.Minute)
+		assert.Nil(t, alerts)
+		alerts.Alert(ops.Alert{Key: "test", Summary: "Test"})
+	})
+
+	t.Run("slack", func(t *testing.T) {
+		alerts := ops.NewAlerts(&ops.LogReporter{}, time.Minute, &ops.SlackAlerter{URL: server.URL})
+		alerts.Alert(ops.Alert{Key: "test", Summary: "Something broke", Details: map[string]string{"b": "2", "a": "1"}})
+
+		body := receive(t)
+		assert.Equal(t, ":rotating_light: Something broke\n*a*: 1\n*b*: 2", body["text"])
+	})
+
+	t.Run("pagerduty", func(t *testing.T) {
+		defer func(url string) { ops.PagerDutyEventsURL = url }(ops.PagerDutyEventsURL)
+		ops.PagerDutyEventsURL = server.URL
+
+		alerts := ops.NewAlerts(&ops.LogReporter{}, time.Minute, &ops.PagerDutyAlerter{RoutingKey: "key", Source: "authn.example.com"})
+		alerts.Alert(ops.Alert{Key: "test", Summary: "Something broke"})
+
+		body := receive(t)
+		assert.Equal(t, "key", body["routing_key"])
+		assert.Equal(t, "trigger", body["event_action"])
+		assert.Equal(t, "authn:test", body["dedup_key"])
+		payload, ok := body["payload"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "Something broke", payload["summary"])
+		assert.Equal(t, "authn.example.com", payload["source"])
+		assert.Equal(t, "critical", payload["severity"])
+	})
+
+	t.Run("cooldown", func(t *testing.T) {
+		alerts := ops.NewAlerts(&ops.LogReporter{}, time.Minute, &ops.SlackAlerter{URL: server.URL})
+		alerts.Alert(ops.Alert{Key: "first", Summary: "First"})
+		alerts.Alert(ops.Alert{Key: "first", Summary: "First again"})
+		alerts.Alert(ops.Alert{Key: "second", Summary: "Second"})
+
+		texts := []interface{}{receive(t)["text"], receive(t)["text"]}
+		assert.ElementsMatch(t, []interface{}{":rotating_light: First", ":rotating_light: Second"}, texts)
+		select {
+		case body := <-bodies:
+			t.Errorf("unexpected alert: %v", body)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
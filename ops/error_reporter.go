@@ -1,9 +1,10 @@
 package ops
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
+
+	"github.com/pkg/errors"
 )
 
 // ErrorReporter is a thing that exports details about errors and panics to another service. Care
@@ -13,8 +14,15 @@ type ErrorReporter interface {
 	ReportRequestError(err error, r *http.Request)
 }
 
+// unavailable is implemented by errors that describe a temporary inability to serve a request,
+// like an overloaded worker pool. These are expected under load and are not reported.
+type unavailable interface {
+	Unavailable() bool
+}
+
 // PanicHandler returns a http.Handler that will recover any panics and report them as request
-// errors. If a panic is caught, the handler will return HTTP 500.
+// errors. If a panic is caught, the handler will return HTTP 500, or HTTP 503 if the cause was an
+// unavailable error.
 func PanicHandler(r ErrorReporter, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		defer func() {
@@ -23,6 +31,10 @@ func PanicHandler(r ErrorReporter, next http.Handler) http.Handler {
 			case nil:
 				return
 			case error:
+				if u, ok := errors.Cause(err).(unavailable); ok && u.Unavailable() {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
 				r.ReportRequestError(err, req)
 				w.WriteHeader(http.StatusInternalServerError)
 			default:
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	httpConnections = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "http_connections",
+			Help: "How many client connections are open, partitioned by port",
+		},
+		[]string{"port"},
+	)
+	httpConnectionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_connections_total",
+			Help: "How many client connections were accepted, partitioned by port",
+		},
+		[]string{"port"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(httpConnections)
+	prometheus.MustRegister(httpConnectionsTotal)
+}
+
+// connectionListener counts connections for metrics. Counting at the listener includes HTTP/2
+// connections, which leave the http.Server's own connection tracking when they are upgraded. Like
+// http.ListenAndServe, it also enables TCP keep-alives so that dead peers are eventually noticed.
+type connectionListener struct {
+	net.Listener
+	port      string
+	keepAlive bool
+}
+
+func (l connectionListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok && l.keepAlive {
+		tcp.SetKeepAlive(true)
+		tcp.SetKeepAlivePeriod(3 * time.Minute)
+	}
+
+	httpConnectionsTotal.WithLabelValues(l.port).Inc()
+	httpConnections.WithLabelValues(l.port).Inc()
+	return &countedConn{Conn: conn, port: l.port}, nil
+}
+
+type countedConn struct {
+	net.Conn
+	port   string
+	closed sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.closed.Do(func() {
+		httpConnections.WithLabelValues(c.port).Dec()
+	})
+	return c.Conn.Close()
+}
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/dynamodb"
+	"github.com/keratin/authn-server/data/mongodb"
+
+	dataRedis "github.com/keratin/authn-server/data/redis"
+)
+
+// Statuses of a Diagnosis.
+const (
+	DiagnosisOK   = "ok"
+	DiagnosisWarn = "warn"
+	DiagnosisFail = "fail"
+	DiagnosisSkip = "skip"
+)
+
+// maxClockSkew is how far the local clock may drift from Redis before tokens are likely to be
+// rejected as not yet valid or expired.
+const maxClockSkew = 30 * time.Second
+
+// Diagnosis is the result of one startup check.
+type Diagnosis struct {
+	Check  string `json:"check"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Diagnose checks the environment described by the config, so that problems are reported before
+// the server starts serving. It connects to the databases but does not modify them.
+func Diagnose(cfg *config.Config) []Diagnosis {
+	report := []Diagnosis{}
+	add := func(check string, status string, detail string, args ...interface{}) {
+		report = append(report, Diagnosis{check, status, fmt.Sprintf(detail, args...)})
+	}
+
+	if cfg.DatabaseURL.Scheme == "mongodb" {
+		db, err := mongodb.NewDB(cfg.DatabaseURL)
+		if err != nil {
+			add("database", DiagnosisFail, "%v", err)
+		} else {
+			defer db.Client().Disconnect(context.Background())
+			add("database", DiagnosisOK, "%s", cfg.DatabaseURL.Scheme)
+		}
+		add("schema", DiagnosisSkip, "MongoDB has no schema")
+	} else if cfg.DatabaseURL.Scheme == "dynamodb" {
+		db, err := dynamodb.NewDB(cfg.DatabaseURL)
+		if err == nil {
+			err = db.Ping()
+		}
+		if err != nil {
+			add("database", DiagnosisFail, "%v (run migrations)", err)
+		} else {
+			add("database", DiagnosisOK, "%s", cfg.DatabaseURL.Scheme)
+		}
+		add("schema", DiagnosisSkip, "DynamoDB has no schema")
+	} else {
+		db, err := data.NewDB(cfg.DatabaseURL)
+		if err == nil {
+			defer db.Close()
+			err = db.Ping()
+		}
+		if err != nil {
+			add("database", DiagnosisFail, "%v", err)
+			add("schema", DiagnosisSkip, "database is unavailable")
+		} else {
+			add("database", DiagnosisOK, "%s", cfg.DatabaseURL.Scheme)
+			if err := data.CheckSchema(db); err != nil {
+				add("schema", DiagnosisFail, "%v (run migrations)", err)
+			} else {
+				add("schema", DiagnosisOK, "")
+			}
+		}
+	}
+
+	if cfg.RedisURL == nil {
+		add("redis", DiagnosisSkip, "not configured")
+		add("clock", DiagnosisSkip, "requires REDIS_URL")
+	} else {
+		client, err := dataRedis.New(cfg.RedisURL, nil, cfg.RedisKeyPrefix)
+		if err == nil {
+			defer client.Close()
+			err = client.Ping().Err()
+		}
+		if err != nil {
+			add("redis", DiagnosisFail, "%v", err)
+			add("clock", DiagnosisSkip, "redis is unavailable")
+		} else {
+			add("redis", DiagnosisOK, "")
+			redisTime, err := client.Time().Result()
+			if err != nil {
+				add("clock", DiagnosisFail, "%v", err)
+			} else if skew := clockSkew(time.Now(), redisTime); skew > maxClockSkew {
+				add("clock", DiagnosisFail, "%v from redis", skew)
+			} else {
+				add("clock", DiagnosisOK, "%v from redis", skew)
+			}
+		}
+	}
+
+	status, detail := diagnoseSigningKey(cfg.IdentitySigningKey)
+	add("keys", status, "%s", detail)
+
+	warnings := diagnoseDomains(cfg)
+	for _, warning := range warnings {
+		add("domains", DiagnosisWarn, "%s", warning)
+	}
+	if len(warnings) == 0 {
+		add("domains", DiagnosisOK, "")
+	}
+
+	return report
+}
+
+// Healthy reports whether every check in the report passed or was skipped. Warnings are healthy.
+func Healthy(report []Diagnosis) bool {
+	for _, d := range report {
+		if d.Status == DiagnosisFail {
+			return false
+		}
+	}
+	return true
+}
+
+func clockSkew(local time.Time, remote time.Time) time.Duration {
+	skew := local.Sub(remote)
+	if skew < 0 {
+		skew = -skew
+	}
+	return skew.Truncate(time.Millisecond)
+}
+
+// diagnoseSigningKey proves that a configured signing key can sign, which is the only way to know
+// that a hardware key is usable.
+func diagnoseSigningKey(key crypto.Signer) (string, string) {
+	if key == nil {
+		return DiagnosisOK, "generated and rotated by AuthN"
+	}
+	public, ok := key.Public().(*rsa.PublicKey)
+	if !ok {
+		return DiagnosisFail, "signing key is not RSA"
+	}
+
+	digest := sha256.Sum256([]byte("authn doctor"))
+	signature, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return DiagnosisFail, fmt.Sprintf("signing failed: %v", err)
+	}
+	err = rsa.VerifyPKCS1v15(public, crypto.SHA256, digest[:], signature)
+	if err != nil {
+		return DiagnosisFail, "signature does not match the public key"
+	}
+
+	if public.N.BitLen() < 2048 {
+		return DiagnosisWarn, fmt.Sprintf("%d-bit RSA key is weaker than recommended (2048)", public.N.BitLen())
+	}
+	return DiagnosisOK, fmt.Sprintf("%d-bit RSA key", public.N.BitLen())
+}
+
+// diagnoseDomains looks for AUTHN_URL and APP_DOMAINS settings that work in development but cause
+// trouble in browsers: plain http, and AuthN on a different site than the application, where its
+// session cookie is a third-party cookie.
+func diagnoseDomains(cfg *config.Config) []string {
+	warnings := []string{}
+	host := cfg.AuthNURL.Hostname()
+	if cfg.AuthNURL.Scheme != "https" && !isLocal(host) {
+		warnings = append(warnings, "AUTHN_URL does not use https")
+	}
+	for _, domain := range cfg.ApplicationDomains {
+		if site(domain.Hostname) != site(host) {
+			warnings = append(warnings, fmt.Sprintf(
+				"APP_DOMAINS %s is not on the same site as AUTHN_URL, so browsers may block the session cookie",
+				domain.Hostname,
+			))
+		}
+	}
+	return warnings
+}
+
+func isLocal(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// site approximates the registrable domain of a host with its last two labels. It does not know the
+// public suffix list, so hosts under suffixes like co.uk always share a site.
+func site(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) <= 2 {
+		return host
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
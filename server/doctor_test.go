@@ -0,0 +1,75 @@
+package server_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "authn-doctor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dbURL := &url.URL{Scheme: "sqlite3", Host: "localhost", Path: filepath.Join(dir, "authn.db")}
+	require.NoError(t, data.MigrateDB(dbURL))
+
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	find := func(report []server.Diagnosis, check string) []server.Diagnosis {
+		found := []server.Diagnosis{}
+		for _, d := range report {
+			if d.Check == check {
+				found = append(found, d)
+			}
+		}
+		return found
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		report := server.Diagnose(&config.Config{
+			DatabaseURL:        dbURL,
+			AuthNURL:           &url.URL{Scheme: "https", Host: "authn.example.com"},
+			ApplicationDomains: []route.Domain{{Hostname: "www.example.com"}},
+		})
+		assert.True(t, server.Healthy(report))
+		assert.Equal(t, []server.Diagnosis{{"database", server.DiagnosisOK, "sqlite3"}}, find(report, "database"))
+		assert.Equal(t, []server.Diagnosis{{"schema", server.DiagnosisOK, ""}}, find(report, "schema"))
+		assert.Equal(t, []server.Diagnosis{{"redis", server.DiagnosisSkip, "not configured"}}, find(report, "redis"))
+		assert.Equal(t, []server.Diagnosis{{"domains", server.DiagnosisOK, ""}}, find(report, "domains"))
+	})
+
+	t.Run("unmigrated database", func(t *testing.T) {
+		report := server.Diagnose(&config.Config{
+			DatabaseURL:        &url.URL{Scheme: "sqlite3", Host: "localhost", Path: filepath.Join(dir, "empty.db")},
+			AuthNURL:           &url.URL{Scheme: "https", Host: "authn.example.com"},
+			ApplicationDomains: []route.Domain{{Hostname: "example.com"}},
+		})
+		assert.False(t, server.Healthy(report))
+		assert.Equal(t, server.DiagnosisFail, find(report, "schema")[0].Status)
+	})
+
+	t.Run("weak signing key and cross-site domains", func(t *testing.T) {
+		report := server.Diagnose(&config.Config{
+			DatabaseURL:        dbURL,
+			AuthNURL:           &url.URL{Scheme: "http", Host: "authn.example.com"},
+			ApplicationDomains: []route.Domain{{Hostname: "app.example.net"}},
+			IdentitySigningKey: weakKey,
+		})
+		assert.True(t, server.Healthy(report))
+		assert.Equal(t, server.DiagnosisWarn, find(report, "keys")[0].Status)
+		assert.Len(t, find(report, "domains"), 2)
+	})
+}
@@ -0,0 +1,405 @@
+package server
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/lib/route"
+)
+
+type param struct {
+	name     string
+	kind     string
+	required bool
+}
+
+type operation struct {
+	summary string
+	status  int
+	params  []param
+	result  []param
+}
+
+var accountResult = []param{{"id", "integer", true}, {"username", "string", true}, {"external_id", "string", false}, {"locked", "boolean", true}, {"deleted", "boolean", true}, {"expires_at", "string", false}, {"throttled_until", "string", false}, {"legal_hold", "boolean", true}, {"password_scheme", "string", true}}
+var idTokenResult = []param{{"id_token", "string", true}}
+
+// operations describes the request and response types of each known route. Routes that are
+// attached without an entry here are still documented, but only by their path and security.
+var operations = map[string]operation{
+	"POST /accounts":                        {"Signup", http.StatusCreated, []param{{"username", "string", true}, {"password", "string", true}, {"terms_version", "string", false}, {"marketing_opt_in", "boolean", false}, {"form_started_at", "integer", false}}, idTokenResult},
+	"GET /accounts":                         {"Find Accounts", http.StatusOK, []param{{"username", "string", false}, {"external_id", "string", false}, {"tag", "string", false}, {"after", "integer", false}, {"limit", "integer", false}}, nil},
+	"GET /accounts/available":               {"Username Availability", http.StatusOK, []param{{"username", "string", true}}, nil},
+	"POST /accounts/import":                 {"Import Account", http.StatusCreated, []param{{"username", "string", true}, {"password", "string", true}, {"locked", "boolean", false}, {"expires_at", "string", false}}, []param{{"id", "integer", true}}},
+	"POST /accounts/bulk":                   {"Bulk Account Actions", http.StatusOK, []param{{"action", "string", true}, {"ids", "string", true}}, nil},
+	"GET /accounts/{id}":                    {"Get Account", http.StatusOK, nil, accountResult},
+	"PATCH /accounts/{id}":                  {"Update", http.StatusOK, []param{{"username", "string", true}}, nil},
+	"PATCH /accounts/{id}/expiry":           {"Set Account Expiry", http.StatusOK, []param{{"expires_at", "string", false}}, nil},
+	"PATCH /accounts/{id}/lock":             {"Lock Account", http.StatusOK, nil, nil},
+	"PATCH /accounts/{id}/unlock":           {"Unlock Account", http.StatusOK, nil, nil},
+	"PATCH /accounts/{id}/restore":          {"Restore Account", http.StatusOK, nil, nil},
+	"PATCH /accounts/{id}/unthrottle":       {"Unthrottle Account", http.StatusOK, nil, nil},
+	"PATCH /accounts/{id}/expire_password":  {"Expire Password", http.StatusOK, nil, nil},
+	"PATCH /accounts/{id}/reset_password":   {"Reset Password", http.StatusOK, []param{{"send_reset", "boolean", false}}, nil},
+	"PATCH /accounts/{id}/legal_hold":       {"Place Legal Hold", http.StatusOK, nil, nil},
+	"DELETE /accounts/{id}/legal_hold":      {"Release Legal Hold", http.StatusOK, nil, nil},
+	"DELETE /accounts/{id}":                 {"Archive Account", http.StatusOK, nil, nil},
+	"GET /accounts/{id}/tags":               {"Get Account Tags", http.StatusOK, nil, nil},
+	"POST /accounts/{id}/tags":              {"Tag Account", http.StatusOK, []param{{"tag", "string", true}}, nil},
+	"DELETE /accounts/{id}/tags/{tag}":      {"Untag Account", http.StatusOK, nil, nil},
+	"GET /accounts/{id}/metadata":           {"Get Account Metadata", http.StatusOK, nil, nil},
+	"GET /accounts/{id}/sessions":           {"Get Account Sessions", http.StatusOK, nil, []param{{"count", "integer", true}, {"limit", "integer", true}, {"sessions", "array", true}}},
+	"GET /accounts/{id}/audit":              {"Get Account Audit Trail", http.StatusOK, []param{{"after", "string", false}}, []param{{"events", "array", true}, {"next", "string", true}}},
+	"GET /accounts/{id}/consents":           {"Get Account Consents", http.StatusOK, nil, []param{{"terms_version", "string", true}, {"accepted_terms_version", "string", true}, {"marketing_opt_in", "boolean", true}, {"history", "array", true}}},
+	"GET /accounts/{id}/usernames":          {"Get Username History", http.StatusOK, nil, nil},
+	"GET /accounts/{id}/notes":              {"Get Account Notes", http.StatusOK, nil, nil},
+	"POST /accounts/{id}/notes":             {"Add Account Note", http.StatusCreated, []param{{"body", "string", true}}, []param{{"id", "integer", true}, {"body", "string", true}, {"created_at", "string", true}}},
+	"DELETE /accounts/{id}/notes/{note_id}": {"Delete Account Note", http.StatusOK, nil, nil},
+	"POST /accounts/{id}/takeout":           {"Create Takeout Link", http.StatusCreated, nil, []param{{"url", "string", true}, {"expires_at", "string", true}}},
+	"GET /takeout":                          {"Download Takeout", http.StatusOK, nil, nil},
+	"POST /accounts/{id}/links":             {"Create Action Link", http.StatusCreated, []param{{"action", "string", true}}, []param{{"url", "string", true}, {"action", "string", true}, {"expires_at", "string", true}}},
+	"GET /links":                            {"Visit Action Link", http.StatusOK, []param{{"token", "string", true}}, []param{{"account_id", "integer", true}, {"action", "string", true}}},
+	"DELETE /account":                       {"Delete Current Account", http.StatusOK, nil, []param{{"archive_at", "string", true}}},
+	"GET /account/logins":                   {"Login History", http.StatusOK, nil, nil},
+	"GET /account/profile":                  {"Get Current Account Profile", http.StatusOK, nil, []param{{"fields", "object", true}, {"missing", "array", true}}},
+	"PATCH /account/profile":                {"Update Current Account Profile", http.StatusOK, nil, []param{{"fields", "object", true}, {"missing", "array", true}}},
+	"POST /session":                         {"Login", http.StatusCreated, []param{{"username", "string", true}, {"password", "string", true}, {"terms_version", "string", false}, {"marketing_opt_in", "boolean", false}}, idTokenResult},
+	"POST /session/confirm":                 {"Confirm Login", http.StatusCreated, []param{{"token", "string", true}}, idTokenResult},
+	"POST /session/mfa":                     {"Complete MFA Login", http.StatusCreated, []param{{"token", "string", true}}, idTokenResult},
+	"GET /session/refresh":                  {"Refresh Session", http.StatusCreated, []param{{"scope", "string", false}}, idTokenResult},
+	"GET /session/refresh/frame":            {"Refresh Session (iframe)", http.StatusOK, []param{{"origin", "string", true}, {"scope", "string", false}}, nil},
+	"GET /session/events":                   {"Session Events", http.StatusOK, nil, nil},
+	"POST /session/step_up":                 {"Step Up Session", http.StatusAccepted, nil, []param{{"mfa", "string", true}, {"token", "string", true}}},
+	"POST /session/step_up/confirm":         {"Confirm Step Up", http.StatusCreated, []param{{"token", "string", true}}, idTokenResult},
+	"DELETE /session":                       {"Logout", http.StatusOK, nil, nil},
+	"GET /session/logout":                   {"Logout (OIDC)", http.StatusSeeOther, []param{{"id_token_hint", "string", true}, {"post_logout_redirect_uri", "string", false}, {"state", "string", false}}, nil},
+	"POST /introspect":                      {"Introspect Access Token", http.StatusOK, []param{{"token", "string", true}}, nil},
+	"POST /introspect/batch":                {"Introspect Access Tokens", http.StatusOK, []param{{"token", "array", true}}, nil},
+	"POST /password":                        {"Change Password", http.StatusCreated, []param{{"password", "string", true}, {"token", "string", false}, {"currentPassword", "string", false}}, idTokenResult},
+	"GET /password/reset":                   {"Request Password Reset", http.StatusOK, []param{{"username", "string", true}}, nil},
+	"POST /username":                        {"Request Username Change", http.StatusOK, []param{{"username", "string", true}}, nil},
+	"POST /username/confirm":                {"Confirm Username Change", http.StatusOK, []param{{"token", "string", true}}, nil},
+	"POST /username/revert":                 {"Revert Username Change", http.StatusOK, []param{{"token", "string", true}}, nil},
+	"GET /oauth/{providerName}":             {"Begin OAuth", http.StatusSeeOther, []param{{"redirect_uri", "string", true}}, nil},
+	"GET /oauth/{providerName}/return":      {"OAuth Return", http.StatusSeeOther, nil, nil},
+	"GET /configuration":                    {"Service Configuration", http.StatusOK, nil, nil},
+	"GET /jwks":                             {"JSON Web Keys", http.StatusOK, nil, nil},
+	"GET /stats":                            {"Service Stats", http.StatusOK, nil, nil},
+	"GET /health":                           {"Health Check", http.StatusOK, nil, nil},
+	"GET /openapi.json":                     {"OpenAPI Specification", http.StatusOK, nil, nil},
+	"GET /debug/pprof/{profile}":            {"Profile", http.StatusOK, []param{{"seconds", "integer", false}}, nil},
+	"GET /debug/pprof/profile":              {"CPU Profile", http.StatusOK, []param{{"seconds", "integer", false}}, nil},
+	"GET /debug/pprof/trace":                {"Execution Trace", http.StatusOK, []param{{"seconds", "integer", false}}, nil},
+	"GET /debug/vars":                       {"Runtime Stats", http.StatusOK, nil, nil},
+	"GET /config":                           {"Effective Configuration", http.StatusOK, nil, []param{{"settings", "array", true}}},
+	"GET /mode":                             {"Get Mode", http.StatusOK, nil, []param{{"mode", "string", true}}},
+	"PATCH /mode":                           {"Set Mode", http.StatusOK, []param{{"mode", "string", true}}, []param{{"mode", "string", true}}},
+	"GET /debug/benchmark":                  {"Benchmark", http.StatusOK, []param{{"costs", "string", false}, {"samples", "integer", false}, {"seconds", "integer", false}}, nil},
+	"POST /graphql":                         {"GraphQL", http.StatusOK, nil, nil},
+	"GET /admin":                            {"Admin Dashboard", http.StatusOK, nil, nil},
+	"GET /metrics":                          {"Prometheus Metrics", http.StatusOK, nil, nil},
+	"GET /":                                 {"Root", http.StatusOK, nil, nil},
+	"GET /debug/pprof/cmdline":              {"Command Line", http.StatusOK, nil, nil},
+	"GET /debug/pprof/symbol":               {"Symbol Lookup", http.StatusOK, nil, nil},
+	"POST /debug/pprof/symbol":              {"Symbol Lookup", http.StatusOK, nil, nil},
+	"GET /api_keys":                         {"List API Keys", http.StatusOK, nil, nil},
+	"POST /api_keys":                        {"Create API Key", http.StatusCreated, []param{{"name", "string", true}, {"scopes", "string", true}}, []param{{"id", "integer", true}, {"name", "string", true}, {"username", "string", true}, {"password", "string", true}, {"scopes", "array", true}}},
+	"DELETE /api_keys/{id}":                 {"Revoke API Key", http.StatusOK, nil, nil},
+	"GET /outbox":                           {"Outbox Status", http.StatusOK, nil, []param{{"pending", "integer", true}, {"delivered", "integer", true}, {"failed", "integer", true}}},
+	"GET /outbox/{id}":                      {"Outbox Message Status", http.StatusOK, nil, []param{{"id", "integer", true}, {"kind", "string", true}, {"destination", "string", true}, {"status", "string", true}, {"attempts", "integer", true}, {"response_code", "integer", true}, {"last_error", "string", true}, {"created_at", "string", true}, {"next_attempt_at", "string", true}, {"delivered_at", "string", false}}},
+	"GET /outbox/messages":                  {"Outbox Messages", http.StatusOK, []param{{"kind", "string", false}, {"status", "string", false}, {"limit", "integer", false}}, nil},
+	"GET /outbox/{id}/payload":              {"Outbox Message Payload", http.StatusOK, nil, []param{{"id", "integer", true}, {"kind", "string", true}, {"payload", "object", true}}},
+	"POST /outbox/{id}/redeliver":           {"Redeliver Outbox Message", http.StatusOK, nil, nil},
+	"POST /outbox/secrets/{endpoint}":       {"Rotate Webhook Secret", http.StatusCreated, []param{{"overlap", "integer", false}}, []param{{"endpoint", "string", true}, {"secret", "string", true}}},
+}
+
+type alternative struct {
+	operation
+	description string
+}
+
+// privateAlternatives are operations served by a public route to requests with private API
+// credentials: the shared HTTP_AUTH_USERNAME and HTTP_AUTH_PASSWORD, or an API key's. Probing the
+// route only finds the public security, unless the public operation is disabled.
+var privateAlternatives = map[string]alternative{
+	"POST /accounts": {
+		operation{"Provision Account", http.StatusCreated, []param{{"username", "string", true}, {"password", "string", true}, {"locked", "boolean", false}, {"email_verified", "boolean", false}, {"expires_at", "string", false}, {"roles", "string", false}}, []param{{"id", "integer", true}}},
+		"Requests with an Origin sign up and create a session. Requests with Basic Auth credentials, either HTTP_AUTH_USERNAME and HTTP_AUTH_PASSWORD or an API key with the account-admin scope, provision an account for a backoffice system with its initial state in place, and do not create a session. Provisioning also accepts metadata[name] fields.",
+	},
+}
+
+// securityActionToken documents unsecured routes whose only credential is a signed token in the
+// query, such as a link that is handed to the user.
+const securityActionToken = "action_token"
+
+var actionTokenRoutes = map[string]bool{
+	"GET /takeout": true,
+}
+
+var pathVar = regexp.MustCompile(`\{(\w+)(:[^}]+)?\}`)
+
+// OpenAPI builds an OpenAPI 3 document from a route table. It is generated from the same routes
+// that are attached to the router, so that it never documents an endpoint that is not served.
+func OpenAPI(app *api.App, routes []*route.HandledRoute) map[string]interface{} {
+	paths := map[string]map[string]interface{}{}
+	for _, r := range routes {
+		desc := r.Describe()
+		path, pathParams := templateToPath(desc.Path)
+
+		key := operationKey(desc.Method, path)
+		if desc.Security == route.SecurityNone && actionTokenRoutes[key] {
+			desc.Security = securityActionToken
+		}
+		op := operations[key]
+		alt, hasAlt := privateAlternatives[key]
+		if hasAlt && desc.Security == route.SecurityBasicAuth {
+			op, hasAlt = alt.operation, false
+		}
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		result := buildOperation(desc, path, op, pathParams)
+		if hasAlt {
+			altDesc := desc
+			altDesc.Security = route.SecurityBasicAuth
+			result = mergeOperations(result, buildOperation(altDesc, path, alt.operation, pathParams), alt.description)
+		}
+		paths[path][strings.ToLower(desc.Method)] = result
+	}
+
+	server := app.Config.MountedPath
+	if server == "" {
+		server = "/"
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Keratin AuthN",
+			"version": "1",
+		},
+		"servers": []map[string]interface{}{{"url": server}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				route.SecurityBasicAuth: map[string]interface{}{"type": "http", "scheme": "basic", "description": "HTTP_AUTH_USERNAME and HTTP_AUTH_PASSWORD, or the credentials of an API key"},
+				route.SecurityOrigin:    map[string]interface{}{"type": "apiKey", "in": "header", "name": "Origin"},
+				securityActionToken:     map[string]interface{}{"type": "apiKey", "in": "query", "name": "token"},
+			},
+		},
+	}
+}
+
+func getOpenAPI(app *api.App, routes []*route.HandledRoute) http.HandlerFunc {
+	doc := OpenAPI(app, routes)
+	return func(w http.ResponseWriter, r *http.Request) {
+		api.WriteJSON(w, http.StatusOK, doc)
+	}
+}
+
+// withOpenAPI adds the OpenAPI route to a route table, including itself in the document.
+func withOpenAPI(app *api.App, routes []*route.HandledRoute) []*route.HandledRoute {
+	openAPI := route.Get("/openapi.json").SecuredWith(route.Unsecured())
+	documented := append(routes[:len(routes):len(routes)], openAPI.Handle(nil))
+	return append(routes[:len(routes):len(routes)], openAPI.Handle(getOpenAPI(app, documented)))
+}
+
+// templateToPath converts a gorilla/mux template into an OpenAPI path, returning the path
+// parameters it declares.
+func templateToPath(tpl string) (string, []param) {
+	var params []param
+	path := pathVar.ReplaceAllStringFunc(tpl, func(v string) string {
+		m := pathVar.FindStringSubmatch(v)
+		kind := "string"
+		if m[2] == ":[0-9]+" {
+			kind = "integer"
+		}
+		params = append(params, param{m[1], kind, true})
+		return "{" + m[1] + "}"
+	})
+	return path, params
+}
+
+// operationKey finds the catalog entry for a path. OAuth routes are attached once per configured
+// provider, so they share an entry.
+func operationKey(method string, path string) string {
+	if strings.HasPrefix(path, "/oauth/") {
+		segments := strings.Split(path, "/")
+		segments[2] = "{providerName}"
+		path = strings.Join(segments, "/")
+	}
+	return method + " " + path
+}
+
+func buildOperation(desc route.Description, path string, op operation, pathParams []param) map[string]interface{} {
+	if op.status == 0 {
+		op.status = http.StatusOK
+	}
+
+	result := map[string]interface{}{
+		"operationId": desc.Method + " " + path,
+		"responses":   buildResponses(desc, op),
+	}
+	if op.summary != "" {
+		result["summary"] = op.summary
+	}
+	if desc.Security != route.SecurityNone {
+		result["security"] = []map[string][]string{{desc.Security: {}}}
+	}
+
+	var parameters []map[string]interface{}
+	for _, p := range pathParams {
+		parameters = append(parameters, buildParameter(p, "path"))
+	}
+	if desc.Method == "GET" || desc.Method == "DELETE" {
+		for _, p := range op.params {
+			parameters = append(parameters, buildParameter(p, "query"))
+		}
+	} else if len(op.params) > 0 {
+		result["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/x-www-form-urlencoded": map[string]interface{}{
+					"schema": buildObject(op.params),
+				},
+			},
+		}
+	}
+	if len(parameters) > 0 {
+		result["parameters"] = parameters
+	}
+
+	return result
+}
+
+// mergeOperations documents a public operation and its private alternative as one operation that
+// accepts either security, with either request body and either response.
+func mergeOperations(public map[string]interface{}, private map[string]interface{}, description string) map[string]interface{} {
+	public["summary"] = public["summary"].(string) + " or " + private["summary"].(string)
+	public["description"] = description
+	security, _ := public["security"].([]map[string][]string)
+	public["security"] = append(security, private["security"].([]map[string][]string)...)
+
+	if body, ok := private["requestBody"].(map[string]interface{}); ok {
+		if existing, ok := public["requestBody"].(map[string]interface{}); ok {
+			mergeContent(existing, body)
+		} else {
+			public["requestBody"] = body
+		}
+	}
+
+	responses := public["responses"].(map[string]interface{})
+	for status, res := range private["responses"].(map[string]interface{}) {
+		if existing, ok := responses[status].(map[string]interface{}); ok {
+			mergeContent(existing, res.(map[string]interface{}))
+		} else {
+			responses[status] = res
+		}
+	}
+	return public
+}
+
+// mergeContent adds the content of a request body or response to another, so that the schema of a
+// media type that both have is one of them.
+func mergeContent(dst map[string]interface{}, src map[string]interface{}) {
+	srcContent, ok := src["content"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	dstContent, ok := dst["content"].(map[string]interface{})
+	if !ok {
+		dst["content"] = srcContent
+		return
+	}
+	for mediaType, media := range srcContent {
+		existing, ok := dstContent[mediaType].(map[string]interface{})
+		if !ok {
+			dstContent[mediaType] = media
+			continue
+		}
+		existing["schema"] = map[string]interface{}{
+			"oneOf": []interface{}{existing["schema"], media.(map[string]interface{})["schema"]},
+		}
+	}
+}
+
+func buildResponses(desc route.Description, op operation) map[string]interface{} {
+	success := map[string]interface{}{"description": op.summary}
+	if op.result != nil {
+		success["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": buildObject([]param{{"result", "object", true}}, op.result),
+			},
+		}
+	}
+
+	responses := map[string]interface{}{
+		strconv.Itoa(op.status): success,
+	}
+	if len(op.params) > 0 {
+		responses["422"] = map[string]interface{}{
+			"description": "Validation errors",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"errors": map[string]interface{}{
+								"type":  "array",
+								"items": buildObject([]param{{"field", "string", true}, {"message", "string", true}}),
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	switch desc.Security {
+	case route.SecurityBasicAuth:
+		responses["401"] = map[string]interface{}{"description": "Unauthorized"}
+	case route.SecurityOrigin:
+		responses["403"] = map[string]interface{}{"description": "Origin is not a trusted host"}
+	case securityActionToken:
+		responses["404"] = map[string]interface{}{"description": "Token is invalid or expired"}
+	}
+	return responses
+}
+
+func buildParameter(p param, in string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":     p.name,
+		"in":       in,
+		"required": p.required,
+		"schema":   map[string]interface{}{"type": p.kind},
+	}
+}
+
+// buildObject creates an object schema. An "object" property is described by the next list of
+// nested properties, if one is given.
+func buildObject(props []param, nested ...[]param) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for _, p := range props {
+		schema := map[string]interface{}{"type": p.kind}
+		if p.kind == "object" && len(nested) > 0 {
+			schema = buildObject(nested[0], nested[1:]...)
+		}
+		properties[p.name] = schema
+		if p.required {
+			required = append(required, p.name)
+		}
+	}
+	sort.Strings(required)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,95 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type openAPIDocument struct {
+	OpenAPI string                                       `json:"openapi"`
+	Paths   map[string]map[string]map[string]interface{} `json:"paths"`
+}
+
+func getOpenAPI(t *testing.T, handler http.Handler) openAPIDocument {
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/openapi.json")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []string{"application/json"}, res.Header["Content-Type"])
+
+	doc := openAPIDocument{}
+	err = json.Unmarshal(test.ReadBody(res), &doc)
+	require.NoError(t, err)
+	return doc
+}
+
+func TestOpenAPI(t *testing.T) {
+	app := test.App()
+
+	t.Run("private port", func(t *testing.T) {
+		doc := getOpenAPI(t, server.Router(app))
+		assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+		patch := doc.Paths["/accounts/{id}"]["patch"]
+		require.NotNil(t, patch)
+		assert.Equal(t, "Update", patch["summary"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"basic": []interface{}{}}}, patch["security"])
+		assert.NotEmpty(t, patch["parameters"])
+		assert.NotEmpty(t, patch["requestBody"])
+
+		signup := doc.Paths["/accounts"]["post"]
+		require.NotNil(t, signup)
+		assert.Equal(t, "Signup or Provision Account", signup["summary"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"origin": []interface{}{}},
+			map[string]interface{}{"basic": []interface{}{}},
+		}, signup["security"])
+		assert.NotEmpty(t, signup["description"])
+		created := signup["responses"].(map[string]interface{})["201"].(map[string]interface{})
+		schema := created["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"]
+		assert.Len(t, schema.(map[string]interface{})["oneOf"], 2)
+		assert.NotNil(t, signup["responses"].(map[string]interface{})["401"])
+
+		login := doc.Paths["/session"]["post"]
+		require.NotNil(t, login)
+		assert.Equal(t, []interface{}{map[string]interface{}{"origin": []interface{}{}}}, login["security"])
+
+		assert.NotNil(t, doc.Paths["/openapi.json"]["get"])
+
+		link := doc.Paths["/accounts/{id}/takeout"]["post"]
+		require.NotNil(t, link)
+		assert.Equal(t, "Create Takeout Link", link["summary"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"basic": []interface{}{}}}, link["security"])
+		assert.NotNil(t, link["responses"].(map[string]interface{})["201"])
+
+		cfg := doc.Paths["/config"]["get"]
+		require.NotNil(t, cfg)
+		assert.Equal(t, "Effective Configuration", cfg["summary"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"basic": []interface{}{}}}, cfg["security"])
+		assert.NotNil(t, cfg["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"])
+
+		takeout := doc.Paths["/takeout"]["get"]
+		require.NotNil(t, takeout)
+		assert.Equal(t, "Download Takeout", takeout["summary"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"action_token": []interface{}{}}}, takeout["security"])
+		assert.NotNil(t, takeout["responses"].(map[string]interface{})["404"])
+	})
+
+	t.Run("public port", func(t *testing.T) {
+		doc := getOpenAPI(t, server.PublicRouter(app))
+
+		assert.NotNil(t, doc.Paths["/session"]["post"])
+		assert.NotNil(t, doc.Paths["/health"]["get"])
+		assert.Nil(t, doc.Paths["/accounts/{id}"])
+		assert.Nil(t, doc.Paths["/metrics"])
+	})
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"net/http"
+
+	gorilla "github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/api/accounts"
+	"github.com/keratin/authn-server/api/apikeys"
+	"github.com/keratin/authn-server/api/graph"
+	"github.com/keratin/authn-server/api/meta"
+	"github.com/keratin/authn-server/api/oauth"
+	"github.com/keratin/authn-server/api/outbox"
+	"github.com/keratin/authn-server/api/passwords"
+	"github.com/keratin/authn-server/api/sessions"
+	"github.com/keratin/authn-server/api/usernames"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/ops"
+)
+
+// Router returns a handler for every route, for the private port.
+func Router(app *api.App) http.Handler {
+	var routes []*route.HandledRoute
+	routes = append(routes, meta.Routes(app)...)
+	routes = append(routes, accounts.Routes(app)...)
+	routes = append(routes, sessions.Routes(app)...)
+	routes = append(routes, passwords.Routes(app)...)
+	routes = append(routes, usernames.Routes(app)...)
+	routes = append(routes, oauth.Routes(app)...)
+	routes = append(routes, graph.Routes(app)...)
+	routes = append(routes, apikeys.Routes(app)...)
+	routes = append(routes, outbox.Routes(app)...)
+
+	r := mux.NewRouter()
+	route.Attach(r, app.Config.MountedPath, route.DefaultTimeout(app.Config.RequestTimeout, withOpenAPI(app, routes)...)...)
+
+	return wrapRouter(r, app)
+}
+
+// PublicRouter returns a handler for only the public routes, for the public port.
+func PublicRouter(app *api.App) http.Handler {
+	var routes []*route.HandledRoute
+	routes = append(routes, meta.PublicRoutes(app)...)
+	routes = append(routes, accounts.PublicRoutes(app)...)
+	routes = append(routes, sessions.PublicRoutes(app)...)
+	routes = append(routes, passwords.PublicRoutes(app)...)
+	routes = append(routes, usernames.PublicRoutes(app)...)
+	routes = append(routes, oauth.PublicRoutes(app)...)
+
+	r := mux.NewRouter()
+	route.Attach(r, app.Config.MountedPath, route.DefaultTimeout(app.Config.RequestTimeout, withOpenAPI(app, routes)...)...)
+
+	return wrapRouter(r, app)
+}
+
+func wrapRouter(r *mux.Router, app *api.App) http.Handler {
+	stack := api.RequestLog(app)(api.Maintenance(app)(api.LoadShedding(app)(r)))
+
+	stack = app.Hooks.Wrap(stack)
+
+	stack = api.Session(app)(stack)
+
+	stack = api.Budget(app)(stack)
+
+	stack = gorilla.CORS(
+		gorilla.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE"}),
+		gorilla.AllowedHeaders([]string{"DPoP"}),
+		gorilla.AllowCredentials(),
+		gorilla.AllowedOrigins([]string{}), // see: https://github.com/gorilla/handlers/issues/117
+		gorilla.AllowedOriginValidator(api.OriginValidator(app.Config.ApplicationDomains)),
+	)(stack)
+
+	if app.Config.Proxied {
+		stack = gorilla.ProxyHeaders(stack)
+	}
+
+	if app.Config.MaxRequestBodySize > 0 {
+		stack = api.BodyLimit(app.Config.MaxRequestBodySize)(stack)
+	}
+
+	return ops.PanicHandler(app.Reporter, stack)
+}
@@ -1,12 +1,15 @@
-package main
+package server_test
 
 import (
 	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/keratin/authn-server/api"
 	"github.com/keratin/authn-server/api/test"
 	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/server"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -14,10 +17,10 @@ import (
 func TestCORS(t *testing.T) {
 	app := test.App()
 	domain := app.Config.ApplicationDomains[0]
-	server := httptest.NewServer(router(app))
-	defer server.Close()
+	ts := httptest.NewServer(server.Router(app))
+	defer ts.Close()
 
-	client := route.NewClient(server.URL)
+	client := route.NewClient(ts.URL)
 	res, err := client.Preflight(&domain, "PATCH", "/path")
 	require.NoError(t, err)
 
@@ -33,3 +36,23 @@ func TestCORS(t *testing.T) {
 	assert.Equal(t, "PATCH", res.Header.Get("Access-Control-Allow-Methods"))
 	assert.Equal(t, origin, res.Header.Get("Access-Control-Allow-Origin"))
 }
+
+func TestMiddleware(t *testing.T) {
+	app := test.App()
+	app.Hooks = &api.Hooks{
+		Middleware: []api.Middleware{
+			func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("X-Custom", "yes")
+					next.ServeHTTP(w, r)
+				})
+			},
+		},
+	}
+	ts := httptest.NewServer(server.PublicRouter(app))
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/health")
+	require.NoError(t, err)
+	assert.Equal(t, "yes", res.Header.Get("X-Custom"))
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/keratin/authn-server/api"
+	"github.com/keratin/authn-server/config"
+	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// Server is an AuthN server that may be embedded in another Go program.
+type Server struct {
+	App *api.App
+}
+
+// Option customizes a Server. See WithMiddleware, WithSignupValidator, and WithAccountCreatedHook.
+type Option func(*api.Hooks)
+
+// WithMiddleware wraps both routers with the given middleware, e.g. to add custom headers. The
+// middleware runs after the session has been read, so it may use api.GetSessionAccountID.
+func WithMiddleware(mw ...api.Middleware) Option {
+	return func(h *api.Hooks) {
+		h.Middleware = append(h.Middleware, mw...)
+	}
+}
+
+// WithSignupValidator adds custom validation to signups. A validator that returns an error will
+// reject the signup before any account is created.
+func WithSignupValidator(fn api.SignupValidator) Option {
+	return func(h *api.Hooks) {
+		h.SignupValidators = append(h.SignupValidators, fn)
+	}
+}
+
+// WithAccountCreatedHook adds a callback for new accounts, whether from signup or import, e.g. to
+// enrich a user profile in another system.
+func WithAccountCreatedHook(fn api.AccountHook) Option {
+	return func(h *api.Hooks) {
+		h.AccountCreated = append(h.AccountCreated, fn)
+	}
+}
+
+// New connects to the databases described by the config and prepares a Server.
+func New(cfg *config.Config, opts ...Option) (*Server, error) {
+	app, err := api.NewApp(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	hooks := &api.Hooks{}
+	for _, opt := range opts {
+		opt(hooks)
+	}
+	app.Hooks = hooks
+
+	return &Server{App: app}, nil
+}
+
+// Handler returns the router for the private port, with every route.
+func (s *Server) Handler() http.Handler {
+	return Router(s.App)
+}
+
+// PublicHandler returns the router for the public port, with only the public routes.
+func (s *Server) PublicHandler() http.Handler {
+	return PublicRouter(s.App)
+}
+
+// ListenAndServe binds the configured PORT and, if configured, PUBLIC_PORT. It blocks until a
+// listener fails.
+func (s *Server) ListenAndServe() error {
+	errs := make(chan error, 2)
+
+	if s.App.Config.PublicPort != 0 {
+		go func() {
+			errs <- s.listenAndServe(s.App.Config.PublicPort, s.PublicHandler())
+		}()
+	}
+
+	go func() {
+		errs <- s.listenAndServe(s.App.Config.ServerPort, s.Handler())
+	}()
+
+	return <-errs
+}
+
+// Serve accepts connections on a listener that the caller has bound, e.g. from socket activation,
+// with the configured timeouts and protocols. The handler is usually Handler or PublicHandler.
+func (s *Server) Serve(ln net.Listener, handler http.Handler) error {
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		return errors.Wrap(err, "SplitHostPort")
+	}
+	return s.httpServer(handler).Serve(connectionListener{
+		Listener:  ln,
+		port:      port,
+		keepAlive: s.App.Config.ServerKeepAlive,
+	})
+}
+
+func (s *Server) listenAndServe(port int, handler http.Handler) error {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln, handler)
+}
+
+// httpServer applies the configured timeouts, rather than Go's defaults that allow a slow client to
+// hold a connection open forever.
+func (s *Server) httpServer(handler http.Handler) *http.Server {
+	cfg := s.App.Config
+	if cfg.HTTP2 {
+		handler = h2c.NewHandler(handler, &http2.Server{
+			MaxConcurrentStreams: uint32(cfg.HTTP2MaxConcurrentStreams),
+			IdleTimeout:          cfg.ServerIdleTimeout,
+		})
+	}
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ServerReadHeaderTimeout,
+		ReadTimeout:       cfg.ServerReadTimeout,
+		WriteTimeout:      cfg.ServerWriteTimeout,
+		IdleTimeout:       cfg.ServerIdleTimeout,
+	}
+	srv.SetKeepAlivesEnabled(cfg.ServerKeepAlive)
+	return srv
+}
@@ -0,0 +1,71 @@
+package server_test
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/keratin/authn-server/api/test"
+	"github.com/keratin/authn-server/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+func TestServe(t *testing.T) {
+	app := test.App()
+	app.Config.ServerKeepAlive = true
+	app.Config.HTTP2 = true
+	app.Config.HTTP2MaxConcurrentStreams = 10
+	srv := &server.Server{App: app}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go srv.Serve(ln, srv.Handler())
+	url := fmt.Sprintf("http://%s/jwks", ln.Addr())
+
+	t.Run("HTTP/1.1", func(t *testing.T) {
+		res, err := http.Get(url)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 1, res.ProtoMajor)
+	})
+
+	t.Run("HTTP/2 with prior knowledge", func(t *testing.T) {
+		client := &http.Client{Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		}}
+		res, err := client.Get(url)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 2, res.ProtoMajor)
+	})
+
+	t.Run("connection metrics", func(t *testing.T) {
+		families, err := prometheus.DefaultGatherer.Gather()
+		require.NoError(t, err)
+
+		_, port, _ := net.SplitHostPort(ln.Addr().String())
+		accepted := 0.0
+		for _, family := range families {
+			if family.GetName() != "http_connections_total" {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				if metric.GetLabel()[0].GetValue() == port {
+					accepted = metric.GetCounter().GetValue()
+				}
+			}
+		}
+		assert.Equal(t, 2.0, accepted)
+	})
+}
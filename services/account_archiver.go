@@ -13,6 +13,9 @@ func AccountArchiver(store data.AccountStore, tokenStore data.RefreshTokenStore,
 	if account == nil {
 		return FieldErrors{{"account", ErrNotFound}}
 	}
+	if account.LegalHold {
+		return FieldErrors{{"account", ErrLegalHold}}
+	}
 
 	tokens, err := tokenStore.FindAll(accountID)
 	if err != nil {
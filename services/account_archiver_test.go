@@ -41,6 +41,24 @@ func TestAccountArchiver(t *testing.T) {
 		assert.Empty(t, id)
 	})
 
+	t.Run("held account", func(t *testing.T) {
+		account, err := accountStore.Create("held@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		require.NoError(t, accountStore.SetLegalHold(account.ID, true))
+		token, err := refreshStore.Create(account.ID)
+		require.NoError(t, err)
+
+		errs := services.AccountArchiver(accountStore, refreshStore, account.ID)
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrLegalHold}}, errs)
+
+		acct, err := accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.False(t, acct.Archived())
+		id, err := refreshStore.Find(token)
+		require.NoError(t, err)
+		assert.Equal(t, account.ID, id)
+	})
+
 	t.Run("unknown account", func(t *testing.T) {
 		errs := services.AccountArchiver(accountStore, refreshStore, 123456789)
 		assert.Equal(t, services.FieldErrors{{"account", services.ErrNotFound}}, errs)
@@ -7,7 +7,6 @@ import (
 	"github.com/keratin/authn-server/data"
 	"github.com/keratin/authn-server/models"
 	"github.com/pkg/errors"
-	"golang.org/x/crypto/bcrypt"
 )
 
 func AccountCreator(store data.AccountStore, cfg *config.Config, username string, password string) (*models.Account, error) {
@@ -29,7 +28,7 @@ func AccountCreator(store data.AccountStore, cfg *config.Config, username string
 		return nil, errs
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), cfg.BcryptCost)
+	hash, err := generateFromPassword(cfg, []byte(password))
 	if err != nil {
 		return nil, errors.Wrap(err, "bcrypt")
 	}
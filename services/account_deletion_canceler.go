@@ -0,0 +1,22 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// AccountDeletionCanceler cancels a scheduled deletion when the user logs back in. It reports
+// whether there was a deletion to cancel.
+func AccountDeletionCanceler(store data.AccountStore, account *models.Account) (bool, error) {
+	if account.ArchiveAt == nil {
+		return false, nil
+	}
+
+	err := store.CancelArchive(account.ID)
+	if err != nil {
+		return false, errors.Wrap(err, "CancelArchive")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,41 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountDeletionCanceler(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+
+	t.Run("scheduled deletion", func(t *testing.T) {
+		account, err := accountStore.Create("scheduled", []byte("password"))
+		require.NoError(t, err)
+		err = accountStore.ScheduleArchive(account.ID, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		account, err = accountStore.Find(account.ID)
+		require.NoError(t, err)
+
+		cancelled, err := services.AccountDeletionCanceler(accountStore, account)
+		require.NoError(t, err)
+		assert.True(t, cancelled)
+
+		found, err := accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.ArchiveAt)
+	})
+
+	t.Run("no deletion", func(t *testing.T) {
+		account, err := accountStore.Create("unscheduled", []byte("password"))
+		require.NoError(t, err)
+
+		cancelled, err := services.AccountDeletionCanceler(accountStore, account)
+		require.NoError(t, err)
+		assert.False(t, cancelled)
+	})
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/pkg/errors"
+)
+
+// AccountDeletionScheduler schedules an account to be archived after the grace period, and
+// revokes every session immediately. The user may cancel by logging in before the deadline.
+func AccountDeletionScheduler(store data.AccountStore, tokenStore data.RefreshTokenStore, cfg *config.Config, accountID int) (time.Time, error) {
+	account, err := store.Find(accountID)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "Find")
+	}
+	if account == nil || account.Archived() {
+		return time.Time{}, FieldErrors{{"account", ErrNotFound}}
+	}
+
+	archiveAt := time.Now().Add(cfg.AccountDeletionGrace).Truncate(time.Second)
+	err = store.ScheduleArchive(account.ID, archiveAt)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "ScheduleArchive")
+	}
+
+	tokens, err := tokenStore.FindAll(account.ID)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "FindAll")
+	}
+	for _, token := range tokens {
+		err = tokenStore.Revoke(token)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "Revoke")
+		}
+	}
+
+	return archiveAt, nil
+}
@@ -0,0 +1,54 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountDeletionScheduler(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	refreshStore := mock.NewRefreshTokenStore()
+	cfg := &config.Config{AccountDeletionGrace: time.Hour}
+
+	t.Run("active account", func(t *testing.T) {
+		account, err := accountStore.Create("active", []byte("password"))
+		require.NoError(t, err)
+		_, err = refreshStore.Create(account.ID)
+		require.NoError(t, err)
+
+		archiveAt, err := services.AccountDeletionScheduler(accountStore, refreshStore, cfg, account.ID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), archiveAt, time.Second)
+
+		found, err := accountStore.Find(account.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found.ArchiveAt)
+		assert.Equal(t, archiveAt, *found.ArchiveAt)
+		assert.False(t, found.Archived())
+
+		tokens, err := refreshStore.FindAll(account.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+
+	t.Run("archived account", func(t *testing.T) {
+		account, err := accountStore.Create("archived", []byte("password"))
+		require.NoError(t, err)
+		err = accountStore.Archive(account.ID)
+		require.NoError(t, err)
+
+		_, err = services.AccountDeletionScheduler(accountStore, refreshStore, cfg, account.ID)
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrNotFound}}, err)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		_, err := services.AccountDeletionScheduler(accountStore, refreshStore, cfg, 9999)
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrNotFound}}, err)
+	})
+}
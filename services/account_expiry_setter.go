@@ -0,0 +1,26 @@
+package services
+
+import (
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/pkg/errors"
+)
+
+// AccountExpirySetter sets or clears the time after which a temporary account may no longer log in
+// and will be archived.
+func AccountExpirySetter(store data.AccountStore, accountID int, expiresAt *time.Time) error {
+	account, err := store.Find(accountID)
+	if err != nil {
+		return errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return FieldErrors{{"account", ErrNotFound}}
+	}
+
+	err = store.SetExpiry(account.ID, expiresAt)
+	if err != nil {
+		return errors.Wrap(err, "SetExpiry")
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountExpirySetter(t *testing.T) {
+	store := mock.NewAccountStore()
+
+	t.Run("unknown account", func(t *testing.T) {
+		err := services.AccountExpirySetter(store, 0, nil)
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrNotFound}}, err)
+	})
+
+	t.Run("setting and clearing expiry", func(t *testing.T) {
+		account, err := store.Create("temporary", []byte("password"))
+		require.NoError(t, err)
+
+		at := time.Now().Add(time.Hour)
+		err = services.AccountExpirySetter(store, account.ID, &at)
+		require.NoError(t, err)
+		found, err := store.Find(account.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found.ExpiresAt)
+		assert.WithinDuration(t, at, *found.ExpiresAt, time.Second)
+
+		err = services.AccountExpirySetter(store, account.ID, nil)
+		require.NoError(t, err)
+		found, err = store.Find(account.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.ExpiresAt)
+	})
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// ExportOptions chooses the format and contents of an account export.
+type ExportOptions struct {
+	// Format is either "jsonl" or "csv".
+	Format          string
+	IncludeOauth    bool
+	IncludeMetadata bool
+	IncludeHashes   bool
+}
+
+type exportedOauthAccount struct {
+	Provider   string `json:"provider"`
+	ProviderID string `json:"provider_id"`
+}
+
+type exportedAccount struct {
+	ID                 int                    `json:"id"`
+	Username           string                 `json:"username"`
+	Password           string                 `json:"password,omitempty"`
+	Locked             bool                   `json:"locked"`
+	RequireNewPassword bool                   `json:"require_new_password"`
+	PasswordChangedAt  time.Time              `json:"password_changed_at"`
+	CreatedAt          time.Time              `json:"created_at"`
+	UpdatedAt          time.Time              `json:"updated_at"`
+	DeletedAt          *time.Time             `json:"deleted_at"`
+	ArchiveAt          *time.Time             `json:"archive_at"`
+	ExpiresAt          *time.Time             `json:"expires_at"`
+	OauthAccounts      []exportedOauthAccount `json:"oauth_accounts,omitempty"`
+	Metadata           map[string]string      `json:"metadata,omitempty"`
+}
+
+var exportColumns = []string{
+	"id", "username", "locked", "require_new_password", "password_changed_at", "created_at",
+	"updated_at", "deleted_at", "archive_at", "expires_at",
+}
+
+// AccountExporter writes every account given by each to w, one at a time, so that an export of any
+// size runs in constant memory. OAuth access tokens are never exported, and password hashes are
+// only exported when asked for. In CSV, OAuth accounts are written as provider:provider_id pairs
+// separated by spaces, and metadata as a JSON object.
+func AccountExporter(
+	each func(func(*models.Account) error) error,
+	store data.AccountStore,
+	annotations data.AnnotationStore,
+	w io.Writer,
+	opts ExportOptions,
+) error {
+	var write func(*exportedAccount) error
+	switch opts.Format {
+	case "jsonl":
+		encoder := json.NewEncoder(w)
+		write = func(account *exportedAccount) error {
+			return encoder.Encode(account)
+		}
+	case "csv":
+		columns := exportColumns
+		if opts.IncludeHashes {
+			columns = append(columns[:2:2], append([]string{"password"}, columns[2:]...)...)
+		}
+		if opts.IncludeOauth {
+			columns = append(columns, "oauth_accounts")
+		}
+		if opts.IncludeMetadata {
+			columns = append(columns, "metadata")
+		}
+
+		writer := csv.NewWriter(w)
+		defer writer.Flush()
+		err := writer.Write(columns)
+		if err != nil {
+			return errors.Wrap(err, "Write")
+		}
+		write = func(account *exportedAccount) error {
+			record, err := csvRecord(account, opts)
+			if err != nil {
+				return err
+			}
+			return writer.Write(record)
+		}
+	default:
+		return fmt.Errorf("unsupported format: %s", opts.Format)
+	}
+
+	return each(func(account *models.Account) error {
+		exported := &exportedAccount{
+			ID:                 account.ID,
+			Username:           account.Username,
+			Locked:             account.Locked,
+			RequireNewPassword: account.RequireNewPassword,
+			PasswordChangedAt:  account.PasswordChangedAt,
+			CreatedAt:          account.CreatedAt,
+			UpdatedAt:          account.UpdatedAt,
+			DeletedAt:          account.DeletedAt,
+			ArchiveAt:          account.ArchiveAt,
+			ExpiresAt:          account.ExpiresAt,
+		}
+		if opts.IncludeHashes {
+			exported.Password = string(account.Password)
+		}
+
+		if opts.IncludeOauth {
+			oauthAccounts, err := store.GetOauthAccounts(account.ID)
+			if err != nil {
+				return errors.Wrap(err, "GetOauthAccounts")
+			}
+			for _, oauthAccount := range oauthAccounts {
+				exported.OauthAccounts = append(exported.OauthAccounts, exportedOauthAccount{
+					Provider:   oauthAccount.Provider,
+					ProviderID: oauthAccount.ProviderID,
+				})
+			}
+		}
+
+		if opts.IncludeMetadata {
+			metadata, err := annotations.GetMetadata(account.ID)
+			if err != nil {
+				return errors.Wrap(err, "GetMetadata")
+			}
+			if len(metadata) > 0 {
+				exported.Metadata = metadata
+			}
+		}
+
+		return errors.Wrap(write(exported), "write")
+	})
+}
+
+func csvRecord(account *exportedAccount, opts ExportOptions) ([]string, error) {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+
+	record := []string{strconv.Itoa(account.ID), account.Username}
+	if opts.IncludeHashes {
+		record = append(record, account.Password)
+	}
+	record = append(record,
+		strconv.FormatBool(account.Locked),
+		strconv.FormatBool(account.RequireNewPassword),
+		formatTime(&account.PasswordChangedAt),
+		formatTime(&account.CreatedAt),
+		formatTime(&account.UpdatedAt),
+		formatTime(account.DeletedAt),
+		formatTime(account.ArchiveAt),
+		formatTime(account.ExpiresAt),
+	)
+
+	if opts.IncludeOauth {
+		pairs := []string{}
+		for _, oauthAccount := range account.OauthAccounts {
+			pairs = append(pairs, oauthAccount.Provider+":"+oauthAccount.ProviderID)
+		}
+		record = append(record, strings.Join(pairs, " "))
+	}
+	if opts.IncludeMetadata {
+		metadata := ""
+		if len(account.Metadata) > 0 {
+			encoded, err := json.Marshal(account.Metadata)
+			if err != nil {
+				return nil, errors.Wrap(err, "Marshal")
+			}
+			metadata = string(encoded)
+		}
+		record = append(record, metadata)
+	}
+	return record, nil
+}
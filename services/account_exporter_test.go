@@ -0,0 +1,79 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountExporter(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	annotations := mock.NewAnnotationStore()
+
+	first, err := accountStore.Create("first@keratin.tech", []byte("$2a$10$hash"))
+	require.NoError(t, err)
+	require.NoError(t, accountStore.AddOauthAccount(first.ID, "google", "123", "secret-token"))
+	require.NoError(t, annotations.SetMetadata(first.ID, map[string]string{"plan": "pro"}))
+	second, err := accountStore.Create("second@keratin.tech", []byte("$2a$10$hash"))
+	require.NoError(t, err)
+
+	each := func(fn func(*models.Account) error) error {
+		for _, id := range []int{first.ID, second.ID} {
+			account, err := accountStore.Find(id)
+			require.NoError(t, err)
+			if err := fn(account); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	t.Run("jsonl", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := services.AccountExporter(each, accountStore, annotations, &buf, services.ExportOptions{
+			Format:          "jsonl",
+			IncludeOauth:    true,
+			IncludeMetadata: true,
+		})
+		require.NoError(t, err)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 2)
+
+		exported := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &exported))
+		assert.Equal(t, "first@keratin.tech", exported["username"])
+		assert.NotContains(t, exported, "password")
+		assert.Equal(t, []interface{}{map[string]interface{}{"provider": "google", "provider_id": "123"}}, exported["oauth_accounts"])
+		assert.Equal(t, map[string]interface{}{"plan": "pro"}, exported["metadata"])
+		assert.NotContains(t, lines[0], "secret-token")
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := services.AccountExporter(each, accountStore, annotations, &buf, services.ExportOptions{
+			Format:        "csv",
+			IncludeOauth:  true,
+			IncludeHashes: true,
+		})
+		require.NoError(t, err)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 3)
+		assert.Equal(t, "id,username,password,locked,require_new_password,password_changed_at,created_at,updated_at,deleted_at,archive_at,expires_at,oauth_accounts", lines[0])
+		assert.Contains(t, lines[1], "first@keratin.tech,$2a$10$hash,false,false,")
+		assert.True(t, strings.HasSuffix(lines[1], ",google:123"))
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		err := services.AccountExporter(each, accountStore, annotations, &bytes.Buffer{}, services.ExportOptions{Format: "xml"})
+		assert.Error(t, err)
+	})
+}
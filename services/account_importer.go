@@ -3,10 +3,9 @@ package services
 import (
 	"regexp"
 
-	"golang.org/x/crypto/bcrypt"
-
 	"github.com/keratin/authn-server/config"
 	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/hashes"
 	"github.com/keratin/authn-server/models"
 	"github.com/pkg/errors"
 )
@@ -23,10 +22,10 @@ func AccountImporter(store data.AccountStore, cfg *config.Config, username strin
 
 	var hash []byte
 	var err error
-	if bcryptPattern.Match([]byte(password)) {
+	if bcryptPattern.Match([]byte(password)) || hashes.IsTagged([]byte(password)) {
 		hash = []byte(password)
 	} else {
-		hash, err = bcrypt.GenerateFromPassword([]byte(password), cfg.BcryptCost)
+		hash, err = generateFromPassword(cfg, []byte(password))
 		if err != nil {
 			return nil, errors.Wrap(err, "bcrypt")
 		}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/data"
+	"github.com/pkg/errors"
+)
+
+// AccountLegalHoldSetter places or releases a legal hold. While held, an account may not be
+// archived, by request or by any scheduled job, and its kept username is not purged.
+func AccountLegalHoldSetter(store data.AccountStore, accountID int, held bool) error {
+	account, err := store.Find(accountID)
+	if err != nil {
+		return errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return FieldErrors{{"account", ErrNotFound}}
+	}
+
+	err = store.SetLegalHold(account.ID, held)
+	if err != nil {
+		return errors.Wrap(err, "SetLegalHold")
+	}
+	return nil
+}
+
+// isLegalHold reports whether the error is from an account under a legal hold.
+func isLegalHold(err error) bool {
+	fe, ok := err.(FieldErrors)
+	return ok && len(fe) > 0 && fe[0].Message == ErrLegalHold
+}
@@ -0,0 +1,34 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountLegalHoldSetter(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+
+	t.Run("unknown account", func(t *testing.T) {
+		err := services.AccountLegalHoldSetter(accountStore, 999, true)
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrNotFound}}, err)
+	})
+
+	t.Run("placing and releasing", func(t *testing.T) {
+		account, err := accountStore.Create("held@test.com", []byte("password"))
+		require.NoError(t, err)
+
+		require.NoError(t, services.AccountLegalHoldSetter(accountStore, account.ID, true))
+		account, err = accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.True(t, account.LegalHold)
+
+		require.NoError(t, services.AccountLegalHoldSetter(accountStore, account.ID, false))
+		account, err = accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.False(t, account.LegalHold)
+	})
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// maxNoteLength keeps notes to a reasonable size for display in admin tooling.
+const maxNoteLength = 10000
+
+func AccountNoteCreator(store data.AccountStore, annotations data.AnnotationStore, accountID int, body string) (*models.AccountNote, error) {
+	if body == "" {
+		return nil, FieldErrors{{"body", ErrMissing}}
+	}
+	if len(body) > maxNoteLength {
+		return nil, FieldErrors{{"body", ErrFormatInvalid}}
+	}
+
+	account, err := store.Find(accountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return nil, FieldErrors{{"account", ErrNotFound}}
+	}
+
+	note, err := annotations.AddNote(account.ID, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "AddNote")
+	}
+	return note, nil
+}
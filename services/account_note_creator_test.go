@@ -0,0 +1,44 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountNoteCreator(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	annotations := mock.NewAnnotationStore()
+
+	account, err := accountStore.Create("noted@keratin.tech", []byte("password"))
+	require.NoError(t, err)
+
+	t.Run("valid note", func(t *testing.T) {
+		note, err := services.AccountNoteCreator(accountStore, annotations, account.ID, "called support")
+		require.NoError(t, err)
+		assert.Equal(t, "called support", note.Body)
+
+		notes, err := annotations.GetNotes(account.ID)
+		require.NoError(t, err)
+		assert.Len(t, notes, 1)
+	})
+
+	testCases := []struct {
+		accountID int
+		body      string
+		errors    services.FieldErrors
+	}{
+		{account.ID, "", services.FieldErrors{{"body", services.ErrMissing}}},
+		{account.ID, strings.Repeat("a", 10001), services.FieldErrors{{"body", services.ErrFormatInvalid}}},
+		{account.ID + 1, "note", services.FieldErrors{{"account", services.ErrNotFound}}},
+	}
+
+	for _, tc := range testCases {
+		_, err := services.AccountNoteCreator(accountStore, annotations, tc.accountID, tc.body)
+		assert.Equal(t, tc.errors, err)
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// EmailVerifiedMetadata is the metadata name that records a provisioned account's email as
+// verified. AuthN does not verify emails itself, so this is only a record for the application.
+const EmailVerifiedMetadata = "email_verified"
+
+// Provision describes the initial state of an account created by a backoffice system.
+type Provision struct {
+	Username      string
+	Password      string
+	Locked        bool
+	EmailVerified bool
+	ExpiresAt     *time.Time
+	Metadata      map[string]string
+	Roles         []string
+}
+
+// AccountProvisioner creates an account with its initial state in place. Like an import, the
+// password may be an existing BCrypt hash and is not validated for complexity, but roles are
+// validated as tags before anything is created.
+func AccountProvisioner(store data.AccountStore, annotations data.AnnotationStore, cfg *config.Config, p Provision) (*models.Account, error) {
+	for _, role := range p.Roles {
+		if !tagPattern.MatchString(role) {
+			return nil, FieldErrors{{"roles", ErrFormatInvalid}}
+		}
+	}
+
+	account, err := AccountImporter(store, cfg, p.Username, p.Password, p.Locked)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.ExpiresAt != nil {
+		err = store.SetExpiry(account.ID, p.ExpiresAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "SetExpiry")
+		}
+		account.ExpiresAt = p.ExpiresAt
+	}
+
+	metadata := map[string]string{}
+	for name, value := range p.Metadata {
+		metadata[name] = value
+	}
+	if p.EmailVerified {
+		metadata[EmailVerifiedMetadata] = "true"
+	}
+	if len(metadata) > 0 {
+		err = annotations.SetMetadata(account.ID, metadata)
+		if err != nil {
+			return nil, errors.Wrap(err, "SetMetadata")
+		}
+	}
+
+	for _, role := range p.Roles {
+		err = annotations.Tag(account.ID, role)
+		if err != nil {
+			return nil, errors.Wrap(err, "Tag")
+		}
+	}
+
+	return account, nil
+}
@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountProvisioner(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	annotations := mock.NewAnnotationStore()
+	cfg := &config.Config{
+		BcryptCost: 4,
+	}
+
+	t.Run("with initial state", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+		account, err := services.AccountProvisioner(accountStore, annotations, cfg, services.Provision{
+			Username:      "provisioned@keratin.tech",
+			Password:      "secret",
+			Locked:        true,
+			EmailVerified: true,
+			ExpiresAt:     &expiresAt,
+			Metadata:      map[string]string{"plan": "enterprise"},
+			Roles:         []string{"admin", "billing"},
+		})
+		require.NoError(t, err)
+
+		found, err := accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.True(t, found.Locked)
+		require.NotNil(t, found.ExpiresAt)
+		assert.Equal(t, expiresAt.Unix(), found.ExpiresAt.Unix())
+
+		metadata, err := annotations.GetMetadata(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"plan": "enterprise", "email_verified": "true"}, metadata)
+
+		tags, err := annotations.GetTags(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin", "billing"}, tags)
+	})
+
+	t.Run("with only credentials", func(t *testing.T) {
+		account, err := services.AccountProvisioner(accountStore, annotations, cfg, services.Provision{
+			Username: "plain@keratin.tech",
+			Password: "secret",
+		})
+		require.NoError(t, err)
+		assert.False(t, account.Locked)
+		assert.Nil(t, account.ExpiresAt)
+
+		metadata, err := annotations.GetMetadata(account.ID)
+		require.NoError(t, err)
+		assert.Empty(t, metadata)
+	})
+
+	t.Run("with an invalid role", func(t *testing.T) {
+		_, err := services.AccountProvisioner(accountStore, annotations, cfg, services.Provision{
+			Username: "invalid-role@keratin.tech",
+			Password: "secret",
+			Roles:    []string{"has spaces"},
+		})
+		assert.Equal(t, services.FieldErrors{{"roles", services.ErrFormatInvalid}}, err)
+
+		account, err := accountStore.FindByUsername("invalid-role@keratin.tech")
+		require.NoError(t, err)
+		assert.Nil(t, account)
+	})
+
+	t.Run("with a taken username", func(t *testing.T) {
+		_, err := services.AccountProvisioner(accountStore, annotations, cfg, services.Provision{
+			Username: "plain@keratin.tech",
+			Password: "secret",
+		})
+		assert.Equal(t, services.FieldErrors{{"username", services.ErrTaken}}, err)
+	})
+}
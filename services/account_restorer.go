@@ -0,0 +1,44 @@
+package services
+
+import (
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/pkg/errors"
+)
+
+// AccountRestorer undoes an archive within the configured window by restoring the account's
+// username. Since archiving erased the password, the account must choose a new one.
+func AccountRestorer(store data.AccountStore, cfg *config.Config, accountID int) error {
+	account, err := store.Find(accountID)
+	if err != nil {
+		return errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return FieldErrors{{"account", ErrNotFound}}
+	}
+	if !account.Archived() {
+		return nil
+	}
+	if time.Since(*account.DeletedAt) > cfg.AccountRestoreWindow {
+		return FieldErrors{{"account", ErrExpired}}
+	}
+
+	username, err := store.FindArchivedUsername(account.ID)
+	if err != nil {
+		return errors.Wrap(err, "FindArchivedUsername")
+	}
+	if username == nil {
+		return FieldErrors{{"account", ErrExpired}}
+	}
+
+	err = store.Restore(account.ID, string(username))
+	if err != nil {
+		if data.IsUniquenessError(err) {
+			return FieldErrors{{"username", ErrTaken}}
+		}
+		return errors.Wrap(err, "Restore")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountRestorer(t *testing.T) {
+	accountStore := data.NewRestorableAccountStore(mock.NewAccountStore(), []byte("DLz2TNDRdWWA5w8YNeCJ7uzcS4WDzQmB"))
+	cfg := &config.Config{AccountRestoreWindow: time.Hour}
+
+	t.Run("archived account", func(t *testing.T) {
+		account, err := accountStore.Create("restored@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		require.NoError(t, accountStore.Archive(account.ID))
+
+		err = services.AccountRestorer(accountStore, cfg, account.ID)
+		require.NoError(t, err)
+
+		found, err := accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "restored@keratin.tech", found.Username)
+		assert.False(t, found.Archived())
+		assert.True(t, found.RequireNewPassword)
+	})
+
+	t.Run("reclaimed username", func(t *testing.T) {
+		account, err := accountStore.Create("reclaimed@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		require.NoError(t, accountStore.Archive(account.ID))
+		_, err = accountStore.Create("reclaimed@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		err = services.AccountRestorer(accountStore, cfg, account.ID)
+		assert.Equal(t, services.FieldErrors{{"username", services.ErrTaken}}, err)
+	})
+
+	t.Run("outside the window", func(t *testing.T) {
+		account, err := accountStore.Create("expired@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		require.NoError(t, accountStore.Archive(account.ID))
+
+		err = services.AccountRestorer(accountStore, &config.Config{AccountRestoreWindow: time.Nanosecond}, account.ID)
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrExpired}}, err)
+	})
+
+	t.Run("forgotten username", func(t *testing.T) {
+		account, err := accountStore.Create("forgotten@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		require.NoError(t, accountStore.Archive(account.ID))
+		_, err = accountStore.PurgeArchivedUsernames(time.Now().Add(time.Second))
+		require.NoError(t, err)
+
+		err = services.AccountRestorer(accountStore, cfg, account.ID)
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrExpired}}, err)
+	})
+
+	t.Run("active account", func(t *testing.T) {
+		account, err := accountStore.Create("active@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		err = services.AccountRestorer(accountStore, cfg, account.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		err := services.AccountRestorer(accountStore, cfg, 999)
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrNotFound}}, err)
+	})
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// SeedPassword is the password of every seeded account.
+const SeedPassword = "password"
+
+type seed struct {
+	username           string
+	locked             bool
+	requireNewPassword bool
+	oauthProvider      string
+}
+
+// AuthN has no concept of an unverified account, so the closest variant is an account that must
+// reset its password before logging in.
+var seeds = []seed{
+	{username: "active@example.com"},
+	{username: "locked@example.com", locked: true},
+	{username: "expired@example.com", requireNewPassword: true},
+	{username: "oauth@example.com", oauthProvider: "google"},
+}
+
+// AccountSeeder creates a fixed set of accounts for local development and QA. It is safe to run
+// repeatedly: accounts that already exist are left alone and returned as they are.
+func AccountSeeder(store data.AccountStore, cfg *config.Config) ([]*models.Account, error) {
+	accounts := make([]*models.Account, 0, len(seeds))
+	for _, s := range seeds {
+		account, err := store.FindByUsername(s.username)
+		if err != nil {
+			return nil, errors.Wrap(err, "FindByUsername")
+		}
+		if account != nil {
+			accounts = append(accounts, account)
+			continue
+		}
+
+		account, err = AccountImporter(store, cfg, s.username, SeedPassword, s.locked)
+		if err != nil {
+			return nil, errors.Wrap(err, "AccountImporter")
+		}
+
+		if s.requireNewPassword {
+			err = store.RequireNewPassword(account.ID)
+			if err != nil {
+				return nil, errors.Wrap(err, "RequireNewPassword")
+			}
+			account.RequireNewPassword = true
+		}
+
+		if s.oauthProvider != "" {
+			err = store.AddOauthAccount(account.ID, s.oauthProvider, "seed-"+s.username, "seed-token")
+			if err != nil {
+				return nil, errors.Wrap(err, "AddOauthAccount")
+			}
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,41 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountSeeder(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	cfg := &config.Config{
+		BcryptCost: 4,
+	}
+
+	accounts, err := services.AccountSeeder(accountStore, cfg)
+	require.NoError(t, err)
+	assert.Len(t, accounts, 4)
+
+	locked, err := accountStore.FindByUsername("locked@example.com")
+	require.NoError(t, err)
+	assert.True(t, locked.Locked)
+
+	expired, err := accountStore.FindByUsername("expired@example.com")
+	require.NoError(t, err)
+	assert.True(t, expired.RequireNewPassword)
+
+	linked, err := accountStore.FindByOauthAccount("google", "seed-oauth@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, linked)
+	assert.Equal(t, "oauth@example.com", linked.Username)
+
+	again, err := services.AccountSeeder(accountStore, cfg)
+	require.NoError(t, err)
+	for i := range accounts {
+		assert.Equal(t, accounts[i].ID, again[i].ID)
+	}
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"regexp"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/pkg/errors"
+)
+
+var tagPattern = regexp.MustCompile(`\A[A-Za-z0-9_.:-]{1,64}\z`)
+
+func AccountTagger(store data.AccountStore, annotations data.AnnotationStore, accountID int, tag string) error {
+	if tag == "" {
+		return FieldErrors{{"tag", ErrMissing}}
+	}
+	if !tagPattern.MatchString(tag) {
+		return FieldErrors{{"tag", ErrFormatInvalid}}
+	}
+
+	account, err := store.Find(accountID)
+	if err != nil {
+		return errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return FieldErrors{{"account", ErrNotFound}}
+	}
+
+	return errors.Wrap(annotations.Tag(account.ID, tag), "Tag")
+}
@@ -0,0 +1,44 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountTagger(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	annotations := mock.NewAnnotationStore()
+
+	account, err := accountStore.Create("tagged@keratin.tech", []byte("password"))
+	require.NoError(t, err)
+
+	t.Run("valid tag", func(t *testing.T) {
+		err := services.AccountTagger(accountStore, annotations, account.ID, "fraud-review")
+		assert.NoError(t, err)
+
+		tags, err := annotations.GetTags(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"fraud-review"}, tags)
+	})
+
+	testCases := []struct {
+		accountID int
+		tag       string
+		errors    services.FieldErrors
+	}{
+		{account.ID, "", services.FieldErrors{{"tag", services.ErrMissing}}},
+		{account.ID, "has spaces", services.FieldErrors{{"tag", services.ErrFormatInvalid}}},
+		{account.ID, strings.Repeat("a", 65), services.FieldErrors{{"tag", services.ErrFormatInvalid}}},
+		{account.ID + 1, "vip", services.FieldErrors{{"account", services.ErrNotFound}}},
+	}
+
+	for _, tc := range testCases {
+		err := services.AccountTagger(accountStore, annotations, tc.accountID, tc.tag)
+		assert.Equal(t, tc.errors, err)
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// AccountETag identifies the current state of an account. It changes whenever the account is
+// updated.
+func AccountETag(account *models.Account) string {
+	return fmt.Sprintf(`"%d-%d"`, account.Version, account.UpdatedAt.UnixNano())
+}
+
+// AccountVersionClaimer checks an If-Match header against the account's current ETag and claims
+// the account's version, so that of two concurrent changes from the same ETag only one succeeds.
+// Unknown accounts are left for the caller to report.
+func AccountVersionClaimer(store data.AccountStore, accountID int, ifMatch string) error {
+	account, err := store.Find(accountID)
+	if err != nil {
+		return errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return nil
+	}
+
+	current := AccountETag(account)
+	matched := false
+	for _, etag := range strings.Split(ifMatch, ",") {
+		etag = strings.TrimSpace(etag)
+		if etag == "*" || etag == current {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return FieldErrors{{"account", ErrStale}}
+	}
+
+	claimed, err := store.ClaimVersion(account.ID, account.Version)
+	if err != nil {
+		return errors.Wrap(err, "ClaimVersion")
+	}
+	if !claimed {
+		return FieldErrors{{"account", ErrStale}}
+	}
+	return nil
+}
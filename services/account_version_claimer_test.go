@@ -0,0 +1,46 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountVersionClaimer(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	account, err := accountStore.Create("versioned@keratin.tech", []byte("password"))
+	require.NoError(t, err)
+	etag := services.AccountETag(account)
+
+	t.Run("stale ETag", func(t *testing.T) {
+		err := services.AccountVersionClaimer(accountStore, account.ID, `"0-0"`)
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrStale}}, err)
+	})
+
+	t.Run("current ETag among several", func(t *testing.T) {
+		err := services.AccountVersionClaimer(accountStore, account.ID, `"0-0", `+etag)
+		require.NoError(t, err)
+
+		found, err := accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.NotEqual(t, etag, services.AccountETag(found))
+	})
+
+	t.Run("reused ETag", func(t *testing.T) {
+		err := services.AccountVersionClaimer(accountStore, account.ID, etag)
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrStale}}, err)
+	})
+
+	t.Run("any version", func(t *testing.T) {
+		err := services.AccountVersionClaimer(accountStore, account.ID, "*")
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		err := services.AccountVersionClaimer(accountStore, 999, etag)
+		assert.NoError(t, err)
+	})
+}
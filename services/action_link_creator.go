@@ -0,0 +1,31 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/tokens/actions"
+	"github.com/pkg/errors"
+)
+
+// ActionLinkCreator returns the claims for a link that performs an action on an account once. The
+// link is bound to the account's current version, so any change claimed through If-Match, or any
+// other link, will also invalidate it.
+func ActionLinkCreator(store data.AccountStore, cfg *config.Config, accountID int, action string) (*actions.Claims, error) {
+	account, err := store.Find(accountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return nil, FieldErrors{{"account", ErrNotFound}}
+	} else if account.Archived() {
+		return nil, FieldErrors{{"account", ErrLocked}}
+	}
+
+	if action == "" {
+		return nil, FieldErrors{{"action", ErrMissing}}
+	} else if !actions.Valid(action) {
+		return nil, FieldErrors{{"action", ErrFormatInvalid}}
+	}
+
+	return actions.New(cfg, account.ID, action, account.Version)
+}
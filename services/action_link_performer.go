@@ -0,0 +1,56 @@
+package services
+
+import (
+	"strconv"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/tokens/actions"
+	"github.com/pkg/errors"
+)
+
+// ActionLinkPerformer performs the action of a link's token, and returns its claims. The token's
+// version is claimed before the action is performed, so that of two visits only one may act, even
+// if the action then fails. Quotas may be nil when throttling is not configured.
+func ActionLinkPerformer(store data.AccountStore, tokenStore data.RefreshTokenStore, quotas data.Quotas, cfg *config.Config, token string) (*actions.Claims, error) {
+	claims, err := actions.Parse(token, cfg)
+	if err != nil {
+		return nil, FieldErrors{{"token", ErrInvalidOrExpired}}
+	}
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, errors.Wrap(err, "Atoi")
+	}
+
+	account, err := store.Find(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return nil, FieldErrors{{"account", ErrNotFound}}
+	} else if account.Archived() {
+		return nil, FieldErrors{{"account", ErrLocked}}
+	}
+
+	claimed, err := store.ClaimVersion(account.ID, claims.Version)
+	if err != nil {
+		return nil, errors.Wrap(err, "ClaimVersion")
+	}
+	if !claimed {
+		return nil, FieldErrors{{"token", ErrInvalidOrExpired}}
+	}
+
+	switch claims.Action {
+	case actions.Unlock:
+		err = AccountUnlocker(store, account.ID)
+	case actions.Unthrottle:
+		err = AccountUnthrottler(store, quotas, account.ID)
+	case actions.Archive:
+		err = AccountArchiver(store, tokenStore, account.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
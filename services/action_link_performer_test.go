@@ -0,0 +1,90 @@
+package services_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/actions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionLinkPerformer(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	tokenStore := mock.NewRefreshTokenStore()
+	cfg := &config.Config{
+		AuthNURL:             &url.URL{Scheme: "https", Host: "authn.example.com"},
+		ActionLinkSigningKey: []byte("key-a-reno"),
+		ActionLinkTTL:        time.Hour,
+	}
+
+	newToken := func(id int, action string) string {
+		claims, err := services.ActionLinkCreator(accountStore, cfg, id, action)
+		require.NoError(t, err)
+		token, err := claims.Sign(cfg.ActionLinkSigningKey)
+		require.NoError(t, err)
+		return token
+	}
+
+	t.Run("unlocks once", func(t *testing.T) {
+		account, err := accountStore.Create("unlock@test.com", []byte("password"))
+		require.NoError(t, err)
+		require.NoError(t, accountStore.Lock(account.ID))
+		token := newToken(account.ID, actions.Unlock)
+
+		claims, err := services.ActionLinkPerformer(accountStore, tokenStore, nil, cfg, token)
+		require.NoError(t, err)
+		assert.Equal(t, actions.Unlock, claims.Action)
+		account, err = accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.False(t, account.Locked)
+
+		_, err = services.ActionLinkPerformer(accountStore, tokenStore, nil, cfg, token)
+		assert.Equal(t, services.FieldErrors{{"token", services.ErrInvalidOrExpired}}, err)
+	})
+
+	t.Run("account changed since the link was issued", func(t *testing.T) {
+		account, err := accountStore.Create("changed@test.com", []byte("password"))
+		require.NoError(t, err)
+		token := newToken(account.ID, actions.Unlock)
+		err = services.AccountVersionClaimer(accountStore, account.ID, "*")
+		require.NoError(t, err)
+
+		_, err = services.ActionLinkPerformer(accountStore, tokenStore, nil, cfg, token)
+		assert.Equal(t, services.FieldErrors{{"token", services.ErrInvalidOrExpired}}, err)
+	})
+
+	t.Run("archives and revokes sessions", func(t *testing.T) {
+		account, err := accountStore.Create("archive@test.com", []byte("password"))
+		require.NoError(t, err)
+		_, err = tokenStore.Create(account.ID)
+		require.NoError(t, err)
+
+		_, err = services.ActionLinkPerformer(accountStore, tokenStore, nil, cfg, newToken(account.ID, actions.Archive))
+		require.NoError(t, err)
+		account, err = accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.True(t, account.Archived())
+		tokens, err := tokenStore.FindAll(account.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+
+	t.Run("archiving an account on legal hold", func(t *testing.T) {
+		account, err := accountStore.Create("held@test.com", []byte("password"))
+		require.NoError(t, err)
+		require.NoError(t, accountStore.SetLegalHold(account.ID, true))
+
+		_, err = services.ActionLinkPerformer(accountStore, tokenStore, nil, cfg, newToken(account.ID, actions.Archive))
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrLegalHold}}, err)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		_, err := services.ActionLinkPerformer(accountStore, tokenStore, nil, cfg, "not-a-token")
+		assert.Equal(t, services.FieldErrors{{"token", services.ErrInvalidOrExpired}}, err)
+	})
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// APIKeyCreator creates a key with the scopes, and returns it with its secret. Only a hash of the
+// secret is stored, so it can not be shown again.
+func APIKeyCreator(store data.APIKeyStore, name string, scopes []string) (*models.APIKey, string, error) {
+	errs := FieldErrors{}
+	if strings.TrimSpace(name) == "" {
+		errs = append(errs, fieldError{"name", ErrMissing})
+	}
+	if len(scopes) == 0 {
+		errs = append(errs, fieldError{"scopes", ErrMissing})
+	}
+	for _, scope := range scopes {
+		if !validAPIKeyScope(scope) {
+			errs = append(errs, fieldError{"scopes", ErrFormatInvalid})
+			break
+		}
+	}
+	if len(errs) > 0 {
+		return nil, "", errs
+	}
+
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, "", errors.Wrap(err, "rand.Read")
+	}
+	secret := base64.RawURLEncoding.EncodeToString(bytes)
+
+	key, err := store.Create(strings.TrimSpace(name), strings.Join(scopes, " "), hashAPIKeySecret(secret))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Create")
+	}
+	return key, secret, nil
+}
+
+func validAPIKeyScope(scope string) bool {
+	for _, valid := range models.APIKeyScopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// hashAPIKeySecret does not need a slow hash like bcrypt, because secrets are random and long
+// enough that they can not be guessed.
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
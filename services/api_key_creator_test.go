@@ -0,0 +1,38 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyCreator(t *testing.T) {
+	store := mock.NewAPIKeyStore()
+
+	t.Run("valid key", func(t *testing.T) {
+		key, secret, err := services.APIKeyCreator(store, "billing", []string{"read-only", "stats"})
+		require.NoError(t, err)
+		assert.Equal(t, "billing", key.Name)
+		assert.Equal(t, []string{"read-only", "stats"}, key.ScopeList())
+		assert.NotEmpty(t, secret)
+		assert.NotContains(t, key.SecretHash, secret)
+	})
+
+	testCases := []struct {
+		name   string
+		scopes []string
+		errors services.FieldErrors
+	}{
+		{"", []string{"stats"}, services.FieldErrors{{"name", services.ErrMissing}}},
+		{"billing", nil, services.FieldErrors{{"scopes", services.ErrMissing}}},
+		{"billing", []string{"stats", "root"}, services.FieldErrors{{"scopes", services.ErrFormatInvalid}}},
+	}
+
+	for _, tc := range testCases {
+		_, _, err := services.APIKeyCreator(store, tc.name, tc.scopes)
+		assert.Equal(t, tc.errors, err)
+	}
+}
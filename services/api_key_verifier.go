@@ -0,0 +1,36 @@
+package services
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"strings"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// APIKeyVerifier returns the key for Basic Auth credentials, or nil when they do not belong to an
+// active key.
+func APIKeyVerifier(store data.APIKeyStore, username string, secret string) (*models.APIKey, error) {
+	if !strings.HasPrefix(username, models.APIKeyUsernamePrefix) {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(username, models.APIKeyUsernamePrefix))
+	if err != nil {
+		return nil, nil
+	}
+
+	key, err := store.Find(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+	if key == nil || key.Revoked() {
+		return nil, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(key.SecretHash)) != 1 {
+		return nil, nil
+	}
+	return key, nil
+}
@@ -0,0 +1,45 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyVerifier(t *testing.T) {
+	store := mock.NewAPIKeyStore()
+	key, secret, err := services.APIKeyCreator(store, "billing", []string{"read-only"})
+	require.NoError(t, err)
+
+	t.Run("valid credentials", func(t *testing.T) {
+		found, err := services.APIKeyVerifier(store, key.Username(), secret)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, key.ID, found.ID)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		for _, creds := range [][2]string{
+			{key.Username(), "wrong"},
+			{"key_999", secret},
+			{"key_abc", secret},
+			{"admin", secret},
+		} {
+			found, err := services.APIKeyVerifier(store, creds[0], creds[1])
+			require.NoError(t, err)
+			assert.Nil(t, found, creds[0])
+		}
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		_, err := store.Revoke(key.ID)
+		require.NoError(t, err)
+
+		found, err := services.APIKeyVerifier(store, key.Username(), secret)
+		require.NoError(t, err)
+		assert.Nil(t, found)
+	})
+}
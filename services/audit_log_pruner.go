@@ -0,0 +1,109 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/s3"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// auditPruneBatch bounds how many events are archived in one object and deleted at once.
+const auditPruneBatch = 1000
+
+// archivedAuditEvent is one line of an archive. The hashes are kept so that archives may be
+// verified too.
+type archivedAuditEvent struct {
+	Cursor   string `json:"cursor"`
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
+	events.Event
+}
+
+// AuditLogPruner deletes every event that happened before the time, and returns how many were
+// deleted. Events of accounts under a legal hold are kept. When a bucket is given, each batch is
+// archived as gzipped NDJSON before it is deleted, and a batch that fails to archive is kept for
+// the next run.
+func AuditLogPruner(log data.AuditLog, store data.AccountStore, bucket *s3.Bucket, before time.Time) (int, error) {
+	pruned := 0
+	held := map[int]bool{}
+	cursor := ""
+	for {
+		entries, err := log.FindBefore(before, cursor, auditPruneBatch)
+		if err != nil {
+			return pruned, errors.Wrap(err, "FindBefore")
+		}
+		if len(entries) == 0 {
+			return pruned, nil
+		}
+		cursor = entries[len(entries)-1].Cursor
+
+		prunable := make([]models.AuditEntry, 0, len(entries))
+		for _, entry := range entries {
+			isHeld, ok := held[entry.Event.AccountID]
+			if !ok {
+				account, err := store.Find(entry.Event.AccountID)
+				if err != nil {
+					return pruned, errors.Wrap(err, "Find")
+				}
+				isHeld = account != nil && account.LegalHold
+				held[entry.Event.AccountID] = isHeld
+			}
+			if !isHeld {
+				prunable = append(prunable, entry)
+			}
+		}
+
+		if len(prunable) > 0 {
+			if bucket != nil {
+				err = archiveAuditEntries(bucket, prunable)
+				if err != nil {
+					return pruned, errors.Wrap(err, "archiveAuditEntries")
+				}
+			}
+
+			err = log.Delete(prunable)
+			if err != nil {
+				return pruned, errors.Wrap(err, "Delete")
+			}
+			pruned += len(prunable)
+		}
+
+		if len(entries) < auditPruneBatch {
+			return pruned, nil
+		}
+	}
+}
+
+// archiveAuditEntries uploads a batch to a key like audit/2020/01/31/<first>-<last>.ndjson.gz,
+// dated by the first event. Cursors never repeat, so a batch that is retried overwrites itself.
+func archiveAuditEntries(bucket *s3.Bucket, entries []models.AuditEntry) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, entry := range entries {
+		err := enc.Encode(archivedAuditEvent{
+			Cursor:   entry.Cursor,
+			PrevHash: entry.PrevHash,
+			Hash:     entry.Hash,
+			Event:    entry.Event,
+		})
+		if err != nil {
+			return errors.Wrap(err, "Encode")
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return errors.Wrap(err, "Close")
+	}
+
+	first := entries[0]
+	last := entries[len(entries)-1]
+	key := fmt.Sprintf("audit/%s/%s-%s.ndjson.gz", first.Event.Time.UTC().Format("2006/01/02"), first.Cursor, last.Cursor)
+	return bucket.Put(key, buf.Bytes(), "application/x-ndjson", "gzip")
+}
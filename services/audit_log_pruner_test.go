@@ -0,0 +1,143 @@
+package services_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/s3"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogPruner(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	appendEvents := func(log interface{ Append(events.Event) error }) {
+		for i, age := range []time.Duration{72 * time.Hour, 48 * time.Hour, time.Hour} {
+			err := log.Append(events.Event{
+				ID:        string(rune('a' + i)),
+				Type:      events.AccountUpdated,
+				AccountID: 1,
+				Time:      now.Add(-age),
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	t.Run("without archival", func(t *testing.T) {
+		log := mock.NewAuditLog([]byte("key"))
+		appendEvents(log)
+
+		pruned, err := services.AuditLogPruner(log, mock.NewAccountStore(), nil, now.Add(-24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 2, pruned)
+
+		entries, err := log.Find(0, "", 10)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "c", entries[0].Event.ID)
+	})
+
+	t.Run("with a legal hold", func(t *testing.T) {
+		store := mock.NewAccountStore()
+		held, err := store.Create("held@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		require.NoError(t, store.SetLegalHold(held.ID, true))
+		other, err := store.Create("other@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		log := mock.NewAuditLog([]byte("key"))
+		for i, accountID := range []int{held.ID, other.ID, held.ID} {
+			err := log.Append(events.Event{
+				ID:        string(rune('a' + i)),
+				Type:      events.AccountUpdated,
+				AccountID: accountID,
+				Time:      now.Add(-48 * time.Hour),
+			})
+			require.NoError(t, err)
+		}
+
+		pruned, err := services.AuditLogPruner(log, store, nil, now.Add(-24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, pruned)
+
+		entries, err := log.Find(0, "", 10)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "c", entries[0].Event.ID)
+		assert.Equal(t, "a", entries[1].Event.ID)
+	})
+
+	t.Run("with archival", func(t *testing.T) {
+		uploads := map[string][]byte{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			uploads[r.URL.Path] = body
+		}))
+		defer server.Close()
+		bucket := newTestBucket(t, server.URL+"/archive")
+
+		log := mock.NewAuditLog([]byte("key"))
+		appendEvents(log)
+
+		pruned, err := services.AuditLogPruner(log, mock.NewAccountStore(), bucket, now.Add(-24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 2, pruned)
+
+		key := "/archive/audit/" + now.Add(-72*time.Hour).Format("2006/01/02") + "/1-2.ndjson.gz"
+		require.Contains(t, uploads, key)
+		zr, err := gzip.NewReader(bytes.NewReader(uploads[key]))
+		require.NoError(t, err)
+		lines := []map[string]interface{}{}
+		scanner := bufio.NewScanner(zr)
+		for scanner.Scan() {
+			line := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			lines = append(lines, line)
+		}
+		require.Len(t, lines, 2)
+		assert.Equal(t, "1", lines[0]["cursor"])
+		assert.Equal(t, "a", lines[0]["id"])
+		assert.Equal(t, "b", lines[1]["id"])
+		assert.NotEmpty(t, lines[0]["hash"])
+		assert.Equal(t, lines[0]["hash"], lines[1]["prev_hash"])
+	})
+
+	t.Run("with failed archival", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		bucket := newTestBucket(t, server.URL+"/archive")
+
+		log := mock.NewAuditLog([]byte("key"))
+		appendEvents(log)
+
+		pruned, err := services.AuditLogPruner(log, mock.NewAccountStore(), bucket, now.Add(-24*time.Hour))
+		assert.Error(t, err)
+		assert.Equal(t, 0, pruned)
+
+		entries, err := log.Find(0, "", 10)
+		require.NoError(t, err)
+		assert.Len(t, entries, 3)
+	})
+}
+
+func newTestBucket(t *testing.T, raw string) *s3.Bucket {
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	u.User = url.UserPassword("AKID", "secret")
+	bucket, err := s3.NewBucket(u)
+	require.NoError(t, err)
+	return bucket
+}
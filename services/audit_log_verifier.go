@@ -0,0 +1,69 @@
+package services
+
+import (
+	"crypto/hmac"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// AuditLogVerification is the result of walking the audit log's hash chain.
+type AuditLogVerification struct {
+	// Verified is how many entries were checked before the chain broke, or in total.
+	Verified int
+	// Unsigned is how many of the oldest entries were recorded before the log was chained.
+	Unsigned int
+	// BrokenAt is the cursor of the first entry that does not match its hash or does not chain from
+	// the entry before it. It is empty when the chain is intact.
+	BrokenAt string
+}
+
+// AuditLogVerifier walks the audit log from the oldest entry and checks that each entry matches its
+// hash and chains from the entry before it. The oldest remaining entry is trusted to chain from a
+// pruned entry, so removing the oldest entries can not be told apart from pruning.
+func AuditLogVerifier(log data.AuditLog, key []byte) (*AuditLogVerification, error) {
+	result := &AuditLogVerification{}
+	prevHash := ""
+	cursor := ""
+	for {
+		entries, err := log.FindAfter(cursor, auditPruneBatch)
+		if err != nil {
+			return nil, errors.Wrap(err, "FindAfter")
+		}
+
+		for _, entry := range entries {
+			if entry.Hash == "" && result.Verified == 0 {
+				result.Unsigned++
+				continue
+			}
+			ok, err := chains(key, prevHash, result.Verified > 0, entry)
+			if err != nil {
+				return nil, errors.Wrap(err, "chains")
+			}
+			if !ok {
+				result.BrokenAt = entry.Cursor
+				return result, nil
+			}
+			result.Verified++
+			prevHash = entry.Hash
+		}
+
+		if len(entries) < auditPruneBatch {
+			return result, nil
+		}
+		cursor = entries[len(entries)-1].Cursor
+	}
+}
+
+// chains reports whether the entry matches its hash, and when linked, whether it follows prevHash.
+func chains(key []byte, prevHash string, linked bool, entry models.AuditEntry) (bool, error) {
+	if entry.Hash == "" || (linked && entry.PrevHash != prevHash) {
+		return false, nil
+	}
+	expected, err := models.AuditHash(key, entry.PrevHash, entry.Cursor, entry.Event)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal([]byte(expected), []byte(entry.Hash)), nil
+}
@@ -0,0 +1,107 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tamperedAuditLog serves altered entries in place of the log's own.
+type tamperedAuditLog struct {
+	data.AuditLog
+	entries []models.AuditEntry
+}
+
+func (l *tamperedAuditLog) FindAfter(after string, limit int) ([]models.AuditEntry, error) {
+	return l.entries, nil
+}
+
+func TestAuditLogVerifier(t *testing.T) {
+	key := []byte("key")
+	newLog := func() data.AuditLog {
+		log := mock.NewAuditLog(key)
+		for _, id := range []string{"a", "b", "c"} {
+			err := log.Append(events.Event{ID: id, Type: events.AccountUpdated, AccountID: 1, Time: time.Now()})
+			require.NoError(t, err)
+		}
+		return log
+	}
+	tamper := func(log data.AuditLog, fn func([]models.AuditEntry) []models.AuditEntry) data.AuditLog {
+		entries, err := log.FindAfter("", 10)
+		require.NoError(t, err)
+		return &tamperedAuditLog{AuditLog: log, entries: fn(entries)}
+	}
+
+	t.Run("intact chain", func(t *testing.T) {
+		result, err := services.AuditLogVerifier(newLog(), key)
+		require.NoError(t, err)
+		assert.Equal(t, &services.AuditLogVerification{Verified: 3}, result)
+	})
+
+	t.Run("empty log", func(t *testing.T) {
+		result, err := services.AuditLogVerifier(mock.NewAuditLog(key), key)
+		require.NoError(t, err)
+		assert.Equal(t, &services.AuditLogVerification{}, result)
+	})
+
+	t.Run("with the wrong key", func(t *testing.T) {
+		result, err := services.AuditLogVerifier(newLog(), []byte("other"))
+		require.NoError(t, err)
+		assert.Equal(t, &services.AuditLogVerification{BrokenAt: "1"}, result)
+	})
+
+	t.Run("after pruning", func(t *testing.T) {
+		log := tamper(newLog(), func(entries []models.AuditEntry) []models.AuditEntry {
+			return entries[1:]
+		})
+		result, err := services.AuditLogVerifier(log, key)
+		require.NoError(t, err)
+		assert.Equal(t, &services.AuditLogVerification{Verified: 2}, result)
+	})
+
+	t.Run("with an altered event", func(t *testing.T) {
+		log := tamper(newLog(), func(entries []models.AuditEntry) []models.AuditEntry {
+			entries[1].Event.AccountID = 2
+			return entries
+		})
+		result, err := services.AuditLogVerifier(log, key)
+		require.NoError(t, err)
+		assert.Equal(t, &services.AuditLogVerification{Verified: 1, BrokenAt: "2"}, result)
+	})
+
+	t.Run("with a removed entry", func(t *testing.T) {
+		log := tamper(newLog(), func(entries []models.AuditEntry) []models.AuditEntry {
+			return []models.AuditEntry{entries[0], entries[2]}
+		})
+		result, err := services.AuditLogVerifier(log, key)
+		require.NoError(t, err)
+		assert.Equal(t, &services.AuditLogVerification{Verified: 1, BrokenAt: "3"}, result)
+	})
+
+	t.Run("with unsigned entries before the chain", func(t *testing.T) {
+		log := tamper(newLog(), func(entries []models.AuditEntry) []models.AuditEntry {
+			unsigned := models.AuditEntry{Cursor: "0", Event: events.Event{ID: "z"}}
+			return append([]models.AuditEntry{unsigned}, entries...)
+		})
+		result, err := services.AuditLogVerifier(log, key)
+		require.NoError(t, err)
+		assert.Equal(t, &services.AuditLogVerification{Verified: 3, Unsigned: 1}, result)
+	})
+
+	t.Run("with an unsigned entry within the chain", func(t *testing.T) {
+		log := tamper(newLog(), func(entries []models.AuditEntry) []models.AuditEntry {
+			entries[1].Hash = ""
+			return entries
+		})
+		result, err := services.AuditLogVerifier(log, key)
+		require.NoError(t, err)
+		assert.Equal(t, &services.AuditLogVerification{Verified: 1, BrokenAt: "2"}, result)
+	})
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/lib/hashes"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// generateFromPassword hashes a password with the configured algorithm, waiting for a slot in the
+// bcrypt concurrency limiter.
+func generateFromPassword(cfg *config.Config, password []byte) ([]byte, error) {
+	var hash []byte
+	var err error
+	limitErr := cfg.BcryptLimiter.Do(func() {
+		if cfg.PasswordHashAlgorithm == hashes.Argon2id {
+			hash, err = hashes.NewArgon2id(password)
+		} else {
+			hash, err = bcrypt.GenerateFromPassword(password, cfg.BcryptCost)
+		}
+	})
+	if limitErr != nil {
+		return nil, limitErr
+	}
+	return hash, err
+}
+
+// compareHashAndPassword verifies a password against a hash, waiting for a slot in the bcrypt
+// concurrency limiter. Hashes of other schemes are verified by their own algorithm, whose cost
+// varies: imported salted SHA-1 hashes are far cheaper than bcrypt, so those accounts may be told
+// apart by timing until they log in and are upgraded.
+func compareHashAndPassword(cfg *config.Config, hash []byte, password []byte) error {
+	var err error
+	limitErr := cfg.BcryptLimiter.Do(func() {
+		if hashes.IsTagged(hash) {
+			err = hashes.Compare(hash, password, cfg.DevisePepper)
+		} else {
+			err = bcrypt.CompareHashAndPassword(hash, password)
+		}
+	})
+	if limitErr != nil {
+		return limitErr
+	}
+	return err
+}
+
+// needsUpgrade reports whether a hash is of a scheme other than the configured algorithm. An
+// unconfigured algorithm is bcrypt.
+func needsUpgrade(cfg *config.Config, hash []byte) bool {
+	algorithm := cfg.PasswordHashAlgorithm
+	if algorithm == "" {
+		algorithm = hashes.Bcrypt
+	}
+	return hashes.Scheme(hash) != algorithm
+}
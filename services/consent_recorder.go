@@ -0,0 +1,34 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/pkg/errors"
+)
+
+// ConsentRecorder ensures that an account has accepted the current terms of service. If it has
+// not, the request must accept the current version, and the consent is recorded.
+func ConsentRecorder(store data.ConsentStore, cfg *config.Config, accountID int, termsVersion string, marketingOptIn bool, ip string) error {
+	if cfg.TermsVersion == "" {
+		return nil
+	}
+
+	latest, err := store.Latest(accountID)
+	if err != nil {
+		return errors.Wrap(err, "Latest")
+	}
+	if latest != nil && latest.TermsVersion == cfg.TermsVersion {
+		return nil
+	}
+
+	err = TermsValidator(cfg, termsVersion)
+	if err != nil {
+		return err
+	}
+
+	_, err = store.Create(accountID, termsVersion, marketingOptIn, ip)
+	if err != nil {
+		return errors.Wrap(err, "Create")
+	}
+	return nil
+}
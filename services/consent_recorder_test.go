@@ -0,0 +1,60 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsentRecorder(t *testing.T) {
+	consentStore := mock.NewConsentStore()
+	cfg := &config.Config{TermsVersion: "v2"}
+
+	t.Run("accepting the current terms", func(t *testing.T) {
+		err := services.ConsentRecorder(consentStore, cfg, 1, "v2", true, "127.0.0.1")
+		require.NoError(t, err)
+
+		consent, err := consentStore.Latest(1)
+		require.NoError(t, err)
+		require.NotNil(t, consent)
+		assert.Equal(t, "v2", consent.TermsVersion)
+		assert.True(t, consent.MarketingOptIn)
+		assert.Equal(t, "127.0.0.1", consent.IP)
+	})
+
+	t.Run("having accepted the current terms", func(t *testing.T) {
+		_, err := consentStore.Create(2, "v2", false, "127.0.0.1")
+		require.NoError(t, err)
+
+		err = services.ConsentRecorder(consentStore, cfg, 2, "", false, "127.0.0.1")
+		require.NoError(t, err)
+
+		consents, err := consentStore.FindAll(2)
+		require.NoError(t, err)
+		assert.Len(t, consents, 1)
+	})
+
+	t.Run("having accepted outdated terms", func(t *testing.T) {
+		_, err := consentStore.Create(3, "v1", false, "127.0.0.1")
+		require.NoError(t, err)
+
+		err = services.ConsentRecorder(consentStore, cfg, 3, "", false, "127.0.0.1")
+		assert.Equal(t, services.FieldErrors{{"terms_version", services.ErrMissing}}, err)
+
+		err = services.ConsentRecorder(consentStore, cfg, 3, "v1", false, "127.0.0.1")
+		assert.Equal(t, services.FieldErrors{{"terms_version", services.ErrExpired}}, err)
+	})
+
+	t.Run("without configured terms", func(t *testing.T) {
+		err := services.ConsentRecorder(consentStore, &config.Config{}, 4, "", false, "127.0.0.1")
+		require.NoError(t, err)
+
+		consents, err := consentStore.FindAll(4)
+		require.NoError(t, err)
+		assert.Empty(t, consents)
+	})
+}
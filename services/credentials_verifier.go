@@ -3,18 +3,11 @@ package services
 import (
 	"github.com/keratin/authn-server/config"
 	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib"
 	"github.com/keratin/authn-server/models"
 	"github.com/pkg/errors"
-	"golang.org/x/crypto/bcrypt"
 )
 
-var emptyHashes = map[int]string{
-	4:  "$2a$04$riUL94VEMOJwUfFkCUy8QO7HEL5L3uqUusOMELp509TuCWWJNuQG2",
-	10: "$2a$10$1hP23Pl/f58gGNZeHHm80uqxrWUdALYVfp8aucGBmQiVRemEhZI7i",
-	11: "$2a$11$GxV0LDD.xwM0ItzfbuMEDeMihmkIjs0Si6x6zhZtAAlm3p.6/3Z6q",
-	12: "$2a$12$w58M3IGXURRAqXQ/OAsMmuqcV4YqP3WyJ.yHvHI5ANUK1bRWxeceK",
-}
-
 func CredentialsVerifier(store data.AccountStore, cfg *config.Config, username string, password string) (*models.Account, error) {
 	if username == "" && password == "" {
 		return nil, FieldErrors{{"credentials", ErrFailed}}
@@ -25,25 +18,54 @@ func CredentialsVerifier(store data.AccountStore, cfg *config.Config, username s
 		return nil, errors.Wrap(err, "FindByUsername")
 	}
 
-	// if no account is found, we continue with a fake password hash. otherwise we
-	// present a timing attack that can be used for user enumeration.
+	// if no account is found, we continue with a dummy password hash of the same cost. otherwise
+	// we present a timing attack that can be used for user enumeration. accounts without a
+	// password (e.g. from OAuth) are treated the same.
 	var passwordHash []byte
-	if account == nil {
-		passwordHash = []byte(emptyHashes[cfg.BcryptCost])
+	if account == nil || len(account.Password) == 0 {
+		passwordHash = cfg.DummyPasswordHash
 	} else {
 		passwordHash = []byte(account.Password)
 	}
 
-	err = bcrypt.CompareHashAndPassword(passwordHash, []byte(password))
+	err = compareHashAndPassword(cfg, passwordHash, []byte(password))
+	if err == lib.ErrQueueTimeout {
+		return nil, errors.Wrap(err, "compareHashAndPassword")
+	}
+	// a throttled account refuses even the right password, so that guessing learns nothing. when
+	// preventing enumeration, it also fails like an unknown username.
+	if account != nil && account.Throttled() {
+		if cfg.PreventEnumeration {
+			return nil, FieldErrors{{"credentials", ErrFailed}}
+		}
+		return nil, FieldErrors{{"account", ErrThrottled}}
+	}
 	if account == nil || err != nil {
 		return nil, FieldErrors{{"credentials", ErrFailed}}
 	}
 	if account.Locked {
 		return nil, FieldErrors{{"account", ErrLocked}}
 	}
+	if account.Expired() {
+		return nil, FieldErrors{{"account", ErrExpired}}
+	}
 	if account.RequireNewPassword {
 		return nil, FieldErrors{{"credentials", ErrExpired}}
 	}
 
+	// a hash of another scheme is replaced with one of the configured algorithm while the password
+	// is known
+	if needsUpgrade(cfg, account.Password) {
+		hash, err := generateFromPassword(cfg, []byte(password))
+		if err != nil {
+			return nil, errors.Wrap(err, "generateFromPassword")
+		}
+		err = store.UpgradePassword(account.ID, hash)
+		if err != nil {
+			return nil, errors.Wrap(err, "UpgradePassword")
+		}
+		account.Password = hash
+	}
+
 	return account, nil
 }
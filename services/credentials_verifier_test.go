@@ -2,12 +2,15 @@ package services_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/keratin/authn-server/config"
 	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/hashes"
 	"github.com/keratin/authn-server/services"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestCredentialsVerifierSuccess(t *testing.T) {
@@ -25,6 +28,43 @@ func TestCredentialsVerifierSuccess(t *testing.T) {
 	assert.Equal(t, username, acc.Username)
 }
 
+func TestCredentialsVerifierUpgrade(t *testing.T) {
+	cfg := config.Config{BcryptCost: 4, DevisePepper: "pepper"}
+	peppered, err := bcrypt.GenerateFromPassword([]byte("mysecretpepper"), 4)
+	require.NoError(t, err)
+	store := mock.NewAccountStore()
+	imported, err := store.Create("imported", hashes.Devise(peppered))
+	require.NoError(t, err)
+
+	acc, err := services.CredentialsVerifier(store, &cfg, "imported", "mysecret")
+	require.NoError(t, err)
+	assert.False(t, hashes.IsTagged(acc.Password))
+
+	found, err := store.Find(imported.ID)
+	require.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword(found.Password, []byte("mysecret")))
+
+	_, err = services.CredentialsVerifier(store, &cfg, "imported", "mysecret")
+	assert.NoError(t, err)
+}
+
+func TestCredentialsVerifierUpgradeToArgon2id(t *testing.T) {
+	cfg := config.Config{BcryptCost: 4, PasswordHashAlgorithm: hashes.Argon2id}
+	store := mock.NewAccountStore()
+	account, err := store.Create("myname", []byte("$2a$04$lzQPXlov4RFLxps1uUGq4e4wmVjLYz3WrqQw4bSdfIiJRyo3/fk3C"))
+	require.NoError(t, err)
+
+	_, err = services.CredentialsVerifier(store, &cfg, "myname", "mysecret")
+	require.NoError(t, err)
+
+	found, err := store.Find(account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, hashes.Argon2id, hashes.Scheme(found.Password))
+
+	_, err = services.CredentialsVerifier(store, &cfg, "myname", "mysecret")
+	assert.NoError(t, err)
+}
+
 func TestCredentialsVerifierFailure(t *testing.T) {
 	password := "mysecret"
 	bcrypted := []byte("$2a$04$lzQPXlov4RFLxps1uUGq4e4wmVjLYz3WrqQw4bSdfIiJRyo3/fk3C")
@@ -36,6 +76,14 @@ func TestCredentialsVerifierFailure(t *testing.T) {
 	store.Lock(acc.ID)
 	acc, _ = store.Create("expired", bcrypted)
 	store.RequireNewPassword(acc.ID)
+	past := time.Now().Add(-time.Minute)
+	acc, _ = store.Create("temporary", bcrypted)
+	store.SetExpiry(acc.ID, &past)
+	future := time.Now().Add(time.Minute)
+	acc, _ = store.Create("throttled", bcrypted)
+	store.SetThrottledUntil(acc.ID, &future)
+	acc, _ = store.Create("unthrottled", bcrypted)
+	store.SetThrottledUntil(acc.ID, &past)
 
 	testCases := []struct {
 		username string
@@ -49,10 +97,53 @@ func TestCredentialsVerifierFailure(t *testing.T) {
 		{"unknown", password, services.FieldErrors{{"credentials", "FAILED"}}},
 		{"locked", password, services.FieldErrors{{"account", "LOCKED"}}},
 		{"expired", password, services.FieldErrors{{"credentials", "EXPIRED"}}},
+		{"temporary", password, services.FieldErrors{{"account", "EXPIRED"}}},
+		{"throttled", "unknown", services.FieldErrors{{"account", "THROTTLED"}}},
+		{"throttled", password, services.FieldErrors{{"account", "THROTTLED"}}},
+		{"unthrottled", "unknown", services.FieldErrors{{"credentials", "FAILED"}}},
 	}
 
 	for _, tc := range testCases {
 		_, errs := services.CredentialsVerifier(store, &cfg, tc.username, tc.password)
 		assert.Equal(t, tc.errors, errs)
 	}
+
+	t.Run("preventing enumeration", func(t *testing.T) {
+		cfg := config.Config{BcryptCost: 4, PreventEnumeration: true}
+		for _, password := range []string{"unknown", password} {
+			_, errs := services.CredentialsVerifier(store, &cfg, "throttled", password)
+			assert.Equal(t, services.FieldErrors{{"credentials", "FAILED"}}, errs)
+		}
+	})
+}
+
+func TestCredentialsVerifierTiming(t *testing.T) {
+	// a dummy hash of the same cost, as config prepares
+	cfg := config.Config{BcryptCost: 6}
+	dummy, err := bcrypt.GenerateFromPassword([]byte("dummy"), cfg.BcryptCost)
+	require.NoError(t, err)
+	cfg.DummyPasswordHash = dummy
+	store := mock.NewAccountStore()
+	hash, err := bcrypt.GenerateFromPassword([]byte("mysecret"), cfg.BcryptCost)
+	require.NoError(t, err)
+	_, err = store.Create("known", hash)
+	require.NoError(t, err)
+
+	fastest := func(username string) time.Duration {
+		var min time.Duration
+		for i := 0; i < 3; i++ {
+			start := time.Now()
+			_, err := services.CredentialsVerifier(store, &cfg, username, "wrong")
+			elapsed := time.Since(start)
+			assert.Equal(t, services.FieldErrors{{"credentials", "FAILED"}}, err)
+			if i == 0 || elapsed < min {
+				min = elapsed
+			}
+		}
+		return min
+	}
+
+	known := fastest("known")
+	unknown := fastest("unknown")
+	assert.True(t, unknown > known/4, "unknown username took %v, known took %v", unknown, known)
 }
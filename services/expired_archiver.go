@@ -0,0 +1,31 @@
+package services
+
+import (
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/pkg/errors"
+)
+
+// ExpiredArchiver archives every temporary account whose expiry has passed, and returns their IDs.
+// Accounts under a legal hold are skipped until the hold is released.
+func ExpiredArchiver(store data.AccountStore, tokenStore data.RefreshTokenStore, now time.Time) ([]int, error) {
+	ids, err := store.FindExpired(now)
+	if err != nil {
+		return nil, errors.Wrap(err, "FindExpired")
+	}
+
+	archived := []int{}
+	for _, id := range ids {
+		err = AccountArchiver(store, tokenStore, id)
+		if isLegalHold(err) {
+			continue
+		}
+		if err != nil {
+			return archived, errors.Wrap(err, "AccountArchiver")
+		}
+		archived = append(archived, id)
+	}
+
+	return archived, nil
+}
@@ -0,0 +1,52 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiredArchiver(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	refreshStore := mock.NewRefreshTokenStore()
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	expired, err := accountStore.Create("expired", []byte("password"))
+	require.NoError(t, err)
+	err = accountStore.SetExpiry(expired.ID, &past)
+	require.NoError(t, err)
+
+	temporary, err := accountStore.Create("temporary", []byte("password"))
+	require.NoError(t, err)
+	err = accountStore.SetExpiry(temporary.ID, &future)
+	require.NoError(t, err)
+
+	held, err := accountStore.Create("held", []byte("password"))
+	require.NoError(t, err)
+	err = accountStore.SetExpiry(held.ID, &past)
+	require.NoError(t, err)
+	err = accountStore.SetLegalHold(held.ID, true)
+	require.NoError(t, err)
+
+	archived, err := services.ExpiredArchiver(accountStore, refreshStore, now)
+	require.NoError(t, err)
+	assert.Equal(t, []int{expired.ID}, archived)
+
+	found, err := accountStore.Find(expired.ID)
+	require.NoError(t, err)
+	assert.True(t, found.Archived())
+
+	found, err = accountStore.Find(temporary.ID)
+	require.NoError(t, err)
+	assert.False(t, found.Archived())
+
+	found, err = accountStore.Find(held.ID)
+	require.NoError(t, err)
+	assert.False(t, found.Archived())
+}
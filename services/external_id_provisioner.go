@@ -0,0 +1,63 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// ExternalIDProvisioner notifies the APP_ACCOUNT_PROVISIONING_URL of a new account, and stores the
+// external ID from its response. Delivery is retried, but a response without an external ID is
+// accepted as it is.
+func ExternalIDProvisioner(store data.AccountStore, cfg *config.Config, account *models.Account) error {
+	if cfg.AppAccountProvisioningURL == nil {
+		return nil
+	}
+
+	values := url.Values{
+		"account_id": []string{strconv.Itoa(account.ID)},
+		"username":   []string{account.Username},
+	}
+	var body []byte
+	err := retry(timeSensitiveDelivery, func() error {
+		res, err := http.PostForm(cfg.AppAccountProvisioningURL.String(), values)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode > 299 {
+			return fmt.Errorf("Status Code: %v", res.StatusCode)
+		}
+		body, err = ioutil.ReadAll(io.LimitReader(res.Body, 4096))
+		return err
+	})
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			// avoid reporting the URL with potential HTTP auth credentials
+			err = urlErr.Err
+		}
+		return errors.Wrap(err, "PostForm")
+	}
+
+	provisioned := struct {
+		ExternalID string `json:"external_id"`
+	}{}
+	if json.Unmarshal(body, &provisioned) != nil || provisioned.ExternalID == "" {
+		return nil
+	}
+
+	err = store.SetExternalID(account.ID, provisioned.ExternalID)
+	if data.IsUniquenessError(err) {
+		return fmt.Errorf("external ID for account %d is already in use: %s", account.ID, provisioned.ExternalID)
+	}
+	return errors.Wrap(err, "SetExternalID")
+}
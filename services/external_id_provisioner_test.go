@@ -0,0 +1,77 @@
+package services_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalIDProvisioner(t *testing.T) {
+	remoteApp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/provision":
+			fmt.Fprintf(w, `{"external_id":"ext-%s"}`, r.FormValue("account_id"))
+		case "/duplicate":
+			fmt.Fprint(w, `{"external_id":"ext-1"}`)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer remoteApp.Close()
+	serverURL, err := url.Parse(remoteApp.URL)
+	require.NoError(t, err)
+
+	accountStore := mock.NewAccountStore()
+	provisionURL := &url.URL{Scheme: "http", Host: serverURL.Host, Path: "/provision"}
+
+	t.Run("storing the external id", func(t *testing.T) {
+		account, err := accountStore.Create("first@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		err = services.ExternalIDProvisioner(accountStore, &config.Config{AppAccountProvisioningURL: provisionURL}, account)
+		require.NoError(t, err)
+
+		found, err := accountStore.FindByExternalID(fmt.Sprintf("ext-%d", account.ID))
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, account.ID, found.ID)
+	})
+
+	t.Run("without an external id in the response", func(t *testing.T) {
+		account, err := accountStore.Create("second@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		cfg := &config.Config{AppAccountProvisioningURL: &url.URL{Scheme: "http", Host: serverURL.Host, Path: "/empty"}}
+		err = services.ExternalIDProvisioner(accountStore, cfg, account)
+		require.NoError(t, err)
+
+		found, err := accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.ExternalID)
+	})
+
+	t.Run("with an external id that is in use", func(t *testing.T) {
+		account, err := accountStore.Create("third@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		cfg := &config.Config{AppAccountProvisioningURL: &url.URL{Scheme: "http", Host: serverURL.Host, Path: "/duplicate"}}
+		err = services.ExternalIDProvisioner(accountStore, cfg, account)
+		assert.Error(t, err)
+	})
+
+	t.Run("without configured url", func(t *testing.T) {
+		account, err := accountStore.Create("fourth@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		err = services.ExternalIDProvisioner(accountStore, &config.Config{}, account)
+		assert.NoError(t, err)
+	})
+}
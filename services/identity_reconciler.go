@@ -14,14 +14,14 @@ import (
 //
 // * finding the linked account
 // * linking to an existing account
-// * creating a new account
+// * creating a new account, with any configured profile attributes copied into metadata
 //
 // Some expected errors include:
 //
 // * account is locked
 // * linkable account is already linked
 // * identity's email is already registered
-func IdentityReconciler(accountStore data.AccountStore, cfg *config.Config, providerName string, providerUser *oauth.UserInfo, providerToken *oauth2.Token, linkableAccountID int) (*models.Account, error) {
+func IdentityReconciler(accountStore data.AccountStore, annotationStore data.AnnotationStore, cfg *config.Config, providerName string, providerUser *oauth.UserInfo, providerToken *oauth2.Token, linkableAccountID int) (*models.Account, error) {
 	// 1. check for linked account
 	linkedAccount, err := accountStore.FindByOauthAccount(providerName, providerUser.ID)
 	if err != nil {
@@ -61,5 +61,18 @@ func IdentityReconciler(accountStore data.AccountStore, cfg *config.Config, prov
 		return nil, errors.Wrap(err, "AccountCreator")
 	}
 	accountStore.AddOauthAccount(newAccount.ID, providerName, providerUser.ID, providerToken.AccessToken)
+
+	metadata := map[string]string{}
+	for name, field := range cfg.OauthAttributeMappings[providerName] {
+		if val, ok := providerUser.Profile[field]; ok {
+			metadata[name] = val
+		}
+	}
+	if len(metadata) > 0 {
+		err = annotationStore.SetMetadata(newAccount.ID, metadata)
+		if err != nil {
+			return nil, errors.Wrap(err, "SetMetadata")
+		}
+	}
 	return newAccount, nil
 }
@@ -17,7 +17,12 @@ import (
 
 func TestIdentityReconciler(t *testing.T) {
 	store := mock.NewAccountStore()
-	cfg := &config.Config{}
+	annotations := mock.NewAnnotationStore()
+	cfg := &config.Config{
+		OauthAttributeMappings: map[string]map[string]string{
+			"testProvider": {"avatar": "picture", "locale": "locale"},
+		},
+	}
 
 	t.Run("linked account", func(t *testing.T) {
 		acct, err := store.Create("linked@test.com", []byte("password"))
@@ -25,7 +30,7 @@ func TestIdentityReconciler(t *testing.T) {
 		err = store.AddOauthAccount(acct.ID, "testProvider", "123", "TOKEN")
 		require.NoError(t, err)
 
-		found, err := services.IdentityReconciler(store, cfg, "testProvider", &oauth.UserInfo{ID: "123", Email: "linked@test.com"}, &oauth2.Token{}, 0)
+		found, err := services.IdentityReconciler(store, annotations, cfg, "testProvider", &oauth.UserInfo{ID: "123", Email: "linked@test.com"}, &oauth2.Token{}, 0)
 		assert.NoError(t, err)
 		if assert.NotNil(t, found) {
 			assert.Equal(t, found.Username, "linked@test.com")
@@ -40,7 +45,7 @@ func TestIdentityReconciler(t *testing.T) {
 		err = store.Lock(acct.ID)
 		require.NoError(t, err)
 
-		found, err := services.IdentityReconciler(store, cfg, "testProvider", &oauth.UserInfo{ID: "234", Email: "linkedlocked@test.com"}, &oauth2.Token{}, 0)
+		found, err := services.IdentityReconciler(store, annotations, cfg, "testProvider", &oauth.UserInfo{ID: "234", Email: "linkedlocked@test.com"}, &oauth2.Token{}, 0)
 		assert.Error(t, err)
 		assert.Nil(t, found)
 	})
@@ -49,7 +54,7 @@ func TestIdentityReconciler(t *testing.T) {
 		acct, err := store.Create("linkable@test.com", []byte("password"))
 		require.NoError(t, err)
 
-		found, err := services.IdentityReconciler(store, cfg, "testProvider", &oauth.UserInfo{ID: "345", Email: "linkable@test.com"}, &oauth2.Token{}, acct.ID)
+		found, err := services.IdentityReconciler(store, annotations, cfg, "testProvider", &oauth.UserInfo{ID: "345", Email: "linkable@test.com"}, &oauth2.Token{}, acct.ID)
 		assert.NoError(t, err)
 		if assert.NotNil(t, found) {
 			assert.Equal(t, found.Username, "linkable@test.com")
@@ -62,24 +67,38 @@ func TestIdentityReconciler(t *testing.T) {
 		err = store.AddOauthAccount(acct.ID, "testProvider", "0", "TOKEN")
 		require.NoError(t, err)
 
-		found, err := services.IdentityReconciler(store, cfg, "testProvider", &oauth.UserInfo{ID: "456", Email: "linkablelinked@test.com"}, &oauth2.Token{}, acct.ID)
+		found, err := services.IdentityReconciler(store, annotations, cfg, "testProvider", &oauth.UserInfo{ID: "456", Email: "linkablelinked@test.com"}, &oauth2.Token{}, acct.ID)
 		assert.Error(t, err)
 		assert.Nil(t, found)
 	})
 
 	t.Run("new account", func(t *testing.T) {
-		found, err := services.IdentityReconciler(store, cfg, "testProvider", &oauth.UserInfo{ID: "567", Email: "new@test.com"}, &oauth2.Token{}, 0)
+		found, err := services.IdentityReconciler(store, annotations, cfg, "testProvider", &oauth.UserInfo{ID: "567", Email: "new@test.com"}, &oauth2.Token{}, 0)
 		assert.NoError(t, err)
 		if assert.NotNil(t, found) {
 			assert.Equal(t, found.Username, "new@test.com")
 		}
 	})
 
+	t.Run("new account with profile attributes", func(t *testing.T) {
+		found, err := services.IdentityReconciler(store, annotations, cfg, "testProvider", &oauth.UserInfo{
+			ID:      "789",
+			Email:   "profile@test.com",
+			Profile: map[string]string{"picture": "https://example.com/me.png", "name": "Example"},
+		}, &oauth2.Token{}, 0)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+
+		metadata, err := annotations.GetMetadata(found.ID)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"avatar": "https://example.com/me.png"}, metadata)
+	})
+
 	t.Run("new account with username collision", func(t *testing.T) {
 		_, err := store.Create("existing@test.com", []byte("password"))
 		require.NoError(t, err)
 
-		found, err := services.IdentityReconciler(store, cfg, "testProvider", &oauth.UserInfo{ID: "678", Email: "existing@test.com"}, &oauth2.Token{}, 0)
+		found, err := services.IdentityReconciler(store, annotations, cfg, "testProvider", &oauth.UserInfo{ID: "678", Email: "existing@test.com"}, &oauth2.Token{}, 0)
 		assert.Error(t, err)
 		assert.Nil(t, found)
 	})
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/pkg/errors"
+)
+
+// LocationFinder looks up the location of a request's remote address. It returns nil when GeoIP
+// is not configured or the address is unknown.
+func LocationFinder(locator geoip.Locator, remoteAddr string) (*geoip.Location, error) {
+	if locator == nil {
+		return nil, nil
+	}
+
+	ip := geoip.ParseRemoteAddr(remoteAddr)
+	if ip == nil {
+		return nil, nil
+	}
+
+	location, err := locator.Locate(ip)
+	if err != nil {
+		return nil, errors.Wrap(err, "Locate")
+	}
+	return location, nil
+}
@@ -0,0 +1,52 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocationFinder(t *testing.T) {
+	locator := geoip.StaticLocator{
+		"203.0.113.1": &geoip.Location{Country: "NZ", City: "Auckland"},
+	}
+
+	t.Run("without a locator", func(t *testing.T) {
+		location, err := services.LocationFinder(nil, "203.0.113.1:1234")
+		require.NoError(t, err)
+		assert.Nil(t, location)
+	})
+
+	t.Run("known address", func(t *testing.T) {
+		location, err := services.LocationFinder(locator, "203.0.113.1:1234")
+		require.NoError(t, err)
+		assert.Equal(t, &geoip.Location{Country: "NZ", City: "Auckland"}, location)
+	})
+
+	t.Run("unknown address", func(t *testing.T) {
+		location, err := services.LocationFinder(locator, "198.51.100.1")
+		require.NoError(t, err)
+		assert.Nil(t, location)
+	})
+
+	t.Run("unparseable address", func(t *testing.T) {
+		location, err := services.LocationFinder(locator, "pipe")
+		require.NoError(t, err)
+		assert.Nil(t, location)
+	})
+}
+
+func TestLocationValidator(t *testing.T) {
+	cfg := &config.Config{BlockedCountries: []string{"KP"}}
+
+	assert.NoError(t, services.LocationValidator(cfg, nil))
+	assert.NoError(t, services.LocationValidator(cfg, &geoip.Location{Country: "NZ"}))
+	assert.Equal(t,
+		services.FieldErrors{{"location", services.ErrBlocked}},
+		services.LocationValidator(cfg, &geoip.Location{Country: "KP"}),
+	)
+}
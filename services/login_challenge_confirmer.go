@@ -0,0 +1,45 @@
+package services
+
+import (
+	"strconv"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/tokens/challenges"
+	"github.com/pkg/errors"
+)
+
+// LoginChallengeConfirmer completes a login that was held for confirmation. It checks the account
+// again, since it may have changed while the login was pending.
+func LoginChallengeConfirmer(store data.AccountStore, cfg *config.Config, token string) (*models.Account, error) {
+	claims, err := challenges.Parse(token, cfg)
+	if err != nil {
+		return nil, FieldErrors{{"token", ErrInvalidOrExpired}}
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, errors.Wrap(err, "Atoi")
+	}
+
+	account, err := store.Find(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return nil, FieldErrors{{"account", ErrNotFound}}
+	} else if account.Locked || account.Archived() {
+		return nil, FieldErrors{{"account", ErrLocked}}
+	} else if account.Throttled() {
+		return nil, FieldErrors{{"account", ErrThrottled}}
+	} else if account.Expired() {
+		return nil, FieldErrors{{"account", ErrExpired}}
+	}
+
+	if claims.LockExpired(account.PasswordChangedAt) {
+		return nil, FieldErrors{{"token", ErrInvalidOrExpired}}
+	}
+
+	return account, nil
+}
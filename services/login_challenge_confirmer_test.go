@@ -0,0 +1,68 @@
+package services_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/challenges"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginChallengeConfirmer(t *testing.T) {
+	store := mock.NewAccountStore()
+	cfg := &config.Config{
+		AuthNURL:                 &url.URL{Scheme: "https", Host: "authn.example.com"},
+		LoginChallengeSigningKey: []byte("challenge-a-reno"),
+		LoginChallengeTokenTTL:   time.Hour,
+	}
+
+	newToken := func(t *testing.T, accountID int, passwordChangedAt time.Time) string {
+		claims, err := challenges.New(cfg, accountID, passwordChangedAt)
+		require.NoError(t, err)
+		token, err := claims.Sign(cfg.LoginChallengeSigningKey)
+		require.NoError(t, err)
+		return token
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		account, err := store.Create("valid@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		confirmed, err := services.LoginChallengeConfirmer(store, cfg, newToken(t, account.ID, account.PasswordChangedAt))
+		require.NoError(t, err)
+		assert.Equal(t, account.ID, confirmed.ID)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		_, err := services.LoginChallengeConfirmer(store, cfg, "invalid")
+		assert.Equal(t, services.FieldErrors{{"token", services.ErrInvalidOrExpired}}, err)
+	})
+
+	t.Run("after a password change", func(t *testing.T) {
+		account, err := store.Create("changed@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		token := newToken(t, account.ID, account.PasswordChangedAt.Add(-time.Hour))
+
+		_, err = services.LoginChallengeConfirmer(store, cfg, token)
+		assert.Equal(t, services.FieldErrors{{"token", services.ErrInvalidOrExpired}}, err)
+	})
+
+	t.Run("locked account", func(t *testing.T) {
+		account, err := store.Create("locked@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		require.NoError(t, store.Lock(account.ID))
+
+		_, err = services.LoginChallengeConfirmer(store, cfg, newToken(t, account.ID, account.PasswordChangedAt))
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrLocked}}, err)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		_, err := services.LoginChallengeConfirmer(store, cfg, newToken(t, 123456789, time.Now()))
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrNotFound}}, err)
+	})
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/tokens/challenges"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// LoginChallengeSender delivers a token that completes a suspicious login, so that the app may
+// email a confirmation link to the account.
+func LoginChallengeSender(cfg *config.Config, account *models.Account, reasons []string, location *geoip.Location) error {
+	challenge, err := challenges.New(cfg, account.ID, account.PasswordChangedAt)
+	if err != nil {
+		return errors.Wrap(err, "New Challenge")
+	}
+	challengeStr, err := challenge.Sign(cfg.LoginChallengeSigningKey)
+	if err != nil {
+		return errors.Wrap(err, "Sign")
+	}
+
+	params := url.Values{
+		"account_id": []string{strconv.Itoa(account.ID)},
+		"username":   []string{account.Username},
+		"reasons":    []string{strings.Join(reasons, ",")},
+		"token":      []string{challengeStr},
+	}
+	if location != nil {
+		params.Set("country", location.Country)
+		params.Set("city", location.City)
+	}
+	err = WebhookSender(cfg.AppLoginChallengeURL, &params, timeSensitiveDelivery)
+	if err != nil {
+		return errors.Wrap(err, "Webhook")
+	}
+
+	log.WithFields(log.Fields{"accountID": account.ID, "reasons": reasons}).Info("sent login challenge token")
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package services_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/challenges"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginChallengeSender(t *testing.T) {
+	var received url.Values
+	remoteApp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		received = r.PostForm
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer remoteApp.Close()
+	serverURL, err := url.Parse(remoteApp.URL)
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		AuthNURL:                 &url.URL{Scheme: "https", Host: "authn.example.com"},
+		AppLoginChallengeURL:     serverURL,
+		LoginChallengeSigningKey: []byte("challenge-a-reno"),
+		LoginChallengeTokenTTL:   time.Hour,
+	}
+	account := &models.Account{ID: 1234, Username: "user@keratin.tech", PasswordChangedAt: time.Now()}
+
+	err = services.LoginChallengeSender(cfg, account, []string{"new_country", "tor"}, &geoip.Location{Country: "FR", City: "Paris"})
+	require.NoError(t, err)
+	assert.Equal(t, "1234", received.Get("account_id"))
+	assert.Equal(t, "user@keratin.tech", received.Get("username"))
+	assert.Equal(t, "new_country,tor", received.Get("reasons"))
+	assert.Equal(t, "FR", received.Get("country"))
+	assert.Equal(t, "Paris", received.Get("city"))
+
+	claims, err := challenges.Parse(received.Get("token"), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "1234", claims.Subject)
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/pkg/errors"
+)
+
+// loginHistoryPageSize is how many audit entries are read at a time while searching for logins.
+const loginHistoryPageSize = 100
+
+// loginHistoryMaxScan bounds how far back the audit log is searched, so that an account with a long
+// history of other events does not make the search expensive.
+const loginHistoryMaxScan = 1000
+
+// LoginHistoryFinder finds up to limit of an account's most recent logins in the audit log, newest
+// first.
+func LoginHistoryFinder(log data.AuditLog, accountID int, limit int) ([]events.Event, error) {
+	logins := []events.Event{}
+	cursor := ""
+	for scanned := 0; scanned < loginHistoryMaxScan; scanned += loginHistoryPageSize {
+		entries, err := log.Find(accountID, cursor, loginHistoryPageSize)
+		if err != nil {
+			return nil, errors.Wrap(err, "Find")
+		}
+
+		for _, entry := range entries {
+			if entry.Event.Type == events.SessionCreated {
+				logins = append(logins, entry.Event)
+				if len(logins) == limit {
+					return logins, nil
+				}
+			}
+		}
+
+		if len(entries) < loginHistoryPageSize {
+			break
+		}
+		cursor = entries[len(entries)-1].Cursor
+	}
+
+	return logins, nil
+}
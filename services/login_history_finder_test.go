@@ -0,0 +1,44 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginHistoryFinder(t *testing.T) {
+	log := mock.NewAuditLog([]byte("key"))
+	require.NoError(t, log.Append(events.Event{ID: "1", Type: events.AccountCreated, AccountID: 1}))
+	require.NoError(t, log.Append(events.Event{ID: "2", Type: events.SessionCreated, AccountID: 1, IP: "203.0.113.1"}))
+	require.NoError(t, log.Append(events.Event{ID: "3", Type: events.SessionCreated, AccountID: 2}))
+	require.NoError(t, log.Append(events.Event{ID: "4", Type: events.SessionRevoked, AccountID: 1}))
+	require.NoError(t, log.Append(events.Event{ID: "5", Type: events.SessionCreated, AccountID: 1, IP: "198.51.100.1"}))
+
+	t.Run("newest logins first", func(t *testing.T) {
+		logins, err := services.LoginHistoryFinder(log, 1, 10)
+		require.NoError(t, err)
+		if assert.Len(t, logins, 2) {
+			assert.Equal(t, "5", logins[0].ID)
+			assert.Equal(t, "198.51.100.1", logins[0].IP)
+			assert.Equal(t, "2", logins[1].ID)
+		}
+	})
+
+	t.Run("with a limit", func(t *testing.T) {
+		logins, err := services.LoginHistoryFinder(log, 1, 1)
+		require.NoError(t, err)
+		if assert.Len(t, logins, 1) {
+			assert.Equal(t, "5", logins[0].ID)
+		}
+	})
+
+	t.Run("without logins", func(t *testing.T) {
+		logins, err := services.LoginHistoryFinder(log, 3, 10)
+		require.NoError(t, err)
+		assert.Empty(t, logins)
+	})
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/pkg/errors"
+)
+
+// Reasons that a login may be challenged.
+const (
+	RiskNewCountry = "new_country"
+	RiskTor        = "tor"
+)
+
+// loginRiskHistory is how many recent logins are compared when looking for a new country.
+const loginRiskHistory = 20
+
+// LoginRiskAssessor applies the configured login challenge rules to a login and returns the reasons
+// that it looks suspicious, if any.
+//
+// An account's first login is never from a new country, and neither is a login that can not be
+// located.
+func LoginRiskAssessor(log data.AuditLog, cfg *config.Config, accountID int, location *geoip.Location, remoteAddr string) ([]string, error) {
+	reasons := []string{}
+	for _, rule := range cfg.LoginChallengeRules {
+		switch rule {
+		case RiskTor:
+			if cfg.TorExitNodes.Contains(geoip.ParseRemoteAddr(remoteAddr)) {
+				reasons = append(reasons, RiskTor)
+			}
+		case RiskNewCountry:
+			if log == nil || location == nil || location.Country == "" {
+				continue
+			}
+			logins, err := LoginHistoryFinder(log, accountID, loginRiskHistory)
+			if err != nil {
+				return nil, errors.Wrap(err, "LoginHistoryFinder")
+			}
+			known := false
+			located := false
+			for _, login := range logins {
+				if login.Location != nil && login.Location.Country != "" {
+					located = true
+					if login.Location.Country == location.Country {
+						known = true
+						break
+					}
+				}
+			}
+			if located && !known {
+				reasons = append(reasons, RiskNewCountry)
+			}
+		}
+	}
+	return reasons, nil
+}
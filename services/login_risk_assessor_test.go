@@ -0,0 +1,55 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/lib/geoip"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginRiskAssessor(t *testing.T) {
+	log := mock.NewAuditLog([]byte("key"))
+	require.NoError(t, log.Append(events.Event{ID: "1", Type: events.SessionCreated, AccountID: 1, Location: &geoip.Location{Country: "US"}}))
+	require.NoError(t, log.Append(events.Event{ID: "2", Type: events.SessionCreated, AccountID: 2}))
+
+	cfg := &config.Config{
+		LoginChallengeRules: []string{services.RiskNewCountry, services.RiskTor},
+		TorExitNodes:        geoip.IPList{"192.0.2.1": true},
+	}
+
+	testCases := []struct {
+		name       string
+		accountID  int
+		location   *geoip.Location
+		remoteAddr string
+		reasons    []string
+	}{
+		{"known country", 1, &geoip.Location{Country: "US"}, "198.51.100.1:1234", []string{}},
+		{"new country", 1, &geoip.Location{Country: "FR"}, "198.51.100.1:1234", []string{"new_country"}},
+		{"unknown location", 1, nil, "198.51.100.1:1234", []string{}},
+		{"first login", 3, &geoip.Location{Country: "FR"}, "198.51.100.1:1234", []string{}},
+		{"no located history", 2, &geoip.Location{Country: "FR"}, "198.51.100.1:1234", []string{}},
+		{"tor exit node", 1, &geoip.Location{Country: "US"}, "192.0.2.1:1234", []string{"tor"}},
+		{"tor from a new country", 1, &geoip.Location{Country: "DE"}, "192.0.2.1", []string{"new_country", "tor"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reasons, err := services.LoginRiskAssessor(log, cfg, tc.accountID, tc.location, tc.remoteAddr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.reasons, reasons)
+		})
+	}
+
+	t.Run("with only some rules", func(t *testing.T) {
+		torOnly := &config.Config{LoginChallengeRules: []string{services.RiskTor}, TorExitNodes: cfg.TorExitNodes}
+		reasons, err := services.LoginRiskAssessor(log, torOnly, 1, &geoip.Location{Country: "FR"}, "198.51.100.1")
+		require.NoError(t, err)
+		assert.Empty(t, reasons)
+	})
+}
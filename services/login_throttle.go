@@ -0,0 +1,112 @@
+package services
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/pkg/errors"
+)
+
+const (
+	loginFailureWindow  = time.Hour
+	loginThrottleWindow = 24 * time.Hour
+)
+
+// LoginFailureTracker counts a failed login against an account. When the account reaches the
+// configured number of failures, it is temporarily locked. Each temporary lock within a day lasts
+// twice as long as the last, up to the configured maximum.
+func LoginFailureTracker(store data.AccountStore, quotas data.Quotas, cfg *config.Config, username string) error {
+	if cfg.LoginThrottleAttempts == 0 {
+		return nil
+	}
+
+	account, err := store.FindByUsername(username)
+	if err != nil {
+		return errors.Wrap(err, "FindByUsername")
+	}
+	if account == nil || account.Locked || account.Throttled() {
+		return nil
+	}
+
+	failuresKey := loginFailuresKey(account.ID)
+	err = quotas.Hit(failuresKey, loginFailureWindow)
+	if err != nil {
+		return errors.Wrap(err, "Hit")
+	}
+	failures, err := quotas.Count(failuresKey, loginFailureWindow)
+	if err != nil {
+		return errors.Wrap(err, "Count")
+	}
+	if failures < cfg.LoginThrottleAttempts {
+		rateLimitChecks.WithLabelValues("login_failures", "allowed").Inc()
+		return nil
+	}
+	rateLimitChecks.WithLabelValues("login_failures", "limited").Inc()
+
+	throttlesKey := loginThrottlesKey(account.ID)
+	err = quotas.Hit(throttlesKey, loginThrottleWindow)
+	if err != nil {
+		return errors.Wrap(err, "Hit")
+	}
+	throttles, err := quotas.Count(throttlesKey, loginThrottleWindow)
+	if err != nil {
+		return errors.Wrap(err, "Count")
+	}
+	err = quotas.Reset(failuresKey, loginFailureWindow)
+	if err != nil {
+		return errors.Wrap(err, "Reset")
+	}
+
+	duration := cfg.LoginThrottleDuration
+	for i := 1; i < throttles && duration < cfg.LoginThrottleMaxDuration; i++ {
+		duration *= 2
+	}
+	if duration > cfg.LoginThrottleMaxDuration {
+		duration = cfg.LoginThrottleMaxDuration
+	}
+	until := time.Now().Add(duration)
+	return store.SetThrottledUntil(account.ID, &until)
+}
+
+// LoginFailureResetter forgets an account's failed logins after it logs in successfully. Earlier
+// temporary locks still count towards escalation until they age out.
+func LoginFailureResetter(quotas data.Quotas, cfg *config.Config, accountID int) error {
+	if cfg.LoginThrottleAttempts == 0 {
+		return nil
+	}
+	return quotas.Reset(loginFailuresKey(accountID), loginFailureWindow)
+}
+
+// AccountUnthrottler clears a temporary lock along with the failures and escalation that led to
+// it. Hard locks are unaffected. Quotas may be nil when throttling is not configured.
+func AccountUnthrottler(store data.AccountStore, quotas data.Quotas, accountID int) error {
+	account, err := store.Find(accountID)
+	if err != nil {
+		return errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return FieldErrors{{"account", ErrNotFound}}
+	}
+
+	if quotas != nil {
+		err = quotas.Reset(loginFailuresKey(account.ID), loginFailureWindow)
+		if err != nil {
+			return errors.Wrap(err, "Reset")
+		}
+		err = quotas.Reset(loginThrottlesKey(account.ID), loginThrottleWindow)
+		if err != nil {
+			return errors.Wrap(err, "Reset")
+		}
+	}
+	return store.SetThrottledUntil(account.ID, nil)
+}
+
+func loginFailuresKey(accountID int) string {
+	return "logins:failures:" + strconv.Itoa(accountID)
+}
+
+func loginThrottlesKey(accountID int) string {
+	return "logins:throttles:" + strconv.Itoa(accountID)
+}
@@ -0,0 +1,137 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginFailureTracker(t *testing.T) {
+	cfg := &config.Config{
+		LoginThrottleAttempts:    3,
+		LoginThrottleDuration:    time.Minute,
+		LoginThrottleMaxDuration: 3 * time.Minute,
+	}
+
+	fail := func(t *testing.T, store data.AccountStore, quotas data.Quotas, username string, times int) {
+		for i := 0; i < times; i++ {
+			require.NoError(t, services.LoginFailureTracker(store, quotas, cfg, username))
+		}
+	}
+
+	t.Run("below the limit", func(t *testing.T) {
+		store := mock.NewAccountStore()
+		quotas := mock.NewQuotas()
+		account, err := store.Create("below@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		fail(t, store, quotas, "below@keratin.tech", 2)
+		found, err := store.Find(account.ID)
+		require.NoError(t, err)
+		assert.False(t, found.Throttled())
+	})
+
+	t.Run("escalating durations", func(t *testing.T) {
+		store := mock.NewAccountStore()
+		quotas := mock.NewQuotas()
+		account, err := store.Create("escalate@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		for _, expected := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+			fail(t, store, quotas, "escalate@keratin.tech", 3)
+			found, err := store.Find(account.ID)
+			require.NoError(t, err)
+			require.True(t, found.Throttled())
+			assert.WithinDuration(t, time.Now().Add(expected), *found.ThrottledUntil, time.Second)
+
+			// failures while throttled do not count
+			fail(t, store, quotas, "escalate@keratin.tech", 3)
+			again, err := store.Find(account.ID)
+			require.NoError(t, err)
+			assert.Equal(t, found.ThrottledUntil, again.ThrottledUntil)
+
+			past := time.Now().Add(-time.Second)
+			require.NoError(t, store.SetThrottledUntil(account.ID, &past))
+		}
+	})
+
+	t.Run("after a successful login", func(t *testing.T) {
+		store := mock.NewAccountStore()
+		quotas := mock.NewQuotas()
+		account, err := store.Create("success@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		fail(t, store, quotas, "success@keratin.tech", 2)
+		require.NoError(t, services.LoginFailureResetter(quotas, cfg, account.ID))
+		fail(t, store, quotas, "success@keratin.tech", 2)
+		found, err := store.Find(account.ID)
+		require.NoError(t, err)
+		assert.False(t, found.Throttled())
+	})
+
+	t.Run("unknown username", func(t *testing.T) {
+		store := mock.NewAccountStore()
+		quotas := mock.NewQuotas()
+		fail(t, store, quotas, "unknown@keratin.tech", 3)
+	})
+
+	t.Run("when disabled", func(t *testing.T) {
+		store := mock.NewAccountStore()
+		quotas := mock.NewQuotas()
+		account, err := store.Create("disabled@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, services.LoginFailureTracker(store, quotas, &config.Config{}, "disabled@keratin.tech"))
+		}
+		found, err := store.Find(account.ID)
+		require.NoError(t, err)
+		assert.False(t, found.Throttled())
+	})
+}
+
+func TestAccountUnthrottler(t *testing.T) {
+	cfg := &config.Config{
+		LoginThrottleAttempts:    1,
+		LoginThrottleDuration:    time.Minute,
+		LoginThrottleMaxDuration: time.Hour,
+	}
+	store := mock.NewAccountStore()
+	quotas := mock.NewQuotas()
+
+	account, err := store.Create("throttled@keratin.tech", []byte("password"))
+	require.NoError(t, err)
+	require.NoError(t, services.LoginFailureTracker(store, quotas, cfg, "throttled@keratin.tech"))
+	require.NoError(t, store.Lock(account.ID))
+
+	t.Run("unknown account", func(t *testing.T) {
+		err := services.AccountUnthrottler(store, quotas, 123456789)
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrNotFound}}, err)
+	})
+
+	t.Run("throttled account", func(t *testing.T) {
+		err := services.AccountUnthrottler(store, quotas, account.ID)
+		require.NoError(t, err)
+
+		found, err := store.Find(account.ID)
+		require.NoError(t, err)
+		assert.False(t, found.Throttled())
+		assert.True(t, found.Locked, "hard lock is unaffected")
+	})
+
+	t.Run("escalation is forgotten", func(t *testing.T) {
+		require.NoError(t, store.Unlock(account.ID))
+		require.NoError(t, services.LoginFailureTracker(store, quotas, cfg, "throttled@keratin.tech"))
+
+		found, err := store.Find(account.ID)
+		require.NoError(t, err)
+		require.True(t, found.Throttled())
+		assert.WithinDuration(t, time.Now().Add(time.Minute), *found.ThrottledUntil, time.Second)
+	})
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"net/url"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/ops"
+	"github.com/keratin/authn-server/tokens/identities"
+	"github.com/pkg/errors"
+)
+
+// LogoutNotifier delivers a back-channel logout token to every application with a
+// backchannel_logout_uri, so that they can end their own sessions for the revoked AuthN session.
+// Delivery happens in the background and failures are reported.
+func LogoutNotifier(cfg *config.Config, keyStore data.KeyStore, r ops.ErrorReporter, refreshToken string, accountID int) error {
+	for _, app := range cfg.Applications {
+		if app.BackchannelLogoutURI == nil {
+			continue
+		}
+
+		token, err := identities.NewLogout(cfg, refreshToken, accountID, app.Domain.String())
+		if err != nil {
+			return errors.Wrap(err, "NewLogout")
+		}
+		tokenStr, err := token.Sign(keyStore.Key())
+		if err != nil {
+			return errors.Wrap(err, "Sign")
+		}
+
+		destination := app.BackchannelLogoutURI
+		go func() {
+			err := WebhookSender(destination, &url.Values{
+				"logout_token": []string{tokenStr},
+			}, timeSensitiveDelivery)
+			if err != nil {
+				r.ReportError(err)
+			}
+		}()
+	}
+
+	return nil
+}
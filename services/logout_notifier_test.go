@@ -0,0 +1,58 @@
+package services_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/route"
+	"github.com/keratin/authn-server/ops"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/identities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestLogoutNotifier(t *testing.T) {
+	received := make(chan string, 1)
+	remoteApp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.FormValue("logout_token")
+	}))
+	defer remoteApp.Close()
+	logoutURI, err := url.Parse(remoteApp.URL + "/logout")
+	require.NoError(t, err)
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	cfg := &config.Config{
+		AuthNURL: &url.URL{Scheme: "https", Host: "authn.example.com"},
+		Applications: []config.ApplicationDomain{
+			{Domain: route.ParseDomain("app.example.com"), BackchannelLogoutURI: logoutURI},
+			{Domain: route.ParseDomain("other.example.com")},
+		},
+	}
+
+	err = services.LogoutNotifier(cfg, mock.NewKeyStore(key), &ops.LogReporter{}, "refresh-token", 1234)
+	require.NoError(t, err)
+
+	select {
+	case tokenStr := <-received:
+		token, err := jwt.ParseSigned(tokenStr)
+		require.NoError(t, err)
+		claims := identities.Claims{}
+		require.NoError(t, token.Claims(key.Public(), &claims))
+		assert.Equal(t, "1234", claims.Subject)
+		assert.Equal(t, jwt.Audience{"app.example.com"}, claims.Audience)
+		assert.Equal(t, identities.SessionID("refresh-token"), claims.Sid)
+		assert.Contains(t, claims.Events, identities.BackchannelLogoutEvent)
+	case <-time.After(time.Second):
+		t.Fatal("logout token was not delivered")
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/compat"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/ops"
+	"github.com/pkg/errors"
+)
+
+// outboxBatch bounds how many messages are attempted in one run.
+const outboxBatch = 100
+
+// outboxRetrySchedule is how long to wait after each failed attempt. A message fails for good
+// after one more attempt than the schedule allows for, a little over two days after it was
+// created.
+var outboxRetrySchedule = []time.Duration{
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+}
+
+// outboxClient bounds each webhook, so that a slow application does not hold up the outbox.
+var outboxClient = &http.Client{Timeout: 10 * time.Second}
+
+// WebhookSignatureHeader signs a webhook when its endpoint has a secret. See webhookSignature.
+const WebhookSignatureHeader = "X-Authn-Signature"
+
+// OutboxDispatcher attempts the pending messages that are due, and returns how many were
+// delivered. Events are published to every publisher, and webhooks are posted to their configured
+// URL. Delivery is at-least-once: a message is retried in full if any part of it fails, or if
+// AuthN stops before recording the result.
+func OutboxDispatcher(outbox data.OutboxStore, secrets data.WebhookSecretStore, cfg *config.Config, publishers []events.Publisher, now time.Time) (int, error) {
+	msgs, err := outbox.FindDue(now, outboxBatch)
+	if err != nil {
+		return 0, errors.Wrap(err, "FindDue")
+	}
+
+	delivered := 0
+	for _, msg := range msgs {
+		var code int
+		switch msg.Kind {
+		case models.OutboxEvent:
+			err = publishOutboxEvent(msg, publishers)
+		case models.OutboxWebhook:
+			code, err = postOutboxWebhook(msg, secrets, cfg, now)
+		default:
+			err = fmt.Errorf("unknown kind: %s", msg.Kind)
+		}
+
+		if err == nil {
+			delivered++
+			err = outbox.Delivered(msg.ID, code)
+			if err != nil {
+				return delivered, errors.Wrap(err, "Delivered")
+			}
+			continue
+		}
+
+		var retryAt *time.Time
+		if msg.Attempts < len(outboxRetrySchedule) {
+			at := now.Add(outboxRetrySchedule[msg.Attempts])
+			retryAt = &at
+		} else {
+			alertOutboxFailure(cfg, msg, err)
+		}
+		err = outbox.Failed(msg.ID, code, err.Error(), retryAt)
+		if err != nil {
+			return delivered, errors.Wrap(err, "Failed")
+		}
+	}
+
+	return delivered, nil
+}
+
+// alertOutboxFailure alerts that a message has failed for good, and will not be delivered unless it
+// is redelivered by hand.
+func alertOutboxFailure(cfg *config.Config, msg *models.OutboxMessage, err error) {
+	destination := msg.Destination
+	if destination == "" {
+		destination = "publishers"
+	}
+	cfg.Alerts.Alert(ops.Alert{
+		Key:     "outbox:" + msg.Kind + ":" + destination,
+		Summary: fmt.Sprintf("Outbox %s to %s failed after %d attempts", msg.Kind, destination, msg.Attempts+1),
+		Details: map[string]string{"id": strconv.Itoa(msg.ID), "error": err.Error()},
+	})
+}
+
+func publishOutboxEvent(msg *models.OutboxMessage, publishers []events.Publisher) error {
+	var event events.Event
+	err := json.Unmarshal([]byte(msg.Payload), &event)
+	if err != nil {
+		return errors.Wrap(err, "Unmarshal")
+	}
+
+	for _, p := range publishers {
+		err = p.Publish(event)
+		if err != nil {
+			return errors.Wrap(err, "Publish")
+		}
+	}
+	return nil
+}
+
+func postOutboxWebhook(msg *models.OutboxMessage, secrets data.WebhookSecretStore, cfg *config.Config, now time.Time) (int, error) {
+	destination := webhookURL(cfg, msg.Destination)
+	if destination == nil {
+		return 0, fmt.Errorf("URL unconfigured")
+	}
+
+	req, err := http.NewRequest("POST", destination.String(), strings.NewReader(msg.Payload))
+	if err != nil {
+		return 0, errors.Wrap(err, "NewRequest")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	active, err := secrets.FindActive(msg.Destination, now)
+	if err != nil {
+		return 0, errors.Wrap(err, "FindActive")
+	}
+	if len(active) > 0 {
+		keys := []string{}
+		for _, secret := range active {
+			key, err := compat.Decrypt([]byte(secret.Secret), cfg.DBEncryptionKey)
+			if err != nil {
+				return 0, errors.Wrap(err, "Decrypt")
+			}
+			keys = append(keys, key)
+		}
+		req.Header.Set(WebhookSignatureHeader, webhookSignature(keys, now, msg.Payload))
+	}
+
+	res, err := outboxClient.Do(req)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			// avoid reporting the URL with potential HTTP auth credentials
+			return 0, errors.Wrap(urlErr.Err, "Do")
+		}
+		return 0, errors.Wrap(err, "Do")
+	}
+	res.Body.Close()
+
+	if res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("Status Code: %v", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// webhookSignature signs the body and a timestamp with each secret, so that the application can
+// verify that a webhook came from AuthN and is recent. It has the form:
+//
+//	t=1577836800,v1=<hex>,v1=<hex>
+//
+// where each v1 is the HMAC-SHA256 of "<t>.<body>" with one secret, newest first. There is more
+// than one while a rotated secret overlaps with its replacement.
+func webhookSignature(secrets []string, now time.Time, body string) string {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	parts := []string{"t=" + timestamp}
+	for _, secret := range secrets {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "." + body))
+		parts = append(parts, "v1="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	return strings.Join(parts, ",")
+}
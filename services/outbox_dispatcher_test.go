@@ -0,0 +1,225 @@
+package services_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/ops"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	published []events.Event
+	err       error
+}
+
+func (p *recordingPublisher) Publish(e events.Event) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.published = append(p.published, e)
+	return nil
+}
+
+type channelAlerter chan ops.Alert
+
+func (a channelAlerter) Alert(alert ops.Alert) error {
+	a <- alert
+	return nil
+}
+
+func TestOutboxDispatcher(t *testing.T) {
+	t.Run("publishing events", func(t *testing.T) {
+		outbox := mock.NewOutboxStore()
+		publisher := &recordingPublisher{}
+		err := (&data.EventOutbox{Store: outbox}).Enqueue(events.Event{ID: "abc", Type: events.AccountLocked, AccountID: 42})
+		require.NoError(t, err)
+
+		delivered, err := services.OutboxDispatcher(outbox, mock.NewWebhookSecretStore(), &config.Config{}, []events.Publisher{publisher}, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+		require.Len(t, publisher.published, 1)
+		assert.Equal(t, "abc", publisher.published[0].ID)
+		assert.Equal(t, events.AccountLocked, publisher.published[0].Type)
+		assert.Equal(t, 42, publisher.published[0].AccountID)
+
+		msg, err := outbox.Find(1)
+		require.NoError(t, err)
+		assert.Equal(t, models.OutboxDelivered, msg.Status)
+	})
+
+	t.Run("retrying events", func(t *testing.T) {
+		outbox := mock.NewOutboxStore()
+		publisher := &recordingPublisher{err: fmt.Errorf("broker unavailable")}
+		err := (&data.EventOutbox{Store: outbox}).Enqueue(events.Event{ID: "abc", Type: events.AccountLocked})
+		require.NoError(t, err)
+
+		now := time.Now()
+		delivered, err := services.OutboxDispatcher(outbox, mock.NewWebhookSecretStore(), &config.Config{}, []events.Publisher{publisher}, now)
+		require.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+
+		msg, err := outbox.Find(1)
+		require.NoError(t, err)
+		assert.Equal(t, models.OutboxPending, msg.Status)
+		assert.Equal(t, 1, msg.Attempts)
+		assert.Contains(t, msg.LastError, "broker unavailable")
+		assert.Equal(t, now.Add(10*time.Second), msg.NextAttemptAt)
+
+		publisher.err = nil
+		delivered, err = services.OutboxDispatcher(outbox, mock.NewWebhookSecretStore(), &config.Config{}, []events.Publisher{publisher}, now)
+		require.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+		delivered, err = services.OutboxDispatcher(outbox, mock.NewWebhookSecretStore(), &config.Config{}, []events.Publisher{publisher}, now.Add(10*time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+	})
+
+	t.Run("failing events", func(t *testing.T) {
+		outbox := mock.NewOutboxStore()
+		publisher := &recordingPublisher{err: fmt.Errorf("broker unavailable")}
+		err := (&data.EventOutbox{Store: outbox}).Enqueue(events.Event{ID: "abc", Type: events.AccountLocked})
+		require.NoError(t, err)
+
+		alerter := make(channelAlerter, 10)
+		cfg := &config.Config{Alerts: ops.NewAlerts(&ops.LogReporter{}, time.Hour, alerter)}
+
+		now := time.Now()
+		for i := 0; i < 20; i++ {
+			_, err = services.OutboxDispatcher(outbox, mock.NewWebhookSecretStore(), cfg, []events.Publisher{publisher}, now)
+			require.NoError(t, err)
+			now = now.Add(24 * time.Hour)
+		}
+
+		msg, err := outbox.Find(1)
+		require.NoError(t, err)
+		assert.Equal(t, models.OutboxFailed, msg.Status)
+		assert.Equal(t, 11, msg.Attempts)
+
+		select {
+		case a := <-alerter:
+			assert.Equal(t, "outbox:event:publishers", a.Key)
+			assert.Equal(t, "Outbox event to publishers failed after 11 attempts", a.Summary)
+			assert.Contains(t, a.Details["error"], "broker unavailable")
+		case <-time.After(time.Second):
+			t.Error("failure was not alerted")
+		}
+		assert.Len(t, alerter, 0)
+	})
+
+	t.Run("posting webhooks", func(t *testing.T) {
+		received := url.Values{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			received = r.PostForm
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+		destination, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		cfg := &config.Config{AppPasswordChangedURL: destination}
+
+		outbox := mock.NewOutboxStore()
+		err = services.WebhookEnqueuer(outbox, services.PasswordChangedWebhook, &url.Values{"account_id": []string{"42"}})
+		require.NoError(t, err)
+
+		delivered, err := services.OutboxDispatcher(outbox, mock.NewWebhookSecretStore(), cfg, nil, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+		assert.Equal(t, "42", received.Get("account_id"))
+
+		msg, err := outbox.Find(1)
+		require.NoError(t, err)
+		assert.Equal(t, models.OutboxDelivered, msg.Status)
+		assert.Equal(t, http.StatusAccepted, msg.ResponseCode)
+	})
+
+	t.Run("rejected webhooks", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		destination, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		cfg := &config.Config{AppPasswordChangedURL: destination}
+
+		outbox := mock.NewOutboxStore()
+		err = services.WebhookEnqueuer(outbox, services.PasswordChangedWebhook, &url.Values{"account_id": []string{"42"}})
+		require.NoError(t, err)
+
+		delivered, err := services.OutboxDispatcher(outbox, mock.NewWebhookSecretStore(), cfg, nil, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+
+		msg, err := outbox.Find(1)
+		require.NoError(t, err)
+		assert.Equal(t, models.OutboxPending, msg.Status)
+		assert.Equal(t, http.StatusServiceUnavailable, msg.ResponseCode)
+		assert.Equal(t, "Status Code: 503", msg.LastError)
+	})
+
+	t.Run("unconfigured webhooks", func(t *testing.T) {
+		outbox := mock.NewOutboxStore()
+		err := services.WebhookEnqueuer(outbox, services.PasswordChangedWebhook, &url.Values{"account_id": []string{"42"}})
+		require.NoError(t, err)
+
+		_, err = services.OutboxDispatcher(outbox, mock.NewWebhookSecretStore(), &config.Config{}, nil, time.Now())
+		require.NoError(t, err)
+
+		msg, err := outbox.Find(1)
+		require.NoError(t, err)
+		assert.Equal(t, "URL unconfigured", msg.LastError)
+	})
+	t.Run("signing webhooks", func(t *testing.T) {
+		var signature string
+		var body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			signature = r.Header.Get(services.WebhookSignatureHeader)
+			raw, _ := ioutil.ReadAll(r.Body)
+			body = string(raw)
+		}))
+		defer server.Close()
+		destination, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		cfg := &config.Config{
+			AppPasswordChangedURL: destination,
+			DBEncryptionKey:       []byte("DLz2TNDRdWWA5w8YNeCJ7uzcS4WDzQmB"),
+		}
+
+		secrets := mock.NewWebhookSecretStore()
+		old, err := services.WebhookSecretRotator(secrets, cfg, services.PasswordChangedWebhook, 0)
+		require.NoError(t, err)
+		current, err := services.WebhookSecretRotator(secrets, cfg, services.PasswordChangedWebhook, time.Hour)
+		require.NoError(t, err)
+
+		outbox := mock.NewOutboxStore()
+		err = services.WebhookEnqueuer(outbox, services.PasswordChangedWebhook, &url.Values{"account_id": []string{"42"}})
+		require.NoError(t, err)
+
+		now := time.Now()
+		_, err = services.OutboxDispatcher(outbox, secrets, cfg, nil, now)
+		require.NoError(t, err)
+
+		sign := func(secret string) string {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write([]byte(fmt.Sprintf("%d.%s", now.Unix(), body)))
+			return hex.EncodeToString(mac.Sum(nil))
+		}
+		assert.Equal(t, fmt.Sprintf("t=%d,v1=%s,v1=%s", now.Unix(), sign(current), sign(old)), signature)
+	})
+}
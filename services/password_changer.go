@@ -3,12 +3,16 @@ package services
 import (
 	"github.com/keratin/authn-server/config"
 	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib"
+	"github.com/keratin/authn-server/models"
 	"github.com/keratin/authn-server/ops"
 	"github.com/pkg/errors"
-	"golang.org/x/crypto/bcrypt"
 )
 
-func PasswordChanger(store data.AccountStore, r ops.ErrorReporter, cfg *config.Config, id int, currentPassword string, password string) error {
+// PasswordChanger sets a new password after verifying the current one. Since a password change
+// may be the response to a lost device or a shared password, it also revokes every session except
+// the one to keep, if any.
+func PasswordChanger(store data.AccountStore, tokenStore data.RefreshTokenStore, outbox data.OutboxStore, r ops.ErrorReporter, cfg *config.Config, id int, currentPassword string, password string, keep models.RefreshToken) error {
 	account, err := store.Find(id)
 	if err != nil {
 		return errors.Wrap(err, "Find")
@@ -19,10 +23,17 @@ func PasswordChanger(store data.AccountStore, r ops.ErrorReporter, cfg *config.C
 		return FieldErrors{{"account", ErrLocked}}
 	}
 
-	err = bcrypt.CompareHashAndPassword(account.Password, []byte(currentPassword))
-	if err != nil {
+	err = compareHashAndPassword(cfg, account.Password, []byte(currentPassword))
+	if err == lib.ErrQueueTimeout {
+		return errors.Wrap(err, "compareHashAndPassword")
+	} else if err != nil {
 		return FieldErrors{{"credentials", ErrFailed}}
 	}
 
-	return PasswordSetter(store, r, cfg, id, password)
+	err = PasswordSetter(store, outbox, r, cfg, id, password)
+	if err != nil {
+		return err
+	}
+
+	return SessionRevoker(tokenStore, id, keep)
 }
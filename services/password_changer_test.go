@@ -17,13 +17,14 @@ import (
 
 func TestPasswordChanger(t *testing.T) {
 	accountStore := mock.NewAccountStore()
+	refreshStore := mock.NewRefreshTokenStore()
 	cfg := &config.Config{
 		BcryptCost:            4,
 		PasswordMinComplexity: 1,
 	}
 
 	invoke := func(id int, currentPassword string, password string) error {
-		return services.PasswordChanger(accountStore, &ops.LogReporter{}, cfg, id, currentPassword, password)
+		return services.PasswordChanger(accountStore, refreshStore, nil, &ops.LogReporter{}, cfg, id, currentPassword, password, "")
 	}
 
 	factory := func(username string, password string) (*models.Account, error) {
@@ -53,6 +54,38 @@ func TestPasswordChanger(t *testing.T) {
 		assert.NotEqual(t, expired.Password, account.Password)
 	})
 
+	t.Run("it revokes sessions", func(t *testing.T) {
+		revoked, err := factory("revoked@keratin.tech", "old")
+		require.NoError(t, err)
+		_, err = refreshStore.Create(revoked.ID)
+		require.NoError(t, err)
+		_, err = refreshStore.Create(revoked.ID)
+		require.NoError(t, err)
+
+		err = invoke(revoked.ID, "old", "0a0b0c0d0e0f")
+		assert.NoError(t, err)
+
+		tokens, err := refreshStore.FindAll(revoked.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+
+	t.Run("it keeps the current session", func(t *testing.T) {
+		revoked, err := factory("kept@keratin.tech", "old")
+		require.NoError(t, err)
+		_, err = refreshStore.Create(revoked.ID)
+		require.NoError(t, err)
+		kept, err := refreshStore.Create(revoked.ID)
+		require.NoError(t, err)
+
+		err = services.PasswordChanger(accountStore, refreshStore, nil, &ops.LogReporter{}, cfg, revoked.ID, "old", "0a0b0c0d0e0f", kept)
+		assert.NoError(t, err)
+
+		tokens, err := refreshStore.FindAll(revoked.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.RefreshToken{kept}, tokens)
+	})
+
 	t.Run("with an unknown account", func(t *testing.T) {
 		err := invoke(0, "unknown", "0ab0c0d0e0f")
 		assert.Equal(t, services.FieldErrors{{"account", "NOT_FOUND"}}, err)
@@ -7,11 +7,14 @@ import (
 
 	"github.com/keratin/authn-server/config"
 	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
 	"github.com/keratin/authn-server/tokens/resets"
 	"github.com/pkg/errors"
 )
 
-func PasswordResetter(store data.AccountStore, r ops.ErrorReporter, cfg *config.Config, token string, password string) (int, error) {
+// PasswordResetter sets a new password with a reset token. Whoever lost the password may have lost
+// it to someone else, so every session except the one to keep, if any, is revoked.
+func PasswordResetter(store data.AccountStore, tokenStore data.RefreshTokenStore, outbox data.OutboxStore, r ops.ErrorReporter, cfg *config.Config, token string, password string, keep models.RefreshToken) (int, error) {
 	claims, err := resets.Parse(token, cfg)
 	if err != nil {
 		return 0, FieldErrors{{"token", ErrInvalidOrExpired}}
@@ -38,5 +41,10 @@ func PasswordResetter(store data.AccountStore, r ops.ErrorReporter, cfg *config.
 		return 0, FieldErrors{{"token", ErrInvalidOrExpired}}
 	}
 
-	return account.ID, PasswordSetter(store, r, cfg, id, password)
+	err = PasswordSetter(store, outbox, r, cfg, id, password)
+	if err != nil {
+		return 0, err
+	}
+
+	return account.ID, SessionRevoker(tokenStore, id, keep)
 }
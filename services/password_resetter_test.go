@@ -7,6 +7,7 @@ import (
 
 	"github.com/keratin/authn-server/config"
 	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/models"
 	"github.com/keratin/authn-server/ops"
 	"github.com/keratin/authn-server/services"
 	"github.com/keratin/authn-server/tokens/resets"
@@ -16,6 +17,7 @@ import (
 
 func TestPasswordResetter(t *testing.T) {
 	accountStore := mock.NewAccountStore()
+	refreshStore := mock.NewRefreshTokenStore()
 	cfg := &config.Config{
 		AuthNURL:              &url.URL{Scheme: "http", Host: "authn.example.com"},
 		BcryptCost:            4,
@@ -32,7 +34,7 @@ func TestPasswordResetter(t *testing.T) {
 	}
 
 	invoke := func(token string, password string) error {
-		_, err := services.PasswordResetter(accountStore, &ops.LogReporter{}, cfg, token, password)
+		_, err := services.PasswordResetter(accountStore, refreshStore, nil, &ops.LogReporter{}, cfg, token, password, "")
 		return err
 	}
 
@@ -54,6 +56,22 @@ func TestPasswordResetter(t *testing.T) {
 		assert.False(t, account.RequireNewPassword)
 	})
 
+	t.Run("revokes sessions", func(t *testing.T) {
+		revoked, err := accountStore.Create("revoked@keratin.tech", []byte("old"))
+		require.NoError(t, err)
+		_, err = refreshStore.Create(revoked.ID)
+		require.NoError(t, err)
+		kept, err := refreshStore.Create(revoked.ID)
+		require.NoError(t, err)
+
+		_, err = services.PasswordResetter(accountStore, refreshStore, nil, &ops.LogReporter{}, cfg, newToken(revoked.ID, revoked.PasswordChangedAt), "0a0b0c0d0e0f", kept)
+		assert.NoError(t, err)
+
+		tokens, err := refreshStore.FindAll(revoked.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.RefreshToken{kept}, tokens)
+	})
+
 	t.Run("when token is invalid", func(t *testing.T) {
 		token := "not.valid.jwt"
 
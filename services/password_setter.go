@@ -8,30 +8,47 @@ import (
 	"github.com/keratin/authn-server/data"
 	"github.com/keratin/authn-server/ops"
 	"github.com/pkg/errors"
-	"golang.org/x/crypto/bcrypt"
 )
 
-func PasswordSetter(store data.AccountStore, r ops.ErrorReporter, cfg *config.Config, accountID int, password string) error {
+// PasswordSetter validates and stores a new password, and notifies AppPasswordChangedURL. With an
+// outbox the notification is persisted once the password is stored, and delivered by the
+// OutboxDispatcher. Otherwise it is sent in the background. The password is changed either way, so
+// a notification that can not be persisted is only reported.
+func PasswordSetter(store data.AccountStore, outbox data.OutboxStore, r ops.ErrorReporter, cfg *config.Config, accountID int, password string) error {
 	fieldError := passwordValidator(cfg, password)
 	if fieldError != nil {
 		return FieldErrors{*fieldError}
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), cfg.BcryptCost)
+	hash, err := generateFromPassword(cfg, []byte(password))
 	if err != nil {
 		return errors.Wrap(err, "GenerateFromPassword")
 	}
 
-	if cfg.AppPasswordChangedURL != nil {
+	values := &url.Values{
+		"account_id": []string{strconv.Itoa(accountID)},
+	}
+
+	if cfg.AppPasswordChangedURL != nil && outbox == nil {
 		go func() {
-			err := WebhookSender(cfg.AppPasswordChangedURL, &url.Values{
-				"account_id": []string{strconv.Itoa(accountID)},
-			}, timeSensitiveDelivery)
+			err := WebhookSender(cfg.AppPasswordChangedURL, values, timeSensitiveDelivery)
 			if err != nil {
 				r.ReportError(err)
 			}
 		}()
 	}
 
-	return store.SetPassword(accountID, hash)
+	err = store.SetPassword(accountID, hash)
+	if err != nil {
+		return err
+	}
+
+	if cfg.AppPasswordChangedURL != nil && outbox != nil {
+		err = WebhookEnqueuer(outbox, PasswordChangedWebhook, values)
+		if err != nil {
+			r.ReportError(errors.Wrap(err, "WebhookEnqueuer"))
+		}
+	}
+
+	return nil
 }
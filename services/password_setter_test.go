@@ -1,12 +1,17 @@
 package services_test
 
 import (
+	"fmt"
+	"net/url"
 	"testing"
 
 	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
 	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/models"
 	"github.com/keratin/authn-server/ops"
 	"github.com/keratin/authn-server/services"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -19,7 +24,7 @@ func TestPasswordSetter(t *testing.T) {
 	}
 
 	invoke := func(id int, password string) error {
-		return services.PasswordSetter(accountStore, &ops.LogReporter{}, cfg, id, password)
+		return services.PasswordSetter(accountStore, nil, &ops.LogReporter{}, cfg, id, password)
 	}
 
 	account, err := accountStore.Create("existing@keratin.tech", []byte("old"))
@@ -47,4 +52,43 @@ func TestPasswordSetter(t *testing.T) {
 		err := invoke(account.ID, "abc")
 		assert.Equal(t, services.FieldErrors{{"password", "INSECURE"}}, err)
 	})
+	t.Run("with an outbox", func(t *testing.T) {
+		outbox := mock.NewOutboxStore()
+		cfg := *cfg
+		cfg.AppPasswordChangedURL = &url.URL{Scheme: "https", Host: "app.example.com"}
+
+		err := services.PasswordSetter(accountStore, outbox, &ops.LogReporter{}, &cfg, account.ID, "0a0b0c0d0e0f0")
+		require.NoError(t, err)
+
+		msg, err := outbox.Find(1)
+		require.NoError(t, err)
+		require.NotNil(t, msg)
+		assert.Equal(t, models.OutboxWebhook, msg.Kind)
+		assert.Equal(t, services.PasswordChangedWebhook, msg.Destination)
+		assert.Equal(t, fmt.Sprintf("account_id=%d", account.ID), msg.Payload)
+	})
+
+	t.Run("with a failing outbox", func(t *testing.T) {
+		cfg := *cfg
+		cfg.AppPasswordChangedURL = &url.URL{Scheme: "https", Host: "app.example.com"}
+
+		before, err := accountStore.Find(account.ID)
+		require.NoError(t, err)
+
+		err = services.PasswordSetter(accountStore, failingOutbox{mock.NewOutboxStore()}, &ops.LogReporter{}, &cfg, account.ID, "0a0b0c0d0e0f0")
+		assert.NoError(t, err)
+
+		after, err := accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.NotEqual(t, before.Password, after.Password)
+	})
+}
+
+// failingOutbox can not persist messages.
+type failingOutbox struct {
+	data.OutboxStore
+}
+
+func (failingOutbox) Create(kind string, destination string, payload string) (*models.OutboxMessage, error) {
+	return nil, errors.New("database unavailable")
 }
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/pkg/errors"
+)
+
+// Profile is an account's values for the PROFILE_FIELDS, and the fields that have yet to be
+// collected.
+type Profile struct {
+	Fields  map[string]string `json:"fields"`
+	Missing []string          `json:"missing"`
+}
+
+// ProfileGetter reads an account's profile from its metadata. Other metadata is not included.
+func ProfileGetter(annotations data.AnnotationStore, cfg *config.Config, accountID int) (*Profile, error) {
+	metadata, err := annotations.GetMetadata(accountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetMetadata")
+	}
+
+	profile := &Profile{Fields: map[string]string{}, Missing: []string{}}
+	for _, field := range cfg.ProfileFields {
+		if value, ok := metadata[field.Name]; ok && value != "" {
+			profile.Fields[field.Name] = value
+		} else {
+			profile.Missing = append(profile.Missing, field.Name)
+		}
+	}
+	return profile, nil
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/pkg/errors"
+)
+
+// ProfileUpdater validates profile fields that are collected after signup, and stores them in the
+// account's metadata.
+func ProfileUpdater(annotations data.AnnotationStore, cfg *config.Config, accountID int, values map[string]string) error {
+	valid, err := ProfileValidator(cfg, values, false)
+	if err != nil {
+		return err
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+
+	err = annotations.SetMetadata(accountID, valid)
+	if err != nil {
+		return errors.Wrap(err, "SetMetadata")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileUpdater(t *testing.T) {
+	fields, err := config.ParseProfileFields(`{
+		"name": {"required": true, "max_length": 10},
+		"birthdate": {"type": "date"},
+		"locale": {"pattern": "^[a-z]{2}$"}
+	}`)
+	require.NoError(t, err)
+	cfg := &config.Config{ProfileFields: fields}
+	annotations := mock.NewAnnotationStore()
+
+	testCases := []struct {
+		values map[string]string
+		errors services.FieldErrors
+	}{
+		{map[string]string{"locale": "en"}, nil},
+		{map[string]string{"name": "Jane", "birthdate": "2000-02-29"}, nil},
+		{map[string]string{"name": ""}, services.FieldErrors{{"name", services.ErrMissing}}},
+		{map[string]string{"name": "Jane Jacobs Doe"}, services.FieldErrors{{"name", services.ErrFormatInvalid}}},
+		{map[string]string{"birthdate": "2001-02-29"}, services.FieldErrors{{"birthdate", services.ErrFormatInvalid}}},
+		{map[string]string{"locale": "eng"}, services.FieldErrors{{"locale", services.ErrFormatInvalid}}},
+		{map[string]string{"plan": "pro"}, services.FieldErrors{{"plan", services.ErrFormatInvalid}}},
+	}
+	for _, tc := range testCases {
+		err := services.ProfileUpdater(annotations, cfg, 1, tc.values)
+		if tc.errors == nil {
+			assert.NoError(t, err, tc.values)
+		} else {
+			assert.Equal(t, tc.errors, err, tc.values)
+		}
+	}
+
+	metadata, err := annotations.GetMetadata(1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "Jane", "birthdate": "2000-02-29", "locale": "en"}, metadata)
+}
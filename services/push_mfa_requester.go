@@ -0,0 +1,39 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/lib/mfa"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/tokens/approvals"
+	"github.com/pkg/errors"
+)
+
+// PushMFARequester asks the account's push authenticator to approve a login. It returns a token
+// that the client may use to complete the login once approved, or an empty token when the account
+// has not enrolled an authenticator.
+func PushMFARequester(cfg *config.Config, account *models.Account, ip string, userAgent string) (string, error) {
+	return pushMFARequest(cfg, account, ip, userAgent, approvals.LoginScope)
+}
+
+// pushMFARequest asks for approval of a login or a step-up, and returns a token of the scope.
+func pushMFARequest(cfg *config.Config, account *models.Account, ip string, userAgent string, scope string) (string, error) {
+	requestID, err := cfg.PushMFAProvider.Request(mfa.PushRequest{
+		AccountID: account.ID,
+		Username:  account.Username,
+		IP:        ip,
+		UserAgent: userAgent,
+		StepUp:    scope == approvals.StepUpScope,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "Request")
+	}
+	if requestID == "" {
+		return "", nil
+	}
+
+	claims, err := approvals.New(cfg, account.ID, requestID, scope)
+	if err != nil {
+		return "", errors.Wrap(err, "New Approval")
+	}
+	return claims.Sign(cfg.PushMFASigningKey)
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/mfa"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/tokens/approvals"
+	"github.com/pkg/errors"
+)
+
+// PushMFAVerifier checks whether the push authenticator has approved a held login. It returns the
+// account once approved, and a PENDING error until then. It checks the account again, since it may
+// have changed while the login was pending.
+func PushMFAVerifier(store data.AccountStore, cfg *config.Config, token string) (*models.Account, error) {
+	return pushMFAVerify(store, cfg, token, approvals.LoginScope)
+}
+
+// pushMFAVerify checks a token of the scope for approval, and returns the approved account.
+func pushMFAVerify(store data.AccountStore, cfg *config.Config, token string, scope string) (*models.Account, error) {
+	claims, err := approvals.Parse(token, cfg, scope)
+	if err != nil {
+		return nil, FieldErrors{{"token", ErrInvalidOrExpired}}
+	}
+
+	status, err := cfg.PushMFAProvider.Status(claims.Request)
+	if err != nil {
+		return nil, errors.Wrap(err, "Status")
+	}
+	switch status {
+	case mfa.StatusPending:
+		return nil, FieldErrors{{"mfa", ErrPending}}
+	case mfa.StatusDenied:
+		return nil, FieldErrors{{"mfa", ErrDenied}}
+	case mfa.StatusApproved:
+	default:
+		return nil, fmt.Errorf("unknown status: %v", status)
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, errors.Wrap(err, "Atoi")
+	}
+
+	account, err := store.Find(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return nil, FieldErrors{{"account", ErrNotFound}}
+	} else if account.Locked || account.Archived() {
+		return nil, FieldErrors{{"account", ErrLocked}}
+	} else if account.Throttled() {
+		return nil, FieldErrors{{"account", ErrThrottled}}
+	} else if account.Expired() {
+		return nil, FieldErrors{{"account", ErrExpired}}
+	}
+
+	return account, nil
+}
@@ -0,0 +1,72 @@
+package services_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/lib/mfa"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushMFA(t *testing.T) {
+	store := mock.NewAccountStore()
+	provider := mfa.StaticProvider{}
+	cfg := &config.Config{
+		AuthNURL:          &url.URL{Scheme: "https", Host: "authn.example.com"},
+		PushMFAProvider:   provider,
+		PushMFASigningKey: []byte("push-a-reno"),
+		PushMFATimeout:    time.Minute,
+	}
+
+	account, err := store.Create("enrolled@keratin.tech", []byte("password"))
+	require.NoError(t, err)
+	provider["enrolled@keratin.tech"] = mfa.StatusPending
+
+	t.Run("unenrolled account", func(t *testing.T) {
+		unenrolled, err := store.Create("unenrolled@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		token, err := services.PushMFARequester(cfg, unenrolled, "127.0.0.1", "test")
+		require.NoError(t, err)
+		assert.Empty(t, token)
+	})
+
+	token, err := services.PushMFARequester(cfg, account, "127.0.0.1", "test")
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	t.Run("pending approval", func(t *testing.T) {
+		_, err := services.PushMFAVerifier(store, cfg, token)
+		assert.Equal(t, services.FieldErrors{{"mfa", services.ErrPending}}, err)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		provider["enrolled@keratin.tech"] = mfa.StatusDenied
+		_, err := services.PushMFAVerifier(store, cfg, token)
+		assert.Equal(t, services.FieldErrors{{"mfa", services.ErrDenied}}, err)
+	})
+
+	t.Run("approved", func(t *testing.T) {
+		provider["enrolled@keratin.tech"] = mfa.StatusApproved
+		found, err := services.PushMFAVerifier(store, cfg, token)
+		require.NoError(t, err)
+		assert.Equal(t, account.ID, found.ID)
+	})
+
+	t.Run("approved for a locked account", func(t *testing.T) {
+		require.NoError(t, store.Lock(account.ID))
+		defer store.Unlock(account.ID)
+		_, err := services.PushMFAVerifier(store, cfg, token)
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrLocked}}, err)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		_, err := services.PushMFAVerifier(store, cfg, "invalid")
+		assert.Equal(t, services.FieldErrors{{"token", services.ErrInvalidOrExpired}}, err)
+	})
+}
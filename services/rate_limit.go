@@ -0,0 +1,65 @@
+package services
+
+import (
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var rateLimitChecks = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "rate_limit_checks_total",
+		Help: "How many requests were checked against a rate limit, partitioned by limit and result",
+	},
+	[]string{"limit", "result"},
+)
+
+func init() {
+	prometheus.MustRegister(rateLimitChecks)
+}
+
+// RateLimit is the state of a client's limit, as reported in RateLimit-* headers.
+type RateLimit struct {
+	Name      string
+	Limit     int
+	Remaining int
+	Window    time.Duration
+	// Reset is how long until the oldest hit leaves the window, and frees a slot.
+	Reset time.Duration
+}
+
+// Exceeded reports whether the client has used its whole limit.
+func (rl *RateLimit) Exceeded() bool {
+	return rl.Remaining <= 0
+}
+
+// checkRateLimit reads a limit from the quotas and records the outcome in metrics.
+func checkRateLimit(quotas data.Quotas, name string, key string, limit int, window time.Duration) (*RateLimit, error) {
+	count, err := quotas.Count(key, window)
+	if err != nil {
+		return nil, errors.Wrap(err, "Count")
+	}
+	rl := &RateLimit{Name: name, Limit: limit, Remaining: limit - count, Window: window}
+	if rl.Remaining < 0 {
+		rl.Remaining = 0
+	}
+
+	if count > 0 {
+		expiry, err := quotas.Expiry(key, window)
+		if err != nil {
+			return nil, errors.Wrap(err, "Expiry")
+		}
+		if !expiry.IsZero() {
+			rl.Reset = time.Until(expiry)
+		}
+	}
+
+	if rl.Exceeded() {
+		rateLimitChecks.WithLabelValues(name, "limited").Inc()
+	} else {
+		rateLimitChecks.WithLabelValues(name, "allowed").Inc()
+	}
+	return rl, nil
+}
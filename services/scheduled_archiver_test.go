@@ -0,0 +1,39 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledArchiver(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	refreshStore := mock.NewRefreshTokenStore()
+	now := time.Now()
+
+	due, err := accountStore.Create("due", []byte("password"))
+	require.NoError(t, err)
+	err = accountStore.ScheduleArchive(due.ID, now.Add(-time.Minute))
+	require.NoError(t, err)
+
+	later, err := accountStore.Create("later", []byte("password"))
+	require.NoError(t, err)
+	err = accountStore.ScheduleArchive(later.ID, now.Add(time.Hour))
+	require.NoError(t, err)
+
+	archived, err := services.ScheduledArchiver(accountStore, refreshStore, now)
+	require.NoError(t, err)
+	assert.Equal(t, []int{due.ID}, archived)
+
+	found, err := accountStore.Find(due.ID)
+	require.NoError(t, err)
+	assert.True(t, found.Archived())
+
+	found, err = accountStore.Find(later.ID)
+	require.NoError(t, err)
+	assert.False(t, found.Archived())
+}
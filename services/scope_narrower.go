@@ -0,0 +1,29 @@
+package services
+
+import "strings"
+
+// ScopeNarrower returns the requested subset of a session's granted scopes. The request is a
+// space-delimited list, as in OAuth. An empty request keeps every granted scope.
+func ScopeNarrower(granted []string, requested string) ([]string, error) {
+	if requested == "" {
+		return granted, nil
+	}
+
+	narrowed := []string{}
+	for _, scope := range strings.Fields(requested) {
+		if !isGranted(granted, scope) {
+			return nil, FieldErrors{{"scope", ErrNotGranted}}
+		}
+		narrowed = append(narrowed, scope)
+	}
+	return narrowed, nil
+}
+
+func isGranted(granted []string, scope string) bool {
+	for _, s := range granted {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
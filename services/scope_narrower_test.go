@@ -0,0 +1,36 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeNarrower(t *testing.T) {
+	granted := []string{"profile", "billing", "admin"}
+
+	t.Run("without a request", func(t *testing.T) {
+		scopes, err := services.ScopeNarrower(granted, "")
+		require.NoError(t, err)
+		assert.Equal(t, granted, scopes)
+	})
+
+	t.Run("with a subset", func(t *testing.T) {
+		scopes, err := services.ScopeNarrower(granted, "billing  profile")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"billing", "profile"}, scopes)
+	})
+
+	t.Run("with an ungranted scope", func(t *testing.T) {
+		scopes, err := services.ScopeNarrower(granted, "profile superuser")
+		assert.Equal(t, services.FieldErrors{{"scope", services.ErrNotGranted}}, err)
+		assert.Empty(t, scopes)
+	})
+
+	t.Run("without granted scopes", func(t *testing.T) {
+		_, err := services.ScopeNarrower(nil, "profile")
+		assert.Equal(t, services.FieldErrors{{"scope", services.ErrNotGranted}}, err)
+	})
+}
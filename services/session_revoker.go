@@ -0,0 +1,25 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// SessionRevoker revokes every session of an account except the one to keep, which may be empty.
+func SessionRevoker(tokenStore data.RefreshTokenStore, accountID int, keep models.RefreshToken) error {
+	tokens, err := tokenStore.FindAll(accountID)
+	if err != nil {
+		return errors.Wrap(err, "FindAll")
+	}
+	for _, token := range tokens {
+		if token == keep {
+			continue
+		}
+		err = tokenStore.Revoke(token)
+		if err != nil {
+			return errors.Wrap(err, "Revoke")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+)
+
+// SuspectedBotTag is added to accounts that were allowed to sign up despite looking automated.
+const SuspectedBotTag = "suspected-bot"
+
+// SignupBotDetector returns true when a signup looks automated: the honeypot field was filled in,
+// or the form was submitted faster than a human could manage. It runs before any expensive work
+// like bcrypt, so that bots are cheap to turn away.
+func SignupBotDetector(cfg *config.Config, honeypot string, formStartedAt string, now time.Time) bool {
+	if cfg.SignupHoneypotField != "" && honeypot != "" {
+		return true
+	}
+
+	if cfg.SignupMinFillTime > 0 {
+		startedAt, err := strconv.ParseInt(formStartedAt, 10, 64)
+		if err != nil {
+			return true
+		}
+		if now.Sub(time.Unix(startedAt, 0)) < cfg.SignupMinFillTime {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,38 @@
+package services_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignupBotDetector(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) string {
+		return strconv.FormatInt(now.Add(-d).Unix(), 10)
+	}
+
+	t.Run("unconfigured", func(t *testing.T) {
+		cfg := &config.Config{}
+		assert.False(t, services.SignupBotDetector(cfg, "filled", "", now))
+	})
+
+	t.Run("honeypot", func(t *testing.T) {
+		cfg := &config.Config{SignupHoneypotField: "website"}
+		assert.False(t, services.SignupBotDetector(cfg, "", "", now))
+		assert.True(t, services.SignupBotDetector(cfg, "http://spam.example.com", "", now))
+	})
+
+	t.Run("minimum fill time", func(t *testing.T) {
+		cfg := &config.Config{SignupMinFillTime: 5 * time.Second}
+		assert.True(t, services.SignupBotDetector(cfg, "", "", now))
+		assert.True(t, services.SignupBotDetector(cfg, "", "yesterday", now))
+		assert.True(t, services.SignupBotDetector(cfg, "", ago(time.Second), now))
+		assert.True(t, services.SignupBotDetector(cfg, "", ago(-time.Minute), now))
+		assert.False(t, services.SignupBotDetector(cfg, "", ago(10*time.Second), now))
+	})
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/pkg/errors"
+)
+
+type signupQuota struct {
+	name   string
+	field  string
+	key    string
+	limit  int
+	window time.Duration
+}
+
+// SignupQuotaChecker refuses a signup when its IP address or email domain has already reached the
+// configured quota. It returns the quota with the least remaining, for RateLimit-* headers, or nil
+// when no quota applies.
+func SignupQuotaChecker(quotas data.Quotas, cfg *config.Config, ip string, username string) (*RateLimit, error) {
+	var tightest *RateLimit
+	for _, q := range signupQuotas(cfg, ip, username) {
+		rl, err := checkRateLimit(quotas, q.name, q.key, q.limit, q.window)
+		if err != nil {
+			return nil, err
+		}
+		if rl.Exceeded() {
+			return rl, FieldErrors{{q.field, ErrThrottled}}
+		}
+		if tightest == nil || rl.Remaining < tightest.Remaining {
+			tightest = rl
+		}
+	}
+	return tightest, nil
+}
+
+// SignupQuotaTracker counts a successful signup against the quotas of its IP address and email
+// domain.
+func SignupQuotaTracker(quotas data.Quotas, cfg *config.Config, ip string, username string) error {
+	for _, q := range signupQuotas(cfg, ip, username) {
+		err := quotas.Hit(q.key, q.window)
+		if err != nil {
+			return errors.Wrap(err, "Hit")
+		}
+	}
+	return nil
+}
+
+func signupQuotas(cfg *config.Config, ip string, username string) []signupQuota {
+	quotas := []signupQuota{}
+
+	if cfg.SignupQuotaPerIP > 0 && ip != "" {
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		quotas = append(quotas, signupQuota{"signup_ip", "ip", "signups:ip:" + ip, cfg.SignupQuotaPerIP, time.Hour})
+	}
+
+	if cfg.SignupQuotaPerDomain > 0 {
+		if i := strings.LastIndex(username, "@"); i >= 0 && i < len(username)-1 {
+			domain := strings.ToLower(strings.TrimSpace(username[i+1:]))
+			quotas = append(quotas, signupQuota{"signup_domain", "username", "signups:domain:" + domain, cfg.SignupQuotaPerDomain, 24 * time.Hour})
+		}
+	}
+
+	return quotas
+}
@@ -0,0 +1,58 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignupQuota(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		quotas := mock.NewQuotas()
+		cfg := &config.Config{}
+		for i := 0; i < 3; i++ {
+			require.NoError(t, services.SignupQuotaTracker(quotas, cfg, "127.0.0.1:1234", "a@example.com"))
+		}
+		rl, err := services.SignupQuotaChecker(quotas, cfg, "127.0.0.1:1234", "a@example.com")
+		assert.NoError(t, err)
+		assert.Nil(t, rl)
+	})
+
+	t.Run("per IP", func(t *testing.T) {
+		quotas := mock.NewQuotas()
+		cfg := &config.Config{SignupQuotaPerIP: 2}
+		require.NoError(t, services.SignupQuotaTracker(quotas, cfg, "127.0.0.1:1234", "a@example.com"))
+		rl, err := services.SignupQuotaChecker(quotas, cfg, "127.0.0.1:5678", "b@example.com")
+		assert.NoError(t, err)
+		require.NotNil(t, rl)
+		assert.Equal(t, 2, rl.Limit)
+		assert.Equal(t, 1, rl.Remaining)
+		assert.InDelta(t, time.Hour.Seconds(), rl.Reset.Seconds(), 1)
+		require.NoError(t, services.SignupQuotaTracker(quotas, cfg, "127.0.0.1:5678", "b@example.com"))
+
+		rl, err = services.SignupQuotaChecker(quotas, cfg, "127.0.0.1:9012", "c@example.com")
+		assert.Equal(t, services.FieldErrors{{"ip", services.ErrThrottled}}, err)
+		require.NotNil(t, rl)
+		assert.Equal(t, 0, rl.Remaining)
+		_, err = services.SignupQuotaChecker(quotas, cfg, "10.0.0.1:9012", "c@example.com")
+		assert.NoError(t, err)
+	})
+
+	t.Run("per domain", func(t *testing.T) {
+		quotas := mock.NewQuotas()
+		cfg := &config.Config{SignupQuotaPerDomain: 1}
+		require.NoError(t, services.SignupQuotaTracker(quotas, cfg, "127.0.0.1:1234", "a@example.com"))
+
+		_, err := services.SignupQuotaChecker(quotas, cfg, "10.0.0.1:1234", "b@EXAMPLE.com")
+		assert.Equal(t, services.FieldErrors{{"username", services.ErrThrottled}}, err)
+		_, err = services.SignupQuotaChecker(quotas, cfg, "10.0.0.1:1234", "b@example.org")
+		assert.NoError(t, err)
+		_, err = services.SignupQuotaChecker(quotas, cfg, "10.0.0.1:1234", "username")
+		assert.NoError(t, err)
+	})
+}
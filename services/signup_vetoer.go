@@ -0,0 +1,87 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/ops"
+	"github.com/pkg/errors"
+)
+
+type vetoUnavailableError struct {
+	cause error
+}
+
+func (e vetoUnavailableError) Error() string {
+	return "signup veto unavailable: " + e.cause.Error()
+}
+
+// Unavailable signals that signups are refused because the veto endpoint is down.
+func (e vetoUnavailableError) Unavailable() bool {
+	return true
+}
+
+// SignupVetoer asks the APP_SIGNUP_VETO_URL whether a signup may proceed. A 2xx response allows
+// it. A 4xx response rejects it, with an optional JSON body of {"code": "..."} that is returned to
+// the client as the validation message.
+//
+// Any other outcome is a failure of the endpoint. Failures are reported and then either allow the
+// signup (fail open) or refuse it as unavailable (fail closed), per configuration.
+func SignupVetoer(cfg *config.Config, reporter ops.ErrorReporter, username string, metadata url.Values) error {
+	if cfg.AppSignupVetoURL == nil {
+		return nil
+	}
+
+	values := url.Values{}
+	for k, v := range metadata {
+		values[k] = v
+	}
+	values.Set("username", username)
+
+	client := &http.Client{Timeout: cfg.SignupVetoTimeout}
+	res, err := client.PostForm(cfg.AppSignupVetoURL.String(), values)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			// avoid reporting the URL with potential HTTP auth credentials
+			err = urlErr.Err
+		}
+		return vetoFailure(cfg, reporter, errors.Wrap(err, "PostForm"))
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return nil
+	case res.StatusCode >= 400 && res.StatusCode < 500:
+		return FieldErrors{{"username", vetoCode(res.Body)}}
+	default:
+		return vetoFailure(cfg, reporter, errors.Errorf("Status Code: %v", res.StatusCode))
+	}
+}
+
+func vetoCode(body io.Reader) string {
+	data, err := ioutil.ReadAll(io.LimitReader(body, 4096))
+	if err != nil {
+		return ErrVetoed
+	}
+
+	veto := struct {
+		Code string `json:"code"`
+	}{}
+	if json.Unmarshal(data, &veto) != nil || veto.Code == "" {
+		return ErrVetoed
+	}
+	return veto.Code
+}
+
+func vetoFailure(cfg *config.Config, reporter ops.ErrorReporter, err error) error {
+	if cfg.SignupVetoFailOpen {
+		reporter.ReportError(errors.Wrap(err, "SignupVetoer"))
+		return nil
+	}
+	return vetoUnavailableError{err}
+}
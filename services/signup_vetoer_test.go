@@ -0,0 +1,86 @@
+package services_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/ops"
+	"github.com/keratin/authn-server/services"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignupVetoer(t *testing.T) {
+	remoteApp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("username") {
+		case "allowed":
+			assert.Equal(t, "1.2.3.4", r.FormValue("ip"))
+			w.WriteHeader(http.StatusOK)
+		case "rejected":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"code": "NOT_ON_ALLOWLIST"}`))
+		case "rejected-plain":
+			w.WriteHeader(http.StatusForbidden)
+		case "slow":
+			time.Sleep(100 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer remoteApp.Close()
+	vetoURL, err := url.Parse(remoteApp.URL)
+	require.NoError(t, err)
+
+	metadata := url.Values{"ip": []string{"1.2.3.4"}}
+	reporter := &ops.LogReporter{}
+
+	cfg := &config.Config{
+		AppSignupVetoURL:  vetoURL,
+		SignupVetoTimeout: 50 * time.Millisecond,
+	}
+
+	t.Run("without configured url", func(t *testing.T) {
+		err := services.SignupVetoer(&config.Config{}, reporter, "broken", metadata)
+		assert.NoError(t, err)
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		err := services.SignupVetoer(cfg, reporter, "allowed", metadata)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejected with code", func(t *testing.T) {
+		err := services.SignupVetoer(cfg, reporter, "rejected", metadata)
+		assert.Equal(t, services.FieldErrors{{"username", "NOT_ON_ALLOWLIST"}}, err)
+	})
+
+	t.Run("rejected without code", func(t *testing.T) {
+		err := services.SignupVetoer(cfg, reporter, "rejected-plain", metadata)
+		assert.Equal(t, services.FieldErrors{{"username", services.ErrVetoed}}, err)
+	})
+
+	t.Run("failing closed", func(t *testing.T) {
+		for _, username := range []string{"broken", "slow"} {
+			err := services.SignupVetoer(cfg, reporter, username, metadata)
+			if assert.Error(t, err) {
+				u, ok := errors.Cause(err).(interface{ Unavailable() bool })
+				assert.True(t, ok && u.Unavailable())
+			}
+		}
+	})
+
+	t.Run("failing open", func(t *testing.T) {
+		openCfg := *cfg
+		openCfg.SignupVetoFailOpen = true
+		for _, username := range []string{"broken", "slow"} {
+			err := services.SignupVetoer(&openCfg, reporter, username, metadata)
+			assert.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/tokens/approvals"
+	"github.com/pkg/errors"
+)
+
+// StepUpRequester asks the account's push authenticator to re-verify a user who is already logged
+// in, without a password. It returns a token that the client may use to step up the session once
+// approved.
+func StepUpRequester(store data.AccountStore, cfg *config.Config, accountID int, ip string, userAgent string) (string, error) {
+	account, err := store.Find(accountID)
+	if err != nil {
+		return "", errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return "", FieldErrors{{"account", ErrNotFound}}
+	} else if account.Locked || account.Archived() {
+		return "", FieldErrors{{"account", ErrLocked}}
+	}
+
+	token, err := pushMFARequest(cfg, account, ip, userAgent, approvals.StepUpScope)
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		return "", FieldErrors{{"mfa", ErrNotEnrolled}}
+	}
+	return token, nil
+}
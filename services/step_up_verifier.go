@@ -0,0 +1,35 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/tokens/approvals"
+	"github.com/keratin/authn-server/tokens/sessions"
+)
+
+// StepUpVerifier checks whether the push authenticator has approved a step-up for the session's
+// account. It returns a PENDING error until then, like PushMFAVerifier.
+func StepUpVerifier(store data.AccountStore, cfg *config.Config, accountID int, token string) error {
+	account, err := pushMFAVerify(store, cfg, token, approvals.StepUpScope)
+	if err != nil {
+		return err
+	}
+	if account.ID != accountID {
+		return FieldErrors{{"token", ErrInvalidOrExpired}}
+	}
+	return nil
+}
+
+// StepUpChecker fails when a session requests any of STEP_UP_SCOPES without a recent second
+// factor, so that the client knows to step up rather than receive a token without them.
+func StepUpChecker(cfg *config.Config, session *sessions.Claims, scopes []string) error {
+	if session.SteppedUp(cfg.StepUpMaxAge) {
+		return nil
+	}
+	for _, scope := range scopes {
+		if cfg.StepUpScope(scope) {
+			return FieldErrors{{"scope", ErrStepUpRequired}}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/events"
+	"github.com/keratin/authn-server/models"
+	"github.com/pkg/errors"
+)
+
+// takeoutAuditPageSize is how many audit entries are read at a time.
+const takeoutAuditPageSize = 100
+
+// Takeout is an archive of what AuthN knows about an account.
+type Takeout struct {
+	Account     exportedAccount   `json:"account"`
+	Sessions    []takeoutSession  `json:"sessions"`
+	Consents    []*models.Consent `json:"consents"`
+	Events      []events.Event    `json:"events"`
+	GeneratedAt time.Time         `json:"generated_at"`
+}
+
+// takeoutSession identifies a session by a digest of its refresh token, which is a bearer
+// credential.
+type takeoutSession struct {
+	ID string `json:"id"`
+}
+
+// TakeoutAssembler gathers an account's profile, active sessions, consents, and audit events (oldest
+// first). Password hashes and OAuth access tokens are never included. The audit log is optional.
+func TakeoutAssembler(
+	store data.AccountStore,
+	annotations data.AnnotationStore,
+	tokens data.RefreshTokenStore,
+	consents data.ConsentStore,
+	log data.AuditLog,
+	accountID int,
+) (*Takeout, error) {
+	account, err := AccountGetter(store, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	takeout := &Takeout{
+		Account: exportedAccount{
+			ID:                 account.ID,
+			Username:           account.Username,
+			Locked:             account.Locked,
+			RequireNewPassword: account.RequireNewPassword,
+			PasswordChangedAt:  account.PasswordChangedAt,
+			CreatedAt:          account.CreatedAt,
+			UpdatedAt:          account.UpdatedAt,
+			DeletedAt:          account.DeletedAt,
+			ArchiveAt:          account.ArchiveAt,
+			ExpiresAt:          account.ExpiresAt,
+		},
+		Sessions:    []takeoutSession{},
+		Events:      []events.Event{},
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	oauthAccounts, err := store.GetOauthAccounts(account.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetOauthAccounts")
+	}
+	for _, oauthAccount := range oauthAccounts {
+		takeout.Account.OauthAccounts = append(takeout.Account.OauthAccounts, exportedOauthAccount{
+			Provider:   oauthAccount.Provider,
+			ProviderID: oauthAccount.ProviderID,
+		})
+	}
+
+	metadata, err := annotations.GetMetadata(account.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetMetadata")
+	}
+	if len(metadata) > 0 {
+		takeout.Account.Metadata = metadata
+	}
+
+	refreshTokens, err := tokens.FindAll(account.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "FindAll")
+	}
+	for _, t := range refreshTokens {
+		sum := sha256.Sum256([]byte(t))
+		takeout.Sessions = append(takeout.Sessions, takeoutSession{ID: hex.EncodeToString(sum[:])})
+	}
+
+	takeout.Consents, err = consents.FindAll(account.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "FindAll")
+	}
+	if takeout.Consents == nil {
+		takeout.Consents = []*models.Consent{}
+	}
+
+	if log != nil {
+		cursor := ""
+		for {
+			entries, err := log.Find(account.ID, cursor, takeoutAuditPageSize)
+			if err != nil {
+				return nil, errors.Wrap(err, "Find")
+			}
+			for _, entry := range entries {
+				takeout.Events = append(takeout.Events, entry.Event)
+			}
+			if len(entries) < takeoutAuditPageSize {
+				break
+			}
+			cursor = entries[len(entries)-1].Cursor
+		}
+		for i, j := 0, len(takeout.Events)-1; i < j; i, j = i+1, j-1 {
+			takeout.Events[i], takeout.Events[j] = takeout.Events[j], takeout.Events[i]
+		}
+	}
+
+	return takeout, nil
+}
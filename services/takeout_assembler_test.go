@@ -0,0 +1,39 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeoutAssembler(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	annotations := mock.NewAnnotationStore()
+	tokens := mock.NewRefreshTokenStore()
+	consents := mock.NewConsentStore()
+
+	t.Run("unknown account", func(t *testing.T) {
+		_, err := services.TakeoutAssembler(accountStore, annotations, tokens, consents, nil, 999)
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrNotFound}}, err)
+	})
+
+	t.Run("without an audit log", func(t *testing.T) {
+		account, err := accountStore.Create("takeout@test.com", []byte("secret"))
+		require.NoError(t, err)
+		require.NoError(t, accountStore.AddOauthAccount(account.ID, "google", "123", "token"))
+		require.NoError(t, annotations.SetMetadata(account.ID, map[string]string{"locale": "en"}))
+
+		takeout, err := services.TakeoutAssembler(accountStore, annotations, tokens, consents, nil, account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "takeout@test.com", takeout.Account.Username)
+		assert.Equal(t, map[string]string{"locale": "en"}, takeout.Account.Metadata)
+		assert.Len(t, takeout.Account.OauthAccounts, 1)
+		assert.Empty(t, takeout.Sessions)
+		assert.Equal(t, []*models.Consent{}, takeout.Consents)
+		assert.Empty(t, takeout.Events)
+	})
+}
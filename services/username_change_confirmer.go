@@ -0,0 +1,67 @@
+package services
+
+import (
+	"strconv"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+	"github.com/keratin/authn-server/tokens/usernames"
+	"github.com/pkg/errors"
+)
+
+// UsernameChangeConfirmer changes an account's username with a confirmation token, and returns
+// the claims that will revert the change.
+func UsernameChangeConfirmer(store data.AccountStore, cfg *config.Config, token string) (*usernames.Claims, error) {
+	claims, err := usernames.Parse(token, cfg, usernames.ChangeScope)
+	if err != nil {
+		return nil, FieldErrors{{"token", ErrInvalidOrExpired}}
+	}
+
+	account, err := findUsernameChangeAccount(store, claims)
+	if err != nil {
+		return nil, err
+	}
+	if account.Locked {
+		return nil, FieldErrors{{"account", ErrLocked}}
+	}
+	err = usernameChangeCooldown(store, cfg, account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.UpdateUsername(account.ID, claims.To)
+	if err != nil {
+		if data.IsUniquenessError(err) {
+			return nil, FieldErrors{{"username", ErrTaken}}
+		}
+		return nil, errors.Wrap(err, "UpdateUsername")
+	}
+
+	return usernames.NewRevert(cfg, account.ID, claims.To, claims.From)
+}
+
+// findUsernameChangeAccount finds the account for a username change token. A token is no longer
+// valid once the account's username has changed from what it expects.
+func findUsernameChangeAccount(store data.AccountStore, claims *usernames.Claims) (*models.Account, error) {
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, errors.Wrap(err, "Atoi")
+	}
+
+	account, err := store.Find(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return nil, FieldErrors{{"account", ErrNotFound}}
+	} else if account.Archived() {
+		return nil, FieldErrors{{"account", ErrLocked}}
+	}
+
+	if account.Username != claims.From {
+		return nil, FieldErrors{{"token", ErrInvalidOrExpired}}
+	}
+
+	return account, nil
+}
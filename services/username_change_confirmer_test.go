@@ -0,0 +1,82 @@
+package services_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/usernames"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsernameChangeConfirmer(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	cfg := &config.Config{
+		AuthNURL:                 &url.URL{Scheme: "https", Host: "authn.example.com"},
+		UsernameChangeSigningKey: []byte("change-a-reno"),
+		UsernameChangeTokenTTL:   time.Hour,
+		UsernameRevertTTL:        time.Hour,
+	}
+
+	newToken := func(id int, from string, to string) string {
+		claims, err := usernames.NewChange(cfg, id, from, to)
+		require.NoError(t, err)
+		token, err := claims.Sign(cfg.UsernameChangeSigningKey)
+		require.NoError(t, err)
+		return token
+	}
+
+	t.Run("changes username", func(t *testing.T) {
+		account, err := accountStore.Create("first", []byte("password"))
+		require.NoError(t, err)
+
+		revert, err := services.UsernameChangeConfirmer(accountStore, cfg, newToken(account.ID, "first", "second"))
+		require.NoError(t, err)
+		assert.Equal(t, usernames.RevertScope, revert.Scope)
+		assert.Equal(t, "second", revert.From)
+		assert.Equal(t, "first", revert.To)
+
+		found, err := accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "second", found.Username)
+	})
+
+	t.Run("when the username has changed since", func(t *testing.T) {
+		account, err := accountStore.Create("third", []byte("password"))
+		require.NoError(t, err)
+
+		_, err = services.UsernameChangeConfirmer(accountStore, cfg, newToken(account.ID, "changed", "fourth"))
+		assert.Equal(t, services.FieldErrors{{"token", services.ErrInvalidOrExpired}}, err)
+	})
+
+	t.Run("when the username has been taken since", func(t *testing.T) {
+		account, err := accountStore.Create("fifth", []byte("password"))
+		require.NoError(t, err)
+		_, err = accountStore.Create("sixth", []byte("password"))
+		require.NoError(t, err)
+
+		_, err = services.UsernameChangeConfirmer(accountStore, cfg, newToken(account.ID, "fifth", "sixth"))
+		assert.Equal(t, services.FieldErrors{{"username", services.ErrTaken}}, err)
+	})
+
+	t.Run("with a revert token", func(t *testing.T) {
+		account, err := accountStore.Create("seventh", []byte("password"))
+		require.NoError(t, err)
+		claims, err := usernames.NewRevert(cfg, account.ID, "seventh", "eighth")
+		require.NoError(t, err)
+		token, err := claims.Sign(cfg.UsernameChangeSigningKey)
+		require.NoError(t, err)
+
+		_, err = services.UsernameChangeConfirmer(accountStore, cfg, token)
+		assert.Equal(t, services.FieldErrors{{"token", services.ErrInvalidOrExpired}}, err)
+	})
+
+	t.Run("with an invalid token", func(t *testing.T) {
+		_, err := services.UsernameChangeConfirmer(accountStore, cfg, "not.valid.jwt")
+		assert.Equal(t, services.FieldErrors{{"token", services.ErrInvalidOrExpired}}, err)
+	})
+}
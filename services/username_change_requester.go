@@ -0,0 +1,62 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/tokens/usernames"
+	"github.com/pkg/errors"
+)
+
+// UsernameChangeRequester validates a new username for an account and returns the claims that
+// will confirm the change. The username does not change until those claims are confirmed.
+func UsernameChangeRequester(store data.AccountStore, cfg *config.Config, accountID int, username string) (*usernames.Claims, error) {
+	account, err := store.Find(accountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+	if account == nil {
+		return nil, FieldErrors{{"account", ErrNotFound}}
+	} else if account.Locked || account.Archived() {
+		return nil, FieldErrors{{"account", ErrLocked}}
+	}
+
+	err = usernameChangeCooldown(store, cfg, account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	username = strings.TrimSpace(username)
+
+	fieldError := usernameValidator(cfg, username)
+	if fieldError != nil {
+		return nil, FieldErrors{*fieldError}
+	}
+
+	existing, err := store.FindByUsername(username)
+	if err != nil {
+		return nil, errors.Wrap(err, "FindByUsername")
+	}
+	if existing != nil {
+		return nil, FieldErrors{{"username", ErrTaken}}
+	}
+
+	return usernames.NewChange(cfg, account.ID, account.Username, username)
+}
+
+// usernameChangeCooldown fails while the account's last username change is within the cooldown.
+func usernameChangeCooldown(store data.AccountStore, cfg *config.Config, accountID int) error {
+	if cfg.UsernameChangeCooldown == 0 {
+		return nil
+	}
+	changes, err := store.GetUsernameHistory(accountID)
+	if err != nil {
+		return errors.Wrap(err, "GetUsernameHistory")
+	}
+	if len(changes) > 0 && time.Since(changes[len(changes)-1].CreatedAt) < cfg.UsernameChangeCooldown {
+		return FieldErrors{{"username", ErrThrottled}}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package services_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/usernames"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsernameChangeRequester(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	cfg := &config.Config{
+		AuthNURL:        &url.URL{Scheme: "https", Host: "authn.example.com"},
+		UsernameIsEmail: true,
+	}
+
+	account, err := accountStore.Create("old@keratin.tech", []byte("password"))
+	require.NoError(t, err)
+
+	t.Run("valid username", func(t *testing.T) {
+		claims, err := services.UsernameChangeRequester(accountStore, cfg, account.ID, " new@keratin.tech ")
+		require.NoError(t, err)
+		assert.Equal(t, usernames.ChangeScope, claims.Scope)
+		assert.Equal(t, "old@keratin.tech", claims.From)
+		assert.Equal(t, "new@keratin.tech", claims.To)
+
+		found, err := accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "old@keratin.tech", found.Username)
+	})
+
+	t.Run("invalid username", func(t *testing.T) {
+		_, err := services.UsernameChangeRequester(accountStore, cfg, account.ID, "new")
+		assert.Equal(t, services.FieldErrors{{"username", services.ErrFormatInvalid}}, err)
+	})
+
+	t.Run("taken username", func(t *testing.T) {
+		_, err := accountStore.Create("taken@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+
+		_, err = services.UsernameChangeRequester(accountStore, cfg, account.ID, "taken@keratin.tech")
+		assert.Equal(t, services.FieldErrors{{"username", services.ErrTaken}}, err)
+	})
+
+	t.Run("locked account", func(t *testing.T) {
+		locked, err := accountStore.Create("locked@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		err = accountStore.Lock(locked.ID)
+		require.NoError(t, err)
+
+		_, err = services.UsernameChangeRequester(accountStore, cfg, locked.ID, "unlocked@keratin.tech")
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrLocked}}, err)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		_, err := services.UsernameChangeRequester(accountStore, cfg, 9999, "unknown@keratin.tech")
+		assert.Equal(t, services.FieldErrors{{"account", services.ErrNotFound}}, err)
+	})
+
+	t.Run("recently changed username", func(t *testing.T) {
+		cooling, err := accountStore.Create("cooling@keratin.tech", []byte("password"))
+		require.NoError(t, err)
+		err = accountStore.UpdateUsername(cooling.ID, "changed@keratin.tech")
+		require.NoError(t, err)
+
+		cooldownCfg := *cfg
+		cooldownCfg.UsernameChangeCooldown = time.Hour
+		_, err = services.UsernameChangeRequester(accountStore, &cooldownCfg, cooling.ID, "again@keratin.tech")
+		assert.Equal(t, services.FieldErrors{{"username", services.ErrThrottled}}, err)
+
+		_, err = services.UsernameChangeRequester(accountStore, cfg, cooling.ID, "again@keratin.tech")
+		assert.NoError(t, err)
+	})
+}
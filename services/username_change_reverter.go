@@ -0,0 +1,33 @@
+package services
+
+import (
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/tokens/usernames"
+	"github.com/pkg/errors"
+)
+
+// UsernameChangeReverter restores an account's previous username with a revert token. Since the
+// change may have been made by someone who took over the account, it also revokes every session
+// and requires a new password.
+func UsernameChangeReverter(store data.AccountStore, tokenStore data.RefreshTokenStore, cfg *config.Config, token string) (int, error) {
+	claims, err := usernames.Parse(token, cfg, usernames.RevertScope)
+	if err != nil {
+		return 0, FieldErrors{{"token", ErrInvalidOrExpired}}
+	}
+
+	account, err := findUsernameChangeAccount(store, claims)
+	if err != nil {
+		return 0, err
+	}
+
+	err = store.UpdateUsername(account.ID, claims.To)
+	if err != nil {
+		if data.IsUniquenessError(err) {
+			return 0, FieldErrors{{"username", ErrTaken}}
+		}
+		return 0, errors.Wrap(err, "UpdateUsername")
+	}
+
+	return account.ID, PasswordExpirer(store, tokenStore, account.ID)
+}
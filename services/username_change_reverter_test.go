@@ -0,0 +1,66 @@
+package services_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/usernames"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsernameChangeReverter(t *testing.T) {
+	accountStore := mock.NewAccountStore()
+	refreshStore := mock.NewRefreshTokenStore()
+	cfg := &config.Config{
+		AuthNURL:                 &url.URL{Scheme: "https", Host: "authn.example.com"},
+		UsernameChangeSigningKey: []byte("change-a-reno"),
+		UsernameChangeTokenTTL:   time.Hour,
+		UsernameRevertTTL:        time.Hour,
+	}
+
+	newToken := func(id int, from string, to string) string {
+		claims, err := usernames.NewRevert(cfg, id, from, to)
+		require.NoError(t, err)
+		token, err := claims.Sign(cfg.UsernameChangeSigningKey)
+		require.NoError(t, err)
+		return token
+	}
+
+	t.Run("restores username", func(t *testing.T) {
+		account, err := accountStore.Create("attacker", []byte("password"))
+		require.NoError(t, err)
+		_, err = refreshStore.Create(account.ID)
+		require.NoError(t, err)
+
+		id, err := services.UsernameChangeReverter(accountStore, refreshStore, cfg, newToken(account.ID, "attacker", "victim"))
+		require.NoError(t, err)
+		assert.Equal(t, account.ID, id)
+
+		found, err := accountStore.Find(account.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "victim", found.Username)
+		assert.True(t, found.RequireNewPassword)
+
+		tokens, err := refreshStore.FindAll(account.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+
+	t.Run("when already reverted", func(t *testing.T) {
+		account, err := accountStore.Create("reverted", []byte("password"))
+		require.NoError(t, err)
+
+		_, err = services.UsernameChangeReverter(accountStore, refreshStore, cfg, newToken(account.ID, "changed", "reverted"))
+		assert.Equal(t, services.FieldErrors{{"token", services.ErrInvalidOrExpired}}, err)
+	})
+
+	t.Run("with an invalid token", func(t *testing.T) {
+		_, err := services.UsernameChangeReverter(accountStore, refreshStore, cfg, "not.valid.jwt")
+		assert.Equal(t, services.FieldErrors{{"token", services.ErrInvalidOrExpired}}, err)
+	})
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"net/url"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/tokens/usernames"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// UsernameChangeSender delivers a username change token to the username it changes to. That is
+// the new username for a confirmation, and the old username for a revert.
+func UsernameChangeSender(cfg *config.Config, claims *usernames.Claims) error {
+	tokenStr, err := claims.Sign(cfg.UsernameChangeSigningKey)
+	if err != nil {
+		return errors.Wrap(err, "Sign")
+	}
+
+	err = WebhookSender(cfg.AppUsernameChangeURL, &url.Values{
+		"account_id": []string{claims.Subject},
+		"username":   []string{claims.To},
+		"action":     []string{claims.Scope},
+		"token":      []string{tokenStr},
+	}, timeSensitiveDelivery)
+	if err != nil {
+		return errors.Wrap(err, "Webhook")
+	}
+
+	log.WithFields(log.Fields{"accountID": claims.Subject, "action": claims.Scope}).Info("sent username change token")
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package services_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/services"
+	"github.com/keratin/authn-server/tokens/usernames"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsernameChangeSender(t *testing.T) {
+	var received url.Values
+	remoteApp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		received = r.PostForm
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer remoteApp.Close()
+	serverURL, err := url.Parse(remoteApp.URL)
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		AuthNURL:                 &url.URL{Scheme: "https", Host: "authn.example.com"},
+		AppUsernameChangeURL:     serverURL,
+		UsernameChangeSigningKey: []byte("change-a-reno"),
+		UsernameChangeTokenTTL:   time.Hour,
+	}
+
+	claims, err := usernames.NewChange(cfg, 1234, "old@keratin.tech", "new@keratin.tech")
+	require.NoError(t, err)
+
+	err = services.UsernameChangeSender(cfg, claims)
+	require.NoError(t, err)
+	assert.Equal(t, "1234", received.Get("account_id"))
+	assert.Equal(t, "new@keratin.tech", received.Get("username"))
+	assert.Equal(t, usernames.ChangeScope, received.Get("action"))
+
+	parsed, err := usernames.Parse(received.Get("token"), cfg, usernames.ChangeScope)
+	require.NoError(t, err)
+	assert.Equal(t, "new@keratin.tech", parsed.To)
+}
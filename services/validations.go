@@ -2,9 +2,13 @@ package services
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/lib/geoip"
 	zxcvbn "github.com/nbutton23/zxcvbn-go"
 )
 
@@ -17,6 +21,22 @@ var ErrLocked = "LOCKED"
 var ErrExpired = "EXPIRED"
 var ErrNotFound = "NOT_FOUND"
 var ErrInvalidOrExpired = "INVALID_OR_EXPIRED"
+var ErrVetoed = "VETOED"
+var ErrUnconfigured = "UNCONFIGURED"
+var ErrThrottled = "THROTTLED"
+var ErrBlocked = "BLOCKED"
+var ErrNotGranted = "NOT_GRANTED"
+var ErrLimitReached = "LIMIT_REACHED"
+var ErrPending = "PENDING"
+var ErrDenied = "DENIED"
+var ErrTooLarge = "TOO_LARGE"
+var ErrReadOnly = "READ_ONLY"
+var ErrMaintenance = "MAINTENANCE"
+var ErrOverloaded = "OVERLOADED"
+var ErrStale = "STALE"
+var ErrLegalHold = "LEGAL_HOLD"
+var ErrNotEnrolled = "NOT_ENROLLED"
+var ErrStepUpRequired = "STEP_UP_REQUIRED"
 
 type fieldError struct {
 	Field   string `json:"field"`
@@ -56,6 +76,108 @@ func passwordValidator(cfg *config.Config, password string) *fieldError {
 	return nil
 }
 
+// ExpiryValidator parses an optional RFC 3339 expiry for temporary accounts.
+func ExpiryValidator(expiresAt string) (*time.Time, error) {
+	if expiresAt == "" {
+		return nil, nil
+	}
+	at, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return nil, FieldErrors{{"expires_at", ErrFormatInvalid}}
+	}
+	return &at, nil
+}
+
+// LocationValidator refuses requests from countries that have been blocked.
+func LocationValidator(cfg *config.Config, location *geoip.Location) error {
+	if location == nil {
+		return nil
+	}
+	for _, country := range cfg.BlockedCountries {
+		if location.Country == country {
+			return FieldErrors{{"location", ErrBlocked}}
+		}
+	}
+	return nil
+}
+
+// TermsValidator checks that a request accepts the current terms of service, when configured.
+func TermsValidator(cfg *config.Config, termsVersion string) error {
+	if cfg.TermsVersion == "" {
+		return nil
+	}
+	if termsVersion == "" {
+		return FieldErrors{{"terms_version", ErrMissing}}
+	}
+	if termsVersion != cfg.TermsVersion {
+		return FieldErrors{{"terms_version", ErrExpired}}
+	}
+	return nil
+}
+
+// ProfileValidator checks submitted values against the PROFILE_FIELDS schema, and returns those
+// to store in metadata. At signup, every required field must be given. Later, any fields may be
+// given, and a blank value leaves an optional field as it was.
+func ProfileValidator(cfg *config.Config, values map[string]string, signup bool) (map[string]string, error) {
+	errs := FieldErrors{}
+	known := map[string]bool{}
+	valid := map[string]string{}
+	for _, field := range cfg.ProfileFields {
+		known[field.Name] = true
+		value := strings.TrimSpace(values[field.Name])
+		if value == "" {
+			_, given := values[field.Name]
+			if field.Required && (signup || given) {
+				errs = append(errs, fieldError{field.Name, ErrMissing})
+			}
+			continue
+		}
+		if !profileValueValid(field, value) {
+			errs = append(errs, fieldError{field.Name, ErrFormatInvalid})
+			continue
+		}
+		valid[field.Name] = value
+	}
+
+	unknown := []string{}
+	for name := range values {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, fieldError{name, ErrFormatInvalid})
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return valid, nil
+}
+
+func profileValueValid(field config.ProfileField, value string) bool {
+	if field.Type == "date" {
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	}
+	if utf8.RuneCountInString(value) > field.MaxLength {
+		return false
+	}
+	if field.Pattern != nil && !field.Pattern.MatchString(value) {
+		return false
+	}
+	if len(field.Enum) > 0 {
+		for _, option := range field.Enum {
+			if value == option {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
 func usernameValidator(cfg *config.Config, username string) *fieldError {
 	if cfg.UsernameIsEmail {
 		if !isEmail(username) {
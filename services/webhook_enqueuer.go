@@ -0,0 +1,35 @@
+package services
+
+import (
+	"net/url"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/models"
+)
+
+// Webhooks that may be delivered through the outbox. The outbox records the name rather than the
+// URL, so that credentials in the URL are not persisted and a changed URL applies to pending
+// messages.
+const (
+	PasswordChangedWebhook = "password_changed"
+)
+
+// WebhookEndpoints are the names of every webhook that may be delivered through the outbox.
+var WebhookEndpoints = []string{PasswordChangedWebhook}
+
+// webhookURL finds the configured URL for a named webhook.
+func webhookURL(cfg *config.Config, name string) *url.URL {
+	switch name {
+	case PasswordChangedWebhook:
+		return cfg.AppPasswordChangedURL
+	default:
+		return nil
+	}
+}
+
+// WebhookEnqueuer persists a webhook in the outbox, for the OutboxDispatcher to deliver.
+func WebhookEnqueuer(outbox data.OutboxStore, name string, values *url.Values) error {
+	_, err := outbox.Create(models.OutboxWebhook, name, values.Encode())
+	return err
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/data"
+	"github.com/keratin/authn-server/lib/compat"
+	"github.com/pkg/errors"
+)
+
+// WebhookSecretRotator creates a new secret for a webhook endpoint and returns it. The endpoint's
+// previous secrets continue to sign webhooks until the overlap has passed.
+func WebhookSecretRotator(store data.WebhookSecretStore, cfg *config.Config, endpoint string, overlap time.Duration) (string, error) {
+	if overlap < 0 {
+		return "", FieldErrors{{"overlap", ErrFormatInvalid}}
+	}
+
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", errors.Wrap(err, "rand.Read")
+	}
+	secret := base64.RawURLEncoding.EncodeToString(bytes)
+
+	encrypted, err := compat.Encrypt([]byte(secret), cfg.DBEncryptionKey)
+	if err != nil {
+		return "", errors.Wrap(err, "Encrypt")
+	}
+
+	_, err = store.Rotate(endpoint, string(encrypted), time.Now().Add(overlap))
+	if err != nil {
+		return "", errors.Wrap(err, "Rotate")
+	}
+	return secret, nil
+}
@@ -0,0 +1,96 @@
+package actions
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+const scope = "action"
+
+// Actions that a link may perform.
+const (
+	Unlock     = "unlock"
+	Unthrottle = "unthrottle"
+	Archive    = "archive"
+)
+
+// Valid reports whether a link may perform the action.
+func Valid(action string) bool {
+	switch action {
+	case Unlock, Unthrottle, Archive:
+		return true
+	}
+	return false
+}
+
+// Claims authorize performing one action on an account. A token is only valid while the account
+// still has the Version it was issued for, and performing the action claims that version, so
+// that it can be used only once.
+type Claims struct {
+	Scope   string `json:"scope"`
+	Action  string `json:"action"`
+	Version int    `json:"version"`
+	jwt.Claims
+}
+
+func (c *Claims) Sign(hmacKey []byte) (string, error) {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.HS256, Key: hmacKey},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "NewSigner")
+	}
+	return jwt.Signed(signer).Claims(c).CompactSerialize()
+}
+
+func Parse(tokenStr string, cfg *config.Config) (*Claims, error) {
+	token, err := jwt.ParseSigned(tokenStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseSigned")
+	}
+
+	claims := Claims{}
+	err = token.Claims(cfg.ActionLinkSigningKey, &claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "Claims")
+	}
+
+	err = claims.Claims.Validate(jwt.Expected{
+		Audience: jwt.Audience{cfg.AuthNURL.String()},
+		Issuer:   cfg.AuthNURL.String(),
+		Time:     time.Now(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Validate")
+	}
+	if claims.Scope != scope {
+		return nil, fmt.Errorf("token scope not valid")
+	}
+	if !Valid(claims.Action) {
+		return nil, fmt.Errorf("token action not valid")
+	}
+
+	return &claims, nil
+}
+
+func New(cfg *config.Config, accountID int, action string, version int) (*Claims, error) {
+	return &Claims{
+		Scope:   scope,
+		Action:  action,
+		Version: version,
+		Claims: jwt.Claims{
+			Issuer:   cfg.AuthNURL.String(),
+			Subject:  strconv.Itoa(accountID),
+			Audience: jwt.Audience{cfg.AuthNURL.String()},
+			Expiry:   jwt.NewNumericDate(time.Now().Add(cfg.ActionLinkTTL)),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+	}, nil
+}
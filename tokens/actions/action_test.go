@@ -0,0 +1,70 @@
+package actions_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/tokens/actions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionToken(t *testing.T) {
+	cfg := &config.Config{
+		AuthNURL:             &url.URL{Scheme: "https", Host: "authn.example.com"},
+		ActionLinkSigningKey: []byte("key-a-reno"),
+		ActionLinkTTL:        time.Hour,
+	}
+
+	t.Run("creating signing and parsing", func(t *testing.T) {
+		token, err := actions.New(cfg, 52167, actions.Unlock, 3)
+		require.NoError(t, err)
+		assert.Equal(t, "https://authn.example.com", token.Issuer)
+		assert.Equal(t, "52167", token.Subject)
+		assert.True(t, token.Audience.Contains("https://authn.example.com"))
+		assert.NotEmpty(t, token.Expiry)
+
+		tokenStr, err := token.Sign(cfg.ActionLinkSigningKey)
+		require.NoError(t, err)
+
+		claims, err := actions.Parse(tokenStr, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "52167", claims.Subject)
+		assert.Equal(t, actions.Unlock, claims.Action)
+		assert.Equal(t, 3, claims.Version)
+	})
+
+	t.Run("parsing with a different key", func(t *testing.T) {
+		token, err := actions.New(cfg, 52167, actions.Unlock, 3)
+		require.NoError(t, err)
+		tokenStr, err := token.Sign([]byte("old-a-reno"))
+		require.NoError(t, err)
+
+		_, err = actions.Parse(tokenStr, cfg)
+		assert.Error(t, err)
+	})
+
+	t.Run("parsing an expired token", func(t *testing.T) {
+		expired := *cfg
+		expired.ActionLinkTTL = -time.Hour
+		token, err := actions.New(&expired, 52167, actions.Unlock, 3)
+		require.NoError(t, err)
+		tokenStr, err := token.Sign(cfg.ActionLinkSigningKey)
+		require.NoError(t, err)
+
+		_, err = actions.Parse(tokenStr, cfg)
+		assert.Error(t, err)
+	})
+
+	t.Run("parsing an unknown action", func(t *testing.T) {
+		token, err := actions.New(cfg, 52167, "delete", 3)
+		require.NoError(t, err)
+		tokenStr, err := token.Sign(cfg.ActionLinkSigningKey)
+		require.NoError(t, err)
+
+		_, err = actions.Parse(tokenStr, cfg)
+		assert.Error(t, err)
+	})
+}
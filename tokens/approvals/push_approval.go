@@ -0,0 +1,80 @@
+package approvals
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+// LoginScope is for tokens that complete a login once it is approved.
+const LoginScope = "push_approval"
+
+// StepUpScope is for tokens that re-verify an existing session once it is approved. They can not
+// complete a login.
+const StepUpScope = "push_step_up"
+
+// Claims track a login or step-up that is waiting for approval from the account's push
+// authenticator. The token is given to the client, so that it may check for approval, but it can
+// only complete once the authenticator has approved Request.
+type Claims struct {
+	Scope   string `json:"scope"`
+	Request string `json:"req"`
+	jwt.Claims
+}
+
+func (c *Claims) Sign(hmacKey []byte) (string, error) {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.HS256, Key: hmacKey},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "NewSigner")
+	}
+	return jwt.Signed(signer).Claims(c).CompactSerialize()
+}
+
+func Parse(tokenStr string, cfg *config.Config, scope string) (*Claims, error) {
+	token, err := jwt.ParseSigned(tokenStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseSigned")
+	}
+
+	claims := Claims{}
+	err = token.Claims(cfg.PushMFASigningKey, &claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "Claims")
+	}
+
+	err = claims.Claims.Validate(jwt.Expected{
+		Audience: jwt.Audience{cfg.AuthNURL.String()},
+		Issuer:   cfg.AuthNURL.String(),
+		Time:     time.Now(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Validate")
+	}
+	if claims.Scope != scope {
+		return nil, fmt.Errorf("token scope not valid")
+	}
+
+	return &claims, nil
+}
+
+func New(cfg *config.Config, accountID int, requestID string, scope string) (*Claims, error) {
+	return &Claims{
+		Scope:   scope,
+		Request: requestID,
+		Claims: jwt.Claims{
+			Issuer:   cfg.AuthNURL.String(),
+			Subject:  strconv.Itoa(accountID),
+			Audience: jwt.Audience{cfg.AuthNURL.String()},
+			Expiry:   jwt.NewNumericDate(time.Now().Add(cfg.PushMFATimeout)),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+	}, nil
+}
@@ -0,0 +1,64 @@
+package approvals_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/tokens/approvals"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushApprovalToken(t *testing.T) {
+	cfg := &config.Config{
+		AuthNURL:          &url.URL{Scheme: "https", Host: "authn.example.com"},
+		PushMFASigningKey: []byte("key-a-reno"),
+		PushMFATimeout:    time.Minute,
+	}
+
+	t.Run("creating signing and parsing", func(t *testing.T) {
+		token, err := approvals.New(cfg, 52167, "tx-1", approvals.LoginScope)
+		require.NoError(t, err)
+		assert.Equal(t, "push_approval", token.Scope)
+		assert.Equal(t, "tx-1", token.Request)
+		assert.Equal(t, "52167", token.Subject)
+
+		tokenStr, err := token.Sign(cfg.PushMFASigningKey)
+		require.NoError(t, err)
+
+		parsed, err := approvals.Parse(tokenStr, cfg, approvals.LoginScope)
+		require.NoError(t, err)
+		assert.Equal(t, "tx-1", parsed.Request)
+	})
+
+	t.Run("parsing with a different key", func(t *testing.T) {
+		token, err := approvals.New(cfg, 52167, "tx-1", approvals.LoginScope)
+		require.NoError(t, err)
+		tokenStr, err := token.Sign([]byte("old-a-reno"))
+		require.NoError(t, err)
+		_, err = approvals.Parse(tokenStr, cfg, approvals.LoginScope)
+		assert.Error(t, err)
+	})
+
+	t.Run("parsing an expired token", func(t *testing.T) {
+		expiredCfg := *cfg
+		expiredCfg.PushMFATimeout = -time.Minute
+		token, err := approvals.New(&expiredCfg, 52167, "tx-1", approvals.LoginScope)
+		require.NoError(t, err)
+		tokenStr, err := token.Sign(cfg.PushMFASigningKey)
+		require.NoError(t, err)
+		_, err = approvals.Parse(tokenStr, cfg, approvals.LoginScope)
+		assert.Error(t, err)
+	})
+
+	t.Run("parsing with another scope", func(t *testing.T) {
+		token, err := approvals.New(cfg, 52167, "tx-1", approvals.StepUpScope)
+		require.NoError(t, err)
+		tokenStr, err := token.Sign(cfg.PushMFASigningKey)
+		require.NoError(t, err)
+		_, err = approvals.Parse(tokenStr, cfg, approvals.LoginScope)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,67 @@
+package challenges_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/tokens/challenges"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginChallengeToken(t *testing.T) {
+	cfg := &config.Config{
+		AuthNURL:                 &url.URL{Scheme: "https", Host: "authn.example.com"},
+		LoginChallengeSigningKey: []byte("key-a-reno"),
+		LoginChallengeTokenTTL:   time.Hour,
+	}
+
+	then := time.Now().Add(-time.Second).Truncate(time.Second)
+	accountID := 52167
+
+	t.Run("creating signing and parsing", func(t *testing.T) {
+		token, err := challenges.New(cfg, accountID, then)
+		require.NoError(t, err)
+		assert.Equal(t, "login_challenge", token.Scope)
+		assert.Equal(t, then, token.Lock.Time())
+		assert.Equal(t, "https://authn.example.com", token.Issuer)
+		assert.Equal(t, "52167", token.Subject)
+		assert.True(t, token.Audience.Contains("https://authn.example.com"))
+		assert.NotEmpty(t, token.Expiry)
+
+		tokenStr, err := token.Sign(cfg.LoginChallengeSigningKey)
+		require.NoError(t, err)
+
+		_, err = challenges.Parse(tokenStr, cfg)
+		require.NoError(t, err)
+	})
+
+	t.Run("parsing with a different key", func(t *testing.T) {
+		token, err := challenges.New(cfg, accountID, then)
+		require.NoError(t, err)
+		tokenStr, err := token.Sign([]byte("old-a-reno"))
+		require.NoError(t, err)
+		_, err = challenges.Parse(tokenStr, cfg)
+		assert.Error(t, err)
+	})
+
+	t.Run("parsing an expired token", func(t *testing.T) {
+		expiredCfg := *cfg
+		expiredCfg.LoginChallengeTokenTTL = -time.Minute
+		token, err := challenges.New(&expiredCfg, accountID, then)
+		require.NoError(t, err)
+		tokenStr, err := token.Sign(cfg.LoginChallengeSigningKey)
+		require.NoError(t, err)
+		_, err = challenges.Parse(tokenStr, cfg)
+		assert.Error(t, err)
+	})
+
+	t.Run("checking the lock", func(t *testing.T) {
+		token, err := challenges.New(cfg, accountID, then)
+		require.NoError(t, err)
+		assert.False(t, token.LockExpired(then))
+		assert.True(t, token.LockExpired(then.Add(time.Second)))
+	})
+}
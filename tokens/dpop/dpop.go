@@ -0,0 +1,129 @@
+package dpop
+
+import (
+	"crypto"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+const proofType = "dpop+jwt"
+
+// Proofs may be this old, or this far into the future to allow for clock skew.
+const maxAge = 5 * time.Minute
+const maxSkew = time.Minute
+
+// Window is how long a proof may be accepted after it was issued, from its earliest acceptable iat
+// until its latest.
+const Window = maxAge + maxSkew
+
+// Proofs remembers the proofs that were accepted, so that each may be used once.
+type Proofs interface {
+	// Use records the key for ttl, and reports false when it was already recorded.
+	Use(key string, ttl time.Duration) (bool, error)
+}
+
+// Claims are the claims of a DPoP proof (RFC 9449). A proof is signed by a key held by the client,
+// and demonstrates possession of that key for a single HTTP request.
+type Claims struct {
+	Htm string `json:"htm"`
+	Htu string `json:"htu"`
+	jwt.Claims
+}
+
+// Parse verifies a DPoP proof for the HTTP method and URL of a request, and returns the JWK
+// thumbprint of the key that signed it. Tokens may be bound to this thumbprint so that only the
+// holder of the key may use them.
+func Parse(proof string, method string, requestURL string, now time.Time) (string, error) {
+	thumbprint, _, err := parse(proof, method, requestURL, now)
+	return thumbprint, err
+}
+
+// ParseOnce is Parse for a proof that may only be used once. The proof's jti is recorded in proofs
+// for as long as the proof could be accepted, and a proof that was already recorded is refused.
+func ParseOnce(proof string, method string, requestURL string, now time.Time, proofs Proofs) (string, error) {
+	thumbprint, jti, err := parse(proof, method, requestURL, now)
+	if err != nil {
+		return "", err
+	}
+	// a jti need only be unique to its key
+	fresh, err := proofs.Use(thumbprint+":"+jti, Window)
+	if err != nil {
+		return "", errors.Wrap(err, "Use")
+	}
+	if !fresh {
+		return "", fmt.Errorf("proof was already used")
+	}
+	return thumbprint, nil
+}
+
+func parse(proof string, method string, requestURL string, now time.Time) (string, string, error) {
+	token, err := jwt.ParseSigned(proof)
+	if err != nil {
+		return "", "", errors.Wrap(err, "ParseSigned")
+	}
+	if len(token.Headers) != 1 {
+		return "", "", fmt.Errorf("proof must have one signature")
+	}
+	header := token.Headers[0]
+	if typ, _ := header.ExtraHeaders[jose.HeaderType].(string); typ != proofType {
+		return "", "", fmt.Errorf("proof type not valid: %v", typ)
+	}
+	if strings.HasPrefix(header.Algorithm, "HS") {
+		return "", "", fmt.Errorf("proof algorithm not valid: %v", header.Algorithm)
+	}
+	jwk := header.JSONWebKey
+	if jwk == nil || !jwk.Valid() || !jwk.IsPublic() {
+		return "", "", fmt.Errorf("proof key not valid")
+	}
+
+	claims := Claims{}
+	err = token.Claims(jwk.Key, &claims)
+	if err != nil {
+		return "", "", errors.Wrap(err, "Claims")
+	}
+
+	if claims.ID == "" {
+		return "", "", fmt.Errorf("proof is missing jti")
+	}
+	if claims.Htm != method {
+		return "", "", fmt.Errorf("proof method not valid: %v", claims.Htm)
+	}
+	if !sameURL(claims.Htu, requestURL) {
+		return "", "", fmt.Errorf("proof URL not valid: %v", claims.Htu)
+	}
+	if claims.IssuedAt == 0 {
+		return "", "", fmt.Errorf("proof is missing iat")
+	}
+	issuedAt := claims.IssuedAt.Time()
+	if issuedAt.Before(now.Add(-maxAge)) || issuedAt.After(now.Add(maxSkew)) {
+		return "", "", fmt.Errorf("proof is expired")
+	}
+
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", "", errors.Wrap(err, "Thumbprint")
+	}
+	return base64.RawURLEncoding.EncodeToString(thumbprint), claims.ID, nil
+}
+
+// sameURL compares URLs without their query and fragment, as required for htu.
+func sameURL(a string, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) &&
+		strings.EqualFold(ua.Host, ub.Host) &&
+		ua.EscapedPath() == ub.EscapedPath()
+}
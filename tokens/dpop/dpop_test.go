@@ -0,0 +1,122 @@
+package dpop_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/tokens/dpop"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jose "gopkg.in/square/go-jose.v2"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, typ string, claims dpop.Claims) string {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: key},
+		(&jose.SignerOptions{EmbedJWK: true}).WithType(jose.ContentType(typ)),
+	)
+	require.NoError(t, err)
+	proof, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	return proof
+}
+
+func TestParse(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	now := time.Now()
+	valid := dpop.Claims{
+		Htm: "POST",
+		Htu: "https://authn.example.com/session",
+		Claims: jwt.Claims{
+			ID:       "abc123",
+			IssuedAt: jwt.NewNumericDate(now),
+		},
+	}
+
+	t.Run("valid proof", func(t *testing.T) {
+		proof := sign(t, key, "dpop+jwt", valid)
+		thumbprint, err := dpop.Parse(proof, "POST", "https://authn.example.com/session?x=1", now)
+		require.NoError(t, err)
+		assert.NotEmpty(t, thumbprint)
+
+		again, err := dpop.Parse(sign(t, key, "dpop+jwt", valid), "POST", "https://authn.example.com/session", now)
+		require.NoError(t, err)
+		assert.Equal(t, thumbprint, again)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		_, err := dpop.Parse(sign(t, key, "JWT", valid), "POST", "https://authn.example.com/session", now)
+		assert.Error(t, err)
+	})
+
+	t.Run("wrong method", func(t *testing.T) {
+		_, err := dpop.Parse(sign(t, key, "dpop+jwt", valid), "GET", "https://authn.example.com/session", now)
+		assert.Error(t, err)
+	})
+
+	t.Run("wrong URL", func(t *testing.T) {
+		_, err := dpop.Parse(sign(t, key, "dpop+jwt", valid), "POST", "https://authn.example.com/accounts", now)
+		assert.Error(t, err)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		_, err := dpop.Parse(sign(t, key, "dpop+jwt", valid), "POST", "https://authn.example.com/session", now.Add(time.Hour))
+		assert.Error(t, err)
+	})
+
+	t.Run("missing jti", func(t *testing.T) {
+		claims := valid
+		claims.ID = ""
+		_, err := dpop.Parse(sign(t, key, "dpop+jwt", claims), "POST", "https://authn.example.com/session", now)
+		assert.Error(t, err)
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		_, err := dpop.Parse("not.a.proof", "POST", "https://authn.example.com/session", now)
+		assert.Error(t, err)
+	})
+}
+
+type proofs map[string]time.Duration
+
+func (p proofs) Use(key string, ttl time.Duration) (bool, error) {
+	if _, ok := p[key]; ok {
+		return false, nil
+	}
+	p[key] = ttl
+	return true, nil
+}
+
+func TestParseOnce(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	now := time.Now()
+	claims := dpop.Claims{
+		Htm: "GET",
+		Htu: "https://authn.example.com/session/refresh",
+		Claims: jwt.Claims{
+			ID:       "abc123",
+			IssuedAt: jwt.NewNumericDate(now),
+		},
+	}
+	used := proofs{}
+
+	proof := sign(t, key, "dpop+jwt", claims)
+	thumbprint, err := dpop.ParseOnce(proof, "GET", "https://authn.example.com/session/refresh", now, used)
+	require.NoError(t, err)
+	assert.Equal(t, proofs{thumbprint + ":abc123": dpop.Window}, used)
+
+	_, err = dpop.ParseOnce(proof, "GET", "https://authn.example.com/session/refresh", now, used)
+	assert.Error(t, err)
+
+	// the jti is only unique to its key
+	_, err = dpop.ParseOnce(sign(t, otherKey, "dpop+jwt", claims), "GET", "https://authn.example.com/session/refresh", now, used)
+	assert.NoError(t, err)
+}
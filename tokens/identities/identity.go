@@ -1,12 +1,19 @@
 package identities
 
 import (
+	"crypto"
+	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/keratin/authn-server/config"
 	"github.com/keratin/authn-server/lib/compat"
+	"github.com/keratin/authn-server/models"
 	"github.com/keratin/authn-server/tokens/sessions"
 	"github.com/pkg/errors"
 	jose "gopkg.in/square/go-jose.v2"
@@ -14,16 +21,29 @@ import (
 )
 
 type Claims struct {
-	AuthTime jwt.NumericDate `json:"auth_time"`
+	AuthTime          *jwt.NumericDate       `json:"auth_time,omitempty"`
+	Amr               []string               `json:"amr,omitempty"`
+	MFATime           *jwt.NumericDate       `json:"mfa_time,omitempty"`
+	Sid               string                 `json:"sid,omitempty"`
+	Username          string                 `json:"username,omitempty"`
+	PasswordChangedAt *jwt.NumericDate       `json:"password_changed_at,omitempty"`
+	Scope             string                 `json:"scope,omitempty"`
+	Cnf               *sessions.Confirmation `json:"cnf,omitempty"`
+	Events            map[string]struct{}    `json:"events,omitempty"`
 	jwt.Claims
 }
 
-func (c *Claims) Sign(rsaKey *rsa.PrivateKey) (string, error) {
-	keyID, err := compat.KeyID(rsaKey.Public())
+func (c *Claims) Sign(key crypto.Signer) (string, error) {
+	keyID, err := compat.KeyID(key.Public())
 	if err != nil {
 		return "", errors.Wrap(err, "KeyID")
 	}
 
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return c.signOpaque(key, keyID)
+	}
+
 	jwk := jose.JSONWebKey{
 		Key:   rsaKey,
 		KeyID: keyID,
@@ -39,15 +59,123 @@ func (c *Claims) Sign(rsaKey *rsa.PrivateKey) (string, error) {
 	return jwt.Signed(signer).Claims(c).CompactSerialize()
 }
 
+// signOpaque builds an RS256 JWT for a key that can sign digests but can not be exported, such as a
+// key on a hardware security module.
+func (c *Claims) signOpaque(key crypto.Signer, keyID string) (string, error) {
+	header, err := json.Marshal(map[string]string{
+		"alg": string(jose.RS256),
+		"kid": keyID,
+		"typ": "JWT",
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "Marshal header")
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", errors.Wrap(err, "Marshal claims")
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return "", errors.Wrap(err, "Sign")
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Encrypt wraps a signed identity token in a JWE addressed to the relying party's public key. The
+// content type marks the payload as a nested JWT, so that audiences know to verify the signature
+// after decrypting.
+func Encrypt(token string, key *rsa.PublicKey) (string, error) {
+	encrypter, err := jose.NewEncrypter(
+		jose.A256GCM,
+		jose.Recipient{Algorithm: jose.RSA_OAEP_256, Key: key},
+		(&jose.EncrypterOptions{}).WithType("JWT").WithContentType("JWT"),
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "NewEncrypter")
+	}
+	jwe, err := encrypter.Encrypt([]byte(token))
+	if err != nil {
+		return "", errors.Wrap(err, "Encrypt")
+	}
+	return jwe.CompactSerialize()
+}
+
 func New(cfg *config.Config, session *sessions.Claims, accountID int, audience string) *Claims {
-	return &Claims{
-		AuthTime: session.IssuedAt,
+	claims := &Claims{
+		Scope: strings.Join(stepUpScopes(cfg, session), " "),
+		Cnf:   session.Cnf,
 		Claims: jwt.Claims{
 			Issuer:   session.Issuer,
 			Subject:  strconv.Itoa(accountID),
 			Audience: jwt.Audience{audience},
-			Expiry:   jwt.NewNumericDate(time.Now().Add(cfg.AccessTokenTTL)),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(cfg.AccessTokenTTLFor(audience))),
 			IssuedAt: jwt.NewNumericDate(time.Now()),
 		},
 	}
+
+	if Allows(cfg, "auth_time") {
+		authTime := session.IssuedAt
+		claims.AuthTime = &authTime
+	}
+	if Allows(cfg, "amr") {
+		claims.Amr = session.Amr
+	}
+	if Allows(cfg, "mfa_time") {
+		claims.MFATime = session.MFATime
+	}
+	if Allows(cfg, "sid") {
+		claims.Sid = SessionID(session.Subject)
+	}
+
+	return claims
+}
+
+// stepUpScopes returns the session's scopes, without any of STEP_UP_SCOPES unless the session has
+// verified a second factor within STEP_UP_MAX_AGE.
+func stepUpScopes(cfg *config.Config, session *sessions.Claims) []string {
+	if len(cfg.StepUpScopes) == 0 || session.SteppedUp(cfg.StepUpMaxAge) {
+		return session.Scopes
+	}
+	scopes := []string{}
+	for _, scope := range session.Scopes {
+		if !cfg.StepUpScope(scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// WithAccount adds the account claims allowed by ACCESS_TOKEN_CLAIMS.
+func (c *Claims) WithAccount(cfg *config.Config, account *models.Account) *Claims {
+	if Allows(cfg, "username") {
+		c.Username = account.Username
+	}
+	if Allows(cfg, "password_changed_at") {
+		changedAt := jwt.NewNumericDate(account.PasswordChangedAt)
+		c.PasswordChangedAt = &changedAt
+	}
+	return c
+}
+
+// Allows reports whether ACCESS_TOKEN_CLAIMS includes an optional claim.
+func Allows(cfg *config.Config, claim string) bool {
+	allowed := cfg.AccessTokenClaims
+	if allowed == nil {
+		allowed = config.DefaultAccessTokenClaims
+	}
+	for _, c := range allowed {
+		if c == claim {
+			return true
+		}
+	}
+	return false
+}
+
+// SessionID identifies a session without revealing its refresh token.
+func SessionID(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
 }
@@ -1,17 +1,24 @@
 package identities_test
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/base64"
+	"io"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/keratin/authn-server/lib/compat"
 
 	jose "gopkg.in/square/go-jose.v2"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
 
 	"github.com/keratin/authn-server/config"
 	"github.com/keratin/authn-server/data/mock"
+	"github.com/keratin/authn-server/models"
 	"github.com/keratin/authn-server/tokens/identities"
 	"github.com/keratin/authn-server/tokens/sessions"
 	"github.com/stretchr/testify/assert"
@@ -24,7 +31,7 @@ func TestIdentityClaims(t *testing.T) {
 		AuthNURL:          &url.URL{Scheme: "http", Host: "authn.example.com"},
 		SessionSigningKey: []byte("key-a-reno"),
 	}
-	key, err := rsa.GenerateKey(rand.Reader, 512)
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
 	require.NoError(t, err)
 	session, err := sessions.New(store, &cfg, 1, "example.com")
 	require.NoError(t, err)
@@ -41,4 +48,145 @@ func TestIdentityClaims(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, keyID, parsed.Signatures[0].Header.KeyID)
 	})
+	t.Run("signs with an opaque key", func(t *testing.T) {
+		identityStr, err := identities.New(&cfg, session, 1, "example.com").Sign(opaqueKey{key})
+		require.NoError(t, err)
+
+		parsed, err := jose.ParseSigned(identityStr)
+		require.NoError(t, err)
+		keyID, err := compat.KeyID(key.Public())
+		require.NoError(t, err)
+		assert.Equal(t, keyID, parsed.Signatures[0].Header.KeyID)
+
+		tok, err := jwt.ParseSigned(identityStr)
+		require.NoError(t, err)
+		claims := identities.Claims{}
+		require.NoError(t, tok.Claims(key.Public(), &claims))
+		assert.Equal(t, "1", claims.Subject)
+	})
+	t.Run("encrypts to a public key", func(t *testing.T) {
+		identityStr, err := identities.New(&cfg, session, 1, "example.com").Sign(key)
+		require.NoError(t, err)
+
+		rpKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		encrypted, err := identities.Encrypt(identityStr, &rpKey.PublicKey)
+		require.NoError(t, err)
+
+		jwe, err := jose.ParseEncrypted(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "JWT", jwe.Header.ExtraHeaders[jose.HeaderContentType])
+		decrypted, err := jwe.Decrypt(rpKey)
+		require.NoError(t, err)
+		assert.Equal(t, identityStr, string(decrypted))
+	})
+	t.Run("default claims", func(t *testing.T) {
+		identity := identities.New(&cfg, session, 1, "example.com")
+		require.NotNil(t, identity.AuthTime)
+		assert.Equal(t, session.IssuedAt, *identity.AuthTime)
+		assert.Empty(t, identity.Sid)
+		assert.Empty(t, identity.Amr)
+	})
+
+	t.Run("allowed claims", func(t *testing.T) {
+		allowed := cfg
+		allowed.AccessTokenClaims = []string{"amr", "sid", "username"}
+		session.Amr = []string{"pwd"}
+
+		identity := identities.New(&allowed, session, 1, "example.com").
+			WithAccount(&allowed, &models.Account{Username: "alice@example.com"})
+		assert.Nil(t, identity.AuthTime)
+		assert.Equal(t, []string{"pwd"}, identity.Amr)
+		assert.NotEmpty(t, identity.Sid)
+		assert.NotContains(t, identity.Sid, session.Subject)
+		assert.Equal(t, "alice@example.com", identity.Username)
+		assert.Nil(t, identity.PasswordChangedAt)
+	})
+}
+
+func TestLogoutClaims(t *testing.T) {
+	cfg := config.Config{
+		AuthNURL: &url.URL{Scheme: "http", Host: "authn.example.com"},
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	logout, err := identities.NewLogout(&cfg, "refresh-token", 1, "app.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "http://authn.example.com", logout.Issuer)
+	assert.Equal(t, "1", logout.Subject)
+	assert.Equal(t, identities.SessionID("refresh-token"), logout.Sid)
+	assert.Contains(t, logout.Events, identities.BackchannelLogoutEvent)
+	assert.NotEmpty(t, logout.ID)
+	assert.Zero(t, logout.Expiry)
+
+	logoutStr, err := logout.Sign(key)
+	require.NoError(t, err)
+	assert.Contains(t, decodePayload(t, logoutStr), `"events":{"http://schemas.openid.net/event/backchannel-logout":{}}`)
+}
+
+func TestParseHint(t *testing.T) {
+	store := mock.NewRefreshTokenStore()
+	cfg := config.Config{
+		AuthNURL:          &url.URL{Scheme: "http", Host: "authn.example.com"},
+		SessionSigningKey: []byte("key-a-reno"),
+	}
+	oldKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	session, err := sessions.New(store, &cfg, 1, "example.com")
+	require.NoError(t, err)
+	keys := []crypto.Signer{newKey, oldKey}
+
+	t.Run("accepts any current key", func(t *testing.T) {
+		identityStr, err := identities.New(&cfg, session, 1, "example.com").Sign(oldKey)
+		require.NoError(t, err)
+		claims, err := identities.ParseHint(identityStr, &cfg, keys)
+		require.NoError(t, err)
+		assert.Equal(t, "1", claims.Subject)
+	})
+	t.Run("accepts an expired token", func(t *testing.T) {
+		identity := identities.New(&cfg, session, 1, "example.com")
+		identity.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+		identityStr, err := identity.Sign(newKey)
+		require.NoError(t, err)
+		_, err = identities.ParseHint(identityStr, &cfg, keys)
+		assert.NoError(t, err)
+	})
+	t.Run("rejects an unknown key", func(t *testing.T) {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+		identityStr, err := identities.New(&cfg, session, 1, "example.com").Sign(otherKey)
+		require.NoError(t, err)
+		_, err = identities.ParseHint(identityStr, &cfg, keys)
+		assert.Error(t, err)
+	})
+	t.Run("rejects another issuer", func(t *testing.T) {
+		identity := identities.New(&cfg, session, 1, "example.com")
+		identity.Issuer = "http://evil.example.com"
+		identityStr, err := identity.Sign(newKey)
+		require.NoError(t, err)
+		_, err = identities.ParseHint(identityStr, &cfg, keys)
+		assert.Error(t, err)
+	})
+}
+
+func decodePayload(t *testing.T, token string) string {
+	payload, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[1])
+	require.NoError(t, err)
+	return string(payload)
+}
+
+// opaqueKey hides the private key behind crypto.Signer, like a key on a hardware security module.
+type opaqueKey struct {
+	key *rsa.PrivateKey
+}
+
+func (k opaqueKey) Public() crypto.PublicKey {
+	return k.key.Public()
+}
+
+func (k opaqueKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return k.key.Sign(rand, digest, opts)
 }
@@ -0,0 +1,63 @@
+package identities
+
+import (
+	"crypto"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/lib"
+	"github.com/pkg/errors"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+// BackchannelLogoutEvent is the event member that marks a JWT as an OIDC logout token.
+const BackchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// NewLogout builds a back-channel logout token for the session identified by a refresh token.
+// cf: https://openid.net/specs/openid-connect-backchannel-1_0.html#LogoutToken
+func NewLogout(cfg *config.Config, refreshToken string, accountID int, audience string) (*Claims, error) {
+	jti, err := lib.GenerateToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "GenerateToken")
+	}
+
+	return &Claims{
+		Sid:    SessionID(refreshToken),
+		Events: map[string]struct{}{BackchannelLogoutEvent: {}},
+		Claims: jwt.Claims{
+			Issuer:   cfg.AuthNURL.String(),
+			Subject:  strconv.Itoa(accountID),
+			Audience: jwt.Audience{audience},
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			ID:       hex.EncodeToString(jti),
+		},
+	}, nil
+}
+
+// ParseHint verifies an identity token that was offered as an id_token_hint. The hint only needs to
+// prove which account it was issued for, so it may have expired.
+func ParseHint(tokenStr string, cfg *config.Config, keys []crypto.Signer) (*Claims, error) {
+	token, err := jwt.ParseSigned(tokenStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseSigned")
+	}
+
+	claims := Claims{}
+	for _, key := range keys {
+		if err = token.Claims(key.Public(), &claims); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Claims")
+	}
+
+	if claims.Issuer != cfg.AuthNURL.String() {
+		return nil, fmt.Errorf("token issuer not valid")
+	}
+
+	return &claims, nil
+}
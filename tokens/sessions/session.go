@@ -14,11 +14,22 @@ import (
 const scope = "refresh"
 
 type Claims struct {
-	Scope string `json:"scope"`
-	Azp   string `json:"azp"`
+	Scope       string           `json:"scope"`
+	Azp         string           `json:"azp"`
+	Scopes      []string         `json:"scopes,omitempty"`
+	Amr         []string         `json:"amr,omitempty"`
+	MFATime     *jwt.NumericDate `json:"mfa_time,omitempty"`
+	Fingerprint string           `json:"fpr,omitempty"`
+	Cnf         *Confirmation    `json:"cnf,omitempty"`
 	jwt.Claims
 }
 
+// Confirmation binds a token to a key held by the client (RFC 7800), by the key's JWK thumbprint
+// (RFC 9449).
+type Confirmation struct {
+	Jkt string `json:"jkt"`
+}
+
 func (c *Claims) Sign(hmacKey []byte) (string, error) {
 	signer, err := jose.NewSigner(
 		jose.SigningKey{Algorithm: jose.HS256, Key: hmacKey},
@@ -56,15 +67,41 @@ func Parse(tokenStr string, cfg *config.Config) (*Claims, error) {
 	return &claims, nil
 }
 
+// SteppedUp reports whether the session verified a second factor within maxAge, either at login or
+// by stepping up since. MFATime records when.
+func (c *Claims) SteppedUp(maxAge time.Duration) bool {
+	return c.MFATime != nil && time.Since(c.MFATime.Time()) <= maxAge
+}
+
+// StepUp records that the session verified a second factor just now.
+func (c *Claims) StepUp() {
+	mfaTime := jwt.NewNumericDate(time.Now())
+	c.MFATime = &mfaTime
+	for _, method := range c.Amr {
+		if method == "mfa" {
+			return
+		}
+	}
+	c.Amr = append(c.Amr[:len(c.Amr):len(c.Amr)], "mfa")
+}
+
+// ErrLimitReached is returned by New when the account already has MAX_SESSIONS_PER_ACCOUNT
+// sessions, and the policy is to reject new ones.
+var ErrLimitReached = fmt.Errorf("session limit reached")
+
 func New(store data.RefreshTokenStore, cfg *config.Config, accountID int, authorizedAudience string) (*Claims, error) {
-	refreshToken, err := store.Create(accountID)
+	refreshToken, err := store.CreateLimited(accountID, cfg.MaxSessionsPerAccount, !cfg.RejectExcessSessions)
 	if err != nil {
-		return nil, errors.Wrap(err, "Create")
+		return nil, errors.Wrap(err, "CreateLimited")
+	}
+	if refreshToken == "" {
+		return nil, ErrLimitReached
 	}
 
 	return &Claims{
-		Scope: scope,
-		Azp:   authorizedAudience,
+		Scope:  scope,
+		Azp:    authorizedAudience,
+		Scopes: cfg.SessionScopes,
 		Claims: jwt.Claims{
 			Issuer:   cfg.AuthNURL.String(),
 			Subject:  string(refreshToken),
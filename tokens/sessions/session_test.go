@@ -3,6 +3,7 @@ package sessions_test
 import (
 	"net/url"
 	"testing"
+	"time"
 
 	jwt "gopkg.in/square/go-jose.v2/jwt"
 
@@ -69,3 +70,24 @@ func TestParseInvalidSessionJWT(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestStepUp(t *testing.T) {
+	store := mock.NewRefreshTokenStore()
+	cfg := config.Config{AuthNURL: &url.URL{Scheme: "http", Host: "authn.example.com"}}
+
+	token, err := sessions.New(store, &cfg, 1, "example.com")
+	require.NoError(t, err)
+	token.Amr = []string{"pwd"}
+	assert.False(t, token.SteppedUp(time.Minute))
+
+	token.StepUp()
+	assert.True(t, token.SteppedUp(time.Minute))
+	assert.Equal(t, []string{"pwd", "mfa"}, token.Amr)
+
+	token.StepUp()
+	assert.Equal(t, []string{"pwd", "mfa"}, token.Amr)
+
+	stale := jwt.NewNumericDate(time.Now().Add(-2 * time.Minute))
+	token.MFATime = &stale
+	assert.False(t, token.SteppedUp(time.Minute))
+}
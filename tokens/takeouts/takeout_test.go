@@ -0,0 +1,58 @@
+package takeouts_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/tokens/takeouts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeoutToken(t *testing.T) {
+	cfg := &config.Config{
+		AuthNURL:          &url.URL{Scheme: "https", Host: "authn.example.com"},
+		TakeoutSigningKey: []byte("key-a-reno"),
+		TakeoutTTL:        time.Hour,
+	}
+
+	t.Run("creating signing and parsing", func(t *testing.T) {
+		token, err := takeouts.New(cfg, 52167)
+		require.NoError(t, err)
+		assert.Equal(t, "https://authn.example.com", token.Issuer)
+		assert.Equal(t, "52167", token.Subject)
+		assert.True(t, token.Audience.Contains("https://authn.example.com"))
+		assert.NotEmpty(t, token.Expiry)
+
+		tokenStr, err := token.Sign(cfg.TakeoutSigningKey)
+		require.NoError(t, err)
+
+		claims, err := takeouts.Parse(tokenStr, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "52167", claims.Subject)
+	})
+
+	t.Run("parsing with a different key", func(t *testing.T) {
+		token, err := takeouts.New(cfg, 52167)
+		require.NoError(t, err)
+		tokenStr, err := token.Sign([]byte("old-a-reno"))
+		require.NoError(t, err)
+
+		_, err = takeouts.Parse(tokenStr, cfg)
+		assert.Error(t, err)
+	})
+
+	t.Run("parsing an expired token", func(t *testing.T) {
+		expired := *cfg
+		expired.TakeoutTTL = -time.Hour
+		token, err := takeouts.New(&expired, 52167)
+		require.NoError(t, err)
+		tokenStr, err := token.Sign(cfg.TakeoutSigningKey)
+		require.NoError(t, err)
+
+		_, err = takeouts.Parse(tokenStr, cfg)
+		assert.Error(t, err)
+	})
+}
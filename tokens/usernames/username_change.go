@@ -0,0 +1,92 @@
+package usernames
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+// ChangeScope is for tokens that confirm a requested username change. They are delivered to the
+// new username.
+const ChangeScope = "username_change"
+
+// RevertScope is for tokens that undo a confirmed username change. They are delivered to the old
+// username.
+const RevertScope = "username_revert"
+
+// Claims authorize changing an account's username from one value to another. A token is only
+// valid while the account still has the From username, so that it can be used only once.
+type Claims struct {
+	Scope string `json:"scope"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	jwt.Claims
+}
+
+func (c *Claims) Sign(hmacKey []byte) (string, error) {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.HS256, Key: hmacKey},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "NewSigner")
+	}
+	return jwt.Signed(signer).Claims(c).CompactSerialize()
+}
+
+func Parse(tokenStr string, cfg *config.Config, scope string) (*Claims, error) {
+	token, err := jwt.ParseSigned(tokenStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParseSigned")
+	}
+
+	claims := Claims{}
+	err = token.Claims(cfg.UsernameChangeSigningKey, &claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "Claims")
+	}
+
+	err = claims.Claims.Validate(jwt.Expected{
+		Audience: jwt.Audience{cfg.AuthNURL.String()},
+		Issuer:   cfg.AuthNURL.String(),
+		Time:     time.Now(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Validate")
+	}
+	if claims.Scope != scope {
+		return nil, fmt.Errorf("token scope not valid")
+	}
+
+	return &claims, nil
+}
+
+// NewChange creates a token that confirms changing the username from its current value.
+func NewChange(cfg *config.Config, accountID int, from string, to string) (*Claims, error) {
+	return newClaims(cfg, ChangeScope, accountID, from, to, cfg.UsernameChangeTokenTTL)
+}
+
+// NewRevert creates a token that restores the previous username after a confirmed change.
+func NewRevert(cfg *config.Config, accountID int, from string, to string) (*Claims, error) {
+	return newClaims(cfg, RevertScope, accountID, from, to, cfg.UsernameRevertTTL)
+}
+
+func newClaims(cfg *config.Config, scope string, accountID int, from string, to string, ttl time.Duration) (*Claims, error) {
+	return &Claims{
+		Scope: scope,
+		From:  from,
+		To:    to,
+		Claims: jwt.Claims{
+			Issuer:   cfg.AuthNURL.String(),
+			Subject:  strconv.Itoa(accountID),
+			Audience: jwt.Audience{cfg.AuthNURL.String()},
+			Expiry:   jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+	}, nil
+}
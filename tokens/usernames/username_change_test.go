@@ -0,0 +1,62 @@
+package usernames_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/keratin/authn-server/config"
+	"github.com/keratin/authn-server/tokens/usernames"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsernameChangeToken(t *testing.T) {
+	cfg := &config.Config{
+		AuthNURL:                 &url.URL{Scheme: "https", Host: "authn.example.com"},
+		UsernameChangeSigningKey: []byte("key-a-reno"),
+		UsernameChangeTokenTTL:   time.Hour,
+		UsernameRevertTTL:        time.Hour,
+	}
+
+	t.Run("creating signing and parsing", func(t *testing.T) {
+		token, err := usernames.NewChange(cfg, 52167, "old@example.com", "new@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, usernames.ChangeScope, token.Scope)
+		assert.Equal(t, "old@example.com", token.From)
+		assert.Equal(t, "new@example.com", token.To)
+		assert.Equal(t, "https://authn.example.com", token.Issuer)
+		assert.Equal(t, "52167", token.Subject)
+		assert.True(t, token.Audience.Contains("https://authn.example.com"))
+		assert.NotEmpty(t, token.Expiry)
+
+		tokenStr, err := token.Sign(cfg.UsernameChangeSigningKey)
+		require.NoError(t, err)
+
+		claims, err := usernames.Parse(tokenStr, cfg, usernames.ChangeScope)
+		require.NoError(t, err)
+		assert.Equal(t, "new@example.com", claims.To)
+	})
+
+	t.Run("parsing with the wrong scope", func(t *testing.T) {
+		token, err := usernames.NewRevert(cfg, 52167, "new@example.com", "old@example.com")
+		require.NoError(t, err)
+		tokenStr, err := token.Sign(cfg.UsernameChangeSigningKey)
+		require.NoError(t, err)
+
+		_, err = usernames.Parse(tokenStr, cfg, usernames.ChangeScope)
+		assert.Error(t, err)
+		_, err = usernames.Parse(tokenStr, cfg, usernames.RevertScope)
+		assert.NoError(t, err)
+	})
+
+	t.Run("parsing with a different key", func(t *testing.T) {
+		token, err := usernames.NewChange(cfg, 52167, "old@example.com", "new@example.com")
+		require.NoError(t, err)
+		tokenStr, err := token.Sign([]byte("old-a-reno"))
+		require.NoError(t, err)
+
+		_, err = usernames.Parse(tokenStr, cfg, usernames.ChangeScope)
+		assert.Error(t, err)
+	})
+}